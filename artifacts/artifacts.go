@@ -0,0 +1,219 @@
+package artifacts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+)
+
+const (
+	// Archive artifacts (e.g. Lambda zip files) are stored under a bucket path
+	Archive = "archive"
+	// Image artifacts are stored in an OCI registry
+	Image = "image"
+)
+
+// Push stores a built artifact in the project's artifact store with the
+// version; versions are immutable, so an existing version is not overwritten
+func Push(cfg *config.Config, artifact *config.Artifact, kind, version string) error {
+	if cfg.Config.Artifacts == nil {
+		return errors.New("the project does not have an artifact store (add \"artifacts\" to kettle.json)")
+	}
+	switch kind {
+	case Archive:
+		uri, err := getArchiveURI(cfg, version)
+		if err != nil {
+			return err
+		}
+		if archiveExists(uri) {
+			return fmt.Errorf("artifact %s already exists (versions are immutable)", uri)
+		}
+		if err := copyArchive(artifact.Location, uri, "Uploading the artifact"); err != nil {
+			return err
+		}
+		artifact.Location = uri
+	case Image:
+		reference, err := getImageReference(cfg, version)
+		if err != nil {
+			return err
+		}
+		if imageExists(reference) {
+			return fmt.Errorf("artifact %s already exists (versions are immutable)", reference)
+		}
+		err = cli.Execute("docker", []string{
+			"tag",
+			artifact.Location,
+			reference,
+		}, "Tagging the artifact")
+		if err != nil {
+			return err
+		}
+		err = cli.Execute("docker", []string{
+			"push",
+			reference,
+		}, "Pushing the artifact")
+		if err != nil {
+			return err
+		}
+		digest, err := getImageDigest(reference)
+		if err != nil {
+			return err
+		}
+		artifact.Location = reference
+		artifact.Digest = digest
+	default:
+		return fmt.Errorf("unknown artifact kind: %s", kind)
+	}
+	artifact.Version = version
+	return nil
+}
+
+// Pull fetches a version of the project's artifact from its artifact store:
+// archives are downloaded to a temporary file, and images are pulled
+func Pull(cfg *config.Config, kind, version string) (*config.Artifact, error) {
+	if cfg.Config.Artifacts == nil {
+		return nil, errors.New("the project does not have an artifact store (add \"artifacts\" to kettle.json)")
+	}
+	switch kind {
+	case Archive:
+		uri, err := getArchiveURI(cfg, version)
+		if err != nil {
+			return nil, err
+		}
+		f, err := ioutil.TempFile("", "kettle-artifact*.zip")
+		if err != nil {
+			return nil, err
+		}
+		f.Close()
+		if err := copyArchive(uri, f.Name(), "Downloading the artifact"); err != nil {
+			os.Remove(f.Name())
+			return nil, err
+		}
+		// The digest of archives is computed by the service that deploys them
+		return &config.Artifact{
+			Location: f.Name(),
+			Version:  version,
+		}, nil
+	case Image:
+		reference, err := getImageReference(cfg, version)
+		if err != nil {
+			return nil, err
+		}
+		err = cli.Execute("docker", []string{
+			"pull",
+			reference,
+		}, "Pulling the artifact")
+		if err != nil {
+			return nil, err
+		}
+		digest, err := getImageDigest(reference)
+		if err != nil {
+			return nil, err
+		}
+		return &config.Artifact{
+			Location: reference,
+			Digest:   digest,
+			Version:  version,
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown artifact kind: %s", kind)
+}
+
+// getArchiveURI returns where a version of the project's archive is stored;
+// stages share artifacts, so they are stored under the project's base name
+func getArchiveURI(cfg *config.Config, version string) (string, error) {
+	uri := cfg.Config.Artifacts.URI
+	if !isRemote(uri) {
+		return "", fmt.Errorf("unsupported artifact uri: %s (expected s3:// or gs://)", uri)
+	}
+	return fmt.Sprintf("%s/%s/%s.zip", strings.TrimSuffix(uri, "/"), cfg.GetBaseProjectName(), version), nil
+}
+
+func getImageReference(cfg *config.Config, version string) (string, error) {
+	registry := cfg.Config.Artifacts.Registry
+	if registry == "" {
+		return "", errors.New("the artifact store does not have a registry for images")
+	}
+	return fmt.Sprintf("%s/%s:%s", strings.TrimSuffix(registry, "/"), cfg.GetBaseProjectName(), version), nil
+}
+
+func copyArchive(source, destination, statusMessage string) error {
+	uri := source
+	if isRemote(destination) {
+		uri = destination
+	}
+	if strings.HasPrefix(uri, "s3://") {
+		return cli.Execute("aws", []string{
+			"s3",
+			"cp",
+			source,
+			destination,
+		}, statusMessage)
+	}
+	return cli.Execute("gcloud", []string{
+		"storage",
+		"cp",
+		source,
+		destination,
+	}, statusMessage)
+}
+
+func archiveExists(uri string) bool {
+	if strings.HasPrefix(uri, "s3://") {
+		_, err := cli.ExecuteSilently("aws", []string{
+			"s3",
+			"ls",
+			uri,
+		})
+		return err == nil
+	}
+	_, err := cli.ExecuteSilently("gcloud", []string{
+		"storage",
+		"ls",
+		uri,
+	})
+	return err == nil
+}
+
+func imageExists(reference string) bool {
+	_, err := cli.ExecuteSilently("docker", []string{
+		"manifest",
+		"inspect",
+		reference,
+	})
+	return err == nil
+}
+
+// getImageDigest returns the digest of an image that has been pushed to
+// (or pulled from) a registry, from its repository digests
+func getImageDigest(reference string) (string, error) {
+	output, err := cli.ExecuteWithResult("docker", []string{
+		"inspect",
+		"--format", "{{json .RepoDigests}}",
+		reference,
+	}, "Retrieving the image digest")
+	if err != nil {
+		return "", err
+	}
+	var repoDigests []string
+	if err := json.Unmarshal(output, &repoDigests); err != nil {
+		return "", err
+	}
+	repository := reference[:strings.LastIndex(reference, ":")]
+	for _, repoDigest := range repoDigests {
+		if strings.HasPrefix(repoDigest, repository+"@") {
+			return strings.TrimPrefix(repoDigest, repository+"@"), nil
+		}
+	}
+	return "", fmt.Errorf("could not find the digest of: %s", reference)
+}
+
+func isRemote(uri string) bool {
+	return strings.HasPrefix(uri, "s3://") || strings.HasPrefix(uri, "gs://")
+}
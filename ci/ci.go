@@ -0,0 +1,53 @@
+// Package ci sets the secrets that a project's CI pipeline needs (e.g. the
+// role that it deploys with) on its repository, on GitHub or GitLab
+package ci
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+)
+
+// GetRepository returns the repository (e.g. owner/name) that the project's
+// git repository (its origin) is a clone of, or an empty string if the
+// project is not in a git repository with an origin
+func GetRepository(projectPath string) string {
+	output, err := cli.ExecuteSilently("git", []string{"-C", projectPath, "remote", "get-url", "origin"})
+	if err != nil {
+		return ""
+	}
+	remote := strings.TrimSuffix(strings.TrimSuffix(strings.TrimSpace(string(output)), "/"), ".git")
+	if remoteURL, err := url.Parse(remote); err == nil && remoteURL.Host != "" {
+		return strings.TrimPrefix(remoteURL.Path, "/")
+	}
+	// scp-like remotes, e.g. git@github.com:owner/name
+	if i := strings.Index(remote, ":"); i != -1 {
+		return remote[i+1:]
+	}
+	return ""
+}
+
+// ListSecrets returns the names of the secrets that are set on the repository
+func ListSecrets(provider, repository string) (map[string]bool, error) {
+	switch provider {
+	case config.CIGitHub:
+		return listGitHubSecrets(repository)
+	case config.CIGitLab:
+		return listGitLabVariables(repository)
+	}
+	return nil, fmt.Errorf("unknown ci provider: %s", provider)
+}
+
+// SetSecret sets a secret on the repository, or replaces its value if it is set
+func SetSecret(provider, repository, name, value string) error {
+	switch provider {
+	case config.CIGitHub:
+		return setGitHubSecret(repository, name, value)
+	case config.CIGitLab:
+		return setGitLabVariable(repository, name, value)
+	}
+	return fmt.Errorf("unknown ci provider: %s", provider)
+}
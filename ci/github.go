@@ -0,0 +1,60 @@
+package ci
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+
+	"github.com/operatorai/kettle-cli/cli"
+)
+
+// GitHub encrypts secrets with the repository's public key before they are
+// stored, so they are set with the GitHub CLI (which authenticates with
+// gh auth login, or GH_TOKEN, and uses GH_HOST for GitHub Enterprise)
+func checkGitHubCLI() error {
+	if _, err := exec.LookPath("gh"); err != nil {
+		return errors.New(fmt.Sprintf("please install the GitHub CLI (gh) to set the secrets: %s", err))
+	}
+	return nil
+}
+
+func listGitHubSecrets(repository string) (map[string]bool, error) {
+	if err := checkGitHubCLI(); err != nil {
+		return nil, err
+	}
+	output, err := cli.ExecuteWithResult("gh", []string{
+		"secret",
+		"list",
+		"--repo", repository,
+		"--json", "name",
+	}, fmt.Sprintf("Listing the secrets of %s", repository))
+	if err != nil {
+		return nil, err
+	}
+	var secrets []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(output, &secrets); err != nil {
+		return nil, err
+	}
+	names := map[string]bool{}
+	for _, secret := range secrets {
+		names[secret.Name] = true
+	}
+	return names, nil
+}
+
+// setGitHubSecret sets an Actions secret, whose value is passed on
+// stdin so that it is not in the command's arguments
+func setGitHubSecret(repository, name, value string) error {
+	if err := checkGitHubCLI(); err != nil {
+		return err
+	}
+	_, err := cli.ExecuteWithInput("gh", []string{
+		"secret",
+		"set", name,
+		"--repo", repository,
+	}, []byte(value), fmt.Sprintf("Setting the secret %s", name))
+	return err
+}
@@ -0,0 +1,131 @@
+package ci
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/operatorai/kettle-cli/cli"
+)
+
+const (
+	gitLabTokenVariable  = "GITLAB_TOKEN"
+	gitLabAPIVariable    = "GITLAB_API_URL"
+	defaultGitLabAPI     = "https://gitlab.com/api/v4"
+	gitLabRequestTimeout = 30 * time.Second
+	// gitLabVariablesPageSize is the most variables that GitLab lists in a page
+	gitLabVariablesPageSize = 100
+)
+
+// maskablePattern matches the values that GitLab can mask in job logs
+var maskablePattern = regexp.MustCompile(`^[A-Za-z0-9+/=@:.~_-]{8,}$`)
+
+type gitLabVariable struct {
+	Key       string `json:"key"`
+	Value     string `json:"value,omitempty"`
+	Masked    bool   `json:"masked"`
+	Protected bool   `json:"protected"`
+}
+
+func listGitLabVariables(repository string) (map[string]bool, error) {
+	names := map[string]bool{}
+	for page := 1; ; page++ {
+		var variables []*gitLabVariable
+		status, err := callGitLab(http.MethodGet, repository, fmt.Sprintf("variables?per_page=%d&page=%d", gitLabVariablesPageSize, page), nil, &variables)
+		if err != nil {
+			return nil, err
+		}
+		if status != http.StatusOK {
+			return nil, fmt.Errorf("GitLab did not list the CI/CD variables of %s: %s", repository, http.StatusText(status))
+		}
+		for _, variable := range variables {
+			names[variable.Key] = true
+		}
+		if len(variables) < gitLabVariablesPageSize {
+			return names, nil
+		}
+	}
+}
+
+// setGitLabVariable sets a CI/CD variable of the project, which is masked
+// in job logs if GitLab can mask its value (and is available to the
+// pipelines of all branches, like GitHub's secrets)
+func setGitLabVariable(repository, name, value string) error {
+	if cli.ReadOnly {
+		return fmt.Errorf("%w: refusing to set the CI/CD variable %s", cli.ErrReadOnly, name)
+	}
+	variable := &gitLabVariable{
+		Key:    name,
+		Value:  value,
+		Masked: maskablePattern.MatchString(value),
+	}
+	status, err := callGitLab(http.MethodGet, repository, "variables/"+url.PathEscape(name), nil, nil)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusOK {
+		status, err = callGitLab(http.MethodPut, repository, "variables/"+url.PathEscape(name), variable, nil)
+	} else {
+		status, err = callGitLab(http.MethodPost, repository, "variables", variable, nil)
+	}
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK && status != http.StatusCreated {
+		return fmt.Errorf("GitLab did not set the CI/CD variable %s: %s", name, http.StatusText(status))
+	}
+	return nil
+}
+
+// callGitLab calls an endpoint of a project with GitLab's API (or the API of
+// a self-managed instance, in GITLAB_API_URL), with the token in GITLAB_TOKEN,
+// and returns the response's status; successful responses are read into result
+func callGitLab(method, repository, endpoint string, body interface{}, result interface{}) (int, error) {
+	token := os.Getenv(gitLabTokenVariable)
+	if token == "" {
+		return 0, fmt.Errorf("setting CI/CD variables needs a GitLab token in %s", gitLabTokenVariable)
+	}
+	api := os.Getenv(gitLabAPIVariable)
+	if api == "" {
+		api = defaultGitLabAPI
+	}
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+	}
+	requestURL := fmt.Sprintf("%s/projects/%s/%s", strings.TrimSuffix(api, "/"), url.PathEscape(repository), endpoint)
+	request, err := http.NewRequest(method, requestURL, bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("PRIVATE-TOKEN", token)
+	request.Header.Set("Content-Type", "application/json")
+	client := &http.Client{Timeout: gitLabRequestTimeout}
+	response, err := client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusUnauthorized || response.StatusCode == http.StatusForbidden {
+		return 0, fmt.Errorf("GitLab refused the token in %s: %s", gitLabTokenVariable, response.Status)
+	}
+	if result == nil || response.StatusCode != http.StatusOK {
+		return response.StatusCode, nil
+	}
+	output, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return 0, err
+	}
+	return response.StatusCode, json.Unmarshal(output, result)
+}
@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// AccessibleEnvironmentVariable enables the accessible mode, like the
+// --accessible flag or accessible: true in ~/.kettle.yaml
+const AccessibleEnvironmentVariable = "KETTLE_ACCESSIBLE"
+
+// Accessible is set for screen readers: prompts are numbered lists that are
+// answered by typing a number, there are no spinners or select widgets that
+// move the cursor, and emoji are removed from kettle's output
+var Accessible bool
+
+// stdin is shared by the accessible prompts, so that input which is
+// buffered by one prompt is not lost by the next one
+var stdin = bufio.NewReader(os.Stdin)
+
+// IsAccessibleEnvironment returns true if the accessible mode
+// is enabled by the environment
+func IsAccessibleEnvironment() bool {
+	enabled, err := strconv.ParseBool(os.Getenv(AccessibleEnvironmentVariable))
+	return err == nil && enabled
+}
+
+// RemoveEmoji filters the emoji out of everything that is printed to stdout;
+// the returned function must be called before exiting, to flush the output
+func RemoveEmoji() (func(), error) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	terminal := os.Stdout
+	os.Stdout = writer
+	done := make(chan struct{})
+	go func() {
+		copyWithoutEmoji(terminal, reader)
+		close(done)
+	}()
+	return func() {
+		os.Stdout = terminal
+		writer.Close()
+		<-done
+	}, nil
+}
+
+// copyWithoutEmoji copies the output as it is written (prompts do not end
+// with a new line), keeping any partial runes until the rest of them arrive
+func copyWithoutEmoji(w io.Writer, r io.Reader) {
+	buffer := make([]byte, 4096)
+	pending := []byte{}
+	for {
+		n, err := r.Read(buffer)
+		pending = append(pending, buffer[:n]...)
+		complete := len(pending)
+		if start := lastRuneStart(pending); !utf8.FullRune(pending[start:]) {
+			complete = start
+		}
+		if complete > 0 {
+			w.Write([]byte(stripEmoji(string(pending[:complete]))))
+			pending = pending[complete:]
+		}
+		if err != nil {
+			w.Write(pending)
+			return
+		}
+	}
+}
+
+func lastRuneStart(data []byte) int {
+	for i := len(data) - 1; i >= 0; i-- {
+		if utf8.RuneStart(data[i]) {
+			return i
+		}
+	}
+	return 0
+}
+
+// stripEmoji removes emoji (and the spaces that separate them from the text)
+func stripEmoji(text string) string {
+	var stripped strings.Builder
+	skipSpaces := false
+	for _, r := range text {
+		if isEmoji(r) {
+			skipSpaces = true
+			continue
+		}
+		if skipSpaces && r == ' ' {
+			continue
+		}
+		skipSpaces = false
+		stripped.WriteRune(r)
+	}
+	return stripped.String()
+}
+
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF:
+		return true
+	case r >= 0x2300 && r <= 0x23FF, r >= 0x2600 && r <= 0x27BF, r >= 0x2B00 && r <= 0x2BFF:
+		return true
+	case r == 0xFE0F || r == 0x200D:
+		// Variation selectors and joiners of emoji sequences
+		return true
+	}
+	return false
+}
+
+// readLine reads an answer from stdin
+func readLine() (string, error) {
+	line, err := stdin.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRightFunc(line, unicode.IsSpace), nil
+}
+
+// promptForNumber prints the items as a numbered list, and asks for the
+// number of one of them until a valid number is typed; an empty answer
+// chooses the default item (if there is one, i.e. if it is not -1)
+func promptForNumber(label string, items []string, defaultIndex int) (string, error) {
+	fmt.Println(label)
+	for i, item := range items {
+		fmt.Println(fmt.Sprintf("  %d. %s", i+1, item))
+	}
+	for {
+		if defaultIndex >= 0 {
+			fmt.Printf("Type a number from 1 to %d (default %d): ", len(items), defaultIndex+1)
+		} else {
+			fmt.Printf("Type a number from 1 to %d: ", len(items))
+		}
+		answer, err := readLine()
+		if err != nil {
+			return "", err
+		}
+		if answer == "" && defaultIndex >= 0 {
+			return items[defaultIndex], nil
+		}
+		number, err := strconv.Atoi(strings.TrimSpace(answer))
+		if err == nil && number >= 1 && number <= len(items) {
+			return items[number-1], nil
+		}
+		fmt.Println(fmt.Sprintf("Invalid choice: %s", answer))
+	}
+}
+
+// promptForLine asks for a string until it is valid; an empty
+// answer is the default value (if there is one)
+func promptForLine(label string, defaultValue string, validate func(string) error) (string, error) {
+	for {
+		if defaultValue != "" {
+			fmt.Printf("%s (default %s): ", label, defaultValue)
+		} else {
+			fmt.Printf("%s: ", label)
+		}
+		answer, err := readLine()
+		if err != nil {
+			return "", err
+		}
+		if answer == "" {
+			answer = defaultValue
+		}
+		if validate == nil {
+			return answer, nil
+		}
+		if err := validate(answer); err != nil {
+			fmt.Println(fmt.Sprintf("Invalid value: %s", err))
+			continue
+		}
+		return answer, nil
+	}
+}
+
+// promptForYesOrNo asks a yes/no question, which is answered with no by default
+func promptForYesOrNo(label string) bool {
+	fmt.Printf("%s? Type yes or no (default no): ", label)
+	answer, err := readLine()
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v2"
+)
+
+// NonInteractive is set by the --non-interactive flag; prompts that do not
+// have an answer (or a default) fail instead of waiting for input, e.g. in CI
+var NonInteractive bool
+
+// Answers are the values of prompts that are given up front (with --set or
+// --values), keyed by the prompt's label in snake_case, or a template's key
+var Answers = map[string]string{}
+
+// SetAnswers adds the answers from a values file (YAML) and from key=value
+// pairs; pairs take precedence over the values file
+func SetAnswers(pairs []string, valuesFile string) error {
+	if valuesFile != "" {
+		data, err := ioutil.ReadFile(valuesFile)
+		if err != nil {
+			return err
+		}
+		values := map[string]string{}
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return fmt.Errorf("invalid values file %s: %s", valuesFile, err)
+		}
+		for key, value := range values {
+			Answers[key] = value
+		}
+	}
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return fmt.Errorf("invalid value: %s (expected key=value)", pair)
+		}
+		Answers[parts[0]] = parts[1]
+	}
+	return nil
+}
+
+// AnswerKey returns the key that answers a prompt, e.g. "Project name"
+// is answered by project_name
+func AnswerKey(label string) string {
+	key := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return '_'
+	}, label)
+	for strings.Contains(key, "__") {
+		key = strings.ReplaceAll(key, "__", "_")
+	}
+	return strings.Trim(key, "_")
+}
+
+func getAnswer(label string) (string, bool) {
+	answer, ok := Answers[AnswerKey(label)]
+	return answer, ok
+}
+
+func missingAnswer(label string) error {
+	return fmt.Errorf("%s is required in non-interactive mode (set it with: --set %s=<value>)", label, AnswerKey(label))
+}
+
+// getChoice returns the value of an answer to a select prompt, which can
+// be one of the labels or one of the values
+func getChoice(label, answer string, values map[string]string) (string, string, error) {
+	if value, ok := values[answer]; ok {
+		return answer, value, nil
+	}
+	for valueLabel, value := range values {
+		if value == answer {
+			return valueLabel, value, nil
+		}
+	}
+	return "", "", fmt.Errorf("invalid value for %s: %s", AnswerKey(label), answer)
+}
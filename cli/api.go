@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/iancoleman/strcase"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+// CallAPI calls an operation of a cloud's API (e.g. with the AWS SDK) like a cli's
+// command is run: it is refused in read-only mode if it changes anything, it is
+// recorded (as e.g. aws lambda get-function), and its status is shown with a
+// spinner; calls without a status message are made silently (e.g. while polling)
+func CallAPI(cloud, service, operation, statusMessage string, call func(ctx context.Context) error) error {
+	args := []string{service, strcase.ToKebab(operation)}
+	if err := checkReadOnly(cloud, args); err != nil {
+		return err
+	}
+	defer trackCommand(cloud, args)()
+	record := recordCommand(cloud, args)
+	if settings.DebugMode {
+		fmt.Println("\n", cloud, service, operation)
+	} else if statusMessage != "" {
+		s := getSpinner(statusMessage)
+		defer s.Stop()
+	}
+	if err := call(commandContext); err != nil {
+		record(-1, err)
+		return err
+	}
+	record(0, nil)
+	return nil
+}
@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// CacheTTL is how long the results of read-only lookups are kept on disk
+const CacheTTL = 5 * time.Minute
+
+// NoCache is set by the --no-cache flag; lookups are then only cached
+// for the duration of the command, and not on disk
+var NoCache bool
+
+// cache holds the results of the lookups of this run, by key; cacheLock guards
+// it, as lookups can run in the background (e.g. reading a template's changelog)
+var (
+	cache     = map[string][]byte{}
+	cacheLock sync.Mutex
+)
+
+type cacheEntry struct {
+	Expires time.Time `json:"expires"`
+	Output  []byte    `json:"output"`
+}
+
+// ExecuteCached runs a read-only lookup (e.g. listing roles or regions), whose
+// result is reused for the rest of the run, and by later runs until it expires
+func ExecuteCached(command string, args []string, statusMessage string) ([]byte, error) {
+	key := getCacheKey(command, args)
+	if output, ok := getCached(key); ok {
+		return output, nil
+	}
+	if output, ok := readCacheFile(key); ok {
+		setCached(key, output)
+		return output, nil
+	}
+	output, err := ExecuteWithResult(command, args, statusMessage)
+	if err != nil {
+		return nil, err
+	}
+	setCached(key, output)
+	writeCacheFile(key, output, CacheTTL)
+	return output, nil
+}
+
+// CacheLookup runs a lookup that is not a command (e.g. an AWS SDK call, or
+// reading the latest version of a template), whose result is kept for the ttl;
+// the key identifies the lookup
+func CacheLookup(key string, ttl time.Duration, lookup func() ([]byte, error)) ([]byte, error) {
+	hash := sha1.Sum([]byte(key))
+	key = hex.EncodeToString(hash[:])
+	if output, ok := getCached(key); ok {
+		return output, nil
+	}
+	if output, ok := readCacheFile(key); ok {
+		setCached(key, output)
+		return output, nil
+	}
+	output, err := lookup()
+	if err != nil {
+		return nil, err
+	}
+	setCached(key, output)
+	writeCacheFile(key, output, ttl)
+	return output, nil
+}
+
+// ClearCache removes the cached lookups, after kettle has
+// created a resource that they list (e.g. a role)
+func ClearCache() {
+	cacheLock.Lock()
+	cache = map[string][]byte{}
+	cacheLock.Unlock()
+	if directory, err := getCacheDirectory(); err == nil {
+		os.RemoveAll(directory)
+	}
+}
+
+func getCached(key string) ([]byte, bool) {
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+	output, ok := cache[key]
+	return output, ok
+}
+
+func setCached(key string, output []byte) {
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+	cache[key] = output
+}
+
+// getCacheKey is a hash of the command, and of the environment variables
+// that choose the credentials, account, or project that it runs with
+func getCacheKey(command string, args []string) string {
+	environment := []string{}
+	for _, variable := range os.Environ() {
+		if strings.HasPrefix(variable, "AWS_") || strings.HasPrefix(variable, "CLOUDSDK_") {
+			environment = append(environment, variable)
+		}
+	}
+	sort.Strings(environment)
+	hash := sha1.New()
+	hash.Write([]byte(strings.Join(append([]string{command}, args...), "\x00")))
+	hash.Write([]byte(strings.Join(environment, "\x00")))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func getCacheDirectory() (string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(home, ".kettle", "cache"), nil
+}
+
+// readCacheFile returns a lookup's cached output, if it has not expired;
+// the cache is best effort, so any errors are treated as a miss
+func readCacheFile(key string) ([]byte, bool) {
+	if NoCache {
+		return nil, false
+	}
+	directory, err := getCacheDirectory()
+	if err != nil {
+		return nil, false
+	}
+	data, err := ioutil.ReadFile(path.Join(directory, key+".json"))
+	if err != nil {
+		return nil, false
+	}
+	entry := &cacheEntry{}
+	if err := json.Unmarshal(data, entry); err != nil || time.Now().After(entry.Expires) {
+		return nil, false
+	}
+	return entry.Output, true
+}
+
+func writeCacheFile(key string, output []byte, ttl time.Duration) {
+	if NoCache {
+		return
+	}
+	directory, err := getCacheDirectory()
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(directory, 0700); err != nil {
+		return
+	}
+	data, err := json.Marshal(&cacheEntry{
+		Expires: time.Now().Add(ttl),
+		Output:  output,
+	})
+	if err != nil {
+		return
+	}
+	ioutil.WriteFile(path.Join(directory, key+".json"), data, 0600)
+}
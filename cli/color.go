@@ -0,0 +1,28 @@
+package cli
+
+import "os"
+
+// ANSI colors of the output, which are only used on terminals that do not
+// have colors turned off (with NO_COLOR), and not in the accessible mode
+const (
+	colorRed    = "\033[31m"
+	colorYellow = "\033[33m"
+	colorReset  = "\033[0m"
+)
+
+// Red colors text red, e.g. an error
+func Red(text string) string {
+	return colorize(colorRed, text)
+}
+
+// Yellow colors text yellow, e.g. the cause of an error
+func Yellow(text string) string {
+	return colorize(colorYellow, text)
+}
+
+func colorize(color, text string) string {
+	if Accessible || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" || !isTerminal(os.Stdout) {
+		return text
+	}
+	return color + text + colorReset
+}
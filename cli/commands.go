@@ -1,7 +1,10 @@
 package cli
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strings"
@@ -11,23 +14,68 @@ import (
 	"github.com/operatorai/kettle-cli/settings"
 )
 
+// commandContext stops the commands that are running when it is done,
+// e.g. when a deploy that was started by another Go program is cancelled
+var commandContext = context.Background()
+
+// UseContext runs the commands with the context, and returns
+// a function that restores the previous context
+func UseContext(ctx context.Context) func() {
+	previous := commandContext
+	commandContext = ctx
+	return func() {
+		commandContext = previous
+	}
+}
+
 func getSpinner(statusMessage string) *spinner.Spinner {
 	s := spinner.New(spinner.CharSets[39], 100*time.Millisecond)
 	s.Suffix = fmt.Sprintf("  %s...", statusMessage)
+	if Accessible {
+		// The spinner moves the cursor, so the status is printed once instead
+		fmt.Println(statusMessage + "...")
+		return s
+	}
+	if !isTerminal(os.Stdout) {
+		// e.g. the functions that kettle deploy --all deploys, whose output is buffered
+		return s
+	}
 	s.Start()
 	return s
 }
 
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
 func Execute(command string, args []string, statusMessage string) error {
 	_, err := ExecuteWithResult(command, args, statusMessage)
 	return err
 }
 
 func ExecuteWithResult(command string, args []string, statusMessage string) ([]byte, error) {
-	osCmd := exec.Command(command, args...)
+	return ExecuteWithInput(command, args, nil, statusMessage)
+}
+
+// ExecuteWithInput runs a command that reads from stdin, e.g. so that
+// passwords are not passed as command line arguments
+func ExecuteWithInput(command string, args []string, input []byte, statusMessage string) ([]byte, error) {
+	if err := checkReadOnly(command, args); err != nil {
+		return nil, err
+	}
+	defer trackCommand(command, args)()
+	record := recordCommand(command, args)
+	osCmd := exec.CommandContext(commandContext, command, args...)
+	if input != nil {
+		osCmd.Stdin = bytes.NewReader(input)
+	}
+	// The command's error is kept, so that failures can be reported (and checked)
+	var stderr bytes.Buffer
+	osCmd.Stderr = &stderr
 	if settings.DebugMode {
 		fmt.Println("\n", command, strings.Join(args, " "))
-		osCmd.Stderr = os.Stderr
+		osCmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
 	} else {
 		s := getSpinner(statusMessage)
 		defer s.Stop()
@@ -35,7 +83,59 @@ func ExecuteWithResult(command string, args []string, statusMessage string) ([]b
 
 	output, err := osCmd.Output()
 	if err != nil {
+		commandErr := getCommandError(command, args, stderr.String(), err)
+		record(-1, commandErr)
+		return nil, commandErr
+	}
+	record(0, nil)
+	return output, nil
+}
+
+// ExecuteSilently runs a command without a spinner, so that
+// it can be called concurrently (e.g. during a load test)
+func ExecuteSilently(command string, args []string) ([]byte, error) {
+	if err := checkReadOnly(command, args); err != nil {
 		return nil, err
 	}
+	defer trackCommand(command, args)()
+	record := recordCommand(command, args)
+	var stderr bytes.Buffer
+	osCmd := exec.CommandContext(commandContext, command, args...)
+	osCmd.Stderr = &stderr
+	output, err := osCmd.Output()
+	if err != nil {
+		commandErr := getCommandError(command, args, stderr.String(), err)
+		record(-1, commandErr)
+		return nil, commandErr
+	}
+	record(0, nil)
 	return output, nil
 }
+
+// ExecuteInteractively runs a command with the terminal attached (e.g. so
+// that its output is streamed, and it can prompt), and returns its exit code
+func ExecuteInteractively(command string, args []string, environment []string) (int, error) {
+	if err := checkReadOnly(command, args); err != nil {
+		return -1, err
+	}
+	defer trackCommand(command, args)()
+	record := recordCommand(command, args)
+	osCmd := exec.CommandContext(commandContext, command, args...)
+	osCmd.Stdin = os.Stdin
+	osCmd.Stdout = os.Stdout
+	osCmd.Stderr = os.Stderr
+	osCmd.Env = append(os.Environ(), environment...)
+	if settings.DebugMode {
+		fmt.Println("\n", command, strings.Join(args, " "))
+	}
+	if err := osCmd.Run(); err != nil {
+		commandErr := getCommandError(command, args, "", err)
+		record(-1, commandErr)
+		if exitErr, ok := commandErr.(*CommandError); ok {
+			return exitErr.ExitCode, nil
+		}
+		return -1, commandErr
+	}
+	record(0, nil)
+	return 0, nil
+}
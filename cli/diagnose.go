@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Diagnosis explains why a command failed in plain language,
+// and the steps that are likely to fix it
+type Diagnosis struct {
+	Cause string
+	Fixes []string
+}
+
+// failure describes a common failure of the clouds' clis: it matches an error
+// that has any of the codes (or messages), and diagnoses it from the message
+type failure struct {
+	codes    []string
+	diagnose func(message string) *Diagnosis
+}
+
+var (
+	deniedActionPattern   = regexp.MustCompile(`not authorized to perform:? ([A-Za-z0-9-]+:[A-Za-z0-9]+)`)
+	deniedResourcePattern = regexp.MustCompile(`on resource:? (\S+)`)
+	gcpPermissionPattern  = regexp.MustCompile(`[Pp]ermission '?([a-z]+\.[A-Za-z.]+)'? denied`)
+)
+
+// failures are checked in order, so more specific failures (e.g. a denied
+// iam:PassRole) come before the general ones (e.g. any denied action)
+var failures = []*failure{
+	{
+		codes: []string{"ExpiredToken", "RequestExpired", "token has expired", "Token has expired", "Reauthentication", "invalid_grant", "refresh token"},
+		diagnose: func(message string) *Diagnosis {
+			return &Diagnosis{
+				Cause: "Your cloud credentials have expired.",
+				Fixes: []string{
+					"On AWS, sign in again, e.g. with: aws sso login (add --profile for a named profile)",
+					"On GCP, sign in again with: gcloud auth login (and gcloud auth application-default login)",
+				},
+			}
+		},
+	},
+	{
+		codes: []string{"InvalidClientTokenId", "UnrecognizedClientException", "Unable to locate credentials", "SignatureDoesNotMatch", "You do not currently have an active account selected"},
+		diagnose: func(message string) *Diagnosis {
+			return &Diagnosis{
+				Cause: "The cloud cli does not have valid credentials.",
+				Fixes: []string{
+					"Check which identity is used with: aws sts get-caller-identity (or: gcloud auth list)",
+					"Configure credentials with: aws configure (or: gcloud auth login)",
+					"If AWS_PROFILE or AWS_ACCESS_KEY_ID are set, check that they are the ones you expect",
+				},
+			}
+		},
+	},
+	{
+		codes: []string{"iam:PassRole"},
+		diagnose: func(message string) *Diagnosis {
+			role := "the execution role"
+			if match := deniedResourcePattern.FindStringSubmatch(message); match != nil {
+				role = match[1]
+			}
+			return &Diagnosis{
+				Cause: fmt.Sprintf("Your credentials are not allowed to pass %s to Lambda (iam:PassRole), which deploying a function needs.", role),
+				Fixes: []string{
+					"Add iam:PassRole on the role to your deploy policy; kettle bootstrap-iam <path> prints a policy that has it",
+					"Or deploy with a role that you can pass: set role_arn under aws in kettle.json (or ~/.kettle.yaml)",
+				},
+			}
+		},
+	},
+	{
+		codes: []string{"AccessDenied", "UnauthorizedOperation", "not authorized to perform", "PERMISSION_DENIED", "does not have permission"},
+		diagnose: func(message string) *Diagnosis {
+			action := "this action"
+			if match := deniedActionPattern.FindStringSubmatch(message); match != nil {
+				action = match[1]
+			} else if match := gcpPermissionPattern.FindStringSubmatch(message); match != nil {
+				action = match[1]
+			}
+			return &Diagnosis{
+				Cause: fmt.Sprintf("Your credentials are not allowed to perform %s.", action),
+				Fixes: []string{
+					"Check which identity is used with: aws sts get-caller-identity (or: gcloud auth list)",
+					"kettle bootstrap-iam <path> prints the permissions that deploying the project needs",
+					"If the account has a permissions boundary or an SCP, ask its administrator to allow the action",
+				},
+			}
+		},
+	},
+	{
+		codes: []string{"ResourceConflictException", "ResourceInUseException", "operation is in progress"},
+		diagnose: func(message string) *Diagnosis {
+			return &Diagnosis{
+				Cause: "Another change to the resource is still in progress; Lambda (and API Gateway) only allow one update at a time.",
+				Fixes: []string{
+					"Wait a minute, and run the command again",
+					"Check that no other deploy of the project is running (e.g. in CI); a state backend locks deploys",
+				},
+			}
+		},
+	},
+	{
+		codes: []string{"exec format error", "invalid ELF header", "wrong ELF class", "cannot execute binary file"},
+		diagnose: func(message string) *Diagnosis {
+			return &Diagnosis{
+				Cause: "The package has native code that was built for another OS or architecture than the function's runtime.",
+				Fixes: []string{
+					"Check that \"architecture\" in kettle.json (x86_64 or arm64) is the one that the dependencies were built for",
+					"Test in the runtime's own image before deploying, with: kettle deploy <path> --parity",
+				},
+			}
+		},
+	},
+	{
+		codes: []string{"runtime parameter", "Unsupported runtime", "is not supported for runtime", "runtime is not supported", "deprecated runtime"},
+		diagnose: func(message string) *Diagnosis {
+			return &Diagnosis{
+				Cause: "The function's runtime is not supported: it may be deprecated, misspelt, or not available in this region.",
+				Fixes: []string{
+					"Set a supported \"runtime\" in kettle.json (e.g. python3.12 or nodejs20.x)",
+					"If the runtime was upgraded, check that the dependencies support it, e.g. with: kettle deploy <path> --parity",
+				},
+			}
+		},
+	},
+	{
+		codes: []string{"ThrottlingException", "TooManyRequestsException", "Rate exceeded", "RESOURCE_EXHAUSTED", "SlowDown"},
+		diagnose: func(message string) *Diagnosis {
+			return &Diagnosis{
+				Cause: "The cloud's API is throttling requests, or a quota has been reached.",
+				Fixes: []string{
+					"Wait a minute, and run the command again",
+					"If it keeps failing, check the account's service quotas (e.g. concurrent executions)",
+				},
+			}
+		},
+	},
+	{
+		codes: []string{"Could not connect to the endpoint URL", "connection refused", "no such host", "i/o timeout"},
+		diagnose: func(message string) *Diagnosis {
+			return &Diagnosis{
+				Cause: "The cloud's API could not be reached.",
+				Fixes: []string{
+					"Check your network connection, and any proxy (HTTPS_PROXY) or custom CA (--ca-bundle)",
+					"Check that the region, and any endpoints, in ~/.kettle.yaml are right",
+				},
+			}
+		},
+	},
+}
+
+// Diagnose explains a failure that is common (e.g. expired credentials, or a
+// denied action), or returns nil if the error is not one of them
+func Diagnose(err error) *Diagnosis {
+	if err == nil {
+		return nil
+	}
+	message := err.Error()
+	var commandErr *CommandError
+	if errors.As(err, &commandErr) {
+		message = commandErr.Stderr + "\n" + message
+	}
+	for _, f := range failures {
+		for _, code := range f.codes {
+			if strings.Contains(message, code) {
+				return f.diagnose(message)
+			}
+		}
+	}
+	return nil
+}
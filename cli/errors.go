@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// CommandError is returned when a command (e.g. the aws or gcloud cli)
+// fails, with its exit code and the error that it printed
+type CommandError struct {
+	Command  string
+	Args     []string
+	ExitCode int
+	Stderr   string
+}
+
+func (e *CommandError) Error() string {
+	lines := strings.Split(strings.TrimSpace(e.Stderr), "\n")
+	message := strings.TrimSpace(lines[len(lines)-1])
+	if message == "" {
+		return fmt.Sprintf("%s exited with status %d", e.Command, e.ExitCode)
+	}
+	return fmt.Sprintf("%s: %s", e.Command, message)
+}
+
+// awsErrorCodePattern matches the error code that the aws cli prints, e.g.
+// An error occurred (ResourceNotFoundException) when calling the GetFunction operation
+var awsErrorCodePattern = regexp.MustCompile(`An error occurred \(([^)]+)\) when calling`)
+
+// notFoundErrors are printed by the clis that do not print error codes (e.g.
+// gcloud) when a resource does not exist
+var notFoundErrors = []string{
+	"NOT_FOUND",
+	"Could not find",
+	"could not be found",
+	"was not found",
+	"does not exist",
+}
+
+// notFoundCodes are (parts of) the error codes of AWS's APIs when a resource does not
+// exist, e.g. ResourceNotFoundException, NoSuchEntity, DBClusterNotFoundFault, or 404
+var notFoundCodes = []string{
+	"NotFound",
+	"NoSuch",
+	"DoesNotExist",
+	"NonExistent",
+}
+
+// ErrorCode returns the code of the error that a cloud's API returned (e.g.
+// ResourceNotFoundException): the code of an SDK's error, or the code that
+// the aws cli printed; other errors do not have codes
+func ErrorCode(err error) string {
+	var coded interface{ ErrorCode() string }
+	if errors.As(err, &coded) {
+		return coded.ErrorCode()
+	}
+	var commandErr *CommandError
+	if errors.As(err, &commandErr) && commandErr.Command == "aws" {
+		if match := awsErrorCodePattern.FindStringSubmatch(commandErr.Stderr); match != nil {
+			return match[1]
+		}
+	}
+	return ""
+}
+
+// IsNotFound returns true if a command (or an API call) failed because
+// the resource that it looked for (or changed) does not exist
+func IsNotFound(err error) bool {
+	if code := ErrorCode(err); code != "" {
+		if code == "404" {
+			return true
+		}
+		for _, notFound := range notFoundCodes {
+			if strings.Contains(code, notFound) {
+				return true
+			}
+		}
+		return false
+	}
+	var commandErr *CommandError
+	if !errors.As(err, &commandErr) || commandErr.Command == "aws" {
+		return false
+	}
+	for _, notFound := range notFoundErrors {
+		if strings.Contains(commandErr.Stderr, notFound) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasErrorCode returns true if a command (or an API call) failed with
+// the given error code, e.g. ConditionalCheckFailedException
+func HasErrorCode(err error, code string) bool {
+	if errorCode := ErrorCode(err); errorCode != "" {
+		return errorCode == code
+	}
+	var commandErr *CommandError
+	if !errors.As(err, &commandErr) {
+		return false
+	}
+	return strings.Contains(commandErr.Stderr, code)
+}
+
+// getCommandError converts the error of a command that failed to run
+func getCommandError(command string, args []string, stderr string, err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &CommandError{
+			Command:  command,
+			Args:     args,
+			ExitCode: exitErr.ExitCode(),
+			Stderr:   stderr,
+		}
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("the %s cli is not installed, or is not on your PATH", command)
+	}
+	return err
+}
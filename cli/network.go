@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// CABundleEnvironmentVariable sets a custom CA bundle, like
+// the --ca-bundle flag or ca_bundle in ~/.kettle.yaml
+const CABundleEnvironmentVariable = "KETTLE_CA_BUNDLE"
+
+// caBundleVariables are the environment variables that point the tools that
+// kettle runs (the aws and gcloud clis, git, pip, and npm) at a CA bundle
+var caBundleVariables = []string{
+	"AWS_CA_BUNDLE",
+	"CLOUDSDK_CORE_CUSTOM_CA_CERTS_FILE",
+	"GIT_SSL_CAINFO",
+	"REQUESTS_CA_BUNDLE",
+	"PIP_CERT",
+	"NODE_EXTRA_CA_CERTS",
+}
+
+// proxyVariables are passed to docker builds, which do not
+// otherwise use the proxy that kettle is run with
+var proxyVariables = []string{
+	"HTTP_PROXY",
+	"HTTPS_PROXY",
+	"NO_PROXY",
+	"http_proxy",
+	"https_proxy",
+	"no_proxy",
+}
+
+// UseCABundle trusts the certificates in the bundle (e.g. of a proxy that
+// intercepts TLS), in addition to the system's certificates, for kettle's
+// own requests and for the tools that it runs; the proxy itself is set with
+// HTTP_PROXY, HTTPS_PROXY, and NO_PROXY, which are honored by all of them
+func UseCABundle(bundlePath string) error {
+	data, err := ioutil.ReadFile(bundlePath)
+	if err != nil {
+		return fmt.Errorf("cannot read the CA bundle: %s", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return fmt.Errorf("the CA bundle has no PEM certificates: %s", bundlePath)
+	}
+	if transport, ok := http.DefaultTransport.(*http.Transport); ok {
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	// Variables that the user has set are kept, as they may point at other bundles
+	for _, variable := range caBundleVariables {
+		if _, ok := os.LookupEnv(variable); !ok {
+			os.Setenv(variable, bundlePath)
+		}
+	}
+	return nil
+}
+
+// GetCABundle returns the CA bundle that is set by the environment (if there is one)
+func GetCABundle() string {
+	return strings.TrimSpace(os.Getenv(CABundleEnvironmentVariable))
+}
+
+// ProxyBuildArgs passes the proxy that kettle is run with to a docker build,
+// so that the build can download its dependencies
+func ProxyBuildArgs() []string {
+	args := []string{}
+	for _, variable := range proxyVariables {
+		if _, ok := os.LookupEnv(variable); ok {
+			args = append(args, "--build-arg", variable)
+		}
+	}
+	return args
+}
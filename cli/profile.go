@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Profiling is set by the --profile-run flag: kettle records how long each step
+// of the run takes (each command that it runs, and each file that it renders),
+// and prints a breakdown when it finishes
+var Profiling bool
+
+// profileSlowestSteps is how many of the slowest steps are printed
+const profileSlowestSteps = 10
+
+type profileStep struct {
+	category string
+	name     string
+	duration time.Duration
+}
+
+var (
+	profileStart = time.Now()
+	profileSteps = []*profileStep{}
+	// Commands can be run concurrently (e.g. during a load test)
+	profileLock sync.Mutex
+)
+
+// Track starts timing a step of the run, in a category (e.g. render, or the
+// name of a cli), and returns the function that stops timing it
+func Track(category, name string) func() {
+	if !Profiling {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		profileLock.Lock()
+		defer profileLock.Unlock()
+		profileSteps = append(profileSteps, &profileStep{
+			category: category,
+			name:     name,
+			duration: time.Since(start),
+		})
+	}
+}
+
+// trackCommand times a command, by its subcommands, e.g. aws lambda update-function-code
+func trackCommand(command string, args []string) func() {
+	name := []string{command}
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") || len(name) == 3 {
+			break
+		}
+		name = append(name, arg)
+	}
+	return Track(command, strings.Join(name, " "))
+}
+
+// PrintProfile prints the time that the run spent in each category of steps,
+// and its slowest steps
+func PrintProfile() {
+	if !Profiling {
+		return
+	}
+	profileLock.Lock()
+	defer profileLock.Unlock()
+
+	total := time.Since(profileStart)
+	fmt.Println("\n⏱   Run profile: ", total.Round(time.Millisecond))
+	categories := map[string]*profileStep{}
+	counts := map[string]int{}
+	for _, step := range profileSteps {
+		if _, ok := categories[step.category]; !ok {
+			categories[step.category] = &profileStep{category: step.category}
+		}
+		categories[step.category].duration += step.duration
+		counts[step.category]++
+	}
+	totals := []*profileStep{}
+	for _, category := range categories {
+		totals = append(totals, category)
+	}
+	sortSteps(totals)
+	for _, category := range totals {
+		fmt.Println(fmt.Sprintf("  %-10s %10s %5.1f%%  (%d steps)",
+			category.category,
+			category.duration.Round(time.Millisecond),
+			100*category.duration.Seconds()/total.Seconds(),
+			counts[category.category],
+		))
+	}
+
+	steps := append([]*profileStep{}, profileSteps...)
+	sortSteps(steps)
+	if len(steps) > profileSlowestSteps {
+		steps = steps[:profileSlowestSteps]
+	}
+	if len(steps) > 0 {
+		fmt.Println("🐢  Slowest steps:")
+	}
+	for _, step := range steps {
+		fmt.Println(fmt.Sprintf("  %10s  %s", step.duration.Round(time.Millisecond), step.name))
+	}
+}
+
+func sortSteps(steps []*profileStep) {
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].duration > steps[j].duration
+	})
+}
@@ -1,7 +1,9 @@
 package cli
 
 import (
+	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/manifoldco/promptui"
@@ -12,6 +14,16 @@ const (
 )
 
 func PromptForValue(label string, values map[string]string, addNoneOfThese bool) (string, error) {
+	if answer, ok := getAnswer(label); ok {
+		if addNoneOfThese && answer == "" {
+			return "", nil
+		}
+		_, value, err := getChoice(label, answer, values)
+		return value, err
+	}
+	if NonInteractive {
+		return "", missingAnswer(label)
+	}
 	valueLabels := []string{}
 	for valueLabel, _ := range values {
 		valueLabels = append(valueLabels, valueLabel)
@@ -21,11 +33,7 @@ func PromptForValue(label string, values map[string]string, addNoneOfThese bool)
 		valueLabels = append(valueLabels, PromptNoneOfTheseOption)
 	}
 
-	prompt := promptui.Select{
-		Label: label,
-		Items: valueLabels,
-	}
-	_, result, err := prompt.Run()
+	result, err := selectValue(label, valueLabels, -1)
 	if err != nil {
 		return "", err
 	}
@@ -36,7 +44,53 @@ func PromptForValue(label string, values map[string]string, addNoneOfThese bool)
 	return values[result], nil
 }
 
+// PromptForValueWithDefault prompts for a value, with the cursor
+// on the label of the default value (if it is one of the values)
+func PromptForValueWithDefault(label string, values map[string]string, defaultValue string) (string, error) {
+	if answer, ok := getAnswer(label); ok {
+		_, value, err := getChoice(label, answer, values)
+		return value, err
+	}
+	if NonInteractive {
+		if defaultValue == "" {
+			return "", missingAnswer(label)
+		}
+		return defaultValue, nil
+	}
+	valueLabels := []string{}
+	for valueLabel, _ := range values {
+		valueLabels = append(valueLabels, valueLabel)
+	}
+	sort.Strings(valueLabels)
+
+	defaultIndex := -1
+	for i, valueLabel := range valueLabels {
+		if values[valueLabel] == defaultValue {
+			defaultIndex = i
+			break
+		}
+	}
+	result, err := selectValue(label, valueLabels, defaultIndex)
+	if err != nil {
+		return "", err
+	}
+	return values[result], nil
+}
+
+// PromptToConfirm asks a yes/no question; in non-interactive mode,
+// questions that do not have an answer are answered with no
 func PromptToConfirm(label string) bool {
+	if answer, ok := getAnswer(label); ok {
+		confirmed, err := strconv.ParseBool(answer)
+		return err == nil && confirmed
+	}
+	if NonInteractive {
+		fmt.Println(fmt.Sprintf("⏭   Skipping: %s (answer it with: --set %s=true)", label, AnswerKey(label)))
+		return false
+	}
+	if Accessible {
+		return promptForYesOrNo(label)
+	}
 	prompt := promptui.Prompt{
 		Label:     label,
 		IsConfirm: true,
@@ -53,18 +107,30 @@ func PromptToConfirm(label string) bool {
 	return false
 }
 
+// PromptToContinue waits for enter to be pressed, e.g. between the steps
+// of a walkthrough; in non-interactive mode, it does not wait
+func PromptToContinue() {
+	if NonInteractive {
+		return
+	}
+	fmt.Print("Press enter to continue...")
+	readLine()
+}
+
 func PromptForKeyValue(label string, values map[string]string) (string, string, error) {
+	if answer, ok := getAnswer(label); ok {
+		return getChoice(label, answer, values)
+	}
+	if NonInteractive {
+		return "", "", missingAnswer(label)
+	}
 	valueLabels := []string{}
 	for valueLabel, _ := range values {
 		valueLabels = append(valueLabels, valueLabel)
 	}
 	sort.Strings(valueLabels)
 
-	prompt := promptui.Select{
-		Label: label,
-		Items: valueLabels,
-	}
-	_, result, err := prompt.Run()
+	result, err := selectValue(label, valueLabels, -1)
 	if err != nil {
 		return "", "", err
 	}
@@ -72,6 +138,15 @@ func PromptForKeyValue(label string, values map[string]string) (string, string,
 }
 
 func PromptForString(label string) (string, error) {
+	if answer, ok := getAnswer(label); ok {
+		return answer, nil
+	}
+	if NonInteractive {
+		return "", missingAnswer(label)
+	}
+	if Accessible {
+		return promptForLine(label, "", nil)
+	}
 	prompt := promptui.Prompt{
 		Label: label,
 	}
@@ -82,3 +157,77 @@ func PromptForString(label string) (string, error) {
 	}
 	return result, nil
 }
+
+// PromptForStringWithDefault prompts for a string, which is validated as
+// it is typed; in non-interactive mode, the default value is used
+func PromptForStringWithDefault(label string, defaultValue string, validate func(string) error) (string, error) {
+	if answer, ok := getAnswer(label); ok {
+		return answer, nil
+	}
+	if NonInteractive {
+		if defaultValue == "" {
+			return "", missingAnswer(label)
+		}
+		return defaultValue, nil
+	}
+	if Accessible {
+		return promptForLine(label, defaultValue, validate)
+	}
+
+	prompt := promptui.Prompt{
+		Label:    label,
+		Default:  defaultValue,
+		Validate: validate,
+	}
+	result, err := prompt.Run()
+	if err != nil {
+		return "", err
+	}
+	return result, nil
+}
+
+// PromptForSecret prompts for a secret, which is masked as it is typed; in
+// non-interactive mode, the default value (if there is one) is used
+func PromptForSecret(label string, defaultValue string) (string, error) {
+	if answer, ok := getAnswer(label); ok {
+		return answer, nil
+	}
+	if NonInteractive {
+		if defaultValue == "" {
+			return "", missingAnswer(label)
+		}
+		return defaultValue, nil
+	}
+	if Accessible {
+		return promptForLine(label, defaultValue, nil)
+	}
+
+	prompt := promptui.Prompt{
+		Label:   label,
+		Default: defaultValue,
+		Mask:    '*',
+	}
+	result, err := prompt.Run()
+	if err != nil {
+		return "", err
+	}
+	return result, nil
+}
+
+// selectValue selects one of the labels, with the cursor on the default
+// label (if it is not -1), or from a numbered list in accessible mode
+func selectValue(label string, valueLabels []string, defaultIndex int) (string, error) {
+	if Accessible {
+		return promptForNumber(label, valueLabels, defaultIndex)
+	}
+	prompt := promptui.Select{
+		Label: label,
+		Items: valueLabels,
+	}
+	if defaultIndex >= 0 {
+		prompt.CursorPos = defaultIndex
+		prompt.Size = 10
+	}
+	_, result, err := prompt.Run()
+	return result, err
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ReadOnly is set by the --read-only flag (or read_only: true in ~/.kettle.yaml, or
+// KETTLE_READ_ONLY); kettle then refuses to run the cloud commands that change
+// anything, so that auditors can inspect the resources that kettle manages
+var ReadOnly bool
+
+// ErrReadOnly is returned when a command that changes resources is run in read-only mode
+var ErrReadOnly = errors.New("kettle is in read-only mode")
+
+// readOnlyOperations are the aws cli's operations (and their prefixes, e.g.
+// describe-functions) and gcloud's commands that do not change anything
+var readOnlyOperations = []string{
+	"describe",
+	"get",
+	"list",
+	"head",
+	"lookup",
+	"search",
+	"filter",
+	"batch-get",
+	"query",
+	"scan",
+	"tail",
+	"wait",
+	"simulate",
+	"ls",
+	"cat",
+	"read",
+	"show",
+	"print-access-token",
+	"print-identity-token",
+}
+
+// readOnlyCommands are the commands that do not change anything, although
+// their operation's name does not say so
+var readOnlyCommands = []string{
+	"aws sts assume-role",
+	"aws logs start-query",
+	"aws logs stop-query",
+}
+
+// checkReadOnly returns ErrReadOnly if kettle is in read-only mode, and
+// the command could change the resources of a cloud (or a registry)
+func checkReadOnly(command string, args []string) error {
+	if !ReadOnly || isReadOnly(command, args) {
+		return nil
+	}
+	return fmt.Errorf("%w: refusing to run %s %s", ErrReadOnly, command, strings.Join(getPositionalArgs(args, 3), " "))
+}
+
+func isReadOnly(command string, args []string) bool {
+	positional := getPositionalArgs(args, len(args))
+	switch command {
+	case "aws":
+		if len(positional) < 2 {
+			return true
+		}
+		for _, readOnlyCommand := range readOnlyCommands {
+			if readOnlyCommand == fmt.Sprintf("aws %s %s", positional[0], positional[1]) {
+				return true
+			}
+		}
+		if positional[1] == "cp" || positional[1] == "sync" {
+			return isDownload(positional[2:])
+		}
+		return isReadOnlyOperation(positional[1])
+	case "gcloud", "gsutil", "bq":
+		for i, arg := range positional {
+			if arg == "cp" || arg == "rsync" {
+				return isDownload(positional[i+1:])
+			}
+			if isReadOnlyOperation(arg) {
+				return true
+			}
+		}
+		return len(positional) == 0
+	case "gh":
+		// e.g. gh secret list
+		return len(positional) < 2 || isReadOnlyOperation(positional[1])
+	case "docker":
+		return len(positional) == 0 || positional[0] != "push"
+	}
+	// Other commands (e.g. builds and package managers) run locally
+	return true
+}
+
+func isReadOnlyOperation(operation string) bool {
+	for _, readOnly := range readOnlyOperations {
+		if operation == readOnly || strings.HasPrefix(operation, readOnly+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// isDownload returns true if a copy's destination (its last path) is local
+func isDownload(paths []string) bool {
+	if len(paths) < 2 {
+		return false
+	}
+	destination := paths[len(paths)-1]
+	return !strings.Contains(destination, "://")
+}
+
+// getPositionalArgs returns up to count of the arguments that are not flags;
+// flags that are not set with = are assumed to take the next argument
+func getPositionalArgs(args []string, count int) []string {
+	positional := []string{}
+	for i := 0; i < len(args) && len(positional) < count; i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			positional = append(positional, arg)
+			continue
+		}
+		if !strings.Contains(arg, "=") && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			i++
+		}
+	}
+	return positional
+}
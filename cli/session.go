@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// Each run of kettle is recorded (its arguments, the commands that it ran, and
+// how they failed) in ~/.kettle/sessions, so that kettle report can bundle the
+// last run into a bug report; the values of arguments that look secret are
+// redacted before they are recorded, and commands' input is never recorded
+
+const (
+	lastSessionFileName = "last.json"
+	// sessionMaxCommands is how many commands are recorded; a run that runs
+	// more (e.g. a load test) keeps the last ones
+	sessionMaxCommands = 500
+	// sessionMaxStderr is how much of the end of a failed command's error output is recorded
+	sessionMaxStderr = 4000
+	redactedArg      = "********"
+)
+
+// secretArgNames matches the flags (and the keys of key=value arguments)
+// whose values may be secret, e.g. --environment, which has the function's
+// environment variables, or --secret-string
+var secretArgNames = regexp.MustCompile(`(?i)(secret|password|passwd|token|private[-_]?key|api[-_]?key|credential|environment|body|value)`)
+
+// answerFlags answer prompts, whose answers are recorded without their values
+var answerFlags = map[string]bool{
+	"--set": true,
+}
+
+// Session is the recording of a run of kettle
+
+type Session struct {
+	Version  string            `json:"version"`
+	Args     []string          `json:"args"`
+	Started  time.Time         `json:"started"`
+	Duration string            `json:"duration"`
+	Error    string            `json:"error,omitempty"`
+	Commands []*SessionCommand `json:"commands"`
+	// SkippedCommands is how many of the first commands are not recorded
+	SkippedCommands int `json:"skipped_commands,omitempty"`
+}
+
+// SessionCommand is a command that was run during a session, e.g. a cloud cli's
+
+type SessionCommand struct {
+	Command  string    `json:"command"`
+	Args     []string  `json:"args"`
+	Started  time.Time `json:"started"`
+	Duration string    `json:"duration"`
+	ExitCode int       `json:"exit_code"`
+	Error    string    `json:"error,omitempty"`
+}
+
+var (
+	session *Session
+	// Commands can be run concurrently (e.g. during a load test)
+	sessionLock sync.Mutex
+)
+
+// StartSession starts recording the run
+func StartSession(version string, args []string) {
+	sessionLock.Lock()
+	defer sessionLock.Unlock()
+	session = &Session{
+		Version:  version,
+		Args:     RedactArgs(args),
+		Started:  time.Now(),
+		Commands: []*SessionCommand{},
+	}
+}
+
+// RecordError records the error that the run failed with; commands
+// print their errors instead of returning them
+func RecordError(err error) {
+	sessionLock.Lock()
+	defer sessionLock.Unlock()
+	if session == nil || err == nil {
+		return
+	}
+	session.Error = err.Error()
+}
+
+// EndSession writes the recording of the run, if it was started, to ~/.kettle/sessions;
+// the recording is best effort, so it does not fail the run
+func EndSession(err error) {
+	RecordError(err)
+	sessionLock.Lock()
+	defer sessionLock.Unlock()
+	if session == nil {
+		return
+	}
+	session.Duration = time.Since(session.Started).Round(time.Millisecond).String()
+	sessionPath, pathErr := getLastSessionPath()
+	if pathErr != nil {
+		return
+	}
+	contents, jsonErr := json.MarshalIndent(session, "", "  ")
+	if jsonErr != nil {
+		return
+	}
+	if os.MkdirAll(path.Dir(sessionPath), 0700) != nil {
+		return
+	}
+	ioutil.WriteFile(sessionPath, contents, 0600)
+}
+
+// ReadLastSession returns the recording of the last run
+func ReadLastSession() (*Session, error) {
+	sessionPath, err := getLastSessionPath()
+	if err != nil {
+		return nil, err
+	}
+	contents, err := ioutil.ReadFile(sessionPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.New("no run of kettle has been recorded yet")
+		}
+		return nil, err
+	}
+	var last Session
+	if err := json.Unmarshal(contents, &last); err != nil {
+		return nil, err
+	}
+	return &last, nil
+}
+
+func getLastSessionPath() (string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(home, ".kettle", "sessions", lastSessionFileName), nil
+}
+
+// recordCommand starts recording a command, and returns the function
+// that records how it finished
+func recordCommand(command string, args []string) func(exitCode int, err error) {
+	start := time.Now()
+	return func(exitCode int, err error) {
+		sessionLock.Lock()
+		defer sessionLock.Unlock()
+		if session == nil {
+			return
+		}
+		recorded := &SessionCommand{
+			Command:  command,
+			Args:     RedactArgs(args),
+			Started:  start,
+			Duration: time.Since(start).Round(time.Millisecond).String(),
+			ExitCode: exitCode,
+		}
+		var commandErr *CommandError
+		switch {
+		case errors.As(err, &commandErr):
+			recorded.ExitCode = commandErr.ExitCode
+			recorded.Error = truncateStart(commandErr.Stderr, sessionMaxStderr)
+		case err != nil:
+			recorded.ExitCode = -1
+			recorded.Error = err.Error()
+		}
+		session.Commands = append(session.Commands, recorded)
+		if len(session.Commands) > sessionMaxCommands {
+			session.Commands = session.Commands[1:]
+			session.SkippedCommands++
+		}
+	}
+}
+
+// RedactArgs returns a command's arguments, with the values of the flags (and
+// key=value arguments) that may be secret, and the answers to prompts, redacted
+func RedactArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		redacted[i] = arg
+		if i > 0 && redactsNextArg(args[i-1]) && !strings.HasPrefix(arg, "-") {
+			key := strings.SplitN(arg, "=", 2)[0]
+			if answerFlags[args[i-1]] && key != arg {
+				redacted[i] = key + "=" + redactedArg
+			} else {
+				redacted[i] = redactedArg
+			}
+			continue
+		}
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		if answerFlags[parts[0]] {
+			redacted[i] = parts[0] + "=" + strings.SplitN(parts[1], "=", 2)[0] + "=" + redactedArg
+		} else if secretArgNames.MatchString(parts[0]) {
+			redacted[i] = parts[0] + "=" + redactedArg
+		}
+	}
+	return redacted
+}
+
+// redactsNextArg returns true if the argument is a flag whose value
+// (the next argument) may be secret
+func redactsNextArg(arg string) bool {
+	if !strings.HasPrefix(arg, "-") || strings.Contains(arg, "=") {
+		return false
+	}
+	return answerFlags[arg] || secretArgNames.MatchString(arg)
+}
+
+// truncateStart returns the end of a string that is longer than the limit
+func truncateStart(value string, limit int) string {
+	if len(value) <= limit {
+		return value
+	}
+	return "..." + value[len(value)-limit:]
+}
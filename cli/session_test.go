@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRedactArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "no secrets",
+			args: []string{"kettle", "deploy", "--stage", "prod"},
+			want: []string{"kettle", "deploy", "--stage", "prod"},
+		},
+		{
+			name: "secret flag with its value in the next argument",
+			args: []string{"kettle", "secrets", "set", "--token", "abc123"},
+			want: []string{"kettle", "secrets", "set", "--token", redactedArg},
+		},
+		{
+			name: "secret flag with its value after an equals sign",
+			args: []string{"kettle", "login", "--password=hunter2"},
+			want: []string{"kettle", "login", "--password=" + redactedArg},
+		},
+		{
+			name: "secret key=value argument",
+			args: []string{"kettle", "env", "set", "API_KEY=abc123", "LOG_LEVEL=debug"},
+			want: []string{"kettle", "env", "set", "API_KEY=" + redactedArg, "LOG_LEVEL=debug"},
+		},
+		{
+			name: "answer in the next argument keeps its prompt",
+			args: []string{"kettle", "create", "--set", "name=users"},
+			want: []string{"kettle", "create", "--set", "name=" + redactedArg},
+		},
+		{
+			name: "answer after an equals sign keeps its prompt",
+			args: []string{"kettle", "create", "--set=name=users"},
+			want: []string{"kettle", "create", "--set=name=" + redactedArg},
+		},
+		{
+			name: "answer without a prompt",
+			args: []string{"kettle", "create", "--set", "users"},
+			want: []string{"kettle", "create", "--set", redactedArg},
+		},
+		{
+			name: "flag after a secret flag is kept",
+			args: []string{"kettle", "secrets", "set", "--token", "--yes"},
+			want: []string{"kettle", "secrets", "set", "--token", "--yes"},
+		},
+		{
+			name: "secret flag as the last argument",
+			args: []string{"kettle", "login", "--token"},
+			want: []string{"kettle", "login", "--token"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := RedactArgs(test.args)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("RedactArgs(%q) = %q, want %q", test.args, got, test.want)
+			}
+		})
+	}
+}
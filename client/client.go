@@ -0,0 +1,118 @@
+// Package client generates small typed clients for the endpoints of deployed
+// projects, so that the services that call them do not hand-roll HTTP calls
+package client
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"path"
+	"text/template"
+
+	"github.com/iancoleman/strcase"
+
+	"github.com/operatorai/kettle-cli/config"
+)
+
+// Languages that clients can be generated in
+const (
+	Python     = "python"
+	TypeScript = "typescript"
+)
+
+//go:embed templates
+var clientTemplates embed.FS
+
+// templateFiles are the templates of the clients, by language
+var templateFiles = map[string]string{
+	Python:     "templates/client.py.tmpl",
+	TypeScript: "templates/client.ts.tmpl",
+}
+
+// fileExtensions are the extensions of the clients' files, by language
+var fileExtensions = map[string]string{
+	Python:     ".py",
+	TypeScript: ".ts",
+}
+
+// clientValues are the values of a client's template
+type clientValues struct {
+	ProjectName string
+	ClassName   string
+	URL         string
+	Region      string
+	Method      string
+	Path        string
+	// The model of the request body (the first model), and the models that it has
+	Models  []*model
+	Request string
+	// How requests are authenticated: none, api_key, aws_iam, or identity_token
+	Auth string
+}
+
+// Generate returns the client of a project's endpoint in a language, and the
+// name of its file; the request's path, method, and body are the project's
+// smoke test, whose body is the example that the request model is inferred from
+func Generate(language string, cfg *config.Config, url, region string) ([]byte, string, error) {
+	templateFile, ok := templateFiles[language]
+	if !ok {
+		return nil, "", fmt.Errorf("unsupported language: %s (use %s or %s)", language, Python, TypeScript)
+	}
+	smokeTest := cfg.GetSmokeTest()
+	className := strcase.ToCamel(cfg.ProjectName)
+	values := &clientValues{
+		ProjectName: cfg.ProjectName,
+		ClassName:   className + "Client",
+		URL:         url,
+		Region:      region,
+		Method:      smokeTest.Method,
+		Path:        smokeTest.Path,
+		Auth:        getAuth(cfg),
+	}
+	models, err := inferModels(className+"Request", smokeTest.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	values.Models = models
+	if len(models) > 0 {
+		values.Request = models[0].Name
+	}
+
+	tmpl, err := template.New("client").Funcs(template.FuncMap{
+		"reverse": reverse,
+	}).ParseFS(clientTemplates, templateFile)
+	if err != nil {
+		return nil, "", err
+	}
+	var b bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&b, path.Base(templateFile), values); err != nil {
+		return nil, "", err
+	}
+	fileName := strcase.ToSnake(cfg.ProjectName) + "_client" + fileExtensions[language]
+	if language == TypeScript {
+		fileName = strcase.ToLowerCamel(cfg.ProjectName) + "Client" + fileExtensions[language]
+	}
+	return b.Bytes(), fileName, nil
+}
+
+func getAuth(cfg *config.Config) string {
+	switch {
+	case cfg.Config.Auth == config.AuthAPIKey:
+		return "api_key"
+	case cfg.Config.Auth == config.AuthIAM && cfg.Config.CloudProvider == "aws":
+		return "aws_iam"
+	case cfg.Config.Auth == config.AuthIAM:
+		return "identity_token"
+	}
+	return "none"
+}
+
+// reverse returns the models with the models that they contain first, as
+// Python needs types to be defined before they are used
+func reverse(models []*model) []*model {
+	reversed := make([]*model, len(models))
+	for i, m := range models {
+		reversed[len(models)-1-i] = m
+	}
+	return reversed
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/iancoleman/strcase"
+)
+
+// model is a type of the client's requests or responses, inferred
+// from an example (e.g. the project's smoke test body)
+
+type model struct {
+	Name   string
+	Fields []*field
+}
+
+type field struct {
+	Name string
+	// The field's type in each language
+	Python     string
+	TypeScript string
+}
+
+// inferModels returns the models of an example JSON object: the named model
+// of the object, followed by the models of any objects that it contains
+func inferModels(name, example string) ([]*model, error) {
+	if example == "" {
+		return nil, nil
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(example), &value); err != nil {
+		return nil, fmt.Errorf("the smoke test body is not JSON: %s", err)
+	}
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	models := []*model{}
+	addModel(name, object, &models)
+	return models, nil
+}
+
+func addModel(name string, object map[string]interface{}, models *[]*model) {
+	m := &model{Name: name}
+	*models = append(*models, m)
+	keys := []string{}
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		python, typeScript := inferType(name+strcase.ToCamel(key), object[key], models)
+		m.Fields = append(m.Fields, &field{
+			Name:       key,
+			Python:     python,
+			TypeScript: typeScript,
+		})
+	}
+}
+
+// inferType returns the Python and TypeScript types of a JSON value; objects
+// are added as models, named after the field that they are in
+func inferType(name string, value interface{}, models *[]*model) (string, string) {
+	switch v := value.(type) {
+	case string:
+		return "str", "string"
+	case bool:
+		return "bool", "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "int", "number"
+		}
+		return "float", "number"
+	case []interface{}:
+		if len(v) == 0 {
+			return "List[Any]", "unknown[]"
+		}
+		python, typeScript := inferType(name+"Item", v[0], models)
+		return "List[" + python + "]", typeScript + "[]"
+	case map[string]interface{}:
+		addModel(name, v, models)
+		return name, name
+	}
+	return "Optional[Any]", "unknown"
+}
@@ -4,9 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"os/exec"
+	"time"
 
 	"github.com/operatorai/kettle-cli/clouds/aws"
+	"github.com/operatorai/kettle-cli/config"
 	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
 )
 
 type AmazonWebServices struct{}
@@ -15,23 +18,131 @@ func (AmazonWebServices) GetService(deploymentType string) (Service, error) {
 	switch deploymentType {
 	case "lambda":
 		return aws.AWSLambdaFunction{}, nil
+	case "sagemaker":
+		return aws.AWSSageMakerEndpoint{}, nil
 	}
 	return nil, errors.New(fmt.Sprintf("unimplemented service: %s", deploymentType))
 }
 
+func (AmazonWebServices) GetDeploymentTypes() []string {
+	return []string{"lambda", "sagemaker"}
+}
+
 func (AmazonWebServices) Setup(stg *settings.Settings) error {
-	_, err := exec.LookPath("aws")
-	if err != nil {
-		return errors.New(fmt.Sprintf("please install the aws cli: %s", err))
+	// Deploys call the AWS APIs with the SDK, unless the aws cli is chosen
+	if stg.AWSCli {
+		settings.AWSCli = true
+	}
+	if settings.AWSCli {
+		_, err := exec.LookPath("aws")
+		if err != nil {
+			return errors.New(fmt.Sprintf("please install the aws cli: %s", err))
+		}
 	}
 	if stg.AWS == nil {
 		stg.AWS = &settings.AWSSettings{}
 	}
+	aws.UseEndpoints(stg.AWS.Endpoints)
+	aws.UseRegion(stg.AWS.DeploymentRegion)
 	if err := aws.SetAccountID(stg.AWS); err != nil {
 		return err
 	}
-	if err := aws.SetDeploymentRegion(stg.AWS); err != nil {
-		return err
-	}
 	return nil
 }
+
+func (AmazonWebServices) GetAccount(stg *settings.Settings) (string, error) {
+	return aws.GetAccount(stg.AWS)
+}
+
+func (AmazonWebServices) UseStageAccount(stage *config.Stage, stg *settings.Settings) error {
+	return aws.UseStageAccount(stage, stg)
+}
+
+func (AmazonWebServices) GetRegions(cfg *config.Config) (map[string]string, error) {
+	return aws.GetRegions(cfg)
+}
+
+func (AmazonWebServices) GetRegion(stg *settings.Settings) string {
+	return stg.AWS.DeploymentRegion
+}
+
+func (AmazonWebServices) SetRegion(stg *settings.Settings, region string) {
+	stg.AWS.DeploymentRegion = region
+	aws.UseRegion(region)
+}
+
+func (AmazonWebServices) DeployStaticSite(directory string, cfg *config.Config, stg *settings.Settings) error {
+	return aws.DeployStaticSite(directory, cfg, stg)
+}
+
+func (AmazonWebServices) ProvisionAddOns(directory string, cfg *config.Config, stg *settings.Settings) (map[string]string, error) {
+	return aws.ProvisionAddOns(directory, cfg, stg)
+}
+
+func (AmazonWebServices) SyncSecrets(directory string, cfg *config.Config, stg *settings.Settings, secrets map[string]string) (map[string]string, error) {
+	return aws.SyncSecrets(directory, cfg, stg, secrets)
+}
+
+func (AmazonWebServices) GetSecretStore() string {
+	return "Secrets Manager"
+}
+
+func (AmazonWebServices) DeployCanary(directory string, cfg *config.Config, stg *settings.Settings, url string) error {
+	return aws.DeployCanary(directory, cfg, stg, url)
+}
+
+func (AmazonWebServices) SetBudget(directory string, cfg *config.Config, stg *settings.Settings) error {
+	return aws.SetBudget(directory, cfg, stg)
+}
+
+func (AmazonWebServices) GetMonthToDateSpend(cfg *config.Config, stg *settings.Settings) (float64, string, error) {
+	return aws.GetMonthToDateSpend(cfg, stg)
+}
+
+func (AmazonWebServices) GetCosts(projectName string, start, end time.Time) ([]*config.Cost, error) {
+	return aws.GetCosts(projectName, start, end)
+}
+
+func (AmazonWebServices) PullState(directory string, cfg *config.Config, stg *settings.Settings) error {
+	return aws.PullState(directory, cfg, stg)
+}
+
+func (AmazonWebServices) PushState(directory string, cfg *config.Config, stg *settings.Settings) error {
+	return aws.PushState(directory, cfg, stg)
+}
+
+func (AmazonWebServices) Lock(cfg *config.Config, stg *settings.Settings) error {
+	return aws.Lock(cfg, stg)
+}
+
+func (AmazonWebServices) Unlock(cfg *config.Config, stg *settings.Settings) error {
+	return aws.Unlock(cfg, stg)
+}
+
+func (AmazonWebServices) GetDeployPolicy(cfg *config.Config, stg *settings.Settings) ([]byte, error) {
+	return aws.GetDeployPolicy(cfg, stg)
+}
+
+func (AmazonWebServices) CreateDeployRole(cfg *config.Config, stg *settings.Settings, repository string, policy []byte) (string, error) {
+	return aws.CreateDeployRole(cfg, stg, repository, policy)
+}
+
+func (AmazonWebServices) BootstrapAccount(cfg *config.Config, stg *settings.Settings) error {
+	return aws.BootstrapAccount(cfg, stg)
+}
+
+func (AmazonWebServices) GetCommands() []string {
+	return []string{"aws"}
+}
+
+func (AmazonWebServices) GetCommandEnvironment(cfg *config.Config, stg *settings.Settings) map[string]string {
+	return aws.GetCommandEnvironment(cfg, stg)
+}
+
+func (AmazonWebServices) GetApiRoutes(apiName string, stg *settings.Settings) (string, []*config.ApiRoute, error) {
+	return aws.GetApiRoutes(apiName, stg)
+}
+
+func (AmazonWebServices) ExplainResource(resource *state.Resource, stg *settings.Settings) *config.Explanation {
+	return aws.ExplainResource(resource, stg)
+}
@@ -0,0 +1,238 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+// tagPolicyName is the name of the Organizations tag policy that
+// standardises the tags that kettle adds to resources
+const tagPolicyName = "kettle-tags"
+
+// organizationsUnavailable are the errors of Organizations when the account
+// is not in an organization, or is not its management account
+var organizationsUnavailable = []string{
+	"AWSOrganizationsNotInUseException",
+	"AccessDeniedException",
+	"PolicyTypeNotEnabledException",
+}
+
+// BootstrapAccount prepares the account that a project's stage is deployed to:
+// the buckets of its artifact store and state backend (versioned, private, and
+// encrypted), the lock table, the execution role, and the tag policy and cost
+// allocation tags of kettle's tags. Existing resources are left as they are
+func BootstrapAccount(cfg *config.Config, stg *settings.Settings) error {
+	if err := SetAccountID(stg.AWS); err != nil {
+		return err
+	}
+	if cfg.Config.Artifacts != nil {
+		if bucket := getBucketName(cfg.Config.Artifacts.URI); bucket != "" {
+			if err := createVersionedBucket(bucket, stg); err != nil {
+				return err
+			}
+			fmt.Println("✅  Artifact bucket: ", bucket)
+		}
+		if repository := getECRRepositoryName(cfg); repository != "" {
+			if err := createArtifactRepository(repository); err != nil {
+				return err
+			}
+			fmt.Println("✅  Artifact repository: ", repository)
+		}
+	}
+	if cfg.Config.StateBackend != nil {
+		if err := createVersionedBucket(cfg.Config.StateBackend.Bucket, stg); err != nil {
+			return err
+		}
+		fmt.Println("✅  State bucket: ", cfg.Config.StateBackend.Bucket)
+		if err := createLockTable(cfg.LockTable()); err != nil {
+			return err
+		}
+		fmt.Println("✅  Lock table: ", cfg.LockTable())
+	}
+
+	role := lambdaExecutionRole
+	if cfg.Config.DeploymentType == "sagemaker" {
+		role = sagemakerExecutionRole
+	}
+	roleArn, err := getOrCreateExecutionRole(role, stg.AWS.DeploymentRegion)
+	if err != nil {
+		return err
+	}
+	fmt.Println("✅  Execution role: ", roleArn)
+
+	if err := putTagPolicy(stg); err != nil {
+		return err
+	}
+	// Tags can only be activated once AWS has seen them on a resource
+	for _, tag := range []string{config.ProjectTag, config.StageTag} {
+		err := cli.Execute("aws", []string{
+			"ce",
+			"update-cost-allocation-tags-status",
+			"--cost-allocation-tags-status", fmt.Sprintf("TagKey=%s,Status=Active", tag),
+		}, fmt.Sprintf("Activating the %s cost allocation tag", tag))
+		if err != nil && settings.DebugMode {
+			fmt.Println(err.Error())
+		}
+	}
+	return nil
+}
+
+// getBucketName returns the bucket of an s3:// uri, or an
+// empty string if the uri is not in S3
+func getBucketName(uri string) string {
+	if !strings.HasPrefix(uri, "s3://") {
+		return ""
+	}
+	return strings.SplitN(strings.TrimPrefix(uri, "s3://"), "/", 2)[0]
+}
+
+// getECRRepositoryName returns the ECR repository that the project's images are
+// pushed to, if the artifact store's registry is in ECR in the current account
+func getECRRepositoryName(cfg *config.Config) string {
+	parts := strings.SplitN(strings.TrimSuffix(cfg.Config.Artifacts.Registry, "/"), "/", 2)
+	if !strings.Contains(parts[0], ".dkr.ecr.") {
+		return ""
+	}
+	if len(parts) == 1 {
+		return cfg.GetBaseProjectName()
+	}
+	return fmt.Sprintf("%s/%s", parts[1], cfg.GetBaseProjectName())
+}
+
+// createVersionedBucket creates a private, encrypted S3 bucket,
+// which keeps the previous versions of its objects
+func createVersionedBucket(bucket string, stg *settings.Settings) error {
+	if err := createBucket(bucket, stg); err != nil {
+		return err
+	}
+	if err := protectBucket(bucket); err != nil {
+		return err
+	}
+	return cli.Execute("aws", []string{
+		"s3api",
+		"put-bucket-versioning",
+		"--bucket", bucket,
+		"--versioning-configuration", "Status=Enabled",
+	}, "Enabling bucket versioning")
+}
+
+// createArtifactRepository creates an ECR repository whose tags cannot be
+// overwritten, as the versions of artifacts are immutable
+func createArtifactRepository(repository string) error {
+	_, err := cli.ExecuteWithResult("aws", []string{
+		"ecr",
+		"describe-repositories",
+		"--repository-names", repository,
+	}, "Looking for the ECR repository")
+	if err == nil || !cli.IsNotFound(err) {
+		return err
+	}
+	return cli.Execute("aws", []string{
+		"ecr",
+		"create-repository",
+		"--repository-name", repository,
+		"--image-tag-mutability", "IMMUTABLE",
+		"--image-scanning-configuration", "scanOnPush=true",
+	}, fmt.Sprintf("Creating an ECR repository called: %s", repository))
+}
+
+// getOrCreateExecutionRole returns the ARN of kettle's execution role,
+// and creates it if it does not exist
+func getOrCreateExecutionRole(role *executionRole, region string) (string, error) {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"iam",
+		"get-role",
+		"--role-name", role.name,
+		"--query", "Role.Arn",
+		"--output", "text",
+	}, fmt.Sprintf("Looking for the IAM role: %s", role.name))
+	if err == nil {
+		return strings.TrimSpace(string(output)), nil
+	}
+	if !cli.IsNotFound(err) {
+		return "", err
+	}
+	return createExecutionRole(role, region)
+}
+
+// putTagPolicy creates the organization's kettle-tags policy, which fixes the
+// case of kettle's tag keys, and attaches it to the account; this needs the
+// organization's management account, so it is skipped in other accounts
+func putTagPolicy(stg *settings.Settings) error {
+	tags := map[string]interface{}{}
+	for _, tag := range []string{config.ProjectTag, config.StageTag, config.ExpiryTag} {
+		tags[tag] = map[string]interface{}{
+			"tag_key": map[string]string{"@@assign": tag},
+		}
+	}
+	content, err := json.Marshal(map[string]interface{}{"tags": tags})
+	if err != nil {
+		return err
+	}
+
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"organizations",
+		"list-policies",
+		"--filter", "TAG_POLICY",
+		"--query", fmt.Sprintf("Policies[?Name=='%s'].Id", tagPolicyName),
+		"--output", "text",
+	}, "Looking for the tag policy")
+	if skipTagPolicy(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	policyID := strings.TrimSpace(string(output))
+	if policyID == "" || policyID == "None" {
+		output, err = cli.ExecuteWithResult("aws", []string{
+			"organizations",
+			"create-policy",
+			"--name", tagPolicyName,
+			"--type", "TAG_POLICY",
+			"--description", "The tags that kettle adds to the resources of projects",
+			"--content", string(content),
+			"--query", "Policy.PolicySummary.Id",
+			"--output", "text",
+		}, fmt.Sprintf("Creating a tag policy called: %s", tagPolicyName))
+		if skipTagPolicy(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		policyID = strings.TrimSpace(string(output))
+	}
+
+	err = cli.Execute("aws", []string{
+		"organizations",
+		"attach-policy",
+		"--policy-id", policyID,
+		"--target-id", stg.AWS.AccountID,
+	}, "Attaching the tag policy to the account")
+	if err != nil && !cli.HasErrorCode(err, "DuplicatePolicyAttachmentException") {
+		if skipTagPolicy(err) {
+			return nil
+		}
+		return err
+	}
+	fmt.Println("✅  Tag policy: ", tagPolicyName)
+	return nil
+}
+
+// skipTagPolicy returns true (and says why) if the tag policy
+// cannot be managed from the current account
+func skipTagPolicy(err error) bool {
+	for _, code := range organizationsUnavailable {
+		if cli.HasErrorCode(err, code) {
+			fmt.Println("⏭  Tag policy: skipped (it is managed from the organization's management account)")
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,486 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/iancoleman/strcase"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+const (
+	defaultAuroraEngine   = "aurora-postgresql"
+	auroraMasterUsername  = "kettle"
+	auroraScalingCapacity = "MinCapacity=0.5,MaxCapacity=4"
+	redisPort             = 6379
+	cachePollInterval     = 10 * time.Second
+)
+
+// ProvisionAddOns creates any add-ons that do not exist yet, grants the execution
+// role access to them, and returns their connection details as environment variables
+func ProvisionAddOns(directory string, cfg *config.Config, stg *settings.Settings) (map[string]string, error) {
+	environment := map[string]string{}
+	if len(cfg.Config.AddOns) == 0 {
+		return environment, nil
+	}
+	if err := SetAccountID(stg.AWS); err != nil {
+		return nil, err
+	}
+	if err := adoptExistingResources(directory, cfg, stg); err != nil {
+		return nil, err
+	}
+	if err := setExecutionRole(stg); err != nil {
+		return nil, err
+	}
+
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return nil, err
+	}
+	for _, addOn := range cfg.Config.AddOns {
+		fmt.Println("🧩  Add-on: ", addOn.Name, fmt.Sprintf("(%s)", addOn.Type))
+		prefix := addOn.EnvironmentPrefix()
+		resourceName := fmt.Sprintf("%s-%s", cfg.ProjectName, strcase.ToKebab(addOn.Name))
+		if addOn.Existing != "" && addOn.Type != "bucket" {
+			return nil, fmt.Errorf("existing resources are only supported for bucket add-ons, not: %s", addOn.Type)
+		}
+		switch addOn.Type {
+		case "dynamodb":
+			err = provisionDynamoDBTable(resourceName, prefix, addOn, stg, st, environment)
+		case "aurora":
+			err = provisionAuroraCluster(resourceName, prefix, addOn, stg, st, environment)
+		case "redis":
+			err = provisionRedisCache(resourceName, prefix, cfg, stg, st, environment)
+		case "bucket":
+			// Bucket names are global, so they include the account ID
+			bucket := fmt.Sprintf("%s-%s", resourceName, stg.AWS.AccountID)
+			if addOn.Existing != "" {
+				err = adoptBucket(addOn.Existing, prefix, stg, st, environment)
+			} else {
+				err = provisionBucket(bucket, prefix, addOn, stg, st, environment)
+			}
+		default:
+			err = fmt.Errorf("unsupported add-on on aws: %s", addOn.Type)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := state.WriteState(directory, st); err != nil {
+			return nil, err
+		}
+	}
+	return environment, nil
+}
+
+func provisionDynamoDBTable(tableName, prefix string, addOn *config.AddOn, stg *settings.Settings, st *state.State, environment map[string]string) error {
+	tableArn, err := getDynamoDBTableArn(tableName)
+	if err != nil {
+		return err
+	}
+	if tableArn == "" {
+		partitionKey := addOn.PartitionKey
+		if partitionKey == "" {
+			partitionKey = "id"
+		}
+		attributes := []string{fmt.Sprintf("AttributeName=%s,AttributeType=S", partitionKey)}
+		keys := []string{fmt.Sprintf("AttributeName=%s,KeyType=HASH", partitionKey)}
+		if addOn.SortKey != "" {
+			attributes = append(attributes, fmt.Sprintf("AttributeName=%s,AttributeType=S", addOn.SortKey))
+			keys = append(keys, fmt.Sprintf("AttributeName=%s,KeyType=RANGE", addOn.SortKey))
+		}
+
+		args := []string{
+			"dynamodb",
+			"create-table",
+			"--table-name", tableName,
+			"--billing-mode", "PAY_PER_REQUEST",
+			"--attribute-definitions",
+		}
+		args = append(args, attributes...)
+		args = append(args, "--key-schema")
+		args = append(args, keys...)
+		if err := cli.Execute("aws", args, fmt.Sprintf("Creating a DynamoDB table called: %s", tableName)); err != nil {
+			return err
+		}
+		err := cli.Execute("aws", []string{
+			"dynamodb",
+			"wait",
+			"table-exists",
+			"--table-name", tableName,
+		}, "Waiting for the DynamoDB table to be created")
+		if err != nil {
+			return err
+		}
+		tableArn, err = getDynamoDBTableArn(tableName)
+		if err != nil {
+			return err
+		}
+	}
+	st.AddResource(state.AWSDynamoDBTable, tableName, tableArn)
+
+	err = putRolePolicy(stg, tableName, st, []map[string]interface{}{
+		{
+			"Effect":   "Allow",
+			"Action":   "dynamodb:*",
+			"Resource": []string{tableArn, fmt.Sprintf("%s/index/*", tableArn)},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	environment[fmt.Sprintf("%s_TABLE", prefix)] = tableName
+	return nil
+}
+
+func getDynamoDBTableArn(tableName string) (string, error) {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"dynamodb",
+		"describe-table",
+		"--table-name", tableName,
+		"--output", "json",
+	}, "Looking for DynamoDB table")
+	if err != nil {
+		if cli.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	var result struct {
+		Table struct {
+			TableArn string `json:"TableArn"`
+		} `json:"Table"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return "", err
+	}
+	return result.Table.TableArn, nil
+}
+
+// provisionAuroraCluster creates an Aurora Serverless v2 cluster with the Data API enabled,
+// so that functions can query it without being placed in the cluster's VPC
+func provisionAuroraCluster(clusterID, prefix string, addOn *config.AddOn, stg *settings.Settings, st *state.State, environment map[string]string) error {
+	engine := addOn.Engine
+	if engine == "" {
+		engine = defaultAuroraEngine
+	}
+	databaseName := strcase.ToSnake(addOn.Name)
+
+	clusterArn, secretArn, err := getAuroraCluster(clusterID)
+	if err != nil {
+		return err
+	}
+	if clusterArn == "" {
+		err := cli.Execute("aws", []string{
+			"rds",
+			"create-db-cluster",
+			"--db-cluster-identifier", clusterID,
+			"--engine", engine,
+			"--database-name", databaseName,
+			"--master-username", auroraMasterUsername,
+			"--manage-master-user-password",
+			"--serverless-v2-scaling-configuration", auroraScalingCapacity,
+			"--enable-http-endpoint",
+		}, fmt.Sprintf("Creating an Aurora cluster called: %s", clusterID))
+		if err != nil {
+			return err
+		}
+		instanceID := fmt.Sprintf("%s-instance", clusterID)
+		err = cli.Execute("aws", []string{
+			"rds",
+			"create-db-instance",
+			"--db-instance-identifier", instanceID,
+			"--db-cluster-identifier", clusterID,
+			"--engine", engine,
+			"--db-instance-class", "db.serverless",
+		}, "Creating the Aurora Serverless instance")
+		if err != nil {
+			return err
+		}
+		st.AddResource(state.AWSAuroraInstance, instanceID, "")
+		err = cli.Execute("aws", []string{
+			"rds",
+			"wait",
+			"db-instance-available",
+			"--db-instance-identifier", instanceID,
+		}, "Waiting for the Aurora instance to be available")
+		if err != nil {
+			return err
+		}
+		clusterArn, secretArn, err = getAuroraCluster(clusterID)
+		if err != nil {
+			return err
+		}
+	}
+	st.AddResource(state.AWSAuroraCluster, clusterID, clusterArn)
+
+	err = putRolePolicy(stg, clusterID, st, []map[string]interface{}{
+		{
+			"Effect":   "Allow",
+			"Action":   "rds-data:*",
+			"Resource": clusterArn,
+		},
+		{
+			"Effect":   "Allow",
+			"Action":   "secretsmanager:GetSecretValue",
+			"Resource": secretArn,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	environment[fmt.Sprintf("%s_CLUSTER_ARN", prefix)] = clusterArn
+	environment[fmt.Sprintf("%s_SECRET_ARN", prefix)] = secretArn
+	environment[fmt.Sprintf("%s_DATABASE", prefix)] = databaseName
+	return nil
+}
+
+func getAuroraCluster(clusterID string) (string, string, error) {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"rds",
+		"describe-db-clusters",
+		"--db-cluster-identifier", clusterID,
+		"--output", "json",
+	}, "Looking for Aurora cluster")
+	if err != nil {
+		if cli.IsNotFound(err) {
+			return "", "", nil
+		}
+		return "", "", err
+	}
+	var result struct {
+		DBClusters []struct {
+			DBClusterArn     string `json:"DBClusterArn"`
+			MasterUserSecret struct {
+				SecretArn string `json:"SecretArn"`
+			} `json:"MasterUserSecret"`
+		} `json:"DBClusters"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return "", "", err
+	}
+	if len(result.DBClusters) == 0 {
+		return "", "", nil
+	}
+	return result.DBClusters[0].DBClusterArn, result.DBClusters[0].MasterUserSecret.SecretArn, nil
+}
+
+// provisionBucket creates a private, encrypted S3 bucket; incomplete uploads
+// (and, optionally, old objects) are removed by a lifecycle rule
+func provisionBucket(bucket, prefix string, addOn *config.AddOn, stg *settings.Settings, st *state.State, environment map[string]string) error {
+	if err := createBucket(bucket, stg); err != nil {
+		return err
+	}
+	bucketArn := fmt.Sprintf("arn:%s:s3:::%s", getPartition(stg.AWS.DeploymentRegion), bucket)
+	st.AddResource(state.AWSS3Bucket, bucket, bucketArn)
+	if err := protectBucket(bucket); err != nil {
+		return err
+	}
+
+	rules := []interface{}{
+		map[string]interface{}{
+			"ID":     "abort-incomplete-uploads",
+			"Status": "Enabled",
+			"Filter": map[string]string{},
+			"AbortIncompleteMultipartUpload": map[string]int{
+				"DaysAfterInitiation": 7,
+			},
+		},
+	}
+	if addOn.ExpireAfter != 0 {
+		rules = append(rules, map[string]interface{}{
+			"ID":     "expire-objects",
+			"Status": "Enabled",
+			"Filter": map[string]string{},
+			"Expiration": map[string]int{
+				"Days": addOn.ExpireAfter,
+			},
+		})
+	}
+	lifecycle, err := json.Marshal(map[string]interface{}{
+		"Rules": rules,
+	})
+	if err != nil {
+		return err
+	}
+	err = cli.Execute("aws", []string{
+		"s3api",
+		"put-bucket-lifecycle-configuration",
+		"--bucket", bucket,
+		"--lifecycle-configuration", string(lifecycle),
+	}, "Setting bucket lifecycle rules")
+	if err != nil {
+		return err
+	}
+
+	return grantBucketAccess(bucket, prefix, stg, st, environment)
+}
+
+// protectBucket blocks public access to a bucket, and encrypts its objects at rest
+func protectBucket(bucket string) error {
+	err := cli.Execute("aws", []string{
+		"s3api",
+		"put-public-access-block",
+		"--bucket", bucket,
+		"--public-access-block-configuration",
+		"BlockPublicAcls=true,IgnorePublicAcls=true,BlockPublicPolicy=true,RestrictPublicBuckets=true",
+	}, "Blocking public access to the bucket")
+	if err != nil {
+		return err
+	}
+
+	encryption, err := json.Marshal(map[string]interface{}{
+		"Rules": []interface{}{
+			map[string]interface{}{
+				"ApplyServerSideEncryptionByDefault": map[string]string{
+					"SSEAlgorithm": "AES256",
+				},
+				"BucketKeyEnabled": true,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return cli.Execute("aws", []string{
+		"s3api",
+		"put-bucket-encryption",
+		"--bucket", bucket,
+		"--server-side-encryption-configuration", string(encryption),
+	}, "Enabling bucket encryption")
+}
+
+// adoptBucket grants the execution role access to an existing bucket; its
+// configuration (e.g. encryption and lifecycle rules) is left as it is
+func adoptBucket(bucket, prefix string, stg *settings.Settings, st *state.State, environment map[string]string) error {
+	if err := validateExistingBucket(bucket, stg); err != nil {
+		return err
+	}
+	st.AddResource(state.AWSS3Bucket, bucket, fmt.Sprintf("arn:%s:s3:::%s", getPartition(stg.AWS.DeploymentRegion), bucket)).Adopted = true
+	return grantBucketAccess(bucket, prefix, stg, st, environment)
+}
+
+func grantBucketAccess(bucket, prefix string, stg *settings.Settings, st *state.State, environment map[string]string) error {
+	bucketArn := fmt.Sprintf("arn:%s:s3:::%s", getPartition(stg.AWS.DeploymentRegion), bucket)
+	err := putRolePolicy(stg, bucket, st, []map[string]interface{}{
+		{
+			"Effect":   "Allow",
+			"Action":   "s3:ListBucket",
+			"Resource": bucketArn,
+		},
+		{
+			"Effect":   "Allow",
+			"Action":   []string{"s3:GetObject", "s3:PutObject", "s3:DeleteObject"},
+			"Resource": fmt.Sprintf("%s/*", bucketArn),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	environment[fmt.Sprintf("%s_BUCKET", prefix)] = bucket
+	return nil
+}
+
+// provisionRedisCache creates an ElastiCache Serverless cache in the default VPC,
+// and places the function in the same VPC so that it can reach the cache
+func provisionRedisCache(cacheName, prefix string, cfg *config.Config, stg *settings.Settings, st *state.State, environment map[string]string) error {
+	if err := setVpcConfig(cfg, stg, st, redisPort); err != nil {
+		return err
+	}
+
+	status, endpoint, err := getRedisCache(cacheName)
+	if err != nil {
+		return err
+	}
+	if status == "" {
+		args := []string{
+			"elasticache",
+			"create-serverless-cache",
+			"--serverless-cache-name", cacheName,
+			"--engine", "redis",
+			"--subnet-ids",
+		}
+		args = append(args, cfg.Config.AWS.SubnetIDs...)
+		args = append(args, "--security-group-ids")
+		args = append(args, cfg.Config.AWS.SecurityGroupIDs...)
+		if err := cli.Execute("aws", args, fmt.Sprintf("Creating an ElastiCache cache called: %s", cacheName)); err != nil {
+			return err
+		}
+	}
+	st.AddResource(state.AWSElastiCache, cacheName, "")
+
+	// There is no cli waiter for serverless caches
+	for status != "available" {
+		time.Sleep(cachePollInterval)
+		status, endpoint, err = getRedisCache(cacheName)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Serverless caches only accept TLS connections
+	environment[fmt.Sprintf("%s_HOST", prefix)] = endpoint
+	environment[fmt.Sprintf("%s_PORT", prefix)] = fmt.Sprintf("%d", redisPort)
+	environment[fmt.Sprintf("%s_URL", prefix)] = fmt.Sprintf("rediss://%s:%d", endpoint, redisPort)
+	return nil
+}
+
+func getRedisCache(cacheName string) (string, string, error) {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"elasticache",
+		"describe-serverless-caches",
+		"--serverless-cache-name", cacheName,
+		"--output", "json",
+	}, "Waiting for the ElastiCache cache to be available")
+	if err != nil {
+		if cli.IsNotFound(err) {
+			return "", "", nil
+		}
+		return "", "", err
+	}
+	var result struct {
+		ServerlessCaches []struct {
+			Status   string `json:"Status"`
+			Endpoint struct {
+				Address string `json:"Address"`
+			} `json:"Endpoint"`
+		} `json:"ServerlessCaches"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return "", "", err
+	}
+	if len(result.ServerlessCaches) == 0 {
+		return "", "", nil
+	}
+	return result.ServerlessCaches[0].Status, result.ServerlessCaches[0].Endpoint.Address, nil
+}
+
+// putRolePolicy adds an inline policy to the execution role, which
+// grants access to a single add-on
+func putRolePolicy(stg *settings.Settings, policyName string, st *state.State, statements []map[string]interface{}) error {
+	policy, err := json.Marshal(map[string]interface{}{
+		"Version":   "2012-10-17",
+		"Statement": statements,
+	})
+	if err != nil {
+		return err
+	}
+
+	roleName := roleNameFromArn(stg.AWS.RoleArn)
+	err = cli.Execute("aws", []string{
+		"iam",
+		"put-role-policy",
+		"--role-name", roleName,
+		"--policy-name", policyName,
+		"--policy-document", string(policy),
+	}, "Granting the execution role access to the add-on")
+	if err != nil {
+		return err
+	}
+	st.AddResource(state.AWSIAMRolePolicy, fmt.Sprintf("%s/%s", roleName, policyName), "")
+	return nil
+}
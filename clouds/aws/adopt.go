@@ -0,0 +1,211 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+// Lambda's default timeout, in seconds
+const defaultLambdaTimeout = 3
+
+// adoptExistingResources uses the execution role and REST API that the project's
+// config names, instead of choosing (or creating) them, once they have been checked;
+// they are recorded in the state as adopted, so that they are never deleted, and
+// are only used by the project (they are not written to the settings)
+func adoptExistingResources(directory string, cfg *config.Config, stg *settings.Settings) error {
+	roleArn, restApiID := cfg.Config.AWS.RoleArn, cfg.Config.AWS.RestApiID
+	if roleArn == "" && restApiID == "" {
+		return nil
+	}
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return err
+	}
+	if roleArn != "" {
+		if err := validateExistingRole(roleArn); err != nil {
+			return err
+		}
+		stg.AWS.UseProjectRole(roleArn)
+		st.AddResource(state.AWSIAMRole, roleNameFromArn(roleArn), roleArn).Adopted = true
+	}
+	if restApiID != "" {
+		if err := validateExistingRestApi(restApiID); err != nil {
+			return err
+		}
+		stg.AWS.UseProjectRestApi(restApiID)
+		st.AddResource(state.AWSRestApi, restApiID, "").Adopted = true
+	}
+	return state.WriteState(directory, st)
+}
+
+// validateExistingRole checks that the role exists, and that Lambda can assume it
+func validateExistingRole(roleArn string) error {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"iam",
+		"get-role",
+		"--role-name", roleNameFromArn(roleArn),
+		"--output", "json",
+	}, "Checking the existing execution role")
+	if err != nil {
+		if cli.IsNotFound(err) {
+			return fmt.Errorf("the existing role does not exist: %s", roleArn)
+		}
+		return err
+	}
+	var result struct {
+		Role struct {
+			Arn                      string          `json:"Arn"`
+			AssumeRolePolicyDocument json.RawMessage `json:"AssumeRolePolicyDocument"`
+		} `json:"Role"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return err
+	}
+	if result.Role.Arn != roleArn {
+		return fmt.Errorf("the existing role's ARN is %s, not %s", result.Role.Arn, roleArn)
+	}
+	if !strings.Contains(string(result.Role.AssumeRolePolicyDocument), lambdaExecutionRole.service) {
+		return fmt.Errorf("the existing role %s cannot be assumed by Lambda (its trust policy does not allow %s)",
+			roleArn,
+			lambdaExecutionRole.service,
+		)
+	}
+	return nil
+}
+
+// validateExistingRestApi checks that the REST API exists, and is not private
+func validateExistingRestApi(restApiID string) error {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"apigateway",
+		"get-rest-api",
+		"--rest-api-id", restApiID,
+		"--output", "json",
+	}, "Checking the existing REST API")
+	if err != nil {
+		if cli.IsNotFound(err) {
+			return fmt.Errorf("the existing REST API does not exist: %s", restApiID)
+		}
+		return err
+	}
+	var result struct {
+		EndpointConfiguration struct {
+			Types []string `json:"types"`
+		} `json:"endpointConfiguration"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return err
+	}
+	for _, endpointType := range result.EndpointConfiguration.Types {
+		if endpointType == "PRIVATE" {
+			return fmt.Errorf("the existing REST API %s is private, so its endpoint would not be reachable", restApiID)
+		}
+	}
+	return nil
+}
+
+// adoptQueue returns the URL and ARN of an existing queue (from its URL or ARN),
+// once it has been checked that the function can consume it
+func adoptQueue(existing string, cfg *config.Config) (string, string, error) {
+	queueURL := existing
+	if strings.HasPrefix(existing, "arn:") {
+		// arn:aws:sqs:<region>:<account>:<name>
+		parts := strings.Split(existing, ":")
+		if len(parts) != 6 {
+			return "", "", fmt.Errorf("invalid queue ARN: %s", existing)
+		}
+		output, err := cli.ExecuteWithResult("aws", []string{
+			"sqs",
+			"get-queue-url",
+			"--queue-name", parts[5],
+			"--queue-owner-aws-account-id", parts[4],
+			"--region", parts[3],
+			"--output", "json",
+		}, "Looking for the existing SQS queue")
+		if err != nil {
+			return "", "", err
+		}
+		var result struct {
+			QueueURL string `json:"QueueUrl"`
+		}
+		if err := json.Unmarshal(output, &result); err != nil {
+			return "", "", err
+		}
+		queueURL = result.QueueURL
+	}
+
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"sqs",
+		"get-queue-attributes",
+		"--queue-url", queueURL,
+		"--attribute-names", "QueueArn", "VisibilityTimeout",
+		"--output", "json",
+	}, "Checking the existing SQS queue")
+	if err != nil {
+		if cli.IsNotFound(err) {
+			return "", "", fmt.Errorf("the existing queue does not exist: %s", existing)
+		}
+		return "", "", err
+	}
+	var attributes struct {
+		Attributes struct {
+			QueueArn          string `json:"QueueArn"`
+			VisibilityTimeout string `json:"VisibilityTimeout"`
+		} `json:"Attributes"`
+	}
+	if err := json.Unmarshal(output, &attributes); err != nil {
+		return "", "", err
+	}
+
+	// Lambda only consumes queues whose messages stay hidden for as long as it runs
+	timeout := defaultLambdaTimeout
+	if cfg.Config.Timeout != 0 {
+		timeout = cfg.Config.Timeout
+	}
+	var visibilityTimeout int
+	fmt.Sscanf(attributes.Attributes.VisibilityTimeout, "%d", &visibilityTimeout)
+	if visibilityTimeout < timeout {
+		return "", "", fmt.Errorf("the existing queue's visibility timeout (%ds) is shorter than the function's timeout (%ds)",
+			visibilityTimeout,
+			timeout,
+		)
+	}
+	return queueURL, attributes.Attributes.QueueArn, nil
+}
+
+// validateExistingBucket checks that the bucket exists, and that
+// it is in the region that the project is deployed to
+func validateExistingBucket(bucket string, stg *settings.Settings) error {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"s3api",
+		"get-bucket-location",
+		"--bucket", bucket,
+		"--output", "json",
+	}, "Checking the existing S3 bucket")
+	if err != nil {
+		if cli.IsNotFound(err) {
+			return fmt.Errorf("the existing bucket does not exist: %s", bucket)
+		}
+		return err
+	}
+	var result struct {
+		LocationConstraint *string `json:"LocationConstraint"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return err
+	}
+	// Buckets in us-east-1 do not have a location constraint
+	region := "us-east-1"
+	if result.LocationConstraint != nil && *result.LocationConstraint != "" {
+		region = *result.LocationConstraint
+	}
+	if region != stg.AWS.DeploymentRegion {
+		return fmt.Errorf("the existing bucket %s is in %s, not %s", bucket, region, stg.AWS.DeploymentRegion)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/iancoleman/strcase"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/clouds/aws/apigateway"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+// getIntegrationTarget returns the function that the REST API invokes: with
+// API stages, this is the alias that is named by the stage's variable
+func getIntegrationTarget(cfg *config.Config) string {
+	if len(cfg.Config.ApiStages) == 0 {
+		return cfg.ProjectName
+	}
+	return fmt.Sprintf("%s:${stageVariables.%s}", cfg.ProjectName, stageVariableName(cfg))
+}
+
+// stageVariableName returns the name of the function's stage variable; the
+// REST API is shared, so each function that it invokes has its own variable
+func stageVariableName(cfg *config.Config) string {
+	return fmt.Sprintf("%s_alias", strcase.ToSnake(cfg.ProjectName))
+}
+
+// setApiStages publishes a version of the function, points the alias of each
+// API stage at it (unless the stage is pinned to a version), and deploys the
+// REST API's stages with the variable that selects the alias
+func setApiStages(directory string, cfg *config.Config, stg *settings.Settings) error {
+	if len(cfg.Config.ApiStages) == 0 || cfg.Config.AWS.RestApiResourceID == "" || stg.AWS.RestApiID == "" {
+		return nil
+	}
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return err
+	}
+	version, err := publishVersion(cfg)
+	if err != nil {
+		return err
+	}
+
+	// The integration is updated, in case the function was added to the API without stages
+	if err := addFunctionIntegration(cfg, stg); err != nil {
+		return err
+	}
+
+	stages := []string{}
+	for stage := range cfg.Config.ApiStages {
+		stages = append(stages, stage)
+	}
+	sort.Strings(stages)
+	for _, stage := range stages {
+		apiStage := cfg.Config.ApiStages[stage]
+		alias := apiStage.GetAlias(stage)
+		aliasVersion := version
+		if apiStage != nil && apiStage.Version != "" {
+			aliasVersion = apiStage.Version
+		}
+		fmt.Println("🎚   API stage: ", stage, fmt.Sprintf("(%s → version %s)", alias, aliasVersion))
+		if err := setAlias(alias, aliasVersion, cfg); err != nil {
+			return err
+		}
+		st.AddResource(state.AWSLambdaAlias, alias, "")
+		if err := addStageInvocationPermission(stage, alias, cfg, stg); err != nil {
+			return err
+		}
+		variables := map[string]string{stageVariableName(cfg): alias}
+		if err := apigateway.DeployStage(stage, variables, stg); err != nil {
+			return err
+		}
+	}
+	return state.WriteState(directory, st)
+}
+
+func publishVersion(cfg *config.Config) (string, error) {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"lambda",
+		"publish-version",
+		"--function-name", cfg.ProjectName,
+		"--output", "json",
+	}, "Publishing a version of the function")
+	if err != nil {
+		return "", err
+	}
+	var result struct {
+		Version string `json:"Version"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return "", err
+	}
+	return result.Version, nil
+}
+
+// setAlias points the alias at the version, creating it if it does not exist
+func setAlias(alias, version string, cfg *config.Config) error {
+	err := cli.Execute("aws", []string{
+		"lambda",
+		"update-alias",
+		"--function-name", cfg.ProjectName,
+		"--name", alias,
+		"--function-version", version,
+	}, fmt.Sprintf("Updating the %s alias", alias))
+	if !cli.IsNotFound(err) {
+		return err
+	}
+	return cli.Execute("aws", []string{
+		"lambda",
+		"create-alias",
+		"--function-name", cfg.ProjectName,
+		"--name", alias,
+		"--function-version", version,
+	}, fmt.Sprintf("Creating the %s alias", alias))
+}
+
+// addStageInvocationPermission allows the REST API's stage to invoke the alias;
+// the permission belongs to the alias, and is deleted with it
+func addStageInvocationPermission(stage, alias string, cfg *config.Config, stg *settings.Settings) error {
+	err := cli.Execute("aws", []string{
+		"lambda",
+		"add-permission",
+		"--function-name", cfg.ProjectName,
+		"--qualifier", alias,
+		"--statement-id", fmt.Sprintf("kettle-api-stage-%s", stage),
+		"--action", "lambda:InvokeFunction",
+		"--principal", "apigateway.amazonaws.com",
+		"--source-arn", fmt.Sprintf("arn:%s:execute-api:%s:%s:%s/%s/POST/%s",
+			getPartition(stg.AWS.DeploymentRegion),
+			stg.AWS.DeploymentRegion,
+			stg.AWS.AccountID,
+			stg.AWS.RestApiID,
+			stage,
+			cfg.ProjectName,
+		),
+	}, fmt.Sprintf("Allowing the %s API stage to invoke the function", stage))
+	if cli.HasErrorCode(err, "ResourceConflictException") {
+		// The permission already exists
+		return nil
+	}
+	return err
+}
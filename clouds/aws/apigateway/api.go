@@ -1,9 +1,10 @@
 package apigateway
 
 import (
-	"encoding/json"
+	"fmt"
 
 	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/clouds/aws/client"
 	"github.com/operatorai/kettle-cli/settings"
 )
 
@@ -11,21 +12,40 @@ const (
 	operatorApiName = "operator-apigateway"
 )
 
-func SetRestApiID(stg *settings.Settings) error {
+// SetRestApiID sets the REST API that the function is added to: the shared API
+// with the given name (e.g. a team's API), which is created if it does not exist
+// and is only used by the project (it is not written to the settings), or
+// otherwise the one in the settings, or one that the user chooses
+func SetRestApiID(apiName string, stg *settings.Settings) error {
+	if apiName != "" {
+		restApiID, err := GetRestApiID(apiName, stg.AWS.DeploymentRegion)
+		if err != nil {
+			return err
+		}
+		if restApiID == "" {
+			restApiID, err = createRestApi(apiName, stg.AWS.DeploymentRegion)
+			if err != nil {
+				return err
+			}
+		}
+		stg.AWS.UseProjectRestApi(restApiID)
+		return nil
+	}
 	if stg.AWS.RestApiID != "" {
 		return nil
 	}
 
 	// Look for existing REST APIs
-	apis, operatorApiExists, err := getRestApis()
+	apis, err := getRestApis(stg.AWS.DeploymentRegion)
 	if err != nil {
 		return err
 	}
+	_, operatorApiExists := apis[operatorApiName]
 
 	var restApiID string
 	if len(apis) == 0 {
 		// Create a new rest API
-		restApiID, err = createRestApi()
+		restApiID, err = createRestApi(operatorApiName, stg.AWS.DeploymentRegion)
 		if err != nil {
 			return err
 		}
@@ -37,7 +57,7 @@ func SetRestApiID(stg *settings.Settings) error {
 			return err
 		}
 		if restApiID == "" {
-			restApiID, err = createRestApi()
+			restApiID, err = createRestApi(operatorApiName, stg.AWS.DeploymentRegion)
 			if err != nil {
 				return err
 			}
@@ -48,63 +68,79 @@ func SetRestApiID(stg *settings.Settings) error {
 	return nil
 }
 
-func Deploy(stg *settings.Settings) error {
-	return cli.Execute("aws", []string{
-		"apigateway",
-		"create-deployment",
-		"--rest-api-id", stg.AWS.RestApiID,
-		"--stage-name", "prod", // @TODO add support for different stages
-	}, "Deploying the REST API")
+// GetRestApiID returns the ID of the REST API with the given name (or
+// the default shared API, if the name is empty), if it exists
+func GetRestApiID(apiName, region string) (string, error) {
+	if apiName == "" {
+		apiName = operatorApiName
+	}
+	apis, err := getRestApis(region)
+	if err != nil {
+		return "", err
+	}
+	return apis[apiName], nil
 }
 
-func getRestApis() (map[string]string, bool, error) {
-	output, err := cli.ExecuteWithResult("aws", []string{
+// DeployStage deploys the REST API to a stage, and sets the stage's variables
+func DeployStage(stage string, variables map[string]string, stg *settings.Settings) error {
+	err := cli.Execute("aws", []string{
 		"apigateway",
-		"get-rest-apis",
-	}, "Collecting available REST APIs")
+		"create-deployment",
+		"--rest-api-id", stg.AWS.RestApiID,
+		"--stage-name", stage,
+	}, fmt.Sprintf("Deploying the REST API to: %s", stage))
 	if err != nil {
-		if err.Error() == "exit status 254" {
-			return map[string]string{}, false, nil
+		return err
+	}
+	for name, value := range variables {
+		err := cli.Execute("aws", []string{
+			"apigateway",
+			"update-stage",
+			"--rest-api-id", stg.AWS.RestApiID,
+			"--stage-name", stage,
+			"--patch-operations", fmt.Sprintf("op=replace,path=/variables/%s,value=%s", name, value),
+		}, fmt.Sprintf("Setting the %s stage variable", name))
+		if err != nil {
+			return err
 		}
-		return nil, false, err
 	}
+	return nil
+}
 
-	var results struct {
-		Items []struct {
-			ID   string `json:"id"`
-			Name string `json:"name"`
-		} `json:"items"`
-	}
-	if err := json.Unmarshal(output, &results); err != nil {
-		return nil, false, err
-	}
+func Deploy(stg *settings.Settings) error {
+	return DeployRestApi(stg.AWS.RestApiID, stg.AWS.DeploymentRegion)
+}
 
-	restApis := map[string]string{}
-	operatorApiGatewayExists := false
-	for _, restApi := range results.Items {
-		restApis[restApi.Name] = restApi.ID
-		if restApi.Name == operatorApiName {
-			operatorApiGatewayExists = true
-		}
+// DeployRestApi deploys a REST API (e.g. the one that the project's
+// state records) to its prod stage
+func DeployRestApi(restApiID, region string) error {
+	api, err := client.Get(region)
+	if err != nil {
+		return err
 	}
-	return restApis, operatorApiGatewayExists, nil
+	// @TODO add support for different stages
+	return api.CreateDeployment(restApiID, "prod")
 }
 
-func createRestApi() (string, error) {
-	output, err := cli.ExecuteWithResult("aws", []string{
-		"apigateway",
-		"create-rest-api",
-		"--name", operatorApiName,
-	}, "Creating a new REST API")
+func getRestApis(region string) (map[string]string, error) {
+	api, err := client.Get(region)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-
-	var result struct {
-		ApiID string `json:"id"`
+	restApis, err := api.GetRestApis()
+	if err != nil {
+		if cli.IsNotFound(err) {
+			return map[string]string{}, nil
+		}
+		return nil, err
 	}
-	if err := json.Unmarshal(output, &result); err != nil {
+	return restApis, nil
+}
+
+func createRestApi(apiName, region string) (string, error) {
+	api, err := client.Get(region)
+	if err != nil {
 		return "", err
 	}
-	return result.ApiID, nil
+	return api.CreateRestApi(apiName)
 }
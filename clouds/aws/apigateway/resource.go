@@ -1,10 +1,10 @@
 package apigateway
 
 import (
-	"encoding/json"
 	"fmt"
 
 	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/clouds/aws/client"
 	"github.com/operatorai/kettle-cli/config"
 	"github.com/operatorai/kettle-cli/settings"
 )
@@ -13,57 +13,64 @@ type RestApiResource struct {
 	Path          string
 	ID            string
 	HasPostMethod bool
+	// The function that the resource's POST method invokes, if it has one
+	Function string
 }
 
 func SetResourceID(resources []*RestApiResource, cfg *config.Config, stg *settings.Settings) error {
 	if cfg.Config.AWS.RestApiResourceID != "" {
 		return nil
 	}
+	api, err := client.Get(stg.AWS.DeploymentRegion)
+	if err != nil {
+		return err
+	}
 
-	// Look for existing resource ID
-	restApiResource := getResourceWithPath(resources, cfg.ProjectName)
+	// Look for existing resource ID; the resource may only be reused if it
+	// is not already attached to another function (e.g. another team's)
+	restApiResource := GetResourceWithPath(resources, cfg.ProjectName)
+	if restApiResource != nil && restApiResource.Function != "" && restApiResource.Function != cfg.ProjectName {
+		return fmt.Errorf("the path /%s of the REST API is already used by the %s function (rename the project, or use another rest_api_name)",
+			cfg.ProjectName,
+			restApiResource.Function,
+		)
+	}
 	if restApiResource == nil {
 		// Not found: create a resource in the API
-		output, err := cli.ExecuteWithResult("aws", []string{
-			"apigateway",
-			"create-resource",
-			"--rest-api-id", stg.AWS.RestApiID,
-			"--path-part", cfg.ProjectName,
-			"--parent-id", stg.AWS.RestApiRootID,
-		}, fmt.Sprintf("Creating /%s API resource", cfg.ProjectName))
+		resourceID, err := api.CreateResource(stg.AWS.RestApiID, stg.AWS.RestApiRootID, cfg.ProjectName)
 		if err != nil {
 			return err
 		}
-
-		var result struct {
-			ID string `json:"id"`
-		}
-		if err := json.Unmarshal(output, &result); err != nil {
-			return err
-		}
 		restApiResource = &RestApiResource{
 			Path:          cfg.ProjectName,
-			ID:            result.ID,
+			ID:            resourceID,
 			HasPostMethod: false,
 		}
 	}
 
 	cfg.Config.AWS.RestApiResourceID = restApiResource.ID
 	// Check for POST method
-	if err := addResourcePOSTMethod(restApiResource, stg.AWS.RestApiID, cfg.Config.AWS.RestApiResourceID); err != nil {
+	if err := addResourcePOSTMethod(api, restApiResource, stg.AWS.RestApiID, cfg.Config.AWS.RestApiResourceID, cfg); err != nil {
 		return err
 	}
 	return nil
 }
 
-func addResourcePOSTMethod(resource *RestApiResource, apiID, resourceID string) error {
+func addResourcePOSTMethod(api client.Client, resource *RestApiResource, apiID, resourceID string, cfg *config.Config) error {
 	if resource.HasPostMethod {
 		return nil
 	}
 
-	apiKeySetting := "--no-api-key-required"
-	if cli.PromptToConfirm("Require an API key to call the URL") {
-		apiKeySetting = "--api-key-required"
+	// Projects that do not declare their auth are prompted for it
+	authorizationType := "NONE"
+	if cfg.Config.Auth == config.AuthIAM {
+		authorizationType = "AWS_IAM"
+	}
+	requireAPIKey := cfg.Config.Auth == config.AuthAPIKey
+	if cfg.Config.Auth == "" {
+		requireAPIKey = cli.PromptToConfirm("Require an API key to call the URL")
+	}
+	if requireAPIKey {
 		// Note: if an api key is required, then there is more set up to do:
 
 		// 1. Create a usage plan and add the usage plan to the rest api (& stage)
@@ -76,31 +83,23 @@ func addResourcePOSTMethod(resource *RestApiResource, apiID, resourceID string)
 	}
 
 	// Create the method
-	err := cli.Execute("aws", []string{
-		"apigateway",
-		"put-method",
-		"--rest-api-id", apiID,
-		"--resource-id", resourceID,
-		"--http-method", "POST",
-		"--authorization-type", "NONE",
-		apiKeySetting,
-	}, "Adding a POST method to the API resource")
+	err := api.PutMethod(&client.Method{
+		RestApiID:         apiID,
+		ResourceID:        resourceID,
+		HttpMethod:        "POST",
+		AuthorizationType: authorizationType,
+		ApiKeyRequired:    requireAPIKey,
+	})
 	if err != nil {
 		return err
 	}
 
 	// Set the method response to JSON
-	err = cli.Execute("aws", []string{
-		"apigateway",
-		"put-method-response",
-		"--rest-api-id", apiID,
-		"--resource-id", resourceID,
-		"--http-method", "POST",
-		"--status-code", "200",
-		"--response-models", "application/json=Empty",
-	}, "Setting the resource response type to JSON")
-	if err != nil {
-		return err
-	}
-	return nil
+	return api.PutMethodResponse(&client.MethodResponse{
+		RestApiID:      apiID,
+		ResourceID:     resourceID,
+		HttpMethod:     "POST",
+		StatusCode:     "200",
+		ResponseModels: map[string]string{"application/json": "Empty"},
+	})
 }
@@ -1,48 +1,47 @@
 package apigateway
 
 import (
-	"encoding/json"
 	"strings"
 
-	"github.com/operatorai/kettle-cli/cli"
-	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/clouds/aws/client"
 )
 
-func GetResources(stg *settings.Settings) ([]*RestApiResource, error) {
-	output, err := cli.ExecuteWithResult("aws", []string{
-		"apigateway",
-		"get-resources",
-		"--rest-api-id", stg.AWS.RestApiID,
-	}, "Collecting API resources")
+func GetResources(restApiID, region string) ([]*RestApiResource, error) {
+	api, err := client.Get(region)
 	if err != nil {
 		return nil, err
 	}
-
-	var results struct {
-		Items []struct {
-			Path            string `json:"path"`
-			ID              string `json:"id"`
-			ResourceMethods struct {
-				POST *struct{} `json:"POST"`
-			} `json:"resourceMethods"`
-		} `json:"items"`
-	}
-	if err := json.Unmarshal(output, &results); err != nil {
+	results, err := api.GetResources(restApiID)
+	if err != nil {
 		return nil, err
 	}
 
 	resources := []*RestApiResource{}
-	for _, result := range results.Items {
+	for _, result := range results {
 		resources = append(resources, &RestApiResource{
 			Path:          result.Path,
 			ID:            result.ID,
-			HasPostMethod: (result.ResourceMethods.POST != nil),
+			HasPostMethod: result.HasPostMethod,
+			Function:      functionFromIntegrationURI(result.IntegrationURI),
 		})
 	}
 	return resources, nil
 }
 
-func getResourceWithPath(resources []*RestApiResource, pathPart string) *RestApiResource {
+// functionFromIntegrationURI returns the name of the function that a Lambda
+// integration invokes, from its URI, e.g. arn:aws:apigateway:<region>:lambda:
+// path/2015-03-31/functions/arn:aws:lambda:<region>:<account>:function:<name>/invocations
+func functionFromIntegrationURI(uri string) string {
+	parts := strings.SplitN(uri, ":function:", 2)
+	if len(parts) != 2 {
+		return ""
+	}
+	name := strings.TrimSuffix(parts[1], "/invocations")
+	return strings.SplitN(name, ":", 2)[0]
+}
+
+// GetResourceWithPath returns the resource whose path is /<pathPart>, if it exists
+func GetResourceWithPath(resources []*RestApiResource, pathPart string) *RestApiResource {
 	for _, resource := range resources {
 		if resource.Path == strings.Join([]string{"/", pathPart}, "") {
 			return resource
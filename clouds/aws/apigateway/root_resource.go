@@ -14,7 +14,7 @@ func SetRootResourceID(resources []*RestApiResource, stg *settings.Settings) err
 		return errors.New("rest api id not set")
 	}
 
-	resource := getResourceWithPath(resources, "")
+	resource := GetResourceWithPath(resources, "")
 	if resource == nil {
 		return errors.New("did not find root apigateway resource")
 	}
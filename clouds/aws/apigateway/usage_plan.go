@@ -18,7 +18,7 @@ func getUsagePlans(stg *settings.Settings) (map[string]string, bool, error) {
 		"--output", "json",
 	}, "Collecting available usage plans")
 	if err != nil {
-		if err.Error() == "exit status 254" {
+		if cli.IsNotFound(err) {
 			return map[string]string{}, false, nil
 		}
 		return nil, false, err
@@ -0,0 +1,119 @@
+package apigateway
+
+import (
+	"encoding/json"
+
+	"github.com/operatorai/kettle-cli/cli"
+)
+
+// requestValidatorName is the request validator that kettle adds to REST
+// APIs, which validates the bodies of requests against their method's model
+const requestValidatorName = "kettle-request-body"
+
+// SetRequestModel validates the bodies of requests to the resource's POST
+// method against a JSON Schema (as a model of the API), so that requests
+// that do not match it are rejected with a 400 before they reach the function
+func SetRequestModel(apiID, resourceID, modelName string, schema []byte) error {
+	if err := putModel(apiID, modelName, schema); err != nil {
+		return err
+	}
+	validatorID, err := getRequestValidator(apiID)
+	if err != nil {
+		return err
+	}
+	return cli.Execute("aws", []string{
+		"apigateway",
+		"update-method",
+		"--rest-api-id", apiID,
+		"--resource-id", resourceID,
+		"--http-method", "POST",
+		"--patch-operations",
+		"op=replace,path=/requestValidatorId,value=" + validatorID,
+		"op=add,path=/requestModels/application~1json,value=" + modelName,
+	}, "Validating requests against the contract")
+}
+
+// putModel creates the model, or replaces its schema if it exists
+func putModel(apiID, modelName string, schema []byte) error {
+	_, err := cli.ExecuteWithResult("aws", []string{
+		"apigateway",
+		"get-model",
+		"--rest-api-id", apiID,
+		"--model-name", modelName,
+	}, "Checking for the request model")
+	if cli.IsNotFound(err) {
+		return cli.Execute("aws", []string{
+			"apigateway",
+			"create-model",
+			"--rest-api-id", apiID,
+			"--name", modelName,
+			"--content-type", "application/json",
+			"--schema", string(schema),
+		}, "Creating the request model")
+	}
+	if err != nil {
+		return err
+	}
+	// The schema is JSON, so the patch is too (instead of the shorthand syntax)
+	patch, err := json.Marshal([]map[string]string{{
+		"op":    "replace",
+		"path":  "/schema",
+		"value": string(schema),
+	}})
+	if err != nil {
+		return err
+	}
+	return cli.Execute("aws", []string{
+		"apigateway",
+		"update-model",
+		"--rest-api-id", apiID,
+		"--model-name", modelName,
+		"--patch-operations", string(patch),
+	}, "Updating the request model")
+}
+
+// getRequestValidator returns the ID of kettle's request validator,
+// which is created if the API does not have it yet
+func getRequestValidator(apiID string) (string, error) {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"apigateway",
+		"get-request-validators",
+		"--rest-api-id", apiID,
+	}, "Collecting request validators")
+	if err != nil {
+		return "", err
+	}
+	var results struct {
+		Items []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(output, &results); err != nil {
+		return "", err
+	}
+	for _, validator := range results.Items {
+		if validator.Name == requestValidatorName {
+			return validator.ID, nil
+		}
+	}
+
+	output, err = cli.ExecuteWithResult("aws", []string{
+		"apigateway",
+		"create-request-validator",
+		"--rest-api-id", apiID,
+		"--name", requestValidatorName,
+		"--validate-request-body",
+		"--no-validate-request-parameters",
+	}, "Creating a request validator")
+	if err != nil {
+		return "", err
+	}
+	var validator struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(output, &validator); err != nil {
+		return "", err
+	}
+	return validator.ID, nil
+}
@@ -0,0 +1,177 @@
+package aws
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+// ErrLocked is returned when another deploy is holding the lock
+var ErrLocked = errors.New("the project is locked by another deploy")
+
+// PullState downloads the project's state from the state backend's S3 bucket,
+// replacing the local state; projects without remote state keep their local state
+func PullState(directory string, cfg *config.Config, stg *settings.Settings) error {
+	bucket := cfg.Config.StateBackend.Bucket
+	_, err := cli.ExecuteWithResult("aws", []string{
+		"s3api",
+		"head-object",
+		"--bucket", bucket,
+		"--key", cfg.StateKey(),
+	}, "Looking for remote state")
+	if err != nil {
+		if cli.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	statePath := state.GetStatePath(directory)
+	if err := os.MkdirAll(path.Dir(statePath), os.ModePerm); err != nil {
+		return err
+	}
+	return cli.Execute("aws", []string{
+		"s3",
+		"cp",
+		fmt.Sprintf("s3://%s/%s", bucket, cfg.StateKey()),
+		statePath,
+	}, "Downloading remote state")
+}
+
+// PushState uploads the project's local state to the state backend's S3 bucket,
+// or deletes the remote state if the local state was deleted (e.g. with a preview)
+func PushState(directory string, cfg *config.Config, stg *settings.Settings) error {
+	statePath := state.GetStatePath(directory)
+	if _, err := os.Stat(statePath); os.IsNotExist(err) {
+		return cli.Execute("aws", []string{
+			"s3",
+			"rm",
+			fmt.Sprintf("s3://%s/%s", cfg.Config.StateBackend.Bucket, cfg.StateKey()),
+		}, "Deleting remote state")
+	}
+	return cli.Execute("aws", []string{
+		"s3",
+		"cp",
+		statePath,
+		fmt.Sprintf("s3://%s/%s", cfg.Config.StateBackend.Bucket, cfg.StateKey()),
+	}, "Uploading remote state")
+}
+
+// Lock acquires the lock on the project's stage, by adding an item to the lock
+// table that only succeeds if no other deploy has added one
+func Lock(cfg *config.Config, stg *settings.Settings) error {
+	if err := createLockTable(cfg.LockTable()); err != nil {
+		return err
+	}
+	item, err := json.Marshal(map[string]interface{}{
+		"LockID":  map[string]string{"S": cfg.LockID()},
+		"Owner":   map[string]string{"S": config.LockOwner()},
+		"Created": map[string]string{"S": time.Now().UTC().Format(time.RFC3339)},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = cli.ExecuteWithResult("aws", []string{
+		"dynamodb",
+		"put-item",
+		"--table-name", cfg.LockTable(),
+		"--item", string(item),
+		"--condition-expression", "attribute_not_exists(LockID)",
+	}, "Acquiring the state lock")
+	if err == nil {
+		return nil
+	}
+	if !cli.HasErrorCode(err, "ConditionalCheckFailedException") {
+		return err
+	}
+
+	owner, created, err := getLock(cfg)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %s since %s (if it is stuck, run: kettle force-unlock)", ErrLocked, owner, created)
+}
+
+// Unlock releases the lock on the project's stage
+func Unlock(cfg *config.Config, stg *settings.Settings) error {
+	key, err := json.Marshal(map[string]interface{}{
+		"LockID": map[string]string{"S": cfg.LockID()},
+	})
+	if err != nil {
+		return err
+	}
+	return cli.Execute("aws", []string{
+		"dynamodb",
+		"delete-item",
+		"--table-name", cfg.LockTable(),
+		"--key", string(key),
+	}, "Releasing the state lock")
+}
+
+func getLock(cfg *config.Config) (string, string, error) {
+	key, err := json.Marshal(map[string]interface{}{
+		"LockID": map[string]string{"S": cfg.LockID()},
+	})
+	if err != nil {
+		return "", "", err
+	}
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"dynamodb",
+		"get-item",
+		"--table-name", cfg.LockTable(),
+		"--key", string(key),
+		"--consistent-read",
+		"--output", "json",
+	}, "Reading the state lock")
+	if err != nil {
+		return "", "", err
+	}
+	var result struct {
+		Item struct {
+			Owner struct {
+				S string `json:"S"`
+			} `json:"Owner"`
+			Created struct {
+				S string `json:"S"`
+			} `json:"Created"`
+		} `json:"Item"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return "", "", err
+	}
+	return result.Item.Owner.S, result.Item.Created.S, nil
+}
+
+// createLockTable creates the table that holds locks, if it does not exist;
+// the table is shared by all of the projects that use it
+func createLockTable(tableName string) error {
+	tableArn, err := getDynamoDBTableArn(tableName)
+	if err != nil || tableArn != "" {
+		return err
+	}
+	err = cli.Execute("aws", []string{
+		"dynamodb",
+		"create-table",
+		"--table-name", tableName,
+		"--billing-mode", "PAY_PER_REQUEST",
+		"--attribute-definitions", "AttributeName=LockID,AttributeType=S",
+		"--key-schema", "AttributeName=LockID,KeyType=HASH",
+	}, fmt.Sprintf("Creating a DynamoDB table called: %s", tableName))
+	if err != nil {
+		return err
+	}
+	return cli.Execute("aws", []string{
+		"dynamodb",
+		"wait",
+		"table-exists",
+		"--table-name", tableName,
+	}, "Waiting for the DynamoDB table to be created")
+}
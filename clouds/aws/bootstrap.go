@@ -0,0 +1,382 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+const (
+	githubOIDCProvider   = "token.actions.githubusercontent.com"
+	githubOIDCThumbprint = "6938fd4d98bab03faadb97b34396831e3780aea1"
+)
+
+// deployPolicyStatement is a statement in the deploy policy document
+type deployPolicyStatement struct {
+	Effect   string   `json:"Effect"`
+	Action   []string `json:"Action"`
+	Resource []string `json:"Resource"`
+}
+
+// GetDeployPolicy returns the IAM policy document that grants the permissions
+// that kettle needs to deploy (and destroy) the project, given its features
+func GetDeployPolicy(cfg *config.Config, stg *settings.Settings) ([]byte, error) {
+	if err := SetAccountID(stg.AWS); err != nil {
+		return nil, err
+	}
+	region := stg.AWS.DeploymentRegion
+	partition := getPartition(region)
+	account := stg.AWS.AccountID
+	// Resources are named after the project, and previews add a suffix
+	name := cfg.GetNamePattern()
+
+	statements := []*deployPolicyStatement{
+		{
+			Action:   []string{"sts:GetCallerIdentity", "tag:GetResources"},
+			Resource: []string{"*"},
+		},
+		{
+			Action: []string{
+				"iam:ListRoles",
+				"iam:GetRole",
+				"iam:CreateRole",
+				"iam:AttachRolePolicy",
+				"iam:PutRolePolicy",
+				"iam:DeleteRolePolicy",
+				"iam:PassRole",
+			},
+			Resource: getRoleArns(stg),
+		},
+	}
+
+	switch cfg.Config.DeploymentType {
+	case "lambda":
+		statements = append(statements,
+			&deployPolicyStatement{
+				Action:   []string{"lambda:*"},
+				Resource: []string{fmt.Sprintf("arn:%s:lambda:%s:%s:function:%s", partition, region, account, name)},
+			},
+			&deployPolicyStatement{
+				Action:   []string{"apigateway:*"},
+				Resource: []string{fmt.Sprintf("arn:%s:apigateway:%s::/*", partition, region)},
+			},
+			&deployPolicyStatement{
+				Action:   []string{"logs:FilterLogEvents", "logs:DescribeLogGroups"},
+				Resource: []string{fmt.Sprintf("arn:%s:logs:%s:%s:log-group:/aws/lambda/%s", partition, region, account, name)},
+			},
+		)
+		if cfg.Config.Tracing != nil {
+			statements = append(statements, &deployPolicyStatement{
+				Action:   []string{"lambda:GetLayerVersion"},
+				Resource: []string{"*"},
+			})
+		}
+		if domain := cfg.Config.CustomDomain; domain != nil {
+			statements = append(statements, &deployPolicyStatement{
+				Action:   []string{"acm:DescribeCertificate"},
+				Resource: []string{domain.CertificateArn},
+			})
+			if domain.MutualTLS != nil {
+				// API Gateway reads the truststore with the deploying identity's permissions
+				statements = append(statements, &deployPolicyStatement{
+					Action:   []string{"s3:GetObject", "s3:GetObjectVersion"},
+					Resource: []string{fmt.Sprintf("arn:%s:s3:::%s", partition, strings.TrimPrefix(domain.MutualTLS.TruststoreURI, "s3://"))},
+				})
+			}
+		}
+		if len(cfg.Config.LogMetrics) != 0 {
+			statements = append(statements, &deployPolicyStatement{
+				Action:   []string{"logs:CreateLogGroup", "logs:PutMetricFilter", "logs:DeleteMetricFilter"},
+				Resource: []string{fmt.Sprintf("arn:%s:logs:%s:%s:log-group:/aws/lambda/%s", partition, region, account, name)},
+			})
+		}
+		if cfg.GetFlagStore() == config.FlagStoreSSM {
+			statements = append(statements, &deployPolicyStatement{
+				Action:   []string{"ssm:PutParameter", "ssm:DeleteParameter"},
+				Resource: []string{fmt.Sprintf("arn:%s:ssm:%s:%s:parameter/kettle/%s/flags", partition, region, account, name)},
+			})
+		}
+		if len(cfg.DeploySecrets()) != 0 {
+			statements = append(statements, &deployPolicyStatement{
+				Action: []string{
+					"secretsmanager:GetSecretValue",
+					"secretsmanager:CreateSecret",
+					"secretsmanager:PutSecretValue",
+					"secretsmanager:DeleteSecret",
+					"secretsmanager:TagResource",
+				},
+				Resource: []string{fmt.Sprintf("arn:%s:secretsmanager:%s:%s:secret:kettle/%s/*", partition, region, account, name)},
+			})
+		}
+		if cfg.Config.KeepWarm != "" {
+			statements = append(statements, &deployPolicyStatement{
+				Action:   []string{"events:*"},
+				Resource: []string{fmt.Sprintf("arn:%s:events:%s:%s:rule/kettle-keep-warm-%s", partition, region, account, name)},
+			})
+		}
+		if cfg.Config.Queue != nil {
+			statements = append(statements,
+				&deployPolicyStatement{
+					Action:   []string{"sqs:*"},
+					Resource: []string{fmt.Sprintf("arn:%s:sqs:%s:%s:*", partition, region, account)},
+				},
+				&deployPolicyStatement{
+					// Event source mappings do not support resource-level permissions
+					Action: []string{
+						"lambda:CreateEventSourceMapping",
+						"lambda:GetEventSourceMapping",
+						"lambda:ListEventSourceMappings",
+						"lambda:UpdateEventSourceMapping",
+						"lambda:DeleteEventSourceMapping",
+					},
+					Resource: []string{"*"},
+				},
+			)
+			if cfg.Config.Queue.Retries > 0 {
+				statements = append(statements, &deployPolicyStatement{
+					Action:   []string{"cloudwatch:PutMetricAlarm", "cloudwatch:DeleteAlarms", "sns:*"},
+					Resource: []string{"*"},
+				})
+			}
+		}
+	case "sagemaker":
+		statements = append(statements,
+			&deployPolicyStatement{
+				Action: []string{"sagemaker:*"},
+				Resource: []string{
+					fmt.Sprintf("arn:%s:sagemaker:%s:%s:model/%s", partition, region, account, name),
+					fmt.Sprintf("arn:%s:sagemaker:%s:%s:endpoint-config/%s", partition, region, account, name),
+					fmt.Sprintf("arn:%s:sagemaker:%s:%s:endpoint/%s", partition, region, account, name),
+				},
+			},
+			&deployPolicyStatement{
+				Action:   []string{"ecr:*"},
+				Resource: []string{fmt.Sprintf("arn:%s:ecr:%s:%s:repository/%s", partition, region, account, name)},
+			},
+			&deployPolicyStatement{
+				Action:   []string{"ecr:GetAuthorizationToken"},
+				Resource: []string{"*"},
+			},
+		)
+	}
+
+	buckets := getBucketArns(cfg, stg)
+	if len(buckets) > 0 {
+		statements = append(statements, &deployPolicyStatement{
+			Action:   []string{"s3:*"},
+			Resource: buckets,
+		})
+	}
+	if cfg.Config.Static != nil {
+		statements = append(statements, &deployPolicyStatement{
+			Action:   []string{"cloudfront:*"},
+			Resource: []string{"*"},
+		})
+	}
+	for _, addOn := range cfg.Config.AddOns {
+		switch addOn.Type {
+		case "dynamodb":
+			statements = append(statements, &deployPolicyStatement{
+				Action:   []string{"dynamodb:*"},
+				Resource: []string{fmt.Sprintf("arn:%s:dynamodb:%s:%s:table/%s", partition, region, account, name)},
+			})
+		case "aurora":
+			statements = append(statements, &deployPolicyStatement{
+				Action:   []string{"rds:*", "secretsmanager:GetSecretValue"},
+				Resource: []string{"*"},
+			})
+		case "redis":
+			statements = append(statements,
+				&deployPolicyStatement{
+					Action:   []string{"elasticache:*"},
+					Resource: []string{"*"},
+				},
+				&deployPolicyStatement{
+					Action: []string{
+						"ec2:DescribeVpcs",
+						"ec2:DescribeSubnets",
+						"ec2:DescribeSecurityGroups",
+						"ec2:CreateSecurityGroup",
+						"ec2:AuthorizeSecurityGroupIngress",
+						"ec2:DeleteSecurityGroup",
+					},
+					Resource: []string{"*"},
+				},
+			)
+		}
+	}
+	if cfg.Config.Canary != nil {
+		statements = append(statements, &deployPolicyStatement{
+			Action:   []string{"synthetics:*", "cloudwatch:PutMetricAlarm", "cloudwatch:DeleteAlarms", "sns:*"},
+			Resource: []string{"*"},
+		})
+	}
+	if cfg.Config.Budget != nil {
+		statements = append(statements, &deployPolicyStatement{
+			Action:   []string{"budgets:*", "ce:GetCostAndUsage", "ce:UpdateCostAllocationTagsStatus"},
+			Resource: []string{"*"},
+		})
+	}
+	if cfg.Config.StateBackend != nil {
+		statements = append(statements, &deployPolicyStatement{
+			Action:   []string{"dynamodb:DescribeTable", "dynamodb:CreateTable", "dynamodb:PutItem", "dynamodb:GetItem", "dynamodb:DeleteItem"},
+			Resource: []string{fmt.Sprintf("arn:%s:dynamodb:%s:%s:table/%s", partition, region, account, cfg.LockTable())},
+		})
+	}
+
+	for _, statement := range statements {
+		statement.Effect = "Allow"
+	}
+	return json.MarshalIndent(map[string]interface{}{
+		"Version":   "2012-10-17",
+		"Statement": statements,
+	}, "", "  ")
+}
+
+// getRoleArns returns the roles that kettle creates, and passes to the services that it deploys
+func getRoleArns(stg *settings.Settings) []string {
+	partition := getPartition(stg.AWS.DeploymentRegion)
+	roles := []string{fmt.Sprintf("arn:%s:iam::%s:role/operator-*", partition, stg.AWS.AccountID)}
+	for _, role := range []string{stg.AWS.RoleArn, stg.AWS.SageMakerRoleArn, stg.AWS.CanaryRoleArn} {
+		if role != "" {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// getBucketArns returns the S3 buckets that the project's features use
+func getBucketArns(cfg *config.Config, stg *settings.Settings) []string {
+	buckets := []string{}
+	if cfg.Config.Static != nil {
+		buckets = append(buckets, cfg.Config.Static.Bucket)
+	}
+	if cfg.Config.Canary != nil {
+		buckets = append(buckets, fmt.Sprintf("%s-canary-%s", cfg.GetBaseProjectName(), stg.AWS.AccountID))
+	}
+	if cfg.Config.StateBackend != nil {
+		buckets = append(buckets, cfg.Config.StateBackend.Bucket)
+	}
+	for _, addOn := range cfg.Config.AddOns {
+		if addOn.Type == "bucket" {
+			buckets = append(buckets, fmt.Sprintf("%s*-%s", cfg.GetBaseProjectName(), stg.AWS.AccountID))
+		}
+	}
+	if cfg.Config.Model != nil && strings.HasPrefix(cfg.Config.Model.URI, "s3://") {
+		buckets = append(buckets, strings.SplitN(strings.TrimPrefix(cfg.Config.Model.URI, "s3://"), "/", 2)[0])
+	}
+
+	partition := getPartition(stg.AWS.DeploymentRegion)
+	arns := []string{}
+	for _, bucket := range buckets {
+		arns = append(arns, fmt.Sprintf("arn:%s:s3:::%s", partition, bucket), fmt.Sprintf("arn:%s:s3:::%s/*", partition, bucket))
+	}
+	return arns
+}
+
+// CreateDeployRole creates (or updates) a role with the deploy policy, that
+// GitHub Actions workflows in the repository can assume with OIDC
+func CreateDeployRole(cfg *config.Config, stg *settings.Settings, repository string, policy []byte) (string, error) {
+	if err := SetAccountID(stg.AWS); err != nil {
+		return "", err
+	}
+	providerArn, err := getOrCreateGitHubOIDCProvider(stg)
+	if err != nil {
+		return "", err
+	}
+
+	roleName := fmt.Sprintf("kettle-deploy-%s", cfg.GetBaseProjectName())
+	trustPolicy, err := json.Marshal(map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []interface{}{
+			map[string]interface{}{
+				"Effect":    "Allow",
+				"Principal": map[string]string{"Federated": providerArn},
+				"Action":    "sts:AssumeRoleWithWebIdentity",
+				"Condition": map[string]interface{}{
+					"StringEquals": map[string]string{
+						fmt.Sprintf("%s:aud", githubOIDCProvider): "sts.amazonaws.com",
+					},
+					"StringLike": map[string]string{
+						fmt.Sprintf("%s:sub", githubOIDCProvider): fmt.Sprintf("repo:%s:*", repository),
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	roleArn := fmt.Sprintf("arn:%s:iam::%s:role/%s", getPartition(stg.AWS.DeploymentRegion), stg.AWS.AccountID, roleName)
+	_, err = cli.ExecuteWithResult("aws", []string{
+		"iam",
+		"get-role",
+		"--role-name", roleName,
+	}, "Looking for the deploy role")
+	if err != nil {
+		if !cli.IsNotFound(err) {
+			return "", err
+		}
+		err = cli.Execute("aws", []string{
+			"iam",
+			"create-role",
+			"--role-name", roleName,
+			"--assume-role-policy-document", string(trustPolicy),
+		}, fmt.Sprintf("Creating an IAM role called: %s", roleName))
+	} else {
+		err = cli.Execute("aws", []string{
+			"iam",
+			"update-assume-role-policy",
+			"--role-name", roleName,
+			"--policy-document", string(trustPolicy),
+		}, "Updating the deploy role's trust policy")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	err = cli.Execute("aws", []string{
+		"iam",
+		"put-role-policy",
+		"--role-name", roleName,
+		"--policy-name", roleName,
+		"--policy-document", string(policy),
+	}, "Adding the deploy policy to the IAM role")
+	if err != nil {
+		return "", err
+	}
+	return roleArn, nil
+}
+
+// getOrCreateGitHubOIDCProvider returns the account's identity provider for
+// GitHub Actions, which is created if it does not exist
+func getOrCreateGitHubOIDCProvider(stg *settings.Settings) (string, error) {
+	providerArn := fmt.Sprintf("arn:%s:iam::%s:oidc-provider/%s", getPartition(stg.AWS.DeploymentRegion), stg.AWS.AccountID, githubOIDCProvider)
+	_, err := cli.ExecuteWithResult("aws", []string{
+		"iam",
+		"get-open-id-connect-provider",
+		"--open-id-connect-provider-arn", providerArn,
+	}, "Looking for the GitHub Actions identity provider")
+	if err == nil {
+		return providerArn, nil
+	}
+	if !cli.IsNotFound(err) {
+		return "", err
+	}
+	err = cli.Execute("aws", []string{
+		"iam",
+		"create-open-id-connect-provider",
+		"--url", fmt.Sprintf("https://%s", githubOIDCProvider),
+		"--client-id-list", "sts.amazonaws.com",
+		"--thumbprint-list", githubOIDCThumbprint,
+	}, "Creating the GitHub Actions identity provider")
+	if err != nil {
+		return "", err
+	}
+	return providerArn, nil
+}
@@ -0,0 +1,152 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+// SetBudget creates (or updates) an AWS Budget that is scoped to the resources
+// with the project's tag, and alerts by email when the threshold is passed
+func SetBudget(directory string, cfg *config.Config, stg *settings.Settings) error {
+	if err := SetAccountID(stg.AWS); err != nil {
+		return err
+	}
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return err
+	}
+
+	// Tags can only be used to filter costs once they are activated
+	// as cost allocation tags, which fails until AWS has seen the tag
+	err = cli.Execute("aws", []string{
+		"ce",
+		"update-cost-allocation-tags-status",
+		"--cost-allocation-tags-status", fmt.Sprintf("TagKey=%s,Status=Active", config.ProjectTag),
+	}, "Activating the project cost allocation tag")
+	if err != nil && settings.DebugMode {
+		fmt.Println(err.Error())
+	}
+
+	budgetName := cfg.BudgetName()
+	fmt.Println("💰  Budget: ", budgetName, fmt.Sprintf("(%.2f %s per month)", cfg.Config.Budget.Amount, cfg.BudgetCurrency()))
+	budget, err := json.Marshal(map[string]interface{}{
+		"BudgetName": budgetName,
+		"BudgetLimit": map[string]string{
+			"Amount": strconv.FormatFloat(cfg.Config.Budget.Amount, 'f', 2, 64),
+			"Unit":   cfg.BudgetCurrency(),
+		},
+		"TimeUnit":   "MONTHLY",
+		"BudgetType": "COST",
+		"CostFilters": map[string][]string{
+			"TagKeyValue": {fmt.Sprintf("user:%s$%s", config.ProjectTag, cfg.GetBaseProjectName())},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	exists, err := budgetExists(budgetName, stg)
+	if err != nil {
+		return err
+	}
+	if exists {
+		err = cli.Execute("aws", []string{
+			"budgets",
+			"update-budget",
+			"--account-id", stg.AWS.AccountID,
+			"--new-budget", string(budget),
+		}, "Updating the project budget")
+	} else {
+		args := []string{
+			"budgets",
+			"create-budget",
+			"--account-id", stg.AWS.AccountID,
+			"--budget", string(budget),
+		}
+		if cfg.Config.Budget.AlertEmail != "" {
+			notifications, err := json.Marshal([]interface{}{
+				map[string]interface{}{
+					"Notification": map[string]interface{}{
+						"NotificationType":   "ACTUAL",
+						"ComparisonOperator": "GREATER_THAN",
+						"Threshold":          cfg.BudgetAlertThreshold(),
+						"ThresholdType":      "PERCENTAGE",
+					},
+					"Subscribers": []interface{}{
+						map[string]string{
+							"SubscriptionType": "EMAIL",
+							"Address":          cfg.Config.Budget.AlertEmail,
+						},
+					},
+				},
+			})
+			if err != nil {
+				return err
+			}
+			args = append(args, "--notifications-with-subscribers", string(notifications))
+		}
+		err = cli.Execute("aws", args, "Creating the project budget")
+	}
+	if err != nil {
+		return err
+	}
+
+	st.AddResource(state.AWSBudget, budgetName, fmt.Sprintf("arn:%s:budgets::%s:budget/%s", getPartition(stg.AWS.DeploymentRegion), stg.AWS.AccountID, budgetName))
+	return state.WriteState(directory, st)
+}
+
+func budgetExists(budgetName string, stg *settings.Settings) (bool, error) {
+	_, err := cli.ExecuteWithResult("aws", []string{
+		"budgets",
+		"describe-budget",
+		"--account-id", stg.AWS.AccountID,
+		"--budget-name", budgetName,
+	}, "Looking for the project budget")
+	if err != nil {
+		if cli.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// GetMonthToDateSpend returns the cost of the resources with the
+// project's tag since the start of the month, from Cost Explorer
+func GetMonthToDateSpend(cfg *config.Config, stg *settings.Settings) (float64, string, error) {
+	now := time.Now().UTC()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	costs, err := GetCosts(cfg.GetBaseProjectName(), start, now)
+	if err != nil {
+		return 0, "", err
+	}
+	total := 0.0
+	unit := ""
+	for _, cost := range costs {
+		total += cost.Amount
+		unit = cost.Unit
+	}
+	return total, unit, nil
+}
+
+// deleteBudget deletes a budget, whose ARN includes the account that it belongs to
+func deleteBudget(resource *state.Resource) error {
+	parts := strings.Split(resource.Arn, ":")
+	if len(parts) < 5 {
+		return fmt.Errorf("invalid budget: %s", resource.Arn)
+	}
+	return cli.Execute("aws", []string{
+		"budgets",
+		"delete-budget",
+		"--account-id", parts[4],
+		"--budget-name", resource.ID,
+	}, "Deleting budget")
+}
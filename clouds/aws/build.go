@@ -0,0 +1,43 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/artifacts"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+// Build creates the function's deployment archive, without deploying it
+func (AWSLambdaFunction) Build(cfg *config.Config, stg *settings.Settings) (*config.Artifact, error) {
+	if _, err := createDeploymentArchive(cfg); err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(cfg.Config.Runtime, "go") {
+		// The binary has been added to the archive
+		if err := removeFile(goBuildFileName); err != nil {
+			return nil, err
+		}
+	}
+	return cfg.Artifact, nil
+}
+
+func (AWSLambdaFunction) GetArtifactKind() string {
+	return artifacts.Archive
+}
+
+// Build builds the endpoint's docker container, without pushing it to ECR
+func (AWSSageMakerEndpoint) Build(cfg *config.Config, stg *settings.Settings) (*config.Artifact, error) {
+	imageURI := fmt.Sprintf("%s:kettle-build", cfg.GetBaseProjectName())
+	if err := buildImage(imageURI); err != nil {
+		return nil, err
+	}
+	return &config.Artifact{
+		Location: imageURI,
+	}, nil
+}
+
+func (AWSSageMakerEndpoint) GetArtifactKind() string {
+	return artifacts.Image
+}
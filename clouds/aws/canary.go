@@ -0,0 +1,354 @@
+package aws
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+const (
+	canaryRuntime      = "syn-python-selenium-4.1"
+	canaryHandler      = "canary.handler"
+	canaryArchiveKey   = "canary.zip"
+	canaryMaxName      = 21
+	canaryPollInterval = 5 * time.Second
+)
+
+var canaryExecutionRole = &executionRole{
+	name:    "operator-canary-role",
+	service: "lambda.amazonaws.com",
+	policies: []string{
+		"arn:aws:iam::aws:policy/service-role/AWSLambdaBasicExecutionRole",
+	},
+	// Canaries write their results to the artifact bucket, and their metrics to CloudWatch
+	inlinePolicy: `{
+		"Version": "2012-10-17",
+		"Statement": [
+			{
+				"Effect": "Allow",
+				"Action": ["s3:PutObject", "s3:GetObject", "s3:GetBucketLocation"],
+				"Resource": ["arn:aws:s3:::*-canary-*", "arn:aws:s3:::*-canary-*/*"]
+			},
+			{
+				"Effect": "Allow",
+				"Action": "s3:ListAllMyBuckets",
+				"Resource": "*"
+			},
+			{
+				"Effect": "Allow",
+				"Action": "cloudwatch:PutMetricData",
+				"Resource": "*",
+				"Condition": {"StringEquals": {"cloudwatch:namespace": "CloudWatchSynthetics"}}
+			}
+		]
+	}`,
+}
+
+// The canary script makes the smoke test request, and fails if
+// the response does not have the expected status
+const canaryScript = `import urllib.error
+import urllib.request
+
+URL = %s
+METHOD = %s
+BODY = %s
+EXPECTED_STATUS = %d
+
+
+def handler(event, context):
+    data = BODY.encode() if BODY else None
+    request = urllib.request.Request(URL, data=data, method=METHOD, headers={"Content-Type": "application/json"})
+    try:
+        with urllib.request.urlopen(request, timeout=30) as response:
+            status = response.status
+    except urllib.error.HTTPError as e:
+        status = e.code
+    if status != EXPECTED_STATUS:
+        raise Exception(f"expected status {EXPECTED_STATUS}, got {status}")
+    return "Success"
+`
+
+var invalidCanaryCharacters = regexp.MustCompile(`[^0-9a-z_\-]`)
+
+// DeployCanary creates (or updates) a CloudWatch Synthetics canary that runs the
+// project's smoke test against the endpoint, and an alarm that fires when it fails
+func DeployCanary(directory string, cfg *config.Config, stg *settings.Settings, url string) error {
+	if err := SetAccountID(stg.AWS); err != nil {
+		return err
+	}
+	if stg.AWS.CanaryRoleArn == "" {
+		role, err := selectExecutionRole(canaryExecutionRole, stg.AWS.DeploymentRegion)
+		if err != nil {
+			return err
+		}
+		stg.AWS.CanaryRoleArn = role
+	}
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return err
+	}
+
+	name := canaryName(cfg)
+	fmt.Println("🐤  Canary: ", name, fmt.Sprintf("(every %d minutes)", cfg.CanaryInterval()))
+
+	// Canary scripts and results are stored in an artifact bucket
+	bucket := fmt.Sprintf("%s-canary-%s", cfg.ProjectName, stg.AWS.AccountID)
+	if err := createBucket(bucket, stg); err != nil {
+		return err
+	}
+	st.AddResource(state.AWSS3Bucket, bucket, fmt.Sprintf("arn:%s:s3:::%s", getPartition(stg.AWS.DeploymentRegion), bucket))
+	if err := state.WriteState(directory, st); err != nil {
+		return err
+	}
+
+	archive, err := createCanaryArchive(url, cfg.GetSmokeTest())
+	if err != nil {
+		return err
+	}
+	_, err = cli.ExecuteWithInput("aws", []string{
+		"s3",
+		"cp",
+		"-",
+		fmt.Sprintf("s3://%s/%s", bucket, canaryArchiveKey),
+	}, archive, "Uploading canary script")
+	if err != nil {
+		return err
+	}
+
+	code := fmt.Sprintf("S3Bucket=%s,S3Key=%s,Handler=%s", bucket, canaryArchiveKey, canaryHandler)
+	schedule := fmt.Sprintf("Expression=%s", canarySchedule(cfg.CanaryInterval()))
+	canaryState, err := getCanaryState(name)
+	if err != nil {
+		return err
+	}
+	if canaryState == "" {
+		err = cli.Execute("aws", []string{
+			"synthetics",
+			"create-canary",
+			"--name", name,
+			"--code", code,
+			"--artifact-s3-location", fmt.Sprintf("s3://%s/", bucket),
+			"--execution-role-arn", stg.AWS.CanaryRoleArn,
+			"--schedule", schedule,
+			"--runtime-version", canaryRuntime,
+		}, "Creating CloudWatch Synthetics canary")
+	} else {
+		err = cli.Execute("aws", []string{
+			"synthetics",
+			"update-canary",
+			"--name", name,
+			"--code", code,
+			"--execution-role-arn", stg.AWS.CanaryRoleArn,
+			"--schedule", schedule,
+			"--runtime-version", canaryRuntime,
+		}, "Updating CloudWatch Synthetics canary")
+	}
+	if err != nil {
+		return err
+	}
+	st.AddResource(state.AWSSyntheticsCanary, name, "")
+	if err := state.WriteState(directory, st); err != nil {
+		return err
+	}
+
+	// Canaries must finish being created before they can be started
+	canaryState, err = waitForCanary(name)
+	if err != nil {
+		return err
+	}
+	if canaryState == "READY" || canaryState == "STOPPED" {
+		err := cli.Execute("aws", []string{
+			"synthetics",
+			"start-canary",
+			"--name", name,
+		}, "Starting the canary")
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := putCanaryAlarm(name, cfg, st); err != nil {
+		return err
+	}
+	return state.WriteState(directory, st)
+}
+
+// putCanaryAlarm creates an alarm that fires when any canary run fails,
+// and notifies the alarm email (if set) through an SNS topic
+func putCanaryAlarm(name string, cfg *config.Config, st *state.State) error {
+	alarmName := fmt.Sprintf("kettle-canary-%s", cfg.ProjectName)
+	args := []string{
+		"cloudwatch",
+		"put-metric-alarm",
+		"--alarm-name", alarmName,
+		"--namespace", "CloudWatchSynthetics",
+		"--metric-name", "SuccessPercent",
+		"--dimensions", fmt.Sprintf("Name=CanaryName,Value=%s", name),
+		"--statistic", "Average",
+		"--period", fmt.Sprintf("%d", cfg.CanaryInterval()*60),
+		"--evaluation-periods", "1",
+		"--threshold", "100",
+		"--comparison-operator", "LessThanThreshold",
+		"--treat-missing-data", "notBreaching",
+	}
+	if cfg.Config.Canary.AlarmEmail != "" {
+		topicArn, err := createAlarmTopic(alarmName, cfg.Config.Canary.AlarmEmail)
+		if err != nil {
+			return err
+		}
+		st.AddResource(state.AWSSNSTopic, topicArn, topicArn)
+		args = append(args, "--alarm-actions", topicArn)
+	}
+	if err := cli.Execute("aws", args, "Creating the canary alarm"); err != nil {
+		return err
+	}
+	st.AddResource(state.AWSCloudWatchAlarm, alarmName, "")
+	return nil
+}
+
+func createAlarmTopic(topicName, email string) (string, error) {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"sns",
+		"create-topic",
+		"--name", topicName,
+		"--output", "json",
+	}, "Creating the alarm topic")
+	if err != nil {
+		return "", err
+	}
+	var result struct {
+		TopicArn string `json:"TopicArn"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return "", err
+	}
+
+	// The subscription must be confirmed from the email that SNS sends
+	err = cli.Execute("aws", []string{
+		"sns",
+		"subscribe",
+		"--topic-arn", result.TopicArn,
+		"--protocol", "email",
+		"--notification-endpoint", email,
+	}, "Subscribing to the alarm topic")
+	if err != nil {
+		return "", err
+	}
+	return result.TopicArn, nil
+}
+
+func createCanaryArchive(url string, smokeTest *config.SmokeTest) ([]byte, error) {
+	values := []string{}
+	for _, value := range []string{url + smokeTest.Path, smokeTest.Method, smokeTest.Body} {
+		// JSON strings are valid python strings
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, string(data))
+	}
+	script := fmt.Sprintf(canaryScript, values[0], values[1], values[2], smokeTest.ExpectedStatus)
+
+	// Python canaries are loaded from the archive's python/ directory
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	file, err := writer.Create("python/canary.py")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Write([]byte(script)); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return archive.Bytes(), nil
+}
+
+func getCanaryState(name string) (string, error) {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"synthetics",
+		"get-canary",
+		"--name", name,
+		"--query", "Canary.Status.State",
+		"--output", "text",
+	}, "Checking status of canary")
+	if err != nil {
+		if cli.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+func waitForCanary(name string) (string, error) {
+	for {
+		canaryState, err := getCanaryState(name)
+		if err != nil {
+			return "", err
+		}
+		switch canaryState {
+		case "CREATING", "UPDATING", "STARTING", "STOPPING":
+			time.Sleep(canaryPollInterval)
+		case "ERROR":
+			return "", fmt.Errorf("canary %s failed to deploy", name)
+		default:
+			return canaryState, nil
+		}
+	}
+}
+
+// deleteCanary stops the canary (which must not be running when it is
+// deleted) and deletes it, along with the function that runs it
+func deleteCanary(name string) error {
+	canaryState, err := getCanaryState(name)
+	if err != nil {
+		return err
+	}
+	if canaryState == "RUNNING" {
+		err := cli.Execute("aws", []string{
+			"synthetics",
+			"stop-canary",
+			"--name", name,
+		}, "Stopping the canary")
+		if err != nil {
+			return err
+		}
+		if _, err := waitForCanary(name); err != nil {
+			return err
+		}
+	}
+	return cli.Execute("aws", []string{
+		"synthetics",
+		"delete-canary",
+		"--name", name,
+		"--delete-lambda",
+	}, "Deleting CloudWatch Synthetics canary")
+}
+
+// canaryName returns a valid canary name, which is lowercase
+// and at most 21 characters
+func canaryName(cfg *config.Config) string {
+	name := invalidCanaryCharacters.ReplaceAllString(strings.ToLower(cfg.ProjectName), "-")
+	if len(name) > canaryMaxName {
+		name = name[:canaryMaxName]
+	}
+	return name
+}
+
+func canarySchedule(interval int) string {
+	if interval == 1 {
+		return "rate(1 minute)"
+	}
+	return fmt.Sprintf("rate(%d minutes)", interval)
+}
@@ -0,0 +1,570 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/operatorai/kettle-cli/cli"
+)
+
+// cliClient calls the APIs with the aws cli, in its region (or, if it
+// is empty, the aws cli's region, which kettle sets to the deployment region)
+type cliClient struct {
+	region string
+}
+
+func (c cliClient) GetCallerIdentity() (*CallerIdentity, error) {
+	output, err := c.executeCached([]string{
+		"sts",
+		"get-caller-identity",
+		"--output", "json",
+	}, "Retrieving aws caller identity")
+	if err != nil {
+		return nil, err
+	}
+	identity := &CallerIdentity{}
+	if err := json.Unmarshal(output, identity); err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+// cliRole is an IAM role, as the aws cli prints it
+type cliRole struct {
+	RoleName   string `json:"RoleName"`
+	Path       string `json:"Path"`
+	Arn        string `json:"Arn"`
+	RolePolicy struct {
+		Statement []struct {
+			Principal struct {
+				Service interface{} `json:"Service"`
+			} `json:"Principal"`
+		} `json:"Statement"`
+	} `json:"AssumeRolePolicyDocument"`
+}
+
+func (r *cliRole) role() *Role {
+	role := &Role{
+		Name: r.RoleName,
+		Path: r.Path,
+		Arn:  r.Arn,
+	}
+	if len(r.RolePolicy.Statement) > 0 {
+		// A role can trust several services, but kettle's roles trust one
+		if service, ok := r.RolePolicy.Statement[0].Principal.Service.(string); ok {
+			role.TrustedService = service
+		}
+	}
+	return role
+}
+
+func (c cliClient) ListRoles() ([]*Role, error) {
+	output, err := c.executeCached([]string{
+		"iam",
+		"list-roles",
+		"--output", "json",
+	}, "Collecting available IAM roles")
+	if err != nil {
+		return nil, err
+	}
+	var results struct {
+		Roles []*cliRole `json:"Roles"`
+	}
+	if err := json.Unmarshal(output, &results); err != nil {
+		return nil, err
+	}
+	roles := []*Role{}
+	for _, role := range results.Roles {
+		roles = append(roles, role.role())
+	}
+	return roles, nil
+}
+
+func (c cliClient) GetRole(name string) (*Role, error) {
+	output, err := c.executeWithResult([]string{
+		"iam",
+		"get-role",
+		"--role-name", name,
+		"--output", "json",
+	}, fmt.Sprintf("Checking the IAM role: %s", name))
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Role *cliRole `json:"Role"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, err
+	}
+	return result.Role.role(), nil
+}
+
+func (c cliClient) CreateRole(name, trustPolicy string) (*Role, error) {
+	output, err := c.executeWithResult([]string{
+		"iam",
+		"create-role",
+		"--role-name", name,
+		"--assume-role-policy-document", trustPolicy,
+		"--output", "json",
+	}, fmt.Sprintf("Creating an IAM role called: %s", name))
+	if err != nil {
+		return nil, err
+	}
+	// The cached list of roles does not have the new role
+	cli.ClearCache()
+
+	var result struct {
+		Role *cliRole `json:"Role"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, err
+	}
+	return result.Role.role(), nil
+}
+
+func (c cliClient) AttachRolePolicy(roleName, policyArn string) error {
+	return c.execute([]string{
+		"iam",
+		"attach-role-policy",
+		"--role-name", roleName,
+		"--policy-arn", policyArn,
+	}, fmt.Sprintf("Attaching %s to the IAM role", policyArn))
+}
+
+func (c cliClient) PutRolePolicy(roleName, policyName, document string) error {
+	return c.execute([]string{
+		"iam",
+		"put-role-policy",
+		"--role-name", roleName,
+		"--policy-name", policyName,
+		"--policy-document", document,
+	}, "Adding permissions to the IAM role")
+}
+
+func (c cliClient) DeleteRolePolicy(roleName, policyName string) error {
+	return c.execute([]string{
+		"iam",
+		"delete-role-policy",
+		"--role-name", roleName,
+		"--policy-name", policyName,
+	}, "Deleting execution role policy")
+}
+
+func (c cliClient) GetFunction(name string) (*FunctionStatus, error) {
+	return c.getFunctionStatus(name, "Checking status of lambda function")
+}
+
+// getFunctionStatus is called without a spinner (without a status message)
+// while waiting for the function, as the wait reports its own progress
+func (c cliClient) getFunctionStatus(name, statusMessage string) (*FunctionStatus, error) {
+	args := []string{
+		"lambda",
+		"get-function-configuration",
+		"--function-name", name,
+		"--output", "json",
+	}
+	var output []byte
+	var err error
+	if statusMessage == "" {
+		output, err = c.executeSilently(args)
+	} else {
+		output, err = c.executeWithResult(args, statusMessage)
+	}
+	if err != nil {
+		return nil, err
+	}
+	status := &FunctionStatus{}
+	if err := json.Unmarshal(output, status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+func (c cliClient) CreateFunction(function *Function) error {
+	args := []string{
+		"lambda",
+		"create-function",
+		"--function-name", function.Name,
+		"--runtime", function.Runtime,
+		"--role", function.Role,
+		"--handler", function.Handler,
+		"--package-type", "Zip",
+		"--zip-file", fmt.Sprintf("fileb://%s", function.Archive),
+		"--architectures", function.Architecture,
+		"--tags", getTagArgs(function.Tags),
+	}
+	if function.Memory != 0 {
+		args = append(args, "--memory-size", fmt.Sprintf("%d", function.Memory))
+	}
+	if function.Timeout != 0 {
+		args = append(args, "--timeout", fmt.Sprintf("%d", function.Timeout))
+	}
+	configuration, err := getConfigurationArgs(function.Configuration)
+	if err != nil {
+		return err
+	}
+	return c.execute(append(args, configuration...), "Creating new lambda function")
+}
+
+func (c cliClient) UpdateFunctionCode(name, archive, architecture string) error {
+	return c.execute([]string{
+		"lambda",
+		"update-function-code",
+		"--function-name", name,
+		"--zip-file", fmt.Sprintf("fileb://%s", archive),
+		"--architectures", architecture,
+	}, "Updating lambda function code")
+}
+
+func (c cliClient) UpdateFunctionConfiguration(name string, configuration *FunctionConfiguration) error {
+	args, err := getConfigurationArgs(configuration)
+	if err != nil {
+		return err
+	}
+	return c.execute(append([]string{
+		"lambda",
+		"update-function-configuration",
+		"--function-name", name,
+	}, args...), "Updating lambda function configuration")
+}
+
+// getConfigurationArgs returns the flags that set a function's configuration
+func getConfigurationArgs(configuration *FunctionConfiguration) ([]string, error) {
+	args := []string{}
+	if configuration == nil {
+		return args, nil
+	}
+	if len(configuration.Environment) != 0 {
+		data, err := json.Marshal(map[string]map[string]string{
+			"Variables": configuration.Environment,
+		})
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--environment", string(data))
+	}
+	if len(configuration.SubnetIDs) != 0 {
+		args = append(args, "--vpc-config", fmt.Sprintf("SubnetIds=%s,SecurityGroupIds=%s",
+			strings.Join(configuration.SubnetIDs, ","),
+			strings.Join(configuration.SecurityGroupIDs, ","),
+		))
+	}
+	if len(configuration.Layers) != 0 {
+		args = append(append(args, "--layers"), configuration.Layers...)
+	}
+	if configuration.TracingMode != "" {
+		args = append(args, "--tracing-config", "Mode="+configuration.TracingMode)
+	}
+	return args, nil
+}
+
+func (c cliClient) WaitForFunction(name string, updated bool, timeout time.Duration, check func(*FunctionStatus) (bool, error)) error {
+	start := time.Now()
+	for {
+		status, err := c.getFunctionStatus(name, "")
+		if err != nil {
+			return err
+		}
+		done, err := check(status)
+		if done || err != nil {
+			return err
+		}
+		if time.Since(start) >= timeout {
+			return ErrWaitTimeout
+		}
+		time.Sleep(functionPollInterval)
+	}
+}
+
+func (c cliClient) DeleteFunction(name string) error {
+	return c.execute([]string{
+		"lambda",
+		"delete-function",
+		"--function-name", name,
+	}, "Deleting lambda function")
+}
+
+func (c cliClient) AddPermission(permission *Permission) error {
+	return c.execute([]string{
+		"lambda",
+		"add-permission",
+		"--function-name", permission.FunctionName,
+		"--statement-id", permission.StatementID,
+		"--action", permission.Action,
+		"--principal", permission.Principal,
+		"--source-arn", permission.SourceArn,
+	}, fmt.Sprintf("Setting lambda permissions: %s", permission.StatementID))
+}
+
+func (c cliClient) RemovePermission(functionName, statementID string) error {
+	return c.execute([]string{
+		"lambda",
+		"remove-permission",
+		"--function-name", functionName,
+		"--statement-id", statementID,
+	}, "Removing lambda permission")
+}
+
+func (c cliClient) TagResource(arn string, tags map[string]string) error {
+	return c.execute([]string{
+		"lambda",
+		"tag-resource",
+		"--resource", arn,
+		"--tags", getTagArgs(tags),
+	}, "Tagging lambda function")
+}
+
+// getTagArgs returns tags in the Key=Value,... shorthand
+func getTagArgs(tags map[string]string) string {
+	args := []string{}
+	for key, value := range tags {
+		args = append(args, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(args)
+	return strings.Join(args, ",")
+}
+
+func (c cliClient) GetRestApis() (map[string]string, error) {
+	output, err := c.executeCached([]string{
+		"apigateway",
+		"get-rest-apis",
+	}, "Collecting available REST APIs")
+	if err != nil {
+		return nil, err
+	}
+	var results struct {
+		Items []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(output, &results); err != nil {
+		return nil, err
+	}
+	restApis := map[string]string{}
+	for _, restApi := range results.Items {
+		restApis[restApi.Name] = restApi.ID
+	}
+	return restApis, nil
+}
+
+func (c cliClient) GetRestApi(restApiID string) error {
+	return c.execute([]string{
+		"apigateway",
+		"get-rest-api",
+		"--rest-api-id", restApiID,
+	}, fmt.Sprintf("Checking the REST API: %s", restApiID))
+}
+
+func (c cliClient) CreateRestApi(name string) (string, error) {
+	output, err := c.executeWithResult([]string{
+		"apigateway",
+		"create-rest-api",
+		"--name", name,
+	}, fmt.Sprintf("Creating a REST API called: %s", name))
+	if err != nil {
+		return "", err
+	}
+	// The cached list of REST APIs does not have the new API
+	cli.ClearCache()
+
+	var result struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return "", err
+	}
+	return result.ID, nil
+}
+
+func (c cliClient) UpdateRestApi(restApiID string, operation *PatchOperation) error {
+	return c.execute([]string{
+		"apigateway",
+		"update-rest-api",
+		"--rest-api-id", restApiID,
+		"--patch-operations", fmt.Sprintf("op=replace,path=%s,value=%s", operation.Path, operation.Value),
+	}, "Updating the REST API")
+}
+
+func (c cliClient) GetResources(restApiID string) ([]*Resource, error) {
+	output, err := c.executeWithResult([]string{
+		"apigateway",
+		"get-resources",
+		"--rest-api-id", restApiID,
+		"--embed", "methods",
+	}, "Collecting API resources")
+	if err != nil {
+		return nil, err
+	}
+	var results struct {
+		Items []struct {
+			Path            string `json:"path"`
+			ID              string `json:"id"`
+			ResourceMethods struct {
+				POST *struct {
+					MethodIntegration *struct {
+						URI string `json:"uri"`
+					} `json:"methodIntegration"`
+				} `json:"POST"`
+			} `json:"resourceMethods"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(output, &results); err != nil {
+		return nil, err
+	}
+	resources := []*Resource{}
+	for _, result := range results.Items {
+		resource := &Resource{
+			ID:            result.ID,
+			Path:          result.Path,
+			HasPostMethod: result.ResourceMethods.POST != nil,
+		}
+		if resource.HasPostMethod && result.ResourceMethods.POST.MethodIntegration != nil {
+			resource.IntegrationURI = result.ResourceMethods.POST.MethodIntegration.URI
+		}
+		resources = append(resources, resource)
+	}
+	return resources, nil
+}
+
+func (c cliClient) GetResource(restApiID, resourceID string) error {
+	return c.execute([]string{
+		"apigateway",
+		"get-resource",
+		"--rest-api-id", restApiID,
+		"--resource-id", resourceID,
+	}, fmt.Sprintf("Checking the API resource: %s", resourceID))
+}
+
+func (c cliClient) CreateResource(restApiID, parentID, pathPart string) (string, error) {
+	output, err := c.executeWithResult([]string{
+		"apigateway",
+		"create-resource",
+		"--rest-api-id", restApiID,
+		"--path-part", pathPart,
+		"--parent-id", parentID,
+	}, fmt.Sprintf("Creating /%s API resource", pathPart))
+	if err != nil {
+		return "", err
+	}
+	var result struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return "", err
+	}
+	return result.ID, nil
+}
+
+func (c cliClient) DeleteResource(restApiID, resourceID string) error {
+	return c.execute([]string{
+		"apigateway",
+		"delete-resource",
+		"--rest-api-id", restApiID,
+		"--resource-id", resourceID,
+	}, "Deleting REST API resource")
+}
+
+func (c cliClient) PutMethod(method *Method) error {
+	apiKeySetting := "--no-api-key-required"
+	if method.ApiKeyRequired {
+		apiKeySetting = "--api-key-required"
+	}
+	return c.execute([]string{
+		"apigateway",
+		"put-method",
+		"--rest-api-id", method.RestApiID,
+		"--resource-id", method.ResourceID,
+		"--http-method", method.HttpMethod,
+		"--authorization-type", method.AuthorizationType,
+		apiKeySetting,
+	}, fmt.Sprintf("Adding a %s method to the API resource", method.HttpMethod))
+}
+
+func (c cliClient) PutMethodResponse(response *MethodResponse) error {
+	args := []string{
+		"apigateway",
+		"put-method-response",
+		"--rest-api-id", response.RestApiID,
+		"--resource-id", response.ResourceID,
+		"--http-method", response.HttpMethod,
+		"--status-code", response.StatusCode,
+	}
+	if len(response.ResponseModels) != 0 {
+		args = append(args, "--response-models", getTagArgs(response.ResponseModels))
+	}
+	return c.execute(args, fmt.Sprintf("Setting the method's %s response", response.StatusCode))
+}
+
+func (c cliClient) PutIntegration(integration *Integration) error {
+	return c.execute([]string{
+		"apigateway",
+		"put-integration",
+		"--rest-api-id", integration.RestApiID,
+		"--resource-id", integration.ResourceID,
+		"--http-method", integration.HttpMethod,
+		"--type", integration.Type,
+		"--integration-http-method", integration.IntegrationHttpMethod,
+		"--timeout-in-millis", fmt.Sprintf("%d", integration.TimeoutInMillis),
+		"--uri", integration.URI,
+	}, "Integrating the lambda function with the API resource")
+}
+
+func (c cliClient) PutIntegrationResponse(response *IntegrationResponse) error {
+	args := []string{
+		"apigateway",
+		"put-integration-response",
+		"--rest-api-id", response.RestApiID,
+		"--resource-id", response.ResourceID,
+		"--http-method", response.HttpMethod,
+		"--status-code", response.StatusCode,
+	}
+	if response.SelectionPattern != "" {
+		args = append(args, "--selection-pattern", response.SelectionPattern)
+	}
+	if len(response.ResponseTemplates) != 0 {
+		// The templates are passed as JSON, as they can be empty
+		data, err := json.Marshal(response.ResponseTemplates)
+		if err != nil {
+			return err
+		}
+		args = append(args, "--response-templates", string(data))
+	}
+	return c.execute(args, fmt.Sprintf("Setting the integration's %s response", response.StatusCode))
+}
+
+func (c cliClient) CreateDeployment(restApiID, stage string) error {
+	return c.execute([]string{
+		"apigateway",
+		"create-deployment",
+		"--rest-api-id", restApiID,
+		"--stage-name", stage,
+	}, "Deploying the REST API")
+}
+
+// withRegion adds the client's region to the arguments of an aws cli command
+func (c cliClient) withRegion(args []string) []string {
+	if c.region == "" {
+		return args
+	}
+	return append(append([]string{}, args...), "--region", c.region)
+}
+
+func (c cliClient) execute(args []string, statusMessage string) error {
+	return cli.Execute("aws", c.withRegion(args), statusMessage)
+}
+
+func (c cliClient) executeWithResult(args []string, statusMessage string) ([]byte, error) {
+	return cli.ExecuteWithResult("aws", c.withRegion(args), statusMessage)
+}
+
+func (c cliClient) executeCached(args []string, statusMessage string) ([]byte, error) {
+	return cli.ExecuteCached("aws", c.withRegion(args), statusMessage)
+}
+
+func (c cliClient) executeSilently(args []string) ([]byte, error) {
+	return cli.ExecuteSilently("aws", c.withRegion(args))
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithRegion(t *testing.T) {
+	tests := []struct {
+		name   string
+		region string
+		args   []string
+		want   []string
+	}{
+		{
+			name: "the aws cli's region",
+			args: []string{"lambda", "get-function", "--function-name", "hello"},
+			want: []string{"lambda", "get-function", "--function-name", "hello"},
+		},
+		{
+			name:   "the client's region",
+			region: "eu-west-1",
+			args:   []string{"lambda", "get-function", "--function-name", "hello"},
+			want:   []string{"lambda", "get-function", "--function-name", "hello", "--region", "eu-west-1"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			args := append([]string{}, test.args...)
+			got := cliClient{region: test.region}.withRegion(args)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("withRegion() = %v, want %v", got, test.want)
+			}
+			if !reflect.DeepEqual(args, test.args) {
+				t.Errorf("withRegion() changed its arguments to %v", args)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+// Package client calls the AWS APIs that Lambda functions are deployed with (Lambda,
+// API Gateway, IAM, and STS). They are called with the AWS SDK, which finds the
+// credentials, profile (AWS_PROFILE), and region like the aws cli does, or with
+// the aws cli, with --aws-cli (or aws_cli: true in ~/.kettle.yaml)
+package client
+
+import (
+	"errors"
+	"time"
+
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+// functionPollInterval is how often a function is polled while waiting for it
+const functionPollInterval = 2 * time.Second
+
+// ErrWaitTimeout is returned when a function is not ready before the wait's timeout
+var ErrWaitTimeout = errors.New("timed out waiting for the function")
+
+type Client interface {
+	GetCallerIdentity() (*CallerIdentity, error)
+
+	ListRoles() ([]*Role, error)
+	GetRole(name string) (*Role, error)
+	CreateRole(name, trustPolicy string) (*Role, error)
+	AttachRolePolicy(roleName, policyArn string) error
+	PutRolePolicy(roleName, policyName, document string) error
+	DeleteRolePolicy(roleName, policyName string) error
+
+	GetFunction(name string) (*FunctionStatus, error)
+	CreateFunction(function *Function) error
+	UpdateFunctionCode(name, archive, architecture string) error
+	UpdateFunctionConfiguration(name string, configuration *FunctionConfiguration) error
+	// WaitForFunction polls the function (until it is active, or its update has
+	// completed) until check returns true, or an error, or the timeout passes
+	WaitForFunction(name string, updated bool, timeout time.Duration, check func(*FunctionStatus) (bool, error)) error
+	DeleteFunction(name string) error
+	AddPermission(permission *Permission) error
+	RemovePermission(functionName, statementID string) error
+	TagResource(arn string, tags map[string]string) error
+
+	// GetRestApis returns the IDs of the REST APIs, by their names
+	GetRestApis() (map[string]string, error)
+	GetRestApi(restApiID string) error
+	CreateRestApi(name string) (string, error)
+	UpdateRestApi(restApiID string, operation *PatchOperation) error
+	GetResources(restApiID string) ([]*Resource, error)
+	GetResource(restApiID, resourceID string) error
+	CreateResource(restApiID, parentID, pathPart string) (string, error)
+	DeleteResource(restApiID, resourceID string) error
+	PutMethod(method *Method) error
+	PutMethodResponse(response *MethodResponse) error
+	PutIntegration(integration *Integration) error
+	PutIntegrationResponse(response *IntegrationResponse) error
+	CreateDeployment(restApiID, stage string) error
+}
+
+// Get returns the client that calls the APIs in a region (or, if it is empty,
+// the region of AWS_REGION, which kettle sets to the deployment region, or of
+// the profile); both the SDK and the aws cli use the same region
+func Get(region string) (Client, error) {
+	if settings.AWSCli {
+		return cliClient{region: region}, nil
+	}
+	return getSDKClient(region)
+}
+
+type CallerIdentity struct {
+	Account string
+	Arn     string
+}
+
+// Role is an IAM role, and the service that it trusts (which can assume it)
+
+type Role struct {
+	Name           string
+	Path           string
+	Arn            string
+	TrustedService string
+}
+
+// Function is the definition of a Lambda function that is created from a .zip archive
+
+type Function struct {
+	Name          string
+	Runtime       string
+	Role          string
+	Handler       string
+	Archive       string
+	Architecture  string
+	Memory        int
+	Timeout       int
+	Tags          map[string]string
+	Configuration *FunctionConfiguration
+}
+
+// FunctionConfiguration is the configuration of a function that
+// is changed after its code, when it is updated
+
+type FunctionConfiguration struct {
+	Environment      map[string]string
+	SubnetIDs        []string
+	SecurityGroupIDs []string
+	Layers           []string
+	// TracingMode is Active to sample requests with X-Ray
+	TracingMode string
+}
+
+// IsEmpty returns true if the configuration does not change anything
+func (c *FunctionConfiguration) IsEmpty() bool {
+	return len(c.Environment) == 0 && len(c.SubnetIDs) == 0 && len(c.Layers) == 0 && c.TracingMode == ""
+}
+
+// FunctionStatus is the state of a function, and of its last update
+
+type FunctionStatus struct {
+	State                  string
+	StateReason            string
+	LastUpdateStatus       string
+	LastUpdateStatusReason string
+}
+
+// Permission allows a service (e.g. API Gateway) to invoke a function
+
+type Permission struct {
+	FunctionName string
+	StatementID  string
+	Action       string
+	Principal    string
+	SourceArn    string
+}
+
+// PatchOperation replaces a value of a REST API, e.g. /minimumCompressionSize
+
+type PatchOperation struct {
+	Path  string
+	Value string
+}
+
+// Resource is a path of a REST API
+
+type Resource struct {
+	ID             string
+	Path           string
+	HasPostMethod  bool
+	IntegrationURI string
+}
+
+type Method struct {
+	RestApiID         string
+	ResourceID        string
+	HttpMethod        string
+	AuthorizationType string
+	ApiKeyRequired    bool
+}
+
+type MethodResponse struct {
+	RestApiID      string
+	ResourceID     string
+	HttpMethod     string
+	StatusCode     string
+	ResponseModels map[string]string
+}
+
+// Integration sends the requests of a method to a function
+
+type Integration struct {
+	RestApiID             string
+	ResourceID            string
+	HttpMethod            string
+	Type                  string
+	IntegrationHttpMethod string
+	URI                   string
+	TimeoutInMillis       int
+}
+
+type IntegrationResponse struct {
+	RestApiID         string
+	ResourceID        string
+	HttpMethod        string
+	StatusCode        string
+	SelectionPattern  string
+	ResponseTemplates map[string]string
+}
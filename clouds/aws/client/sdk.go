@@ -0,0 +1,664 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/apigateway"
+	apigatewaytypes "github.com/aws/aws-sdk-go-v2/service/apigateway/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
+
+	"github.com/operatorai/kettle-cli/cli"
+)
+
+// defaultRegion is the region of the global services (IAM and STS) that is
+// used if neither the settings nor the profile (or AWS_REGION) have a region,
+// e.g. to look up the account before the region has been chosen
+const defaultRegion = "us-east-1"
+
+// sdkClient calls the APIs with the AWS SDK
+type sdkClient struct {
+	region     string
+	lambda     *lambda.Client
+	apigateway *apigateway.Client
+	iam        *iam.Client
+	sts        *sts.Client
+}
+
+// sdkClients are the clients that have been loaded, by their region
+// and the credentials (the AWS_ variables) that they were loaded with
+var sdkClients = map[string]*sdkClient{}
+
+// getSDKClient loads the SDK's config like the aws cli does: from the AWS_
+// variables (e.g. the AWS_PROFILE that a stage sets, or the AWS_ENDPOINT_URL_
+// variables of the settings' endpoints), and from ~/.aws/config and credentials
+func getSDKClient(region string) (Client, error) {
+	key := strings.Join(append(getCredentialVariables(), region), "\x00")
+	if client, ok := sdkClients[key]; ok {
+		return client, nil
+	}
+	options := []func(*config.LoadOptions) error{}
+	if region != "" {
+		options = append(options, config.WithRegion(region))
+	}
+	cfg, err := config.LoadDefaultConfig(context.Background(), options...)
+	if err != nil {
+		return nil, fmt.Errorf("could not load the aws config: %w", err)
+	}
+	if cfg.Region == "" {
+		cfg.Region = defaultRegion
+	}
+	client := &sdkClient{
+		region:     cfg.Region,
+		lambda:     lambda.NewFromConfig(cfg),
+		apigateway: apigateway.NewFromConfig(cfg),
+		iam:        iam.NewFromConfig(cfg),
+		sts:        sts.NewFromConfig(cfg),
+	}
+	sdkClients[key] = client
+	return client, nil
+}
+
+// getCredentialVariables returns the AWS_ variables, which choose the
+// credentials, account, and endpoints that the APIs are called with
+func getCredentialVariables() []string {
+	variables := []string{}
+	for _, variable := range os.Environ() {
+		if strings.HasPrefix(variable, "AWS_") {
+			variables = append(variables, variable)
+		}
+	}
+	sort.Strings(variables)
+	return variables
+}
+
+// apiError is an error that an AWS API returned; it is printed like the aws cli
+// prints it, and its ErrorCode (e.g. ResourceNotFoundException) is the API's
+type apiError struct {
+	operation string
+	err       smithy.APIError
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("aws: An error occurred (%s) when calling the %s operation: %s", e.err.ErrorCode(), e.operation, e.err.ErrorMessage())
+}
+
+func (e *apiError) ErrorCode() string {
+	return e.err.ErrorCode()
+}
+
+func (e *apiError) Unwrap() error {
+	return e.err
+}
+
+// call calls an API's operation (with cli.CallAPI), and converts the errors
+// that the API returns; the other errors (e.g. the credentials') are returned
+// as they are
+func (c *sdkClient) call(service, operation, statusMessage string, call func(ctx context.Context) error) error {
+	err := cli.CallAPI("aws", service, operation, statusMessage, call)
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return &apiError{operation: operation, err: apiErr}
+	}
+	return err
+}
+
+// cached calls a lookup (e.g. listing roles) whose result is cached like the aws
+// cli's lookups are (see cli.ExecuteCached), and decodes it into result
+func (c *sdkClient) cached(service, operation, statusMessage string, result interface{}, lookup func(ctx context.Context) (interface{}, error)) error {
+	key := strings.Join(append([]string{"aws", service, operation, c.region}, getCredentialVariables()...), "\x00")
+	output, err := cli.CacheLookup(key, cli.CacheTTL, func() ([]byte, error) {
+		var value interface{}
+		err := c.call(service, operation, statusMessage, func(ctx context.Context) error {
+			var err error
+			value, err = lookup(ctx)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(value)
+	})
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(output, result)
+}
+
+func (c *sdkClient) GetCallerIdentity() (*CallerIdentity, error) {
+	identity := &CallerIdentity{}
+	err := c.cached("sts", "GetCallerIdentity", "Retrieving aws caller identity", identity, func(ctx context.Context) (interface{}, error) {
+		output, err := c.sts.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+		if err != nil {
+			return nil, err
+		}
+		return &CallerIdentity{
+			Account: aws.ToString(output.Account),
+			Arn:     aws.ToString(output.Arn),
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+func (c *sdkClient) ListRoles() ([]*Role, error) {
+	roles := []*Role{}
+	err := c.cached("iam", "ListRoles", "Collecting available IAM roles", &roles, func(ctx context.Context) (interface{}, error) {
+		roles := []*Role{}
+		paginator := iam.NewListRolesPaginator(c.iam, &iam.ListRolesInput{})
+		for paginator.HasMorePages() {
+			output, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for _, role := range output.Roles {
+				roles = append(roles, &Role{
+					Name:           aws.ToString(role.RoleName),
+					Path:           aws.ToString(role.Path),
+					Arn:            aws.ToString(role.Arn),
+					TrustedService: getTrustedService(aws.ToString(role.AssumeRolePolicyDocument)),
+				})
+			}
+		}
+		return roles, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// getTrustedService returns the service that a role's trust policy allows to
+// assume it; the API returns the policy URL encoded, unlike the aws cli
+func getTrustedService(document string) string {
+	if decoded, err := url.PathUnescape(document); err == nil {
+		document = decoded
+	}
+	var policy struct {
+		Statement []struct {
+			Principal struct {
+				Service interface{} `json:"Service"`
+			} `json:"Principal"`
+		} `json:"Statement"`
+	}
+	if err := json.Unmarshal([]byte(document), &policy); err != nil || len(policy.Statement) == 0 {
+		return ""
+	}
+	service, _ := policy.Statement[0].Principal.Service.(string)
+	return service
+}
+
+func (c *sdkClient) GetRole(name string) (*Role, error) {
+	var role *Role
+	err := c.call("iam", "GetRole", fmt.Sprintf("Checking the IAM role: %s", name), func(ctx context.Context) error {
+		output, err := c.iam.GetRole(ctx, &iam.GetRoleInput{RoleName: aws.String(name)})
+		if err != nil {
+			return err
+		}
+		role = &Role{
+			Name:           aws.ToString(output.Role.RoleName),
+			Path:           aws.ToString(output.Role.Path),
+			Arn:            aws.ToString(output.Role.Arn),
+			TrustedService: getTrustedService(aws.ToString(output.Role.AssumeRolePolicyDocument)),
+		}
+		return nil
+	})
+	return role, err
+}
+
+func (c *sdkClient) CreateRole(name, trustPolicy string) (*Role, error) {
+	var role *Role
+	err := c.call("iam", "CreateRole", fmt.Sprintf("Creating an IAM role called: %s", name), func(ctx context.Context) error {
+		output, err := c.iam.CreateRole(ctx, &iam.CreateRoleInput{
+			RoleName:                 aws.String(name),
+			AssumeRolePolicyDocument: aws.String(trustPolicy),
+		})
+		if err != nil {
+			return err
+		}
+		role = &Role{
+			Name: aws.ToString(output.Role.RoleName),
+			Path: aws.ToString(output.Role.Path),
+			Arn:  aws.ToString(output.Role.Arn),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The cached list of roles does not have the new role
+	cli.ClearCache()
+	return role, nil
+}
+
+func (c *sdkClient) AttachRolePolicy(roleName, policyArn string) error {
+	return c.call("iam", "AttachRolePolicy", fmt.Sprintf("Attaching %s to the IAM role", policyArn), func(ctx context.Context) error {
+		_, err := c.iam.AttachRolePolicy(ctx, &iam.AttachRolePolicyInput{
+			RoleName:  aws.String(roleName),
+			PolicyArn: aws.String(policyArn),
+		})
+		return err
+	})
+}
+
+func (c *sdkClient) PutRolePolicy(roleName, policyName, document string) error {
+	return c.call("iam", "PutRolePolicy", "Adding permissions to the IAM role", func(ctx context.Context) error {
+		_, err := c.iam.PutRolePolicy(ctx, &iam.PutRolePolicyInput{
+			RoleName:       aws.String(roleName),
+			PolicyName:     aws.String(policyName),
+			PolicyDocument: aws.String(document),
+		})
+		return err
+	})
+}
+
+func (c *sdkClient) DeleteRolePolicy(roleName, policyName string) error {
+	return c.call("iam", "DeleteRolePolicy", "Deleting execution role policy", func(ctx context.Context) error {
+		_, err := c.iam.DeleteRolePolicy(ctx, &iam.DeleteRolePolicyInput{
+			RoleName:   aws.String(roleName),
+			PolicyName: aws.String(policyName),
+		})
+		return err
+	})
+}
+
+func (c *sdkClient) GetFunction(name string) (*FunctionStatus, error) {
+	var status *FunctionStatus
+	err := c.call("lambda", "GetFunction", "Checking status of lambda function", func(ctx context.Context) error {
+		output, err := c.lambda.GetFunction(ctx, &lambda.GetFunctionInput{FunctionName: aws.String(name)})
+		if err != nil {
+			return err
+		}
+		status = getFunctionStatusFromOutput(output)
+		return nil
+	})
+	return status, err
+}
+
+func getFunctionStatusFromOutput(output *lambda.GetFunctionOutput) *FunctionStatus {
+	if output == nil || output.Configuration == nil {
+		return &FunctionStatus{}
+	}
+	return &FunctionStatus{
+		State:                  string(output.Configuration.State),
+		StateReason:            aws.ToString(output.Configuration.StateReason),
+		LastUpdateStatus:       string(output.Configuration.LastUpdateStatus),
+		LastUpdateStatusReason: aws.ToString(output.Configuration.LastUpdateStatusReason),
+	}
+}
+
+func (c *sdkClient) CreateFunction(function *Function) error {
+	archive, err := ioutil.ReadFile(function.Archive)
+	if err != nil {
+		return err
+	}
+	input := &lambda.CreateFunctionInput{
+		FunctionName:  aws.String(function.Name),
+		Runtime:       lambdatypes.Runtime(function.Runtime),
+		Role:          aws.String(function.Role),
+		Handler:       aws.String(function.Handler),
+		PackageType:   lambdatypes.PackageTypeZip,
+		Code:          &lambdatypes.FunctionCode{ZipFile: archive},
+		Architectures: []lambdatypes.Architecture{lambdatypes.Architecture(function.Architecture)},
+		Tags:          function.Tags,
+	}
+	if function.Memory != 0 {
+		input.MemorySize = aws.Int32(int32(function.Memory))
+	}
+	if function.Timeout != 0 {
+		input.Timeout = aws.Int32(int32(function.Timeout))
+	}
+	if configuration := function.Configuration; configuration != nil {
+		update := getConfigurationInput(function.Name, configuration)
+		input.Environment = update.Environment
+		input.VpcConfig = update.VpcConfig
+		input.Layers = update.Layers
+		input.TracingConfig = update.TracingConfig
+	}
+	return c.call("lambda", "CreateFunction", "Creating new lambda function", func(ctx context.Context) error {
+		_, err := c.lambda.CreateFunction(ctx, input)
+		return err
+	})
+}
+
+func (c *sdkClient) UpdateFunctionCode(name, archive, architecture string) error {
+	zipFile, err := ioutil.ReadFile(archive)
+	if err != nil {
+		return err
+	}
+	return c.call("lambda", "UpdateFunctionCode", "Updating lambda function code", func(ctx context.Context) error {
+		_, err := c.lambda.UpdateFunctionCode(ctx, &lambda.UpdateFunctionCodeInput{
+			FunctionName:  aws.String(name),
+			ZipFile:       zipFile,
+			Architectures: []lambdatypes.Architecture{lambdatypes.Architecture(architecture)},
+		})
+		return err
+	})
+}
+
+func (c *sdkClient) UpdateFunctionConfiguration(name string, configuration *FunctionConfiguration) error {
+	return c.call("lambda", "UpdateFunctionConfiguration", "Updating lambda function configuration", func(ctx context.Context) error {
+		_, err := c.lambda.UpdateFunctionConfiguration(ctx, getConfigurationInput(name, configuration))
+		return err
+	})
+}
+
+// getConfigurationInput returns the input that sets a function's configuration;
+// the settings that the configuration does not have are left unchanged
+func getConfigurationInput(name string, configuration *FunctionConfiguration) *lambda.UpdateFunctionConfigurationInput {
+	input := &lambda.UpdateFunctionConfigurationInput{FunctionName: aws.String(name)}
+	if len(configuration.Environment) != 0 {
+		input.Environment = &lambdatypes.Environment{Variables: configuration.Environment}
+	}
+	if len(configuration.SubnetIDs) != 0 {
+		input.VpcConfig = &lambdatypes.VpcConfig{
+			SubnetIds:        configuration.SubnetIDs,
+			SecurityGroupIds: configuration.SecurityGroupIDs,
+		}
+	}
+	if len(configuration.Layers) != 0 {
+		input.Layers = configuration.Layers
+	}
+	if configuration.TracingMode != "" {
+		input.TracingConfig = &lambdatypes.TracingConfig{Mode: lambdatypes.TracingMode(configuration.TracingMode)}
+	}
+	return input
+}
+
+// WaitForFunction waits with Lambda's function-active-v2 (or function-updated-v2)
+// waiter, whose states are checked by check, instead of by the waiter's own rules
+func (c *sdkClient) WaitForFunction(name string, updated bool, timeout time.Duration, check func(*FunctionStatus) (bool, error)) error {
+	// stopErr is the error that stopped the wait, if it did not time out
+	var stopErr error
+	retryable := func(ctx context.Context, input *lambda.GetFunctionInput, output *lambda.GetFunctionOutput, err error) (bool, error) {
+		if err != nil {
+			stopErr = err
+			return false, err
+		}
+		done, err := check(getFunctionStatusFromOutput(output))
+		if err != nil {
+			stopErr = err
+			return false, err
+		}
+		return !done, nil
+	}
+	input := &lambda.GetFunctionInput{FunctionName: aws.String(name)}
+	operation := "WaitFunctionActiveV2"
+	if updated {
+		operation = "WaitFunctionUpdatedV2"
+	}
+	err := c.call("lambda", operation, "", func(ctx context.Context) error {
+		if updated {
+			return lambda.NewFunctionUpdatedV2Waiter(c.lambda, func(options *lambda.FunctionUpdatedV2WaiterOptions) {
+				options.MinDelay = functionPollInterval
+				options.MaxDelay = functionPollInterval
+				options.Retryable = retryable
+			}).Wait(ctx, input, timeout)
+		}
+		return lambda.NewFunctionActiveV2Waiter(c.lambda, func(options *lambda.FunctionActiveV2WaiterOptions) {
+			options.MinDelay = functionPollInterval
+			options.MaxDelay = functionPollInterval
+			options.Retryable = retryable
+		}).Wait(ctx, input, timeout)
+	})
+	if err != nil && stopErr == nil {
+		return ErrWaitTimeout
+	}
+	return err
+}
+
+func (c *sdkClient) DeleteFunction(name string) error {
+	return c.call("lambda", "DeleteFunction", "Deleting lambda function", func(ctx context.Context) error {
+		_, err := c.lambda.DeleteFunction(ctx, &lambda.DeleteFunctionInput{FunctionName: aws.String(name)})
+		return err
+	})
+}
+
+func (c *sdkClient) AddPermission(permission *Permission) error {
+	return c.call("lambda", "AddPermission", fmt.Sprintf("Setting lambda permissions: %s", permission.StatementID), func(ctx context.Context) error {
+		_, err := c.lambda.AddPermission(ctx, &lambda.AddPermissionInput{
+			FunctionName: aws.String(permission.FunctionName),
+			StatementId:  aws.String(permission.StatementID),
+			Action:       aws.String(permission.Action),
+			Principal:    aws.String(permission.Principal),
+			SourceArn:    aws.String(permission.SourceArn),
+		})
+		return err
+	})
+}
+
+func (c *sdkClient) RemovePermission(functionName, statementID string) error {
+	return c.call("lambda", "RemovePermission", "Removing lambda permission", func(ctx context.Context) error {
+		_, err := c.lambda.RemovePermission(ctx, &lambda.RemovePermissionInput{
+			FunctionName: aws.String(functionName),
+			StatementId:  aws.String(statementID),
+		})
+		return err
+	})
+}
+
+func (c *sdkClient) TagResource(arn string, tags map[string]string) error {
+	return c.call("lambda", "TagResource", "Tagging lambda function", func(ctx context.Context) error {
+		_, err := c.lambda.TagResource(ctx, &lambda.TagResourceInput{
+			Resource: aws.String(arn),
+			Tags:     tags,
+		})
+		return err
+	})
+}
+
+func (c *sdkClient) GetRestApis() (map[string]string, error) {
+	restApis := map[string]string{}
+	err := c.cached("apigateway", "GetRestApis", "Collecting available REST APIs", &restApis, func(ctx context.Context) (interface{}, error) {
+		restApis := map[string]string{}
+		paginator := apigateway.NewGetRestApisPaginator(c.apigateway, &apigateway.GetRestApisInput{})
+		for paginator.HasMorePages() {
+			output, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for _, restApi := range output.Items {
+				restApis[aws.ToString(restApi.Name)] = aws.ToString(restApi.Id)
+			}
+		}
+		return restApis, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return restApis, nil
+}
+
+func (c *sdkClient) GetRestApi(restApiID string) error {
+	return c.call("apigateway", "GetRestApi", fmt.Sprintf("Checking the REST API: %s", restApiID), func(ctx context.Context) error {
+		_, err := c.apigateway.GetRestApi(ctx, &apigateway.GetRestApiInput{RestApiId: aws.String(restApiID)})
+		return err
+	})
+}
+
+func (c *sdkClient) CreateRestApi(name string) (string, error) {
+	var restApiID string
+	err := c.call("apigateway", "CreateRestApi", fmt.Sprintf("Creating a REST API called: %s", name), func(ctx context.Context) error {
+		output, err := c.apigateway.CreateRestApi(ctx, &apigateway.CreateRestApiInput{Name: aws.String(name)})
+		if err != nil {
+			return err
+		}
+		restApiID = aws.ToString(output.Id)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	// The cached list of REST APIs does not have the new API
+	cli.ClearCache()
+	return restApiID, nil
+}
+
+func (c *sdkClient) UpdateRestApi(restApiID string, operation *PatchOperation) error {
+	return c.call("apigateway", "UpdateRestApi", "Updating the REST API", func(ctx context.Context) error {
+		_, err := c.apigateway.UpdateRestApi(ctx, &apigateway.UpdateRestApiInput{
+			RestApiId: aws.String(restApiID),
+			PatchOperations: []apigatewaytypes.PatchOperation{{
+				Op:    apigatewaytypes.OpReplace,
+				Path:  aws.String(operation.Path),
+				Value: aws.String(operation.Value),
+			}},
+		})
+		return err
+	})
+}
+
+func (c *sdkClient) GetResources(restApiID string) ([]*Resource, error) {
+	resources := []*Resource{}
+	err := c.call("apigateway", "GetResources", "Collecting API resources", func(ctx context.Context) error {
+		paginator := apigateway.NewGetResourcesPaginator(c.apigateway, &apigateway.GetResourcesInput{
+			RestApiId: aws.String(restApiID),
+			Embed:     []string{"methods"},
+		})
+		for paginator.HasMorePages() {
+			output, err := paginator.NextPage(ctx)
+			if err != nil {
+				return err
+			}
+			for _, item := range output.Items {
+				resource := &Resource{
+					ID:   aws.ToString(item.Id),
+					Path: aws.ToString(item.Path),
+				}
+				if method, ok := item.ResourceMethods["POST"]; ok {
+					resource.HasPostMethod = true
+					if method.MethodIntegration != nil {
+						resource.IntegrationURI = aws.ToString(method.MethodIntegration.Uri)
+					}
+				}
+				resources = append(resources, resource)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+func (c *sdkClient) GetResource(restApiID, resourceID string) error {
+	return c.call("apigateway", "GetResource", fmt.Sprintf("Checking the API resource: %s", resourceID), func(ctx context.Context) error {
+		_, err := c.apigateway.GetResource(ctx, &apigateway.GetResourceInput{
+			RestApiId:  aws.String(restApiID),
+			ResourceId: aws.String(resourceID),
+		})
+		return err
+	})
+}
+
+func (c *sdkClient) CreateResource(restApiID, parentID, pathPart string) (string, error) {
+	var resourceID string
+	err := c.call("apigateway", "CreateResource", fmt.Sprintf("Creating /%s API resource", pathPart), func(ctx context.Context) error {
+		output, err := c.apigateway.CreateResource(ctx, &apigateway.CreateResourceInput{
+			RestApiId: aws.String(restApiID),
+			ParentId:  aws.String(parentID),
+			PathPart:  aws.String(pathPart),
+		})
+		if err != nil {
+			return err
+		}
+		resourceID = aws.ToString(output.Id)
+		return nil
+	})
+	return resourceID, err
+}
+
+func (c *sdkClient) DeleteResource(restApiID, resourceID string) error {
+	return c.call("apigateway", "DeleteResource", "Deleting REST API resource", func(ctx context.Context) error {
+		_, err := c.apigateway.DeleteResource(ctx, &apigateway.DeleteResourceInput{
+			RestApiId:  aws.String(restApiID),
+			ResourceId: aws.String(resourceID),
+		})
+		return err
+	})
+}
+
+func (c *sdkClient) PutMethod(method *Method) error {
+	return c.call("apigateway", "PutMethod", fmt.Sprintf("Adding a %s method to the API resource", method.HttpMethod), func(ctx context.Context) error {
+		_, err := c.apigateway.PutMethod(ctx, &apigateway.PutMethodInput{
+			RestApiId:         aws.String(method.RestApiID),
+			ResourceId:        aws.String(method.ResourceID),
+			HttpMethod:        aws.String(method.HttpMethod),
+			AuthorizationType: aws.String(method.AuthorizationType),
+			ApiKeyRequired:    method.ApiKeyRequired,
+		})
+		return err
+	})
+}
+
+func (c *sdkClient) PutMethodResponse(response *MethodResponse) error {
+	return c.call("apigateway", "PutMethodResponse", fmt.Sprintf("Setting the method's %s response", response.StatusCode), func(ctx context.Context) error {
+		_, err := c.apigateway.PutMethodResponse(ctx, &apigateway.PutMethodResponseInput{
+			RestApiId:      aws.String(response.RestApiID),
+			ResourceId:     aws.String(response.ResourceID),
+			HttpMethod:     aws.String(response.HttpMethod),
+			StatusCode:     aws.String(response.StatusCode),
+			ResponseModels: response.ResponseModels,
+		})
+		return err
+	})
+}
+
+func (c *sdkClient) PutIntegration(integration *Integration) error {
+	return c.call("apigateway", "PutIntegration", "Integrating the lambda function with the API resource", func(ctx context.Context) error {
+		_, err := c.apigateway.PutIntegration(ctx, &apigateway.PutIntegrationInput{
+			RestApiId:             aws.String(integration.RestApiID),
+			ResourceId:            aws.String(integration.ResourceID),
+			HttpMethod:            aws.String(integration.HttpMethod),
+			Type:                  apigatewaytypes.IntegrationType(integration.Type),
+			IntegrationHttpMethod: aws.String(integration.IntegrationHttpMethod),
+			TimeoutInMillis:       aws.Int32(int32(integration.TimeoutInMillis)),
+			Uri:                   aws.String(integration.URI),
+		})
+		return err
+	})
+}
+
+func (c *sdkClient) PutIntegrationResponse(response *IntegrationResponse) error {
+	input := &apigateway.PutIntegrationResponseInput{
+		RestApiId:         aws.String(response.RestApiID),
+		ResourceId:        aws.String(response.ResourceID),
+		HttpMethod:        aws.String(response.HttpMethod),
+		StatusCode:        aws.String(response.StatusCode),
+		ResponseTemplates: response.ResponseTemplates,
+	}
+	if response.SelectionPattern != "" {
+		input.SelectionPattern = aws.String(response.SelectionPattern)
+	}
+	return c.call("apigateway", "PutIntegrationResponse", fmt.Sprintf("Setting the integration's %s response", response.StatusCode), func(ctx context.Context) error {
+		_, err := c.apigateway.PutIntegrationResponse(ctx, input)
+		return err
+	})
+}
+
+func (c *sdkClient) CreateDeployment(restApiID, stage string) error {
+	return c.call("apigateway", "CreateDeployment", "Deploying the REST API", func(ctx context.Context) error {
+		_, err := c.apigateway.CreateDeployment(ctx, &apigateway.CreateDeploymentInput{
+			RestApiId: aws.String(restApiID),
+			StageName: aws.String(stage),
+		})
+		return err
+	})
+}
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/smithy-go"
+
+	"github.com/operatorai/kettle-cli/cli"
+)
+
+func TestCallErrors(t *testing.T) {
+	otherErr := errors.New("could not load the credentials")
+	tests := []struct {
+		name         string
+		err          error
+		wantMessage  string
+		wantCode     string
+		wantNotFound bool
+	}{
+		{
+			name:         "not found",
+			err:          &smithy.GenericAPIError{Code: "ResourceNotFoundException", Message: "Function not found: hello"},
+			wantMessage:  "aws: An error occurred (ResourceNotFoundException) when calling the GetFunction operation: Function not found: hello",
+			wantCode:     "ResourceNotFoundException",
+			wantNotFound: true,
+		},
+		{
+			name:         "no such entity",
+			err:          &smithy.GenericAPIError{Code: "NoSuchEntity", Message: "The role cannot be found"},
+			wantMessage:  "aws: An error occurred (NoSuchEntity) when calling the GetFunction operation: The role cannot be found",
+			wantCode:     "NoSuchEntity",
+			wantNotFound: true,
+		},
+		{
+			name:        "access denied",
+			err:         &smithy.GenericAPIError{Code: "AccessDeniedException", Message: "not authorized"},
+			wantMessage: "aws: An error occurred (AccessDeniedException) when calling the GetFunction operation: not authorized",
+			wantCode:    "AccessDeniedException",
+		},
+		{
+			name:        "not an API error",
+			err:         otherErr,
+			wantMessage: otherErr.Error(),
+		},
+	}
+	c := &sdkClient{}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := c.call("lambda", "GetFunction", "", func(ctx context.Context) error {
+				return test.err
+			})
+			if err == nil {
+				t.Fatal("call() = nil, want an error")
+			}
+			if err.Error() != test.wantMessage {
+				t.Errorf("call() = %q, want %q", err.Error(), test.wantMessage)
+			}
+			if code := cli.ErrorCode(err); code != test.wantCode {
+				t.Errorf("ErrorCode() = %q, want %q", code, test.wantCode)
+			}
+			if notFound := cli.IsNotFound(err); notFound != test.wantNotFound {
+				t.Errorf("IsNotFound() = %v, want %v", notFound, test.wantNotFound)
+			}
+			if !errors.Is(err, test.err) {
+				t.Errorf("call() does not wrap %v", test.err)
+			}
+		})
+	}
+	if err := c.call("lambda", "GetFunction", "", func(ctx context.Context) error { return nil }); err != nil {
+		t.Errorf("call() = %v, want nil", err)
+	}
+}
+
+// functionResponder answers GetFunction with a function in a state
+type functionResponder struct {
+	state string
+}
+
+func (r *functionResponder) Do(request *http.Request) (*http.Response, error) {
+	body := `{"Configuration": {"FunctionName": "hello", "State": "` + r.state + `", "LastUpdateStatus": "Successful"}}`
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    request,
+	}, nil
+}
+
+func TestWaitForFunction(t *testing.T) {
+	checkErr := errors.New("the function failed to start")
+	tests := []struct {
+		name    string
+		state   string
+		timeout time.Duration
+		check   func(*FunctionStatus) (bool, error)
+		wantErr error
+	}{
+		{
+			name:    "active",
+			state:   "Active",
+			timeout: time.Minute,
+			check:   func(status *FunctionStatus) (bool, error) { return status.State == "Active", nil },
+		},
+		{
+			name:    "failed",
+			state:   "Failed",
+			timeout: time.Minute,
+			check:   func(status *FunctionStatus) (bool, error) { return false, checkErr },
+			wantErr: checkErr,
+		},
+		{
+			name:    "timed out",
+			state:   "Pending",
+			timeout: time.Millisecond,
+			check:   func(status *FunctionStatus) (bool, error) { return status.State == "Active", nil },
+			wantErr: ErrWaitTimeout,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &sdkClient{
+				lambda: lambda.New(lambda.Options{
+					Region:      "us-east-1",
+					Credentials: aws.AnonymousCredentials{},
+					HTTPClient:  &functionResponder{state: test.state},
+				}),
+			}
+			err := c.WaitForFunction("hello", false, test.timeout, test.check)
+			if !errors.Is(err, test.wantErr) || (err == nil) != (test.wantErr == nil) {
+				t.Errorf("WaitForFunction() = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
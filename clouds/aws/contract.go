@@ -0,0 +1,34 @@
+package aws
+
+import (
+	"path/filepath"
+
+	"github.com/iancoleman/strcase"
+
+	"github.com/operatorai/kettle-cli/clouds/aws/apigateway"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/contract"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+// setRequestValidation validates the requests to the function's REST API
+// method against the request schema of the project's contract, and redeploys
+// the API (or its stages, which are deployed with their aliases)
+func setRequestValidation(directory string, cfg *config.Config, stg *settings.Settings) error {
+	if !cfg.ValidatesRequests() || cfg.Config.AWS.RestApiResourceID == "" || stg.AWS.RestApiID == "" {
+		return nil
+	}
+	schema, err := contract.ReadSchema(filepath.Join(directory, cfg.Config.Contract.Request))
+	if err != nil {
+		return err
+	}
+	// Models are named with letters and numbers only
+	modelName := strcase.ToCamel(cfg.ProjectName) + "Request"
+	if err := apigateway.SetRequestModel(stg.AWS.RestApiID, cfg.Config.AWS.RestApiResourceID, modelName, schema.JSON()); err != nil {
+		return err
+	}
+	if len(cfg.Config.ApiStages) != 0 {
+		return nil
+	}
+	return apigateway.Deploy(stg)
+}
@@ -0,0 +1,111 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+)
+
+// GetCosts returns the spend of each project and stage between the start and end
+// dates, from Cost Explorer; an empty project name returns all kettle projects
+// https://docs.aws.amazon.com/cli/latest/reference/ce/get-cost-and-usage.html
+func GetCosts(projectName string, start, end time.Time) ([]*config.Cost, error) {
+	// Resources that are not tagged with a project are not kettle's
+	filter := map[string]interface{}{
+		"Not": map[string]interface{}{
+			"Tags": map[string]interface{}{
+				"Key":          config.ProjectTag,
+				"MatchOptions": []string{"ABSENT"},
+			},
+		},
+	}
+	if projectName != "" {
+		filter = map[string]interface{}{
+			"Tags": map[string]interface{}{
+				"Key":    config.ProjectTag,
+				"Values": []string{projectName},
+			},
+		}
+	}
+	filterJSON, err := json.Marshal(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"ce",
+		"get-cost-and-usage",
+		// The end date is exclusive
+		"--time-period", fmt.Sprintf("Start=%s,End=%s", start.Format("2006-01-02"), end.AddDate(0, 0, 1).Format("2006-01-02")),
+		"--granularity", "MONTHLY",
+		"--metrics", "UnblendedCost",
+		"--filter", string(filterJSON),
+		"--group-by", fmt.Sprintf("Type=TAG,Key=%s", config.ProjectTag), fmt.Sprintf("Type=TAG,Key=%s", config.StageTag),
+		"--output", "json",
+	}, "Querying Cost Explorer")
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		ResultsByTime []struct {
+			Groups []struct {
+				Keys    []string `json:"Keys"`
+				Metrics struct {
+					UnblendedCost struct {
+						Amount string `json:"Amount"`
+						Unit   string `json:"Unit"`
+					} `json:"UnblendedCost"`
+				} `json:"Metrics"`
+			} `json:"Groups"`
+		} `json:"ResultsByTime"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, err
+	}
+
+	// Costs are summed across the months in the period
+	costs := map[string]*config.Cost{}
+	for _, period := range result.ResultsByTime {
+		for _, group := range period.Groups {
+			if len(group.Keys) != 2 {
+				continue
+			}
+			amount, err := strconv.ParseFloat(group.Metrics.UnblendedCost.Amount, 64)
+			if err != nil {
+				return nil, err
+			}
+			// Tag group keys are formatted as: key$value
+			project := tagGroupValue(group.Keys[0])
+			stage := tagGroupValue(group.Keys[1])
+			key := project + "/" + stage
+			if _, ok := costs[key]; !ok {
+				costs[key] = &config.Cost{
+					Project: project,
+					Stage:   stage,
+					Unit:    group.Metrics.UnblendedCost.Unit,
+				}
+			}
+			costs[key].Amount += amount
+		}
+	}
+
+	results := []*config.Cost{}
+	for _, cost := range costs {
+		results = append(results, cost)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Amount > results[j].Amount
+	})
+	return results, nil
+}
+
+func tagGroupValue(key string) string {
+	return key[strings.Index(key, "$")+1:]
+}
@@ -0,0 +1,262 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/clouds/aws/apigateway"
+	"github.com/operatorai/kettle-cli/clouds/aws/client"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+// destroyOrder is the order in which resources are deleted, so that
+// nothing is deleted while another resource still depends on it
+var destroyOrder = []string{
+	state.AWSBudget,
+	state.AWSCloudWatchAlarm,
+	state.AWSLogMetricFilter,
+	state.AWSSNSTopic,
+	state.AWSSyntheticsCanary,
+	state.AWSEventSourceMapping,
+	state.AWSEventsRule,
+	state.AWSS3Notification,
+	state.AWSLambdaPermission,
+	state.AWSApiBasePathMapping,
+	state.AWSRestApiResource,
+	state.AWSLambdaAlias,
+	state.AWSLambdaFunction,
+	state.AWSSQSQueue,
+	state.AWSIAMRolePolicy,
+	state.AWSSSMParameter,
+	state.AWSSecret,
+	state.AWSDynamoDBTable,
+	state.AWSS3Bucket,
+	state.AWSAuroraInstance,
+	state.AWSAuroraCluster,
+	state.AWSElastiCache,
+	state.AWSSecurityGroup,
+}
+
+// PlanDestroy lists the resources in the project's state that Destroy deletes, in order,
+// and the ones that it does not (e.g. the execution role and REST API, which are shared);
+// the resources of a previous name, if the project was renamed, are deleted first
+func (AWSLambdaFunction) PlanDestroy(directory string, cfg *config.Config) ([]*state.Resource, []*state.Resource, error) {
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return nil, nil, err
+	}
+	deleted, kept := st.PlanDestroy(destroyOrder, cfg.Config.Protected)
+	if st.Retiring != nil {
+		retiring := &state.State{Resources: st.Retiring.Resources}
+		retired, _ := retiring.PlanDestroy(destroyOrder, cfg.Config.Protected)
+		deleted = append(retired, deleted...)
+	}
+	return deleted, kept, nil
+}
+
+// Destroy deletes the resources in the project's state
+func (AWSLambdaFunction) Destroy(directory string, cfg *config.Config, stg *settings.Settings) error {
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return err
+	}
+	if st.Retiring != nil {
+		if err := deleteRetired(directory, cfg, stg); err != nil {
+			return err
+		}
+		if st, err = state.ReadState(directory); err != nil {
+			return err
+		}
+	}
+
+	deleted, kept := st.PlanDestroy(destroyOrder, cfg.Config.Protected)
+	for _, resource := range deleted {
+		if err := removeResource(st, resource, cfg, stg); err != nil {
+			return err
+		}
+		if err := state.WriteState(directory, st); err != nil {
+			return err
+		}
+	}
+
+	for _, resource := range kept {
+		fmt.Println("⏭   Not deleted: ", resource.Describe(cfg.Config.Protected))
+	}
+	return nil
+}
+
+// removeResource deletes a resource and removes it from the state; resources
+// that have already been deleted (e.g. by hand) are removed from the state too
+func removeResource(st *state.State, resource *state.Resource, cfg *config.Config, stg *settings.Settings) error {
+	if err := reportDestroyed(st, resource, stg, destroyResource(st, resource, cfg, stg)); err != nil {
+		return err
+	}
+	st.RemoveResource(resource.Type, resource.ID)
+	return nil
+}
+
+// reportDestroyed reports that a resource was deleted, or that it was already deleted;
+// a resource that is not found is only known to be deleted if it was looked up in the
+// region that it was created in, as it would not be found in any other region
+func reportDestroyed(st *state.State, resource *state.Resource, stg *settings.Settings, err error) error {
+	switch {
+	case cli.IsNotFound(err):
+		region := getRecordedRegion(st, resource)
+		if region != "" && region != stg.AWS.DeploymentRegion {
+			return fmt.Errorf("%s %s was deployed to %s, not to %s (destroy it with: --region %s)",
+				resource.Type, resource.ID, region, stg.AWS.DeploymentRegion, region)
+		}
+		fmt.Println("⏭   Already deleted: ", resource.Type, resource.ID)
+	case err != nil:
+		return err
+	default:
+		fmt.Println("🗑   Deleted: ", resource.Type, resource.ID)
+	}
+	return nil
+}
+
+// destroyResource deletes a resource; the state is the one that records it (and e.g. the
+// REST API that a REST API resource is in); the function, its permissions, its REST API
+// resource, and its role's policies are deleted with the client that deploys them (see client.Get)
+func destroyResource(st *state.State, resource *state.Resource, cfg *config.Config, stg *settings.Settings) error {
+	api, err := client.Get(stg.AWS.DeploymentRegion)
+	if err != nil {
+		return err
+	}
+	switch resource.Type {
+	case state.AWSEventSourceMapping:
+		return cli.Execute("aws", []string{
+			"lambda",
+			"delete-event-source-mapping",
+			"--uuid", resource.ID,
+		}, "Deleting queue trigger")
+	case state.AWSCloudWatchAlarm:
+		return cli.Execute("aws", []string{
+			"cloudwatch",
+			"delete-alarms",
+			"--alarm-names", resource.ID,
+		}, "Deleting CloudWatch alarm")
+	case state.AWSLogMetricFilter:
+		return deleteLogMetricFilter(resource.ID, cfg)
+	case state.AWSSNSTopic:
+		return cli.Execute("aws", []string{
+			"sns",
+			"delete-topic",
+			"--topic-arn", resource.ID,
+		}, "Deleting SNS topic")
+	case state.AWSBudget:
+		return deleteBudget(resource)
+	case state.AWSSyntheticsCanary:
+		return deleteCanary(resource.ID)
+	case state.AWSEventsRule:
+		if resource.Group == triggerGroup {
+			return deleteScheduleTrigger(resource.ID, cfg)
+		}
+		return deleteKeepWarmRule(resource.ID, cfg)
+	case state.AWSS3Notification:
+		return deleteBucketTrigger(resource.ID, cfg)
+	case state.AWSLambdaPermission:
+		return api.RemovePermission(cfg.ProjectName, resource.ID)
+	case state.AWSApiBasePathMapping:
+		return deleteBasePathMapping(resource.ID)
+	case state.AWSRestApiResource:
+		return deleteRestApiResource(api, st, resource.ID, cfg, stg)
+	case state.AWSLambdaAlias:
+		return cli.Execute("aws", []string{
+			"lambda",
+			"delete-alias",
+			"--function-name", cfg.ProjectName,
+			"--name", resource.ID,
+		}, "Deleting lambda alias")
+	case state.AWSLambdaFunction:
+		return api.DeleteFunction(resource.ID)
+	case state.AWSSQSQueue:
+		return cli.Execute("aws", []string{
+			"sqs",
+			"delete-queue",
+			"--queue-url", resource.ID,
+		}, "Deleting SQS queue")
+	case state.AWSIAMRolePolicy:
+		// Role policies are tracked as role/policy
+		parts := strings.SplitN(resource.ID, "/", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid role policy: %s", resource.ID)
+		}
+		return api.DeleteRolePolicy(parts[0], parts[1])
+	case state.AWSSSMParameter:
+		return deleteFlagParameter(resource)
+	case state.AWSSecret:
+		return deleteSecret(resource)
+	case state.AWSDynamoDBTable:
+		return cli.Execute("aws", []string{
+			"dynamodb",
+			"delete-table",
+			"--table-name", resource.ID,
+		}, "Deleting DynamoDB table")
+	case state.AWSS3Bucket:
+		return cli.Execute("aws", []string{
+			"s3",
+			"rb",
+			fmt.Sprintf("s3://%s", resource.ID),
+			"--force",
+		}, "Deleting S3 bucket")
+	case state.AWSAuroraInstance:
+		err := cli.Execute("aws", []string{
+			"rds",
+			"delete-db-instance",
+			"--db-instance-identifier", resource.ID,
+			"--skip-final-snapshot",
+		}, "Deleting Aurora instance")
+		if err != nil {
+			return err
+		}
+		return cli.Execute("aws", []string{
+			"rds",
+			"wait",
+			"db-instance-deleted",
+			"--db-instance-identifier", resource.ID,
+		}, "Waiting for the Aurora instance to be deleted")
+	case state.AWSAuroraCluster:
+		return cli.Execute("aws", []string{
+			"rds",
+			"delete-db-cluster",
+			"--db-cluster-identifier", resource.ID,
+			"--skip-final-snapshot",
+		}, "Deleting Aurora cluster")
+	case state.AWSElastiCache:
+		return cli.Execute("aws", []string{
+			"elasticache",
+			"delete-serverless-cache",
+			"--serverless-cache-name", resource.ID,
+		}, "Deleting ElastiCache cache")
+	case state.AWSSecurityGroup:
+		return cli.Execute("aws", []string{
+			"ec2",
+			"delete-security-group",
+			"--group-id", resource.ID,
+		}, "Deleting security group")
+	}
+	return fmt.Errorf("cannot delete resource type: %s", resource.Type)
+}
+
+// deleteRestApiResource removes the project's resource from the (shared) REST API
+// that the project's state records, which may not be the one in the settings (e.g.
+// a team's API), and re-deploys the API without it
+func deleteRestApiResource(api client.Client, st *state.State, resourceID string, cfg *config.Config, stg *settings.Settings) error {
+	apis := st.GetResources(state.AWSRestApi)
+	if len(apis) == 0 {
+		return errors.New("cannot delete the REST API resource: the project's state does not record its REST API")
+	}
+	restApiID := apis[0].ID
+	if err := api.DeleteResource(restApiID, resourceID); err != nil && !cli.IsNotFound(err) {
+		return err
+	}
+	if cfg.Config.AWS.RestApiResourceID == resourceID {
+		cfg.Config.AWS.RestApiResourceID = ""
+	}
+	return apigateway.DeployRestApi(restApiID, stg.AWS.DeploymentRegion)
+}
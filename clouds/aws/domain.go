@@ -0,0 +1,207 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+// The base path of a mapping that has no base path
+const emptyBasePath = "(none)"
+
+// apiDomain is the part of a custom domain name that kettle changes
+type apiDomain struct {
+	RegionalDomainName      string `json:"regionalDomainName"`
+	MutualTlsAuthentication *struct {
+		TruststoreURI     string `json:"truststoreUri"`
+		TruststoreVersion string `json:"truststoreVersion"`
+	} `json:"mutualTlsAuthentication"`
+}
+
+func (AWSLambdaFunction) ValidateCustomDomain(cfg *config.Config) error {
+	return cfg.ValidateCustomDomain()
+}
+
+// setCustomDomain creates the project's custom domain (or updates its mTLS
+// truststore), and maps it to the REST API; the domain may be shared by other
+// projects, so it is not deleted when the project is destroyed
+func setCustomDomain(directory string, cfg *config.Config, stg *settings.Settings) error {
+	domain := cfg.Config.CustomDomain
+	if domain == nil || cfg.Config.AWS.RestApiResourceID == "" || stg.AWS.RestApiID == "" {
+		return nil
+	}
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("🌐  Custom domain: ", domain.Name)
+	existing, err := getApiDomain(domain.Name)
+	if err != nil && !cli.IsNotFound(err) {
+		return err
+	}
+	if existing == nil {
+		existing, err = createApiDomain(domain)
+	} else {
+		err = updateMutualTLS(domain, existing)
+	}
+	if err != nil {
+		return err
+	}
+	st.AddResource(state.AWSApiDomain, domain.Name, "")
+
+	basePath := domain.BasePath
+	if basePath == "" {
+		basePath = emptyBasePath
+	}
+	if err := setBasePathMapping(domain, basePath, stg); err != nil {
+		return err
+	}
+	st.AddResource(state.AWSApiBasePathMapping, fmt.Sprintf("%s/%s", domain.Name, basePath), "")
+	if err := state.WriteState(directory, st); err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("https://%s/%s", domain.Name, cfg.ProjectName)
+	if domain.BasePath != "" {
+		url = fmt.Sprintf("https://%s/%s/%s", domain.Name, domain.BasePath, cfg.ProjectName)
+	}
+	fmt.Println("🔍  Custom domain endpoint: ", url)
+	fmt.Println("⏭  DNS: ", fmt.Sprintf("%s CNAME %s", domain.Name, existing.RegionalDomainName))
+	return nil
+}
+
+func getApiDomain(domainName string) (*apiDomain, error) {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"apigateway",
+		"get-domain-name",
+		"--domain-name", domainName,
+		"--output", "json",
+	}, "Looking for the custom domain")
+	if err != nil {
+		return nil, err
+	}
+	existing := &apiDomain{}
+	if err := json.Unmarshal(output, existing); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// createApiDomain creates a regional domain name; mTLS is only
+// supported by regional domains, with TLS 1.2
+func createApiDomain(domain *config.CustomDomain) (*apiDomain, error) {
+	args := []string{
+		"apigateway",
+		"create-domain-name",
+		"--domain-name", domain.Name,
+		"--regional-certificate-arn", domain.CertificateArn,
+		"--endpoint-configuration", "types=REGIONAL",
+		"--security-policy", "TLS_1_2",
+		"--output", "json",
+	}
+	if domain.MutualTLS != nil {
+		mtls := fmt.Sprintf("truststoreUri=%s", domain.MutualTLS.TruststoreURI)
+		if domain.MutualTLS.TruststoreVersion != "" {
+			mtls += fmt.Sprintf(",truststoreVersion=%s", domain.MutualTLS.TruststoreVersion)
+		}
+		args = append(args, "--mutual-tls-authentication", mtls)
+	}
+	output, err := cli.ExecuteWithResult("aws", args, fmt.Sprintf("Creating the custom domain: %s", domain.Name))
+	if err != nil {
+		return nil, err
+	}
+	created := &apiDomain{}
+	if err := json.Unmarshal(output, created); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// updateMutualTLS points an existing domain at the configured truststore (e.g.
+// a new version of it, once client certificate authorities have been added)
+func updateMutualTLS(domain *config.CustomDomain, existing *apiDomain) error {
+	if domain.MutualTLS == nil {
+		if existing.MutualTlsAuthentication != nil && existing.MutualTlsAuthentication.TruststoreURI != "" {
+			fmt.Println("⚠️   The custom domain requires client certificates, but mutual_tls is not in the project's config")
+		}
+		return nil
+	}
+	current := existing.MutualTlsAuthentication
+	if current != nil &&
+		current.TruststoreURI == domain.MutualTLS.TruststoreURI &&
+		(domain.MutualTLS.TruststoreVersion == "" || current.TruststoreVersion == domain.MutualTLS.TruststoreVersion) {
+		return nil
+	}
+	operations := []string{
+		fmt.Sprintf("op=replace,path=/mutualTlsAuthentication/truststoreUri,value=%s", domain.MutualTLS.TruststoreURI),
+	}
+	if domain.MutualTLS.TruststoreVersion != "" {
+		operations = append(operations,
+			fmt.Sprintf("op=replace,path=/mutualTlsAuthentication/truststoreVersion,value=%s", domain.MutualTLS.TruststoreVersion),
+		)
+	}
+	return cli.Execute("aws", append([]string{
+		"apigateway",
+		"update-domain-name",
+		"--domain-name", domain.Name,
+		"--patch-operations",
+	}, operations...), "Updating the custom domain's truststore")
+}
+
+// setBasePathMapping maps the domain's base path to the REST API's stage,
+// unless the base path is already mapped to another API
+func setBasePathMapping(domain *config.CustomDomain, basePath string, stg *settings.Settings) error {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"apigateway",
+		"get-base-path-mapping",
+		"--domain-name", domain.Name,
+		"--base-path", basePath,
+		"--output", "json",
+	}, "Looking for the custom domain's base path mapping")
+	if err == nil {
+		var mapping struct {
+			RestApiID string `json:"restApiId"`
+		}
+		if err := json.Unmarshal(output, &mapping); err != nil {
+			return err
+		}
+		if mapping.RestApiID != stg.AWS.RestApiID {
+			return fmt.Errorf("the base path %s of %s is already mapped to REST API %s", basePath, domain.Name, mapping.RestApiID)
+		}
+		return nil
+	}
+	if !cli.IsNotFound(err) {
+		return err
+	}
+	args := []string{
+		"apigateway",
+		"create-base-path-mapping",
+		"--domain-name", domain.Name,
+		"--rest-api-id", stg.AWS.RestApiID,
+		"--stage", domain.GetStage(),
+	}
+	if basePath != emptyBasePath {
+		args = append(args, "--base-path", basePath)
+	}
+	return cli.Execute("aws", args, "Mapping the custom domain to the REST API")
+}
+
+// deleteBasePathMapping removes a domain's base path mapping, which is tracked as domain/base-path
+func deleteBasePathMapping(resourceID string) error {
+	parts := strings.SplitN(resourceID, "/", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid base path mapping: %s", resourceID)
+	}
+	return cli.Execute("aws", []string{
+		"apigateway",
+		"delete-base-path-mapping",
+		"--domain-name", parts[0],
+		"--base-path", parts[1],
+	}, "Deleting the custom domain's base path mapping")
+}
@@ -0,0 +1,204 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+const (
+	permissionDriftPrefix = "permission:"
+)
+
+// DetectDrift compares the deployed Lambda function against the project config;
+// fields that are listed in the config's ignore_drift are skipped
+func (AWSLambdaFunction) DetectDrift(cfg *config.Config, stg *settings.Settings) ([]*config.Drift, error) {
+	live, err := getFunctionConfiguration(cfg.ProjectName)
+	if err != nil {
+		return nil, err
+	}
+
+	handler, runtime, err := getHandlerAndRuntime(cfg.Config.EntryFunction, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	drift := []*config.Drift{}
+	addDrift := func(field, declared, actual string) {
+		if declared != actual && !cfg.IgnoresDrift(field) {
+			drift = append(drift, &config.Drift{
+				Field:    field,
+				Declared: declared,
+				Live:     actual,
+			})
+		}
+	}
+	addDrift("runtime", runtime, live.Runtime)
+	addDrift("handler", handler, live.Handler)
+	if stg.AWS.RoleArn != "" {
+		addDrift("role", stg.AWS.RoleArn, live.Role)
+	}
+	if cfg.Config.Memory != 0 {
+		addDrift("memory", fmt.Sprintf("%d", cfg.Config.Memory), fmt.Sprintf("%d", live.MemorySize))
+	}
+	if cfg.Config.Timeout != 0 {
+		addDrift("timeout", fmt.Sprintf("%d", cfg.Config.Timeout), fmt.Sprintf("%d", live.Timeout))
+	}
+
+	// Functions that were added to a REST API need their invoke permissions
+	if cfg.Config.AWS.RestApiResourceID != "" {
+		statements, err := getPolicyStatementIDs(cfg.ProjectName)
+		if err != nil {
+			return nil, err
+		}
+		for env := range invocationPermissions {
+			statementID := invocationStatementID(env)
+			actual := "missing"
+			if statements[statementID] {
+				actual = statementID
+			}
+			addDrift(permissionDriftPrefix+env, statementID, actual)
+		}
+	}
+	return drift, nil
+}
+
+// FixDrift reconciles the deployed Lambda function back to the project config
+func (AWSLambdaFunction) FixDrift(cfg *config.Config, stg *settings.Settings, drift []*config.Drift) error {
+	args := []string{
+		"lambda",
+		"update-function-configuration",
+		"--function-name", cfg.ProjectName,
+	}
+	updateConfiguration := false
+	for _, d := range drift {
+		switch {
+		case d.Field == "runtime":
+			args = append(args, "--runtime", d.Declared)
+		case d.Field == "handler":
+			args = append(args, "--handler", d.Declared)
+		case d.Field == "role":
+			args = append(args, "--role", d.Declared)
+		case d.Field == "memory":
+			args = append(args, "--memory-size", d.Declared)
+		case d.Field == "timeout":
+			args = append(args, "--timeout", d.Declared)
+		case strings.HasPrefix(d.Field, permissionDriftPrefix):
+			env := strings.TrimPrefix(d.Field, permissionDriftPrefix)
+			if err := addInvocationPermissionForEnv(env, cfg, stg); err != nil {
+				return err
+			}
+			continue
+		default:
+			return fmt.Errorf("cannot fix drift in: %s", d.Field)
+		}
+		updateConfiguration = true
+	}
+
+	if !updateConfiguration {
+		return nil
+	}
+	if err := cli.Execute("aws", args, "Updating lambda function configuration"); err != nil {
+		return err
+	}
+	return waitForLambda("function-updated", cfg, stg)
+}
+
+type functionConfiguration struct {
+	FunctionArn string `json:"FunctionArn"`
+	Runtime     string `json:"Runtime"`
+	Handler     string `json:"Handler"`
+	Role        string `json:"Role"`
+	MemorySize  int    `json:"MemorySize"`
+	Timeout     int    `json:"Timeout"`
+	CodeSize    int64  `json:"CodeSize"`
+	Environment struct {
+		Variables map[string]string `json:"Variables"`
+	} `json:"Environment"`
+}
+
+func getFunctionConfiguration(name string) (*functionConfiguration, error) {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"lambda",
+		"get-function-configuration",
+		"--function-name", name,
+		"--output", "json",
+	}, "Retrieving lambda function configuration")
+	if err != nil {
+		if cli.IsNotFound(err) {
+			return nil, fmt.Errorf("lambda function not found: %s", name)
+		}
+		return nil, err
+	}
+
+	result := &functionConfiguration{}
+	if err := json.Unmarshal(output, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+type policyStatement struct {
+	Sid       string
+	SourceArn string
+}
+
+func getPolicyStatements(name string) ([]*policyStatement, error) {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"lambda",
+		"get-policy",
+		"--function-name", name,
+		"--output", "json",
+	}, "Retrieving lambda function permissions")
+	if err != nil {
+		if cli.IsNotFound(err) {
+			// The function has no resource policy
+			return []*policyStatement{}, nil
+		}
+		return nil, err
+	}
+
+	// The policy document is returned as a JSON-encoded string
+	var result struct {
+		Policy string `json:"Policy"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, err
+	}
+	var policy struct {
+		Statement []struct {
+			Sid       string `json:"Sid"`
+			Condition struct {
+				ArnLike map[string]string `json:"ArnLike"`
+			} `json:"Condition"`
+		} `json:"Statement"`
+	}
+	if err := json.Unmarshal([]byte(result.Policy), &policy); err != nil {
+		return nil, err
+	}
+
+	statements := []*policyStatement{}
+	for _, statement := range policy.Statement {
+		statements = append(statements, &policyStatement{
+			Sid:       statement.Sid,
+			SourceArn: statement.Condition.ArnLike["AWS:SourceArn"],
+		})
+	}
+	return statements, nil
+}
+
+func getPolicyStatementIDs(name string) (map[string]bool, error) {
+	statements, err := getPolicyStatements(name)
+	if err != nil {
+		return nil, err
+	}
+	statementIDs := map[string]bool{}
+	for _, statement := range statements {
+		statementIDs[statement.Sid] = true
+	}
+	return statementIDs, nil
+}
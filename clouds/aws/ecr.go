@@ -0,0 +1,136 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+// pushContainer builds the docker container in the current directory (or
+// tags the pre-built artifact) and pushes it to an ECR repository, returning
+// the image URI, pinned to the image's digest
+func pushContainer(cfg *config.Config, tag string, stg *settings.Settings) (string, error) {
+	repositoryURI, err := getOrCreateRepository(cfg.ProjectName)
+	if err != nil {
+		return "", err
+	}
+	if err := dockerLogin(repositoryURI, stg); err != nil {
+		return "", err
+	}
+
+	imageURI := fmt.Sprintf("%s:%s", repositoryURI, tag)
+	if cfg.Artifact != nil {
+		fmt.Println("📦  Artifact: ", cfg.Artifact.Digest)
+		err = cli.Execute("docker", []string{
+			"tag",
+			cfg.Artifact.Location,
+			imageURI,
+		}, "Tagging docker container")
+	} else {
+		err = buildImage(imageURI)
+	}
+	if err != nil {
+		return "", err
+	}
+	err = cli.Execute("docker", []string{
+		"push",
+		imageURI,
+	}, "Pushing docker container to ECR")
+	if err != nil {
+		return "", err
+	}
+
+	digest, err := getImageDigest(cfg.ProjectName, tag)
+	if err != nil {
+		return "", err
+	}
+	if cfg.Artifact != nil && cfg.Artifact.Digest != "" && cfg.Artifact.Digest != digest {
+		return "", fmt.Errorf("the pushed image (%s) is not the artifact (%s)", digest, cfg.Artifact.Digest)
+	}
+	cfg.Artifact = &config.Artifact{
+		Location: fmt.Sprintf("%s@%s", repositoryURI, digest),
+		Digest:   digest,
+	}
+	return cfg.Artifact.Location, nil
+}
+
+// buildImage builds the docker container in the current directory
+func buildImage(imageURI string) error {
+	fmt.Println("🏭  Building: ", imageURI)
+	args := []string{
+		"build",
+		"--platform", "linux/amd64",
+		"--tag", imageURI,
+	}
+	args = append(args, cli.ProxyBuildArgs()...)
+	return cli.Execute("docker", append(args, "."), "Building docker container")
+}
+
+func getOrCreateRepository(repositoryName string) (string, error) {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"ecr",
+		"describe-repositories",
+		"--repository-names", repositoryName,
+		"--output", "json",
+	}, "Looking for ECR repository")
+	if err != nil {
+		if !cli.IsNotFound(err) {
+			return "", err
+		}
+		output, err = cli.ExecuteWithResult("aws", []string{
+			"ecr",
+			"create-repository",
+			"--repository-name", repositoryName,
+			"--output", "json",
+		}, fmt.Sprintf("Creating an ECR repository called: %s", repositoryName))
+		if err != nil {
+			return "", err
+		}
+		var result struct {
+			Repository struct {
+				URI string `json:"repositoryUri"`
+			} `json:"repository"`
+		}
+		if err := json.Unmarshal(output, &result); err != nil {
+			return "", err
+		}
+		return result.Repository.URI, nil
+	}
+
+	var results struct {
+		Repositories []struct {
+			URI string `json:"repositoryUri"`
+		} `json:"repositories"`
+	}
+	if err := json.Unmarshal(output, &results); err != nil {
+		return "", err
+	}
+	if len(results.Repositories) == 0 {
+		return "", fmt.Errorf("ecr repository not found: %s", repositoryName)
+	}
+	return results.Repositories[0].URI, nil
+}
+
+func dockerLogin(repositoryURI string, stg *settings.Settings) error {
+	password, err := cli.ExecuteWithResult("aws", []string{
+		"ecr",
+		"get-login-password",
+		"--region", stg.AWS.DeploymentRegion,
+	}, "Retrieving ECR login password")
+	if err != nil {
+		return err
+	}
+
+	registry := strings.Split(repositoryURI, "/")[0]
+	_, err = cli.ExecuteWithInput("docker", []string{
+		"login",
+		"--username", "AWS",
+		"--password-stdin",
+		registry,
+	}, password, "Logging in to ECR")
+	return err
+}
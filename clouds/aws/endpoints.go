@@ -0,0 +1,28 @@
+package aws
+
+import (
+	"os"
+	"strings"
+)
+
+// UseEndpoints points the aws cli and SDK at the endpoints in the settings (e.g.
+// VPC endpoints, or GovCloud's), with their AWS_ENDPOINT_URL_<SERVICE> variables;
+// the default endpoint is used for the services that are not listed
+func UseEndpoints(endpoints map[string]string) {
+	for service, url := range endpoints {
+		variable := "AWS_ENDPOINT_URL"
+		if service != "default" {
+			variable += "_" + endpointVariableSuffix(service)
+		}
+		// Variables that are set in the environment take precedence
+		if _, ok := os.LookupEnv(variable); !ok {
+			os.Setenv(variable, url)
+		}
+	}
+}
+
+// endpointVariableSuffix returns the suffix of a service's
+// endpoint variable, e.g. api-gateway is API_GATEWAY
+func endpointVariableSuffix(service string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", " ", "_").Replace(service))
+}
@@ -0,0 +1,54 @@
+package aws
+
+import (
+	"encoding/json"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+// GetEnvironment returns the environment variables of the deployed function
+func (AWSLambdaFunction) GetEnvironment(cfg *config.Config, stg *settings.Settings) (map[string]string, error) {
+	configuration, err := getFunctionConfiguration(cfg.ProjectName)
+	if err != nil {
+		return nil, err
+	}
+	if configuration.Environment.Variables == nil {
+		return map[string]string{}, nil
+	}
+	return configuration.Environment.Variables, nil
+}
+
+// UpdateEnvironment sets (and removes) environment variables of the deployed
+// function; the function's other variables are not changed
+func (f AWSLambdaFunction) UpdateEnvironment(cfg *config.Config, stg *settings.Settings, set map[string]string, unset []string) error {
+	environment, err := f.GetEnvironment(cfg, stg)
+	if err != nil {
+		return err
+	}
+	for key, value := range set {
+		environment[key] = value
+	}
+	for _, key := range unset {
+		delete(environment, key)
+	}
+
+	// Lambda replaces all of the function's variables at once
+	data, err := json.Marshal(map[string]map[string]string{
+		"Variables": environment,
+	})
+	if err != nil {
+		return err
+	}
+	err = cli.Execute("aws", []string{
+		"lambda",
+		"update-function-configuration",
+		"--function-name", cfg.ProjectName,
+		"--environment", string(data),
+	}, "Updating the function's environment")
+	if err != nil {
+		return err
+	}
+	return waitForLambda("function-updated", cfg, stg)
+}
@@ -0,0 +1,18 @@
+package aws
+
+import (
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+// GetCommandEnvironment returns the environment that the aws cli is run with
+// by kettle exec; the stage's profile (or assumed role) is already set
+func GetCommandEnvironment(cfg *config.Config, stg *settings.Settings) map[string]string {
+	return map[string]string{
+		"AWS_REGION":         stg.AWS.DeploymentRegion,
+		"AWS_DEFAULT_REGION": stg.AWS.DeploymentRegion,
+		"KETTLE_NAME":        cfg.ProjectName,
+		"KETTLE_REGION":      stg.AWS.DeploymentRegion,
+		"KETTLE_TAGS":        getTagArgs(cfg),
+	}
+}
@@ -0,0 +1,127 @@
+package aws
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+// explanations are the kind of each resource that kettle creates on AWS,
+// and why it exists, for users who have not used the service before
+var explanations = map[string][2]string{
+	state.AWSLambdaFunction:     {"Lambda function", "runs the project's code when it is invoked"},
+	state.AWSIAMRole:            {"IAM role", "the identity that the function runs as, and what it is allowed to access"},
+	state.AWSRestApi:            {"API Gateway REST API", "the public HTTP endpoint that sends requests to the function"},
+	state.AWSRestApiResource:    {"API Gateway resource", "the path of the REST API that is attached to the function"},
+	state.AWSLambdaPermission:   {"Lambda permission", "allows the REST API (or a trigger) to invoke the function"},
+	state.AWSLambdaAlias:        {"Lambda alias", "points an API stage at a version of the function"},
+	state.AWSApiDomain:          {"API Gateway custom domain", "serves the REST API from your own domain name"},
+	state.AWSApiBasePathMapping: {"API Gateway base path mapping", "maps a path of the custom domain to the REST API's stage"},
+	state.AWSEventsRule:         {"EventBridge rule", "invokes the function on a schedule (or keeps it warm)"},
+	state.AWSS3Notification:     {"S3 event notification", "invokes the function when objects are added to a bucket"},
+	state.AWSECRRepository:      {"ECR repository", "stores the container images of the model"},
+	state.AWSSageMakerModel:     {"SageMaker model", "a version of the model and the image that serves it"},
+	state.AWSSageMakerConfig:    {"SageMaker endpoint config", "the instance type and count that serve a model version"},
+	state.AWSSageMakerEndpoint:  {"SageMaker endpoint", "the HTTPS endpoint that serves predictions"},
+	state.AWSSQSQueue:           {"SQS queue", "holds messages until the function processes them"},
+	state.AWSEventSourceMapping: {"Lambda event source mapping", "reads messages from the queue and invokes the function with them"},
+	state.AWSS3Bucket:           {"S3 bucket", "stores the project's files (e.g. static assets, canary results, or an add-on's data)"},
+	state.AWSCloudFrontOAC:      {"CloudFront origin access control", "lets the CDN (and only the CDN) read the static site's bucket"},
+	state.AWSCloudFront:         {"CloudFront distribution", "the CDN that serves the static site"},
+	state.AWSIAMRolePolicy:      {"IAM role policy", "gives the function's role access to an add-on"},
+	state.AWSDynamoDBTable:      {"DynamoDB table", "the project's key-value database add-on"},
+	state.AWSAuroraCluster:      {"Aurora Serverless cluster", "the project's Postgres database add-on"},
+	state.AWSAuroraInstance:     {"Aurora Serverless instance", "the database instance of the Aurora cluster"},
+	state.AWSElastiCache:        {"ElastiCache Serverless cache", "the project's Redis add-on"},
+	state.AWSSecurityGroup:      {"VPC security group", "controls which network traffic can reach the project's add-ons"},
+	state.AWSSyntheticsCanary:   {"CloudWatch Synthetics canary", "runs the smoke test against the endpoint on a schedule"},
+	state.AWSCloudWatchAlarm:    {"CloudWatch alarm", "alerts when the canary (or another check) fails"},
+	state.AWSLogMetricFilter:    {"CloudWatch metric filter", "turns matching log lines into a metric"},
+	state.AWSSNSTopic:           {"SNS topic", "sends the alarm's notifications (e.g. emails)"},
+	state.AWSBudget:             {"AWS Budget", "alerts when the project's monthly spend reaches its budget"},
+	state.AWSSSMParameter:       {"SSM parameter", "stores the project's feature flags, which the function reads when it runs"},
+	state.AWSSecret:             {"Secrets Manager secret", "stores a secret from Vault, which the function reads when it runs"},
+}
+
+// ExplainResource describes a resource that kettle has created,
+// with a link to it in the AWS console
+func ExplainResource(resource *state.Resource, stg *settings.Settings) *config.Explanation {
+	explanation, ok := explanations[resource.Type]
+	if !ok {
+		return &config.Explanation{Kind: resource.Type}
+	}
+	return &config.Explanation{
+		Kind:       explanation[0],
+		Reason:     explanation[1],
+		ConsoleURL: consoleURL(resource, stg.AWS.DeploymentRegion),
+	}
+}
+
+func consoleURL(resource *state.Resource, region string) string {
+	console := fmt.Sprintf("https://%s.console.%s", region, getConsoleDomain(region))
+	global := fmt.Sprintf("https://console.%s", getConsoleDomain(region))
+	switch resource.Type {
+	case state.AWSLambdaFunction:
+		return fmt.Sprintf("%s/lambda/home?region=%s#/functions/%s", console, region, resource.ID)
+	case state.AWSLambdaAlias, state.AWSLambdaPermission, state.AWSEventSourceMapping:
+		// These are shown on the function's page
+		return fmt.Sprintf("%s/lambda/home?region=%s#/functions", console, region)
+	case state.AWSIAMRole:
+		return fmt.Sprintf("%s/iam/home#/roles/%s", global, resource.ID)
+	case state.AWSIAMRolePolicy:
+		return fmt.Sprintf("%s/iam/home#/roles/%s", global, strings.SplitN(resource.ID, "/", 2)[0])
+	case state.AWSRestApi:
+		return fmt.Sprintf("%s/apigateway/home?region=%s#/apis/%s/resources", console, region, resource.ID)
+	case state.AWSRestApiResource:
+		return fmt.Sprintf("%s/apigateway/home?region=%s#/apis", console, region)
+	case state.AWSApiDomain, state.AWSApiBasePathMapping:
+		return fmt.Sprintf("%s/apigateway/main/publish/domain-names?region=%s", console, region)
+	case state.AWSEventsRule:
+		return fmt.Sprintf("%s/events/home?region=%s#/eventbus/default/rules/%s", console, region, resource.ID)
+	case state.AWSS3Notification:
+		return fmt.Sprintf("%s/s3/buckets/%s?region=%s&tab=properties", console, strings.SplitN(resource.ID, "/", 2)[0], region)
+	case state.AWSS3Bucket:
+		return fmt.Sprintf("%s/s3/buckets/%s?region=%s", console, resource.ID, region)
+	case state.AWSECRRepository:
+		return fmt.Sprintf("%s/ecr/repositories/private/%s?region=%s", console, resource.ID, region)
+	case state.AWSSageMakerModel:
+		return fmt.Sprintf("%s/sagemaker/home?region=%s#/models/%s", console, region, resource.ID)
+	case state.AWSSageMakerConfig:
+		return fmt.Sprintf("%s/sagemaker/home?region=%s#/endpointConfig/%s", console, region, resource.ID)
+	case state.AWSSageMakerEndpoint:
+		return fmt.Sprintf("%s/sagemaker/home?region=%s#/endpoints/%s", console, region, resource.ID)
+	case state.AWSSQSQueue:
+		return fmt.Sprintf("%s/sqs/v2/home?region=%s#/queues/%s", console, region, url.QueryEscape(resource.ID))
+	case state.AWSCloudFrontOAC:
+		return global + "/cloudfront/v4/home#/originAccess"
+	case state.AWSCloudFront:
+		return fmt.Sprintf("%s/cloudfront/v4/home#/distributions/%s", global, resource.ID)
+	case state.AWSDynamoDBTable:
+		return fmt.Sprintf("%s/dynamodbv2/home?region=%s#table?name=%s", console, region, resource.ID)
+	case state.AWSAuroraCluster, state.AWSAuroraInstance:
+		return fmt.Sprintf("%s/rds/home?region=%s#database:id=%s", console, region, resource.ID)
+	case state.AWSElastiCache:
+		return fmt.Sprintf("%s/elasticache/home?region=%s#/serverless/%s", console, region, resource.ID)
+	case state.AWSSecurityGroup:
+		return fmt.Sprintf("%s/vpcconsole/home?region=%s#SecurityGroup:groupId=%s", console, region, resource.ID)
+	case state.AWSSyntheticsCanary:
+		return fmt.Sprintf("%s/cloudwatch/home?region=%s#synthetics:canary/detail/%s", console, region, resource.ID)
+	case state.AWSCloudWatchAlarm:
+		return fmt.Sprintf("%s/cloudwatch/home?region=%s#alarmsV2:alarm/%s", console, region, url.PathEscape(resource.ID))
+	case state.AWSLogMetricFilter:
+		return fmt.Sprintf("%s/cloudwatch/home?region=%s#logsV2:log-groups", console, region)
+	case state.AWSSNSTopic:
+		return fmt.Sprintf("%s/sns/v3/home?region=%s#/topic/%s", console, region, resource.ID)
+	case state.AWSBudget:
+		return global + "/billing/home#/budgets"
+	case state.AWSSSMParameter:
+		return fmt.Sprintf("%s/systems-manager/parameters%s/description?region=%s", console, resource.ID, region)
+	case state.AWSSecret:
+		return fmt.Sprintf("%s/secretsmanager/secret?name=%s&region=%s", console, url.QueryEscape(resource.ID), region)
+	}
+	return ""
+}
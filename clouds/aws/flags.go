@@ -0,0 +1,77 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+// PutFlags writes the feature flags of the deployed stage to the project's
+// SSM parameter, without updating the function
+func (AWSLambdaFunction) PutFlags(directory string, cfg *config.Config, stg *settings.Settings) error {
+	return setFlags(directory, cfg, stg)
+}
+
+// setFlags writes the feature flags to the project's SSM parameter (if they are
+// stored in SSM); the first time, the execution role is granted access to read it
+func setFlags(directory string, cfg *config.Config, stg *settings.Settings) error {
+	if cfg.GetFlagStore() != config.FlagStoreSSM {
+		return nil
+	}
+	if err := SetAccountID(stg.AWS); err != nil {
+		return err
+	}
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return err
+	}
+
+	parameterName := cfg.FlagParameterName()
+	err = cli.Execute("aws", []string{
+		"ssm",
+		"put-parameter",
+		"--name", parameterName,
+		"--type", "String",
+		"--value", cfg.EncodeFlags(),
+		"--overwrite",
+	}, "Storing the feature flags")
+	if err != nil {
+		return err
+	}
+	if st.GetResource(state.AWSSSMParameter, parameterName) != nil {
+		return nil
+	}
+
+	// Parameter ARNs do not repeat the slash that the name starts with
+	parameterArn := fmt.Sprintf("arn:%s:ssm:%s:%s:parameter%s",
+		getPartition(stg.AWS.DeploymentRegion),
+		stg.AWS.DeploymentRegion,
+		stg.AWS.AccountID,
+		parameterName,
+	)
+	st.AddResource(state.AWSSSMParameter, parameterName, parameterArn)
+	if stg.AWS.RoleArn != "" {
+		err = putRolePolicy(stg, cfg.ProjectName+"-flags", st, []map[string]interface{}{
+			{
+				"Effect":   "Allow",
+				"Action":   []string{"ssm:GetParameter"},
+				"Resource": parameterArn,
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return state.WriteState(directory, st)
+}
+
+func deleteFlagParameter(resource *state.Resource) error {
+	return cli.Execute("aws", []string{
+		"ssm",
+		"delete-parameter",
+		"--name", resource.ID,
+	}, "Deleting the feature flags parameter")
+}
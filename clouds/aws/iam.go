@@ -1,12 +1,10 @@
 package aws
 
 import (
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"os"
 
 	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/clouds/aws/client"
 	"github.com/operatorai/kettle-cli/settings"
 )
 
@@ -14,74 +12,78 @@ const (
 	operatorExecutionRole = "operator-lambda-role"
 )
 
+// executionRole describes an IAM role that is assumed by an AWS service
+type executionRole struct {
+	name     string
+	service  string
+	policies []string
+	// An optional policy document, for permissions that no managed policy grants
+	inlinePolicy string
+}
+
+var lambdaExecutionRole = &executionRole{
+	name:    operatorExecutionRole,
+	service: "lambda.amazonaws.com",
+}
+
 func setExecutionRole(stg *settings.Settings) error {
 	if stg.AWS.RoleArn != "" {
 		return nil
 	}
 
-	roles, operatorExecutionRoleExists, err := getExecutionRoles()
+	role, err := selectExecutionRole(lambdaExecutionRole, stg.AWS.DeploymentRegion)
 	if err != nil {
 		return err
 	}
 
+	stg.AWS.RoleArn = role
+	return nil
+}
+
+func selectExecutionRole(executionRole *executionRole, region string) (string, error) {
+	api, err := client.Get(region)
+	if err != nil {
+		return "", err
+	}
+	roles, operatorExecutionRoleExists, err := getExecutionRoles(api, executionRole)
+	if err != nil {
+		return "", err
+	}
+
 	var role string
 	if len(roles) == 0 {
-		role, err = createExecutionRole()
+		role, err = createExecutionRole(executionRole, region)
 		if err != nil {
-			return err
+			return "", err
 		}
 	} else {
 		role, err = cli.PromptForValue("IAM Role", roles, !operatorExecutionRoleExists)
 		if err != nil {
-			return err
+			return "", err
 		}
 		if role == "" {
-			role, err = createExecutionRole()
+			role, err = createExecutionRole(executionRole, region)
 			if err != nil {
-				return err
+				return "", err
 			}
 		}
 	}
-
-	stg.AWS.RoleArn = role
-	return nil
+	return role, nil
 }
 
-func getExecutionRoles() (map[string]string, bool, error) {
-	output, err := cli.ExecuteWithResult("aws", []string{
-		"iam",
-		"list-roles",
-		"--output", "json",
-	}, "Collecting available IAM roles")
+func getExecutionRoles(api client.Client, executionRole *executionRole) (map[string]string, bool, error) {
+	results, err := api.ListRoles()
 	if err != nil {
 		return nil, false, err
 	}
 
-	var results struct {
-		Roles []struct {
-			RoleName   string `json:"RoleName"`
-			Path       string `json:"Path"`
-			Arn        string `json:"Arn"`
-			RolePolicy struct {
-				Statement []struct {
-					Principal struct {
-						Service string `json:"Service"`
-					} `json:"Principal"`
-				} `json:"Statement"`
-			} `json:"AssumeRolePolicyDocument"`
-		} `json:"Roles"`
-	}
-	if err := json.Unmarshal(output, &results); err != nil {
-		return nil, false, err
-	}
-
 	operatorExecutionRoleExists := false
 	roles := map[string]string{}
-	for _, role := range results.Roles {
-		if role.RolePolicy.Statement[0].Principal.Service == "lambda.amazonaws.com" {
-			displayName := fmt.Sprintf("%s (%s)", role.RoleName, role.Path)
+	for _, role := range results {
+		if role.TrustedService == executionRole.service {
+			displayName := fmt.Sprintf("%s (%s)", role.Name, role.Path)
 			roles[displayName] = role.Arn
-			if role.RoleName == operatorExecutionRole {
+			if role.Name == executionRole.name {
 				operatorExecutionRoleExists = true
 			}
 		}
@@ -89,48 +91,40 @@ func getExecutionRoles() (map[string]string, bool, error) {
 	return roles, operatorExecutionRoleExists, nil
 }
 
-func createExecutionRole() (string, error) {
-	// Write the trust policy to a temp file
-	f, err := ioutil.TempFile(".", "trust_policy*.json")
+// createExecutionRole creates the role, with the managed and inline
+// policies (which are written for the aws partition) in the region's partition
+func createExecutionRole(executionRole *executionRole, region string) (string, error) {
+	api, err := client.Get(region)
 	if err != nil {
 		return "", err
 	}
-	defer os.Remove(f.Name())
-
-	trustPolicy := []byte(`{
+	trustPolicy := fmt.Sprintf(`{
 		"Version": "2012-10-17",
 		"Statement": [
 			{
 				"Effect": "Allow",
 				"Principal": {
-					"Service": "lambda.amazonaws.com"
+					"Service": "%s"
 				},
 				"Action": "sts:AssumeRole"
 			}
 		]
-	}`)
-	if _, err = f.Write(trustPolicy); err != nil {
-		return "", err
-	}
-
-	output, err := cli.ExecuteWithResult("aws", []string{
-		"iam",
-		"create-role",
-		"--role-name", operatorExecutionRole,
-		"--assume-role-policy-document", fmt.Sprintf("file://%s", f.Name()),
-		"--output", "json",
-	}, fmt.Sprintf("Creating an IAM role called: %s", operatorExecutionRole))
+	}`, executionRole.service)
+	role, err := api.CreateRole(executionRole.name, trustPolicy)
 	if err != nil {
 		return "", err
 	}
 
-	var result struct {
-		Role struct {
-			Arn string `json:"Arn"`
-		} `json:"Role"`
+	for _, policy := range executionRole.policies {
+		if err := api.AttachRolePolicy(executionRole.name, inPartition(policy, region)); err != nil {
+			return "", err
+		}
 	}
-	if err := json.Unmarshal(output, &result); err != nil {
-		return "", err
+	if executionRole.inlinePolicy != "" {
+		err := api.PutRolePolicy(executionRole.name, executionRole.name, inPartition(executionRole.inlinePolicy, region))
+		if err != nil {
+			return "", err
+		}
 	}
-	return result.Role.Arn, nil
+	return role.Arn, nil
 }
@@ -0,0 +1,213 @@
+package aws
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/clouds/aws/apigateway"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+// Import generates a project config and state from a Lambda function
+// that was not created by kettle
+func (AWSLambdaFunction) Import(name string, stg *settings.Settings) (*config.Config, *state.State, error) {
+	live, err := getFunctionConfiguration(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cfg := &config.Config{
+		ProjectName: name,
+	}
+	cfg.Config.CloudProvider = "aws"
+	cfg.Config.DeploymentType = "lambda"
+	cfg.Config.Runtime = live.Runtime
+	cfg.Config.Memory = live.MemorySize
+	cfg.Config.Timeout = live.Timeout
+	switch {
+	case strings.HasPrefix(live.Runtime, "python"):
+		// kettle deploys Python handlers from main.py; handlers in other
+		// modules are imported into main.py when the code is downloaded
+		_, function, err := getPythonHandler(live.Handler)
+		if err != nil {
+			return nil, nil, err
+		}
+		cfg.Config.EntryFunction = function
+		pythonManager, err := cli.PromptForValue("Python manager", map[string]string{
+			"pyenv": "pyenv",
+			"conda": "conda",
+		}, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		cfg.Config.PythonManager = pythonManager
+	case strings.HasPrefix(live.Runtime, "go"):
+		cfg.Config.EntryFunction = live.Handler
+	default:
+		return nil, nil, fmt.Errorf("unsupported runtime: %s", live.Runtime)
+	}
+
+	if len(live.Environment.Variables) > 0 {
+		names := []string{}
+		for key := range live.Environment.Variables {
+			names = append(names, key)
+		}
+		sort.Strings(names)
+		fmt.Println(fmt.Sprintf("⚠️   The function's environment variables (%s) are not imported: deploys keep them, until kettle.json sets an environment, which replaces them",
+			strings.Join(names, ", ")))
+	}
+
+	st := &state.State{}
+	st.AddResource(state.AWSLambdaFunction, name, live.FunctionArn)
+	st.AddResource(state.AWSIAMRole, roleNameFromArn(live.Role), live.Role)
+
+	// Adopt any REST API resource that invokes the function
+	if err := importRestApiWiring(name, cfg, stg, st); err != nil {
+		return nil, nil, err
+	}
+	return cfg, st, nil
+}
+
+// DownloadCode downloads and unzips the function's current code bundle; if
+// a Python function's handler is not in main.py, a main.py that imports it is
+// added, as kettle deploys the handler from main.py
+func (AWSLambdaFunction) DownloadCode(name string, directory string) error {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"lambda",
+		"get-function",
+		"--function-name", name,
+		"--query", "Code.Location",
+		"--output", "text",
+	}, "Retrieving lambda function code location")
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile("", "kettle-import*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := downloadCodeBundle(strings.TrimSpace(string(output)), f); err != nil {
+		return err
+	}
+
+	if err := cli.Execute("unzip", []string{
+		"-o",
+		f.Name(),
+		"-d", directory,
+	}, "Extracting lambda function code"); err != nil {
+		return err
+	}
+	live, err := getFunctionConfiguration(name)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(live.Runtime, "python") {
+		return nil
+	}
+	return addPythonEntrypoint(live.Handler, directory)
+}
+
+// getPythonHandler returns the module (e.g. src/app) and the function
+// of a Python handler (e.g. src/app.handler)
+func getPythonHandler(handler string) (string, string, error) {
+	i := strings.LastIndex(handler, ".")
+	if i <= 0 || i == len(handler)-1 {
+		return "", "", fmt.Errorf("unsupported python handler: %s (expected <module>.<function>)", handler)
+	}
+	return handler[:i], handler[i+1:], nil
+}
+
+// addPythonEntrypoint adds a main.py that imports the handler from its module
+func addPythonEntrypoint(handler string, directory string) error {
+	module, function, err := getPythonHandler(handler)
+	if err != nil || module == "main" {
+		return err
+	}
+	entrypoint := path.Join(directory, "main.py")
+	if _, err := os.Stat(entrypoint); err == nil {
+		return fmt.Errorf("the handler is in %s.py, but the code already has a main.py (which kettle deploys main.%s from)", module, function)
+	}
+	contents := fmt.Sprintf(`# kettle deploys the function's handler from main.py, so it
+# is imported from %s.py, where the function's code has it
+from %s import %s  # noqa: F401
+`, module, strings.ReplaceAll(module, "/", "."), function)
+	if err := ioutil.WriteFile(entrypoint, []byte(contents), 0644); err != nil {
+		return err
+	}
+	fmt.Println(fmt.Sprintf("✅  Added main.py, which imports %s from %s.py", function, module))
+	return nil
+}
+
+func importRestApiWiring(name string, cfg *config.Config, stg *settings.Settings, st *state.State) error {
+	statements, err := getPolicyStatements(name)
+	if err != nil {
+		return err
+	}
+
+	for _, statement := range statements {
+		// arn:aws:execute-api:<region>:<account>:<api-id>/<stage>/<method>/<path>
+		arnParts := strings.SplitN(statement.SourceArn, ":", 6)
+		if len(arnParts) != 6 || arnParts[2] != "execute-api" {
+			continue
+		}
+		apiParts := strings.SplitN(arnParts[5], "/", 4)
+		if len(apiParts) != 4 {
+			continue
+		}
+		restApiID, pathPart := apiParts[0], apiParts[3]
+		st.AddResource(state.AWSLambdaPermission, statement.Sid, "")
+
+		if cfg.Config.AWS.RestApiResourceID != "" {
+			continue
+		}
+		// The API is recorded in the project's state, which
+		// projects use instead of the default in the settings
+		stg.AWS.UseProjectRestApi(restApiID)
+		resources, err := apigateway.GetResources(restApiID, stg.AWS.DeploymentRegion)
+		if err != nil {
+			return err
+		}
+		resource := apigateway.GetResourceWithPath(resources, pathPart)
+		if resource == nil {
+			continue
+		}
+		cfg.Config.AWS.RestApiResourceID = resource.ID
+		st.AddResource(state.AWSRestApi, restApiID, "")
+		st.AddResource(state.AWSRestApiResource, resource.ID, "")
+	}
+	return nil
+}
+
+func roleNameFromArn(roleArn string) string {
+	// arn:aws:iam::<account>:role/<path>/<name>
+	parts := strings.Split(roleArn, "/")
+	return parts[len(parts)-1]
+}
+
+// downloadCodeBundle writes a function's code bundle to the file;
+// the code location is a pre-signed S3 URL
+func downloadCodeBundle(location string, f *os.File) error {
+	response, err := http.Get(location)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download code bundle: %s", response.Status)
+	}
+	_, err = io.Copy(f, response.Body)
+	return err
+}
@@ -0,0 +1,90 @@
+package aws
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+// GetEndpoint returns the URL of the REST API method that invokes the function
+func (AWSLambdaFunction) GetEndpoint(cfg *config.Config, stg *settings.Settings) (string, error) {
+	if stg.AWS.RestApiID == "" || cfg.Config.AWS.RestApiResourceID == "" {
+		return "", errors.New("the function has not been added to a REST API")
+	}
+	return fmt.Sprintf("https://%s.execute-api.%s.%s/prod/%s",
+		stg.AWS.RestApiID,
+		stg.AWS.DeploymentRegion,
+		getDNSSuffix(stg.AWS.DeploymentRegion),
+		cfg.ProjectName,
+	), nil
+}
+
+// Invoke calls the function synchronously, and returns an error if the function failed
+func (AWSLambdaFunction) Invoke(cfg *config.Config, stg *settings.Settings, payload []byte) error {
+	output, err := cli.ExecuteSilently("aws", []string{
+		"lambda",
+		"invoke",
+		"--function-name", cfg.ProjectName,
+		"--cli-binary-format", "raw-in-base64-out",
+		"--payload", string(payload),
+		"--output", "json",
+		"/dev/null",
+	})
+	if err != nil {
+		return err
+	}
+	var result struct {
+		StatusCode    int    `json:"StatusCode"`
+		FunctionError string `json:"FunctionError"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return err
+	}
+	if result.FunctionError != "" {
+		return fmt.Errorf("function error: %s", result.FunctionError)
+	}
+	return nil
+}
+
+// CountColdStarts counts the invocations whose report includes an init
+// duration, which is only logged when a new execution environment starts
+func (AWSLambdaFunction) CountColdStarts(cfg *config.Config, stg *settings.Settings, start, end time.Time) (int, error) {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"logs",
+		"filter-log-events",
+		"--log-group-name", fmt.Sprintf("/aws/lambda/%s", cfg.ProjectName),
+		"--start-time", fmt.Sprintf("%d", start.UnixNano()/int64(time.Millisecond)),
+		"--end-time", fmt.Sprintf("%d", end.UnixNano()/int64(time.Millisecond)),
+		"--filter-pattern", `"Init Duration"`,
+		"--output", "json",
+	}, "Counting cold starts")
+	if err != nil {
+		return 0, err
+	}
+	var result struct {
+		Events []struct{} `json:"events"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return 0, err
+	}
+	return len(result.Events), nil
+}
+
+// Invoke calls the endpoint with a JSON payload, and returns an error if the model failed
+func (AWSSageMakerEndpoint) Invoke(cfg *config.Config, stg *settings.Settings, payload []byte) error {
+	_, err := cli.ExecuteSilently("aws", []string{
+		"sagemaker-runtime",
+		"invoke-endpoint",
+		"--endpoint-name", cfg.ProjectName,
+		"--content-type", "application/json",
+		"--cli-binary-format", "raw-in-base64-out",
+		"--body", string(payload),
+		"/dev/null",
+	})
+	return err
+}
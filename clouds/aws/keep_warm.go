@@ -0,0 +1,144 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+const (
+	keepWarmStatementID = "kettle-keep-warm"
+	// Handlers can short-circuit events that contain this key
+	keepWarmPayload = `{"kettle-warmup": true}`
+)
+
+// setKeepWarm creates (or removes) an EventBridge schedule that
+// periodically invokes the function to keep it warm
+func setKeepWarm(directory string, cfg *config.Config, stg *settings.Settings) error {
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return err
+	}
+
+	ruleName := keepWarmRuleName(cfg)
+	if cfg.Config.KeepWarm == "" {
+		if st.GetResource(state.AWSEventsRule, ruleName) == nil {
+			return nil
+		}
+		if err := deleteKeepWarmRule(ruleName, cfg); err != nil {
+			return err
+		}
+		st.RemoveResource(state.AWSEventsRule, ruleName)
+		return state.WriteState(directory, st)
+	}
+
+	fmt.Println("🔥  Keeping", cfg.ProjectName, "warm on schedule:", cfg.Config.KeepWarm)
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"events",
+		"put-rule",
+		"--name", ruleName,
+		"--schedule-expression", cfg.Config.KeepWarm,
+		"--output", "json",
+	}, "Creating keep-warm schedule")
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		RuleArn string `json:"RuleArn"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return err
+	}
+
+	// Allow the rule to invoke the function
+	statements, err := getPolicyStatementIDs(cfg.ProjectName)
+	if err != nil {
+		return err
+	}
+	if !statements[keepWarmStatementID] {
+		err := cli.Execute("aws", []string{
+			"lambda",
+			"add-permission",
+			"--function-name", cfg.ProjectName,
+			"--statement-id", keepWarmStatementID,
+			"--action", "lambda:InvokeFunction",
+			"--principal", "events.amazonaws.com",
+			"--source-arn", result.RuleArn,
+		}, "Setting lambda permissions for the keep-warm schedule")
+		if err != nil {
+			return err
+		}
+	}
+
+	// Target the function with the warmup payload
+	targets, err := json.Marshal([]map[string]string{
+		{
+			"Id":    keepWarmStatementID,
+			"Arn":   functionArn(cfg, stg),
+			"Input": keepWarmPayload,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	err = cli.Execute("aws", []string{
+		"events",
+		"put-targets",
+		"--rule", ruleName,
+		"--targets", string(targets),
+	}, "Adding the function to the keep-warm schedule")
+	if err != nil {
+		return err
+	}
+
+	st.AddResource(state.AWSEventsRule, ruleName, result.RuleArn)
+	return state.WriteState(directory, st)
+}
+
+func deleteKeepWarmRule(ruleName string, cfg *config.Config) error {
+	err := cli.Execute("aws", []string{
+		"events",
+		"remove-targets",
+		"--rule", ruleName,
+		"--ids", keepWarmStatementID,
+	}, "Removing the function from the keep-warm schedule")
+	if err != nil && !cli.IsNotFound(err) {
+		return err
+	}
+	err = cli.Execute("aws", []string{
+		"events",
+		"delete-rule",
+		"--name", ruleName,
+	}, "Deleting the keep-warm schedule")
+	if err != nil && !cli.IsNotFound(err) {
+		return err
+	}
+	err = cli.Execute("aws", []string{
+		"lambda",
+		"remove-permission",
+		"--function-name", cfg.ProjectName,
+		"--statement-id", keepWarmStatementID,
+	}, "Removing lambda permissions for the keep-warm schedule")
+	if cli.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func keepWarmRuleName(cfg *config.Config) string {
+	return fmt.Sprintf("kettle-keep-warm-%s", cfg.ProjectName)
+}
+
+func functionArn(cfg *config.Config, stg *settings.Settings) string {
+	return fmt.Sprintf("arn:%s:lambda:%s:%s:function:%s",
+		getPartition(stg.AWS.DeploymentRegion),
+		stg.AWS.DeploymentRegion,
+		stg.AWS.AccountID,
+		cfg.ProjectName,
+	)
+}
@@ -1,18 +1,40 @@
 package aws
 
 import (
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/operatorai/kettle-cli/cli"
 	"github.com/operatorai/kettle-cli/clouds/aws/apigateway"
+	"github.com/operatorai/kettle-cli/clouds/aws/client"
 	"github.com/operatorai/kettle-cli/config"
 	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
 )
 
 type AWSLambdaFunction struct{}
 
+// lambdaReportInterval is how often the state of a function is reported while waiting for it
+const lambdaReportInterval = 15 * time.Second
+
+// The wildcard character (*) as the stage value indicates testing only
+var invocationPermissions = map[string]string{
+	"test": "*",
+	"prod": "prod",
+}
+
 func (AWSLambdaFunction) Deploy(directory string, cfg *config.Config, stg *settings.Settings) error {
+	if !cfg.IsHTTP1() {
+		return fmt.Errorf("the %s protocol is only supported on container targets", cfg.Config.Protocol)
+	}
+	if err := cfg.ValidateArchitecture(); err != nil {
+		return err
+	}
+	if err := cfg.ValidateLogMetrics(); err != nil {
+		return err
+	}
 	fmt.Println("🚢  Deploying ", cfg.ProjectName, "as an AWS Lambda function")
 	fmt.Println("⏭  Entry point: ", cfg.Config.EntryFunction, fmt.Sprintf("(%s)", cfg.Config.Runtime))
 	// @TODO future - container-based deployments
@@ -30,15 +52,25 @@ func (AWSLambdaFunction) Deploy(directory string, cfg *config.Config, stg *setti
 		}
 	}()
 
+	if err := adoptExistingResources(directory, cfg, stg); err != nil {
+		return err
+	}
+	if err := reuseRecordedResources(directory, cfg, stg); err != nil {
+		return err
+	}
+
 	var waitType string
-	exists, err := lambdaFunctionExists(cfg.ProjectName)
+	exists, err := lambdaFunctionExists(cfg.ProjectName, stg.AWS.DeploymentRegion)
 	if err != nil {
 		return err
 	}
 	if exists {
 		// Update the function with the new code
 		waitType = "function-updated"
-		if err := updateLambda(deploymentArchive, cfg); err != nil {
+		if err := updateLambda(deploymentArchive, cfg, stg); err != nil {
+			return err
+		}
+		if err := tagLambda(cfg, stg, functionArn(cfg, stg)); err != nil {
 			return err
 		}
 	} else {
@@ -48,34 +80,224 @@ func (AWSLambdaFunction) Deploy(directory string, cfg *config.Config, stg *setti
 			return err
 		}
 
+		if err := recordResource(directory, state.AWSLambdaFunction, cfg.ProjectName, functionArn(cfg, stg)); err != nil {
+			return err
+		}
+		if err := recordResource(directory, state.AWSIAMRole, roleNameFromArn(stg.AWS.RoleArn), stg.AWS.RoleArn); err != nil {
+			return err
+		}
+
 		// Note: if the first deployment of a function fails after the function has
 		// been created, then there is currently no way to re-deploy and create the
 		// REST API. This should be changed so that a deployment asks whether to add
 		// a function to an API if e.g. it hasn't already been added to one
-		if cli.PromptToConfirm("Add Lambda function to a REST API") {
+		if cfg.Config.Queue == nil && cli.PromptToConfirm("Add Lambda function to a REST API") {
 			if err := addLambdaToRestAPI(deploymentArchive, cfg, stg); err != nil {
 				return err
 			}
+			if err := recordRestApiWiring(directory, cfg, stg); err != nil {
+				return err
+			}
 
-			url := fmt.Sprintf("https://%s.execute-api.%s.amazonaws.com/prod/%s",
+			url := fmt.Sprintf("https://%s.execute-api.%s.%s/prod/%s",
 				stg.AWS.RestApiID,
 				stg.AWS.DeploymentRegion,
+				getDNSSuffix(stg.AWS.DeploymentRegion),
 				cfg.ProjectName,
 			)
 			fmt.Println("🔍  API Endpoint: ", url)
 		}
 	}
-	return waitForLambda(waitType, cfg)
+	if stg.AWS.RoleArn != "" {
+		if err := setTracingPermissions(cfg, stg); err != nil {
+			return err
+		}
+	}
+	if err := waitForLambda(waitType, cfg, stg); err != nil {
+		return err
+	}
+	if err := setApiSettings(cfg, stg); err != nil {
+		return err
+	}
+	if err := setRequestValidation(directory, cfg, stg); err != nil {
+		return err
+	}
+	if err := setFlags(directory, cfg, stg); err != nil {
+		return err
+	}
+	if err := setApiStages(directory, cfg, stg); err != nil {
+		return err
+	}
+	if err := setCustomDomain(directory, cfg, stg); err != nil {
+		return err
+	}
+	if err := setQueueTrigger(directory, cfg, stg); err != nil {
+		return err
+	}
+	if err := setKeepWarm(directory, cfg, stg); err != nil {
+		return err
+	}
+	if err := setTriggers(directory, cfg, stg); err != nil {
+		return err
+	}
+	return setLogMetrics(directory, cfg)
+}
+
+// recordResource adds a resource that was created during a deployment to the project's state
+func recordResource(directory, resourceType, id, arn string) error {
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return err
+	}
+	st.AddResource(resourceType, id, arn)
+	return state.WriteState(directory, st)
+}
+
+// UseProjectResources uses the execution role and REST API that the project's state
+// records (e.g. ones that it adopted, or its team's API), instead of the defaults
+// in the settings
+func (AWSLambdaFunction) UseProjectResources(directory string, cfg *config.Config, stg *settings.Settings) error {
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return err
+	}
+	useRecordedResources(st, stg)
+	return nil
+}
+
+// useRecordedResources uses the execution role and REST API that are
+// recorded in the project's state instead of the defaults
+func useRecordedResources(st *state.State, stg *settings.Settings) {
+	if roles := st.GetResources(state.AWSIAMRole); len(roles) != 0 {
+		stg.AWS.UseProjectRole(roles[0].Arn)
+	}
+	if apis := st.GetResources(state.AWSRestApi); len(apis) != 0 {
+		stg.AWS.UseProjectRestApi(apis[0].ID)
+	}
+}
+
+// reuseRecordedResources uses the execution role and REST API that were recorded
+// in the project's state (e.g. on another machine); recorded resources that
+// have been deleted by hand are removed from the state first
+func reuseRecordedResources(directory string, cfg *config.Config, stg *settings.Settings) error {
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return err
+	}
+	if err := forgetDeletedResources(st, cfg, stg); err != nil {
+		return err
+	}
+	if err := state.WriteState(directory, st); err != nil {
+		return err
+	}
+	useRecordedResources(st, stg)
+	return nil
+}
+
+// forgetDeletedResources removes the function, execution role, and REST API
+// wiring from the state if they no longer exist, so that a redeploy creates
+// (or chooses) them again instead of failing to update them
+func forgetDeletedResources(st *state.State, cfg *config.Config, stg *settings.Settings) error {
+	for _, resourceType := range []string{
+		state.AWSLambdaFunction,
+		state.AWSIAMRole,
+		state.AWSRestApi,
+		state.AWSRestApiResource,
+	} {
+		for _, resource := range st.GetResources(resourceType) {
+			// A resource is looked up in the region that it was created in, so that
+			// one that is in another region than the deploy's is not forgotten
+			region := getRecordedRegion(st, resource)
+			if region == "" {
+				region = stg.AWS.DeploymentRegion
+			}
+			exists, err := recordedResourceExists(st, resource, region)
+			if err != nil {
+				return err
+			}
+			if exists {
+				continue
+			}
+			fmt.Println("⏭   Already deleted: ", resource.Type, resource.ID)
+			st.RemoveResource(resource.Type, resource.ID)
+
+			switch resource.Type {
+			case state.AWSIAMRole:
+				stg.AWS.ForgetRole(resource.Arn)
+			case state.AWSRestApi:
+				stg.AWS.ForgetRestApi(resource.ID)
+				for _, apiResource := range st.GetResources(state.AWSRestApiResource) {
+					st.RemoveResource(apiResource.Type, apiResource.ID)
+				}
+				cfg.Config.AWS.RestApiResourceID = ""
+			case state.AWSRestApiResource:
+				if cfg.Config.AWS.RestApiResourceID == resource.ID {
+					cfg.Config.AWS.RestApiResourceID = ""
+				}
+			}
+			if resource.Type == state.AWSLambdaFunction || resource.Type == state.AWSRestApi {
+				// The function's invoke permissions were deleted with it (or are unused)
+				for _, permission := range st.GetResources(state.AWSLambdaPermission) {
+					if permission.Group == "" {
+						st.RemoveResource(permission.Type, permission.ID)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// recordedResourceExists looks up a resource that is recorded in the state
+func recordedResourceExists(st *state.State, resource *state.Resource, region string) (bool, error) {
+	api, err := client.Get(region)
+	if err != nil {
+		return false, err
+	}
+	switch resource.Type {
+	case state.AWSLambdaFunction:
+		return lambdaFunctionExists(resource.ID, region)
+	case state.AWSIAMRole:
+		_, err = api.GetRole(resource.ID)
+	case state.AWSRestApi:
+		err = api.GetRestApi(resource.ID)
+	case state.AWSRestApiResource:
+		apis := st.GetResources(state.AWSRestApi)
+		if len(apis) == 0 {
+			return false, nil
+		}
+		err = api.GetResource(apis[0].ID, resource.ID)
+	default:
+		return true, nil
+	}
+	if cli.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// recordRestApiWiring records the REST API, the function's resource in it, and
+// the permissions that allow the API to invoke the function
+func recordRestApiWiring(directory string, cfg *config.Config, stg *settings.Settings) error {
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return err
+	}
+	st.AddResource(state.AWSRestApi, stg.AWS.RestApiID, "")
+	st.AddResource(state.AWSRestApiResource, cfg.Config.AWS.RestApiResourceID, "")
+	for env := range invocationPermissions {
+		st.AddResource(state.AWSLambdaPermission, invocationStatementID(env), "")
+	}
+	return state.WriteState(directory, st)
 }
 
-func lambdaFunctionExists(name string) (bool, error) {
-	_, err := cli.ExecuteWithResult("aws", []string{
-		"lambda",
-		"get-function",
-		"--function-name", name,
-	}, "Checking status of lambda function")
+func lambdaFunctionExists(name, region string) (bool, error) {
+	api, err := client.Get(region)
 	if err != nil {
-		if err.Error() == "exit status 254" {
+		return false, err
+	}
+	if _, err := api.GetFunction(name); err != nil {
+		if cli.IsNotFound(err) {
 			return false, nil
 		}
 		return false, err
@@ -83,24 +305,54 @@ func lambdaFunctionExists(name string) (bool, error) {
 	return true, nil
 }
 
-func updateLambda(deploymentArchive string, cfg *config.Config) error {
-	return cli.Execute("aws", []string{
-		"lambda",
-		"update-function-code",
-		"--function-name", cfg.ProjectName,
-		"--zip-file", fmt.Sprintf("fileb://%s", deploymentArchive),
-	}, "Updating lambda function code")
+func updateLambda(deploymentArchive string, cfg *config.Config, stg *settings.Settings) error {
+	api, err := client.Get(stg.AWS.DeploymentRegion)
+	if err != nil {
+		return err
+	}
+	if err := api.UpdateFunctionCode(cfg.ProjectName, deploymentArchive, cfg.GetArchitecture()); err != nil {
+		return err
+	}
+
+	configuration := getLambdaConfiguration(cfg, stg)
+	if configuration.IsEmpty() {
+		return nil
+	}
+
+	// The code update must complete before the configuration can be changed
+	if err := waitForLambda("function-updated", cfg, stg); err != nil {
+		return err
+	}
+	return api.UpdateFunctionConfiguration(cfg.ProjectName, configuration)
+}
+
+// getLambdaConfiguration returns the function's environment, VPC, and tracing
+func getLambdaConfiguration(cfg *config.Config, stg *settings.Settings) *client.FunctionConfiguration {
+	configuration := &client.FunctionConfiguration{
+		Environment: cfg.DeployEnvironment(),
+	}
+	if wrapper := getTracingWrapper(cfg); wrapper != "" {
+		configuration.Environment["AWS_LAMBDA_EXEC_WRAPPER"] = wrapper
+	}
+	setVpcConfiguration(configuration, cfg)
+	setTracingConfiguration(configuration, cfg, stg)
+	return configuration
 }
 
 // https://docs.aws.amazon.com/lambda/latest/dg/services-apigateway-tutorial.html
 func addLambdaToRestAPI(deploymentArchive string, cfg *config.Config, stg *settings.Settings) error {
 	// Create or set the REST API
-	if err := apigateway.SetRestApiID(stg); err != nil {
+	// An existing REST API (rest_api_id) takes precedence over a shared API's name
+	apiName := cfg.Config.AWS.RestApiName
+	if cfg.Config.AWS.RestApiID != "" {
+		apiName = ""
+	}
+	if err := apigateway.SetRestApiID(apiName, stg); err != nil {
 		return err
 	}
 
 	// Collect the available resources in the API
-	resources, err := apigateway.GetResources(stg)
+	resources, err := apigateway.GetResources(stg.AWS.RestApiID, stg.AWS.DeploymentRegion)
 	if err != nil {
 		return err
 	}
@@ -120,9 +372,12 @@ func addLambdaToRestAPI(deploymentArchive string, cfg *config.Config, stg *setti
 		return err
 	}
 
-	// Set the response codes across the Lambda & API gateway
-	if err := addIntegrationResponses(cfg, stg); err != nil {
-		return err
+	// Set the response codes across the Lambda & API gateway; proxy
+	// integrations return the function's own status code
+	if cfg.GetPayloadFormat() == config.PayloadFormatBody {
+		if err := addIntegrationResponses(cfg, stg); err != nil {
+			return err
+		}
 	}
 
 	// Deploy the API with the new resource & integration
@@ -148,127 +403,259 @@ func createLambdaFunction(deploymentArchive string, functionName string, cfg *co
 		return err
 	}
 
-	// The --handler option in the create-function command changes based on the
-	// programming language
-	var handler, runtime string
+	handler, runtime, err := getHandlerAndRuntime(functionName, cfg)
+	if err != nil {
+		return err
+	}
+
+	// Create the Lambda function
+	api, err := client.Get(stg.AWS.DeploymentRegion)
+	if err != nil {
+		return err
+	}
+	return api.CreateFunction(&client.Function{
+		Name:          cfg.ProjectName,
+		Runtime:       runtime,
+		Role:          stg.AWS.RoleArn,
+		Handler:       handler,
+		Archive:       deploymentArchive,
+		Architecture:  cfg.GetArchitecture(),
+		Memory:        cfg.Config.Memory,
+		Timeout:       cfg.Config.Timeout,
+		Tags:          cfg.Tags(),
+		Configuration: getLambdaConfiguration(cfg, stg),
+	})
+}
+
+// defaultJavaHandlerMethod is the method of a Java handler class that
+// is invoked, if the entry_function only names the class
+const defaultJavaHandlerMethod = "handleRequest"
+
+// rustLambdaRuntime is the OS-only runtime that Rust functions run on
+const rustLambdaRuntime = "provided.al2023"
+
+// The --handler option in the create-function command changes based on the
+// programming language
+func getHandlerAndRuntime(functionName string, cfg *config.Config) (string, string, error) {
 	switch {
 	case strings.HasPrefix(cfg.Config.Runtime, "python"):
-		handler = fmt.Sprintf("main.%s", functionName)
-		runtime = cfg.Config.Runtime
+		return fmt.Sprintf("main.%s", functionName), cfg.Config.Runtime, nil
 	case strings.HasPrefix(cfg.Config.Runtime, "go"):
-		handler = "main"
-		runtime = "go1.x"
-	default:
-		return fmt.Errorf("unknown runtime: %s", cfg.Config.Runtime)
+		return "main", "go1.x", nil
+	case strings.HasPrefix(cfg.Config.Runtime, "java"):
+		// e.g. com.example.Handler::handleRequest
+		if !strings.Contains(functionName, "::") {
+			functionName = fmt.Sprintf("%s::%s", functionName, defaultJavaHandlerMethod)
+		}
+		return functionName, cfg.Config.Runtime, nil
+	case strings.HasPrefix(cfg.Config.Runtime, "dotnet"):
+		// e.g. MyFunction::MyFunction.Function::FunctionHandler
+		if strings.Count(functionName, "::") != 2 {
+			return "", "", fmt.Errorf("the entry_function of .NET functions must be a handler string (Assembly::Namespace.Class::Method), got: %s", functionName)
+		}
+		return functionName, cfg.Config.Runtime, nil
+	case cfg.Config.Runtime == "rust":
+		// Rust functions are built as a bootstrap executable for the OS-only runtime
+		return "bootstrap", rustLambdaRuntime, nil
+	case strings.HasPrefix(cfg.Config.Runtime, "provided"):
+		// Custom runtimes (e.g. Rust) run the archive's bootstrap executable
+		return "bootstrap", cfg.Config.Runtime, nil
 	}
+	return "", "", fmt.Errorf("unknown runtime: %s", cfg.Config.Runtime)
+}
 
-	// Create the Lambda function
-	return cli.Execute("aws", []string{
-		"lambda",
-		"create-function",
-		"--function-name", cfg.ProjectName,
-		"--runtime", runtime,
-		"--role", stg.AWS.RoleArn,
-		"--handler", handler,
-		"--package-type", "Zip",
-		"--zip-file", fmt.Sprintf("fileb://%s", deploymentArchive),
-	}, "Creating new lambda function")
+// waitForLambda waits until the function is active (function-active) or its
+// update has completed (function-updated), and reports its state as it waits,
+// e.g. while Lambda waits for a new execution role to propagate
+func waitForLambda(waitType string, cfg *config.Config, stg *settings.Settings) error {
+	api, err := client.Get(stg.AWS.DeploymentRegion)
+	if err != nil {
+		return err
+	}
+	timeout := cfg.GetWaitTimeout()
+	start := time.Now()
+	lastReported, lastReportTime := "", start
+	err = api.WaitForFunction(cfg.ProjectName, waitType == "function-updated", timeout, func(status *client.FunctionStatus) (bool, error) {
+		current := status.State
+		if waitType == "function-updated" {
+			current = status.LastUpdateStatus
+		}
+		switch current {
+		case "Active", "Successful":
+			return true, nil
+		case "Failed", "Inactive":
+			return false, fmt.Errorf("the function is %s", describeLambdaStatus(status, current))
+		}
+		// The state is reported when it changes, and then every lambdaReportInterval
+		report := describeLambdaStatus(status, current)
+		if report != lastReported || time.Since(lastReportTime) >= lambdaReportInterval {
+			elapsed := time.Since(start).Round(time.Second)
+			fmt.Println("⏳  Waiting for the function: ", fmt.Sprintf("%s, %s elapsed", report, elapsed))
+			lastReportTime = time.Now()
+		}
+		lastReported = report
+		return false, nil
+	})
+	if errors.Is(err, client.ErrWaitTimeout) {
+		return fmt.Errorf("the function was still %s after %s (wait longer with deploy_settings.wait_timeout in kettle.json)",
+			lastReported,
+			timeout,
+		)
+	}
+	return err
 }
 
-func waitForLambda(waitType string, cfg *config.Config) error {
-	return cli.Execute("aws", []string{
-		"lambda",
-		"wait",
-		waitType,
-		"--function-name", cfg.ProjectName,
-	}, "Waiting for function to be active")
+// describeLambdaStatus returns the state, and the reason for it (if there is one)
+func describeLambdaStatus(status *client.FunctionStatus, current string) string {
+	description := strings.ToLower(current)
+	switch {
+	case status.LastUpdateStatusReason != "":
+		description += fmt.Sprintf(" (%s)", status.LastUpdateStatusReason)
+	case status.StateReason != "":
+		description += fmt.Sprintf(" (%s)", status.StateReason)
+	}
+	return description
 }
 
 func addFunctionIntegration(cfg *config.Config, stg *settings.Settings) error {
+	api, err := client.Get(stg.AWS.DeploymentRegion)
+	if err != nil {
+		return err
+	}
+	// The function receives the request's body, or (with a proxy
+	// integration) the whole request, in the proxy event format
+	integrationType := "AWS"
+	if cfg.GetPayloadFormat() == config.PayloadFormatProxy {
+		integrationType = "AWS_PROXY"
+	}
 	// Create the integration between the API gateway and the Lambda
-	return cli.Execute("aws", []string{
-		"apigateway",
-		"put-integration",
-		"--rest-api-id", stg.AWS.RestApiID,
-		"--resource-id", cfg.Config.AWS.RestApiResourceID,
-		"--http-method", "POST",
-		"--type", "AWS",
-		"--integration-http-method", "POST",
-		"--uri", fmt.Sprintf("arn:aws:apigateway:%s:lambda:path/2015-03-31/functions/arn:aws:lambda:%s:%s:function:%s/invocations",
+	return api.PutIntegration(&client.Integration{
+		RestApiID:             stg.AWS.RestApiID,
+		ResourceID:            cfg.Config.AWS.RestApiResourceID,
+		HttpMethod:            "POST",
+		Type:                  integrationType,
+		IntegrationHttpMethod: "POST",
+		TimeoutInMillis:       cfg.GetApiTimeout() * 1000,
+		URI: fmt.Sprintf("arn:%s:apigateway:%s:lambda:path/2015-03-31/functions/arn:%s:lambda:%s:%s:function:%s/invocations",
+			getPartition(stg.AWS.DeploymentRegion),
 			stg.AWS.DeploymentRegion,
+			getPartition(stg.AWS.DeploymentRegion),
 			stg.AWS.DeploymentRegion,
 			stg.AWS.AccountID,
-			cfg.ProjectName,
+			getIntegrationTarget(cfg),
 		),
-	}, "Integrating the lambda function with the API resource")
+	})
+}
+
+// setApiSettings updates the function's integration (its payload format and
+// timeout) and the API's compression, if the function has been added to the API
+func setApiSettings(cfg *config.Config, stg *settings.Settings) error {
+	if cfg.Config.Api == nil || cfg.Config.AWS.RestApiResourceID == "" || stg.AWS.RestApiID == "" {
+		return nil
+	}
+	if cfg.Config.Timeout > cfg.GetApiTimeout() {
+		fmt.Println(fmt.Sprintf("⚠️   The function's timeout (%ds) is longer than the API's (%ds): slower requests will fail with a 504",
+			cfg.Config.Timeout,
+			cfg.GetApiTimeout(),
+		))
+	}
+	if err := addFunctionIntegration(cfg, stg); err != nil {
+		return err
+	}
+	if cfg.GetPayloadFormat() == config.PayloadFormatBody {
+		if err := addIntegrationResponses(cfg, stg); err != nil {
+			return err
+		}
+	}
+	if size := cfg.Config.Api.MinimumCompressionSize; size != nil {
+		// Note: compression is a setting of the whole (shared) REST API
+		api, err := client.Get(stg.AWS.DeploymentRegion)
+		if err != nil {
+			return err
+		}
+		err = api.UpdateRestApi(stg.AWS.RestApiID, &client.PatchOperation{
+			Path:  "/minimumCompressionSize",
+			Value: fmt.Sprintf("%d", *size),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if len(cfg.Config.ApiStages) != 0 {
+		// The API stages are deployed with their aliases
+		return nil
+	}
+	return apigateway.Deploy(stg)
 }
 
 func addIntegrationResponses(cfg *config.Config, stg *settings.Settings) error {
+	api, err := client.Get(stg.AWS.DeploymentRegion)
+	if err != nil {
+		return err
+	}
 	// Set any responses matching the ".*error.*" regex to have status 500
-	err := cli.Execute("aws", []string{
-		"apigateway",
-		"put-integration-response",
-		"--rest-api-id", stg.AWS.RestApiID,
-		"--resource-id", cfg.Config.AWS.RestApiResourceID,
-		"--http-method", "POST",
-		"--status-code", "500",
-		"--selection-pattern", ".*error.*", // .*error.*
-		"--region", stg.AWS.DeploymentRegion,
-	}, "Setting the integration error response")
+	err = api.PutIntegrationResponse(&client.IntegrationResponse{
+		RestApiID:        stg.AWS.RestApiID,
+		ResourceID:       cfg.Config.AWS.RestApiResourceID,
+		HttpMethod:       "POST",
+		StatusCode:       "500",
+		SelectionPattern: ".*error.*",
+	})
 	if err != nil {
 		return err
 	}
 	// Add a 500 response to the gateway method, so that it can also return errors
-	err = cli.Execute("aws", []string{
-		"apigateway",
-		"put-method-response",
-		"--region", stg.AWS.DeploymentRegion,
-		"--rest-api-id", stg.AWS.RestApiID,
-		"--resource-id", cfg.Config.AWS.RestApiResourceID,
-		"--http-method", "POST",
-		"--status-code", "500",
-	}, "Setting the gateway error response")
-	if err != nil {
+	err = api.PutMethodResponse(&client.MethodResponse{
+		RestApiID:  stg.AWS.RestApiID,
+		ResourceID: cfg.Config.AWS.RestApiResourceID,
+		HttpMethod: "POST",
+		StatusCode: "500",
+	})
+	if err != nil && !cli.HasErrorCode(err, "ConflictException") {
+		// The response already exists, if the integration is being updated
 		return err
 	}
 
 	// Set the default integration response to JSON
-	return cli.Execute("aws", []string{
-		"apigateway",
-		"put-integration-response",
-		"--rest-api-id", stg.AWS.RestApiID,
-		"--resource-id", cfg.Config.AWS.RestApiResourceID,
-		"--http-method", "POST",
-		"--status-code", "200",
-		"--response-templates", "application/json=\"\"",
-	}, "Setting the default integration response to JSON")
+	return api.PutIntegrationResponse(&client.IntegrationResponse{
+		RestApiID:         stg.AWS.RestApiID,
+		ResourceID:        cfg.Config.AWS.RestApiResourceID,
+		HttpMethod:        "POST",
+		StatusCode:        "200",
+		ResponseTemplates: map[string]string{"application/json": ""},
+	})
 }
 
 func addInvocationPermission(cfg *config.Config, stg *settings.Settings) error {
-	// The wildcard character (*) as the stage value indicates testing only
-	permissions := map[string]string{
-		"test": "*",
-		"prod": "prod",
-	}
-	for env, permission := range permissions {
-		err := cli.Execute("aws", []string{
-			"lambda",
-			"add-permission",
-			"--function-name", cfg.ProjectName,
-			"--statement-id", fmt.Sprintf("operator-apigateway-%s", env),
-			"--action", "lambda:InvokeFunction",
-			"--principal", "apigateway.amazonaws.com",
-			"--source-arn", fmt.Sprintf("arn:aws:execute-api:%s:%s:%s/%s/POST/%s",
-				stg.AWS.DeploymentRegion,
-				stg.AWS.AccountID,
-				stg.AWS.RestApiID,
-				permission,
-				cfg.ProjectName,
-			),
-		}, fmt.Sprintf("Setting lambda permissions for: %s", env))
-		if err != nil {
+	for env := range invocationPermissions {
+		if err := addInvocationPermissionForEnv(env, cfg, stg); err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+func addInvocationPermissionForEnv(env string, cfg *config.Config, stg *settings.Settings) error {
+	api, err := client.Get(stg.AWS.DeploymentRegion)
+	if err != nil {
+		return err
+	}
+	return api.AddPermission(&client.Permission{
+		FunctionName: cfg.ProjectName,
+		StatementID:  invocationStatementID(env),
+		Action:       "lambda:InvokeFunction",
+		Principal:    "apigateway.amazonaws.com",
+		SourceArn: fmt.Sprintf("arn:%s:execute-api:%s:%s:%s/%s/POST/%s",
+			getPartition(stg.AWS.DeploymentRegion),
+			stg.AWS.DeploymentRegion,
+			stg.AWS.AccountID,
+			stg.AWS.RestApiID,
+			invocationPermissions[env],
+			cfg.ProjectName,
+		),
+	})
+}
+
+func invocationStatementID(env string) string {
+	return fmt.Sprintf("operator-apigateway-%s", env)
+}
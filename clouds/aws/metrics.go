@@ -0,0 +1,99 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+// setLogMetrics creates a CloudWatch metric filter on the function's log group for
+// each of the project's log metrics, and deletes the ones that have been removed
+func setLogMetrics(directory string, cfg *config.Config) error {
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return err
+	}
+	if len(cfg.Config.LogMetrics) != 0 {
+		if err := createLogGroup(cfg); err != nil {
+			return err
+		}
+	}
+
+	declared := map[string]bool{}
+	for _, metric := range cfg.Config.LogMetrics {
+		filterName := logMetricFilterName(metric, cfg)
+		fmt.Println("📈  Log metric: ", metric.Name, fmt.Sprintf("(%s)", metric.GetPattern()))
+		transformation := fmt.Sprintf("metricName=%s,metricNamespace=%s,metricValue=%s",
+			metric.Name,
+			cfg.MetricsNamespace(),
+			metric.GetValue(),
+		)
+		if metric.Value == "" {
+			// Counts are zero when nothing matches, rather than missing
+			transformation += ",defaultValue=0"
+		}
+		if metric.Unit != "" {
+			transformation += fmt.Sprintf(",unit=%s", metric.Unit)
+		}
+		err := cli.Execute("aws", []string{
+			"logs",
+			"put-metric-filter",
+			"--log-group-name", logGroupName(cfg),
+			"--filter-name", filterName,
+			"--filter-pattern", metric.GetPattern(),
+			"--metric-transformations", transformation,
+		}, "Creating the log metric filter")
+		if err != nil {
+			return err
+		}
+		st.AddResource(state.AWSLogMetricFilter, filterName, "")
+		declared[filterName] = true
+	}
+
+	for _, resource := range st.GetResources(state.AWSLogMetricFilter) {
+		if declared[resource.ID] {
+			continue
+		}
+		if err := removeResource(st, resource, cfg, nil); err != nil {
+			return err
+		}
+	}
+	return state.WriteState(directory, st)
+}
+
+// createLogGroup creates the function's log group, which Lambda otherwise
+// only creates when the function is first invoked
+func createLogGroup(cfg *config.Config) error {
+	err := cli.Execute("aws", []string{
+		"logs",
+		"create-log-group",
+		"--log-group-name", logGroupName(cfg),
+	}, "Creating the function's log group")
+	if cli.HasErrorCode(err, "ResourceAlreadyExistsException") {
+		return nil
+	}
+	return err
+}
+
+func deleteLogMetricFilter(filterName string, cfg *config.Config) error {
+	err := cli.Execute("aws", []string{
+		"logs",
+		"delete-metric-filter",
+		"--log-group-name", logGroupName(cfg),
+		"--filter-name", filterName,
+	}, "Deleting the log metric filter")
+	if cli.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func logGroupName(cfg *config.Config) string {
+	return fmt.Sprintf("/aws/lambda/%s", cfg.ProjectName)
+}
+
+func logMetricFilterName(metric *config.LogMetric, cfg *config.Config) string {
+	return fmt.Sprintf("kettle-%s-%s", cfg.ProjectName, metric.Name)
+}
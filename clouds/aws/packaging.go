@@ -0,0 +1,119 @@
+package aws
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+)
+
+// deploymentPackage is the state that is passed between
+// the stages of a packaging pipeline
+type deploymentPackage struct {
+	cfg *config.Config
+	// The path to the deployment archive that the pipeline creates
+	archive string
+	// The python environment's site-packages (python only)
+	sitePackages string
+}
+
+// packagingStep is the built-in implementation of a packaging stage
+type packagingStep func(pkg *deploymentPackage) error
+
+// getPackagingSteps returns the built-in packaging stages for the runtime;
+// stages that the runtime does not need are not included
+func getPackagingSteps(runtime string) (map[string]packagingStep, bool) {
+	switch {
+	case strings.HasPrefix(runtime, "python"):
+		return map[string]packagingStep{
+			config.PackagingClean:       cleanDeploymentArchive,
+			config.PackagingResolveDeps: resolvePythonDependencies,
+			config.PackagingArchive:     archivePythonLambda,
+		}, true
+	case strings.HasPrefix(runtime, "go"):
+		return map[string]packagingStep{
+			config.PackagingClean:       cleanDeploymentArchive,
+			config.PackagingResolveDeps: resolveGoDependencies,
+			config.PackagingBuild:       buildGoLambda,
+			config.PackagingArchive:     archiveGoLambda,
+		}, true
+	case strings.HasPrefix(runtime, "java"):
+		return map[string]packagingStep{
+			config.PackagingClean:   cleanDeploymentArchive,
+			config.PackagingBuild:   buildJavaLambda,
+			config.PackagingArchive: archiveJavaLambda,
+		}, true
+	case runtime == "rust":
+		return map[string]packagingStep{
+			config.PackagingClean:   cleanDeploymentArchive,
+			config.PackagingBuild:   buildRustLambda,
+			config.PackagingArchive: archiveRustLambda,
+		}, true
+	case strings.HasPrefix(runtime, "dotnet"):
+		return map[string]packagingStep{
+			config.PackagingClean:   cleanDeploymentArchive,
+			config.PackagingBuild:   buildDotnetLambda,
+			config.PackagingArchive: archiveDotnetLambda,
+		}, true
+	}
+	return nil, false
+}
+
+// runPackagingPipeline creates the deployment archive by running each stage of
+// the pipeline in order; stages in the project's "packaging" config replace
+// the runtime's built-in stage with commands (or skip it, if they are empty)
+func runPackagingPipeline(archive string, cfg *config.Config) error {
+	if err := cfg.ValidatePackaging(); err != nil {
+		return err
+	}
+	steps, ok := getPackagingSteps(cfg.Config.Runtime)
+	if !ok && len(cfg.Config.Packaging) == 0 {
+		return fmt.Errorf("runtime %s does not have a built-in packaging pipeline (add \"packaging\" to kettle.json)", cfg.Config.Runtime)
+	}
+
+	pkg := &deploymentPackage{
+		cfg:     cfg,
+		archive: archive,
+	}
+	for _, stage := range config.PackagingStages {
+		if commands, ok := cfg.Config.Packaging[stage]; ok {
+			if err := runPackagingCommands(stage, commands, pkg); err != nil {
+				return err
+			}
+			continue
+		}
+		if step, ok := steps[stage]; ok {
+			if err := step(pkg); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := os.Stat(archive); err != nil {
+		return fmt.Errorf("the packaging pipeline did not create the deployment archive: %s", deploymentArchiveName)
+	}
+	return nil
+}
+
+// runPackagingCommands runs a stage's commands in the project directory;
+// commands can use $KETTLE_ARCHIVE, the path of the deployment archive
+func runPackagingCommands(stage string, commands []string, pkg *deploymentPackage) error {
+	os.Setenv("KETTLE_ARCHIVE", pkg.archive)
+	os.Setenv("KETTLE_RUNTIME", pkg.cfg.Config.Runtime)
+	for _, command := range commands {
+		err := cli.Execute("sh", []string{
+			"-c",
+			command,
+		}, fmt.Sprintf("Running the %s stage: %s", stage, command))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cleanDeploymentArchive removes any existing deployment package
+func cleanDeploymentArchive(pkg *deploymentPackage) error {
+	return removeDeploymentArchive(pkg.cfg)
+}
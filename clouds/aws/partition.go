@@ -0,0 +1,58 @@
+package aws
+
+import (
+	"strings"
+)
+
+// Partitions are groups of regions, e.g. AWS GovCloud (US), whose
+// ARNs and endpoints are separate from those of the other regions
+const (
+	partitionAWS      = "aws"
+	partitionChina    = "aws-cn"
+	partitionGovCloud = "aws-us-gov"
+)
+
+// getPartition returns the partition of a region, which is the second part of its ARNs
+func getPartition(region string) string {
+	switch {
+	case strings.HasPrefix(region, "cn-"):
+		return partitionChina
+	case strings.HasPrefix(region, "us-gov-"):
+		return partitionGovCloud
+	}
+	return partitionAWS
+}
+
+// inPartition returns an ARN that is written for the aws partition (e.g. of
+// an AWS managed policy) in the region's partition
+func inPartition(arn string, region string) string {
+	return strings.Replace(arn, "arn:aws:", "arn:"+getPartition(region)+":", -1)
+}
+
+// getDNSSuffix returns the domain of the region's endpoints
+func getDNSSuffix(region string) string {
+	if getPartition(region) == partitionChina {
+		return "amazonaws.com.cn"
+	}
+	return "amazonaws.com"
+}
+
+// getConsoleDomain returns the domain of the partition's console
+func getConsoleDomain(region string) string {
+	switch getPartition(region) {
+	case partitionChina:
+		return "amazonaws.cn"
+	case partitionGovCloud:
+		return "amazonaws-us-gov.com"
+	}
+	return "aws.amazon.com"
+}
+
+// getPartitionFromArn returns the partition of an ARN, e.g. of the cli's identity
+func getPartitionFromArn(arn string) string {
+	parts := strings.SplitN(arn, ":", 3)
+	if len(parts) < 3 || parts[1] == "" {
+		return partitionAWS
+	}
+	return parts[1]
+}
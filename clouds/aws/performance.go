@@ -0,0 +1,215 @@
+package aws
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+const (
+	// largePackageSize is the size (in bytes) above which the
+	// package's download noticeably slows down cold starts
+	largePackageSize = 10 * 1024 * 1024
+	// slowInitDuration is the init duration (in ms) above which a cold start is slow
+	slowInitDuration = 1000
+	// largeMemorySize is the memory (in MB) above which more memory
+	// does not give the function a faster CPU to start with
+	largeMemorySize = 1769
+	// requestPrice is the price of a request (in USD), on top of its duration
+	requestPrice = 0.0000002
+)
+
+// pricePerGBSecond is the price (in USD) of each architecture's compute time in
+// us-east-1; prices are similar in other regions, which is enough to compare
+// memory settings
+var pricePerGBSecond = map[string]float64{
+	config.ArchitectureX86:   0.0000166667,
+	config.ArchitectureARM64: 0.0000133334,
+}
+
+// reportFields are the fields of the REPORT line that Lambda logs after each invocation, e.g.
+// REPORT RequestId: ... Duration: 1.23 ms ... Max Memory Used: 40 MB Init Duration: 150.00 ms
+var reportFields = map[string]*regexp.Regexp{
+	"Duration":        regexp.MustCompile(`\tDuration: ([0-9.]+) ms`),
+	"Billed Duration": regexp.MustCompile(`Billed Duration: ([0-9.]+) ms`),
+	"Max Memory Used": regexp.MustCompile(`Max Memory Used: ([0-9]+) MB`),
+	"Init Duration":   regexp.MustCompile(`Init Duration: ([0-9.]+) ms`),
+}
+
+// snapStartRuntimes are the runtimes that support SnapStart, which
+// restores a snapshot of the initialized function instead of initializing it
+var snapStartRuntimes = []string{"java", "python3.12", "python3.13", "dotnet8"}
+
+// ReportPerformance invokes the function, and reports the duration and memory usage
+// of each invocation; the first invocation after a deploy is a cold start
+func (AWSLambdaFunction) ReportPerformance(cfg *config.Config, stg *settings.Settings, invocations int, payload []byte) (*config.PerformanceReport, error) {
+	function, err := getFunctionConfiguration(cfg.ProjectName)
+	if err != nil {
+		return nil, err
+	}
+	report := &config.PerformanceReport{
+		PackageSize: function.CodeSize,
+		MemorySize:  function.MemorySize,
+	}
+	for i := 0; i < invocations; i++ {
+		invocation, err := invokeWithReport(cfg, payload, fmt.Sprintf("Invoking the function (%d/%d)", i+1, invocations))
+		if err != nil {
+			return nil, err
+		}
+		report.Invocations = append(report.Invocations, invocation)
+	}
+	report.Suggestions = getPerformanceSuggestions(report, function.Runtime)
+	return report, nil
+}
+
+// invokeWithReport invokes the function, and parses the REPORT line at the end of its log
+func invokeWithReport(cfg *config.Config, payload []byte, statusMessage string) (*config.InvocationReport, error) {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"lambda",
+		"invoke",
+		"--function-name", cfg.ProjectName,
+		"--cli-binary-format", "raw-in-base64-out",
+		"--payload", string(payload),
+		"--log-type", "Tail",
+		"--output", "json",
+		"/dev/null",
+	}, statusMessage)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		FunctionError string `json:"FunctionError"`
+		LogResult     string `json:"LogResult"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, err
+	}
+	if result.FunctionError != "" {
+		return nil, fmt.Errorf("function error: %s", result.FunctionError)
+	}
+	logs, err := base64.StdEncoding.DecodeString(result.LogResult)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(string(logs), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if !strings.HasPrefix(lines[i], "REPORT ") {
+			continue
+		}
+		invocation := &config.InvocationReport{}
+		invocation.Duration = parseReportField(lines[i], "Duration")
+		invocation.BilledDuration = parseReportField(lines[i], "Billed Duration")
+		invocation.MaxMemoryUsed = int(parseReportField(lines[i], "Max Memory Used"))
+		invocation.InitDuration = parseReportField(lines[i], "Init Duration")
+		invocation.Cold = invocation.InitDuration > 0
+		return invocation, nil
+	}
+	return nil, fmt.Errorf("the function's log has no report of the invocation")
+}
+
+func parseReportField(line string, field string) float64 {
+	match := reportFields[field].FindStringSubmatch(line)
+	if match == nil {
+		return 0
+	}
+	value, _ := strconv.ParseFloat(match[1], 64)
+	return value
+}
+
+// getPerformanceSuggestions suggests how the function could start faster,
+// or use its memory better
+func getPerformanceSuggestions(report *config.PerformanceReport, runtime string) []string {
+	initDuration := 0.0
+	maxMemoryUsed := 0
+	for _, invocation := range report.Invocations {
+		if invocation.InitDuration > initDuration {
+			initDuration = invocation.InitDuration
+		}
+		if invocation.MaxMemoryUsed > maxMemoryUsed {
+			maxMemoryUsed = invocation.MaxMemoryUsed
+		}
+	}
+
+	suggestions := []string{}
+	if report.PackageSize > largePackageSize {
+		suggestions = append(suggestions, fmt.Sprintf("The package is %.1f MB: a smaller package (e.g. without unused dependencies, or with them in a layer) is faster to load", float64(report.PackageSize)/(1024*1024)))
+	}
+	if initDuration > slowInitDuration {
+		if report.MemorySize < largeMemorySize {
+			suggestions = append(suggestions, fmt.Sprintf("Cold starts take %.0f ms: more memory (set memory in kettle.json) also gives the function more CPU to initialize with", initDuration))
+		}
+		for _, prefix := range snapStartRuntimes {
+			if strings.HasPrefix(runtime, prefix) {
+				suggestions = append(suggestions, fmt.Sprintf("Cold starts take %.0f ms: SnapStart (which %s supports) restores an initialized snapshot of the function instead", initDuration, runtime))
+				break
+			}
+		}
+	}
+	if report.MemorySize > 0 && maxMemoryUsed*10 > report.MemorySize*9 {
+		suggestions = append(suggestions, fmt.Sprintf("The function used %d of its %d MB: more memory would avoid running out of it", maxMemoryUsed, report.MemorySize))
+	}
+	return suggestions
+}
+
+// TuneMemory configures the function with each amount of memory in turn, and measures the
+// average duration and cost of its (warm) invocations; the function's memory is restored after
+func (AWSLambdaFunction) TuneMemory(cfg *config.Config, stg *settings.Settings, memorySizes []int, invocations int, payload []byte) (results []*config.MemoryTuning, err error) {
+	function, err := getFunctionConfiguration(cfg.ProjectName)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if restoreErr := setFunctionMemory(cfg, stg, function.MemorySize); err == nil {
+			err = restoreErr
+		}
+	}()
+
+	for _, memory := range memorySizes {
+		fmt.Println("🧪  Memory: ", fmt.Sprintf("%d MB", memory))
+		if err := setFunctionMemory(cfg, stg, memory); err != nil {
+			return nil, err
+		}
+		// The first invocation after a configuration change is a cold start
+		if _, err := invokeWithReport(cfg, payload, "Warming up the function"); err != nil {
+			return nil, err
+		}
+		result := &config.MemoryTuning{Memory: memory}
+		for i := 0; i < invocations; i++ {
+			invocation, err := invokeWithReport(cfg, payload, fmt.Sprintf("Invoking the function (%d/%d)", i+1, invocations))
+			if err != nil {
+				return nil, err
+			}
+			result.Duration += invocation.Duration / float64(invocations)
+			result.Cost += getInvocationCost(cfg, memory, invocation.BilledDuration) / float64(invocations)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func setFunctionMemory(cfg *config.Config, stg *settings.Settings, memory int) error {
+	err := cli.Execute("aws", []string{
+		"lambda",
+		"update-function-configuration",
+		"--function-name", cfg.ProjectName,
+		"--memory-size", fmt.Sprintf("%d", memory),
+	}, "Updating lambda function memory")
+	if err != nil {
+		return err
+	}
+	return waitForLambda("function-updated", cfg, stg)
+}
+
+// getInvocationCost is the price of an invocation's billed duration, and of the request
+func getInvocationCost(cfg *config.Config, memory int, billedDuration float64) float64 {
+	gbSeconds := float64(memory) / 1024 * billedDuration / 1000
+	return gbSeconds*pricePerGBSecond[cfg.GetArchitecture()] + requestPrice
+}
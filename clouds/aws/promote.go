@@ -0,0 +1,122 @@
+package aws
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+// PullArtifact downloads the function's deployed code bundle, and checks that
+// it is the artifact with the digest (a base64 SHA256, as reported by Lambda)
+func (AWSLambdaFunction) PullArtifact(cfg *config.Config, stg *settings.Settings, digest string) (*config.Artifact, error) {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"lambda",
+		"get-function",
+		"--function-name", cfg.ProjectName,
+		"--output", "json",
+	}, "Retrieving lambda function code location")
+	if err != nil {
+		return nil, err
+	}
+	var function struct {
+		Configuration struct {
+			CodeSha256 string `json:"CodeSha256"`
+		} `json:"Configuration"`
+		Code struct {
+			Location string `json:"Location"`
+		} `json:"Code"`
+	}
+	if err := json.Unmarshal(output, &function); err != nil {
+		return nil, err
+	}
+	if function.Configuration.CodeSha256 != digest {
+		return nil, fmt.Errorf("the deployed code (%s) is not the recorded artifact (%s)",
+			function.Configuration.CodeSha256, digest)
+	}
+
+	f, err := ioutil.TempFile("", "kettle-artifact*.zip")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := downloadCodeBundle(function.Code.Location, f); err != nil {
+		os.Remove(f.Name())
+		return nil, err
+	}
+	downloaded, err := getArchiveDigest(f.Name())
+	if err != nil {
+		os.Remove(f.Name())
+		return nil, err
+	}
+	if downloaded != digest {
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("the downloaded code bundle does not match its digest (%s)", digest)
+	}
+	return &config.Artifact{
+		Location: f.Name(),
+		Digest:   digest,
+	}, nil
+}
+
+// PullArtifact pulls the endpoint's container image, by its digest
+func (AWSSageMakerEndpoint) PullArtifact(cfg *config.Config, stg *settings.Settings, digest string) (*config.Artifact, error) {
+	repositoryURI, err := getOrCreateRepository(cfg.ProjectName)
+	if err != nil {
+		return nil, err
+	}
+	if err := dockerLogin(repositoryURI, stg); err != nil {
+		return nil, err
+	}
+	imageURI := fmt.Sprintf("%s@%s", repositoryURI, digest)
+	err = cli.Execute("docker", []string{
+		"pull",
+		imageURI,
+	}, "Pulling docker container from ECR")
+	if err != nil {
+		return nil, err
+	}
+	return &config.Artifact{
+		Location: imageURI,
+		Digest:   digest,
+	}, nil
+}
+
+// getArchiveDigest returns the base64-encoded SHA256 of a deployment
+// archive, which matches the CodeSha256 that Lambda reports
+func getArchiveDigest(archive string) (string, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(hash.Sum(nil)), nil
+}
+
+// getImageDigest returns the digest of an image (by its tag) in an ECR repository
+func getImageDigest(repositoryName, tag string) (string, error) {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"ecr",
+		"describe-images",
+		"--repository-name", repositoryName,
+		"--image-ids", fmt.Sprintf("imageTag=%s", tag),
+		"--query", "imageDetails[0].imageDigest",
+		"--output", "text",
+	}, "Retrieving the image digest")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,187 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+const (
+	queueWorkerGroup      = "queue-worker"
+	queueExecutionPolicy  = "arn:aws:iam::aws:policy/service-role/AWSLambdaSQSQueueExecutionRole"
+	defaultQueueBatchSize = 10
+)
+
+// setQueueTrigger creates the project's SQS queue and wires it to the function;
+// the queue, the mapping, and the function are tracked in state as one unit
+func setQueueTrigger(directory string, cfg *config.Config, stg *settings.Settings) error {
+	if cfg.Config.Queue == nil {
+		return nil
+	}
+
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return err
+	}
+
+	var queueURL, queueArn string
+	if cfg.Config.Queue.Existing != "" {
+		fmt.Println("📬  Queue: ", cfg.Config.Queue.Existing)
+		queueURL, queueArn, err = adoptQueue(cfg.Config.Queue.Existing, cfg)
+	} else {
+		queueName := getQueueName(cfg)
+		fmt.Println("📬  Queue: ", queueName)
+		attributes := map[string]string{}
+		if cfg.Config.Queue.VisibilityTimeout != 0 {
+			attributes["VisibilityTimeout"] = fmt.Sprintf("%d", cfg.Config.Queue.VisibilityTimeout)
+		}
+		queueURL, queueArn, err = createQueue(queueName, attributes)
+	}
+	if err != nil {
+		return err
+	}
+	queue := st.AddResource(state.AWSSQSQueue, queueURL, queueArn)
+	queue.Group = queueWorkerGroup
+	queue.Adopted = cfg.Config.Queue.Existing != ""
+	if cfg.Config.Queue.Retries > 0 {
+		if err := setDeadLetterQueue(queueURL, cfg, st); err != nil {
+			return err
+		}
+	}
+
+	// The execution role needs permission to read from the queue
+	err = cli.Execute("aws", []string{
+		"iam",
+		"attach-role-policy",
+		"--role-name", roleNameFromArn(stg.AWS.RoleArn),
+		"--policy-arn", inPartition(queueExecutionPolicy, stg.AWS.DeploymentRegion),
+	}, "Allowing the execution role to read from the queue")
+	if err != nil {
+		return err
+	}
+
+	mappingID, err := getEventSourceMapping(cfg.ProjectName, queueArn)
+	if err != nil {
+		return err
+	}
+	if mappingID == "" {
+		batchSize := defaultQueueBatchSize
+		if cfg.Config.Queue.BatchSize != 0 {
+			batchSize = cfg.Config.Queue.BatchSize
+		}
+		args := []string{
+			"lambda",
+			"create-event-source-mapping",
+			"--function-name", cfg.ProjectName,
+			"--event-source-arn", queueArn,
+			"--batch-size", fmt.Sprintf("%d", batchSize),
+			"--output", "json",
+		}
+		if cfg.Config.Queue.Retries > 0 {
+			args = append(args, "--function-response-types", batchItemFailures)
+		}
+		output, err := cli.ExecuteWithResult("aws", args, "Adding the queue as a trigger for the function")
+		if err != nil {
+			return err
+		}
+		var result struct {
+			UUID string `json:"UUID"`
+		}
+		if err := json.Unmarshal(output, &result); err != nil {
+			return err
+		}
+		mappingID = result.UUID
+	} else if cfg.Config.Queue.Retries > 0 {
+		if err := reportBatchItemFailures(mappingID); err != nil {
+			return err
+		}
+	}
+	st.AddResource(state.AWSEventSourceMapping, mappingID, "").Group = queueWorkerGroup
+	if function := st.GetResource(state.AWSLambdaFunction, cfg.ProjectName); function != nil {
+		function.Group = queueWorkerGroup
+	}
+	return state.WriteState(directory, st)
+}
+
+func getQueueName(cfg *config.Config) string {
+	if cfg.Config.Queue.Name != "" {
+		return cfg.Config.Queue.Name
+	}
+	return fmt.Sprintf("%s-queue", cfg.ProjectName)
+}
+
+// createQueue creates the queue (or returns the existing queue with the same name)
+func createQueue(queueName string, attributes map[string]string) (string, string, error) {
+	args := []string{
+		"sqs",
+		"create-queue",
+		"--queue-name", queueName,
+		"--output", "json",
+	}
+	if len(attributes) != 0 {
+		data, err := json.Marshal(attributes)
+		if err != nil {
+			return "", "", err
+		}
+		args = append(args, "--attributes", string(data))
+	}
+	output, err := cli.ExecuteWithResult("aws", args, "Creating SQS queue")
+	if err != nil {
+		return "", "", err
+	}
+	var result struct {
+		QueueURL string `json:"QueueUrl"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return "", "", err
+	}
+
+	output, err = cli.ExecuteWithResult("aws", []string{
+		"sqs",
+		"get-queue-attributes",
+		"--queue-url", result.QueueURL,
+		"--attribute-names", "QueueArn",
+		"--output", "json",
+	}, "Retrieving SQS queue ARN")
+	if err != nil {
+		return "", "", err
+	}
+	var queue struct {
+		Attributes struct {
+			QueueArn string `json:"QueueArn"`
+		} `json:"Attributes"`
+	}
+	if err := json.Unmarshal(output, &queue); err != nil {
+		return "", "", err
+	}
+	return result.QueueURL, queue.Attributes.QueueArn, nil
+}
+
+func getEventSourceMapping(functionName, sourceArn string) (string, error) {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"lambda",
+		"list-event-source-mappings",
+		"--function-name", functionName,
+		"--event-source-arn", sourceArn,
+		"--output", "json",
+	}, "Looking for existing queue triggers")
+	if err != nil {
+		return "", err
+	}
+	var results struct {
+		EventSourceMappings []struct {
+			UUID string `json:"UUID"`
+		} `json:"EventSourceMappings"`
+	}
+	if err := json.Unmarshal(output, &results); err != nil {
+		return "", err
+	}
+	if len(results.EventSourceMappings) == 0 {
+		return "", nil
+	}
+	return results.EventSourceMappings[0].UUID, nil
+}
@@ -0,0 +1,385 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/clouds/aws/apigateway"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+const (
+	// The names of the quotas in Service Quotas, and their documented defaults,
+	// which are used if Service Quotas cannot be read
+	concurrencyQuotaName    = "Concurrent executions"
+	concurrencyQuotaLimit   = 1000
+	edgeApisQuotaName       = "Edge-optimized APIs per Region"
+	edgeApisQuotaLimit      = 120
+	vpcInterfacesQuotaName  = "Elastic network interfaces per VPC"
+	vpcInterfacesQuotaLimit = 500
+	// concurrencyPeakPeriod is how far back the account's peak concurrency is read
+	concurrencyPeakPeriod = 7 * 24 * time.Hour
+)
+
+// quotaCheck returns a quota that the deploy uses, or nil if it does not use it
+type quotaCheck func(cfg *config.Config, stg *settings.Settings) (*config.Quota, error)
+
+// CheckQuotas returns the account's Lambda concurrency, and (if the deploy creates
+// them) its REST APIs and the network interfaces of its VPC; quotas that cannot
+// be read are skipped, as the checks should not stop a deploy
+func (AWSLambdaFunction) CheckQuotas(cfg *config.Config, stg *settings.Settings) ([]*config.Quota, error) {
+	quotas := []*config.Quota{}
+	for _, check := range []quotaCheck{
+		getConcurrencyQuota,
+		getRestApisQuota,
+		getVpcInterfacesQuota,
+	} {
+		quota, err := check(cfg, stg)
+		if err != nil {
+			if settings.DebugMode {
+				fmt.Println(err.Error())
+			}
+			continue
+		}
+		if quota != nil {
+			quotas = append(quotas, quota)
+		}
+	}
+	return quotas, nil
+}
+
+// RequestQuotaIncrease files a request with Service Quotas to increase the quota to
+// its suggested limit, unless one is already open, and returns the request's ID
+func (AWSLambdaFunction) RequestQuotaIncrease(quota *config.Quota, stg *settings.Settings) (string, error) {
+	if !quota.IsRequestable() {
+		return "", fmt.Errorf("an increase of the %s quota cannot be requested", quota.Name)
+	}
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"service-quotas",
+		"list-requested-service-quota-change-history-by-quota",
+		"--service-code", quota.ServiceCode,
+		"--quota-code", quota.QuotaCode,
+		"--output", "json",
+	}, "Looking for open quota increase requests")
+	if err != nil {
+		return "", err
+	}
+	var history struct {
+		RequestedQuotas []*quotaRequest `json:"RequestedQuotas"`
+	}
+	if err := json.Unmarshal(output, &history); err != nil {
+		return "", err
+	}
+	for _, request := range history.RequestedQuotas {
+		if request.Status == "PENDING" || request.Status == "CASE_OPENED" {
+			fmt.Println("⏭   Already requested: ", quota.Name, fmt.Sprintf("(%s)", request.ID))
+			return request.ID, nil
+		}
+	}
+
+	output, err = cli.ExecuteWithResult("aws", []string{
+		"service-quotas",
+		"request-service-quota-increase",
+		"--service-code", quota.ServiceCode,
+		"--quota-code", quota.QuotaCode,
+		"--desired-value", fmt.Sprintf("%.0f", quota.SuggestedLimit()),
+		"--output", "json",
+	}, fmt.Sprintf("Requesting an increase of the %s quota", quota.Name))
+	if err != nil {
+		return "", err
+	}
+	var result struct {
+		RequestedQuota *quotaRequest `json:"RequestedQuota"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return "", err
+	}
+	if result.RequestedQuota == nil {
+		return "", fmt.Errorf("the quota increase request for %s has no ID", quota.Name)
+	}
+	return result.RequestedQuota.ID, nil
+}
+
+type quotaRequest struct {
+	ID     string `json:"Id"`
+	Status string `json:"Status"`
+}
+
+// getServiceQuota returns a service's quota by its name, with its applied value
+// (or its default); if Service Quotas cannot be read, the quota has its
+// documented default, and no code, so that an increase cannot be requested
+func getServiceQuota(serviceCode, name string, defaultLimit float64) *config.Quota {
+	quota := &config.Quota{
+		ServiceCode: serviceCode,
+		Name:        name,
+		Limit:       defaultLimit,
+	}
+	// Applied values are only listed for quotas that have them
+	for _, operation := range []string{"list-service-quotas", "list-aws-default-service-quotas"} {
+		output, err := cli.ExecuteCached("aws", []string{
+			"service-quotas",
+			operation,
+			"--service-code", serviceCode,
+			"--output", "json",
+		}, fmt.Sprintf("Reading the %s quotas", serviceCode))
+		if err != nil {
+			if settings.DebugMode {
+				fmt.Println(err.Error())
+			}
+			continue
+		}
+		var result struct {
+			Quotas []struct {
+				QuotaCode  string  `json:"QuotaCode"`
+				QuotaName  string  `json:"QuotaName"`
+				Value      float64 `json:"Value"`
+				Adjustable bool    `json:"Adjustable"`
+			} `json:"Quotas"`
+		}
+		if err := json.Unmarshal(output, &result); err != nil {
+			continue
+		}
+		for _, q := range result.Quotas {
+			if q.QuotaName == name {
+				quota.QuotaCode = q.QuotaCode
+				quota.Limit = q.Value
+				quota.Adjustable = q.Adjustable
+				return quota
+			}
+		}
+	}
+	return quota
+}
+
+// getConcurrencyQuota compares the account's peak concurrency (or its reserved
+// concurrency, if that is more) with its limit, as the function is throttled
+// once the account's functions run that many executions at once
+func getConcurrencyQuota(cfg *config.Config, stg *settings.Settings) (*config.Quota, error) {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"lambda",
+		"get-account-settings",
+		"--output", "json",
+	}, "Reading the Lambda account settings")
+	if err != nil {
+		return nil, err
+	}
+	var accountSettings struct {
+		AccountLimit struct {
+			ConcurrentExecutions           float64 `json:"ConcurrentExecutions"`
+			UnreservedConcurrentExecutions float64 `json:"UnreservedConcurrentExecutions"`
+		} `json:"AccountLimit"`
+	}
+	if err := json.Unmarshal(output, &accountSettings); err != nil {
+		return nil, err
+	}
+	limit := accountSettings.AccountLimit
+	peak, err := getPeakConcurrency()
+	if err != nil {
+		return nil, err
+	}
+
+	quota := getServiceQuota("lambda", concurrencyQuotaName, concurrencyQuotaLimit)
+	// The account's settings have the limit that applies to the region
+	quota.Limit = limit.ConcurrentExecutions
+	quota.Usage = math.Max(peak, limit.ConcurrentExecutions-limit.UnreservedConcurrentExecutions)
+	quota.Reason = fmt.Sprintf("the peak of the last %d days, or the reserved concurrency", int(concurrencyPeakPeriod.Hours()/24))
+	return quota, nil
+}
+
+// getPeakConcurrency returns the most executions that the
+// account's functions ran at once, in the concurrencyPeakPeriod
+func getPeakConcurrency() (float64, error) {
+	end := time.Now().UTC()
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"cloudwatch",
+		"get-metric-statistics",
+		"--namespace", "AWS/Lambda",
+		"--metric-name", "ConcurrentExecutions",
+		"--start-time", end.Add(-concurrencyPeakPeriod).Format(time.RFC3339),
+		"--end-time", end.Format(time.RFC3339),
+		"--period", "3600",
+		"--statistics", "Maximum",
+		"--output", "json",
+	}, "Reading the peak Lambda concurrency")
+	if err != nil {
+		return 0, err
+	}
+	var result struct {
+		Datapoints []struct {
+			Maximum float64 `json:"Maximum"`
+		} `json:"Datapoints"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return 0, err
+	}
+	peak := 0.0
+	for _, datapoint := range result.Datapoints {
+		peak = math.Max(peak, datapoint.Maximum)
+	}
+	return peak, nil
+}
+
+// getRestApisQuota compares the region's edge-optimized REST APIs (which
+// is the type of the APIs that kettle creates) with their limit, if the
+// deploy creates a REST API for the function
+func getRestApisQuota(cfg *config.Config, stg *settings.Settings) (*config.Quota, error) {
+	// REST APIs are only created with a function, and are not
+	// created for queue consumers or for existing REST APIs
+	if cfg.Config.Queue != nil || cfg.Config.AWS.RestApiID != "" || stg.AWS.RestApiID != "" {
+		return nil, nil
+	}
+	exists, err := lambdaFunctionExists(cfg.ProjectName, stg.AWS.DeploymentRegion)
+	if err != nil || exists {
+		return nil, err
+	}
+	restApiID, err := apigateway.GetRestApiID(cfg.Config.AWS.RestApiName, stg.AWS.DeploymentRegion)
+	if err != nil || restApiID != "" {
+		return nil, err
+	}
+
+	output, err := cli.ExecuteCached("aws", []string{
+		"apigateway",
+		"get-rest-apis",
+	}, "Collecting available REST APIs")
+	if err != nil {
+		return nil, err
+	}
+	var results struct {
+		Items []struct {
+			EndpointConfiguration struct {
+				Types []string `json:"types"`
+			} `json:"endpointConfiguration"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(output, &results); err != nil {
+		return nil, err
+	}
+	quota := getServiceQuota("apigateway", edgeApisQuotaName, edgeApisQuotaLimit)
+	for _, item := range results.Items {
+		for _, endpointType := range item.EndpointConfiguration.Types {
+			if endpointType == "EDGE" {
+				quota.Usage++
+			}
+		}
+	}
+	quota.Needed = 1
+	quota.Reason = "a REST API is created for the function"
+	return quota, nil
+}
+
+// getVpcInterfacesQuota compares the Lambda network interfaces in the function's
+// VPC with their limit, if the function is in a VPC (or an add-on places it in
+// the default VPC); functions share an interface for each combination of
+// subnet and security groups, so the deploy only needs the ones that are new
+func getVpcInterfacesQuota(cfg *config.Config, stg *settings.Settings) (*config.Quota, error) {
+	subnetIDs := cfg.Config.AWS.SubnetIDs
+	var vpcID string
+	var err error
+	if len(subnetIDs) != 0 {
+		vpcID, err = getSubnetVpc(subnetIDs[0])
+	} else if hasVpcAddOn(cfg) {
+		vpcID, subnetIDs, err = getDefaultVpc()
+	}
+	if err != nil || vpcID == "" {
+		return nil, err
+	}
+
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"ec2",
+		"describe-network-interfaces",
+		"--filters",
+		fmt.Sprintf("Name=vpc-id,Values=%s", vpcID),
+		"Name=interface-type,Values=lambda",
+		"--output", "json",
+	}, "Counting the Lambda network interfaces in the VPC")
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		NetworkInterfaces []struct {
+			SubnetID string `json:"SubnetId"`
+			Groups   []struct {
+				GroupID string `json:"GroupId"`
+			} `json:"Groups"`
+		} `json:"NetworkInterfaces"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, err
+	}
+
+	quota := getServiceQuota("lambda", vpcInterfacesQuotaName, vpcInterfacesQuotaLimit)
+	quota.Usage = float64(len(result.NetworkInterfaces))
+	for _, subnetID := range subnetIDs {
+		shared := false
+		for _, networkInterface := range result.NetworkInterfaces {
+			groupIDs := []string{}
+			for _, group := range networkInterface.Groups {
+				groupIDs = append(groupIDs, group.GroupID)
+			}
+			if networkInterface.SubnetID == subnetID && hasSameItems(groupIDs, cfg.Config.AWS.SecurityGroupIDs) {
+				shared = true
+				break
+			}
+		}
+		if !shared {
+			quota.Needed++
+		}
+	}
+	quota.Reason = fmt.Sprintf("the function is in the VPC %s", vpcID)
+	return quota, nil
+}
+
+func getSubnetVpc(subnetID string) (string, error) {
+	output, err := cli.ExecuteCached("aws", []string{
+		"ec2",
+		"describe-subnets",
+		"--subnet-ids", subnetID,
+		"--output", "json",
+	}, "Looking for the function's VPC")
+	if err != nil {
+		return "", err
+	}
+	var subnets struct {
+		Subnets []struct {
+			VpcID string `json:"VpcId"`
+		} `json:"Subnets"`
+	}
+	if err := json.Unmarshal(output, &subnets); err != nil {
+		return "", err
+	}
+	if len(subnets.Subnets) == 0 {
+		return "", fmt.Errorf("subnet not found: %s", subnetID)
+	}
+	return subnets.Subnets[0].VpcID, nil
+}
+
+// hasVpcAddOn returns whether the project has an add-on (a Redis
+// cache) that places the function in the default VPC
+func hasVpcAddOn(cfg *config.Config) bool {
+	for _, addOn := range cfg.Config.AddOns {
+		if addOn.Type == "redis" {
+			return true
+		}
+	}
+	return false
+}
+
+func hasSameItems(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	items := map[string]bool{}
+	for _, item := range a {
+		items[item] = true
+	}
+	for _, item := range b {
+		if !items[item] {
+			return false
+		}
+	}
+	return true
+}
@@ -2,33 +2,130 @@ package aws
 
 import (
 	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
 
 	"github.com/operatorai/kettle-cli/cli"
-	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/state"
 )
 
-func SetDeploymentRegion(stg *settings.AWSSettings) error {
-	if stg.DeploymentRegion != "" {
-		return nil
+// UseRegion points the aws cli and SDK at the deployment region, with
+// AWS_REGION and AWS_DEFAULT_REGION, so that every aws command (and not only
+// the ones that pass --region) runs in it, instead of the profile's region
+func UseRegion(region string) {
+	if region == "" {
+		return
 	}
+	os.Setenv("AWS_REGION", region)
+	os.Setenv("AWS_DEFAULT_REGION", region)
+}
 
-	regions, err := getAWSRegions()
-	if err != nil {
-		return err
+// getRecordedRegion returns the region that a resource in the state was created
+// in: the region of its ARN, or else the region that the state was deployed to
+// (e.g. for a REST API); it is empty for global resources, like IAM roles
+func getRecordedRegion(st *state.State, resource *state.Resource) string {
+	switch resource.Type {
+	case state.AWSIAMRole, state.AWSIAMRolePolicy:
+		return ""
+	}
+	// arn:<partition>:<service>:<region>:<account>:<resource>
+	if parts := strings.SplitN(resource.Arn, ":", 5); len(parts) == 5 {
+		return parts[3]
+	}
+	return st.Region
+}
+
+// GetRegions returns the regions where all of the project's services are
+// available, from the AWS global infrastructure parameters in SSM
+func GetRegions(cfg *config.Config) (map[string]string, error) {
+	if cfg == nil {
+		return getAWSRegions()
+	}
+	// The lists of each service's regions only cover the aws partition; the
+	// regions of the other partitions are listed by ec2 (in the cli's region)
+	if partition, err := getCallerPartition(); err == nil && partition != partitionAWS {
+		return getAWSRegions()
 	}
+	var regions map[string]string
+	for _, service := range getServices(cfg) {
+		serviceRegions, err := getServiceRegions(service)
+		if err != nil {
+			return nil, err
+		}
+		if regions == nil {
+			regions = serviceRegions
+			continue
+		}
+		for region := range regions {
+			if _, ok := serviceRegions[region]; !ok {
+				delete(regions, region)
+			}
+		}
+	}
+	if len(regions) == 0 {
+		return nil, fmt.Errorf("no region supports all of the project's services: %v", getServices(cfg))
+	}
+	return regions, nil
+}
 
-	region, err := cli.PromptForValue("Deployment region", regions, false)
+// getServices returns the (regional) services that the project is deployed to
+func getServices(cfg *config.Config) []string {
+	services := []string{}
+	switch cfg.Config.DeploymentType {
+	case "lambda":
+		services = append(services, "lambda", "apigateway")
+	case "sagemaker":
+		services = append(services, "sagemaker", "ecr")
+	}
+	if cfg.Config.Queue != nil {
+		services = append(services, "sqs")
+	}
+	for _, addOn := range cfg.Config.AddOns {
+		switch addOn.Type {
+		case "dynamodb":
+			services = append(services, "dynamodb")
+		case "aurora":
+			services = append(services, "rds")
+		case "redis":
+			services = append(services, "elasticache")
+		}
+	}
+	return services
+}
+
+// aws ssm get-parameters-by-path --path /aws/service/global-infrastructure/services/lambda/regions
+func getServiceRegions(service string) (map[string]string, error) {
+	output, err := cli.ExecuteCached("aws", []string{
+		"ssm",
+		"get-parameters-by-path",
+		"--path", fmt.Sprintf("/aws/service/global-infrastructure/services/%s/regions", service),
+		"--output", "json",
+	}, fmt.Sprintf("Collecting regions where %s is available", service))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	stg.DeploymentRegion = region
-	return nil
+	var result struct {
+		Parameters []struct {
+			Value string `json:"Value"`
+		} `json:"Parameters"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, err
+	}
+
+	regions := map[string]string{}
+	for _, parameter := range result.Parameters {
+		regions[parameter.Value] = parameter.Value
+	}
+	return regions, nil
 }
 
 // aws ec2 describe-regions --output json
 func getAWSRegions() (map[string]string, error) {
-	output, err := cli.ExecuteWithResult("aws", []string{
+	output, err := cli.ExecuteCached("aws", []string{
 		"ec2",
 		"describe-regions",
 		"--output", "json",
@@ -0,0 +1,49 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/operatorai/kettle-cli/state"
+)
+
+func TestGetRecordedRegion(t *testing.T) {
+	st := &state.State{Region: "eu-west-1"}
+	tests := []struct {
+		name     string
+		resource *state.Resource
+		want     string
+	}{
+		{
+			name:     "a function's ARN",
+			resource: &state.Resource{Type: state.AWSLambdaFunction, ID: "hello", Arn: "arn:aws:lambda:us-east-2:123456789012:function:hello"},
+			want:     "us-east-2",
+		},
+		{
+			name:     "a GovCloud ARN",
+			resource: &state.Resource{Type: state.AWSLambdaFunction, ID: "hello", Arn: "arn:aws-us-gov:lambda:us-gov-west-1:123456789012:function:hello"},
+			want:     "us-gov-west-1",
+		},
+		{
+			name:     "without an ARN",
+			resource: &state.Resource{Type: state.AWSRestApi, ID: "a1b2c3"},
+			want:     "eu-west-1",
+		},
+		{
+			name:     "a role",
+			resource: &state.Resource{Type: state.AWSIAMRole, ID: "kettle-role", Arn: "arn:aws:iam::123456789012:role/kettle-role"},
+			want:     "",
+		},
+		{
+			name:     "a role's policy",
+			resource: &state.Resource{Type: state.AWSIAMRolePolicy, ID: "kettle-role/ssm"},
+			want:     "",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := getRecordedRegion(st, test.resource); got != test.want {
+				t.Errorf("getRecordedRegion() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/clouds/aws/apigateway"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+// renameGroup is the group of the permissions that let the old API path
+// invoke the renamed function, until the old path is deleted
+const renameGroup = "rename"
+
+// renameKept are the types of resources that do not depend on the project's
+// name (e.g. the shared role and REST API, and the ones that hold data), so
+// they are kept when it is renamed
+var renameKept = map[string]bool{
+	state.AWSIAMRole:            true,
+	state.AWSRestApi:            true,
+	state.AWSApiDomain:          true,
+	state.AWSApiBasePathMapping: true,
+	state.AWSECRRepository:      true,
+	state.AWSDynamoDBTable:      true,
+	state.AWSS3Bucket:           true,
+	state.AWSAuroraCluster:      true,
+	state.AWSAuroraInstance:     true,
+	state.AWSElastiCache:        true,
+	state.AWSSecurityGroup:      true,
+}
+
+// renameReplaced are the types of resources that are deleted when the project
+// is renamed, as the new function's would overlap them (e.g. a schedule would
+// run twice, and S3 rejects overlapping bucket notifications)
+var renameReplaced = map[string]bool{
+	state.AWSEventsRule:       true,
+	state.AWSS3Notification:   true,
+	state.AWSSyntheticsCanary: true,
+	state.AWSBudget:           true,
+}
+
+// RetireName prepares the project (with its current name) to be renamed: the
+// resources that the new function replaces are deleted, and the ones that are
+// named after the project are retired, so that they keep serving requests until
+// the grace period is over
+func (AWSLambdaFunction) RetireName(directory string, cfg *config.Config, stg *settings.Settings, after time.Time) error {
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return err
+	}
+	if st.Retiring != nil {
+		return fmt.Errorf("the resources of %s are still retiring (delete them first with: kettle rename --retire)", st.Retiring.Name)
+	}
+
+	// An explicitly named queue keeps its name, so the new function is
+	// wired to it, and the old function stops reading from it
+	queueKept := cfg.Config.Queue != nil && cfg.Config.Queue.Name != ""
+	retired := []*state.Resource{}
+	for _, resourceType := range destroyOrder {
+		for _, resource := range st.GetResources(resourceType) {
+			switch {
+			case resource.Adopted || resource.IsProtected(cfg.Config.Protected) || renameKept[resource.Type]:
+			case resource.Type == state.AWSSQSQueue && queueKept:
+			case renameReplaced[resource.Type] || (resource.Type == state.AWSEventSourceMapping && queueKept):
+				if err := removeResource(st, resource, cfg, stg); err != nil {
+					return err
+				}
+			default:
+				retired = append(retired, resource)
+			}
+		}
+	}
+	st.Retire(cfg.ProjectName, after, retired)
+	cfg.Config.AWS.RestApiResourceID = ""
+	return state.WriteState(directory, st)
+}
+
+// Cutover points the old name's API path at the renamed function, so that
+// clients that still use it are served by the new function
+func (AWSLambdaFunction) Cutover(directory string, cfg *config.Config, stg *settings.Settings) error {
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return err
+	}
+	if st.Retiring == nil {
+		return errors.New("the project is not being renamed")
+	}
+	apiResource := st.Retiring.GetResource(state.AWSRestApiResource)
+	if apiResource == nil || stg.AWS.RestApiID == "" {
+		return nil
+	}
+	if err := SetAccountID(stg.AWS); err != nil {
+		return err
+	}
+
+	fmt.Println("🔀  Cutover: ", fmt.Sprintf("/%s", st.Retiring.Name), "->", cfg.ProjectName)
+	oldPath := *cfg
+	oldPath.Config.AWS.RestApiResourceID = apiResource.ID
+	if err := addFunctionIntegration(&oldPath, stg); err != nil {
+		return err
+	}
+	if cfg.GetPayloadFormat() == config.PayloadFormatBody {
+		if err := addIntegrationResponses(&oldPath, stg); err != nil {
+			return err
+		}
+	}
+	for env := range invocationPermissions {
+		err := cli.Execute("aws", []string{
+			"lambda",
+			"add-permission",
+			"--function-name", cfg.ProjectName,
+			"--statement-id", renamedStatementID(env),
+			"--action", "lambda:InvokeFunction",
+			"--principal", "apigateway.amazonaws.com",
+			"--source-arn", fmt.Sprintf("arn:%s:execute-api:%s:%s:%s/%s/POST/%s",
+				getPartition(stg.AWS.DeploymentRegion),
+				stg.AWS.DeploymentRegion,
+				stg.AWS.AccountID,
+				stg.AWS.RestApiID,
+				invocationPermissions[env],
+				st.Retiring.Name,
+			),
+		}, fmt.Sprintf("Letting the old API path invoke the function: %s", env))
+		if err != nil && !cli.HasErrorCode(err, "ResourceConflictException") {
+			// The permission already exists, if the cutover is repeated
+			return err
+		}
+		st.AddResource(state.AWSLambdaPermission, renamedStatementID(env), "").Group = renameGroup
+	}
+	if err := state.WriteState(directory, st); err != nil {
+		return err
+	}
+	return apigateway.Deploy(stg)
+}
+
+// DeleteRetired deletes the resources of the project's previous name, and the
+// permissions that let its API path invoke the renamed function
+func (AWSLambdaFunction) DeleteRetired(directory string, cfg *config.Config, stg *settings.Settings) error {
+	return deleteRetired(directory, cfg, stg)
+}
+
+func deleteRetired(directory string, cfg *config.Config, stg *settings.Settings) error {
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return err
+	}
+	if st.Retiring == nil {
+		return errors.New("the project has no retiring resources")
+	}
+	for _, permission := range st.GetResources(state.AWSLambdaPermission) {
+		if permission.Group != renameGroup {
+			continue
+		}
+		if err := removeResource(st, permission, cfg, stg); err != nil {
+			return err
+		}
+	}
+
+	// The retiring resources are deleted by their own name; they are not in the
+	// state's resources, which may have the same IDs (e.g. the API permissions)
+	previous := *cfg
+	previous.ProjectName = st.Retiring.Name
+	retiring := &state.State{Resources: st.Retiring.Resources}
+	deleted, kept := retiring.PlanDestroy(destroyOrder, cfg.Config.Protected)
+	for _, resource := range deleted {
+		// The REST API that the retiring resources are in is kept in the state
+		if err := reportDestroyed(st, resource, stg, destroyResource(st, resource, &previous, stg)); err != nil {
+			return err
+		}
+		retiring.RemoveResource(resource.Type, resource.ID)
+		st.Retiring.Resources = retiring.Resources
+		if err := state.WriteState(directory, st); err != nil {
+			return err
+		}
+	}
+	for _, resource := range kept {
+		fmt.Println("⏭   Not deleted: ", resource.Describe(cfg.Config.Protected))
+	}
+	st.Retiring = nil
+	return state.WriteState(directory, st)
+}
+
+func renamedStatementID(env string) string {
+	return fmt.Sprintf("kettle-renamed-apigateway-%s", env)
+}
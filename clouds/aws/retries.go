@@ -0,0 +1,117 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+const (
+	// The function reports the messages of a batch that failed, so
+	// that the rest of the batch is not retried with them
+	batchItemFailures = "ReportBatchItemFailures"
+	// Dead-letter queues keep messages for the longest time that SQS allows (14 days)
+	deadLetterRetention = 14 * 24 * 60 * 60
+	// How often the depth of the dead-letter queue is checked, in seconds
+	deadLetterAlarmPeriod = 300
+)
+
+// setDeadLetterQueue creates the queue's dead-letter queue, which failed messages
+// are moved to after the queue's retries, and an alarm on its depth
+func setDeadLetterQueue(queueURL string, cfg *config.Config, st *state.State) error {
+	deadLetterName := config.DeadLetterQueueName(getQueueName(cfg))
+	fmt.Println("🪦  Dead-letter queue: ", deadLetterName, fmt.Sprintf("(after %d retries)", cfg.Config.Queue.Retries))
+	deadLetterURL, deadLetterArn, err := createQueue(deadLetterName, map[string]string{
+		"MessageRetentionPeriod": fmt.Sprintf("%d", deadLetterRetention),
+	})
+	if err != nil {
+		return err
+	}
+	st.AddResource(state.AWSSQSQueue, deadLetterURL, deadLetterArn).Group = queueWorkerGroup
+
+	// Messages are received once, and then once for each retry
+	redrivePolicy, err := json.Marshal(map[string]string{
+		"deadLetterTargetArn": deadLetterArn,
+		"maxReceiveCount":     fmt.Sprintf("%d", cfg.Config.Queue.Retries+1),
+	})
+	if err != nil {
+		return err
+	}
+	attributes, err := json.Marshal(map[string]string{
+		"RedrivePolicy": string(redrivePolicy),
+	})
+	if err != nil {
+		return err
+	}
+	err = cli.Execute("aws", []string{
+		"sqs",
+		"set-queue-attributes",
+		"--queue-url", queueURL,
+		"--attributes", string(attributes),
+	}, "Setting the queue's redrive policy")
+	if err != nil {
+		return err
+	}
+	return putDeadLetterAlarm(deadLetterName, cfg, st)
+}
+
+// putDeadLetterAlarm creates an alarm that fires when the dead-letter queue has
+// messages, and notifies the alarm email (if set) through an SNS topic
+func putDeadLetterAlarm(deadLetterName string, cfg *config.Config, st *state.State) error {
+	alarmName := fmt.Sprintf("kettle-dlq-%s", cfg.ProjectName)
+	args := []string{
+		"cloudwatch",
+		"put-metric-alarm",
+		"--alarm-name", alarmName,
+		"--namespace", "AWS/SQS",
+		"--metric-name", "ApproximateNumberOfMessagesVisible",
+		"--dimensions", fmt.Sprintf("Name=QueueName,Value=%s", deadLetterName),
+		"--statistic", "Maximum",
+		"--period", fmt.Sprintf("%d", deadLetterAlarmPeriod),
+		"--evaluation-periods", "1",
+		"--threshold", "0",
+		"--comparison-operator", "GreaterThanThreshold",
+		"--treat-missing-data", "notBreaching",
+	}
+	if cfg.Config.Queue.AlarmEmail != "" {
+		topicArn, err := createAlarmTopic(alarmName, cfg.Config.Queue.AlarmEmail)
+		if err != nil {
+			return err
+		}
+		st.AddResource(state.AWSSNSTopic, topicArn, topicArn)
+		args = append(args, "--alarm-actions", topicArn)
+	}
+	if err := cli.Execute("aws", args, "Creating the dead-letter queue alarm"); err != nil {
+		return err
+	}
+	st.AddResource(state.AWSCloudWatchAlarm, alarmName, "")
+	return nil
+}
+
+// reportBatchItemFailures lets the function of an existing event source
+// mapping report the messages of a batch that failed
+func reportBatchItemFailures(mappingID string) error {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"lambda",
+		"get-event-source-mapping",
+		"--uuid", mappingID,
+		"--query", "FunctionResponseTypes",
+		"--output", "text",
+	}, "Looking for the queue trigger's response types")
+	if err != nil {
+		return err
+	}
+	if strings.Contains(string(output), batchItemFailures) {
+		return nil
+	}
+	return cli.Execute("aws", []string{
+		"lambda",
+		"update-event-source-mapping",
+		"--uuid", mappingID,
+		"--function-response-types", batchItemFailures,
+	}, "Reporting the queue trigger's failed messages")
+}
@@ -0,0 +1,231 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+const (
+	sagemakerVariantName              = "AllTraffic"
+	sagemakerDefaultMemory            = 2048
+	sagemakerDefaultConcurrency       = 5
+	sagemakerCanaryPercent            = 10
+	sagemakerCanaryWaitInSeconds      = 300
+	sagemakerTerminationWaitInSeconds = 300
+)
+
+var sagemakerExecutionRole = &executionRole{
+	name:    "operator-sagemaker-role",
+	service: "sagemaker.amazonaws.com",
+	policies: []string{
+		"arn:aws:iam::aws:policy/AmazonSageMakerFullAccess",
+	},
+}
+
+type AWSSageMakerEndpoint struct{}
+
+// Deploy creates a new model and endpoint config for every deployment, so that
+// endpoints are updated with a blue/green swap of the endpoint config
+// https://docs.aws.amazon.com/sagemaker/latest/dg/your-algorithms-inference-code.html
+func (AWSSageMakerEndpoint) Deploy(directory string, cfg *config.Config, stg *settings.Settings) error {
+	if !cfg.IsHTTP1() {
+		return fmt.Errorf("the %s protocol is not supported on sagemaker endpoints", cfg.Config.Protocol)
+	}
+	if err := SetAccountID(stg.AWS); err != nil {
+		return err
+	}
+	if stg.AWS.SageMakerRoleArn == "" {
+		role, err := selectExecutionRole(sagemakerExecutionRole, stg.AWS.DeploymentRegion)
+		if err != nil {
+			return err
+		}
+		stg.AWS.SageMakerRoleArn = role
+	}
+
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return err
+	}
+
+	// Models and endpoint configs cannot be changed, so each deployment has a new version
+	version := fmt.Sprintf("%s-%d", cfg.ProjectName, time.Now().Unix())
+	imageURI, err := pushContainer(cfg, version, stg)
+	if err != nil {
+		return err
+	}
+	st.AddResource(state.AWSECRRepository, cfg.ProjectName, "")
+
+	fmt.Println("🚢  Deploying ", cfg.ProjectName, "as a SageMaker endpoint")
+	if err := createSageMakerModel(version, imageURI, cfg, stg); err != nil {
+		return err
+	}
+	st.AddResource(state.AWSSageMakerModel, version, "")
+
+	if err := createEndpointConfig(version, cfg); err != nil {
+		return err
+	}
+	st.AddResource(state.AWSSageMakerConfig, version, "")
+
+	exists, err := endpointExists(cfg.ProjectName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		err = updateEndpoint(version, cfg)
+	} else {
+		err = cli.Execute("aws", append([]string{
+			"sagemaker",
+			"create-endpoint",
+			"--endpoint-name", cfg.ProjectName,
+			"--endpoint-config-name", version,
+			"--tags",
+		}, getResourceTags(cfg)...), "Creating SageMaker endpoint")
+	}
+	if err != nil {
+		return err
+	}
+	st.AddResource(state.AWSSageMakerEndpoint, cfg.ProjectName, "")
+	if err := state.WriteState(directory, st); err != nil {
+		return err
+	}
+
+	err = cli.Execute("aws", []string{
+		"sagemaker",
+		"wait",
+		"endpoint-in-service",
+		"--endpoint-name", cfg.ProjectName,
+	}, "Waiting for endpoint to be in service")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("🔍  Invocation URL: ", fmt.Sprintf("https://runtime.sagemaker.%s.%s/endpoints/%s/invocations",
+		stg.AWS.DeploymentRegion,
+		getDNSSuffix(stg.AWS.DeploymentRegion),
+		cfg.ProjectName,
+	))
+	return nil
+}
+
+func createSageMakerModel(version, imageURI string, cfg *config.Config, stg *settings.Settings) error {
+	container, err := json.Marshal(map[string]interface{}{
+		"Image":       imageURI,
+		"Environment": cfg.DeployEnvironment(),
+	})
+	if err != nil {
+		return err
+	}
+	return cli.Execute("aws", []string{
+		"sagemaker",
+		"create-model",
+		"--model-name", version,
+		"--primary-container", string(container),
+		"--execution-role-arn", stg.AWS.SageMakerRoleArn,
+	}, "Creating SageMaker model")
+}
+
+func createEndpointConfig(version string, cfg *config.Config) error {
+	variant := map[string]interface{}{
+		"VariantName": sagemakerVariantName,
+		"ModelName":   version,
+	}
+	if isServerlessEndpoint(cfg) {
+		memory := sagemakerDefaultMemory
+		if cfg.Config.Memory != 0 {
+			memory = cfg.Config.Memory
+		}
+		concurrency := sagemakerDefaultConcurrency
+		if cfg.Config.SageMaker != nil && cfg.Config.SageMaker.MaxConcurrency != 0 {
+			concurrency = cfg.Config.SageMaker.MaxConcurrency
+		}
+		variant["ServerlessConfig"] = map[string]int{
+			"MemorySizeInMB": memory,
+			"MaxConcurrency": concurrency,
+		}
+	} else {
+		instanceCount := 1
+		if cfg.Config.SageMaker.InstanceCount != 0 {
+			instanceCount = cfg.Config.SageMaker.InstanceCount
+		}
+		variant["InstanceType"] = cfg.Config.SageMaker.InstanceType
+		variant["InitialInstanceCount"] = instanceCount
+	}
+
+	variants, err := json.Marshal([]interface{}{variant})
+	if err != nil {
+		return err
+	}
+	return cli.Execute("aws", []string{
+		"sagemaker",
+		"create-endpoint-config",
+		"--endpoint-config-name", version,
+		"--production-variants", string(variants),
+	}, "Creating SageMaker endpoint config")
+}
+
+// updateEndpoint swaps the endpoint onto the new endpoint config; instance-backed
+// endpoints shift traffic with a blue/green deployment
+func updateEndpoint(version string, cfg *config.Config) error {
+	args := []string{
+		"sagemaker",
+		"update-endpoint",
+		"--endpoint-name", cfg.ProjectName,
+		"--endpoint-config-name", version,
+	}
+	if !isServerlessEndpoint(cfg) {
+		routing := map[string]interface{}{
+			"Type":                  "ALL_AT_ONCE",
+			"WaitIntervalInSeconds": 0,
+		}
+		switch cfg.Config.SageMaker.TrafficShift {
+		case "", "all_at_once":
+		case "canary":
+			routing = map[string]interface{}{
+				"Type": "CANARY",
+				"CanarySize": map[string]interface{}{
+					"Type":  "CAPACITY_PERCENT",
+					"Value": sagemakerCanaryPercent,
+				},
+				"WaitIntervalInSeconds": sagemakerCanaryWaitInSeconds,
+			}
+		default:
+			return fmt.Errorf("unknown traffic_shift: %s (expected all_at_once or canary)", cfg.Config.SageMaker.TrafficShift)
+		}
+		deploymentConfig, err := json.Marshal(map[string]interface{}{
+			"BlueGreenUpdatePolicy": map[string]interface{}{
+				"TrafficRoutingConfiguration": routing,
+				"TerminationWaitInSeconds":    sagemakerTerminationWaitInSeconds,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		args = append(args, "--deployment-config", string(deploymentConfig))
+	}
+	return cli.Execute("aws", args, "Updating SageMaker endpoint")
+}
+
+func endpointExists(name string) (bool, error) {
+	_, err := cli.ExecuteWithResult("aws", []string{
+		"sagemaker",
+		"describe-endpoint",
+		"--endpoint-name", name,
+	}, "Checking status of SageMaker endpoint")
+	if err != nil {
+		if cli.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func isServerlessEndpoint(cfg *config.Config) bool {
+	return cfg.Config.SageMaker == nil || cfg.Config.SageMaker.InstanceType == ""
+}
@@ -0,0 +1,140 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+// scanTag is the tag that images are pushed with to be scanned, before they are deployed
+const scanTag = "kettle-scan"
+
+// ScanImage pushes the built image to the endpoint's ECR repository, and returns
+// the findings of ECR's scan of it (with basic or enhanced scanning)
+func (AWSSageMakerEndpoint) ScanImage(cfg *config.Config, stg *settings.Settings, artifact *config.Artifact) ([]*config.Vulnerability, error) {
+	repositoryURI, err := getOrCreateRepository(cfg.ProjectName)
+	if err != nil {
+		return nil, err
+	}
+	if err := dockerLogin(repositoryURI, stg); err != nil {
+		return nil, err
+	}
+	imageURI := fmt.Sprintf("%s:%s", repositoryURI, scanTag)
+	err = cli.Execute("docker", []string{
+		"tag",
+		artifact.Location,
+		imageURI,
+	}, "Tagging docker container")
+	if err != nil {
+		return nil, err
+	}
+	err = cli.Execute("docker", []string{
+		"push",
+		imageURI,
+	}, "Pushing docker container to ECR")
+	if err != nil {
+		return nil, err
+	}
+	digest, err := getImageDigest(cfg.ProjectName, scanTag)
+	if err != nil {
+		return nil, err
+	}
+
+	imageID := fmt.Sprintf("imageDigest=%s", digest)
+	err = cli.Execute("aws", []string{
+		"ecr",
+		"start-image-scan",
+		"--repository-name", cfg.ProjectName,
+		"--image-id", imageID,
+	}, "Starting the ECR image scan")
+	// Images that are scanned on push (or continuously) cannot be scanned again
+	if err != nil && !cli.HasErrorCode(err, "LimitExceededException") && !cli.HasErrorCode(err, "ValidationException") {
+		return nil, err
+	}
+	err = cli.Execute("aws", []string{
+		"ecr",
+		"wait",
+		"image-scan-complete",
+		"--repository-name", cfg.ProjectName,
+		"--image-id", imageID,
+	}, "Waiting for the ECR image scan")
+	if err != nil {
+		return nil, err
+	}
+	return getImageScanFindings(cfg.ProjectName, imageID)
+}
+
+func getImageScanFindings(repositoryName, imageID string) ([]*config.Vulnerability, error) {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"ecr",
+		"describe-image-scan-findings",
+		"--repository-name", repositoryName,
+		"--image-id", imageID,
+		"--output", "json",
+	}, "Retrieving the ECR image scan findings")
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		ImageScanFindings struct {
+			// Basic scanning
+			Findings []struct {
+				Name       string `json:"name"`
+				Severity   string `json:"severity"`
+				Attributes []struct {
+					Key   string `json:"key"`
+					Value string `json:"value"`
+				} `json:"attributes"`
+			} `json:"findings"`
+			// Enhanced scanning (with Amazon Inspector)
+			EnhancedFindings []struct {
+				Severity                    string `json:"severity"`
+				PackageVulnerabilityDetails struct {
+					VulnerabilityID    string `json:"vulnerabilityId"`
+					VulnerablePackages []struct {
+						Name           string `json:"name"`
+						Version        string `json:"version"`
+						FixedInVersion string `json:"fixedInVersion"`
+					} `json:"vulnerablePackages"`
+				} `json:"packageVulnerabilityDetails"`
+			} `json:"enhancedFindings"`
+		} `json:"imageScanFindings"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, err
+	}
+
+	vulnerabilities := []*config.Vulnerability{}
+	for _, finding := range result.ImageScanFindings.Findings {
+		vulnerability := &config.Vulnerability{
+			ID:       finding.Name,
+			Severity: strings.ToLower(finding.Severity),
+		}
+		for _, attribute := range finding.Attributes {
+			switch attribute.Key {
+			case "package_name":
+				vulnerability.Package = attribute.Value
+			case "package_version":
+				vulnerability.Version = attribute.Value
+			}
+		}
+		vulnerabilities = append(vulnerabilities, vulnerability)
+	}
+	for _, finding := range result.ImageScanFindings.EnhancedFindings {
+		for _, pkg := range finding.PackageVulnerabilityDetails.VulnerablePackages {
+			vulnerabilities = append(vulnerabilities, &config.Vulnerability{
+				ID:       finding.PackageVulnerabilityDetails.VulnerabilityID,
+				Package:  pkg.Name,
+				Version:  pkg.Version,
+				FixedIn:  pkg.FixedInVersion,
+				Severity: strings.ToLower(finding.Severity),
+			})
+		}
+	}
+	return vulnerabilities, nil
+}
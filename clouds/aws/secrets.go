@@ -0,0 +1,167 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+func (AWSLambdaFunction) ValidateSecrets(cfg *config.Config) error {
+	return cfg.ValidateSecrets()
+}
+
+// SyncSecrets stores the project's secrets in Secrets Manager (as kettle/<project>/<name>),
+// and grants the execution role access to read them; the function's environment variables
+// are the secrets' ARNs, which it reads the values from (e.g. with the Parameters and
+// Secrets Lambda extension). Secrets that are no longer in the config are deleted
+func SyncSecrets(directory string, cfg *config.Config, stg *settings.Settings, secrets map[string]string) (map[string]string, error) {
+	if err := SetAccountID(stg.AWS); err != nil {
+		return nil, err
+	}
+	if err := setExecutionRole(stg); err != nil {
+		return nil, err
+	}
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	environment := map[string]string{}
+	names := []string{}
+	for key := range secrets {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+	for _, key := range names {
+		secretName := fmt.Sprintf("kettle/%s/%s", cfg.ProjectName, key)
+		secretArn, err := putSecret(secretName, secrets[key], cfg)
+		if err != nil {
+			return nil, err
+		}
+		st.AddResource(state.AWSSecret, secretName, secretArn)
+		environment[key] = secretArn
+		fmt.Println("🔐  Secret: ", key, fmt.Sprintf("(%s)", secretName))
+	}
+
+	// Delete the secrets that were removed from the config
+	for _, resource := range st.GetResources(state.AWSSecret) {
+		if _, ok := secrets[strings.TrimPrefix(resource.ID, fmt.Sprintf("kettle/%s/", cfg.ProjectName))]; ok {
+			continue
+		}
+		if err := removeResource(st, resource, cfg, stg); err != nil {
+			return nil, err
+		}
+	}
+
+	secretArns := []string{}
+	for _, resource := range st.GetResources(state.AWSSecret) {
+		secretArns = append(secretArns, resource.Arn)
+	}
+	sort.Strings(secretArns)
+	if len(secretArns) > 0 {
+		err = putRolePolicy(stg, cfg.ProjectName+"-secrets", st, []map[string]interface{}{
+			{
+				"Effect":   "Allow",
+				"Action":   []string{"secretsmanager:GetSecretValue"},
+				"Resource": secretArns,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else if policy := st.GetResource(state.AWSIAMRolePolicy, fmt.Sprintf("%s/%s-secrets", roleNameFromArn(stg.AWS.RoleArn), cfg.ProjectName)); policy != nil {
+		if err := removeResource(st, policy, cfg, stg); err != nil {
+			return nil, err
+		}
+	}
+	if err := state.WriteState(directory, st); err != nil {
+		return nil, err
+	}
+	return environment, nil
+}
+
+// putSecret creates a secret, or stores a new version of its value if it
+// has changed, and returns its ARN
+func putSecret(secretName, value string, cfg *config.Config) (string, error) {
+	// The value is passed in a file, so that it is not a command line argument
+	f, err := ioutil.TempFile("", "kettle-secret-")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(value); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	valueArg := fmt.Sprintf("file://%s", f.Name())
+
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"secretsmanager",
+		"get-secret-value",
+		"--secret-id", secretName,
+		"--query", "[ARN,SecretString]",
+		"--output", "json",
+	}, fmt.Sprintf("Looking for the secret: %s", secretName))
+	if err != nil {
+		if !cli.IsNotFound(err) {
+			return "", err
+		}
+		output, err = cli.ExecuteWithResult("aws", append([]string{
+			"secretsmanager",
+			"create-secret",
+			"--name", secretName,
+			"--secret-string", valueArg,
+			"--query", "ARN",
+			"--output", "text",
+			"--tags",
+		}, getResourceTags(cfg)...), fmt.Sprintf("Creating the secret: %s", secretName))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(output)), nil
+	}
+
+	// The secret's ARN, and its current value
+	var current []string
+	if err := json.Unmarshal(output, &current); err != nil {
+		return "", err
+	}
+	if len(current) != 2 {
+		return "", fmt.Errorf("cannot read the secret: %s", secretName)
+	}
+	if current[1] == value {
+		return current[0], nil
+	}
+	err = cli.Execute("aws", []string{
+		"secretsmanager",
+		"put-secret-value",
+		"--secret-id", secretName,
+		"--secret-string", valueArg,
+	}, fmt.Sprintf("Updating the secret: %s", secretName))
+	if err != nil {
+		return "", err
+	}
+	return current[0], nil
+}
+
+func deleteSecret(resource *state.Resource) error {
+	// Vault is the source of truth, so the secret can be deleted straight away
+	// (rather than after a recovery window, during which its name cannot be reused)
+	return cli.Execute("aws", []string{
+		"secretsmanager",
+		"delete-secret",
+		"--secret-id", resource.ID,
+		"--force-delete-without-recovery",
+	}, "Deleting Secrets Manager secret")
+}
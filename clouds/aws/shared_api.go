@@ -0,0 +1,40 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/operatorai/kettle-cli/clouds/aws/apigateway"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+// GetApiRoutes lists the resources of a shared REST API (by name, or the
+// default shared API), and the functions that are attached to them
+func GetApiRoutes(apiName string, stg *settings.Settings) (string, []*config.ApiRoute, error) {
+	restApiID, err := apigateway.GetRestApiID(apiName, stg.AWS.DeploymentRegion)
+	if err != nil {
+		return "", nil, err
+	}
+	if restApiID == "" {
+		if apiName == "" {
+			return "", nil, fmt.Errorf("the shared REST API does not exist in %s", stg.AWS.DeploymentRegion)
+		}
+		return "", nil, fmt.Errorf("the %s REST API does not exist in %s", apiName, stg.AWS.DeploymentRegion)
+	}
+	resources, err := apigateway.GetResources(restApiID, stg.AWS.DeploymentRegion)
+	if err != nil {
+		return "", nil, err
+	}
+	routes := []*config.ApiRoute{}
+	for _, resource := range resources {
+		if resource.Path == "/" {
+			continue
+		}
+		routes = append(routes, &config.ApiRoute{
+			Path:       resource.Path,
+			ResourceID: resource.ID,
+			Function:   resource.Function,
+		})
+	}
+	return restApiID, routes, nil
+}
@@ -0,0 +1,88 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+// credentialVariables are the environment variables that the aws cli
+// reads credentials from; stages that deploy to their own account set them
+var credentialVariables = []string{
+	"AWS_PROFILE",
+	"AWS_ACCESS_KEY_ID",
+	"AWS_SECRET_ACCESS_KEY",
+	"AWS_SESSION_TOKEN",
+}
+
+// defaultCredentials are the credential variables before any stage's
+// credentials were used, so that they can be restored
+var defaultCredentials map[string]*string
+
+// UseStageAccount points the aws cli at the stage's account, with its profile
+// and/or by assuming its role; a nil stage uses the default credentials. Each
+// stage's settings (e.g. its account ID) are stored separately
+func UseStageAccount(stage *config.Stage, stg *settings.Settings) error {
+	restoreDefaultCredentials()
+	if stage == nil {
+		return nil
+	}
+	if stage.Profile != "" {
+		os.Setenv("AWS_PROFILE", stage.Profile)
+	}
+	if stage.RoleArn == "" {
+		return nil
+	}
+
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"sts",
+		"assume-role",
+		"--role-arn", stage.RoleArn,
+		"--role-session-name", "kettle",
+		"--output", "json",
+	}, fmt.Sprintf("Assuming role: %s", stage.RoleArn))
+	if err != nil {
+		return err
+	}
+	var result struct {
+		Credentials struct {
+			AccessKeyID     string `json:"AccessKeyId"`
+			SecretAccessKey string `json:"SecretAccessKey"`
+			SessionToken    string `json:"SessionToken"`
+		} `json:"Credentials"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return err
+	}
+	// Credentials in the environment take precedence over the profile
+	os.Unsetenv("AWS_PROFILE")
+	os.Setenv("AWS_ACCESS_KEY_ID", result.Credentials.AccessKeyID)
+	os.Setenv("AWS_SECRET_ACCESS_KEY", result.Credentials.SecretAccessKey)
+	os.Setenv("AWS_SESSION_TOKEN", result.Credentials.SessionToken)
+	return nil
+}
+
+func restoreDefaultCredentials() {
+	if defaultCredentials == nil {
+		defaultCredentials = map[string]*string{}
+		for _, variable := range credentialVariables {
+			if value, ok := os.LookupEnv(variable); ok {
+				defaultCredentials[variable] = &value
+			} else {
+				defaultCredentials[variable] = nil
+			}
+		}
+		return
+	}
+	for variable, value := range defaultCredentials {
+		if value == nil {
+			os.Unsetenv(variable)
+		} else {
+			os.Setenv(variable, *value)
+		}
+	}
+}
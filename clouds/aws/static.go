@@ -0,0 +1,236 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+const (
+	// The managed CachingOptimized CloudFront cache policy
+	cachingOptimizedPolicyID = "658327ea-f89d-4fab-a63d-7e88639e58f6"
+	staticOriginID           = "kettle-static"
+)
+
+// DeployStaticSite syncs the project's static assets to a private S3 bucket,
+// which is served by a CloudFront distribution; the distribution's cache
+// is invalidated on every redeploy
+func DeployStaticSite(directory string, cfg *config.Config, stg *settings.Settings) error {
+	if err := SetAccountID(stg.AWS); err != nil {
+		return err
+	}
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return err
+	}
+
+	bucket := cfg.Config.Static.Bucket
+	if bucket == "" {
+		bucket = fmt.Sprintf("%s-static-%s", cfg.ProjectName, stg.AWS.AccountID)
+	}
+	if err := createBucket(bucket, stg); err != nil {
+		return err
+	}
+	st.AddResource(state.AWSS3Bucket, bucket, fmt.Sprintf("arn:%s:s3:::%s", getPartition(stg.AWS.DeploymentRegion), bucket))
+	if err := state.WriteState(directory, st); err != nil {
+		return err
+	}
+
+	fmt.Println("🖼   Uploading static assets from: ", cfg.Config.Static.Directory)
+	err = cli.Execute("aws", []string{
+		"s3",
+		"sync",
+		path.Join(directory, cfg.Config.Static.Directory),
+		fmt.Sprintf("s3://%s", bucket),
+		"--delete",
+	}, "Uploading static assets")
+	if err != nil {
+		return err
+	}
+
+	distributions := st.GetResources(state.AWSCloudFront)
+	if len(distributions) > 0 {
+		err := cli.Execute("aws", []string{
+			"cloudfront",
+			"create-invalidation",
+			"--distribution-id", distributions[0].ID,
+			"--paths", "/*",
+		}, "Invalidating the CDN cache")
+		if err != nil {
+			return err
+		}
+		domainName, err := cli.ExecuteWithResult("aws", []string{
+			"cloudfront",
+			"get-distribution",
+			"--id", distributions[0].ID,
+			"--query", "Distribution.DomainName",
+			"--output", "text",
+		}, "Retrieving CloudFront domain name")
+		if err != nil {
+			return err
+		}
+		fmt.Println("🔍  Static site: ", fmt.Sprintf("https://%s", strings.TrimSpace(string(domainName))))
+		return nil
+	}
+
+	oacID, err := createOriginAccessControl(cfg)
+	if err != nil {
+		return err
+	}
+	st.AddResource(state.AWSCloudFrontOAC, oacID, "")
+	if err := state.WriteState(directory, st); err != nil {
+		return err
+	}
+
+	distributionID, distributionArn, domainName, err := createDistribution(bucket, oacID, cfg, stg)
+	if err != nil {
+		return err
+	}
+	st.AddResource(state.AWSCloudFront, distributionID, distributionArn)
+	if err := state.WriteState(directory, st); err != nil {
+		return err
+	}
+
+	// Only the distribution can read from the bucket
+	if err := setBucketPolicy(bucket, distributionArn); err != nil {
+		return err
+	}
+	fmt.Println("🔍  Static site: ", fmt.Sprintf("https://%s", domainName))
+	return nil
+}
+
+func createBucket(bucket string, stg *settings.Settings) error {
+	_, err := cli.ExecuteWithResult("aws", []string{
+		"s3api",
+		"head-bucket",
+		"--bucket", bucket,
+	}, "Looking for S3 bucket")
+	if err == nil {
+		return nil
+	}
+
+	args := []string{
+		"s3api",
+		"create-bucket",
+		"--bucket", bucket,
+		"--region", stg.AWS.DeploymentRegion,
+	}
+	// Buckets outside of us-east-1 must set their location
+	if stg.AWS.DeploymentRegion != "us-east-1" {
+		args = append(args, "--create-bucket-configuration", fmt.Sprintf("LocationConstraint=%s", stg.AWS.DeploymentRegion))
+	}
+	return cli.Execute("aws", args, fmt.Sprintf("Creating an S3 bucket called: %s", bucket))
+}
+
+func createOriginAccessControl(cfg *config.Config) (string, error) {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"cloudfront",
+		"create-origin-access-control",
+		"--origin-access-control-config", fmt.Sprintf(
+			"Name=%s,SigningProtocol=sigv4,SigningBehavior=always,OriginAccessControlOriginType=s3",
+			cfg.ProjectName,
+		),
+		"--output", "json",
+	}, "Creating CloudFront origin access control")
+	if err != nil {
+		return "", err
+	}
+	var result struct {
+		OriginAccessControl struct {
+			ID string `json:"Id"`
+		} `json:"OriginAccessControl"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return "", err
+	}
+	return result.OriginAccessControl.ID, nil
+}
+
+func createDistribution(bucket, oacID string, cfg *config.Config, stg *settings.Settings) (string, string, string, error) {
+	distributionConfig, err := json.Marshal(map[string]interface{}{
+		"CallerReference":   fmt.Sprintf("%s-%d", cfg.ProjectName, time.Now().Unix()),
+		"Comment":           fmt.Sprintf("kettle: %s", cfg.ProjectName),
+		"Enabled":           true,
+		"DefaultRootObject": "index.html",
+		"Origins": map[string]interface{}{
+			"Quantity": 1,
+			"Items": []interface{}{
+				map[string]interface{}{
+					"Id":                    staticOriginID,
+					"DomainName":            fmt.Sprintf("%s.s3.%s.%s", bucket, stg.AWS.DeploymentRegion, getDNSSuffix(stg.AWS.DeploymentRegion)),
+					"OriginAccessControlId": oacID,
+					"S3OriginConfig": map[string]string{
+						"OriginAccessIdentity": "",
+					},
+				},
+			},
+		},
+		"DefaultCacheBehavior": map[string]interface{}{
+			"TargetOriginId":       staticOriginID,
+			"ViewerProtocolPolicy": "redirect-to-https",
+			"CachePolicyId":        cachingOptimizedPolicyID,
+			"Compress":             true,
+		},
+	})
+	if err != nil {
+		return "", "", "", err
+	}
+
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"cloudfront",
+		"create-distribution",
+		"--distribution-config", string(distributionConfig),
+		"--output", "json",
+	}, "Creating CloudFront distribution")
+	if err != nil {
+		return "", "", "", err
+	}
+	var result struct {
+		Distribution struct {
+			ID         string `json:"Id"`
+			ARN        string `json:"ARN"`
+			DomainName string `json:"DomainName"`
+		} `json:"Distribution"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return "", "", "", err
+	}
+	return result.Distribution.ID, result.Distribution.ARN, result.Distribution.DomainName, nil
+}
+
+func setBucketPolicy(bucket, distributionArn string) error {
+	policy, err := json.Marshal(map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []interface{}{
+			map[string]interface{}{
+				"Effect": "Allow",
+				"Principal": map[string]string{
+					"Service": "cloudfront.amazonaws.com",
+				},
+				"Action":   "s3:GetObject",
+				"Resource": fmt.Sprintf("arn:%s:s3:::%s/*", getPartitionFromArn(distributionArn), bucket),
+				"Condition": map[string]interface{}{
+					"StringEquals": map[string]string{
+						"AWS:SourceArn": distributionArn,
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return cli.Execute("aws", []string{
+		"s3api",
+		"put-bucket-policy",
+		"--bucket", bucket,
+		"--policy", string(policy),
+	}, "Allowing CloudFront to read from the S3 bucket")
+}
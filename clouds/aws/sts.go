@@ -1,9 +1,11 @@
 package aws
 
 import (
-	"encoding/json"
+	"fmt"
+	"strings"
 
 	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/clouds/aws/client"
 	"github.com/operatorai/kettle-cli/settings"
 )
 
@@ -12,22 +14,66 @@ func SetAccountID(stg *settings.AWSSettings) error {
 		return nil
 	}
 
-	output, err := cli.ExecuteWithResult("aws", []string{
-		"sts",
-		"get-caller-identity",
-		"--output", "json",
-	}, "Retrieving aws caller identity")
+	accountID, err := getCallerAccountID(stg.DeploymentRegion)
 	if err != nil {
 		return err
 	}
+	stg.AccountID = accountID
+	return nil
+}
 
-	var result struct {
-		Account string `json:"Account"`
+// GetAccount describes the account that the credentials belong to,
+// which is the account that kettle deploys to
+func GetAccount(stg *settings.AWSSettings) (string, error) {
+	accountID, err := getCallerAccountID(stg.DeploymentRegion)
+	if err != nil {
+		return "", err
 	}
-	if err := json.Unmarshal(output, &result); err != nil {
-		return err
+	if stg.AccountID != "" && stg.AccountID != accountID {
+		fmt.Println("⚠️   The credentials are for a different account than the last deploy:", stg.AccountID)
 	}
+	// Resource ARNs are built from the account ID
+	stg.AccountID = accountID
 
-	stg.AccountID = result.Account
-	return nil
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"iam",
+		"list-account-aliases",
+		"--query", "AccountAliases[0]",
+		"--output", "text",
+	}, "Retrieving aws account alias")
+	if err != nil {
+		// Listing aliases needs a permission that deploy roles may not have
+		return accountID, nil
+	}
+	alias := strings.TrimSpace(string(output))
+	if alias == "" || alias == "None" {
+		return accountID, nil
+	}
+	return fmt.Sprintf("%s (%s)", alias, accountID), nil
+}
+
+func getCallerAccountID(region string) (string, error) {
+	identity, err := getCallerIdentity(region)
+	if err != nil {
+		return "", err
+	}
+	return identity.Account, nil
+}
+
+// getCallerPartition returns the partition of the credentials,
+// e.g. aws-us-gov for an AWS GovCloud (US) account
+func getCallerPartition() (string, error) {
+	identity, err := getCallerIdentity("")
+	if err != nil {
+		return "", err
+	}
+	return getPartitionFromArn(identity.Arn), nil
+}
+
+func getCallerIdentity(region string) (*client.CallerIdentity, error) {
+	api, err := client.Get(region)
+	if err != nil {
+		return nil, err
+	}
+	return api.GetCallerIdentity()
 }
@@ -0,0 +1,43 @@
+package aws
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/clouds/aws/client"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+// getTagArgs returns the project's tags in the Key=Value,... shorthand
+// that lambda commands accept
+func getTagArgs(cfg *config.Config) string {
+	tags := []string{}
+	for key, value := range cfg.Tags() {
+		tags = append(tags, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(tags)
+	return strings.Join(tags, ",")
+}
+
+// getResourceTags returns the project's tags as a list of Key=,Value= pairs,
+// which most other services accept
+func getResourceTags(cfg *config.Config) []string {
+	tags := []string{}
+	for key, value := range cfg.Tags() {
+		tags = append(tags, fmt.Sprintf("Key=%s,Value=%s", key, value))
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// tagLambda adds the project's tags to an existing function, e.g.
+// one that was created before kettle tagged its resources
+func tagLambda(cfg *config.Config, stg *settings.Settings, arn string) error {
+	api, err := client.Get(stg.AWS.DeploymentRegion)
+	if err != nil {
+		return err
+	}
+	return api.TagResource(arn, cfg.Tags())
+}
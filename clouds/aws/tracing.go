@@ -0,0 +1,92 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/clouds/aws/client"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+const (
+	// The account that publishes the AWS Distro for OpenTelemetry (ADOT) layers
+	adotLayerAccount = "901920570463"
+	// The collector layer exports traces for runtimes without an ADOT SDK layer
+	adotCollectorLayer = "aws-otel-collector-%s-ver-0-102-1:1"
+	xrayWritePolicy    = "arn:aws:iam::aws:policy/AWSXRayDaemonWriteAccess"
+)
+
+// adotLayers are the ADOT layers that instrument functions, by runtime, and
+// the wrapper script that starts the function with the instrumentation
+var adotLayers = map[string]struct {
+	layer   string
+	wrapper string
+}{
+	"python": {"aws-otel-python-%s-ver-1-25-0:1", "/opt/otel-instrument"},
+	"nodejs": {"aws-otel-nodejs-%s-ver-1-18-1:4", "/opt/otel-instrument"},
+	"java":   {"aws-otel-java-agent-%s-ver-1-32-0:3", "/opt/otel-handler"},
+}
+
+// setTracingConfiguration attaches the OpenTelemetry layer; functions that
+// do not export to a collector send their traces to X-Ray
+func setTracingConfiguration(configuration *client.FunctionConfiguration, cfg *config.Config, stg *settings.Settings) {
+	if cfg.Config.Tracing == nil {
+		return
+	}
+	configuration.Layers = []string{getTracingLayer(cfg, stg)}
+	if cfg.Config.Tracing.Endpoint == "" {
+		configuration.TracingMode = "Active"
+	}
+}
+
+// getTracingLayer returns the ARN of the tracing layer for the
+// function's runtime and architecture, unless the config sets one
+func getTracingLayer(cfg *config.Config, stg *settings.Settings) string {
+	if cfg.Config.Tracing.Layer != "" {
+		return cfg.Config.Tracing.Layer
+	}
+	architecture := "amd64"
+	if cfg.GetArchitecture() == config.ArchitectureARM64 {
+		architecture = "arm64"
+	}
+	layer := adotCollectorLayer
+	for prefix, adot := range adotLayers {
+		if strings.HasPrefix(cfg.Config.Runtime, prefix) {
+			layer = adot.layer
+		}
+	}
+	return fmt.Sprintf("arn:%s:lambda:%s:%s:layer:%s",
+		getPartition(stg.AWS.DeploymentRegion),
+		stg.AWS.DeploymentRegion,
+		adotLayerAccount,
+		fmt.Sprintf(layer, architecture),
+	)
+}
+
+// getTracingWrapper returns the script that instruments the function,
+// if the tracing layer for its runtime has one
+func getTracingWrapper(cfg *config.Config) string {
+	if cfg.Config.Tracing == nil || cfg.Config.Tracing.Layer != "" {
+		return ""
+	}
+	for prefix, adot := range adotLayers {
+		if strings.HasPrefix(cfg.Config.Runtime, prefix) {
+			return adot.wrapper
+		}
+	}
+	return ""
+}
+
+// setTracingPermissions allows the execution role to send traces to
+// X-Ray, if the function does not export them to a collector
+func setTracingPermissions(cfg *config.Config, stg *settings.Settings) error {
+	if cfg.Config.Tracing == nil || cfg.Config.Tracing.Endpoint != "" {
+		return nil
+	}
+	api, err := client.Get(stg.AWS.DeploymentRegion)
+	if err != nil {
+		return err
+	}
+	return api.AttachRolePolicy(roleNameFromArn(stg.AWS.RoleArn), inPartition(xrayWritePolicy, stg.AWS.DeploymentRegion))
+}
@@ -0,0 +1,307 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+const (
+	// Schedules and bucket notifications are tracked in this group, so
+	// that the ones that are removed from the config can be deleted
+	triggerGroup = "trigger"
+	// Each schedule has a single target, which is the function
+	scheduleTargetID = "kettle-trigger"
+)
+
+// SetTriggers wires the function's queue and triggers to the deployed function,
+// without redeploying its code
+func (AWSLambdaFunction) SetTriggers(directory string, cfg *config.Config, stg *settings.Settings) error {
+	exists, err := lambdaFunctionExists(cfg.ProjectName, stg.AWS.DeploymentRegion)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%s has not been deployed yet (run: kettle deploy)", cfg.ProjectName)
+	}
+	if err := SetAccountID(stg.AWS); err != nil {
+		return err
+	}
+	if err := reuseRecordedResources(directory, cfg, stg); err != nil {
+		return err
+	}
+	if err := setQueueTrigger(directory, cfg, stg); err != nil {
+		return err
+	}
+	return setTriggers(directory, cfg, stg)
+}
+
+// setTriggers creates the project's schedules and bucket notifications,
+// and deletes the ones that have been removed from its config
+func setTriggers(directory string, cfg *config.Config, stg *settings.Settings) error {
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return err
+	}
+
+	declared := map[string]bool{}
+	for _, trigger := range cfg.Config.Triggers {
+		if err := config.ValidateTrigger(trigger); err != nil {
+			return err
+		}
+		var resourceType, id, arn string
+		switch trigger.Type {
+		case config.TriggerSchedule:
+			fmt.Println("⏰  Trigger: ", trigger.Schedule)
+			resourceType, id = state.AWSEventsRule, scheduleRuleName(trigger, cfg)
+			arn, err = setScheduleTrigger(id, trigger, cfg, stg)
+		case config.TriggerS3:
+			fmt.Println("🪣  Trigger: ", fmt.Sprintf("s3://%s/%s", trigger.Bucket, trigger.Prefix))
+			notificationID := bucketNotificationID(trigger, cfg)
+			resourceType, id = state.AWSS3Notification, fmt.Sprintf("%s/%s", trigger.Bucket, notificationID)
+			err = setBucketTrigger(notificationID, trigger, cfg, stg)
+		}
+		if err != nil {
+			return err
+		}
+		st.AddResource(resourceType, id, arn).Group = triggerGroup
+		declared[id] = true
+	}
+
+	for _, resource := range st.Resources {
+		if resource.Group != triggerGroup || declared[resource.ID] {
+			continue
+		}
+		if err := removeResource(st, resource, cfg, stg); err != nil {
+			return err
+		}
+	}
+	return state.WriteState(directory, st)
+}
+
+// setScheduleTrigger creates an EventBridge rule that invokes the function
+// on the trigger's schedule, and returns the rule's ARN
+func setScheduleTrigger(ruleName string, trigger *config.Trigger, cfg *config.Config, stg *settings.Settings) (string, error) {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"events",
+		"put-rule",
+		"--name", ruleName,
+		"--schedule-expression", trigger.Schedule,
+		"--output", "json",
+	}, "Creating the schedule")
+	if err != nil {
+		return "", err
+	}
+	var result struct {
+		RuleArn string `json:"RuleArn"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return "", err
+	}
+
+	// Allow the rule to invoke the function; the rule's name is the statement's ID
+	if err := addTriggerPermission(ruleName, "events.amazonaws.com", result.RuleArn, cfg, stg); err != nil {
+		return "", err
+	}
+	targets, err := json.Marshal([]map[string]string{
+		{
+			"Id":  scheduleTargetID,
+			"Arn": functionArn(cfg, stg),
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	err = cli.Execute("aws", []string{
+		"events",
+		"put-targets",
+		"--rule", ruleName,
+		"--targets", string(targets),
+	}, "Adding the function to the schedule")
+	if err != nil {
+		return "", err
+	}
+	return result.RuleArn, nil
+}
+
+func deleteScheduleTrigger(ruleName string, cfg *config.Config) error {
+	err := cli.Execute("aws", []string{
+		"events",
+		"remove-targets",
+		"--rule", ruleName,
+		"--ids", scheduleTargetID,
+	}, "Removing the function from the schedule")
+	if err != nil && !cli.IsNotFound(err) {
+		return err
+	}
+	err = cli.Execute("aws", []string{
+		"events",
+		"delete-rule",
+		"--name", ruleName,
+	}, "Deleting the schedule")
+	if err != nil && !cli.IsNotFound(err) {
+		return err
+	}
+	return removeTriggerPermission(ruleName, cfg)
+}
+
+// bucketNotificationConfiguration is the part of a bucket's notification
+// configuration that kettle changes; other notifications are kept as they are
+type bucketNotificationConfiguration struct {
+	LambdaFunctionConfigurations []map[string]interface{} `json:"LambdaFunctionConfigurations,omitempty"`
+	QueueConfigurations          []interface{}            `json:"QueueConfigurations,omitempty"`
+	TopicConfigurations          []interface{}            `json:"TopicConfigurations,omitempty"`
+	EventBridgeConfiguration     interface{}              `json:"EventBridgeConfiguration,omitempty"`
+}
+
+// setBucketTrigger invokes the function when objects are created in the
+// trigger's bucket (with its prefix and suffix, if they are set)
+func setBucketTrigger(notificationID string, trigger *config.Trigger, cfg *config.Config, stg *settings.Settings) error {
+	bucketArn := fmt.Sprintf("arn:%s:s3:::%s", getPartition(stg.AWS.DeploymentRegion), trigger.Bucket)
+	if err := addTriggerPermission(notificationID, "s3.amazonaws.com", bucketArn, cfg, stg); err != nil {
+		return err
+	}
+
+	notifications, err := getBucketNotifications(trigger.Bucket)
+	if err != nil {
+		return err
+	}
+	notification := map[string]interface{}{
+		"Id":                notificationID,
+		"LambdaFunctionArn": functionArn(cfg, stg),
+		"Events":            []string{"s3:ObjectCreated:*"},
+	}
+	rules := []map[string]string{}
+	if trigger.Prefix != "" {
+		rules = append(rules, map[string]string{"Name": "prefix", "Value": trigger.Prefix})
+	}
+	if trigger.Suffix != "" {
+		rules = append(rules, map[string]string{"Name": "suffix", "Value": trigger.Suffix})
+	}
+	if len(rules) != 0 {
+		notification["Filter"] = map[string]interface{}{
+			"Key": map[string]interface{}{"FilterRules": rules},
+		}
+	}
+	removeBucketNotification(notifications, notificationID)
+	notifications.LambdaFunctionConfigurations = append(notifications.LambdaFunctionConfigurations, notification)
+	return putBucketNotifications(trigger.Bucket, notifications, "Adding the function to the bucket's notifications")
+}
+
+// deleteBucketTrigger removes the notification, whose ID is tracked as bucket/id
+func deleteBucketTrigger(resourceID string, cfg *config.Config) error {
+	parts := strings.SplitN(resourceID, "/", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid bucket notification: %s", resourceID)
+	}
+	bucket, notificationID := parts[0], parts[1]
+	notifications, err := getBucketNotifications(bucket)
+	if err != nil {
+		if cli.IsNotFound(err) {
+			return removeTriggerPermission(notificationID, cfg)
+		}
+		return err
+	}
+	if removeBucketNotification(notifications, notificationID) {
+		err := putBucketNotifications(bucket, notifications, "Removing the function from the bucket's notifications")
+		if err != nil {
+			return err
+		}
+	}
+	return removeTriggerPermission(notificationID, cfg)
+}
+
+func getBucketNotifications(bucket string) (*bucketNotificationConfiguration, error) {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"s3api",
+		"get-bucket-notification-configuration",
+		"--bucket", bucket,
+		"--output", "json",
+	}, "Retrieving the bucket's notifications")
+	if err != nil {
+		return nil, err
+	}
+	notifications := &bucketNotificationConfiguration{}
+	if len(strings.TrimSpace(string(output))) == 0 {
+		return notifications, nil
+	}
+	if err := json.Unmarshal(output, notifications); err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+func putBucketNotifications(bucket string, notifications *bucketNotificationConfiguration, statusMessage string) error {
+	data, err := json.Marshal(notifications)
+	if err != nil {
+		return err
+	}
+	return cli.Execute("aws", []string{
+		"s3api",
+		"put-bucket-notification-configuration",
+		"--bucket", bucket,
+		"--notification-configuration", string(data),
+	}, statusMessage)
+}
+
+// removeBucketNotification removes the notification with the given
+// ID (if there is one), and returns whether it was removed
+func removeBucketNotification(notifications *bucketNotificationConfiguration, notificationID string) bool {
+	configurations := []map[string]interface{}{}
+	for _, configuration := range notifications.LambdaFunctionConfigurations {
+		if configuration["Id"] != notificationID {
+			configurations = append(configurations, configuration)
+		}
+	}
+	removed := len(configurations) != len(notifications.LambdaFunctionConfigurations)
+	notifications.LambdaFunctionConfigurations = configurations
+	return removed
+}
+
+// addTriggerPermission allows a service to invoke the function, unless
+// the function's policy already has a statement with the ID
+func addTriggerPermission(statementID, principal, sourceArn string, cfg *config.Config, stg *settings.Settings) error {
+	statements, err := getPolicyStatementIDs(cfg.ProjectName)
+	if err != nil {
+		return err
+	}
+	if statements[statementID] {
+		return nil
+	}
+	return cli.Execute("aws", []string{
+		"lambda",
+		"add-permission",
+		"--function-name", cfg.ProjectName,
+		"--statement-id", statementID,
+		"--action", "lambda:InvokeFunction",
+		"--principal", principal,
+		"--source-arn", sourceArn,
+		"--source-account", stg.AWS.AccountID,
+	}, "Setting lambda permissions for the trigger")
+}
+
+func removeTriggerPermission(statementID string, cfg *config.Config) error {
+	err := cli.Execute("aws", []string{
+		"lambda",
+		"remove-permission",
+		"--function-name", cfg.ProjectName,
+		"--statement-id", statementID,
+	}, "Removing lambda permissions for the trigger")
+	if cli.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func scheduleRuleName(trigger *config.Trigger, cfg *config.Config) string {
+	return fmt.Sprintf("kettle-%s-%s", cfg.ProjectName, trigger.GetID())
+}
+
+func bucketNotificationID(trigger *config.Trigger, cfg *config.Config) string {
+	return fmt.Sprintf("kettle-%s-%s", cfg.ProjectName, trigger.GetID())
+}
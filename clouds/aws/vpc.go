@@ -0,0 +1,167 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/clouds/aws/client"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+const (
+	vpcExecutionPolicy = "arn:aws:iam::aws:policy/service-role/AWSLambdaVPCAccessExecutionRole"
+)
+
+// setVpcConfig places the function in the default VPC's subnets, with a security
+// group that allows traffic between the function and the project's add-ons
+func setVpcConfig(cfg *config.Config, stg *settings.Settings, st *state.State, port int) error {
+	vpcID, subnetIDs, err := getDefaultVpc()
+	if err != nil {
+		return err
+	}
+	groupName := fmt.Sprintf("%s-add-ons", cfg.ProjectName)
+	groupID, err := getSecurityGroup(groupName, vpcID)
+	if err != nil {
+		return err
+	}
+	if groupID == "" {
+		groupID, err = createSecurityGroup(groupName, vpcID, port)
+		if err != nil {
+			return err
+		}
+	}
+	st.AddResource(state.AWSSecurityGroup, groupID, "")
+
+	// The execution role needs permission to create network interfaces in the VPC
+	err = cli.Execute("aws", []string{
+		"iam",
+		"attach-role-policy",
+		"--role-name", roleNameFromArn(stg.AWS.RoleArn),
+		"--policy-arn", inPartition(vpcExecutionPolicy, stg.AWS.DeploymentRegion),
+	}, "Allowing the execution role to access the VPC")
+	if err != nil {
+		return err
+	}
+
+	cfg.Config.AWS.SubnetIDs = subnetIDs
+	cfg.Config.AWS.SecurityGroupIDs = []string{groupID}
+	return nil
+}
+
+// setVpcConfiguration sets the subnets and security groups of
+// functions that need to reach add-ons inside a VPC
+func setVpcConfiguration(configuration *client.FunctionConfiguration, cfg *config.Config) {
+	configuration.SubnetIDs = cfg.Config.AWS.SubnetIDs
+	configuration.SecurityGroupIDs = cfg.Config.AWS.SecurityGroupIDs
+}
+
+func getDefaultVpc() (string, []string, error) {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"ec2",
+		"describe-vpcs",
+		"--filters", "Name=isDefault,Values=true",
+		"--output", "json",
+	}, "Looking for the default VPC")
+	if err != nil {
+		return "", nil, err
+	}
+	var vpcs struct {
+		Vpcs []struct {
+			VpcID string `json:"VpcId"`
+		} `json:"Vpcs"`
+	}
+	if err := json.Unmarshal(output, &vpcs); err != nil {
+		return "", nil, err
+	}
+	if len(vpcs.Vpcs) == 0 {
+		return "", nil, fmt.Errorf("no default VPC in region")
+	}
+	vpcID := vpcs.Vpcs[0].VpcID
+
+	output, err = cli.ExecuteWithResult("aws", []string{
+		"ec2",
+		"describe-subnets",
+		"--filters", fmt.Sprintf("Name=vpc-id,Values=%s", vpcID),
+		"--output", "json",
+	}, "Looking for subnets in the default VPC")
+	if err != nil {
+		return "", nil, err
+	}
+	var subnets struct {
+		Subnets []struct {
+			SubnetID string `json:"SubnetId"`
+		} `json:"Subnets"`
+	}
+	if err := json.Unmarshal(output, &subnets); err != nil {
+		return "", nil, err
+	}
+	subnetIDs := []string{}
+	for _, subnet := range subnets.Subnets {
+		subnetIDs = append(subnetIDs, subnet.SubnetID)
+	}
+	return vpcID, subnetIDs, nil
+}
+
+func getSecurityGroup(groupName, vpcID string) (string, error) {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"ec2",
+		"describe-security-groups",
+		"--filters",
+		fmt.Sprintf("Name=group-name,Values=%s", groupName),
+		fmt.Sprintf("Name=vpc-id,Values=%s", vpcID),
+		"--output", "json",
+	}, "Looking for security group")
+	if err != nil {
+		return "", err
+	}
+	var groups struct {
+		SecurityGroups []struct {
+			GroupID string `json:"GroupId"`
+		} `json:"SecurityGroups"`
+	}
+	if err := json.Unmarshal(output, &groups); err != nil {
+		return "", err
+	}
+	if len(groups.SecurityGroups) == 0 {
+		return "", nil
+	}
+	return groups.SecurityGroups[0].GroupID, nil
+}
+
+// createSecurityGroup creates a security group which allows traffic on
+// the given port from anything else in the same group
+func createSecurityGroup(groupName, vpcID string, port int) (string, error) {
+	output, err := cli.ExecuteWithResult("aws", []string{
+		"ec2",
+		"create-security-group",
+		"--group-name", groupName,
+		"--description", "kettle: traffic between a function and its add-ons",
+		"--vpc-id", vpcID,
+		"--output", "json",
+	}, fmt.Sprintf("Creating a security group called: %s", groupName))
+	if err != nil {
+		return "", err
+	}
+	var result struct {
+		GroupID string `json:"GroupId"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return "", err
+	}
+
+	err = cli.Execute("aws", []string{
+		"ec2",
+		"authorize-security-group-ingress",
+		"--group-id", result.GroupID,
+		"--protocol", "tcp",
+		"--port", fmt.Sprintf("%d", port),
+		"--source-group", result.GroupID,
+	}, "Allowing the function to reach the add-on")
+	if err != nil {
+		return "", err
+	}
+	return result.GroupID, nil
+}
@@ -3,8 +3,11 @@ package aws
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
 	"path"
+	"path/filepath"
 	"strings"
 
 	"github.com/operatorai/kettle-cli/cli"
@@ -14,12 +17,27 @@ import (
 const (
 	deploymentArchiveName = "deployment.zip"
 	goBuildFileName       = "main"
+	// .NET functions are published to this directory, then archived
+	dotnetPublishDirectory = "publish"
+	// Rust functions are archived as an executable called bootstrap
+	rustBuildFileName = "bootstrap"
 )
 
+// createDeploymentArchive packages the project with its runtime's packaging
+// pipeline, and returns the path to the deployment archive
 func createDeploymentArchive(cfg *config.Config) (string, error) {
-	// Remove any existing deployment package
-	if err := removeDeploymentArchive(cfg); err != nil {
-		return "", err
+	// Pre-built archives (e.g. that are being promoted) are deployed as they are
+	if cfg.Artifact != nil {
+		if cfg.Artifact.Digest == "" {
+			// Archives from the artifact store are identified by their version
+			digest, err := getArchiveDigest(cfg.Artifact.Location)
+			if err != nil {
+				return "", err
+			}
+			cfg.Artifact.Digest = digest
+		}
+		fmt.Println("📦  Artifact: ", cfg.Artifact.Digest)
+		return cfg.Artifact.Location, nil
 	}
 
 	// Create a path to the deployment archive
@@ -28,18 +46,17 @@ func createDeploymentArchive(cfg *config.Config) (string, error) {
 		return "", err
 	}
 	deploymentFile := path.Join(rootDir, deploymentArchiveName)
+	if err := runPackagingPipeline(deploymentFile, cfg); err != nil {
+		return "", err
+	}
 
-	switch {
-	case strings.HasPrefix(cfg.Config.Runtime, "python"):
-		// https://docs.aws.amazon.com/lambda/latest/dg/python-package.html
-		if err := addPythonLambdaToArchive(deploymentFile, cfg); err != nil {
-			return "", err
-		}
-	case strings.HasPrefix(cfg.Config.Runtime, "go"):
-		// https://docs.aws.amazon.com/lambda/latest/dg/golang-package.html
-		if err := addGoLambdaToArchive(deploymentFile, cfg); err != nil {
-			return "", err
-		}
+	digest, err := getArchiveDigest(deploymentFile)
+	if err != nil {
+		return "", err
+	}
+	cfg.Artifact = &config.Artifact{
+		Location: deploymentFile,
+		Digest:   digest,
 	}
 	return deploymentFile, nil
 }
@@ -53,6 +70,16 @@ func removeDeploymentArchive(cfg *config.Config) error {
 			return err
 		}
 	}
+	if cfg.Config.Runtime == "rust" {
+		if err := removeFile(rustBuildFileName); err != nil {
+			return err
+		}
+	}
+	if strings.HasPrefix(cfg.Config.Runtime, "dotnet") {
+		if err := os.RemoveAll(dotnetPublishDirectory); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -66,7 +93,23 @@ func removeFile(fileName string) error {
 	return os.Remove(fileName)
 }
 
-func addPythonLambdaToArchive(deploymentFile string, cfg *config.Config) error {
+// resolvePythonDependencies finds the site-packages of the project's python
+// environment, which are added to the deployment archive
+func resolvePythonDependencies(pkg *deploymentPackage) error {
+	var err error
+	switch pkg.cfg.Config.PythonManager {
+	case "pyenv":
+		pkg.sitePackages, err = getPyenvSitePackagesDirectory(pkg.cfg.Config.Runtime)
+	case "conda":
+		pkg.sitePackages, err = getCondaSitePackagesDirectory(pkg.cfg.Config.Runtime)
+	default:
+		err = fmt.Errorf("unknown python_manager: %s", pkg.cfg.Config.PythonManager)
+	}
+	return err
+}
+
+// https://docs.aws.amazon.com/lambda/latest/dg/python-package.html
+func archivePythonLambda(pkg *deploymentPackage) error {
 	// Add the contents of the lambda function directory
 	err := cli.Execute("zip", []string{
 		"-g",
@@ -79,23 +122,7 @@ func addPythonLambdaToArchive(deploymentFile string, cfg *config.Config) error {
 	}
 
 	// Python builds need to add the site-packages contents
-	var sitePackages string
-	switch cfg.Config.PythonManager {
-	case "pyenv":
-		sitePackages, err = getPyenvSitePackagesDirectory(cfg.Config.Runtime)
-		if err != nil {
-			return err
-		}
-	case "conda":
-		sitePackages, err = getCondaSitePackagesDirectory(cfg.Config.Runtime)
-		if err != nil {
-			return err
-		}
-	default:
-		return fmt.Errorf("unknown python_manager: %s", cfg.Config.PythonManager)
-	}
-
-	if _, err := os.Stat(sitePackages); !os.IsNotExist(err) {
+	if _, err := os.Stat(pkg.sitePackages); pkg.sitePackages != "" && !os.IsNotExist(err) {
 		// Change to the directory where the site-packages are stored
 		// So that we can add them to the zip file as a directory
 		rootDir, err := os.Getwd()
@@ -103,10 +130,10 @@ func addPythonLambdaToArchive(deploymentFile string, cfg *config.Config) error {
 			return err
 		}
 
-		os.Chdir(sitePackages)
+		os.Chdir(pkg.sitePackages)
 		err = cli.Execute("zip", []string{
 			"-r",
-			deploymentFile,
+			pkg.archive,
 			".",
 		}, "Adding site-packages to the deployment archive")
 		if err != nil {
@@ -168,31 +195,178 @@ func getCondaSitePackagesDirectory(pythonVersion string) (string, error) {
 	), nil
 }
 
-func addGoLambdaToArchive(deploymentFile string, cfg *config.Config) error {
+func resolveGoDependencies(pkg *deploymentPackage) error {
 	// go get github.com/aws/aws-lambda-go/lambda
-	err := cli.Execute("go", []string{
+	return cli.Execute("go", []string{
 		"get",
 		"./...",
 	}, "Running go get ./...")
-	if err != nil {
-		return err
-	}
+}
 
+// https://docs.aws.amazon.com/lambda/latest/dg/golang-package.html
+func buildGoLambda(pkg *deploymentPackage) error {
 	// Build the function for linux
-	err = cli.Execute("env", []string{
+	return cli.Execute("env", []string{
 		"GOOS=linux",
 		"CGO_ENABLED=0",
 		"go",
 		"build",
 		"-o", goBuildFileName,
 	}, "Building Go binary for GOOS=linux")
+}
+
+func archiveGoLambda(pkg *deploymentPackage) error {
+	// zip function.zip main
+	return cli.Execute("zip", []string{
+		pkg.archive,
+		goBuildFileName,
+	}, "Adding Go binary to deployment archive")
+}
+
+// buildJavaLambda builds a shadow (fat) jar, with Gradle or Maven
+// https://docs.aws.amazon.com/lambda/latest/dg/java-package.html
+func buildJavaLambda(pkg *deploymentPackage) error {
+	switch {
+	case fileExists("build.gradle") || fileExists("build.gradle.kts"):
+		gradle := "gradle"
+		if fileExists("gradlew") {
+			gradle = "./gradlew"
+		}
+		return cli.Execute(gradle, []string{
+			"shadowJar",
+		}, "Building shadow jar with Gradle")
+	case fileExists("pom.xml"):
+		// The shade plugin builds the fat jar in the package phase
+		return cli.Execute("mvn", []string{
+			"--batch-mode",
+			"package",
+			"-DskipTests",
+		}, "Building shaded jar with Maven")
+	}
+	return errors.New("java projects must be built with Gradle (build.gradle) or Maven (pom.xml)")
+}
+
+// archiveJavaLambda uses the jar as the deployment archive (jars are zip files)
+func archiveJavaLambda(pkg *deploymentPackage) error {
+	pattern := "build/libs/*-all.jar"
+	if fileExists("pom.xml") {
+		pattern = "target/*.jar"
+	}
+	jars, err := filepath.Glob(pattern)
 	if err != nil {
 		return err
 	}
+	for _, jar := range jars {
+		// Maven keeps the unshaded jar as original-<name>.jar
+		if strings.HasPrefix(filepath.Base(jar), "original-") {
+			continue
+		}
+		fmt.Println("☕  Jar: ", jar)
+		return copyFile(jar, pkg.archive)
+	}
+	return fmt.Errorf("could not find the shadow jar (%s)", pattern)
+}
 
-	// zip function.zip main
+// buildDotnetLambda publishes the function for the Lambda (linux) runtime
+// https://docs.aws.amazon.com/lambda/latest/dg/csharp-package.html
+func buildDotnetLambda(pkg *deploymentPackage) error {
+	runtime := "linux-x64"
+	if pkg.cfg.GetArchitecture() == config.ArchitectureARM64 {
+		runtime = "linux-arm64"
+	}
+	return cli.Execute("dotnet", []string{
+		"publish",
+		"--configuration", "Release",
+		"--runtime", runtime,
+		"--self-contained", "false",
+		"--output", dotnetPublishDirectory,
+	}, "Publishing .NET function")
+}
+
+func archiveDotnetLambda(pkg *deploymentPackage) error {
+	rootDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	// Add the published files to the root of the archive
+	os.Chdir(dotnetPublishDirectory)
+	defer os.Chdir(rootDir)
 	return cli.Execute("zip", []string{
-		deploymentFile,
-		"main",
-	}, "Adding Go binary to deployment archive")
+		"-r",
+		pkg.archive,
+		".",
+	}, "Adding published files to the deployment archive")
+}
+
+// buildRustLambda cross-compiles the function for the function's architecture,
+// with cargo-lambda if it is installed, or with cross
+// https://docs.aws.amazon.com/lambda/latest/dg/rust-package.html
+func buildRustLambda(pkg *deploymentPackage) error {
+	if _, err := exec.LookPath("cargo-lambda"); err == nil {
+		args := []string{
+			"lambda",
+			"build",
+			"--release",
+		}
+		if pkg.cfg.GetArchitecture() == config.ArchitectureARM64 {
+			args = append(args, "--arm64")
+		}
+		return cli.Execute("cargo", args, "Building Rust function with cargo-lambda")
+	}
+	return cli.Execute("cross", []string{
+		"build",
+		"--release",
+		"--target", getRustTarget(pkg.cfg),
+	}, fmt.Sprintf("Building Rust function for %s with cross", getRustTarget(pkg.cfg)))
+}
+
+// archiveRustLambda adds the function's executable to the archive as bootstrap
+func archiveRustLambda(pkg *deploymentPackage) error {
+	// Rust functions are named after their binary (the entry function)
+	binary := pkg.cfg.Config.EntryFunction
+	if binary == "" {
+		binary = pkg.cfg.GetBaseProjectName()
+	}
+	executable := path.Join("target", "lambda", binary, rustBuildFileName)
+	if !fileExists(executable) {
+		executable = path.Join("target", getRustTarget(pkg.cfg), "release", binary)
+	}
+	if err := copyFile(executable, rustBuildFileName); err != nil {
+		return err
+	}
+	if err := os.Chmod(rustBuildFileName, 0755); err != nil {
+		return err
+	}
+	return cli.Execute("zip", []string{
+		pkg.archive,
+		rustBuildFileName,
+	}, "Adding Rust executable to deployment archive")
+}
+
+// getRustTarget returns the target triple of the function's architecture
+func getRustTarget(cfg *config.Config) string {
+	if cfg.GetArchitecture() == config.ArchitectureARM64 {
+		return "aarch64-unknown-linux-gnu"
+	}
+	return "x86_64-unknown-linux-gnu"
+}
+
+func fileExists(fileName string) bool {
+	_, err := os.Stat(fileName)
+	return err == nil
+}
+
+func copyFile(source, destination string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(destination)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, in)
+	return err
 }
@@ -0,0 +1,231 @@
+package clouds
+
+// CloudProviders are the clouds that kettle deploys to
+var CloudProviders = []string{"aws", "gcloud"}
+
+// Feature is a kettle feature that only some clouds, or only some of their
+// services, support; whether they do is read from the interfaces that they
+// implement, so that the matrix of providers stays in step with the code
+
+type Feature struct {
+	Name        string
+	Description string
+	supports    func(cloud Cloud, service Service) bool
+}
+
+// Features are the features that differ between clouds and services, in the
+// order that kettle providers lists them
+var Features = []*Feature{
+	{
+		Name:        "endpoint",
+		Description: "Serve requests from a public HTTP endpoint",
+		supports: func(cloud Cloud, service Service) bool {
+			_, ok := service.(EndpointProvider)
+			return ok
+		},
+	},
+	{
+		Name:        "triggers",
+		Description: "Run on schedules, queues, and bucket events (kettle add trigger)",
+		supports: func(cloud Cloud, service Service) bool {
+			_, ok := service.(TriggerSetter)
+			return ok
+		},
+	},
+	{
+		Name:        "custom-domains",
+		Description: "Serve the endpoint from a custom domain, optionally with mTLS",
+		supports: func(cloud Cloud, service Service) bool {
+			_, ok := service.(CustomDomainCapable)
+			return ok
+		},
+	},
+	{
+		Name:        "canaries",
+		Description: "Run the smoke test on a schedule, and alarm when it fails",
+		supports: func(cloud Cloud, service Service) bool {
+			_, isHost := cloud.(CanaryHost)
+			_, hasEndpoint := service.(EndpointProvider)
+			return isHost && hasEndpoint
+		},
+	},
+	{
+		Name:        "gpu",
+		Description: "Deploy to GPU-backed infrastructure",
+		supports: func(cloud Cloud, service Service) bool {
+			_, ok := service.(GPUCapable)
+			return ok
+		},
+	},
+	{
+		Name:        "secrets",
+		Description: "Sync secrets from Vault into the cloud's secret store",
+		supports: func(cloud Cloud, service Service) bool {
+			_, isSyncer := cloud.(SecretSyncer)
+			_, isConsumer := service.(SecretsConsumer)
+			return isSyncer && isConsumer
+		},
+	},
+	{
+		Name:        "jobs",
+		Description: "Run batch jobs on demand (kettle run-job)",
+		supports: func(cloud Cloud, service Service) bool {
+			_, ok := service.(JobRunner)
+			return ok
+		},
+	},
+	{
+		Name:        "artifacts",
+		Description: "Build artifacts without deploying them, and promote them between stages",
+		supports: func(cloud Cloud, service Service) bool {
+			_, isBuilder := service.(Builder)
+			_, isPromoter := service.(Promoter)
+			return isBuilder && isPromoter
+		},
+	},
+	{
+		Name:        "image-scanning",
+		Description: "Scan the built image for vulnerabilities before it is deployed",
+		supports: func(cloud Cloud, service Service) bool {
+			_, ok := service.(ImageScanner)
+			return ok
+		},
+	},
+	{
+		Name:        "environment",
+		Description: "Read and change the deployed environment variables (kettle env)",
+		supports: func(cloud Cloud, service Service) bool {
+			_, ok := service.(EnvironmentEditor)
+			return ok
+		},
+	},
+	{
+		Name:        "flags",
+		Description: "Store feature flags that the function reads when it runs (kettle flags)",
+		supports: func(cloud Cloud, service Service) bool {
+			_, ok := service.(FlagStore)
+			return ok
+		},
+	},
+	{
+		Name:        "drift",
+		Description: "Detect and fix drift from kettle.json (kettle status & apply)",
+		supports: func(cloud Cloud, service Service) bool {
+			_, ok := service.(DriftDetector)
+			return ok
+		},
+	},
+	{
+		Name:        "import",
+		Description: "Adopt existing resources into a new project (kettle import)",
+		supports: func(cloud Cloud, service Service) bool {
+			_, ok := service.(Importer)
+			return ok
+		},
+	},
+	{
+		Name:        "rename",
+		Description: "Move a deployed project to a new name (kettle rename)",
+		supports: func(cloud Cloud, service Service) bool {
+			_, ok := service.(Renamer)
+			return ok
+		},
+	},
+	{
+		Name:        "destroy",
+		Description: "Delete the project's resources (kettle destroy)",
+		supports: func(cloud Cloud, service Service) bool {
+			_, ok := service.(Destroyer)
+			return ok
+		},
+	},
+	{
+		Name:        "performance",
+		Description: "Report cold and warm start performance, and tune memory (kettle memory-tune)",
+		supports: func(cloud Cloud, service Service) bool {
+			_, isReporter := service.(PerformanceReporter)
+			_, isTuner := service.(MemoryTuner)
+			return isReporter && isTuner
+		},
+	},
+	{
+		Name:        "quotas",
+		Description: "Warn when a deploy is likely to hit an account quota, and request increases",
+		supports: func(cloud Cloud, service Service) bool {
+			_, ok := service.(QuotaChecker)
+			return ok
+		},
+	},
+	{
+		Name:        "static-sites",
+		Description: "Host static assets (e.g. a frontend) behind a CDN",
+		supports: func(cloud Cloud, service Service) bool {
+			_, ok := cloud.(StaticSiteHost)
+			return ok
+		},
+	},
+	{
+		Name:        "add-ons",
+		Description: "Provision add-ons (e.g. databases) that are declared in kettle.json",
+		supports: func(cloud Cloud, service Service) bool {
+			_, ok := cloud.(AddOnProvisioner)
+			return ok
+		},
+	},
+	{
+		Name:        "budgets",
+		Description: "Set a monthly budget on the project's resources",
+		supports: func(cloud Cloud, service Service) bool {
+			_, ok := cloud.(BudgetManager)
+			return ok
+		},
+	},
+	{
+		Name:        "remote-state",
+		Description: "Store the project's state in a bucket, and lock it during deploys",
+		supports: func(cloud Cloud, service Service) bool {
+			_, ok := cloud.(StateBackend)
+			return ok
+		},
+	},
+}
+
+// Capabilities are the features that a cloud's service supports
+
+type Capabilities struct {
+	CloudProvider  string          `json:"cloud_provider"`
+	DeploymentType string          `json:"deployment_type"`
+	Features       map[string]bool `json:"features"`
+	// The secret store that the service's secrets are synced to
+	SecretStore string `json:"secret_store,omitempty"`
+}
+
+// GetCapabilities returns the capabilities of each of the clouds' services
+func GetCapabilities() ([]*Capabilities, error) {
+	capabilities := []*Capabilities{}
+	for _, cloudProvider := range CloudProviders {
+		cloud, err := GetCloudProvider(cloudProvider)
+		if err != nil {
+			return nil, err
+		}
+		for _, deploymentType := range cloud.GetDeploymentTypes() {
+			service, err := cloud.GetService(deploymentType)
+			if err != nil {
+				return nil, err
+			}
+			serviceCapabilities := &Capabilities{
+				CloudProvider:  cloudProvider,
+				DeploymentType: deploymentType,
+				Features:       map[string]bool{},
+			}
+			for _, feature := range Features {
+				serviceCapabilities.Features[feature.Name] = feature.supports(cloud, service)
+			}
+			if syncer, ok := cloud.(SecretSyncer); ok && serviceCapabilities.Features["secrets"] {
+				serviceCapabilities.SecretStore = syncer.GetSecretStore()
+			}
+			capabilities = append(capabilities, serviceCapabilities)
+		}
+	}
+	return capabilities, nil
+}
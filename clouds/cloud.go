@@ -3,9 +3,11 @@ package clouds
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/operatorai/kettle-cli/config"
 	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
 )
 
 type Service interface {
@@ -16,6 +18,18 @@ type Cloud interface {
 	Setup(settings *settings.Settings) error
 
 	GetService(deploymentType string) (Service, error)
+	// GetDeploymentTypes returns the types of the cloud's services
+	GetDeploymentTypes() []string
+
+	// GetRegions returns the regions where the project's services are
+	// available (or all of the cloud's regions, if cfg is nil)
+	GetRegions(cfg *config.Config) (map[string]string, error)
+	GetRegion(settings *settings.Settings) string
+	SetRegion(settings *settings.Settings, region string)
+
+	// GetAccount describes the account (or project) that
+	// the cloud's cli is deploying to
+	GetAccount(settings *settings.Settings) (string, error)
 }
 
 func GetCloudProvider(cloudType string) (Cloud, error) {
@@ -27,3 +41,302 @@ func GetCloudProvider(cloudType string) (Cloud, error) {
 	}
 	return nil, errors.New(fmt.Sprintf("unimplemented cloud: %s", cloudType))
 }
+
+// DriftDetector is implemented by services that can compare a deployed
+// resource against the project config, and reconcile any differences
+type DriftDetector interface {
+	DetectDrift(cfg *config.Config, stg *settings.Settings) ([]*config.Drift, error)
+	FixDrift(cfg *config.Config, stg *settings.Settings, drift []*config.Drift) error
+}
+
+// Importer is implemented by services that can adopt an existing
+// resource, which was not created by kettle, into a new project
+type Importer interface {
+	Import(name string, stg *settings.Settings) (*config.Config, *state.State, error)
+	DownloadCode(name string, directory string) error
+}
+
+// GPUCapable is implemented by services that can deploy
+// to GPU-backed infrastructure
+type GPUCapable interface {
+	ValidateGPU(cfg *config.Config) error
+}
+
+// CustomDomainCapable is implemented by services that can serve
+// their endpoint from a custom domain
+type CustomDomainCapable interface {
+	ValidateCustomDomain(cfg *config.Config) error
+}
+
+// SecretsConsumer is implemented by services whose deployments can read the
+// project's secrets from the cloud's secret store (see SecretSyncer)
+type SecretsConsumer interface {
+	ValidateSecrets(cfg *config.Config) error
+}
+
+// QuotaChecker is implemented by services that can compare the account quotas
+// that a deploy uses (e.g. its concurrency) with how much of them is used, and
+// request an increase of the ones that the deploy is likely to hit
+type QuotaChecker interface {
+	CheckQuotas(cfg *config.Config, stg *settings.Settings) ([]*config.Quota, error)
+	RequestQuotaIncrease(quota *config.Quota, stg *settings.Settings) (string, error)
+}
+
+// ValidateFeatures returns an error if the service cannot satisfy
+// the features that are required by the project config
+func ValidateFeatures(service Service, cfg *config.Config) error {
+	if cfg.Config.GPU {
+		gpuService, ok := service.(GPUCapable)
+		if !ok {
+			return fmt.Errorf("gpu is not supported on %s %s deployments (try: gcloud run)",
+				cfg.Config.CloudProvider,
+				cfg.Config.DeploymentType,
+			)
+		}
+		if err := gpuService.ValidateGPU(cfg); err != nil {
+			return err
+		}
+	}
+	if err := cfg.ValidateApi(); err != nil {
+		return err
+	}
+	if cfg.Config.CustomDomain != nil {
+		domainService, ok := service.(CustomDomainCapable)
+		if !ok {
+			return fmt.Errorf("custom domains are not supported on %s %s deployments",
+				cfg.Config.CloudProvider,
+				cfg.Config.DeploymentType,
+			)
+		}
+		if err := domainService.ValidateCustomDomain(cfg); err != nil {
+			return err
+		}
+	}
+	if err := cfg.ValidateScan(); err != nil {
+		return err
+	}
+	if err := cfg.ValidateTracing(); err != nil {
+		return err
+	}
+	if err := cfg.ValidateQueue(); err != nil {
+		return err
+	}
+	if len(cfg.DeploySecrets()) > 0 {
+		secretsService, ok := service.(SecretsConsumer)
+		if !ok {
+			return fmt.Errorf("secrets are not supported on %s %s deployments",
+				cfg.Config.CloudProvider,
+				cfg.Config.DeploymentType,
+			)
+		}
+		if err := secretsService.ValidateSecrets(cfg); err != nil {
+			return err
+		}
+	}
+	if cfg.Config.Parity != nil {
+		if err := cfg.ValidateParity(); err != nil {
+			return err
+		}
+	}
+	return cfg.ValidateFlags()
+}
+
+// JobRunner is implemented by services that deploy batch jobs,
+// which are executed on demand
+type JobRunner interface {
+	RunJob(cfg *config.Config, stg *settings.Settings, args []string, environment map[string]string) error
+}
+
+// TriggerSetter is implemented by services that can wire a deployed function's
+// triggers (e.g. schedules, queues, and buckets) without redeploying it
+type TriggerSetter interface {
+	SetTriggers(directory string, cfg *config.Config, stg *settings.Settings) error
+}
+
+// EnvironmentEditor is implemented by services whose deployed environment
+// variables can be read and changed without a redeploy
+type EnvironmentEditor interface {
+	GetEnvironment(cfg *config.Config, stg *settings.Settings) (map[string]string, error)
+	UpdateEnvironment(cfg *config.Config, stg *settings.Settings, set map[string]string, unset []string) error
+}
+
+// FlagStore is implemented by services that store a project's feature flags
+// in a parameter, which the deployed function reads when it runs
+type FlagStore interface {
+	PutFlags(directory string, cfg *config.Config, stg *settings.Settings) error
+}
+
+// Destroyer is implemented by services that can delete the
+// resources that kettle has created for a project
+type Destroyer interface {
+	Destroy(directory string, cfg *config.Config, stg *settings.Settings) error
+}
+
+// DestroyPlanner is implemented by services that can list the resources
+// that Destroy would delete (in order), and the ones that it would not
+type DestroyPlanner interface {
+	PlanDestroy(directory string, cfg *config.Config) ([]*state.Resource, []*state.Resource, error)
+}
+
+// Renamer is implemented by services that can move a deployed project to a new
+// name: the resources of the old name are retired (and keep serving requests,
+// e.g. on the old API path, which is cut over to the new function) until they
+// are deleted after a grace period
+type Renamer interface {
+	RetireName(directory string, cfg *config.Config, stg *settings.Settings, after time.Time) error
+	Cutover(directory string, cfg *config.Config, stg *settings.Settings) error
+	DeleteRetired(directory string, cfg *config.Config, stg *settings.Settings) error
+}
+
+// StaticSiteHost is implemented by clouds that can host a
+// project's static assets (e.g. a frontend) behind a CDN
+type StaticSiteHost interface {
+	DeployStaticSite(directory string, cfg *config.Config, stg *settings.Settings) error
+}
+
+// AddOnProvisioner is implemented by clouds that can create the add-ons
+// (e.g. databases) that are declared in a project's config
+type AddOnProvisioner interface {
+	ProvisionAddOns(directory string, cfg *config.Config, stg *settings.Settings) (map[string]string, error)
+}
+
+// SecretSyncer is implemented by clouds that can store a project's secrets (e.g.
+// from Vault) in their secret store; it returns the environment variables that
+// refer to the stored secrets, by the environment variables of the secrets' values
+type SecretSyncer interface {
+	SyncSecrets(directory string, cfg *config.Config, stg *settings.Settings, secrets map[string]string) (map[string]string, error)
+	// GetSecretStore returns the name of the cloud's secret store
+	GetSecretStore() string
+}
+
+// EndpointProvider is implemented by services that serve requests
+// from a public HTTP endpoint
+type EndpointProvider interface {
+	GetEndpoint(cfg *config.Config, stg *settings.Settings) (string, error)
+}
+
+// Invoker is implemented by services that can be invoked directly,
+// without going through an HTTP endpoint
+type Invoker interface {
+	Invoke(cfg *config.Config, stg *settings.Settings, payload []byte) error
+}
+
+// ColdStartCounter is implemented by services that can report how many
+// new instances were started to serve requests in a time window
+type ColdStartCounter interface {
+	CountColdStarts(cfg *config.Config, stg *settings.Settings, start, end time.Time) (int, error)
+}
+
+// PerformanceReporter is implemented by services that can invoke a deployed
+// function and report its cold and warm start performance
+type PerformanceReporter interface {
+	ReportPerformance(cfg *config.Config, stg *settings.Settings, invocations int, payload []byte) (*config.PerformanceReport, error)
+}
+
+// MemoryTuner is implemented by services that can measure the duration and
+// cost of a deployed function's invocations across memory settings
+type MemoryTuner interface {
+	TuneMemory(cfg *config.Config, stg *settings.Settings, memorySizes []int, invocations int, payload []byte) ([]*config.MemoryTuning, error)
+}
+
+// CanaryHost is implemented by clouds that can run a project's
+// smoke test on a schedule, and alarm when it fails
+type CanaryHost interface {
+	DeployCanary(directory string, cfg *config.Config, stg *settings.Settings, url string) error
+}
+
+// BudgetManager is implemented by clouds that can set a monthly
+// budget on the resources that are tagged with a project's name
+type BudgetManager interface {
+	SetBudget(directory string, cfg *config.Config, stg *settings.Settings) error
+}
+
+// SpendReporter is implemented by clouds that can report how much
+// a project's tagged resources have cost this month
+type SpendReporter interface {
+	GetMonthToDateSpend(cfg *config.Config, stg *settings.Settings) (float64, string, error)
+}
+
+// CostReporter is implemented by clouds that can report the spend
+// of each project and stage, from the tags on their resources
+type CostReporter interface {
+	GetCosts(projectName string, start, end time.Time) ([]*config.Cost, error)
+}
+
+// StateBackend is implemented by clouds that can store a project's state
+// in a bucket, and lock it so that only one deploy changes it at a time
+type StateBackend interface {
+	PullState(directory string, cfg *config.Config, stg *settings.Settings) error
+	PushState(directory string, cfg *config.Config, stg *settings.Settings) error
+	Lock(cfg *config.Config, stg *settings.Settings) error
+	Unlock(cfg *config.Config, stg *settings.Settings) error
+}
+
+// DeployIdentityProvider is implemented by clouds that can generate the
+// least-privilege permissions that are needed to deploy a project, and
+// create an identity with them that CI (GitHub Actions) can use
+type DeployIdentityProvider interface {
+	GetDeployPolicy(cfg *config.Config, stg *settings.Settings) ([]byte, error)
+	CreateDeployRole(cfg *config.Config, stg *settings.Settings, repository string, policy []byte) (string, error)
+}
+
+// AccountBootstrapper is implemented by clouds that can prepare an account (or
+// project) for a project's stage: its artifact store, state backend and lock
+// table, base roles, and tag policies, so that a new environment is onboarded
+// with one command
+type AccountBootstrapper interface {
+	BootstrapAccount(cfg *config.Config, stg *settings.Settings) error
+}
+
+// CommandContext is implemented by clouds whose cli can be run with a project's
+// context (e.g. its region and tags), for operations that kettle does not model
+type CommandContext interface {
+	// GetCommands returns the names of the cloud's cli commands
+	GetCommands() []string
+	GetCommandEnvironment(cfg *config.Config, stg *settings.Settings) map[string]string
+}
+
+// SharedApiHost is implemented by clouds whose functions can share a REST
+// API, so that the functions that are attached to it can be listed
+type SharedApiHost interface {
+	GetApiRoutes(apiName string, stg *settings.Settings) (string, []*config.ApiRoute, error)
+}
+
+// StageAccountSwitcher is implemented by clouds that can deploy a stage
+// to its own account (or project), e.g. so that staging and prod are isolated
+type StageAccountSwitcher interface {
+	UseStageAccount(stage *config.Stage, stg *settings.Settings) error
+}
+
+// Promoter is implemented by services that can fetch the artifact that is
+// deployed, so that it can be promoted to another stage without rebuilding it
+type Promoter interface {
+	PullArtifact(cfg *config.Config, stg *settings.Settings, digest string) (*config.Artifact, error)
+}
+
+// Builder is implemented by services that can build their artifact without
+// deploying it, so that it can be stored in an artifact store
+type Builder interface {
+	Build(cfg *config.Config, stg *settings.Settings) (*config.Artifact, error)
+	// GetArtifactKind returns whether the service deploys an archive or an image
+	GetArtifactKind() string
+}
+
+// ImageScanner is implemented by services whose image registry can scan
+// a built image for vulnerabilities (e.g. ECR), before it is deployed
+type ImageScanner interface {
+	ScanImage(cfg *config.Config, stg *settings.Settings, artifact *config.Artifact) ([]*config.Vulnerability, error)
+}
+
+// ResourceExplainer is implemented by clouds that can describe the resources
+// that kettle creates, and link to them in the cloud's console
+type ResourceExplainer interface {
+	ExplainResource(resource *state.Resource, stg *settings.Settings) *config.Explanation
+}
+
+// ProjectResourceUser is implemented by services whose projects can use their
+// own resources (e.g. a team's REST API), which their state records, instead
+// of the defaults that projects share in the settings
+type ProjectResourceUser interface {
+	UseProjectResources(directory string, cfg *config.Config, stg *settings.Settings) error
+}
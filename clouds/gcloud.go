@@ -6,7 +6,9 @@ import (
 	"os/exec"
 
 	"github.com/operatorai/kettle-cli/clouds/gcloud"
+	"github.com/operatorai/kettle-cli/config"
 	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
 )
 
 type GoogleCloud struct{}
@@ -17,10 +19,16 @@ func (GoogleCloud) GetService(deploymentType string) (Service, error) {
 		return gcloud.GoogleCloudFunction{}, nil
 	case "run":
 		return gcloud.GoogleCloudRun{}, nil
+	case "job":
+		return gcloud.GoogleCloudRunJob{}, nil
 	}
 	return nil, errors.New(fmt.Sprintf("unimplemented service: %s", deploymentType))
 }
 
+func (GoogleCloud) GetDeploymentTypes() []string {
+	return []string{"function", "run", "job"}
+}
+
 func (GoogleCloud) Setup(stg *settings.Settings) error {
 	_, err := exec.LookPath("gcloud")
 	if err != nil {
@@ -29,11 +37,93 @@ func (GoogleCloud) Setup(stg *settings.Settings) error {
 	if stg.GoogleCloud == nil {
 		stg.GoogleCloud = &settings.GoogleCloudSettings{}
 	}
+	gcloud.UseEndpoints(stg.GoogleCloud.Endpoints)
 	if err := gcloud.SetProjectID(stg.GoogleCloud); err != nil {
 		return err
 	}
-	if err := gcloud.SetDeploymentRegion(stg.GoogleCloud); err != nil {
-		return err
-	}
 	return nil
 }
+
+func (GoogleCloud) GetAccount(stg *settings.Settings) (string, error) {
+	return gcloud.GetAccount(stg.GoogleCloud)
+}
+
+func (GoogleCloud) UseStageAccount(stage *config.Stage, stg *settings.Settings) error {
+	return gcloud.UseStageAccount(stage, stg)
+}
+
+func (GoogleCloud) GetRegions(cfg *config.Config) (map[string]string, error) {
+	return gcloud.GetRegions(cfg)
+}
+
+func (GoogleCloud) GetRegion(stg *settings.Settings) string {
+	return stg.GoogleCloud.DeploymentRegion
+}
+
+func (GoogleCloud) SetRegion(stg *settings.Settings, region string) {
+	stg.GoogleCloud.DeploymentRegion = region
+}
+
+func (GoogleCloud) DeployStaticSite(directory string, cfg *config.Config, stg *settings.Settings) error {
+	return gcloud.DeployStaticSite(directory, cfg, stg)
+}
+
+func (GoogleCloud) ProvisionAddOns(directory string, cfg *config.Config, stg *settings.Settings) (map[string]string, error) {
+	return gcloud.ProvisionAddOns(directory, cfg, stg)
+}
+
+func (GoogleCloud) SyncSecrets(directory string, cfg *config.Config, stg *settings.Settings, secrets map[string]string) (map[string]string, error) {
+	return gcloud.SyncSecrets(directory, cfg, stg, secrets)
+}
+
+func (GoogleCloud) GetSecretStore() string {
+	return "Secret Manager"
+}
+
+func (GoogleCloud) DeployCanary(directory string, cfg *config.Config, stg *settings.Settings, url string) error {
+	return gcloud.DeployCanary(directory, cfg, stg, url)
+}
+
+func (GoogleCloud) SetBudget(directory string, cfg *config.Config, stg *settings.Settings) error {
+	return gcloud.SetBudget(directory, cfg, stg)
+}
+
+func (GoogleCloud) PullState(directory string, cfg *config.Config, stg *settings.Settings) error {
+	return gcloud.PullState(directory, cfg, stg)
+}
+
+func (GoogleCloud) PushState(directory string, cfg *config.Config, stg *settings.Settings) error {
+	return gcloud.PushState(directory, cfg, stg)
+}
+
+func (GoogleCloud) Lock(cfg *config.Config, stg *settings.Settings) error {
+	return gcloud.Lock(cfg, stg)
+}
+
+func (GoogleCloud) Unlock(cfg *config.Config, stg *settings.Settings) error {
+	return gcloud.Unlock(cfg, stg)
+}
+
+func (GoogleCloud) GetDeployPolicy(cfg *config.Config, stg *settings.Settings) ([]byte, error) {
+	return gcloud.GetDeployPolicy(cfg, stg)
+}
+
+func (GoogleCloud) CreateDeployRole(cfg *config.Config, stg *settings.Settings, repository string, policy []byte) (string, error) {
+	return gcloud.CreateDeployRole(cfg, stg, repository, policy)
+}
+
+func (GoogleCloud) BootstrapAccount(cfg *config.Config, stg *settings.Settings) error {
+	return gcloud.BootstrapAccount(cfg, stg)
+}
+
+func (GoogleCloud) GetCommands() []string {
+	return []string{"gcloud", "gsutil", "bq"}
+}
+
+func (GoogleCloud) GetCommandEnvironment(cfg *config.Config, stg *settings.Settings) map[string]string {
+	return gcloud.GetCommandEnvironment(cfg, stg)
+}
+
+func (GoogleCloud) ExplainResource(resource *state.Resource, stg *settings.Settings) *config.Explanation {
+	return gcloud.ExplainResource(resource, stg)
+}
@@ -0,0 +1,126 @@
+package gcloud
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+// serviceAPIs are the APIs that each deployment type uses, which are
+// disabled in new projects
+var serviceAPIs = map[string][]string{
+	"function": {"cloudfunctions.googleapis.com", "cloudbuild.googleapis.com", "artifactregistry.googleapis.com"},
+	"run":      {"run.googleapis.com", "cloudbuild.googleapis.com", "artifactregistry.googleapis.com"},
+	"job":      {"run.googleapis.com", "cloudbuild.googleapis.com", "artifactregistry.googleapis.com"},
+}
+
+// BootstrapAccount prepares the project that a kettle project's stage is deployed
+// to: the APIs that it uses, and the buckets (versioned and private) and Artifact
+// Registry repository of its artifact store and state backend. GCP does not have
+// tag policies; kettle's labels are added to each resource when it is deployed
+func BootstrapAccount(cfg *config.Config, stg *settings.Settings) error {
+	apis := append([]string{"storage.googleapis.com"}, serviceAPIs[cfg.Config.DeploymentType]...)
+	if len(cfg.DeploySecrets()) != 0 {
+		apis = append(apis, "secretmanager.googleapis.com")
+	}
+	err := cli.Execute("gcloud", append([]string{
+		"services",
+		"enable",
+	}, apis...), "Enabling the project's APIs")
+	if err != nil {
+		return err
+	}
+	fmt.Println("✅  APIs: ", strings.Join(apis, ", "))
+
+	if cfg.Config.Artifacts != nil {
+		if strings.HasPrefix(cfg.Config.Artifacts.URI, "gs://") {
+			bucket := strings.SplitN(strings.TrimPrefix(cfg.Config.Artifacts.URI, "gs://"), "/", 2)[0]
+			if err := createVersionedBucket(bucket, stg); err != nil {
+				return err
+			}
+			fmt.Println("✅  Artifact bucket: ", bucket)
+		}
+		if location, repository := getArtifactRepository(cfg.Config.Artifacts.Registry); repository != "" {
+			if err := createArtifactRepository(location, repository); err != nil {
+				return err
+			}
+			fmt.Println("✅  Artifact repository: ", repository)
+		}
+	}
+	if cfg.Config.StateBackend != nil {
+		// Locks are stored in the state bucket
+		if err := createVersionedBucket(cfg.Config.StateBackend.Bucket, stg); err != nil {
+			return err
+		}
+		fmt.Println("✅  State bucket: ", cfg.Config.StateBackend.Bucket)
+	}
+	return nil
+}
+
+// getArtifactRepository returns the location and name of an Artifact Registry
+// repository (e.g. us-central1-docker.pkg.dev/<project>/<repository>)
+func getArtifactRepository(registry string) (string, string) {
+	parts := strings.Split(strings.TrimSuffix(registry, "/"), "/")
+	if len(parts) < 3 || !strings.HasSuffix(parts[0], "-docker.pkg.dev") {
+		return "", ""
+	}
+	return strings.TrimSuffix(parts[0], "-docker.pkg.dev"), parts[2]
+}
+
+// createVersionedBucket creates a Cloud Storage bucket that cannot be made
+// public, and which keeps the previous versions of its objects
+func createVersionedBucket(bucket string, stg *settings.Settings) error {
+	bucketURI := fmt.Sprintf("gs://%s", bucket)
+	_, err := cli.ExecuteWithResult("gcloud", []string{
+		"storage",
+		"buckets",
+		"describe", bucketURI,
+	}, "Looking for the Cloud Storage bucket")
+	if err != nil {
+		if !cli.IsNotFound(err) {
+			return err
+		}
+		err = cli.Execute("gcloud", []string{
+			"storage",
+			"buckets",
+			"create", bucketURI,
+			fmt.Sprintf("--location=%s", stg.GoogleCloud.DeploymentRegion),
+			"--uniform-bucket-level-access",
+			"--public-access-prevention",
+		}, fmt.Sprintf("Creating a Cloud Storage bucket called: %s", bucket))
+		if err != nil {
+			return err
+		}
+	}
+	return cli.Execute("gcloud", []string{
+		"storage",
+		"buckets",
+		"update", bucketURI,
+		"--versioning",
+	}, "Enabling bucket versioning")
+}
+
+// createArtifactRepository creates a docker repository in Artifact Registry
+// (unless it already exists), whose tags are immutable like artifact versions
+func createArtifactRepository(location, repository string) error {
+	_, err := cli.ExecuteWithResult("gcloud", []string{
+		"artifacts",
+		"repositories",
+		"describe", repository,
+		fmt.Sprintf("--location=%s", location),
+	}, "Looking for the Artifact Registry repository")
+	if err == nil || !cli.IsNotFound(err) {
+		return err
+	}
+	return cli.Execute("gcloud", []string{
+		"artifacts",
+		"repositories",
+		"create", repository,
+		"--repository-format=docker",
+		"--immutable-tags",
+		fmt.Sprintf("--location=%s", location),
+	}, fmt.Sprintf("Creating an Artifact Registry repository called: %s", repository))
+}
@@ -0,0 +1,277 @@
+package gcloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+const (
+	vpcConnectorName  = "kettle-connector"
+	vpcConnectorRange = "10.8.0.0/28"
+)
+
+// ProvisionAddOns creates any add-ons that do not exist yet, and returns
+// their connection details as environment variables
+func ProvisionAddOns(directory string, cfg *config.Config, stg *settings.Settings) (map[string]string, error) {
+	environment := map[string]string{}
+	if len(cfg.Config.AddOns) == 0 {
+		return environment, nil
+	}
+
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return nil, err
+	}
+	for _, addOn := range cfg.Config.AddOns {
+		fmt.Println("🧩  Add-on: ", addOn.Name, fmt.Sprintf("(%s)", addOn.Type))
+		prefix := addOn.EnvironmentPrefix()
+		if addOn.Existing != "" {
+			return nil, fmt.Errorf("existing resources are not supported for add-ons on gcloud: %s", addOn.Name)
+		}
+		switch addOn.Type {
+		case "firestore":
+			databaseName := fmt.Sprintf("%s-%s", cfg.ProjectName, strcase.ToKebab(addOn.Name))
+			if err := createFirestoreDatabase(databaseName, stg); err != nil {
+				return nil, err
+			}
+			st.AddResource(state.GoogleFirestore, databaseName, "")
+			environment[fmt.Sprintf("%s_DATABASE", prefix)] = databaseName
+			environment["GOOGLE_CLOUD_PROJECT"] = stg.GoogleCloud.ProjectID
+		case "redis":
+			instanceName := fmt.Sprintf("%s-%s", cfg.ProjectName, strcase.ToKebab(addOn.Name))
+			if err := createVpcConnector(stg); err != nil {
+				return nil, err
+			}
+			host, port, err := createRedisInstance(instanceName, stg)
+			if err != nil {
+				return nil, err
+			}
+			st.AddResource(state.GoogleRedis, instanceName, "")
+			environment[fmt.Sprintf("%s_HOST", prefix)] = host
+			environment[fmt.Sprintf("%s_PORT", prefix)] = fmt.Sprintf("%d", port)
+			environment[fmt.Sprintf("%s_URL", prefix)] = fmt.Sprintf("redis://%s:%d", host, port)
+		case "bucket":
+			// Bucket names are global, so they include the project ID
+			bucket := fmt.Sprintf("%s-%s-%s", cfg.ProjectName, strcase.ToKebab(addOn.Name), stg.GoogleCloud.ProjectID)
+			if st.GetResource(state.GoogleStorageBucket, bucket) == nil {
+				if err := createPrivateBucket(bucket, addOn, stg); err != nil {
+					return nil, err
+				}
+				st.AddResource(state.GoogleStorageBucket, bucket, "")
+			}
+			if err := grantBucketAccess(bucket, cfg, stg); err != nil {
+				return nil, err
+			}
+			environment[fmt.Sprintf("%s_BUCKET", prefix)] = bucket
+		default:
+			return nil, fmt.Errorf("unsupported add-on on gcloud: %s", addOn.Type)
+		}
+		if err := state.WriteState(directory, st); err != nil {
+			return nil, err
+		}
+	}
+	return environment, nil
+}
+
+// https://cloud.google.com/sdk/gcloud/reference/firestore/databases/create
+func createFirestoreDatabase(databaseName string, stg *settings.Settings) error {
+	_, err := cli.ExecuteWithResult("gcloud", []string{
+		"firestore",
+		"databases",
+		"describe",
+		fmt.Sprintf("--database=%s", databaseName),
+	}, "Looking for Firestore database")
+	if err == nil {
+		return nil
+	}
+	return cli.Execute("gcloud", []string{
+		"firestore",
+		"databases",
+		"create",
+		fmt.Sprintf("--database=%s", databaseName),
+		fmt.Sprintf("--location=%s", stg.GoogleCloud.DeploymentRegion),
+		"--type=firestore-native",
+	}, fmt.Sprintf("Creating a Firestore database called: %s", databaseName))
+}
+
+// createRedisInstance creates a Memorystore instance on the default network,
+// and returns its host and port
+// https://cloud.google.com/sdk/gcloud/reference/redis/instances/create
+func createRedisInstance(instanceName string, stg *settings.Settings) (string, int, error) {
+	describeArgs := []string{
+		"redis",
+		"instances",
+		"describe", instanceName,
+		fmt.Sprintf("--region=%s", stg.GoogleCloud.DeploymentRegion),
+		"--format", "json",
+	}
+	output, err := cli.ExecuteWithResult("gcloud", describeArgs, "Looking for Memorystore instance")
+	if err != nil {
+		err := cli.Execute("gcloud", []string{
+			"redis",
+			"instances",
+			"create", instanceName,
+			"--size=1",
+			"--tier=basic",
+			"--network=default",
+			fmt.Sprintf("--region=%s", stg.GoogleCloud.DeploymentRegion),
+		}, fmt.Sprintf("Creating a Memorystore instance called: %s", instanceName))
+		if err != nil {
+			return "", 0, err
+		}
+		output, err = cli.ExecuteWithResult("gcloud", describeArgs, "Retrieving the Memorystore instance")
+		if err != nil {
+			return "", 0, err
+		}
+	}
+	var result struct {
+		Host string `json:"host"`
+		Port int    `json:"port"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return "", 0, err
+	}
+	return result.Host, result.Port, nil
+}
+
+// createVpcConnector creates the Serverless VPC Access connector that lets
+// functions and Cloud Run services reach add-ons on the default network;
+// the connector is shared by all of the projects in a region
+func createVpcConnector(stg *settings.Settings) error {
+	_, err := cli.ExecuteWithResult("gcloud", []string{
+		"compute",
+		"networks",
+		"vpc-access",
+		"connectors",
+		"describe", vpcConnectorName,
+		fmt.Sprintf("--region=%s", stg.GoogleCloud.DeploymentRegion),
+	}, "Looking for VPC connector")
+	if err == nil {
+		return nil
+	}
+	return cli.Execute("gcloud", []string{
+		"compute",
+		"networks",
+		"vpc-access",
+		"connectors",
+		"create", vpcConnectorName,
+		"--network=default",
+		fmt.Sprintf("--range=%s", vpcConnectorRange),
+		fmt.Sprintf("--region=%s", stg.GoogleCloud.DeploymentRegion),
+	}, "Creating a VPC connector")
+}
+
+// getNetworkArgs routes the service's private traffic through the VPC
+// connector, if it uses any add-ons that are only reachable on the network
+func getNetworkArgs(cfg *config.Config) []string {
+	for _, addOn := range cfg.Config.AddOns {
+		if addOn.Type == "redis" {
+			return []string{fmt.Sprintf("--vpc-connector=%s", vpcConnectorName)}
+		}
+	}
+	return []string{}
+}
+
+// createPrivateBucket creates a Cloud Storage bucket that cannot be made public;
+// incomplete uploads (and, optionally, old objects) are removed by a lifecycle rule.
+// Objects are encrypted at rest by default
+func createPrivateBucket(bucket string, addOn *config.AddOn, stg *settings.Settings) error {
+	bucketURI := fmt.Sprintf("gs://%s", bucket)
+	err := cli.Execute("gcloud", []string{
+		"storage",
+		"buckets",
+		"create", bucketURI,
+		fmt.Sprintf("--location=%s", stg.GoogleCloud.DeploymentRegion),
+		"--uniform-bucket-level-access",
+		"--public-access-prevention",
+	}, fmt.Sprintf("Creating a Cloud Storage bucket called: %s", bucket))
+	if err != nil {
+		return err
+	}
+
+	rules := []interface{}{
+		map[string]interface{}{
+			"action":    map[string]string{"type": "AbortIncompleteMultipartUpload"},
+			"condition": map[string]int{"age": 7},
+		},
+	}
+	if addOn.ExpireAfter != 0 {
+		rules = append(rules, map[string]interface{}{
+			"action":    map[string]string{"type": "Delete"},
+			"condition": map[string]int{"age": addOn.ExpireAfter},
+		})
+	}
+	lifecycle, err := json.Marshal(map[string]interface{}{
+		"rule": rules,
+	})
+	if err != nil {
+		return err
+	}
+	lifecycleFile, err := os.CreateTemp("", "kettle-lifecycle-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(lifecycleFile.Name())
+	if _, err := lifecycleFile.Write(lifecycle); err != nil {
+		return err
+	}
+	if err := lifecycleFile.Close(); err != nil {
+		return err
+	}
+	return cli.Execute("gcloud", []string{
+		"storage",
+		"buckets",
+		"update", bucketURI,
+		fmt.Sprintf("--lifecycle-file=%s", lifecycleFile.Name()),
+	}, "Setting bucket lifecycle rules")
+}
+
+// grantBucketAccess allows the service's default service account
+// to read and write objects in the bucket
+func grantBucketAccess(bucket string, cfg *config.Config, stg *settings.Settings) error {
+	serviceAccount, err := getServiceAccount(cfg, stg)
+	if err != nil {
+		return err
+	}
+	return cli.Execute("gcloud", []string{
+		"storage",
+		"buckets",
+		"add-iam-policy-binding", fmt.Sprintf("gs://%s", bucket),
+		fmt.Sprintf("--member=serviceAccount:%s", serviceAccount),
+		"--role=roles/storage.objectAdmin",
+	}, "Granting the service account access to the bucket")
+}
+
+// getServiceAccount returns the default service account that the
+// project's service runs as
+func getServiceAccount(cfg *config.Config, stg *settings.Settings) (string, error) {
+	if cfg.Config.DeploymentType == "function" {
+		return fmt.Sprintf("%s@appspot.gserviceaccount.com", stg.GoogleCloud.ProjectID), nil
+	}
+	projectNumber, err := getProjectNumber(stg)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s-compute@developer.gserviceaccount.com", projectNumber), nil
+}
+
+func getProjectNumber(stg *settings.Settings) (string, error) {
+	output, err := cli.ExecuteWithResult("gcloud", []string{
+		"projects",
+		"describe", stg.GoogleCloud.ProjectID,
+		"--format", "value(projectNumber)",
+	}, "Retrieving the project number")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
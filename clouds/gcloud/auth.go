@@ -0,0 +1,14 @@
+package gcloud
+
+import (
+	"github.com/operatorai/kettle-cli/config"
+)
+
+// getAuthArgs returns whether anyone can call the service, or only
+// callers with the run.invoker (or cloudfunctions.invoker) role
+func getAuthArgs(cfg *config.Config) []string {
+	if cfg.IsPublic() {
+		return []string{"--allow-unauthenticated"}
+	}
+	return []string{"--no-allow-unauthenticated"}
+}
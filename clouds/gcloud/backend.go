@@ -0,0 +1,128 @@
+package gcloud
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+// ErrLocked is returned when another deploy is holding the lock
+var ErrLocked = errors.New("the project is locked by another deploy")
+
+// lock is the content of a lock file, which says who is holding it
+type lock struct {
+	Owner   string `json:"owner"`
+	Created string `json:"created"`
+}
+
+// PullState downloads the project's state from the state backend's bucket,
+// replacing the local state; projects without remote state keep their local state
+func PullState(directory string, cfg *config.Config, stg *settings.Settings) error {
+	stateURI := getStateURI(cfg, cfg.StateKey())
+	_, err := cli.ExecuteWithResult("gcloud", []string{
+		"storage",
+		"ls", stateURI,
+	}, "Looking for remote state")
+	if err != nil {
+		return nil
+	}
+
+	statePath := state.GetStatePath(directory)
+	if err := os.MkdirAll(path.Dir(statePath), os.ModePerm); err != nil {
+		return err
+	}
+	return cli.Execute("gcloud", []string{
+		"storage",
+		"cp", stateURI, statePath,
+	}, "Downloading remote state")
+}
+
+// PushState uploads the project's local state to the state backend's bucket,
+// or deletes the remote state if the local state was deleted (e.g. with a preview)
+func PushState(directory string, cfg *config.Config, stg *settings.Settings) error {
+	statePath := state.GetStatePath(directory)
+	if _, err := os.Stat(statePath); os.IsNotExist(err) {
+		// Deleting remote state that does not exist is not an error
+		cli.Execute("gcloud", []string{
+			"storage",
+			"rm", getStateURI(cfg, cfg.StateKey()),
+		}, "Deleting remote state")
+		return nil
+	}
+	return cli.Execute("gcloud", []string{
+		"storage",
+		"cp", statePath, getStateURI(cfg, cfg.StateKey()),
+	}, "Uploading remote state")
+}
+
+// Lock acquires the lock on the project's stage, by uploading a lock file
+// that only succeeds if no other deploy has uploaded one
+func Lock(cfg *config.Config, stg *settings.Settings) error {
+	data, err := json.Marshal(&lock{
+		Owner:   config.LockOwner(),
+		Created: time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+	lockFile, err := os.CreateTemp("", "kettle-lock-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(lockFile.Name())
+	if _, err := lockFile.Write(data); err != nil {
+		return err
+	}
+	if err := lockFile.Close(); err != nil {
+		return err
+	}
+
+	// A generation of 0 only matches objects that do not exist
+	_, err = cli.ExecuteWithResult("gcloud", []string{
+		"storage",
+		"cp", lockFile.Name(), getLockURI(cfg),
+		"--if-generation-match=0",
+	}, "Acquiring the state lock")
+	if err == nil {
+		return nil
+	}
+
+	output, readErr := cli.ExecuteWithResult("gcloud", []string{
+		"storage",
+		"cat", getLockURI(cfg),
+	}, "Reading the state lock")
+	if readErr != nil {
+		// The lock file was not the reason that the upload failed
+		return err
+	}
+	var current lock
+	if err := json.Unmarshal(output, &current); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %s since %s (if it is stuck, run: kettle force-unlock)", ErrLocked, current.Owner, current.Created)
+}
+
+// Unlock releases the lock on the project's stage
+func Unlock(cfg *config.Config, stg *settings.Settings) error {
+	return cli.Execute("gcloud", []string{
+		"storage",
+		"rm", getLockURI(cfg),
+	}, "Releasing the state lock")
+}
+
+func getStateURI(cfg *config.Config, key string) string {
+	return fmt.Sprintf("gs://%s/%s", cfg.Config.StateBackend.Bucket, key)
+}
+
+// getLockURI returns the lock file, which is stored alongside the stage's state
+func getLockURI(cfg *config.Config) string {
+	return getStateURI(cfg, fmt.Sprintf("%s/lock.json", cfg.LockID()))
+}
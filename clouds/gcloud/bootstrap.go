@@ -0,0 +1,201 @@
+package gcloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+const (
+	githubIdentityPool   = "github"
+	githubOIDCIssuer     = "https://token.actions.githubusercontent.com"
+	maxServiceAccountLen = 30
+)
+
+// deployPolicy lists the predefined roles that kettle needs to deploy the project
+type deployPolicy struct {
+	Roles []string `json:"roles"`
+}
+
+// GetDeployPolicy returns the roles that grant the permissions that kettle
+// needs to deploy (and destroy) the project, given its features
+func GetDeployPolicy(cfg *config.Config, stg *settings.Settings) ([]byte, error) {
+	roles := map[string]bool{
+		// Services run as a service account, which the deployer must be able to act as
+		"roles/iam.serviceAccountUser": true,
+	}
+	switch cfg.Config.DeploymentType {
+	case "function":
+		roles["roles/cloudfunctions.developer"] = true
+	case "run", "job":
+		roles["roles/run.admin"] = true
+		roles["roles/cloudbuild.builds.editor"] = true
+		roles["roles/storage.admin"] = true
+	}
+	if cfg.Config.Static != nil {
+		roles["roles/storage.admin"] = true
+		roles["roles/compute.loadBalancerAdmin"] = true
+	}
+	for _, addOn := range cfg.Config.AddOns {
+		switch addOn.Type {
+		case "firestore":
+			roles["roles/datastore.owner"] = true
+		case "bucket":
+			roles["roles/storage.admin"] = true
+		case "redis":
+			roles["roles/redis.admin"] = true
+			roles["roles/vpcaccess.admin"] = true
+		}
+	}
+	if len(cfg.DeploySecrets()) != 0 {
+		roles["roles/secretmanager.admin"] = true
+	}
+	if cfg.Config.Canary != nil {
+		roles["roles/monitoring.editor"] = true
+	}
+	if cfg.Config.Budget != nil {
+		// Budgets belong to the billing account, so this role must be granted on it
+		roles["roles/billing.costsManager"] = true
+		roles["roles/monitoring.notificationChannelEditor"] = true
+	}
+	if cfg.Config.StateBackend != nil || (cfg.Config.Model != nil && strings.HasPrefix(cfg.Config.Model.URI, "gs://")) {
+		roles["roles/storage.objectAdmin"] = true
+	}
+
+	policy := &deployPolicy{}
+	for role := range roles {
+		policy.Roles = append(policy.Roles, role)
+	}
+	sort.Strings(policy.Roles)
+	return json.MarshalIndent(policy, "", "  ")
+}
+
+// CreateDeployRole creates a service account with the deploy roles, that GitHub
+// Actions workflows in the repository can impersonate with workload identity federation
+func CreateDeployRole(cfg *config.Config, stg *settings.Settings, repository string, policy []byte) (string, error) {
+	var deploy deployPolicy
+	if err := json.Unmarshal(policy, &deploy); err != nil {
+		return "", err
+	}
+	owner := strings.SplitN(repository, "/", 2)[0]
+	projectNumber, err := getProjectNumber(stg)
+	if err != nil {
+		return "", err
+	}
+
+	accountName := fmt.Sprintf("kettle-deploy-%s", labelValue(cfg.GetBaseProjectName()))
+	if len(accountName) > maxServiceAccountLen {
+		accountName = strings.TrimRight(accountName[:maxServiceAccountLen], "-")
+	}
+	serviceAccount := fmt.Sprintf("%s@%s.iam.gserviceaccount.com", accountName, stg.GoogleCloud.ProjectID)
+	_, err = cli.ExecuteWithResult("gcloud", []string{
+		"iam",
+		"service-accounts",
+		"describe", serviceAccount,
+	}, "Looking for the deploy service account")
+	if err != nil {
+		err := cli.Execute("gcloud", []string{
+			"iam",
+			"service-accounts",
+			"create", accountName,
+			fmt.Sprintf("--display-name=kettle deploys of %s", cfg.GetBaseProjectName()),
+		}, fmt.Sprintf("Creating a service account called: %s", accountName))
+		if err != nil {
+			return "", err
+		}
+	}
+	for _, role := range deploy.Roles {
+		if role == "roles/billing.costsManager" {
+			fmt.Println("💳  Grant", serviceAccount, "roles/billing.costsManager on the billing account, to manage budgets")
+			continue
+		}
+		err := cli.Execute("gcloud", []string{
+			"projects",
+			"add-iam-policy-binding", stg.GoogleCloud.ProjectID,
+			fmt.Sprintf("--member=serviceAccount:%s", serviceAccount),
+			fmt.Sprintf("--role=%s", role),
+			"--condition=None",
+		}, fmt.Sprintf("Granting %s to the service account", role))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	provider, err := getOrCreateGitHubIdentityProvider(owner, projectNumber)
+	if err != nil {
+		return "", err
+	}
+	fmt.Println("🪪  Workload identity provider: ", provider)
+	err = cli.Execute("gcloud", []string{
+		"iam",
+		"service-accounts",
+		"add-iam-policy-binding", serviceAccount,
+		"--role=roles/iam.workloadIdentityUser",
+		fmt.Sprintf(
+			"--member=principalSet://iam.googleapis.com/projects/%s/locations/global/workloadIdentityPools/%s/attribute.repository/%s",
+			projectNumber, githubIdentityPool, repository,
+		),
+	}, "Allowing the repository to impersonate the service account")
+	if err != nil {
+		return "", err
+	}
+	return serviceAccount, nil
+}
+
+// getOrCreateGitHubIdentityProvider returns the workload identity provider for
+// GitHub Actions, which is created (and limited to the repository's owner)
+// if it does not exist
+func getOrCreateGitHubIdentityProvider(owner, projectNumber string) (string, error) {
+	provider := fmt.Sprintf("projects/%s/locations/global/workloadIdentityPools/%s/providers/%s",
+		projectNumber, githubIdentityPool, githubIdentityPool)
+	_, err := cli.ExecuteWithResult("gcloud", []string{
+		"iam",
+		"workload-identity-pools",
+		"providers",
+		"describe", githubIdentityPool,
+		"--location=global",
+		fmt.Sprintf("--workload-identity-pool=%s", githubIdentityPool),
+	}, "Looking for the GitHub Actions identity provider")
+	if err == nil {
+		return provider, nil
+	}
+
+	_, err = cli.ExecuteWithResult("gcloud", []string{
+		"iam",
+		"workload-identity-pools",
+		"describe", githubIdentityPool,
+		"--location=global",
+	}, "Looking for the GitHub Actions identity pool")
+	if err != nil {
+		err := cli.Execute("gcloud", []string{
+			"iam",
+			"workload-identity-pools",
+			"create", githubIdentityPool,
+			"--location=global",
+			"--display-name=GitHub Actions",
+		}, "Creating the GitHub Actions identity pool")
+		if err != nil {
+			return "", err
+		}
+	}
+	err = cli.Execute("gcloud", []string{
+		"iam",
+		"workload-identity-pools",
+		"providers",
+		"create-oidc", githubIdentityPool,
+		"--location=global",
+		fmt.Sprintf("--workload-identity-pool=%s", githubIdentityPool),
+		fmt.Sprintf("--issuer-uri=%s", githubOIDCIssuer),
+		"--attribute-mapping=google.subject=assertion.sub,attribute.repository=assertion.repository",
+		fmt.Sprintf("--attribute-condition=assertion.repository_owner=='%s'", owner),
+	}, "Creating the GitHub Actions identity provider")
+	if err != nil {
+		return "", err
+	}
+	return provider, nil
+}
@@ -0,0 +1,89 @@
+package gcloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+// SetBudget creates (or updates) a billing budget that is scoped to the resources
+// with the project's label, and alerts by email when the threshold is passed
+// https://cloud.google.com/sdk/gcloud/reference/billing/budgets/create
+func SetBudget(directory string, cfg *config.Config, stg *settings.Settings) error {
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("💰  Budget: ", cfg.BudgetName(), fmt.Sprintf("(%.2f %s per month)", cfg.Config.Budget.Amount, cfg.BudgetCurrency()))
+	amount := fmt.Sprintf("--budget-amount=%.2f%s", cfg.Config.Budget.Amount, cfg.BudgetCurrency())
+	budgets := st.GetResources(state.GoogleBudget)
+	if len(budgets) > 0 {
+		return cli.Execute("gcloud", []string{
+			"billing",
+			"budgets",
+			"update", budgets[0].ID,
+			amount,
+		}, "Updating the project budget")
+	}
+
+	billingAccount, err := getBillingAccount(stg)
+	if err != nil {
+		return err
+	}
+	args := []string{
+		"billing",
+		"budgets",
+		"create",
+		fmt.Sprintf("--billing-account=%s", billingAccount),
+		fmt.Sprintf("--display-name=%s", cfg.BudgetName()),
+		amount,
+		fmt.Sprintf("--threshold-rule=percent=%.2f", float64(cfg.BudgetAlertThreshold())/100),
+		fmt.Sprintf("--filter-projects=projects/%s", stg.GoogleCloud.ProjectID),
+		fmt.Sprintf("--filter-labels=%s=%s", config.ProjectTag, labelValue(cfg.GetBaseProjectName())),
+		"--format", "json",
+	}
+	if cfg.Config.Budget.AlertEmail != "" {
+		channel, err := createNotificationChannel(cfg.BudgetName(), cfg.Config.Budget.AlertEmail)
+		if err != nil {
+			return err
+		}
+		st.AddResource(state.GoogleNotification, channel, "")
+		args = append(args, fmt.Sprintf("--notifications-rule-monitoring-notification-channels=%s", channel))
+	}
+	output, err := cli.ExecuteWithResult("gcloud", args, "Creating the project budget")
+	if err != nil {
+		return err
+	}
+	var budget struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(output, &budget); err != nil {
+		return err
+	}
+	st.AddResource(state.GoogleBudget, budget.Name, "")
+	return state.WriteState(directory, st)
+}
+
+// getBillingAccount returns the ID of the billing account that the Google Cloud project uses
+func getBillingAccount(stg *settings.Settings) (string, error) {
+	output, err := cli.ExecuteWithResult("gcloud", []string{
+		"billing",
+		"projects",
+		"describe", stg.GoogleCloud.ProjectID,
+		"--format", "value(billingAccountName)",
+	}, "Looking for the billing account")
+	if err != nil {
+		return "", err
+	}
+	billingAccount := strings.TrimPrefix(strings.TrimSpace(string(output)), "billingAccounts/")
+	if billingAccount == "" {
+		return "", fmt.Errorf("project %s does not have a billing account", stg.GoogleCloud.ProjectID)
+	}
+	return billingAccount, nil
+}
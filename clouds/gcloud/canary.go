@@ -0,0 +1,200 @@
+package gcloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+// DeployCanary creates (or updates) a Cloud Monitoring uptime check that runs the
+// project's smoke test against the endpoint, and an alert policy that fires when it fails
+// https://cloud.google.com/sdk/gcloud/reference/monitoring/uptime/create
+func DeployCanary(directory string, cfg *config.Config, stg *settings.Settings, endpoint string) error {
+	switch cfg.CanaryInterval() {
+	case 1, 5, 10, 15:
+	default:
+		return fmt.Errorf("uptime checks run every 1, 5, 10, or 15 minutes (not %d)", cfg.CanaryInterval())
+	}
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return err
+	}
+
+	smokeTest := cfg.GetSmokeTest()
+	target, err := url.Parse(endpoint + smokeTest.Path)
+	if err != nil {
+		return err
+	}
+	targetPath := target.Path
+	if targetPath == "" {
+		targetPath = "/"
+	}
+
+	name := fmt.Sprintf("kettle-canary-%s", cfg.ProjectName)
+	fmt.Println("🐤  Canary: ", name, fmt.Sprintf("(every %d minutes)", cfg.CanaryInterval()))
+	checks := st.GetResources(state.GoogleUptimeCheck)
+	if len(checks) > 0 {
+		args := []string{
+			"monitoring",
+			"uptime",
+			"update", checks[0].ID,
+			fmt.Sprintf("--path=%s", targetPath),
+			fmt.Sprintf("--period=%d", cfg.CanaryInterval()),
+			fmt.Sprintf("--set-status-codes=%d", smokeTest.ExpectedStatus),
+		}
+		if smokeTest.Body != "" {
+			args = append(args, fmt.Sprintf("--body=%s", smokeTest.Body))
+		}
+		return cli.Execute("gcloud", args, "Updating the uptime check")
+	}
+
+	args := []string{
+		"monitoring",
+		"uptime",
+		"create", name,
+		"--resource-type=uptime-url",
+		fmt.Sprintf("--resource-labels=host=%s,project_id=%s", target.Host, stg.GoogleCloud.ProjectID),
+		fmt.Sprintf("--path=%s", targetPath),
+		"--protocol=https",
+		fmt.Sprintf("--request-method=%s", strings.ToLower(smokeTest.Method)),
+		fmt.Sprintf("--period=%d", cfg.CanaryInterval()),
+		fmt.Sprintf("--status-codes=%d", smokeTest.ExpectedStatus),
+		"--format", "json",
+	}
+	if smokeTest.Body != "" {
+		args = append(args,
+			fmt.Sprintf("--body=%s", smokeTest.Body),
+			"--content-type=user-provided",
+			"--custom-content-type=application/json",
+		)
+	}
+	output, err := cli.ExecuteWithResult("gcloud", args, "Creating the uptime check")
+	if err != nil {
+		return err
+	}
+	var check struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(output, &check); err != nil {
+		return err
+	}
+	// Checks are referred to by the last part of their name
+	checkID := check.Name[strings.LastIndex(check.Name, "/")+1:]
+	st.AddResource(state.GoogleUptimeCheck, checkID, check.Name)
+	if err := state.WriteState(directory, st); err != nil {
+		return err
+	}
+
+	channels := []string{}
+	if cfg.Config.Canary.AlarmEmail != "" {
+		channel, err := createNotificationChannel(name, cfg.Config.Canary.AlarmEmail)
+		if err != nil {
+			return err
+		}
+		st.AddResource(state.GoogleNotification, channel, "")
+		channels = append(channels, channel)
+	}
+
+	policyName, err := createAlertPolicy(name, checkID, channels)
+	if err != nil {
+		return err
+	}
+	st.AddResource(state.GoogleAlertPolicy, policyName, "")
+	return state.WriteState(directory, st)
+}
+
+func createNotificationChannel(name, email string) (string, error) {
+	output, err := cli.ExecuteWithResult("gcloud", []string{
+		"beta",
+		"monitoring",
+		"channels",
+		"create",
+		fmt.Sprintf("--display-name=%s", name),
+		"--type=email",
+		fmt.Sprintf("--channel-labels=email_address=%s", email),
+		"--format", "json",
+	}, "Creating the alert notification channel")
+	if err != nil {
+		return "", err
+	}
+	var channel struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(output, &channel); err != nil {
+		return "", err
+	}
+	return channel.Name, nil
+}
+
+// createAlertPolicy creates a policy that fires when the uptime check fails
+func createAlertPolicy(name, checkID string, channels []string) (string, error) {
+	policy, err := json.Marshal(map[string]interface{}{
+		"displayName": name,
+		"combiner":    "OR",
+		"conditions": []interface{}{
+			map[string]interface{}{
+				"displayName": "Uptime check failed",
+				"conditionThreshold": map[string]interface{}{
+					"filter": fmt.Sprintf(
+						`metric.type="monitoring.googleapis.com/uptime_check/check_passed" AND metric.label.check_id="%s" AND resource.type="uptime_url"`,
+						checkID,
+					),
+					"aggregations": []interface{}{
+						map[string]interface{}{
+							"alignmentPeriod":    "1200s",
+							"perSeriesAligner":   "ALIGN_NEXT_OLDER",
+							"crossSeriesReducer": "REDUCE_COUNT_FALSE",
+							"groupByFields":      []string{"resource.label.*"},
+						},
+					},
+					"comparison":     "COMPARISON_GT",
+					"thresholdValue": 1,
+					"duration":       "60s",
+					"trigger":        map[string]int{"count": 1},
+				},
+			},
+		},
+		"notificationChannels": channels,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	policyFile, err := os.CreateTemp("", "kettle-policy-*.json")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(policyFile.Name())
+	if _, err := policyFile.Write(policy); err != nil {
+		return "", err
+	}
+	if err := policyFile.Close(); err != nil {
+		return "", err
+	}
+
+	output, err := cli.ExecuteWithResult("gcloud", []string{
+		"alpha",
+		"monitoring",
+		"policies",
+		"create",
+		fmt.Sprintf("--policy-from-file=%s", policyFile.Name()),
+		"--format", "json",
+	}, "Creating the alert policy")
+	if err != nil {
+		return "", err
+	}
+	var result struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return "", err
+	}
+	return result.Name, nil
+}
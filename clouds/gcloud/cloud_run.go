@@ -3,32 +3,28 @@ package gcloud
 import (
 	"encoding/json"
 	"fmt"
-	"strings"
 
+	"github.com/operatorai/kettle-cli/artifacts"
 	"github.com/operatorai/kettle-cli/cli"
 	"github.com/operatorai/kettle-cli/config"
 	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
 )
 
 type GoogleCloudRun struct{}
 
 func (GoogleCloudRun) Deploy(directory string, cfg *config.Config, stg *settings.Settings) error {
-	if strings.Contains(cfg.Config.Runtime, "go") {
-		_ = cli.Execute("go", []string{
-			"mod",
-			"init",
-		}, "Running go mod init")
+	deployArgs, err := getProtocolArgs(cfg)
+	if err != nil {
+		return err
 	}
+	deployArgs = append(deployArgs, getResourceArgs(cfg)...)
+	deployArgs = append(deployArgs, getEnvironmentArgs(cfg)...)
+	deployArgs = append(deployArgs, getNetworkArgs(cfg)...)
+	deployArgs = append(deployArgs, getLabelArgs(cfg)...)
+	deployArgs = append(deployArgs, getAuthArgs(cfg)...)
 
-	fmt.Println("🏭  Building: ", cfg.ProjectName, "as a Cloud Run container")
-	containerTag := fmt.Sprintf("gcr.io/%s/%s", stg.GoogleCloud.ProjectID, cfg.ProjectName)
-	// Build the docker container
-	// gcloud builds submit --tag gcr.io/PROJECT-ID/helloworld
-	err := cli.Execute("gcloud", []string{
-		"builds",
-		"submit",
-		"--tag", containerTag,
-	}, "Building docker container")
+	containerTag, err := buildContainer(cfg, stg)
 	if err != nil {
 		return err
 	}
@@ -36,20 +32,37 @@ func (GoogleCloudRun) Deploy(directory string, cfg *config.Config, stg *settings
 	// Deploy the docker container
 	// gcloud run deploy --image gcr.io/PROJECT-ID/helloworld
 	fmt.Println("🚢  Deploying ", cfg.ProjectName, "as a Cloud Run container")
-	err = cli.Execute("gcloud", []string{
+	err = cli.Execute("gcloud", append([]string{
 		"run",
 		"deploy",
 		cfg.ProjectName,
 		"--image", containerTag,
 		"--platform", "managed",
-		"--allow-unauthenticated",
 		fmt.Sprintf("--region=%s", stg.GoogleCloud.DeploymentRegion),
-	}, "Deploying Cloud Run container")
+	}, deployArgs...), "Deploying Cloud Run container")
 	if err != nil {
 		return err
 	}
+	if err := recordResource(directory, state.GoogleCloudRunService, cfg.ProjectName); err != nil {
+		return err
+	}
 
 	// Get the URL
+	url, err := getServiceURL(cfg, stg)
+	if err != nil {
+		fmt.Println("😥  Could not retrieve URL (but the Cloud Run function has deployed)")
+		return nil
+	}
+	fmt.Println("🔍  API Endpoint: ", url)
+	return nil
+}
+
+// GetEndpoint returns the URL of the Cloud Run service
+func (GoogleCloudRun) GetEndpoint(cfg *config.Config, stg *settings.Settings) (string, error) {
+	return getServiceURL(cfg, stg)
+}
+
+func getServiceURL(cfg *config.Config, stg *settings.Settings) (string, error) {
 	output, err := cli.ExecuteWithResult("gcloud", []string{
 		"run",
 		"services",
@@ -59,8 +72,7 @@ func (GoogleCloudRun) Deploy(directory string, cfg *config.Config, stg *settings
 		"--format", "json",
 	}, "Querying for Cloud Run URL")
 	if err != nil {
-		fmt.Println("😥  Could not retrieve URL (but the Cloud Run function has deployed)")
-		return nil
+		return "", err
 	}
 
 	var results struct {
@@ -69,10 +81,85 @@ func (GoogleCloudRun) Deploy(directory string, cfg *config.Config, stg *settings
 		} `json:"status"`
 	}
 	if err := json.Unmarshal(output, &results); err != nil {
-		fmt.Println("😥  Could not parse response (but the Cloud Run function has deployed)")
-		return nil
+		return "", err
+	}
+	return results.Status.URL, nil
+}
+
+// getProtocolArgs configures the service for the protocol that it serves;
+// streaming protocols need HTTP/2 end-to-end or long-lived, sticky connections
+func getProtocolArgs(cfg *config.Config) ([]string, error) {
+	args := []string{}
+	switch cfg.Config.Protocol {
+	case "", "http1":
+	case "grpc":
+		args = append(args, "--use-http2")
+	case "websocket":
+		args = append(args, "--session-affinity")
+		if cfg.Config.Timeout == 0 {
+			// Connections are closed when the request timeout is reached
+			args = append(args, "--timeout=3600")
+		}
+	default:
+		return nil, fmt.Errorf("unknown protocol: %s (expected http1, grpc, or websocket)", cfg.Config.Protocol)
+	}
+	if cfg.Config.Timeout != 0 {
+		args = append(args, fmt.Sprintf("--timeout=%d", cfg.Config.Timeout))
 	}
+	return args, nil
+}
 
-	fmt.Println("🔍  API Endpoint: ", results.Status.URL)
+const (
+	defaultGPUType = "nvidia-l4"
+	// Cloud Run GPU instances require at least 4 CPUs and 16GiB of memory
+	minimumGPUCPUs   = 4
+	minimumGPUMemory = 16384
+)
+
+func (GoogleCloudRun) ValidateGPU(cfg *config.Config) error {
+	if cfg.Config.Memory != 0 && cfg.Config.Memory < minimumGPUMemory {
+		return fmt.Errorf("cloud run gpus require at least %dMB of memory (config has %dMB)",
+			minimumGPUMemory, cfg.Config.Memory)
+	}
 	return nil
 }
+
+// getResourceArgs sets the memory, CPU, and GPU resources of the service
+func getResourceArgs(cfg *config.Config) []string {
+	args := []string{}
+	memory := cfg.Config.Memory
+	if cfg.Config.GPU {
+		gpuType := cfg.Config.GPUType
+		if gpuType == "" {
+			gpuType = defaultGPUType
+		}
+		if memory == 0 {
+			memory = minimumGPUMemory
+		}
+		args = append(args,
+			"--gpu=1",
+			fmt.Sprintf("--gpu-type=%s", gpuType),
+			fmt.Sprintf("--cpu=%d", minimumGPUCPUs),
+			// GPU instances must always have CPU allocated
+			"--no-cpu-throttling",
+		)
+	}
+	if memory != 0 {
+		args = append(args, fmt.Sprintf("--memory=%dMi", memory))
+	}
+	return args
+}
+
+// PullArtifact pulls the service's container image, by its digest
+func (GoogleCloudRun) PullArtifact(cfg *config.Config, stg *settings.Settings, digest string) (*config.Artifact, error) {
+	return pullImage(cfg, stg, digest)
+}
+
+// Build builds the service's docker container, without deploying it
+func (GoogleCloudRun) Build(cfg *config.Config, stg *settings.Settings) (*config.Artifact, error) {
+	return buildImage(cfg)
+}
+
+func (GoogleCloudRun) GetArtifactKind() string {
+	return artifacts.Image
+}
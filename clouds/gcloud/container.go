@@ -0,0 +1,137 @@
+package gcloud
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+// buildContainer builds the project's docker container with Cloud Build (or
+// pushes the pre-built artifact), and returns the container's tag, pinned
+// to the image's digest
+func buildContainer(cfg *config.Config, stg *settings.Settings) (string, error) {
+	containerTag := fmt.Sprintf("gcr.io/%s/%s", stg.GoogleCloud.ProjectID, cfg.ProjectName)
+	if cfg.Artifact != nil {
+		if err := pushArtifact(containerTag, cfg.Artifact); err != nil {
+			return "", err
+		}
+	} else {
+		if strings.Contains(cfg.Config.Runtime, "go") {
+			_ = cli.Execute("go", []string{
+				"mod",
+				"init",
+			}, "Running go mod init")
+		}
+
+		fmt.Println("🏭  Building: ", cfg.ProjectName, "as a container")
+		// Build the docker container
+		// gcloud builds submit --tag gcr.io/PROJECT-ID/helloworld
+		err := cli.Execute("gcloud", []string{
+			"builds",
+			"submit",
+			"--tag", containerTag,
+		}, "Building docker container")
+		if err != nil {
+			return "", err
+		}
+	}
+
+	digest, err := getImageDigest(containerTag)
+	if err != nil {
+		return "", err
+	}
+	if cfg.Artifact != nil && cfg.Artifact.Digest != "" && cfg.Artifact.Digest != digest {
+		return "", fmt.Errorf("the pushed image (%s) is not the artifact (%s)", digest, cfg.Artifact.Digest)
+	}
+	cfg.Artifact = &config.Artifact{
+		Location: fmt.Sprintf("%s@%s", containerTag, digest),
+		Digest:   digest,
+	}
+	return cfg.Artifact.Location, nil
+}
+
+// buildImage builds the docker container in the current directory, locally
+// rather than with Cloud Build, so that it can be pushed to an artifact store
+func buildImage(cfg *config.Config) (*config.Artifact, error) {
+	imageURI := fmt.Sprintf("%s:kettle-build", cfg.GetBaseProjectName())
+	fmt.Println("🏭  Building: ", imageURI)
+	args := []string{
+		"build",
+		"--platform", "linux/amd64",
+		"--tag", imageURI,
+	}
+	args = append(args, cli.ProxyBuildArgs()...)
+	err := cli.Execute("docker", append(args, "."), "Building docker container")
+	if err != nil {
+		return nil, err
+	}
+	return &config.Artifact{
+		Location: imageURI,
+	}, nil
+}
+
+// pushArtifact tags a pre-built image (which has been pulled), and
+// pushes it to the project's container registry
+func pushArtifact(containerTag string, artifact *config.Artifact) error {
+	fmt.Println("📦  Artifact: ", artifact.Digest)
+	if err := configureDocker(); err != nil {
+		return err
+	}
+	err := cli.Execute("docker", []string{
+		"tag",
+		artifact.Location,
+		containerTag,
+	}, "Tagging docker container")
+	if err != nil {
+		return err
+	}
+	return cli.Execute("docker", []string{
+		"push",
+		containerTag,
+	}, "Pushing docker container")
+}
+
+// pullImage pulls a container image (by its digest) from the project's container registry
+func pullImage(cfg *config.Config, stg *settings.Settings, digest string) (*config.Artifact, error) {
+	if err := configureDocker(); err != nil {
+		return nil, err
+	}
+	imageURI := fmt.Sprintf("gcr.io/%s/%s@%s", stg.GoogleCloud.ProjectID, cfg.ProjectName, digest)
+	err := cli.Execute("docker", []string{
+		"pull",
+		imageURI,
+	}, "Pulling docker container")
+	if err != nil {
+		return nil, err
+	}
+	return &config.Artifact{
+		Location: imageURI,
+		Digest:   digest,
+	}, nil
+}
+
+// configureDocker lets docker authenticate to the container
+// registry with the gcloud cli's credentials
+func configureDocker() error {
+	return cli.Execute("gcloud", []string{
+		"auth",
+		"configure-docker",
+		"--quiet",
+	}, "Configuring docker authentication")
+}
+
+func getImageDigest(containerTag string) (string, error) {
+	output, err := cli.ExecuteWithResult("gcloud", []string{
+		"container",
+		"images",
+		"describe", containerTag,
+		"--format", "value(image_summary.digest)",
+	}, "Retrieving the image digest")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,193 @@
+package gcloud
+
+import (
+	"fmt"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+// destroyOrder is the order in which resources are deleted, so that
+// nothing is deleted while another resource still depends on it
+var destroyOrder = []string{
+	state.GoogleBudget,
+	state.GoogleAlertPolicy,
+	state.GoogleNotification,
+	state.GoogleUptimeCheck,
+	state.GoogleCloudFunction,
+	state.GoogleCloudRunService,
+	state.GoogleCloudRunJob,
+	state.GoogleSecret,
+	state.GoogleFirestore,
+	state.GoogleStorageBucket,
+	state.GoogleRedis,
+}
+
+// Destroy deletes the resources in the project's state
+func (GoogleCloudFunction) Destroy(directory string, cfg *config.Config, stg *settings.Settings) error {
+	return destroy(directory, cfg, stg)
+}
+
+// Destroy deletes the resources in the project's state
+func (GoogleCloudRun) Destroy(directory string, cfg *config.Config, stg *settings.Settings) error {
+	return destroy(directory, cfg, stg)
+}
+
+// Destroy deletes the resources in the project's state
+func (GoogleCloudRunJob) Destroy(directory string, cfg *config.Config, stg *settings.Settings) error {
+	return destroy(directory, cfg, stg)
+}
+
+// PlanDestroy lists the resources that Destroy deletes, and the ones it does not
+func (GoogleCloudFunction) PlanDestroy(directory string, cfg *config.Config) ([]*state.Resource, []*state.Resource, error) {
+	return planDestroy(directory, cfg)
+}
+
+// PlanDestroy lists the resources that Destroy deletes, and the ones it does not
+func (GoogleCloudRun) PlanDestroy(directory string, cfg *config.Config) ([]*state.Resource, []*state.Resource, error) {
+	return planDestroy(directory, cfg)
+}
+
+// PlanDestroy lists the resources that Destroy deletes, and the ones it does not
+func (GoogleCloudRunJob) PlanDestroy(directory string, cfg *config.Config) ([]*state.Resource, []*state.Resource, error) {
+	return planDestroy(directory, cfg)
+}
+
+func planDestroy(directory string, cfg *config.Config) ([]*state.Resource, []*state.Resource, error) {
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return nil, nil, err
+	}
+	deleted, kept := st.PlanDestroy(destroyOrder, cfg.Config.Protected)
+	return deleted, kept, nil
+}
+
+func destroy(directory string, cfg *config.Config, stg *settings.Settings) error {
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return err
+	}
+
+	deleted, kept := st.PlanDestroy(destroyOrder, cfg.Config.Protected)
+	for _, resource := range deleted {
+		err := destroyResource(resource, stg)
+		switch {
+		case cli.IsNotFound(err):
+			// The resource has already been deleted (e.g. by hand)
+			fmt.Println("⏭   Already deleted: ", resource.Type, resource.ID)
+		case err != nil:
+			return err
+		default:
+			fmt.Println("🗑   Deleted: ", resource.Type, resource.ID)
+		}
+		st.RemoveResource(resource.Type, resource.ID)
+		if err := state.WriteState(directory, st); err != nil {
+			return err
+		}
+	}
+
+	for _, resource := range kept {
+		fmt.Println("⏭   Not deleted: ", resource.Describe(cfg.Config.Protected))
+	}
+	return nil
+}
+
+func destroyResource(resource *state.Resource, stg *settings.Settings) error {
+	region := fmt.Sprintf("--region=%s", stg.GoogleCloud.DeploymentRegion)
+	switch resource.Type {
+	case state.GoogleBudget:
+		return cli.Execute("gcloud", []string{
+			"billing",
+			"budgets",
+			"delete", resource.ID,
+			"--quiet",
+		}, "Deleting billing budget")
+	case state.GoogleAlertPolicy:
+		return cli.Execute("gcloud", []string{
+			"alpha",
+			"monitoring",
+			"policies",
+			"delete", resource.ID,
+			"--quiet",
+		}, "Deleting alert policy")
+	case state.GoogleNotification:
+		return cli.Execute("gcloud", []string{
+			"beta",
+			"monitoring",
+			"channels",
+			"delete", resource.ID,
+			"--quiet",
+		}, "Deleting notification channel")
+	case state.GoogleUptimeCheck:
+		return cli.Execute("gcloud", []string{
+			"monitoring",
+			"uptime",
+			"delete", resource.ID,
+			"--quiet",
+		}, "Deleting uptime check")
+	case state.GoogleCloudFunction:
+		return cli.Execute("gcloud", []string{
+			"functions",
+			"delete", resource.ID,
+			region,
+			"--quiet",
+		}, "Deleting Cloud Function")
+	case state.GoogleCloudRunService:
+		return cli.Execute("gcloud", []string{
+			"run",
+			"services",
+			"delete", resource.ID,
+			region,
+			"--quiet",
+		}, "Deleting Cloud Run service")
+	case state.GoogleCloudRunJob:
+		return cli.Execute("gcloud", []string{
+			"run",
+			"jobs",
+			"delete", resource.ID,
+			region,
+			"--quiet",
+		}, "Deleting Cloud Run job")
+	case state.GoogleFirestore:
+		return cli.Execute("gcloud", []string{
+			"firestore",
+			"databases",
+			"delete",
+			fmt.Sprintf("--database=%s", resource.ID),
+			"--quiet",
+		}, "Deleting Firestore database")
+	case state.GoogleStorageBucket:
+		return cli.Execute("gcloud", []string{
+			"storage",
+			"rm",
+			"--recursive",
+			fmt.Sprintf("gs://%s", resource.ID),
+		}, "Deleting Cloud Storage bucket")
+	case state.GoogleRedis:
+		return cli.Execute("gcloud", []string{
+			"redis",
+			"instances",
+			"delete", resource.ID,
+			region,
+			"--quiet",
+		}, "Deleting Memorystore instance")
+	case state.GoogleSecret:
+		return cli.Execute("gcloud", []string{
+			"secrets",
+			"delete", resource.ID,
+			"--quiet",
+		}, "Deleting Secret Manager secret")
+	}
+	return fmt.Errorf("cannot delete resource type: %s", resource.Type)
+}
+
+func recordResource(directory, resourceType, id string) error {
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return err
+	}
+	st.AddResource(resourceType, id, "")
+	return state.WriteState(directory, st)
+}
@@ -0,0 +1,18 @@
+package gcloud
+
+import (
+	"os"
+	"strings"
+)
+
+// UseEndpoints points the gcloud cli at the endpoints in the settings (e.g.
+// Private Service Connect endpoints), with its api_endpoint_overrides
+func UseEndpoints(endpoints map[string]string) {
+	for service, url := range endpoints {
+		variable := "CLOUDSDK_API_ENDPOINT_OVERRIDES_" + strings.ToUpper(service)
+		// Variables that are set in the environment take precedence
+		if _, ok := os.LookupEnv(variable); !ok {
+			os.Setenv(variable, url)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package gcloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+// getEnvironmentArgs returns the --set-env-vars flag for gcloud deployments, and the
+// --set-secrets flag for variables that refer to a secret (secret:<name>:<version>)
+func getEnvironmentArgs(cfg *config.Config) []string {
+	environment := cfg.DeployEnvironment()
+	if len(environment) == 0 {
+		return []string{}
+	}
+
+	variables := []string{}
+	secrets := []string{}
+	for key, value := range environment {
+		if strings.HasPrefix(value, secretReferencePrefix) {
+			secrets = append(secrets, fmt.Sprintf("%s=%s", key, strings.TrimPrefix(value, secretReferencePrefix)))
+			continue
+		}
+		variables = append(variables, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(variables)
+	sort.Strings(secrets)
+
+	args := []string{}
+	if len(variables) > 0 {
+		// Use a custom delimiter, in case any of the values contain commas
+		args = append(args, fmt.Sprintf("--set-env-vars=^;^%s", strings.Join(variables, ";")))
+	}
+	if len(secrets) > 0 {
+		args = append(args, fmt.Sprintf("--set-secrets=%s", strings.Join(secrets, ",")))
+	}
+	return args
+}
+
+// runEnvironmentVariable is an environment variable of a Cloud Run container,
+// which is either a value or a reference to a Secret Manager secret
+type runEnvironmentVariable struct {
+	Name      string `json:"name"`
+	Value     string `json:"value"`
+	ValueFrom *struct {
+		SecretKeyRef *struct {
+			Name string `json:"name"`
+			Key  string `json:"key"`
+		} `json:"secretKeyRef"`
+	} `json:"valueFrom"`
+}
+
+type runContainers struct {
+	Containers []struct {
+		Env []*runEnvironmentVariable `json:"env"`
+	} `json:"containers"`
+}
+
+// GetEnvironment returns the environment variables of the deployed service
+func (GoogleCloudRun) GetEnvironment(cfg *config.Config, stg *settings.Settings) (map[string]string, error) {
+	return getRunEnvironment("services", cfg, stg)
+}
+
+// UpdateEnvironment sets (and removes) environment variables of the deployed service
+func (GoogleCloudRun) UpdateEnvironment(cfg *config.Config, stg *settings.Settings, set map[string]string, unset []string) error {
+	return updateRunEnvironment("services", cfg, stg, set, unset)
+}
+
+// GetEnvironment returns the environment variables of the deployed job
+func (GoogleCloudRunJob) GetEnvironment(cfg *config.Config, stg *settings.Settings) (map[string]string, error) {
+	return getRunEnvironment("jobs", cfg, stg)
+}
+
+// UpdateEnvironment sets (and removes) environment variables of the deployed job
+func (GoogleCloudRunJob) UpdateEnvironment(cfg *config.Config, stg *settings.Settings, set map[string]string, unset []string) error {
+	return updateRunEnvironment("jobs", cfg, stg, set, unset)
+}
+
+// getRunEnvironment returns the environment variables of a Cloud Run service or
+// job; variables that reference a secret have the value secret:<name>:<version>
+func getRunEnvironment(resource string, cfg *config.Config, stg *settings.Settings) (map[string]string, error) {
+	output, err := cli.ExecuteWithResult("gcloud", []string{
+		"run",
+		resource,
+		"describe", cfg.ProjectName,
+		fmt.Sprintf("--region=%s", stg.GoogleCloud.DeploymentRegion),
+		"--format", "json",
+	}, "Retrieving the environment")
+	if err != nil {
+		return nil, err
+	}
+
+	// Jobs have a task template inside of their execution template
+	var result struct {
+		Spec struct {
+			Template struct {
+				Spec     runContainers `json:"spec"`
+				Template *struct {
+					Spec runContainers `json:"spec"`
+				} `json:"template"`
+			} `json:"template"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, err
+	}
+	spec := result.Spec.Template.Spec
+	if result.Spec.Template.Template != nil {
+		spec = result.Spec.Template.Template.Spec
+	}
+
+	environment := map[string]string{}
+	for _, container := range spec.Containers {
+		for _, variable := range container.Env {
+			environment[variable.Name] = variable.Value
+			if variable.ValueFrom != nil && variable.ValueFrom.SecretKeyRef != nil {
+				environment[variable.Name] = fmt.Sprintf("%s%s:%s", secretReferencePrefix,
+					variable.ValueFrom.SecretKeyRef.Name,
+					variable.ValueFrom.SecretKeyRef.Key,
+				)
+			}
+		}
+	}
+	return environment, nil
+}
+
+func updateRunEnvironment(resource string, cfg *config.Config, stg *settings.Settings, set map[string]string, unset []string) error {
+	args := []string{
+		"run",
+		resource,
+		"update", cfg.ProjectName,
+		fmt.Sprintf("--region=%s", stg.GoogleCloud.DeploymentRegion),
+	}
+	if len(set) != 0 {
+		variables := []string{}
+		for key, value := range set {
+			variables = append(variables, fmt.Sprintf("%s=%s", key, value))
+		}
+		sort.Strings(variables)
+		args = append(args, fmt.Sprintf("--update-env-vars=^;^%s", strings.Join(variables, ";")))
+	}
+	if len(unset) != 0 {
+		args = append(args, fmt.Sprintf("--remove-env-vars=%s", strings.Join(unset, ",")))
+	}
+	return cli.Execute("gcloud", args, "Updating the environment")
+}
@@ -0,0 +1,29 @@
+package gcloud
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+// GetCommandEnvironment returns the environment that the gcloud cli is
+// run with by kettle exec, so that it uses the stage's project and region
+func GetCommandEnvironment(cfg *config.Config, stg *settings.Settings) map[string]string {
+	labels := []string{}
+	for key, value := range getLabels(cfg) {
+		labels = append(labels, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(labels)
+	return map[string]string{
+		"CLOUDSDK_CORE_PROJECT":     stg.GoogleCloud.ProjectID,
+		"CLOUDSDK_RUN_REGION":       stg.GoogleCloud.DeploymentRegion,
+		"CLOUDSDK_FUNCTIONS_REGION": stg.GoogleCloud.DeploymentRegion,
+		"CLOUDSDK_COMPUTE_REGION":   stg.GoogleCloud.DeploymentRegion,
+		"KETTLE_NAME":               cfg.ProjectName,
+		"KETTLE_REGION":             stg.GoogleCloud.DeploymentRegion,
+		"KETTLE_TAGS":               strings.Join(labels, ","),
+	}
+}
@@ -0,0 +1,76 @@
+package gcloud
+
+import (
+	"fmt"
+
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+// explanations are the kind of each resource that kettle creates on Google
+// Cloud, and why it exists, for users who have not used the service before
+var explanations = map[string][2]string{
+	state.GoogleCloudFunction:   {"Cloud Function", "runs the project's code when its URL is requested"},
+	state.GoogleCloudRunService: {"Cloud Run service", "runs the project's container, and serves its URL"},
+	state.GoogleCloudRunJob:     {"Cloud Run job", "runs the project's container to completion when it is executed"},
+	state.GoogleFirestore:       {"Firestore database", "the project's document database add-on"},
+	state.GoogleStorageBucket:   {"Cloud Storage bucket", "stores the project's files (e.g. static assets, or an add-on's data)"},
+	state.GoogleBackendBucket:   {"Backend bucket", "lets the load balancer serve the static site from its bucket, with Cloud CDN"},
+	state.GoogleURLMap:          {"URL map", "routes the load balancer's requests to the static site"},
+	state.GoogleHTTPProxy:       {"Target HTTP proxy", "receives the load balancer's requests, and applies the URL map"},
+	state.GoogleForwardingRule:  {"Forwarding rule", "the static site's public IP address"},
+	state.GoogleRedis:           {"Memorystore instance", "the project's Redis add-on"},
+	state.GoogleUptimeCheck:     {"Uptime check", "runs the smoke test against the endpoint on a schedule"},
+	state.GoogleAlertPolicy:     {"Alert policy", "alerts when the uptime check fails"},
+	state.GoogleNotification:    {"Notification channel", "where alerts are sent (e.g. an email address)"},
+	state.GoogleBudget:          {"Billing budget", "alerts when the project's monthly spend reaches its budget"},
+	state.GoogleSecret:          {"Secret Manager secret", "stores a secret from Vault, which is passed to the service as an environment variable"},
+}
+
+// ExplainResource describes a resource that kettle has created,
+// with a link to it in the Google Cloud console
+func ExplainResource(resource *state.Resource, stg *settings.Settings) *config.Explanation {
+	explanation, ok := explanations[resource.Type]
+	if !ok {
+		return &config.Explanation{Kind: resource.Type}
+	}
+	return &config.Explanation{
+		Kind:       explanation[0],
+		Reason:     explanation[1],
+		ConsoleURL: consoleURL(resource, stg.GoogleCloud.DeploymentRegion, stg.GoogleCloud.ProjectID),
+	}
+}
+
+func consoleURL(resource *state.Resource, region, projectID string) string {
+	var path string
+	switch resource.Type {
+	case state.GoogleCloudFunction:
+		path = fmt.Sprintf("functions/details/%s/%s", region, resource.ID)
+	case state.GoogleCloudRunService:
+		path = fmt.Sprintf("run/detail/%s/%s", region, resource.ID)
+	case state.GoogleCloudRunJob:
+		path = fmt.Sprintf("run/jobs/details/%s/%s", region, resource.ID)
+	case state.GoogleFirestore:
+		path = fmt.Sprintf("firestore/databases/%s", resource.ID)
+	case state.GoogleStorageBucket:
+		path = fmt.Sprintf("storage/browser/%s", resource.ID)
+	case state.GoogleBackendBucket, state.GoogleURLMap, state.GoogleHTTPProxy, state.GoogleForwardingRule:
+		path = "net-services/loadbalancing/list/loadBalancers"
+	case state.GoogleRedis:
+		path = fmt.Sprintf("memorystore/redis/locations/%s/instances/%s/details", region, resource.ID)
+	case state.GoogleUptimeCheck:
+		path = "monitoring/uptime"
+	case state.GoogleAlertPolicy:
+		path = "monitoring/alerting"
+	case state.GoogleNotification:
+		path = "monitoring/alerting/notifications"
+	case state.GoogleBudget:
+		path = "billing/budgets"
+	case state.GoogleSecret:
+		path = fmt.Sprintf("security/secret-manager/secret/%s/versions", resource.ID)
+	default:
+		return ""
+	}
+	return fmt.Sprintf("https://console.cloud.google.com/%s?project=%s", path, projectID)
+}
@@ -6,21 +6,21 @@ import (
 	"github.com/operatorai/kettle-cli/cli"
 	"github.com/operatorai/kettle-cli/config"
 	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
 )
 
 type GoogleCloudFunction struct{}
 
 // https://cloud.google.com/sdk/gcloud/reference/functions/deploy
 func (GoogleCloudFunction) Deploy(directory string, cfg *config.Config, stg *settings.Settings) error {
+	if !cfg.IsHTTP1() {
+		return fmt.Errorf("the %s protocol is only supported on container targets", cfg.Config.Protocol)
+	}
 	fmt.Println("🚢  Deploying ", cfg.ProjectName, "as a Google Cloud function")
 	fmt.Println("⏭  Entry point: ", cfg.Config.EntryFunction, fmt.Sprintf("(%s)", cfg.Config.Runtime))
 
-	fmt.Println(fmt.Sprintf("🔍  https://%s-%s.cloudfunctions.net/%s",
-		stg.GoogleCloud.DeploymentRegion,
-		stg.GoogleCloud.ProjectID,
-		cfg.ProjectName,
-	))
-	return cli.Execute("gcloud", []string{
+	fmt.Println("🔍 ", getFunctionURL(cfg, stg))
+	args := []string{
 		"functions",
 		"deploy",
 		cfg.ProjectName,
@@ -28,6 +28,27 @@ func (GoogleCloudFunction) Deploy(directory string, cfg *config.Config, stg *set
 		"--trigger-http",
 		fmt.Sprintf("--entry-point=%s", cfg.Config.EntryFunction),
 		fmt.Sprintf("--region=%s", stg.GoogleCloud.DeploymentRegion),
-		"--allow-unauthenticated",
-	}, "Deploying Cloud Function")
+	}
+	args = append(args, getAuthArgs(cfg)...)
+	args = append(args, getEnvironmentArgs(cfg)...)
+	args = append(args, getNetworkArgs(cfg)...)
+	args = append(args, getLabelArgs(cfg)...)
+	err := cli.Execute("gcloud", args, "Deploying Cloud Function")
+	if err != nil {
+		return err
+	}
+	return recordResource(directory, state.GoogleCloudFunction, cfg.ProjectName)
+}
+
+// GetEndpoint returns the URL of the function's HTTP trigger
+func (GoogleCloudFunction) GetEndpoint(cfg *config.Config, stg *settings.Settings) (string, error) {
+	return getFunctionURL(cfg, stg), nil
+}
+
+func getFunctionURL(cfg *config.Config, stg *settings.Settings) string {
+	return fmt.Sprintf("https://%s-%s.cloudfunctions.net/%s",
+		stg.GoogleCloud.DeploymentRegion,
+		stg.GoogleCloud.ProjectID,
+		cfg.ProjectName,
+	)
 }
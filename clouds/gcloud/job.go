@@ -0,0 +1,187 @@
+package gcloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/operatorai/kettle-cli/artifacts"
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+const (
+	jobPollInterval = 5 * time.Second
+)
+
+type GoogleCloudRunJob struct{}
+
+// https://cloud.google.com/sdk/gcloud/reference/run/jobs/deploy
+func (GoogleCloudRunJob) Deploy(directory string, cfg *config.Config, stg *settings.Settings) error {
+	containerTag, err := buildContainer(cfg, stg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("🚢  Deploying ", cfg.ProjectName, "as a Cloud Run job")
+	args := []string{
+		"run",
+		"jobs",
+		"deploy",
+		cfg.ProjectName,
+		"--image", containerTag,
+		fmt.Sprintf("--region=%s", stg.GoogleCloud.DeploymentRegion),
+	}
+	if cfg.Config.Memory != 0 {
+		args = append(args, fmt.Sprintf("--memory=%dMi", cfg.Config.Memory))
+	}
+	if cfg.Config.Timeout != 0 {
+		args = append(args, fmt.Sprintf("--task-timeout=%ds", cfg.Config.Timeout))
+	}
+	args = append(args, getEnvironmentArgs(cfg)...)
+	args = append(args, getNetworkArgs(cfg)...)
+	args = append(args, getLabelArgs(cfg)...)
+	if err := cli.Execute("gcloud", args, "Deploying Cloud Run job"); err != nil {
+		return err
+	}
+	if err := recordResource(directory, state.GoogleCloudRunJob, cfg.ProjectName); err != nil {
+		return err
+	}
+
+	fmt.Println("💡  Run the job with: kettle run-job", cfg.ProjectName)
+	return nil
+}
+
+// RunJob executes the job and streams its logs until it completes
+// https://cloud.google.com/sdk/gcloud/reference/run/jobs/execute
+func (GoogleCloudRunJob) RunJob(cfg *config.Config, stg *settings.Settings, args []string, environment map[string]string) error {
+	executeArgs := []string{
+		"run",
+		"jobs",
+		"execute",
+		cfg.ProjectName,
+		fmt.Sprintf("--region=%s", stg.GoogleCloud.DeploymentRegion),
+		"--format", "json",
+	}
+	// Use a custom delimiter, in case any of the values contain commas
+	if len(args) > 0 {
+		executeArgs = append(executeArgs, fmt.Sprintf("--args=^;^%s", strings.Join(args, ";")))
+	}
+	if len(environment) > 0 {
+		variables := []string{}
+		for key, value := range environment {
+			variables = append(variables, fmt.Sprintf("%s=%s", key, value))
+		}
+		sort.Strings(variables)
+		executeArgs = append(executeArgs, fmt.Sprintf("--update-env-vars=^;^%s", strings.Join(variables, ";")))
+	}
+
+	output, err := cli.ExecuteWithResult("gcloud", executeArgs, "Starting Cloud Run job")
+	if err != nil {
+		return err
+	}
+	var execution struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(output, &execution); err != nil {
+		return err
+	}
+
+	fmt.Println("🏃  Execution: ", execution.Metadata.Name)
+	lastTimestamp := ""
+	for {
+		completed, failed, err := getExecutionStatus(execution.Metadata.Name, stg)
+		if err != nil {
+			return err
+		}
+		lastTimestamp, err = printExecutionLogs(execution.Metadata.Name, lastTimestamp)
+		if err != nil {
+			return err
+		}
+		if completed {
+			if failed > 0 {
+				return fmt.Errorf("%d task(s) failed in execution %s", failed, execution.Metadata.Name)
+			}
+			return nil
+		}
+		time.Sleep(jobPollInterval)
+	}
+}
+
+func getExecutionStatus(executionName string, stg *settings.Settings) (bool, int, error) {
+	output, err := cli.ExecuteWithResult("gcloud", []string{
+		"run",
+		"jobs",
+		"executions",
+		"describe",
+		executionName,
+		fmt.Sprintf("--region=%s", stg.GoogleCloud.DeploymentRegion),
+		"--format", "json",
+	}, "Waiting for job to complete")
+	if err != nil {
+		return false, 0, err
+	}
+
+	var result struct {
+		Status struct {
+			CompletionTime string `json:"completionTime"`
+			FailedCount    int    `json:"failedCount"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return false, 0, err
+	}
+	return result.Status.CompletionTime != "", result.Status.FailedCount, nil
+}
+
+// printExecutionLogs prints any log entries that were written after lastTimestamp,
+// and returns the timestamp of the latest entry
+func printExecutionLogs(executionName, lastTimestamp string) (string, error) {
+	filter := fmt.Sprintf(`resource.type="cloud_run_job" AND labels."run.googleapis.com/execution_name"="%s"`, executionName)
+	if lastTimestamp != "" {
+		filter = fmt.Sprintf(`%s AND timestamp>"%s"`, filter, lastTimestamp)
+	}
+	output, err := cli.ExecuteWithResult("gcloud", []string{
+		"logging",
+		"read",
+		filter,
+		"--order", "asc",
+		"--format", "json",
+	}, "Reading job logs")
+	if err != nil {
+		return lastTimestamp, err
+	}
+
+	var entries []struct {
+		Timestamp   string `json:"timestamp"`
+		TextPayload string `json:"textPayload"`
+	}
+	if err := json.Unmarshal(output, &entries); err != nil {
+		return lastTimestamp, err
+	}
+	for _, entry := range entries {
+		fmt.Println(entry.TextPayload)
+		lastTimestamp = entry.Timestamp
+	}
+	return lastTimestamp, nil
+}
+
+// PullArtifact pulls the job's container image, by its digest
+func (GoogleCloudRunJob) PullArtifact(cfg *config.Config, stg *settings.Settings, digest string) (*config.Artifact, error) {
+	return pullImage(cfg, stg, digest)
+}
+
+// Build builds the job's docker container, without deploying it
+func (GoogleCloudRunJob) Build(cfg *config.Config, stg *settings.Settings) (*config.Artifact, error) {
+	return buildImage(cfg)
+}
+
+func (GoogleCloudRunJob) GetArtifactKind() string {
+	return artifacts.Image
+}
@@ -0,0 +1,40 @@
+package gcloud
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/config"
+)
+
+var invalidLabelCharacters = regexp.MustCompile(`[^a-z0-9_\-]`)
+
+// getLabelArgs adds the project's tags to a resource as labels
+func getLabelArgs(cfg *config.Config) []string {
+	labels := []string{}
+	for key, value := range getLabels(cfg) {
+		labels = append(labels, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(labels)
+	return []string{fmt.Sprintf("--update-labels=%s", strings.Join(labels, ","))}
+}
+
+// getLabels returns the project's tags as labels, whose values may
+// only contain lowercase letters, numbers, underscores, and dashes
+func getLabels(cfg *config.Config) map[string]string {
+	labels := map[string]string{}
+	for key, value := range cfg.Tags() {
+		labels[key] = labelValue(value)
+	}
+	return labels
+}
+
+func labelValue(value string) string {
+	value = invalidLabelCharacters.ReplaceAllString(strings.ToLower(value), "-")
+	if len(value) > 63 {
+		value = value[:63]
+	}
+	return value
+}
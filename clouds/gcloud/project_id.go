@@ -3,6 +3,7 @@ package gcloud
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/operatorai/kettle-cli/cli"
 	"github.com/operatorai/kettle-cli/settings"
@@ -61,3 +62,22 @@ func getGoogleCloudProjects() (map[string]string, error) {
 	}
 	return projectIDs, nil
 }
+
+// GetAccount describes the project that the gcloud cli is configured to
+// use, which is the project that kettle deploys to
+func GetAccount(stg *settings.GoogleCloudSettings) (string, error) {
+	output, err := cli.ExecuteWithResult("gcloud", []string{
+		"config",
+		"get-value",
+		"project",
+	}, "Retrieving the gcloud project")
+	if err != nil {
+		return "", err
+	}
+	projectID := strings.TrimSpace(string(output))
+	if projectID != stg.ProjectID {
+		fmt.Println("⚠️   The gcloud cli is configured for a different project than kettle's settings:", stg.ProjectID)
+		return projectID, nil
+	}
+	return fmt.Sprintf("%s (%s)", stg.ProjectName, projectID), nil
+}
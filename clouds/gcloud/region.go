@@ -5,36 +5,67 @@ import (
 	"fmt"
 
 	"github.com/operatorai/kettle-cli/cli"
-	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/config"
 )
 
-func SetDeploymentRegion(stg *settings.GoogleCloudSettings) error {
-	if stg.DeploymentRegion != "" {
-		return nil
+// GetRegions returns the regions where all of the project's services are available
+func GetRegions(cfg *config.Config) (map[string]string, error) {
+	if cfg == nil {
+		return getServiceRegions("functions")
 	}
-
-	regions, err := getGoogleCloudRegions()
-	if err != nil {
-		return err
+	var regions map[string]string
+	for _, service := range getServices(cfg) {
+		serviceRegions, err := getServiceRegions(service)
+		if err != nil {
+			return nil, err
+		}
+		if regions == nil {
+			regions = serviceRegions
+			continue
+		}
+		// Regions are displayed with their names, but keyed by ID
+		available := map[string]bool{}
+		for _, region := range serviceRegions {
+			available[region] = true
+		}
+		for displayName, region := range regions {
+			if !available[region] {
+				delete(regions, displayName)
+			}
+		}
 	}
-
-	region, err := cli.PromptForValue("Deployment region", regions, false)
-	if err != nil {
-		return err
+	if len(regions) == 0 {
+		return nil, fmt.Errorf("no region supports all of the project's services: %v", getServices(cfg))
 	}
+	return regions, nil
+}
 
-	stg.DeploymentRegion = region
-	return nil
+// getServices returns the gcloud command groups of the project's
+// services, which each have a regions list command
+func getServices(cfg *config.Config) []string {
+	services := []string{}
+	switch cfg.Config.DeploymentType {
+	case "function":
+		services = append(services, "functions")
+	case "run", "job":
+		services = append(services, "run")
+	}
+	for _, addOn := range cfg.Config.AddOns {
+		if addOn.Type == "redis" {
+			services = append(services, "redis")
+		}
+	}
+	return services
 }
 
-// gcloud functions regions list --format="json"
-func getGoogleCloudRegions() (map[string]string, error) {
-	output, err := cli.ExecuteWithResult("gcloud", []string{
-		"functions",
+// gcloud functions regions list --format=json
+func getServiceRegions(service string) (map[string]string, error) {
+	output, err := cli.ExecuteCached("gcloud", []string{
+		service,
 		"regions",
 		"list",
-		"--format=\"json\"",
-	}, "Collecting gcloud regions")
+		"--format", "json",
+	}, fmt.Sprintf("Collecting regions where %s is available", service))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,131 @@
+package gcloud
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+// secretReferencePrefix is the prefix of environment variables that refer to
+// a Secret Manager secret (secret:<name>:<version>), rather than being a value
+const secretReferencePrefix = "secret:"
+
+func (GoogleCloudFunction) ValidateSecrets(cfg *config.Config) error {
+	return cfg.ValidateSecrets()
+}
+
+func (GoogleCloudRun) ValidateSecrets(cfg *config.Config) error {
+	return cfg.ValidateSecrets()
+}
+
+func (GoogleCloudRunJob) ValidateSecrets(cfg *config.Config) error {
+	return cfg.ValidateSecrets()
+}
+
+// SyncSecrets stores the project's secrets in Secret Manager (as <project>-<name>),
+// and grants the service's service account access to read them; the service's
+// environment variables are set to the secrets' latest versions when it is
+// deployed. Secrets that are no longer in the config are deleted
+func SyncSecrets(directory string, cfg *config.Config, stg *settings.Settings, secrets map[string]string) (map[string]string, error) {
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return nil, err
+	}
+	serviceAccount, err := getServiceAccount(cfg, stg)
+	if err != nil {
+		return nil, err
+	}
+
+	environment := map[string]string{}
+	names := []string{}
+	for key := range secrets {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+	for _, key := range names {
+		secretName := getSecretName(cfg, key)
+		if err := putSecret(secretName, secrets[key], cfg); err != nil {
+			return nil, err
+		}
+		if st.GetResource(state.GoogleSecret, secretName) == nil {
+			err := cli.Execute("gcloud", []string{
+				"secrets",
+				"add-iam-policy-binding", secretName,
+				fmt.Sprintf("--member=serviceAccount:%s", serviceAccount),
+				"--role=roles/secretmanager.secretAccessor",
+			}, "Granting the service account access to the secret")
+			if err != nil {
+				return nil, err
+			}
+			st.AddResource(state.GoogleSecret, secretName, "")
+		}
+		environment[key] = fmt.Sprintf("%s%s:latest", secretReferencePrefix, secretName)
+		fmt.Println("🔐  Secret: ", key, fmt.Sprintf("(%s)", secretName))
+	}
+
+	// Delete the secrets that were removed from the config
+	for _, resource := range st.GetResources(state.GoogleSecret) {
+		if _, ok := environment[strings.TrimPrefix(resource.ID, getSecretName(cfg, ""))]; ok {
+			continue
+		}
+		if err := destroyResource(resource, stg); err != nil && !cli.IsNotFound(err) {
+			return nil, err
+		}
+		fmt.Println("🗑   Deleted: ", resource.Type, resource.ID)
+		st.RemoveResource(resource.Type, resource.ID)
+	}
+	if err := state.WriteState(directory, st); err != nil {
+		return nil, err
+	}
+	return environment, nil
+}
+
+// getSecretName returns the name of a project's secret in Secret Manager,
+// whose names may only contain letters, numbers, dashes, and underscores
+func getSecretName(cfg *config.Config, key string) string {
+	return fmt.Sprintf("%s-%s", cfg.ProjectName, key)
+}
+
+// putSecret creates a secret, or adds a new version of its value if it has changed
+func putSecret(secretName, value string, cfg *config.Config) error {
+	// Values are passed on stdin, so that they are not command line arguments
+	current, err := cli.ExecuteWithResult("gcloud", []string{
+		"secrets",
+		"versions",
+		"access", "latest",
+		fmt.Sprintf("--secret=%s", secretName),
+	}, fmt.Sprintf("Looking for the secret: %s", secretName))
+	if err == nil {
+		if string(current) == value {
+			return nil
+		}
+		_, err = cli.ExecuteWithInput("gcloud", []string{
+			"secrets",
+			"versions",
+			"add", secretName,
+			"--data-file=-",
+		}, []byte(value), fmt.Sprintf("Updating the secret: %s", secretName))
+		return err
+	}
+	if !cli.IsNotFound(err) {
+		return err
+	}
+	labels := []string{}
+	for key, value := range getLabels(cfg) {
+		labels = append(labels, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(labels)
+	_, err = cli.ExecuteWithInput("gcloud", []string{
+		"secrets",
+		"create", secretName,
+		"--replication-policy=automatic",
+		fmt.Sprintf("--labels=%s", strings.Join(labels, ",")),
+		"--data-file=-",
+	}, []byte(value), fmt.Sprintf("Creating the secret: %s", secretName))
+	return err
+}
@@ -0,0 +1,24 @@
+package gcloud
+
+import (
+	"os"
+
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+// UseStageAccount points the gcloud cli at the stage's project;
+// a nil stage uses the project that the gcloud cli is configured with
+func UseStageAccount(stage *config.Stage, stg *settings.Settings) error {
+	if stage == nil || stage.ProjectID == "" {
+		return os.Unsetenv("CLOUDSDK_CORE_PROJECT")
+	}
+	if stg.GoogleCloud == nil {
+		stg.GoogleCloud = &settings.GoogleCloudSettings{}
+	}
+	if stg.GoogleCloud.ProjectName == "" {
+		stg.GoogleCloud.ProjectName = stage.ProjectID
+	}
+	stg.GoogleCloud.ProjectID = stage.ProjectID
+	return os.Setenv("CLOUDSDK_CORE_PROJECT", stage.ProjectID)
+}
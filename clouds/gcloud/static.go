@@ -0,0 +1,154 @@
+package gcloud
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+// DeployStaticSite syncs the project's static assets to a public Cloud Storage
+// bucket; with cdn enabled, the bucket is served by a load balancer with
+// Cloud CDN, whose cache is invalidated on every redeploy
+func DeployStaticSite(directory string, cfg *config.Config, stg *settings.Settings) error {
+	st, err := state.ReadState(directory)
+	if err != nil {
+		return err
+	}
+
+	bucket := cfg.Config.Static.Bucket
+	if bucket == "" {
+		bucket = fmt.Sprintf("%s-static-%s", cfg.ProjectName, stg.GoogleCloud.ProjectID)
+	}
+	bucketURI := fmt.Sprintf("gs://%s", bucket)
+	if st.GetResource(state.GoogleStorageBucket, bucket) == nil {
+		if err := createPublicBucket(bucketURI, stg); err != nil {
+			return err
+		}
+		st.AddResource(state.GoogleStorageBucket, bucket, "")
+		if err := state.WriteState(directory, st); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println("🖼   Uploading static assets from: ", cfg.Config.Static.Directory)
+	err = cli.Execute("gcloud", []string{
+		"storage",
+		"rsync",
+		path.Join(directory, cfg.Config.Static.Directory),
+		bucketURI,
+		"--recursive",
+		"--delete-unmatched-destination-objects",
+	}, "Uploading static assets")
+	if err != nil {
+		return err
+	}
+
+	if !cfg.Config.Static.CDN {
+		fmt.Println("🔍  Static site: ", fmt.Sprintf("https://storage.googleapis.com/%s/index.html", bucket))
+		return nil
+	}
+
+	urlMap := fmt.Sprintf("%s-static", cfg.ProjectName)
+	if st.GetResource(state.GoogleURLMap, urlMap) != nil {
+		err := cli.Execute("gcloud", []string{
+			"compute",
+			"url-maps",
+			"invalidate-cdn-cache", urlMap,
+			"--path", "/*",
+			"--async",
+		}, "Invalidating the CDN cache")
+		if err != nil {
+			return err
+		}
+	} else if err := createCDN(directory, bucket, urlMap, st); err != nil {
+		return err
+	}
+
+	output, err := cli.ExecuteWithResult("gcloud", []string{
+		"compute",
+		"forwarding-rules",
+		"describe", urlMap,
+		"--global",
+		"--format", "value(IPAddress)",
+	}, "Retrieving the CDN IP address")
+	if err != nil {
+		return err
+	}
+	fmt.Println("🔍  Static site: ", fmt.Sprintf("http://%s", strings.TrimSpace(string(output))))
+	return nil
+}
+
+func createPublicBucket(bucketURI string, stg *settings.Settings) error {
+	err := cli.Execute("gcloud", []string{
+		"storage",
+		"buckets",
+		"create", bucketURI,
+		fmt.Sprintf("--location=%s", stg.GoogleCloud.DeploymentRegion),
+		"--uniform-bucket-level-access",
+	}, "Creating a Cloud Storage bucket")
+	if err != nil {
+		return err
+	}
+	err = cli.Execute("gcloud", []string{
+		"storage",
+		"buckets",
+		"update", bucketURI,
+		"--web-main-page-suffix=index.html",
+		"--web-error-page=404.html",
+	}, "Configuring the bucket as a website")
+	if err != nil {
+		return err
+	}
+	return cli.Execute("gcloud", []string{
+		"storage",
+		"buckets",
+		"add-iam-policy-binding", bucketURI,
+		"--member=allUsers",
+		"--role=roles/storage.objectViewer",
+	}, "Allowing public reads from the bucket")
+}
+
+// createCDN puts the bucket behind an external HTTP load balancer with Cloud CDN
+func createCDN(directory, bucket, name string, st *state.State) error {
+	steps := []struct {
+		resourceType  string
+		args          []string
+		statusMessage string
+	}{
+		{
+			state.GoogleBackendBucket,
+			[]string{"compute", "backend-buckets", "create", name, fmt.Sprintf("--gcs-bucket-name=%s", bucket), "--enable-cdn"},
+			"Creating CDN backend bucket",
+		},
+		{
+			state.GoogleURLMap,
+			[]string{"compute", "url-maps", "create", name, fmt.Sprintf("--default-backend-bucket=%s", name)},
+			"Creating load balancer URL map",
+		},
+		{
+			state.GoogleHTTPProxy,
+			[]string{"compute", "target-http-proxies", "create", name, fmt.Sprintf("--url-map=%s", name)},
+			"Creating load balancer proxy",
+		},
+		{
+			state.GoogleForwardingRule,
+			[]string{"compute", "forwarding-rules", "create", name, "--global", fmt.Sprintf("--target-http-proxy=%s", name), "--ports=80"},
+			"Creating load balancer forwarding rule",
+		},
+	}
+	for _, step := range steps {
+		if err := cli.Execute("gcloud", step.args, step.statusMessage); err != nil {
+			return err
+		}
+		st.AddResource(step.resourceType, name, "")
+		if err := state.WriteState(directory, st); err != nil {
+			return err
+		}
+	}
+	return nil
+}
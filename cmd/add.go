@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/clouds"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/tempdir"
+	"github.com/operatorai/kettle-cli/templates"
+)
+
+var (
+	addTemplate      string
+	addWorkspace     string
+	addTriggerPrefix string
+	addTriggerSuffix string
+	// addQueueRetries sets up a dead-letter queue for queue triggers
+	addQueueRetries    int
+	addQueueAlarmEmail string
+)
+
+var addCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a function to a workspace, or a trigger to a deployed function",
+}
+
+var addFunctionCmd = &cobra.Command{
+	Use:   "function <name>",
+	Short: "Add a function to a workspace, from a template",
+	Long: `🆕 The kettle CLI tool can create a function from a template in a
+ subdirectory of an existing workspace, and register it in the
+ workspace's kettle.workspace.json.`,
+	Args: validateAddFunctionArgs,
+	RunE: runAddFunction,
+}
+
+var addTriggerCmd = &cobra.Command{
+	Use:   "trigger <schedule|queue|s3> <value> <path>",
+	Short: "Add a trigger to a deployed function",
+	Long: `⏰ The kettle CLI tool can add a schedule (e.g. "rate(1 hour)"), a queue,
+ or an S3 bucket as a trigger for a function that has been deployed, and
+ wire it to the function without redeploying its code.`,
+	Example: `  kettle add trigger schedule "rate(1 hour)" ./reports
+  kettle add trigger queue orders ./worker --retries 3 --alarm-email oncall@example.com`,
+	Args: validateAddTriggerArgs,
+	RunE: runAddTrigger,
+}
+
+func init() {
+	addFunctionCmd.Flags().StringVar(&addTemplate, "template", "", "The template to create the function from")
+	addFunctionCmd.Flags().StringVar(&addWorkspace, "workspace", ".", "The workspace directory to add the function to")
+	addTriggerCmd.Flags().StringVar(&addTriggerPrefix, "prefix", "", "Only trigger on objects with this key prefix (s3 triggers)")
+	addTriggerCmd.Flags().StringVar(&addTriggerSuffix, "suffix", "", "Only trigger on objects with this key suffix (s3 triggers)")
+	addTriggerCmd.Flags().IntVar(&addQueueRetries, "retries", 0, "Retry failed messages this many times, then move them to a dead-letter queue with an alarm (queue triggers)")
+	addTriggerCmd.Flags().StringVar(&addQueueAlarmEmail, "alarm-email", "", "Email the dead-letter queue's alarm to this address (queue triggers)")
+	addTriggerCmd.Flags().BoolVar(&previewStage, "preview", false, "Add the trigger to the preview of the current pull request or git branch")
+	addCmd.AddCommand(addFunctionCmd)
+	addCmd.AddCommand(addTriggerCmd)
+	rootCmd.AddCommand(addCmd)
+}
+
+func validateAddFunctionArgs(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return errors.New("please specify the function's name")
+	}
+	if addTemplate == "" {
+		return errors.New("please specify a template (--template)")
+	}
+	return nil
+}
+
+// runAddFunction creates a function from a template in a subdirectory
+// of the workspace, and adds it to the workspace's config
+func runAddFunction(cmd *cobra.Command, args []string) error {
+	functionName := strcase.ToKebab(args[0])
+	workspace, err := config.ReadWorkspace(addWorkspace)
+	if err != nil {
+		return formatError(err)
+	}
+	if workspace.GetFunction(functionName) != nil {
+		return formatError(fmt.Errorf("the workspace already has a function named: %s", functionName))
+	}
+
+	// Get the directory where the template is (or has been cloned to)
+	templatePath, isTempDir, err := templates.GetTemplate(addTemplate)
+	if err != nil {
+		return formatError(err)
+	}
+	if isTempDir {
+		defer tempdir.Remove(templatePath)
+	}
+	templateConfig, err := config.ReadConfig(templatePath)
+	if err != nil {
+		return formatError(err)
+	}
+	templateConfig.Source = templates.GetSource(addTemplate, templatePath)
+
+	// Create the function's directory in the workspace
+	directoryPath, err := templates.NewProjectPath(path.Join(addWorkspace, functionName))
+	if err != nil {
+		return formatError(err)
+	}
+	if err := os.Mkdir(directoryPath, os.ModePerm); err != nil {
+		return formatError(err)
+	}
+
+	if err := populateProject(templatePath, templateConfig, functionName, directoryPath); err != nil {
+		return formatError(cleanUp(directoryPath, err))
+	}
+	if err := workspace.AddFunction(functionName, functionName, addTemplate); err != nil {
+		return formatError(cleanUp(directoryPath, err))
+	}
+	if err := config.WriteWorkspace(addWorkspace, workspace); err != nil {
+		return formatError(cleanUp(directoryPath, err))
+	}
+	fmt.Println("\n✅  Added: ", directoryPath)
+	return nil
+}
+
+func validateAddTriggerArgs(cmd *cobra.Command, args []string) error {
+	if len(args) != 3 {
+		return errors.New("please specify the trigger's type, its value (a schedule, queue name, or bucket), and a path or directory name")
+	}
+	return nil
+}
+
+// runAddTrigger adds a trigger to the project's config, and wires it to the
+// deployed function; other changes to the project are not deployed
+func runAddTrigger(cmd *cobra.Command, args []string) error {
+	trigger := &config.Trigger{Type: args[0]}
+	switch trigger.Type {
+	case config.TriggerQueue:
+	case config.TriggerSchedule:
+		trigger.Schedule = args[1]
+	case config.TriggerS3:
+		trigger.Bucket = args[1]
+		trigger.Prefix = addTriggerPrefix
+		trigger.Suffix = addTriggerSuffix
+	default:
+		return formatError(fmt.Errorf("unknown trigger type: %s (expected %s, %s, or %s)",
+			trigger.Type,
+			config.TriggerSchedule,
+			config.TriggerQueue,
+			config.TriggerS3,
+		))
+	}
+	if trigger.Type != config.TriggerQueue {
+		if err := config.ValidateTrigger(trigger); err != nil {
+			return formatError(err)
+		}
+		if addQueueRetries != 0 || addQueueAlarmEmail != "" {
+			return formatError(errors.New("--retries and --alarm-email can only be used with queue triggers"))
+		}
+	}
+
+	p, err := loadProject(args[2:])
+	if err != nil {
+		return formatError(err)
+	}
+	setter, ok := p.service.(clouds.TriggerSetter)
+	if !ok {
+		return formatError(errors.New("adding triggers is not supported for this deployment type"))
+	}
+
+	// The trigger is added to kettle.json as it is on disk, so
+	// that all of the project's stages get it when they are deployed
+	projectConfig, err := config.ReadConfig(p.path)
+	if err != nil {
+		return formatError(err)
+	}
+	if trigger.Type == config.TriggerQueue {
+		if p.config.Config.Queue != nil {
+			return formatError(errors.New("the project already has a queue"))
+		}
+		queue := &config.Queue{
+			Name:       args[1],
+			Retries:    addQueueRetries,
+			AlarmEmail: addQueueAlarmEmail,
+		}
+		projectConfig.Config.Queue = queue
+		p.config.Config.Queue = queue
+		if err := p.config.ValidateQueue(); err != nil {
+			return formatError(err)
+		}
+	} else {
+		if p.config.HasTrigger(trigger) {
+			return formatError(errors.New("the project already has this trigger"))
+		}
+		projectConfig.Config.Triggers = append(projectConfig.Config.Triggers, trigger)
+		p.config.Config.Triggers = append(p.config.Config.Triggers, trigger)
+	}
+
+	confirmed, err := p.confirm("Add trigger")
+	if err != nil {
+		return formatError(err)
+	}
+	if !confirmed {
+		return nil
+	}
+	unlock, err := p.lockState()
+	if err != nil {
+		return formatError(err)
+	}
+	defer unlock()
+	if err := setter.SetTriggers(p.path, p.config, p.settings); err != nil {
+		return formatError(err)
+	}
+	if err := config.WriteConfig(p.path, projectConfig); err != nil {
+		return formatError(err)
+	}
+	p.save()
+	fmt.Println("✅  Added trigger: ", strings.Join(args[:2], " "))
+	if addQueueRetries > 0 {
+		// The function reports the messages that failed, so only they are retried
+		if err := templates.AddBatchHelper(p.path, p.config.Config.Runtime); err != nil {
+			return formatError(err)
+		}
+		fmt.Println("💡  Return the failed messages of each batch from the handler, e.g. with the kettle_batch helper")
+	}
+	return nil
+}
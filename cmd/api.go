@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/clouds"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+var (
+	apiName          string
+	apiCloudProvider string
+)
+
+var apiCmd = &cobra.Command{
+	Use:   "api",
+	Short: "Manage the shared REST APIs that functions are added to",
+}
+
+var apiListCmd = &cobra.Command{
+	Use:   "list [path]",
+	Short: "List the functions that are attached to a shared REST API",
+	Long: `🔌 The kettle CLI tool adds functions to a shared REST API (one per team,
+ with rest_api_name in kettle.json), as /<function> resources. The list
+ command shows each resource of the API, and the function it invokes.
+
+With a path, it lists the shared API of that project.`,
+	RunE: runApiList,
+}
+
+func init() {
+	apiListCmd.Flags().StringVar(&apiName, "name", "", "The name of the shared REST API (defaults to operator-apigateway)")
+	apiListCmd.Flags().StringVar(&apiCloudProvider, "cloud", "aws", "Cloud provider of the shared API, when no path is given")
+	apiCmd.AddCommand(apiListCmd)
+	rootCmd.AddCommand(apiCmd)
+}
+
+func runApiList(cmd *cobra.Command, args []string) error {
+	var cloud clouds.Cloud
+	var cloudSettings *settings.Settings
+	name := apiName
+	if len(args) > 0 {
+		p, err := loadProject(args)
+		if err != nil {
+			return formatError(err)
+		}
+		cloud, cloudSettings = p.cloud, p.settings
+		if name == "" {
+			name = p.config.Config.AWS.RestApiName
+		}
+	} else {
+		var err error
+		cloudSettings, err = settings.ReadSettings()
+		if err != nil {
+			return formatError(err)
+		}
+		cloud, err = clouds.GetCloudProvider(apiCloudProvider)
+		if err != nil {
+			return formatError(err)
+		}
+		if err := cloud.Setup(cloudSettings); err != nil {
+			return formatError(err)
+		}
+	}
+
+	host, ok := cloud.(clouds.SharedApiHost)
+	if !ok {
+		return formatError(errors.New("shared REST APIs are not supported on this cloud (try: aws)"))
+	}
+	restApiID, routes, err := host.GetApiRoutes(name, cloudSettings)
+	if err != nil {
+		return formatError(err)
+	}
+	fmt.Println("🔌  REST API: ", restApiID)
+	if len(routes) == 0 {
+		fmt.Println("⏭  No functions are attached to the API")
+		return nil
+	}
+	for _, route := range routes {
+		function := route.Function
+		if function == "" {
+			function = "(no function)"
+		}
+		fmt.Printf("%s\t%s\t%s\n", route.Path, function, route.ResourceID)
+	}
+	return nil
+}
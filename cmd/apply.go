@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/clouds"
+)
+
+var fixDrift bool
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Reconcile a deployed project with its config",
+	Long: `🔧 The kettle CLI tool can revert changes that were made
+ to a deployed project outside of kettle (e.g. in the cloud console).
+
+Fields that are intentionally managed elsewhere can be listed in
+the "ignore_drift" section of the project config.`,
+	Args: validateProjectArgs,
+	RunE: runApply,
+}
+
+func init() {
+	applyCmd.Flags().BoolVar(&fixDrift, "fix-drift", false, "Revert any drift back to the declared config")
+	rootCmd.AddCommand(applyCmd)
+}
+
+func runApply(cmd *cobra.Command, args []string) error {
+	p, err := loadProject(args)
+	if err != nil {
+		return formatError(err)
+	}
+
+	detector, ok := p.service.(clouds.DriftDetector)
+	if !ok {
+		return formatError(errors.New("drift repair is not supported for this deployment type"))
+	}
+	drift, err := detector.DetectDrift(p.config, p.settings)
+	if err != nil {
+		return formatError(err)
+	}
+	printDrift(drift)
+	if len(drift) == 0 {
+		return nil
+	}
+	if !fixDrift {
+		fmt.Println("\n💡  Run with --fix-drift to revert these changes")
+		return nil
+	}
+
+	confirmed, err := p.confirm("Revert the drift of")
+	if err != nil {
+		return formatError(err)
+	}
+	if !confirmed {
+		return nil
+	}
+	if err := detector.FixDrift(p.config, p.settings, drift); err != nil {
+		return formatError(err)
+	}
+	fmt.Println("✅  Drift fixed!")
+	return nil
+}
@@ -0,0 +1,341 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/pkg/scaffold"
+	"github.com/operatorai/kettle-cli/tempdir"
+	"github.com/operatorai/kettle-cli/templates"
+)
+
+var (
+	benchRuns        int
+	benchConcurrency int
+	benchSeed        int64
+)
+
+var benchTemplateCmd = &cobra.Command{
+	Use:   "bench-template <template>...",
+	Short: "Render templates many times, to measure them and find nondeterministic output",
+	Long: `⏱  The kettle CLI tool can benchmark templates for their authors: each
+ template is rendered with random (valid) answers to its prompts, and its
+ render time and output size are reported.
+
+Each set of answers is rendered twice, and the two projects are compared, so
+that output which changes between renders (e.g. timestamps or random values
+that leak into files) is found before the template is published.`,
+	Example: `  kettle bench-template ./templates/pyfunction
+  kettle bench-template ./templates/pyfunction ./templates/gorun --runs 50
+  kettle bench-template ./templates/pyfunction --seed 1234`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runBenchTemplate,
+}
+
+func init() {
+	benchTemplateCmd.Flags().IntVar(&benchRuns, "runs", 10, "How many times to render each template")
+	benchTemplateCmd.Flags().IntVar(&benchConcurrency, "concurrency", runtime.NumCPU(), "How many renders to run at the same time")
+	benchTemplateCmd.Flags().Int64Var(&benchSeed, "seed", 0, "The seed of the random answers, to repeat a benchmark (random by default)")
+	rootCmd.AddCommand(benchTemplateCmd)
+}
+
+// benchTemplate is a template that is being benchmarked
+type benchTemplate struct {
+	name string
+	path string
+
+	mutex     sync.Mutex
+	durations []time.Duration
+	sizes     []int64
+	// Runs that failed, and files whose contents changed between renders, by run
+	failures       []string
+	nondeterminism map[string][]int
+}
+
+// benchRun renders a template with a set of answers
+type benchRun struct {
+	template    *benchTemplate
+	number      int
+	projectName string
+	values      map[string]string
+}
+
+func runBenchTemplate(cmd *cobra.Command, args []string) error {
+	if benchRuns <= 0 {
+		return formatError(errors.New("--runs must be greater than zero"))
+	}
+	if benchConcurrency <= 0 {
+		return formatError(errors.New("--concurrency must be greater than zero"))
+	}
+	if !cmd.Flags().Changed("seed") {
+		benchSeed = time.Now().UnixNano()
+	}
+	random := rand.New(rand.NewSource(benchSeed))
+
+	// The answers are generated before rendering, so that a seed always
+	// generates the same answers, whichever order the renders run in
+	runs := []*benchRun{}
+	benchTemplates := []*benchTemplate{}
+	for _, name := range args {
+		templatePath, isTempDir, err := templates.GetTemplate(name)
+		if err != nil {
+			return formatError(err)
+		}
+		if isTempDir {
+			defer tempdir.Remove(templatePath)
+		}
+		templateConfig, err := config.ReadConfig(templatePath)
+		if err != nil {
+			return formatError(err)
+		}
+		bench := &benchTemplate{
+			name:           name,
+			path:           templatePath,
+			nondeterminism: map[string][]int{},
+		}
+		benchTemplates = append(benchTemplates, bench)
+		for i := 1; i <= benchRuns; i++ {
+			run, err := newBenchRun(bench, i, templateConfig, random)
+			if err != nil {
+				return formatError(fmt.Errorf("%s: %w", name, err))
+			}
+			runs = append(runs, run)
+		}
+	}
+
+	fmt.Println("⏱   Rendering: ", strings.Join(args, ", "), fmt.Sprintf("(%d runs each, %d at a time)", benchRuns, benchConcurrency))
+	fmt.Println("🎲  Seed: ", benchSeed)
+	queue := make(chan *benchRun)
+	var wg sync.WaitGroup
+	for i := 0; i < benchConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for run := range queue {
+				run.render()
+			}
+		}()
+	}
+	for _, run := range runs {
+		queue <- run
+	}
+	close(queue)
+	wg.Wait()
+
+	failed := false
+	for _, bench := range benchTemplates {
+		if !bench.printReport() {
+			failed = true
+		}
+	}
+	if failed {
+		return formatError(fmt.Errorf("some templates failed, or are not deterministic (repeat with: --seed %d)", benchSeed))
+	}
+	fmt.Println("\n✅  Benchmark complete!")
+	return nil
+}
+
+// newBenchRun generates a project name and random answers to a template's prompts
+func newBenchRun(bench *benchTemplate, number int, templateConfig *config.Config, random *rand.Rand) (*benchRun, error) {
+	projectName, err := templates.RandomValue(&config.TemplatePrompt{
+		Key:      "ProjectName",
+		Validate: "^[a-z][a-z0-9]{2,10}(-[a-z0-9]{2,8})?$",
+	}, random)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	for _, prompt := range templateConfig.Template {
+		value, err := templates.RandomValue(prompt, random)
+		if err != nil {
+			return nil, err
+		}
+		values[prompt.Key] = value
+	}
+	return &benchRun{
+		template:    bench,
+		number:      number,
+		projectName: projectName,
+		values:      values,
+	}, nil
+}
+
+// render renders the run's answers twice, and records the time and size of
+// the first render, and any files that are different in the second
+func (run *benchRun) render() {
+	bench := run.template
+	first, duration, err := run.renderProject()
+	if first != "" {
+		defer tempdir.Remove(first)
+	}
+	if err != nil {
+		bench.fail(run, err)
+		return
+	}
+	second, _, err := run.renderProject()
+	if second != "" {
+		defer tempdir.Remove(second)
+	}
+	if err != nil {
+		bench.fail(run, err)
+		return
+	}
+	firstFiles, size, err := hashProject(first)
+	if err != nil {
+		bench.fail(run, err)
+		return
+	}
+	secondFiles, _, err := hashProject(second)
+	if err != nil {
+		bench.fail(run, err)
+		return
+	}
+
+	bench.mutex.Lock()
+	defer bench.mutex.Unlock()
+	bench.durations = append(bench.durations, duration)
+	bench.sizes = append(bench.sizes, size)
+	for _, file := range compareProjects(firstFiles, secondFiles) {
+		bench.nondeterminism[file] = append(bench.nondeterminism[file], run.number)
+	}
+}
+
+// renderProject renders the run's answers into a new temporary directory
+func (run *benchRun) renderProject() (string, time.Duration, error) {
+	// Rendering changes the template's config, so each render reads its own
+	templateConfig, err := config.ReadConfig(run.template.path)
+	if err != nil {
+		return "", 0, err
+	}
+	directory, err := tempdir.Create("-bench-")
+	if err != nil {
+		return "", 0, err
+	}
+	start := time.Now()
+	// Formatters and validation hooks (e.g. terraform validate) change the
+	// working directory, and are not part of the render time, so they are not run
+	_, err = scaffold.Render(run.template.path, templateConfig, run.values, directory, &scaffold.Options{
+		ProjectName:    run.projectName,
+		SkipFormatting: true,
+		SkipValidation: true,
+	})
+	return directory, time.Since(start), err
+}
+
+func (bench *benchTemplate) fail(run *benchRun, err error) {
+	answers := []string{}
+	for key, value := range run.values {
+		answers = append(answers, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(answers)
+	bench.mutex.Lock()
+	defer bench.mutex.Unlock()
+	bench.failures = append(bench.failures, fmt.Sprintf("run %d (%s): %s", run.number, strings.Join(answers, ", "), err))
+}
+
+// printReport prints the template's render times, output sizes, failures, and
+// nondeterministic files; it returns false if any runs failed, or any files changed
+func (bench *benchTemplate) printReport() bool {
+	fmt.Println("\n🧪  Template: ", bench.name)
+	if len(bench.durations) > 0 {
+		sort.Slice(bench.durations, func(i, j int) bool {
+			return bench.durations[i] < bench.durations[j]
+		})
+		fmt.Println("⏱   Render time: ", fmt.Sprintf("p50 %s, p90 %s, max %s",
+			getBenchPercentile(bench.durations, 50).Round(time.Microsecond),
+			getBenchPercentile(bench.durations, 90).Round(time.Microsecond),
+			getBenchPercentile(bench.durations, 100).Round(time.Microsecond),
+		))
+		var total, largest int64
+		for _, size := range bench.sizes {
+			total += size
+			if size > largest {
+				largest = size
+			}
+		}
+		fmt.Println("📦  Output size: ", fmt.Sprintf("mean %.1f KB, max %.1f KB",
+			float64(total)/float64(len(bench.sizes))/1024,
+			float64(largest)/1024,
+		))
+	}
+	sort.Strings(bench.failures)
+	for _, failure := range bench.failures {
+		fmt.Println("💥  Failed: ", failure)
+	}
+	files := []string{}
+	for file := range bench.nondeterminism {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		fmt.Println("⚠️   Nondeterministic: ", file, fmt.Sprintf("(changed in %d of %d runs)", len(bench.nondeterminism[file]), len(bench.durations)))
+	}
+	if len(bench.failures) == 0 && len(files) == 0 {
+		fmt.Println("✅  Deterministic: ", fmt.Sprintf("%d of %d runs rendered the same output twice", len(bench.durations), benchRuns))
+		return true
+	}
+	return false
+}
+
+func getBenchPercentile(durations []time.Duration, percentile int) time.Duration {
+	return durations[(len(durations)-1)*percentile/100]
+}
+
+// hashProject returns the hashes of a project's files (by their relative
+// path), and the total size of its files
+func hashProject(directory string) (map[string][]byte, int64, error) {
+	hashes := map[string][]byte{}
+	var size int64
+	err := filepath.Walk(directory, func(filePath string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		data, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(directory, filePath)
+		if err != nil {
+			return err
+		}
+		hash := sha256.Sum256(data)
+		hashes[relativePath] = hash[:]
+		size += info.Size()
+		return nil
+	})
+	return hashes, size, err
+}
+
+// compareProjects returns the files that are only in one of two
+// renders of a project, or whose contents are different
+func compareProjects(first, second map[string][]byte) []string {
+	different := []string{}
+	for file, hash := range first {
+		if other, ok := second[file]; !ok || !bytes.Equal(hash, other) {
+			different = append(different, file)
+		}
+	}
+	for file := range second {
+		if _, ok := first[file]; !ok {
+			different = append(different, file)
+		}
+	}
+	return different
+}
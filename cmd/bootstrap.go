@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/clouds"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/templates"
+)
+
+// bootstrapAllStages is set by the --all-stages flag, which bootstraps the
+// default stage's account and the account of each stage that has its own
+var bootstrapAllStages bool
+
+var bootstrapCmd = &cobra.Command{
+	Use:   "bootstrap",
+	Short: "Prepare an AWS account or GCP project for a project's stages",
+	Long: `🏗  The kettle CLI tool can prepare the account (or project) that a stage is
+ deployed to, so that a new environment is onboarded with one command: the
+ buckets of the project's artifact store and state backend, its lock table,
+ the execution role, and the tag policy of kettle's tags.
+
+Existing resources are left as they are, so it is safe to run it again.`,
+	Example: `  kettle bootstrap ./users
+  kettle bootstrap ./users --stage staging
+  kettle bootstrap ./users --all-stages`,
+	Args: validateProjectArgs,
+	RunE: runBootstrap,
+}
+
+func init() {
+	bootstrapCmd.Flags().BoolVar(&bootstrapAllStages, "all-stages", false, "Bootstrap the accounts of all of the project's stages")
+	rootCmd.AddCommand(bootstrapCmd)
+}
+
+func runBootstrap(cmd *cobra.Command, args []string) error {
+	stages := []string{stageName}
+	if bootstrapAllStages {
+		projectPath, err := templates.GetProject(args)
+		if err != nil {
+			return formatError(err)
+		}
+		cfg, err := config.ReadConfig(projectPath)
+		if err != nil {
+			return formatError(err)
+		}
+		stages = getAccountStages(cfg)
+	}
+	for _, stage := range stages {
+		stageName = stage
+		if err := bootstrapStage(args); err != nil {
+			return formatError(err)
+		}
+	}
+	fmt.Println("✅  Bootstrapped!")
+	return nil
+}
+
+// getAccountStages returns the default stage, and the stages that are
+// deployed to their own account (or project); the others share the
+// default stage's account
+func getAccountStages(cfg *config.Config) []string {
+	stages := []string{}
+	for stage := range cfg.Config.Stages {
+		if cfg.GetStageAccount(stage) != nil {
+			stages = append(stages, stage)
+		}
+	}
+	sort.Strings(stages)
+	return append([]string{config.DefaultStage}, stages...)
+}
+
+// bootstrapStage prepares the account of the current --stage
+func bootstrapStage(args []string) error {
+	p, err := loadProject(args)
+	if err != nil {
+		return err
+	}
+	bootstrapper, ok := p.cloud.(clouds.AccountBootstrapper)
+	if !ok {
+		return fmt.Errorf("bootstrap is not supported on: %s", p.config.Config.CloudProvider)
+	}
+	confirmed, err := p.confirm("Bootstrap the account of")
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return nil
+	}
+	if err := bootstrapper.BootstrapAccount(p.config, p.settings); err != nil {
+		return err
+	}
+	p.save()
+	return nil
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/clouds"
+)
+
+var (
+	bootstrapOutput     string
+	bootstrapCreateRole bool
+	bootstrapRepository string
+)
+
+var bootstrapIAMCmd = &cobra.Command{
+	Use:   "bootstrap-iam",
+	Short: "Generate the least-privilege permissions that deploy a project",
+	Long: `🔐 The kettle CLI tool can generate the minimal permissions that a CI deploy
+ user or role needs for a project's cloud and features, so that it does not need
+ admin credentials.
+
+On AWS, this is an IAM policy document; on GCP, a list of roles. With --create-role,
+ the command also creates a role (or service account) with those permissions, which
+ GitHub Actions workflows in the --repository can assume with OIDC.`,
+	Args: validateProjectArgs,
+	RunE: runBootstrapIAM,
+}
+
+func init() {
+	bootstrapIAMCmd.Flags().StringVarP(&bootstrapOutput, "output", "o", "", "Write the policy to a file, instead of printing it")
+	bootstrapIAMCmd.Flags().BoolVar(&bootstrapCreateRole, "create-role", false, "Create a deploy role with the policy, for GitHub Actions")
+	bootstrapIAMCmd.Flags().StringVar(&bootstrapRepository, "repository", "", "GitHub repository (owner/name) that can assume the deploy role")
+	rootCmd.AddCommand(bootstrapIAMCmd)
+}
+
+func runBootstrapIAM(cmd *cobra.Command, args []string) error {
+	if bootstrapCreateRole && len(strings.Split(bootstrapRepository, "/")) != 2 {
+		return formatError(errors.New("--create-role needs a --repository, as owner/name"))
+	}
+	p, err := loadProject(args)
+	if err != nil {
+		return formatError(err)
+	}
+	provider, ok := p.cloud.(clouds.DeployIdentityProvider)
+	if !ok {
+		return formatError(fmt.Errorf("bootstrap-iam is not supported on: %s", p.config.Config.CloudProvider))
+	}
+
+	policy, err := provider.GetDeployPolicy(p.config, p.settings)
+	if err != nil {
+		return formatError(err)
+	}
+	if bootstrapOutput != "" {
+		if err := ioutil.WriteFile(bootstrapOutput, policy, 0644); err != nil {
+			return formatError(err)
+		}
+		fmt.Println("📝  Deploy policy: ", bootstrapOutput)
+	} else {
+		fmt.Println(string(policy))
+	}
+	if !bootstrapCreateRole {
+		return nil
+	}
+
+	confirmed, err := p.confirm("Create a deploy role for")
+	if err != nil {
+		return formatError(err)
+	}
+	if !confirmed {
+		return nil
+	}
+	identity, err := provider.CreateDeployRole(p.config, p.settings, bootstrapRepository, policy)
+	if err != nil {
+		return formatError(err)
+	}
+	p.save()
+	fmt.Println("✅  Deploy identity: ", identity)
+	return nil
+}
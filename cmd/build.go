@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/artifacts"
+	"github.com/operatorai/kettle-cli/clouds"
+	"github.com/operatorai/kettle-cli/includes"
+)
+
+var (
+	buildVersion string
+)
+
+var buildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Build a project's artifact, and push it to its artifact store",
+	Long: `🏭 The kettle CLI tool can build a project's deployment archive or
+ image without deploying it, and store it with an immutable version,
+ so that it can be deployed later with: kettle deploy --artifact <version>`,
+	Args: validateProjectArgs,
+	RunE: runBuild,
+}
+
+func init() {
+	buildCmd.Flags().StringVar(&buildVersion, "version", "", "The version to store the artifact with (e.g. a git tag)")
+	rootCmd.AddCommand(buildCmd)
+}
+
+// runBuild builds the project's artifact and pushes it to the artifact store
+func runBuild(cmd *cobra.Command, args []string) error {
+	if buildVersion == "" {
+		return formatError(errors.New("please specify the artifact's version (--version)"))
+	}
+	p, err := loadProject(args)
+	if err != nil {
+		return formatError(err)
+	}
+	builder, ok := p.service.(clouds.Builder)
+	if !ok {
+		return formatError(fmt.Errorf("artifacts are not supported on %s %s deployments",
+			p.config.Config.CloudProvider,
+			p.config.Config.DeploymentType,
+		))
+	}
+
+	returnToRoot, err := p.changeDirectory()
+	if err != nil {
+		return formatError(err)
+	}
+	defer returnToRoot()
+	removeIncludes, err := includes.Vendor(p.path, p.config)
+	if err != nil {
+		return formatError(err)
+	}
+	defer removeIncludes()
+
+	artifact, err := builder.Build(p.config, p.settings)
+	if err != nil {
+		return formatError(err)
+	}
+	if builder.GetArtifactKind() == artifacts.Archive {
+		// The archive is removed from the project directory once it is stored
+		defer os.Remove(artifact.Location)
+	}
+	if err := artifacts.Push(p.config, artifact, builder.GetArtifactKind(), buildVersion); err != nil {
+		return formatError(err)
+	}
+	p.save()
+	fmt.Println("📦  Artifact: ", artifact.Location)
+	if artifact.Digest != "" {
+		fmt.Println("🔏  Digest: ", artifact.Digest)
+	}
+	fmt.Println("✅  Built! Deploy it with: kettle deploy --artifact", buildVersion)
+	return nil
+}
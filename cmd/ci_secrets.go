@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/ci"
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/templates"
+)
+
+var (
+	ciSecretsRepository string
+	ciSecretsCheck      bool
+)
+
+var ciSecretsCmd = &cobra.Command{
+	Use:   "ci-secrets",
+	Short: "Set the secrets that a project's CI pipeline needs on its GitHub or GitLab repository",
+	Long: `🔑 The kettle CLI tool lists the secrets that a project's CI pipeline (from
+ its template) needs, such as the role that it deploys with, and sets them on
+ the project's GitHub repository (with the gh cli) or GitLab project (with the
+ token in GITLAB_TOKEN), so that the pipeline passes on its first run.
+
+The secrets' values are asked for (or set with --set or --values), and are
+ not stored. kettle create offers to do this when a project is created.`,
+	Example: `  kettle ci-secrets my-project --repository my-org/my-project
+  kettle ci-secrets my-project --check`,
+	Args: validateProjectArgs,
+	RunE: runCISecrets,
+}
+
+func init() {
+	ciSecretsCmd.Flags().StringVar(&ciSecretsRepository, "repository", "", "The repository (owner/name, or group/project on GitLab) to set the secrets on, instead of the project's git origin")
+	ciSecretsCmd.Flags().BoolVar(&ciSecretsCheck, "check", false, "Only list the secrets, and whether they are set; fails if a secret that the pipeline needs is not set")
+	rootCmd.AddCommand(ciSecretsCmd)
+}
+
+func runCISecrets(cmd *cobra.Command, args []string) error {
+	projectPath, err := templates.GetProject(args)
+	if err != nil {
+		return formatError(err)
+	}
+	cfg, err := config.ReadConfig(projectPath)
+	if err != nil {
+		return formatError(err)
+	}
+	if len(cfg.GetCISecrets()) == 0 {
+		fmt.Println("✅  The project's template does not list any CI secrets")
+		return nil
+	}
+	repository, err := getCIRepository(projectPath)
+	if err != nil {
+		return formatError(err)
+	}
+	existing, err := ci.ListSecrets(cfg.CI.Provider, repository)
+	if err != nil {
+		return formatError(err)
+	}
+	printCIChecklist(cfg, existing)
+	if ciSecretsCheck {
+		if missing := getMissingCISecrets(cfg, existing); len(missing) > 0 {
+			return formatError(fmt.Errorf("%s does not have the secrets that the pipeline needs: %s", repository, strings.Join(missing, ", ")))
+		}
+		return nil
+	}
+	if err := setCISecrets(cfg, repository, existing); err != nil {
+		return formatError(err)
+	}
+	return nil
+}
+
+// offerCISecrets lists the secrets that a new project's pipeline needs, and sets
+// them on its repository if the user agrees to; the project has been created
+// either way, so failures are warnings, with how to set the secrets later
+func offerCISecrets(cfg *config.Config, projectPath string) {
+	if len(cfg.GetCISecrets()) == 0 {
+		return
+	}
+	printCIChecklist(cfg, nil)
+	if !cli.PromptToConfirm("Set the CI secrets on the repository") {
+		fmt.Println("💡  Set them later with: kettle ci-secrets", projectPath)
+		return
+	}
+	err := func() error {
+		repository, err := getCIRepository(projectPath)
+		if err != nil {
+			return err
+		}
+		existing, err := ci.ListSecrets(cfg.CI.Provider, repository)
+		if err != nil {
+			return err
+		}
+		return setCISecrets(cfg, repository, existing)
+	}()
+	if err != nil {
+		fmt.Println("⚠️   " + err.Error())
+		fmt.Println("💡  Set them later with: kettle ci-secrets", projectPath)
+	}
+}
+
+// getCIRepository returns the repository of --repository, or of the project's
+// git origin, or asks for it (e.g. for a new project that is not pushed yet)
+func getCIRepository(projectPath string) (string, error) {
+	if ciSecretsRepository != "" {
+		return ciSecretsRepository, nil
+	}
+	return cli.PromptForStringWithDefault("CI repository", ci.GetRepository(projectPath), func(value string) error {
+		if !strings.Contains(strings.Trim(value, "/"), "/") {
+			return errors.New("expected owner/name (or group/project on GitLab)")
+		}
+		return nil
+	})
+}
+
+// printCIChecklist lists the secrets that the project's pipeline needs, and
+// whether they are set on the repository (if existing is not nil)
+func printCIChecklist(cfg *config.Config, existing map[string]bool) {
+	fmt.Println(fmt.Sprintf("🔑  The project's pipeline (on %s) reads these secrets:", cfg.CI.Provider))
+	for _, secret := range cfg.GetCISecrets() {
+		switch {
+		case existing == nil:
+			fmt.Println(fmt.Sprintf("    -  %s", secret))
+		case existing[secret.Name]:
+			fmt.Println(fmt.Sprintf("    ✅  %s", secret))
+		default:
+			fmt.Println(fmt.Sprintf("    ⬜  %s", secret))
+		}
+	}
+}
+
+// setCISecrets asks for the values of the secrets that are not set on the
+// repository, and sets them; it returns an error if a secret that the pipeline
+// needs is left unset (e.g. as it has no answer in non-interactive mode)
+func setCISecrets(cfg *config.Config, repository string, existing map[string]bool) error {
+	for _, secret := range cfg.GetCISecrets() {
+		if existing[secret.Name] {
+			fmt.Println("⏭   Already set: ", secret.Name)
+			continue
+		}
+		value, err := cli.PromptForSecret(secret.Name, secret.Default)
+		if err != nil && !cli.NonInteractive {
+			return err
+		}
+		if value == "" {
+			fmt.Println("⏭   Not set: ", secret.Name)
+			continue
+		}
+		if err := ci.SetSecret(cfg.CI.Provider, repository, secret.Name, value); err != nil {
+			return err
+		}
+		existing[secret.Name] = true
+		fmt.Println("🔑  Set: ", secret.Name)
+	}
+	if missing := getMissingCISecrets(cfg, existing); len(missing) > 0 {
+		return fmt.Errorf("the pipeline will fail until these secrets are set on %s: %s", repository, strings.Join(missing, ", "))
+	}
+	fmt.Println("✅  The pipeline's secrets are set on", repository)
+	return nil
+}
+
+// getMissingCISecrets returns the names of the secrets that the
+// pipeline needs, which are not set on the repository
+func getMissingCISecrets(cfg *config.Config, existing map[string]bool) []string {
+	missing := []string{}
+	for _, secret := range cfg.GetCISecrets() {
+		if !secret.Optional && !existing[secret.Name] {
+			missing = append(missing, secret.Name)
+		}
+	}
+	return missing
+}
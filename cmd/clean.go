@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/tempdir"
+)
+
+var (
+	cleanDryRun    bool
+	cleanUntracked bool
+)
+
+var cleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove the temporary directories (e.g. template clones) that interrupted runs left behind",
+	Long: `🧹 The kettle CLI tool records the temporary directories that it creates
+ (e.g. template clones, and extracted bundles and archives), and removes the
+ ones whose run has ended without removing them (e.g. as it was interrupted).
+
+This happens at the start of the commands that create them (e.g. kettle create,
+and kettle deploy); kettle clean does it on demand, and can also remove older
+kettle directories that were not recorded.`,
+	Args: cobra.NoArgs,
+	RunE: runClean,
+}
+
+func init() {
+	cleanCmd.Flags().BoolVar(&cleanDryRun, "dry-run", false, "List the directories that would be removed, without removing them")
+	cleanCmd.Flags().BoolVar(&cleanUntracked, "untracked", false, fmt.Sprintf("Also remove kettle's temporary directories that were not recorded (e.g. by older versions of kettle), if they are older than %s", tempdir.UntrackedAge))
+	rootCmd.AddCommand(cleanCmd)
+}
+
+// tempDirCommands are the commands that create temporary directories (e.g. to
+// clone a template), which remove the ones that earlier runs left behind
+var tempDirCommands = map[string]bool{
+	"add":            true,
+	"bench-template": true,
+	"create":         true,
+	"deploy":         true,
+	"describe":       true,
+	"import":         true,
+	"search":         true,
+	"update":         true,
+	"upgrade-all":    true,
+}
+
+// removeLeftovers removes the temporary directories that earlier runs left
+// behind, before a command that creates temporary directories runs
+func removeLeftovers(cmd *cobra.Command) {
+	name := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	if !tempDirCommands[name] {
+		return
+	}
+	if _, err := tempdir.RemoveLeftovers(false); err != nil && settings.DebugMode {
+		fmt.Println(err.Error())
+	}
+}
+
+func runClean(cmd *cobra.Command, args []string) error {
+	removed, err := tempdir.RemoveLeftovers(cleanDryRun)
+	if err != nil {
+		return formatError(err)
+	}
+	if cleanUntracked {
+		untracked, err := tempdir.RemoveUntracked(cleanDryRun)
+		removed = append(removed, untracked...)
+		if err != nil {
+			printRemoved(removed)
+			return formatError(err)
+		}
+	}
+	printRemoved(removed)
+	if len(removed) == 0 {
+		fmt.Println("✅  There are no temporary directories to remove")
+	}
+	return nil
+}
+
+func printRemoved(directories []string) {
+	label := "🧹  Removed: "
+	if cleanDryRun {
+		label = "🧹  Would remove: "
+	}
+	for _, directory := range directories {
+		fmt.Println(label, directory)
+	}
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/client"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/state"
+	"github.com/operatorai/kettle-cli/templates"
+)
+
+var clientCmd = &cobra.Command{
+	Use:   "client",
+	Short: "Generate a typed client for a deployed project's endpoint",
+	Long: `🧩 The kettle CLI tool can generate a small typed client (in Python or
+ TypeScript) for a deployed project's endpoint, with its URL, its auth, and a
+ request model that is inferred from the project's smoke test body, so that
+ the services that call it do not hand-roll HTTP calls.`,
+	Example: `  kettle client ./users --lang python
+  kettle client ./users --lang typescript --file src/usersClient.ts`,
+	Args: validateProjectArgs,
+	RunE: runClient,
+}
+
+var (
+	clientLanguage string
+	clientFile     string
+)
+
+func init() {
+	clientCmd.Flags().StringVar(&clientLanguage, "lang", client.Python, "The language of the client: python or typescript")
+	clientCmd.Flags().StringVar(&clientFile, "file", "", "The file to write the client to (e.g. users_client.py by default)")
+	rootCmd.AddCommand(clientCmd)
+}
+
+func runClient(cmd *cobra.Command, args []string) error {
+	projectPath, err := templates.GetProject(args)
+	if err != nil {
+		return formatError(err)
+	}
+	cfg, err := config.ReadConfig(projectPath)
+	if err != nil {
+		return formatError(err)
+	}
+	if stageName != "" && stageName != config.DefaultStage {
+		if _, ok := cfg.Config.Stages[stageName]; !ok {
+			return formatError(fmt.Errorf("stage %s is not in the project's config", stageName))
+		}
+		state.Stage = stageName
+	}
+	st, err := state.ReadState(projectPath)
+	if err != nil {
+		return formatError(err)
+	}
+	url := st.Outputs["url"]
+	if url == "" {
+		return formatError(fmt.Errorf("%s has no endpoint (has it been deployed?)", cfg.ProjectName))
+	}
+	code, fileName, err := client.Generate(clientLanguage, cfg, url, st.Region)
+	if err != nil {
+		return formatError(err)
+	}
+	if clientFile == "" {
+		clientFile = fileName
+	}
+	if err := ioutil.WriteFile(clientFile, code, 0644); err != nil {
+		return formatError(err)
+	}
+	fmt.Println("✅  Generated: ", clientFile)
+	return nil
+}
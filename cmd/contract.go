@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/contract"
+	"github.com/operatorai/kettle-cli/state"
+	"github.com/operatorai/kettle-cli/templates"
+)
+
+// contractTestTimeout is how long each contract test request has to respond
+const contractTestTimeout = 30 * time.Second
+
+var contractCmd = &cobra.Command{
+	Use:   "contract",
+	Short: "Check a project against the request and response schemas of its contract",
+	Long: `📜 The kettle CLI tool can check a project's smoke test request and its
+ handler's sample responses against the JSON Schemas of its contract, and
+ (with --run) run the contract tests against the deployed endpoint.`,
+	Args: validateProjectArgs,
+	RunE: runContract,
+}
+
+// contractRun runs the contract tests against the deployed endpoint
+var contractRun bool
+
+func init() {
+	contractCmd.Flags().BoolVar(&contractRun, "run", false, "Run the contract tests against the deployed endpoint")
+	rootCmd.AddCommand(contractCmd)
+}
+
+func runContract(cmd *cobra.Command, args []string) error {
+	projectPath, err := templates.GetProject(args)
+	if err != nil {
+		return formatError(err)
+	}
+	cfg, err := config.ReadConfig(projectPath)
+	if err != nil {
+		return formatError(err)
+	}
+	c, err := contract.Read(projectPath, cfg)
+	if err != nil {
+		return formatError(err)
+	}
+	if c == nil {
+		return formatError(errors.New("the project has no contract (add \"contract\": {\"request\": ..., \"response\": ...} to kettle.json)"))
+	}
+	violations, err := c.Check(cfg)
+	if err != nil {
+		return formatError(err)
+	}
+	for _, violation := range violations {
+		fmt.Println("⛔  " + violation)
+	}
+	if len(violations) > 0 {
+		return formatError(fmt.Errorf("the project violates its contract in %d place(s)", len(violations)))
+	}
+	fmt.Println("✅  Contract: the smoke test and", len(c.Samples), "sample response(s) match")
+
+	tests := c.Tests(cfg)
+	if !contractRun {
+		fmt.Println("🧪  Contract tests (run them with --run):")
+		for _, test := range tests {
+			path := test.Path
+			if path == "" {
+				path = "/"
+			}
+			fmt.Println(fmt.Sprintf("    %s: %s %s, expecting %d", test.Name, test.Method, path, test.ExpectedStatus))
+		}
+		return nil
+	}
+	st, err := state.ReadState(projectPath)
+	if err != nil {
+		return formatError(err)
+	}
+	url := st.Outputs["url"]
+	if url == "" {
+		return formatError(fmt.Errorf("%s has no endpoint (has it been deployed?)", cfg.ProjectName))
+	}
+	client := &http.Client{Timeout: contractTestTimeout}
+	for _, test := range tests {
+		if err := test.Run(client, url); err != nil {
+			return formatError(err)
+		}
+		fmt.Println("✅  Passed: ", test.Name)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/clouds"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+const (
+	costDateFormat = "2006-01-02"
+)
+
+var (
+	costCloudProvider string
+	costStart         string
+	costEnd           string
+)
+
+var costCmd = &cobra.Command{
+	Use:   "cost",
+	Short: "Show the spend of deployed projects",
+	Long: `💸 The kettle CLI tool can report how much each project (and each of its
+ stages) has cost, from the tags that kettle adds to deployed resources.
+
+Without a path, the cost command reports the spend of every kettle project.`,
+	RunE: runCost,
+}
+
+func init() {
+	costCmd.Flags().StringVar(&costCloudProvider, "cloud", "aws", "Cloud provider to report on, when no path is given")
+	costCmd.Flags().StringVar(&costStart, "start", "", "First day of the period, as YYYY-MM-DD (defaults to the start of the month)")
+	costCmd.Flags().StringVar(&costEnd, "end", "", "Last day of the period, as YYYY-MM-DD (defaults to today)")
+	rootCmd.AddCommand(costCmd)
+}
+
+func runCost(cmd *cobra.Command, args []string) error {
+	start, end, err := getCostPeriod()
+	if err != nil {
+		return formatError(err)
+	}
+
+	var cloud clouds.Cloud
+	projectName := ""
+	if len(args) > 0 {
+		p, err := loadProject(args)
+		if err != nil {
+			return formatError(err)
+		}
+		cloud = p.cloud
+		projectName = p.config.ProjectName
+	} else {
+		cloudSettings, err := settings.ReadSettings()
+		if err != nil {
+			return formatError(err)
+		}
+		cloud, err = clouds.GetCloudProvider(costCloudProvider)
+		if err != nil {
+			return formatError(err)
+		}
+		if err := cloud.Setup(cloudSettings); err != nil {
+			return formatError(err)
+		}
+	}
+
+	reporter, ok := cloud.(clouds.CostReporter)
+	if !ok {
+		return formatError(errors.New("cost reports are not supported on this cloud (try: aws)"))
+	}
+	costs, err := reporter.GetCosts(projectName, start, end)
+	if err != nil {
+		return formatError(err)
+	}
+	printCosts(costs, start, end)
+	return nil
+}
+
+// getCostPeriod returns the dates of the period to report on,
+// which defaults to the month so far
+func getCostPeriod() (time.Time, time.Time, error) {
+	now := time.Now().UTC()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := now
+	var err error
+	if costStart != "" {
+		start, err = time.Parse(costDateFormat, costStart)
+		if err != nil {
+			return start, end, fmt.Errorf("invalid --start date: %s", costStart)
+		}
+	}
+	if costEnd != "" {
+		end, err = time.Parse(costDateFormat, costEnd)
+		if err != nil {
+			return start, end, fmt.Errorf("invalid --end date: %s", costEnd)
+		}
+	}
+	if end.Before(start) {
+		return start, end, errors.New("--end must not be before --start")
+	}
+	return start, end, nil
+}
+
+func printCosts(costs []*config.Cost, start, end time.Time) {
+	fmt.Println("💸  Spend from", start.Format(costDateFormat), "to", end.Format(costDateFormat))
+	if len(costs) == 0 {
+		fmt.Println("🤷  No spend was found on resources that are tagged by kettle")
+		return
+	}
+	total := 0.0
+	for _, cost := range costs {
+		stage := cost.Stage
+		if stage == "" {
+			stage = "(untagged)"
+		}
+		fmt.Println(fmt.Sprintf("    %-30s %-15s %10.2f %s", cost.Project, stage, cost.Amount, cost.Unit))
+		total += cost.Amount
+	}
+	fmt.Println(fmt.Sprintf("    %-46s %10.2f %s", "Total", total, costs[0].Unit))
+}
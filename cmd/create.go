@@ -3,20 +3,18 @@ package cmd
 import (
 	"errors"
 	"fmt"
-	"io/fs"
-	"io/ioutil"
 	"os"
-	"path"
-	"path/filepath"
 	"strings"
-	"text/template"
 
 	"github.com/iancoleman/strcase"
 	"github.com/spf13/cobra"
 
 	"github.com/operatorai/kettle-cli/cli"
 	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/pkg/scaffold"
+	"github.com/operatorai/kettle-cli/policy"
 	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/tempdir"
 	"github.com/operatorai/kettle-cli/templates"
 )
 
@@ -32,11 +30,32 @@ The create command will create a directory with all the code to get you started.
 	RunE: runCreate,
 }
 
+// createTutorial explains the template's prompts and the project's components
+var createTutorial bool
+
+// createSkipValidation skips the template's validation hooks (e.g. terraform validate)
+var createSkipValidation bool
+
+// createFromFunction is a deployed function that the project is created from,
+// instead of a template
+var createFromFunction string
+
 func init() {
+	createCmd.Flags().BoolVar(&createTutorial, "tutorial", false, "Walk through the template step by step, explaining each prompt and each component that is created")
+	createCmd.Flags().BoolVar(&createSkipValidation, "skip-validation", false, "Do not run the template's validation hooks (e.g. terraform validate, or helm lint) on the project")
+	createCmd.Flags().StringVar(&ciSecretsRepository, "ci-repository", "", "The repository (owner/name) to set the secrets that the template's CI pipeline needs on")
+	createCmd.Flags().StringVar(&createFromFunction, "from-function", "", "Create the project from a deployed AWS Lambda function (its code and configuration), instead of a template")
+	addOutputFlag(createCmd)
 	rootCmd.AddCommand(createCmd)
 }
 
 func validateCreateArgs(cmd *cobra.Command, args []string) error {
+	if createFromFunction != "" {
+		if len(args) > 0 {
+			return errors.New("--from-function creates a project without a template")
+		}
+		return nil
+	}
 	// Validate that a template was given
 	if len(args) == 0 {
 		return errors.New("please specify a template")
@@ -45,89 +64,161 @@ func validateCreateArgs(cmd *cobra.Command, args []string) error {
 }
 
 func runCreate(cmd *cobra.Command, args []string) error {
-	// Get the directory where the template is (or has been cloned to)
-	templatePath, isTempDir, err := templates.GetTemplate(args[0])
+	stream, err := startEventStream()
+	if err != nil {
+		return formatError(err)
+	}
+	if createFromFunction != "" {
+		err = createProjectFromFunction(createFromFunction)
+	} else {
+		err = createProject(args[0])
+	}
+	if stream != nil {
+		return stream.close(err)
+	}
 	if err != nil {
 		return formatError(err)
 	}
+	return nil
+}
+
+// createProject creates a project from a template, in a new directory
+func createProject(template string) error {
+	if createTutorial && outputFormat == ndjsonOutput {
+		return errors.New("--tutorial cannot be used with --output ndjson")
+	}
+
+	// Get the directory where the template is (or has been cloned to)
+	templatePath, isTempDir, err := templates.GetTemplate(template)
+	if err != nil {
+		return err
+	}
 	if isTempDir {
-		defer os.RemoveAll(templatePath)
+		defer tempdir.Remove(templatePath)
 	}
 
 	// Read the template config
 	templateConfig, err := config.ReadConfig(templatePath)
 	if err != nil {
-		return formatError(err)
+		return err
 	}
+	templateConfig.Source = templates.GetSource(template, templatePath)
 
 	// Create the directory where the template will be populated
 	projectName, directoryPath, err := createProjectDirectory()
 	if err != nil {
-		return formatError(err)
+		return err
 	}
 
-	// Ask the user for any input that is required
-	templateConfig.ProjectName = projectName
-	templateValues := map[string]string{
-		"ProjectName": projectName,
-	}
-	for i, templateEntry := range templateConfig.Template {
-		userInput, err := cli.PromptForString(templateEntry.Prompt)
-		if err != nil {
-			return cleanUp(directoryPath, err)
-		}
-		if templateEntry.Style == "camel" {
-			userInput = strcase.ToCamel(userInput)
-		}
-		templateConfig.Template[i].Value = userInput
-		templateValues[templateEntry.Key] = userInput
+	if err := populateProject(templatePath, templateConfig, projectName, directoryPath); err != nil {
+		return cleanUp(directoryPath, err)
 	}
+	fmt.Println("\n✅  Created: ", directoryPath)
+	offerCISecrets(templateConfig, directoryPath)
+	return nil
+}
 
-	// The template files are in a subdirectory of templatePath
-	templateDirectory := path.Join(templatePath, "template")
-	err = filepath.Walk(templateDirectory, func(filePath string, info fs.FileInfo, err error) error {
-		if err != nil {
-			if settings.DebugMode {
-				fmt.Printf("error accessing a path %q: %v\n", filePath, err)
-				return err
-			}
-			return nil
-		}
-
-		// Skip directories
-		if info.IsDir() {
-			return nil
-		}
-
-		// Create the target path
-		targetPath := strings.Replace(filePath, templateDirectory, "", 1)
-		targetPath = path.Join(directoryPath, targetPath)
+// createProjectFromFunction creates a project from a deployed function: its code
+// is downloaded, its config is generated (as well as it can be) from the
+// function's configuration, and the function is adopted into its state
+func createProjectFromFunction(name string) error {
+	directoryName, err := cli.PromptForStringWithDefault("Project name", strcase.ToKebab(name), nil)
+	if err != nil {
+		return err
+	}
+	directoryPath, err := templates.NewProjectPath(strcase.ToKebab(directoryName))
+	if err != nil {
+		return err
+	}
+	// Lambda functions are the only ones that can be imported (see kettle providers)
+	if err := importFunction("aws", "lambda", name, directoryPath, true); err != nil {
+		return err
+	}
+	fmt.Println("📝  Review kettle.json: it was generated from the function's configuration")
+	fmt.Println("\n✅  Created: ", directoryPath, fmt.Sprintf("(from %s)", name))
+	return nil
+}
 
-		// Create the target file
-		if err := createFile(targetPath, filePath, templateValues); err != nil {
-			return err
-		}
-		if strings.HasSuffix(targetPath, ".sh") {
-			if err := os.Chmod(targetPath, 0775); err != nil {
-				if settings.DebugMode {
-					fmt.Println(err.Error())
+// populateProject asks for the template's values (unless they have been set
+// with --set or --values, by the template's key, or are found by the value
+// providers in ~/.kettle.yaml), and creates the project's files and config
+// in directoryPath from the template
+func populateProject(templatePath string, templateConfig *config.Config, projectName, directoryPath string) error {
+	userSettings, err := settings.ReadSettings()
+	if err != nil {
+		return err
+	}
+	providers, err := templates.NewProviders(userSettings)
+	if err != nil {
+		return err
+	}
+	if createTutorial {
+		startTutorial(templateConfig)
+	}
+	result, err := scaffold.Render(templatePath, templateConfig, cli.Answers, directoryPath, &scaffold.Options{
+		ProjectName:    projectName,
+		Events:         scaffoldEvents,
+		Providers:      providers,
+		SkipValidation: createSkipValidation,
+		Prompt: func(templateEntry *config.TemplatePrompt) (string, error) {
+			if createTutorial {
+				for i, entry := range templateConfig.Template {
+					if entry == templateEntry {
+						explainPrompt(templateEntry, i+1, len(templateConfig.Template))
+					}
 				}
 			}
-		}
-		return nil
+			return promptForTemplateValue(templateEntry)
+		},
 	})
 	if err != nil {
-		return cleanUp(directoryPath, err)
+		return err
 	}
-
-	err = config.WriteConfig(directoryPath, templateConfig)
-	if err != nil {
-		return cleanUp(directoryPath, err)
+	if len(result.Provided) > 0 {
+		fmt.Println("🔌  Provided: ", strings.Join(result.Provided, ", "))
+	}
+	for _, leak := range result.Leaks {
+		fmt.Println("⚠️   " + policy.FormatLeak(leak))
+	}
+	for _, command := range result.Formatted {
+		fmt.Println("🧹  Formatted: ", command)
+	}
+	for _, command := range result.SkippedFormatting {
+		fmt.Println("⚠️   Skipped formatting (not installed): ", command)
+	}
+	for _, command := range result.Validated {
+		fmt.Println("✅  Validated: ", command)
+	}
+	for _, command := range result.SkippedValidation {
+		fmt.Println("⚠️   Skipped validation (not installed): ", command)
+	}
+	if createTutorial {
+		return walkThroughProject(templateConfig, directoryPath, result.Values)
 	}
-	fmt.Println("\n✅  Created: ", directoryPath)
 	return nil
 }
 
+// promptForTemplateValue asks for a template value, with a prompt for its type
+func promptForTemplateValue(templateEntry *config.TemplatePrompt) (string, error) {
+	switch templateEntry.Type {
+	case templates.PromptBool:
+		return cli.PromptForValueWithDefault(templateEntry.Prompt, map[string]string{
+			"yes": "true",
+			"no":  "false",
+		}, templateEntry.Default)
+	case templates.PromptSelect:
+		options := map[string]string{}
+		for _, option := range templateEntry.Options {
+			options[option] = option
+		}
+		return cli.PromptForValueWithDefault(templateEntry.Prompt, options, templateEntry.Default)
+	}
+	return cli.PromptForStringWithDefault(templateEntry.Prompt, templateEntry.Default, func(value string) error {
+		_, err := templates.GetValue(templateEntry, value)
+		return err
+	})
+}
+
 func createProjectDirectory() (string, string, error) {
 	// Prompt the user for a project name
 	directoryName, err := cli.PromptForString("Project name")
@@ -151,41 +242,6 @@ func createProjectDirectory() (string, string, error) {
 	return directoryName, directoryPath, nil
 }
 
-func createFile(targetPath, filePath string, templateValues interface{}) error {
-	// Read the source file
-	data, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		return err
-	}
-
-	// Create the parent directory
-	parentDir, _ := path.Split(targetPath)
-	err = os.MkdirAll(parentDir, os.ModePerm)
-	if err != nil {
-		return err
-	}
-
-	// Create the target file
-	f, err := os.Create(targetPath)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	// Populate the target file by executing the template
-	_, fileName := path.Split(filePath)
-	tmpl, err := template.New(fileName).Parse(string(data))
-	if err != nil {
-		return err
-	}
-
-	err = tmpl.Execute(f, templateValues)
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
 func cleanUp(directoryPath string, err error) error {
 	cleanupErr := os.RemoveAll(directoryPath)
 	if cleanupErr != nil {
@@ -1,16 +1,34 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/operatorai/kettle-cli/artifacts"
 	"github.com/operatorai/kettle-cli/clouds"
-	"github.com/operatorai/kettle-cli/config"
-	"github.com/operatorai/kettle-cli/settings"
-	"github.com/operatorai/kettle-cli/templates"
+	"github.com/operatorai/kettle-cli/docs"
+	"github.com/operatorai/kettle-cli/pkg/deploy"
+)
+
+// deployReportInvocations is how many times the function is invoked for the
+// performance report: a cold start, followed by warm starts
+const deployReportInvocations = 5
+
+var (
+	deployTTL      time.Duration
+	deployArtifact string
+	deployExplain  bool
+	deployReport   bool
+	deployParity   bool
+	// deployAllowSecrets deploys packages that look like they have secrets
+	deployAllowSecrets bool
+	// deployRequestQuotas files requests to increase the quotas that the deploy is likely to hit
+	deployRequestQuotas bool
 )
 
 var deployCmd = &cobra.Command{
@@ -23,82 +41,163 @@ var deployCmd = &cobra.Command{
 }
 
 func init() {
+	deployCmd.Flags().BoolVar(&previewStage, "preview", false, "Deploy an isolated copy of the project for the current pull request or git branch")
+	deployCmd.Flags().DurationVar(&deployTTL, "ttl", 0, "Expire the deployment after this long (e.g. 72h), so that it can be pruned")
+	deployCmd.Flags().StringVar(&deployArtifact, "artifact", "", "Deploy a version of the project from its artifact store, instead of building it")
+	deployCmd.Flags().BoolVar(&deployExplain, "explain", false, "Explain each of the project's resources after it is deployed, and which ones were created")
+	deployCmd.Flags().BoolVar(&deployReport, "report", false, "Invoke the function a few times after it is deployed, and report its cold and warm start performance")
+	deployCmd.Flags().BoolVar(&deployParity, "parity", false, "Run the project's tests in its Lambda runtime's container image before it is deployed")
+	deployCmd.Flags().BoolVar(&deployAllowSecrets, "allow-secrets", false, "Deploy even if the files that are packaged look like they have secrets")
+	deployCmd.Flags().BoolVar(&deployRequestQuotas, "request-quota-increases", false, "Request increases of the account quotas (e.g. Lambda concurrency) that the deploy is likely to hit")
+	addOutputFlag(deployCmd)
 	rootCmd.AddCommand(deployCmd)
 }
 
-func validateDeployArgs(cmd *cobra.Command, args []string) error {
-	// Validate that args exist
-	if len(args) == 0 {
-		return errors.New("please specify a path or directory name")
-	}
-	return nil
-}
-
 // runDeploy creates or updates a cloud function
 func runDeploy(cmd *cobra.Command, args []string) error {
-	// Construct the path we want to deploy from
-	deploymentPath, err := templates.GetProject(args)
-	if err != nil {
-		return formatError(err)
+	if deployAll {
+		if outputFormat == ndjsonOutput {
+			return formatError(errors.New("--output ndjson cannot be used with --all"))
+		}
+		if err := deployWorkspace(cmd, args); err != nil {
+			return formatError(err)
+		}
+		return nil
 	}
-
-	// Read the template's config
-	templateConfig, err := config.ReadConfig(deploymentPath)
+	stream, err := startEventStream()
 	if err != nil {
 		return formatError(err)
 	}
-
-	// Read global settings
-	cloudSettings, err := settings.ReadSettings()
+	err = deployArgs(args)
+	if stream != nil {
+		return stream.close(err)
+	}
 	if err != nil {
 		return formatError(err)
 	}
+	return nil
+}
 
-	// Get the cloud provider & service type
-	cloudProvider, err := clouds.GetCloudProvider(templateConfig.Config.CloudProvider)
+// deployArgs deploys the project at the path in args (or a version of
+// it from its artifact store, with --artifact)
+func deployArgs(args []string) error {
+	useSharedSettings()
+	p, err := loadProject(args)
 	if err != nil {
-		return formatError(err)
+		return err
 	}
-	if err := cloudProvider.Setup(cloudSettings); err != nil {
-		return formatError(err)
+	if deployArtifact != "" {
+		builder, ok := p.service.(clouds.Builder)
+		if !ok {
+			return fmt.Errorf("artifacts are not supported on %s %s deployments",
+				p.config.Config.CloudProvider,
+				p.config.Config.DeploymentType,
+			)
+		}
+		artifact, err := artifacts.Pull(p.config, builder.GetArtifactKind(), deployArtifact)
+		if err != nil {
+			return err
+		}
+		if builder.GetArtifactKind() == artifacts.Archive {
+			defer os.Remove(artifact.Location)
+		}
+		p.config.Artifact = artifact
 	}
+	return deployProject(p)
+}
 
-	service, err := cloudProvider.GetService(templateConfig.Config.DeploymentType)
+// deployProject deploys the project to its stage; promotions deploy
+// with the config's artifact set, so that it is not rebuilt
+func deployProject(p *project) error {
+	target := p.toDeploy()
+	if err := target.Prepare(); err != nil {
+		return err
+	}
+	if err := readDependencyOutputs(p); err != nil {
+		return err
+	}
+	confirmed, err := p.confirm("Deploy")
 	if err != nil {
-		return formatError(err)
+		return err
 	}
-
-	// Store the current directory before changing away from it
-	rootDir, err := os.Getwd()
+	if !confirmed {
+		return nil
+	}
+	result, err := target.Run(context.Background(), &deploy.Options{
+		TTL:                   deployTTL,
+		AllowSecrets:          deployAllowSecrets,
+		Parity:                deployParity,
+		RequestQuotaIncreases: deployRequestQuotas,
+	})
 	if err != nil {
-		return formatError(err)
+		return err
 	}
+	if err := writeDeployOutputs(result.Outputs); err != nil {
+		return err
+	}
+	fmt.Println("✅  Deployed!")
+	if p.preview && result.Outputs.URL != "" {
+		// The URL of a preview is printed on its own line, so that
+		// CI jobs can post it (e.g. as a pull request comment)
+		fmt.Println("🔗  Preview URL:", result.Outputs.URL)
+	}
+	if result.Outputs.URL != "" {
+		printExampleRequest(docs.ExampleRequest(p.config.ProjectName, result.Outputs.URL, result.Region, p.config))
+	}
+	if !result.Expires.IsZero() {
+		fmt.Println("⏳  Expires:", result.Expires.Local().Format(time.RFC1123), "(delete it with: kettle prune --expired)")
+	}
+	if deployReport {
+		if err := reportPerformance(p); err != nil {
+			return err
+		}
+	}
+	if deployExplain {
+		return explainResources(p, result.Previous)
+	}
+	return nil
+}
 
-	// Change to the directory where the function to deploy is implemented
-	// and run the deployment command
-	os.Chdir(deploymentPath)
-	defer func() {
-		// Return to the original root directory
-		os.Chdir(rootDir)
-	}()
-
-	// Deploy
-	if err := service.Deploy(deploymentPath, templateConfig, cloudSettings); err != nil {
-		return formatError(err)
+// printExampleRequest prints the example request (from the project's smoke
+// test) as ready-to-run curl and HTTPie commands
+func printExampleRequest(request *docs.Request) {
+	fmt.Println("💡  Try it with curl:")
+	fmt.Println(indent(request.Curl()))
+	if command := request.HTTPie(); command != "" {
+		fmt.Println("💡  Or with HTTPie:")
+		fmt.Println(indent(command))
 	}
+}
 
-	// Write the settings & config back (they may have been changed)
-	if err := settings.WriteSettings(cloudSettings); err != nil {
-		if settings.DebugMode {
-			fmt.Println(err.Error())
-		}
+// reportPerformance invokes the deployed function (with an empty JSON payload),
+// and prints how long its cold and warm starts took, with any suggestions
+func reportPerformance(p *project) error {
+	reporter, ok := p.service.(clouds.PerformanceReporter)
+	if !ok {
+		return fmt.Errorf("performance reports are not supported on %s %s deployments",
+			p.config.Config.CloudProvider,
+			p.config.Config.DeploymentType,
+		)
 	}
-	if err := config.WriteConfig(deploymentPath, templateConfig); err != nil {
-		if settings.DebugMode {
-			fmt.Println(err.Error())
+	report, err := reporter.ReportPerformance(p.config, p.settings, deployReportInvocations, []byte("{}"))
+	if err != nil {
+		return err
+	}
+	fmt.Println("📦  Package size: ", fmt.Sprintf("%.1f MB", float64(report.PackageSize)/(1024*1024)))
+	for i, invocation := range report.Invocations {
+		start := "warm"
+		if invocation.Cold {
+			start = fmt.Sprintf("cold, %.0f ms init", invocation.InitDuration)
 		}
+		fmt.Println(fmt.Sprintf("⏱   Invocation %d: ", i+1), fmt.Sprintf("%.0f ms (%s), %d of %d MB used",
+			invocation.Duration,
+			start,
+			invocation.MaxMemoryUsed,
+			report.MemorySize,
+		))
+	}
+	for _, suggestion := range report.Suggestions {
+		fmt.Println("💡  " + suggestion)
 	}
-
-	fmt.Println("✅  Deployed!")
 	return nil
 }
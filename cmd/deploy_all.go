@@ -0,0 +1,303 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/pkg/deploy"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+const (
+	// dependencyOutputsVariable passes the outputs of a function's dependencies
+	// (as JSON) to the kettle deploy that kettle deploy --all runs for it
+	dependencyOutputsVariable = "KETTLE_DEPENDENCY_OUTPUTS"
+	// deployOutputsVariable is the file that the deploy writes its outputs to
+	deployOutputsVariable = "KETTLE_DEPLOY_OUTPUTS"
+	// sharedSettingsVariable is set for the deploys that run at the same time as
+	// others, which do not write the settings file (so they do not overwrite each other's)
+	sharedSettingsVariable = "KETTLE_SHARED_SETTINGS"
+)
+
+var (
+	deployAll      bool
+	deployParallel int
+)
+
+// workspaceDeploy is the result of deploying one of the workspace's functions
+type workspaceDeploy struct {
+	function *config.WorkspaceFunction
+	output   []byte
+	outputs  map[string]string
+	err      error
+}
+
+func init() {
+	deployCmd.Flags().BoolVar(&deployAll, "all", false, "Deploy all of the functions in a workspace, in the order of their dependencies")
+	deployCmd.Flags().IntVar(&deployParallel, "parallel", 4, "How many of the workspace's functions are deployed at once (with --all)")
+}
+
+func validateDeployArgs(cmd *cobra.Command, args []string) error {
+	if deployAll {
+		return cobra.MaximumNArgs(1)(cmd, args)
+	}
+	return validateProjectArgs(cmd, args)
+}
+
+// deployWorkspace deploys the functions of a workspace: each one is deployed
+// after the functions that it depends on, with their outputs, and functions
+// that do not depend on each other are deployed at the same time
+func deployWorkspace(cmd *cobra.Command, args []string) error {
+	directory := "."
+	if len(args) == 1 {
+		directory = args[0]
+	}
+	workspace, err := config.ReadWorkspace(directory)
+	if err != nil {
+		return err
+	}
+	if len(workspace.Functions) == 0 {
+		return fmt.Errorf("there are no functions in the workspace: %s", directory)
+	}
+	if err := workspace.ReadAddOns(directory); err != nil {
+		return err
+	}
+	if err := workspace.ValidateDependencies(); err != nil {
+		return err
+	}
+	if deployParallel < 1 {
+		return errors.New("--parallel must be at least 1")
+	}
+	if !assumeYes {
+		if cli.NonInteractive {
+			return errors.New("deploying the workspace needs confirmation (use --yes in non-interactive mode)")
+		}
+		if !cli.PromptToConfirm(fmt.Sprintf("Deploy the %d functions in %s", len(workspace.Functions), directory)) {
+			return nil
+		}
+	}
+
+	flags := getPropagatedFlags(cmd)
+	outputs := map[string]map[string]string{}
+	deployed := map[string]bool{}
+	started := map[string]bool{}
+	failed := []string{}
+	results := make(chan *workspaceDeploy)
+	running := 0
+	for {
+		// The first function is deployed on its own, so that anything that the
+		// functions share (e.g. the settings, and the role) is only set up once
+		limit := deployParallel
+		if len(deployed) == 0 {
+			limit = 1
+		}
+		for _, function := range workspace.GetReadyFunctions(deployed, started) {
+			if running >= limit || len(failed) > 0 {
+				break
+			}
+			started[function.Name] = true
+			running++
+			fmt.Println("🚢  Deploying: ", function.Name)
+			inputs := getDependencyOutputs(workspace, function, outputs)
+			shared := limit > 1
+			go func(function *config.WorkspaceFunction) {
+				results <- deployWorkspaceFunction(directory, function, inputs, flags, shared)
+			}(function)
+		}
+		if running == 0 {
+			break
+		}
+		result := <-results
+		running--
+		fmt.Println(fmt.Sprintf("──── %s ────", result.function.Name))
+		os.Stdout.Write(result.output)
+		if result.err != nil {
+			fmt.Println("❌  "+result.function.Name+": ", result.err.Error())
+			failed = append(failed, result.function.Name)
+			continue
+		}
+		deployed[result.function.Name] = true
+		outputs[result.function.Name] = result.outputs
+	}
+
+	if len(failed) > 0 {
+		for _, function := range workspace.Functions {
+			if !deployed[function.Name] && !started[function.Name] {
+				fmt.Println("⏭  Skipped: ", function.Name)
+			}
+		}
+		return fmt.Errorf("%d function(s) failed to deploy: %s", len(failed), strings.Join(failed, ", "))
+	}
+	fmt.Println(fmt.Sprintf("✅  Deployed %d functions!", len(deployed)))
+	return nil
+}
+
+// getDependencyOutputs merges the outputs of the functions that the function depends
+// on; a dependency on an add-on only passes the add-on's connection details
+func getDependencyOutputs(workspace *config.Workspace, function *config.WorkspaceFunction, outputs map[string]map[string]string) map[string]string {
+	inputs := map[string]string{}
+	for _, dependency := range function.DependsOn {
+		addOn := workspace.GetAddOn(dependency)
+		if addOn == nil {
+			for key, value := range outputs[dependency] {
+				inputs[key] = value
+			}
+			continue
+		}
+		prefix := addOn.AddOn.EnvironmentPrefix() + "_"
+		for key, value := range outputs[addOn.Function] {
+			if strings.HasPrefix(key, prefix) {
+				inputs[key] = value
+			}
+		}
+	}
+	return inputs
+}
+
+// deployWorkspaceFunction runs kettle deploy for a function, and buffers its output
+// so that the output of functions that are deployed at the same time is not mixed;
+// a deploy that runs with others (shared) does not write the settings file
+func deployWorkspaceFunction(directory string, function *config.WorkspaceFunction, inputs map[string]string, flags []string, shared bool) *workspaceDeploy {
+	result := &workspaceDeploy{function: function}
+	executable, err := os.Executable()
+	if err != nil {
+		result.err = err
+		return result
+	}
+	encoded, err := json.Marshal(inputs)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	outputsFile, err := ioutil.TempFile("", "kettle-outputs")
+	if err != nil {
+		result.err = err
+		return result
+	}
+	outputsFile.Close()
+	defer os.Remove(outputsFile.Name())
+
+	args := append([]string{"deploy", filepath.Join(directory, function.Path), "--yes"}, flags...)
+	command := exec.Command(executable, args...)
+	command.Env = append(os.Environ(),
+		fmt.Sprintf("%s=%s", dependencyOutputsVariable, encoded),
+		fmt.Sprintf("%s=%s", deployOutputsVariable, outputsFile.Name()),
+		fmt.Sprintf("%s=%t", sharedSettingsVariable, shared),
+	)
+	var output bytes.Buffer
+	command.Stdout = &output
+	command.Stderr = &output
+	err = command.Run()
+	result.output = output.Bytes()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		result.err = fmt.Errorf("the deploy failed (exit status %d)", exitErr.ExitCode())
+		return result
+	}
+	if err != nil {
+		result.err = err
+		return result
+	}
+
+	data, err := ioutil.ReadFile(outputsFile.Name())
+	if err != nil {
+		result.err = err
+		return result
+	}
+	if len(data) == 0 {
+		// e.g. the deploy was not confirmed
+		result.err = errors.New("the deploy did not write its outputs")
+		return result
+	}
+	outputs := &deploy.Outputs{}
+	if err := json.Unmarshal(data, outputs); err != nil {
+		result.err = err
+		return result
+	}
+	result.outputs = getOutputEnvironment(outputs, function)
+	return result
+}
+
+// getPropagatedFlags returns the flags that kettle deploy --all was run with (e.g.
+// --stage or --region), which the deploys of the workspace's functions are run with
+func getPropagatedFlags(cmd *cobra.Command) []string {
+	flags := []string{}
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		switch flag.Name {
+		case "all", "parallel", "yes":
+			return
+		}
+		if values, ok := flag.Value.(pflag.SliceValue); ok {
+			for _, value := range values.GetSlice() {
+				flags = append(flags, fmt.Sprintf("--%s=%s", flag.Name, value))
+			}
+			return
+		}
+		flags = append(flags, fmt.Sprintf("--%s=%s", flag.Name, flag.Value.String()))
+	})
+	return flags
+}
+
+// useSharedSettings stops the deploy from writing the settings file, when
+// kettle deploy --all runs it at the same time as other deploys
+func useSharedSettings() {
+	if shared, err := strconv.ParseBool(os.Getenv(sharedSettingsVariable)); err == nil && shared {
+		settings.SharedFile = true
+	}
+}
+
+// readDependencyOutputs passes the outputs of the functions that the project
+// depends on to the deployment, when it is deployed by kettle deploy --all
+func readDependencyOutputs(p *project) error {
+	encoded := os.Getenv(dependencyOutputsVariable)
+	if encoded == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(encoded), &p.config.DependencyEnvironment)
+}
+
+// writeDeployOutputs writes the outputs of the deployment to the file that
+// kettle deploy --all reads them from, when it runs the deploy
+func writeDeployOutputs(outputs *deploy.Outputs) error {
+	outputsPath := os.Getenv(deployOutputsVariable)
+	if outputsPath == "" {
+		return nil
+	}
+	data, err := json.Marshal(outputs)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(outputsPath, data, 0600)
+}
+
+// getOutputEnvironment returns the outputs as the environment variables that
+// are passed to the functions that depend on the function, e.g. KETTLE_USERS_URL
+func getOutputEnvironment(outputs *deploy.Outputs, function *config.WorkspaceFunction) map[string]string {
+	prefix := function.OutputPrefix()
+	environment := map[string]string{
+		prefix + "_NAME": outputs.Name,
+	}
+	if outputs.URL != "" {
+		environment[prefix+"_URL"] = outputs.URL
+	}
+	if outputs.Arn != "" {
+		environment[prefix+"_ARN"] = outputs.Arn
+	}
+	for key, value := range outputs.AddOns {
+		environment[key] = value
+	}
+	return environment
+}
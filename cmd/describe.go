@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/tempdir"
+	"github.com/operatorai/kettle-cli/templates"
+)
+
+var describeCmd = &cobra.Command{
+	Use:   "describe <template>",
+	Short: "Describe a template: what it deploys, what it asks for, and what it returns",
+	Long: `📖 The kettle CLI tool can describe a template before a project is
+ created from it, using the metadata in its kettle.json.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDescribe,
+}
+
+func init() {
+	rootCmd.AddCommand(describeCmd)
+}
+
+func runDescribe(cmd *cobra.Command, args []string) error {
+	templatePath, isTempDir, err := templates.GetTemplate(args[0])
+	if err != nil {
+		return formatError(err)
+	}
+	if isTempDir {
+		defer tempdir.Remove(templatePath)
+	}
+	templateConfig, err := config.ReadConfig(templatePath)
+	if err != nil {
+		return formatError(err)
+	}
+
+	printEntry(&templates.Entry{
+		Name:           args[0],
+		Runtime:        templateConfig.Config.Runtime,
+		CloudProvider:  templateConfig.Config.CloudProvider,
+		DeploymentType: templateConfig.Config.DeploymentType,
+		Metadata:       templateConfig.Metadata,
+	})
+	if metadata := templateConfig.Metadata; metadata != nil {
+		if metadata.Maintainer != "" {
+			fmt.Println("    Maintainer: " + metadata.Maintainer)
+		}
+		for _, screenshot := range metadata.Screenshots {
+			fmt.Println("🖼   Screenshot: ", screenshot)
+		}
+	}
+	if len(templateConfig.Template) > 0 {
+		fmt.Println("📝  Prompts:")
+		for _, prompt := range templateConfig.Template {
+			line := fmt.Sprintf("    %s (%s)", prompt.Prompt, prompt.Key)
+			if prompt.Default != "" {
+				line += fmt.Sprintf(", default: %s", prompt.Default)
+			}
+			if len(prompt.Options) > 0 {
+				line += fmt.Sprintf(", one of: %s", strings.Join(prompt.Options, ", "))
+			}
+			fmt.Println(line)
+		}
+	}
+	if templateConfig.Metadata != nil && templateConfig.Metadata.ExampleOutput != "" {
+		fmt.Println("📤  Example output:")
+		fmt.Println(getExampleOutput(templatePath, templateConfig.Metadata.ExampleOutput))
+	}
+	return nil
+}
+
+// getExampleOutput returns the contents of the example output's
+// file in the template, or the example output itself (if it is text)
+func getExampleOutput(templatePath, exampleOutput string) string {
+	data, err := ioutil.ReadFile(filepath.Join(templatePath, exampleOutput))
+	if err != nil {
+		return exampleOutput
+	}
+	return strings.TrimRight(string(data), "\n")
+}
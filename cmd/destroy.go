@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/clouds"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+// destroyDryRun is set by the --dry-run flag, which lists the
+// resources that would be deleted without deleting them
+var destroyDryRun bool
+
+var destroyCmd = &cobra.Command{
+	Use:   "destroy",
+	Short: "Delete the cloud resources of a project you have deployed",
+	Long: `🗑  The kettle CLI tool can delete the cloud resources that it
+ created when deploying a project.`,
+	Args: validateProjectArgs,
+	RunE: runDestroy,
+}
+
+func init() {
+	destroyCmd.Flags().BoolVar(&previewStage, "preview", false, "Destroy the preview of the current pull request or git branch")
+	destroyCmd.Flags().BoolVar(&destroyDryRun, "dry-run", false, "List the resources that would be deleted, without deleting them")
+	rootCmd.AddCommand(destroyCmd)
+}
+
+func runDestroy(cmd *cobra.Command, args []string) error {
+	p, err := loadProject(args)
+	if err != nil {
+		return formatError(err)
+	}
+
+	destroyer, ok := p.service.(clouds.Destroyer)
+	if !ok {
+		return formatError(errors.New("destroy is not supported for this deployment type"))
+	}
+	if destroyDryRun {
+		return planDestroy(p)
+	}
+	confirmed, err := p.confirm("Destroy")
+	if err != nil {
+		return formatError(err)
+	}
+	if !confirmed {
+		return nil
+	}
+	unlock, err := p.lockState()
+	if err != nil {
+		return formatError(err)
+	}
+	defer unlock()
+	if err := destroyer.Destroy(p.path, p.config, p.settings); err != nil {
+		return formatError(err)
+	}
+	// The state of a stage is kept if it still tracks protected resources
+	st, err := state.ReadState(p.path)
+	if err != nil {
+		return formatError(err)
+	}
+	if !st.HasProtectedResources(p.config.Config.Protected) {
+		if err := state.DeleteStageState(p.path); err != nil {
+			return formatError(err)
+		}
+	}
+	p.save()
+	fmt.Println("✅  Destroyed!")
+	return nil
+}
+
+// planDestroy prints the resources that destroy would delete, in order
+func planDestroy(p *project) error {
+	planner, ok := p.service.(clouds.DestroyPlanner)
+	if !ok {
+		return formatError(errors.New("--dry-run is not supported for this deployment type"))
+	}
+	deleted, kept, err := planner.PlanDestroy(p.path, p.config)
+	if err != nil {
+		return formatError(err)
+	}
+	if len(deleted) == 0 {
+		fmt.Println("⏭  There are no resources to delete")
+	}
+	for _, resource := range deleted {
+		fmt.Println("🗑   Would delete: ", resource.Type, resource.ID)
+	}
+	for _, resource := range kept {
+		fmt.Println("⏭   Not deleted: ", resource.Describe(p.config.Config.Protected))
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/dev"
+	"github.com/operatorai/kettle-cli/templates"
+)
+
+var (
+	stopDev bool
+)
+
+var devCmd = &cobra.Command{
+	Use:   "dev <path> [-- command]",
+	Short: "Run a project locally, with local containers for its add-ons",
+	Long: `🧪 The kettle CLI tool can start local containers for a project's
+ add-ons, and run the project against them without deploying it.`,
+	Args: validateProjectArgs,
+	RunE: runDev,
+}
+
+func init() {
+	devCmd.Flags().BoolVar(&stopDev, "stop", false, "Stop the project's local containers")
+	rootCmd.AddCommand(devCmd)
+}
+
+func runDev(cmd *cobra.Command, args []string) error {
+	// Anything after -- is the command that runs the project locally
+	command := []string{}
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		command = args[dash:]
+		args = args[:dash]
+	}
+
+	projectPath, err := templates.GetProject(args)
+	if err != nil {
+		return formatError(err)
+	}
+	cfg, err := config.ReadConfig(projectPath)
+	if err != nil {
+		return formatError(err)
+	}
+
+	environment, err := dev.WriteComposeFile(projectPath, cfg)
+	if err != nil {
+		return formatError(err)
+	}
+	composeFile := path.Join(projectPath, dev.ComposeFileName)
+	if stopDev {
+		if err := cli.Execute("docker", []string{"compose", "-f", composeFile, "down"}, "Stopping local add-ons"); err != nil {
+			return formatError(err)
+		}
+		fmt.Println("✅  Stopped!")
+		return nil
+	}
+
+	if len(environment) > 0 {
+		err = cli.Execute("docker", []string{
+			"compose",
+			"-f", composeFile,
+			"up",
+			"--detach",
+		}, "Starting local add-ons")
+		if err != nil {
+			return formatError(err)
+		}
+	}
+
+	// The project's own environment is overridden by the local add-ons
+	for key, value := range cfg.Config.Environment {
+		if _, ok := environment[key]; !ok {
+			environment[key] = value
+		}
+	}
+
+	if len(command) == 0 {
+		fmt.Println("🧪  Point the project at its local add-ons with:")
+		keys := []string{}
+		for key := range environment {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Println(fmt.Sprintf("export %s=%s", key, environment[key]))
+		}
+		return nil
+	}
+
+	fmt.Println("🧪  Running: ", command)
+	osCmd := exec.Command(command[0], command[1:]...)
+	osCmd.Dir = projectPath
+	osCmd.Env = os.Environ()
+	for key, value := range environment {
+		osCmd.Env = append(osCmd.Env, fmt.Sprintf("%s=%s", key, value))
+	}
+	osCmd.Stdin = os.Stdin
+	osCmd.Stdout = os.Stdout
+	osCmd.Stderr = os.Stderr
+	if err := osCmd.Run(); err != nil {
+		return formatError(err)
+	}
+	return nil
+}
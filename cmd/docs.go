@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/docs"
+	"github.com/operatorai/kettle-cli/state"
+	"github.com/operatorai/kettle-cli/templates"
+)
+
+var docsCmd = &cobra.Command{
+	Use:   "docs",
+	Short: "Generate the docs of a deployed service (SERVICE.md)",
+	Long: `📝 The kettle CLI tool can document a project's service from its config
+ and state: its endpoint, an example request, its triggers, its environment
+ variables, its outputs, and how to roll it back. Once SERVICE.md has been
+ generated, each deploy (of the default stage) keeps it up to date.`,
+	Args: validateProjectArgs,
+	RunE: runDocs,
+}
+
+// docsPrint prints the docs, instead of writing them to SERVICE.md
+var docsPrint bool
+
+func init() {
+	docsCmd.Flags().BoolVar(&docsPrint, "print", false, "Print the docs, instead of writing them to "+docs.ServiceFileName)
+	rootCmd.AddCommand(docsCmd)
+}
+
+func runDocs(cmd *cobra.Command, args []string) error {
+	projectPath, err := templates.GetProject(args)
+	if err != nil {
+		return formatError(err)
+	}
+	cfg, err := config.ReadConfig(projectPath)
+	if err != nil {
+		return formatError(err)
+	}
+	st, err := state.ReadState(projectPath)
+	if err != nil {
+		return formatError(err)
+	}
+	if docsPrint {
+		rendered, err := docs.RenderServiceDocs(cfg, st)
+		if err != nil {
+			return formatError(err)
+		}
+		fmt.Print(string(rendered))
+		return nil
+	}
+	if err := docs.WriteServiceDocs(projectPath, cfg, st); err != nil {
+		return formatError(err)
+	}
+	fmt.Println("✅  Generated: ", filepath.Join(projectPath, docs.ServiceFileName))
+	return nil
+}
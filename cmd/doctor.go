@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/templates"
+)
+
+// doctorEndpointTimeout is how long an endpoint has to respond before it is unreachable
+const doctorEndpointTimeout = 5 * time.Second
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check kettle's configuration (e.g. for an air-gapped network)",
+	Long: `🩺 The kettle CLI tool can check that its configuration works: that the
+ cloud clis are installed, that the template bundle can be read, and
+ that the endpoints that it has been configured with can be reached.`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
+
+// doctor counts the problems that are found by the checks
+type doctor struct {
+	problems int
+}
+
+func (d *doctor) pass(check, detail string) {
+	fmt.Println("✅  "+check+": ", detail)
+}
+
+func (d *doctor) warn(check, detail string) {
+	fmt.Println("⚠️   "+check+": ", detail)
+}
+
+func (d *doctor) fail(check, detail string) {
+	fmt.Println("❌  "+check+": ", detail)
+	d.problems++
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	stg, err := settings.ReadSettings()
+	if err != nil {
+		return formatError(err)
+	}
+	d := &doctor{}
+	if settings.AirGapped {
+		d.pass("Mode", "air-gapped")
+	} else {
+		d.pass("Mode", "online")
+	}
+	d.checkTemplates()
+	d.checkNetwork(stg)
+	if stg.AWS != nil || stg.GoogleCloud == nil {
+		d.checkCli("aws")
+		if stg.AWS != nil {
+			d.checkRegion("aws", stg.AWS.DeploymentRegion)
+			d.checkEndpoints("aws", stg.AWS.Endpoints)
+		}
+	}
+	if stg.GoogleCloud != nil || stg.AWS == nil {
+		d.checkCli("gcloud")
+		if stg.GoogleCloud != nil {
+			d.checkRegion("gcloud", stg.GoogleCloud.DeploymentRegion)
+			d.checkEndpoints("gcloud", stg.GoogleCloud.Endpoints)
+		}
+	}
+
+	if d.problems > 0 {
+		return formatError(fmt.Errorf("found %d problem(s)", d.problems))
+	}
+	fmt.Println("✅  No problems found")
+	return nil
+}
+
+// checkTemplates checks where templates come from; in air-gapped
+// mode, the kettle-templates repository cannot be cloned
+func (d *doctor) checkTemplates() {
+	if settings.TemplateBundle == "" {
+		if settings.AirGapped {
+			d.warn("Templates", "only local paths and bundles can be used (set template_bundle to look templates up by name)")
+			return
+		}
+		d.pass("Templates", "github.com/operatorai/kettle-templates")
+		return
+	}
+	if !templates.IsBundle(settings.TemplateBundle) {
+		d.fail("Templates", fmt.Sprintf("the template bundle must be a .tar.gz: %s", settings.TemplateBundle))
+		return
+	}
+	count, err := templates.ValidateBundle(settings.TemplateBundle)
+	switch {
+	case err != nil:
+		d.fail("Templates", fmt.Sprintf("cannot read the template bundle %s: %s", settings.TemplateBundle, err))
+	case count == 0:
+		d.fail("Templates", fmt.Sprintf("the template bundle has no templates: %s", settings.TemplateBundle))
+	default:
+		d.pass("Templates", fmt.Sprintf("%s (%d templates)", settings.TemplateBundle, count))
+	}
+}
+
+// checkNetwork reports the proxy and CA bundle; the CA bundle
+// has already been loaded, so it is valid if it is set
+func (d *doctor) checkNetwork(stg *settings.Settings) {
+	for _, variable := range []string{"HTTPS_PROXY", "NO_PROXY"} {
+		value := os.Getenv(variable)
+		if value == "" {
+			value = os.Getenv(strings.ToLower(variable))
+		}
+		if value != "" {
+			d.pass(variable, value)
+		}
+	}
+	if bundlePath := getCABundle(stg); bundlePath != "" {
+		d.pass("CA bundle", bundlePath)
+	}
+}
+
+func (d *doctor) checkCli(command string) {
+	if _, err := exec.LookPath(command); err != nil {
+		d.fail(command, "the cli is not installed")
+		return
+	}
+	d.pass(command, "installed")
+}
+
+// checkRegion checks that a region is set in air-gapped mode,
+// where the public lists of regions may not be reachable
+func (d *doctor) checkRegion(cloud, region string) {
+	if settings.Region != "" {
+		region = settings.Region
+	}
+	switch {
+	case region == "" && settings.AirGapped:
+		d.fail(cloud+" region", "set the region in ~/.kettle.yaml (or with --region), so that it is not looked up")
+	case region == "":
+		d.warn(cloud+" region", "not set (it is chosen on the first deploy)")
+	default:
+		d.pass(cloud+" region", region)
+	}
+}
+
+// checkEndpoints checks that each endpoint override is an https URL, and that
+// it responds (with any status, as the request is not signed)
+func (d *doctor) checkEndpoints(cloud string, endpoints map[string]string) {
+	services := []string{}
+	for service := range endpoints {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	client := &http.Client{Timeout: doctorEndpointTimeout}
+	for _, service := range services {
+		check := fmt.Sprintf("%s %s endpoint", cloud, service)
+		endpoint, err := url.Parse(endpoints[service])
+		if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" {
+			d.fail(check, fmt.Sprintf("not an https URL: %s", endpoints[service]))
+			continue
+		}
+		response, err := client.Get(endpoint.String())
+		if err != nil {
+			d.fail(check, fmt.Sprintf("%s is unreachable: %s", endpoint, err))
+			continue
+		}
+		response.Body.Close()
+		d.pass(check, endpoint.String())
+	}
+}
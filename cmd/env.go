@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/clouds"
+	"github.com/operatorai/kettle-cli/config"
+)
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "List or change the environment variables of a deployed project",
+	Long: `🌱 The kettle CLI tool can list, set, and unset the environment
+ variables of a deployed function without redeploying it, and keeps
+ the project's kettle.json in sync.`,
+}
+
+var envListCmd = &cobra.Command{
+	Use:   "list <path>",
+	Short: "List the environment variables of the deployed project",
+	Args:  validateProjectArgs,
+	RunE:  runEnvList,
+}
+
+var envSetCmd = &cobra.Command{
+	Use:   "set <path> KEY=VALUE...",
+	Short: "Set environment variables of the deployed project",
+	Args:  validateEnvArgs,
+	RunE:  runEnvSet,
+}
+
+var envUnsetCmd = &cobra.Command{
+	Use:   "unset <path> KEY...",
+	Short: "Remove environment variables from the deployed project",
+	Args:  validateEnvArgs,
+	RunE:  runEnvUnset,
+}
+
+func init() {
+	envCmd.AddCommand(envListCmd)
+	envCmd.AddCommand(envSetCmd)
+	envCmd.AddCommand(envUnsetCmd)
+	rootCmd.AddCommand(envCmd)
+}
+
+func validateEnvArgs(cmd *cobra.Command, args []string) error {
+	if len(args) < 2 {
+		return errors.New("please specify a path or directory name, and the environment variables")
+	}
+	return nil
+}
+
+// loadEnvironmentEditor loads the project, if its service's
+// environment can be changed without a redeploy
+func loadEnvironmentEditor(args []string) (*project, clouds.EnvironmentEditor, error) {
+	p, err := loadProject(args)
+	if err != nil {
+		return nil, nil, err
+	}
+	editor, ok := p.service.(clouds.EnvironmentEditor)
+	if !ok {
+		return nil, nil, errors.New("changing the environment is not supported for this deployment type")
+	}
+	return p, editor, nil
+}
+
+// runEnvList prints the deployed project's environment variables;
+// the values of secrets (and references to them) are masked
+func runEnvList(cmd *cobra.Command, args []string) error {
+	p, editor, err := loadEnvironmentEditor(args)
+	if err != nil {
+		return formatError(err)
+	}
+	environment, err := editor.GetEnvironment(p.config, p.settings)
+	if err != nil {
+		return formatError(err)
+	}
+	if len(environment) == 0 {
+		fmt.Println("⏭  There are no environment variables")
+		return nil
+	}
+
+	keys := []string{}
+	for key := range environment {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Printf("%s=%s\n", key, config.MaskSecret(key, environment[key]))
+	}
+	return nil
+}
+
+func runEnvSet(cmd *cobra.Command, args []string) error {
+	set := map[string]string{}
+	for _, pair := range args[1:] {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return formatError(fmt.Errorf("invalid environment variable: %s (expected KEY=VALUE)", pair))
+		}
+		set[parts[0]] = parts[1]
+	}
+	return updateEnvironment(args, set, nil)
+}
+
+func runEnvUnset(cmd *cobra.Command, args []string) error {
+	return updateEnvironment(args, nil, args[1:])
+}
+
+// updateEnvironment changes the deployed project's environment, and then the
+// environment in kettle.json (of the stage, if it is not the default stage)
+func updateEnvironment(args []string, set map[string]string, unset []string) error {
+	p, editor, err := loadEnvironmentEditor(args[:1])
+	if err != nil {
+		return formatError(err)
+	}
+	confirmed, err := p.confirm("Update environment")
+	if err != nil {
+		return formatError(err)
+	}
+	if !confirmed {
+		return nil
+	}
+	if err := editor.UpdateEnvironment(p.config, p.settings, set, unset); err != nil {
+		return formatError(err)
+	}
+
+	projectConfig, err := config.ReadConfig(p.path)
+	if err != nil {
+		return formatError(err)
+	}
+	environment := projectConfig.GetEnvironment(p.config.Stage)
+	for key, value := range set {
+		environment[key] = value
+		fmt.Println("✅  Set: ", key)
+	}
+	for _, key := range unset {
+		delete(environment, key)
+		fmt.Println("✅  Unset: ", key)
+	}
+	if err := config.WriteConfig(p.path, projectConfig); err != nil {
+		return formatError(err)
+	}
+	return nil
+}
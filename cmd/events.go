@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/pkg/deploy"
+	"github.com/operatorai/kettle-cli/pkg/scaffold"
+)
+
+// ndjsonOutput streams a command's events to stdout as newline-delimited
+// JSON, one event per line, so that other tools (e.g. UIs) can show progress
+const ndjsonOutput = "ndjson"
+
+// outputFormat is set by the --output flag of the commands that stream events
+var outputFormat string
+
+// deployEvents receives the events of the deploy package; they
+// are printed, unless they are streamed with --output ndjson
+var deployEvents = printEvent
+
+// scaffoldEvents receives the events of the scaffold package, which
+// are only streamed (kettle create prints the project when it is done)
+var scaffoldEvents func(*scaffold.Event)
+
+// eventStream writes events to stdout as newline-delimited JSON; anything
+// else that is printed while it is open is sent as a log event
+type eventStream struct {
+	lock        sync.Mutex
+	encoder     *json.Encoder
+	stopCapture func()
+}
+
+func addOutputFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&outputFormat, "output", "text", "How to print progress: text, or ndjson (a JSON event per line, e.g. for UIs)")
+}
+
+// startEventStream opens an event stream when the output format is ndjson
+// (it returns nil for text); streams cannot prompt, so they are non-interactive
+func startEventStream() (*eventStream, error) {
+	switch outputFormat {
+	case "", "text":
+		return nil, nil
+	case ndjsonOutput:
+	default:
+		return nil, fmt.Errorf("unknown output format: %s (use text or ndjson)", outputFormat)
+	}
+	cli.NonInteractive = true
+	stream := &eventStream{
+		encoder: json.NewEncoder(os.Stdout),
+	}
+	stopCapture, err := deploy.CaptureOutput(stream.sendDeployEvent)
+	if err != nil {
+		return nil, err
+	}
+	stream.stopCapture = stopCapture
+	deployEvents = stream.sendDeployEvent
+	scaffoldEvents = stream.sendScaffoldEvent
+	return stream, nil
+}
+
+func (stream *eventStream) send(event interface{}) {
+	stream.lock.Lock()
+	defer stream.lock.Unlock()
+	stream.encoder.Encode(event)
+}
+
+func (stream *eventStream) sendDeployEvent(event *deploy.Event) {
+	stream.send(event)
+}
+
+func (stream *eventStream) sendScaffoldEvent(event *scaffold.Event) {
+	stream.send(event)
+}
+
+// close restores stdout, and sends the command's error (if it failed)
+// as an error event, instead of printing it; the error is returned (as
+// printed) so that kettle still exits with a non-zero status
+func (stream *eventStream) close(err error) error {
+	stream.stopCapture()
+	deployEvents = printEvent
+	scaffoldEvents = nil
+	if err == nil {
+		return nil
+	}
+	stream.send(&deploy.Event{
+		Time:    time.Now(),
+		Type:    deploy.EventError,
+		Message: err.Error(),
+	})
+	cli.RecordError(err)
+	return &printedError{err: err}
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/clouds"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec <path> -- <command> [args...]",
+	Short: "Run a cloud cli command with the project's account, region, and tags",
+	Long: `🛠  The kettle CLI tool can run an aws or gcloud command with the same
+ profile (or assumed role), project, and region that it deploys the project
+ with, for operations that kettle does not support yet. The project's name,
+ region, and tags are available to the command as ${KETTLE_NAME},
+ ${KETTLE_REGION}, and ${KETTLE_TAGS}. Each command is recorded in the
+ project's audit log (.kettle/audit.log).`,
+	Example: `  kettle exec ./my-project -- aws lambda get-function-concurrency --function-name '${KETTLE_NAME}'
+  kettle exec ./my-project --stage staging -- aws sqs create-queue --queue-name jobs --tags '${KETTLE_TAGS}'`,
+	Args: validateExecArgs,
+	RunE: runExec,
+}
+
+func init() {
+	execCmd.Flags().BoolVar(&previewStage, "preview", false, "Run the command against the preview of the current pull request or git branch")
+	rootCmd.AddCommand(execCmd)
+}
+
+func validateExecArgs(cmd *cobra.Command, args []string) error {
+	if cmd.ArgsLenAtDash() != 1 || len(args) < 2 {
+		return errors.New("please specify a path or directory name, then -- and the command to run")
+	}
+	return nil
+}
+
+func runExec(cmd *cobra.Command, args []string) error {
+	p, err := loadProject(args[:1])
+	if err != nil {
+		return formatError(err)
+	}
+	command := args[1]
+	commandContext, ok := p.cloud.(clouds.CommandContext)
+	if !ok {
+		return formatError(fmt.Errorf("exec is not supported on: %s", p.config.Config.CloudProvider))
+	}
+	if !isCloudCommand(commandContext, command) {
+		return formatError(fmt.Errorf("%s is not supported on %s projects (expected: %s)",
+			command,
+			p.config.Config.CloudProvider,
+			strings.Join(commandContext.GetCommands(), ", "),
+		))
+	}
+
+	environment := commandContext.GetCommandEnvironment(p.config, p.settings)
+	commandArgs := expandCommandArgs(args[2:], environment)
+	confirmed, err := p.confirm(fmt.Sprintf("Run %s on", command))
+	if err != nil {
+		return formatError(err)
+	}
+	if !confirmed {
+		return nil
+	}
+
+	commandEnvironment := []string{}
+	for key, value := range environment {
+		commandEnvironment = append(commandEnvironment, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(commandEnvironment)
+	exitCode, err := cli.ExecuteInteractively(command, commandArgs, commandEnvironment)
+	if err != nil {
+		return formatError(err)
+	}
+
+	entry := &state.AuditEntry{
+		Time:     time.Now().UTC(),
+		User:     config.LockOwner(),
+		Stage:    p.config.Stage,
+		Region:   p.cloud.GetRegion(p.settings),
+		Command:  command,
+		Args:     commandArgs,
+		ExitCode: exitCode,
+	}
+	if err := state.AppendAuditLog(p.path, entry); err != nil {
+		return formatError(err)
+	}
+	if exitCode != 0 {
+		return formatError(fmt.Errorf("%s exited with status %d", command, exitCode))
+	}
+	return nil
+}
+
+// isCloudCommand returns true if the command is one of the
+// cli commands of the cloud that the project is deployed to
+func isCloudCommand(commandContext clouds.CommandContext, command string) bool {
+	for _, cloudCommand := range commandContext.GetCommands() {
+		if command == cloudCommand {
+			return true
+		}
+	}
+	return false
+}
+
+// expandCommandArgs replaces the project's variables (e.g. ${KETTLE_TAGS})
+// in the command's arguments; other variables are left as they are
+func expandCommandArgs(args []string, environment map[string]string) []string {
+	expanded := []string{}
+	for _, arg := range args {
+		expanded = append(expanded, os.Expand(arg, func(name string) string {
+			if value, ok := environment[name]; ok && strings.HasPrefix(name, "KETTLE_") {
+				return value
+			}
+			return fmt.Sprintf("${%s}", name)
+		}))
+	}
+	return expanded
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/clouds"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain",
+	Short: "Explain the cloud resources that kettle has created for a project",
+	Long: `🧾 The kettle CLI tool can list each of the cloud resources that it
+ manages for a project, why it exists, and where to find it in
+ your cloud provider's console.`,
+	Args: validateProjectArgs,
+	RunE: runExplain,
+}
+
+func init() {
+	explainCmd.Flags().BoolVar(&previewStage, "preview", false, "Explain the preview of the current pull request or git branch")
+	rootCmd.AddCommand(explainCmd)
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	p, err := loadProject(args)
+	if err != nil {
+		return formatError(err)
+	}
+	if err := explainResources(p, nil); err != nil {
+		return formatError(err)
+	}
+	return nil
+}
+
+// explainResources prints the project's resources, with links to the cloud's
+// console; the resources that are not in existing were created by a deploy
+func explainResources(p *project, existing *state.State) error {
+	explainer, ok := p.cloud.(clouds.ResourceExplainer)
+	if !ok {
+		return fmt.Errorf("explain is not supported on: %s", p.config.Config.CloudProvider)
+	}
+	st, err := state.ReadState(p.path)
+	if err != nil {
+		return err
+	}
+	if len(st.Resources) == 0 {
+		return errors.New("the project has no resources (has it been deployed?)")
+	}
+
+	fmt.Println("🧾  Resources:")
+	for _, resource := range st.Resources {
+		explanation := explainer.ExplainResource(resource, p.settings)
+		description := resource.Describe(p.config.Config.Protected)
+		if existing != nil && existing.GetResource(resource.Type, resource.ID) == nil {
+			description += " (created)"
+		}
+		fmt.Println(fmt.Sprintf("    %s: %s", explanation.Kind, description))
+		if explanation.Reason != "" {
+			fmt.Println("        " + explanation.Reason)
+		}
+		if explanation.ConsoleURL != "" {
+			fmt.Println("        " + explanation.ConsoleURL)
+		}
+	}
+	return nil
+}
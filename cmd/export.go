@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/docs"
+	"github.com/operatorai/kettle-cli/state"
+	"github.com/operatorai/kettle-cli/templates"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export example requests to a deployed project as a Postman or Insomnia collection",
+	Long: `📮 The kettle CLI tool can export a collection with an example request
+ (from the project's smoke test) to each deployed stage of the project,
+ to import into Postman or Insomnia.`,
+	Example: `  kettle export ./users --format postman --file users.postman.json
+  kettle export ./users --format insomnia`,
+	Args: validateProjectArgs,
+	RunE: runExport,
+}
+
+var (
+	exportFormat string
+	exportFile   string
+)
+
+func init() {
+	exportCmd.Flags().StringVar(&exportFormat, "format", docs.FormatPostman, "The format of the collection: postman or insomnia")
+	exportCmd.Flags().StringVar(&exportFile, "file", "", "Write the collection to a file, instead of printing it")
+	rootCmd.AddCommand(exportCmd)
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	projectPath, err := templates.GetProject(args)
+	if err != nil {
+		return formatError(err)
+	}
+	cfg, err := config.ReadConfig(projectPath)
+	if err != nil {
+		return formatError(err)
+	}
+	requests, err := getStageRequests(projectPath, cfg)
+	if err != nil {
+		return formatError(err)
+	}
+	collection, err := docs.Collection(exportFormat, cfg.ProjectName, requests)
+	if err != nil {
+		return formatError(err)
+	}
+	if exportFile == "" {
+		fmt.Println(string(collection))
+		return nil
+	}
+	if err := ioutil.WriteFile(exportFile, collection, 0644); err != nil {
+		return formatError(err)
+	}
+	fmt.Println("✅  Exported: ", exportFile)
+	return nil
+}
+
+// getStageRequests returns the example request to each of the project's
+// stages (the default stage first) that has been deployed with an endpoint
+func getStageRequests(projectPath string, cfg *config.Config) ([]*docs.Request, error) {
+	stages := []string{}
+	for stage := range cfg.Config.Stages {
+		if stage != config.DefaultStage {
+			stages = append(stages, stage)
+		}
+	}
+	sort.Strings(stages)
+	stages = append([]string{config.DefaultStage}, stages...)
+
+	defer func(stage string) { state.Stage = stage }(state.Stage)
+	requests := []*docs.Request{}
+	for _, stage := range stages {
+		state.Stage = stage
+		if stage == config.DefaultStage {
+			state.Stage = ""
+		}
+		st, err := state.ReadState(projectPath)
+		if err != nil {
+			return nil, err
+		}
+		if url := st.Outputs["url"]; url != "" {
+			name := fmt.Sprintf("%s (%s)", cfg.ProjectName, stage)
+			requests = append(requests, docs.ExampleRequest(name, url, st.Region, cfg))
+		}
+	}
+	if len(requests) == 0 {
+		return nil, errors.New("the project has no endpoint (has it been deployed?)")
+	}
+	return requests, nil
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/clouds"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/templates"
+)
+
+var flagsCmd = &cobra.Command{
+	Use:   "flags",
+	Short: "List or change the feature flags of a project",
+	Long: `🚩 The kettle CLI tool can list, set, and unset a project's feature
+ flags, which it passes to the deployed function (as an environment
+ variable, or an SSM parameter), and keeps the project's kettle.json
+ in sync.`,
+	Example: `  kettle flags set ./users new-search
+  kettle flags set ./users new-search=false beta-pricing --stage staging
+  kettle flags unset ./users beta-pricing`,
+}
+
+var flagsListCmd = &cobra.Command{
+	Use:   "list <path>",
+	Short: "List the feature flags of the project's stage",
+	Args:  validateProjectArgs,
+	RunE:  runFlagsList,
+}
+
+var flagsSetCmd = &cobra.Command{
+	Use:   "set <path> NAME[=true|false]...",
+	Short: "Turn feature flags on (or off) in the deployed project",
+	Args:  validateFlagsArgs,
+	RunE:  runFlagsSet,
+}
+
+var flagsUnsetCmd = &cobra.Command{
+	Use:   "unset <path> NAME...",
+	Short: "Remove feature flags from the deployed project",
+	Args:  validateFlagsArgs,
+	RunE:  runFlagsUnset,
+}
+
+func init() {
+	flagsCmd.AddCommand(flagsListCmd)
+	flagsCmd.AddCommand(flagsSetCmd)
+	flagsCmd.AddCommand(flagsUnsetCmd)
+	rootCmd.AddCommand(flagsCmd)
+}
+
+func validateFlagsArgs(cmd *cobra.Command, args []string) error {
+	if len(args) < 2 {
+		return errors.New("please specify a path or directory name, and the feature flags")
+	}
+	return nil
+}
+
+// runFlagsList prints the feature flags of the stage (from kettle.json), and
+// whether each one is set by the stage or by the project's defaults
+func runFlagsList(cmd *cobra.Command, args []string) error {
+	projectPath, err := templates.GetProject(args)
+	if err != nil {
+		return formatError(err)
+	}
+	cfg, err := config.ReadConfig(projectPath)
+	if err != nil {
+		return formatError(err)
+	}
+	stage := stageName
+	if stage == config.DefaultStage {
+		stage = ""
+	}
+	if stage != "" {
+		if _, ok := cfg.Config.Stages[stage]; !ok {
+			return formatError(fmt.Errorf("stage %s is not in the project's config", stage))
+		}
+	}
+	cfg.Stage = stage
+	flags := cfg.DeployFlags()
+	if len(flags) == 0 {
+		fmt.Println("⏭  There are no feature flags")
+		return nil
+	}
+
+	names := []string{}
+	for name := range flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		source := ""
+		if stage != "" && cfg.Config.Stages[stage].Flags != nil {
+			if _, ok := cfg.Config.Stages[stage].Flags[name]; ok {
+				source = fmt.Sprintf(" (%s)", stage)
+			}
+		}
+		fmt.Printf("%s=%t%s\n", name, flags[name], source)
+	}
+	return nil
+}
+
+func runFlagsSet(cmd *cobra.Command, args []string) error {
+	set := map[string]bool{}
+	for _, arg := range args[1:] {
+		parts := strings.SplitN(arg, "=", 2)
+		if err := config.ValidateFlagName(parts[0]); err != nil {
+			return formatError(err)
+		}
+		enabled := true
+		if len(parts) == 2 {
+			value, err := strconv.ParseBool(parts[1])
+			if err != nil {
+				return formatError(fmt.Errorf("invalid feature flag: %s (expected NAME, or NAME=true|false)", arg))
+			}
+			enabled = value
+		}
+		set[parts[0]] = enabled
+	}
+	return updateFlags(args, set, nil)
+}
+
+func runFlagsUnset(cmd *cobra.Command, args []string) error {
+	return updateFlags(args, nil, args[1:])
+}
+
+// updateFlags changes the feature flags in kettle.json (of the stage, if it is
+// not the default stage), and then passes them to the deployed project: flags in
+// SSM are changed in place, and the function's environment is updated otherwise
+func updateFlags(args []string, set map[string]bool, unset []string) error {
+	p, err := loadProject(args[:1])
+	if err != nil {
+		return formatError(err)
+	}
+	store, _ := p.service.(clouds.FlagStore)
+	editor, _ := p.service.(clouds.EnvironmentEditor)
+	if p.config.GetFlagStore() == config.FlagStoreSSM && store == nil {
+		return formatError(errors.New("storing feature flags in SSM is not supported for this deployment type"))
+	}
+	if p.config.GetFlagStore() == config.FlagStoreEnv && editor == nil {
+		return formatError(errors.New("changing feature flags without a redeploy is not supported for this deployment type (try: kettle deploy)"))
+	}
+	confirmed, err := p.confirm("Update feature flags")
+	if err != nil {
+		return formatError(err)
+	}
+	if !confirmed {
+		return nil
+	}
+
+	changeFlags := func(cfg *config.Config) {
+		flags := cfg.GetFlags(p.config.Stage)
+		for name, enabled := range set {
+			flags[name] = enabled
+		}
+		for _, name := range unset {
+			delete(flags, name)
+		}
+	}
+	changeFlags(p.config)
+	if p.config.GetFlagStore() == config.FlagStoreSSM {
+		err = store.PutFlags(p.path, p.config, p.settings)
+	} else {
+		err = editor.UpdateEnvironment(p.config, p.settings, map[string]string{
+			"KETTLE_FLAGS": p.config.EncodeFlags(),
+		}, nil)
+	}
+	if err != nil {
+		return formatError(err)
+	}
+
+	projectConfig, err := config.ReadConfig(p.path)
+	if err != nil {
+		return formatError(err)
+	}
+	changeFlags(projectConfig)
+	for name, enabled := range set {
+		fmt.Printf("✅  Set: %s=%t\n", name, enabled)
+	}
+	for _, name := range unset {
+		fmt.Println("✅  Unset: ", name)
+	}
+	if err := config.WriteConfig(p.path, projectConfig); err != nil {
+		return formatError(err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/clouds"
+)
+
+var forceUnlockCmd = &cobra.Command{
+	Use:   "force-unlock",
+	Short: "Release the state lock of a project",
+	Long: `🔓 The kettle CLI tool locks a project's remote state while it deploys or
+ destroys the project. If a deploy is interrupted, its lock may not be released:
+ the force-unlock command releases it.
+
+Only use this command if you are sure that nobody else is deploying the project.`,
+	Args: validateProjectArgs,
+	RunE: runForceUnlock,
+}
+
+func init() {
+	forceUnlockCmd.Flags().BoolVar(&previewStage, "preview", false, "Release the lock of the current pull request or git branch's preview")
+	rootCmd.AddCommand(forceUnlockCmd)
+}
+
+func runForceUnlock(cmd *cobra.Command, args []string) error {
+	p, err := loadProject(args)
+	if err != nil {
+		return formatError(err)
+	}
+	if p.config.Config.StateBackend == nil {
+		return formatError(errors.New("the project does not have a state backend"))
+	}
+	backend, ok := p.cloud.(clouds.StateBackend)
+	if !ok {
+		return formatError(fmt.Errorf("state backends are not supported on: %s", p.config.Config.CloudProvider))
+	}
+	confirmed, err := p.confirm("Release the lock on")
+	if err != nil {
+		return formatError(err)
+	}
+	if !confirmed {
+		return nil
+	}
+	if err := backend.Unlock(p.config, p.settings); err != nil {
+		return formatError(err)
+	}
+	fmt.Println("🔓  Unlocked!")
+	return nil
+}
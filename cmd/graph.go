@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/clouds"
+	"github.com/operatorai/kettle-cli/graph"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Draw the resources that kettle manages for a project, and how they are related",
+	Long: `🕸  The kettle CLI tool can print a graph of a project's resources (its
+ function, API, triggers, and add-ons) and their relationships, as Graphviz
+ DOT or a Mermaid flowchart, to review and document its architecture.
+ Projects that have not been deployed are drawn from their config.`,
+	Example: `  kettle graph ./users | dot -Tsvg > users.svg
+  kettle graph ./users --format mermaid`,
+	Args: validateProjectArgs,
+	RunE: runGraph,
+}
+
+// graphFormat is dot or mermaid
+var graphFormat string
+
+func init() {
+	graphCmd.Flags().StringVar(&graphFormat, "format", "dot", "The format of the graph: dot (Graphviz) or mermaid")
+	graphCmd.Flags().BoolVar(&previewStage, "preview", false, "Draw the preview of the current pull request or git branch")
+	rootCmd.AddCommand(graphCmd)
+}
+
+func runGraph(cmd *cobra.Command, args []string) error {
+	if graphFormat != "dot" && graphFormat != "mermaid" {
+		return formatError(fmt.Errorf("unknown graph format: %s (use dot or mermaid)", graphFormat))
+	}
+	p, err := loadProject(args)
+	if err != nil {
+		return formatError(err)
+	}
+	st, err := state.ReadState(p.path)
+	if err != nil {
+		return formatError(err)
+	}
+
+	// Projects that have not been deployed are drawn from their config
+	g := graph.FromConfig(p.config)
+	if len(st.Resources) > 0 {
+		g = graph.FromState(p.config.ProjectName, st)
+		if explainer, ok := p.cloud.(clouds.ResourceExplainer); ok {
+			for _, node := range g.Nodes {
+				if explanation := explainer.ExplainResource(node.Resource, p.settings); explanation.Kind != "" {
+					node.Kind = explanation.Kind
+				}
+			}
+		}
+	}
+	if graphFormat == "mermaid" {
+		fmt.Print(g.Mermaid())
+		return nil
+	}
+	fmt.Print(g.DOT())
+	return nil
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/iancoleman/strcase"
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/clouds"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+	"github.com/operatorai/kettle-cli/templates"
+)
+
+var (
+	importCloudProvider  string
+	importDeploymentType string
+	importDownloadCode   bool
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Adopt an existing cloud function into a new kettle project",
+	Long: `📥 The kettle CLI tool can bring a function that was created
+ outside of kettle (e.g. in the cloud console) under kettle management.
+
+The import command creates a project directory with a config file that is
+generated from the function's live configuration.`,
+	Args: validateImportArgs,
+	RunE: runImport,
+}
+
+func init() {
+	importCmd.Flags().StringVar(&importCloudProvider, "cloud", "aws", "Cloud provider of the function")
+	importCmd.Flags().StringVar(&importDeploymentType, "type", "lambda", "Deployment type of the function")
+	importCmd.Flags().BoolVar(&importDownloadCode, "download-code", false, "Download the function's current code")
+	rootCmd.AddCommand(importCmd)
+}
+
+func validateImportArgs(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return errors.New("please specify the name of the function to import")
+	}
+	return nil
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	// Validate that the project directory does not exist
+	directoryPath, err := templates.NewProjectPath(strcase.ToKebab(args[0]))
+	if err != nil {
+		return formatError(err)
+	}
+	if err := importFunction(importCloudProvider, importDeploymentType, args[0], directoryPath, importDownloadCode); err != nil {
+		return formatError(err)
+	}
+	fmt.Println("\n✅  Imported: ", args[0], "into", directoryPath)
+	return nil
+}
+
+// importFunction adopts a function into a new project in directoryPath (which
+// must not exist yet): its config is generated from the function's live
+// configuration, and the function's resources are recorded in its state
+func importFunction(cloudType, deploymentType, name, directoryPath string, downloadCode bool) error {
+	cloudSettings, err := settings.ReadSettings()
+	if err != nil {
+		return err
+	}
+
+	cloudProvider, err := clouds.GetCloudProvider(cloudType)
+	if err != nil {
+		return err
+	}
+	if err := cloudProvider.Setup(cloudSettings); err != nil {
+		return err
+	}
+	// Functions are imported from the default region, unless --region is set
+	if err := selectRegion(cloudProvider, cloudSettings, nil, cloudProvider.GetRegion(cloudSettings)); err != nil {
+		return err
+	}
+	service, err := cloudProvider.GetService(deploymentType)
+	if err != nil {
+		return err
+	}
+	importer, ok := service.(clouds.Importer)
+	if !ok {
+		return fmt.Errorf("importing is not supported for: %s", deploymentType)
+	}
+
+	projectConfig, projectState, err := importer.Import(name, cloudSettings)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Mkdir(directoryPath, os.ModePerm); err != nil {
+		return err
+	}
+	if downloadCode {
+		if err := importer.DownloadCode(name, directoryPath); err != nil {
+			return cleanUp(directoryPath, err)
+		}
+	}
+	if err := config.WriteConfig(directoryPath, projectConfig); err != nil {
+		return cleanUp(directoryPath, err)
+	}
+	projectState.Region = cloudProvider.GetRegion(cloudSettings)
+	if err := state.WriteState(directoryPath, projectState); err != nil {
+		return cleanUp(directoryPath, err)
+	}
+	if err := settings.WriteSettings(cloudSettings); err != nil {
+		if settings.DebugMode {
+			fmt.Println(err.Error())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/clouds"
+	"github.com/operatorai/kettle-cli/loadtest"
+)
+
+const (
+	loadTestRequestTimeout = 30 * time.Second
+)
+
+var (
+	loadTestRate         int
+	loadTestDuration     time.Duration
+	loadTestURL          string
+	loadTestMethod       string
+	loadTestBody         string
+	loadTestDirect       bool
+	loadTestMaxP99       time.Duration
+	loadTestMaxErrorRate float64
+)
+
+var loadTestCmd = &cobra.Command{
+	Use:   "loadtest",
+	Short: "Send a constant rate of requests to a deployed project",
+	Long: `📈 The kettle CLI tool can load test a deployed project, and report
+ its latency, errors, throttles, and cold starts.`,
+	Args: validateProjectArgs,
+	RunE: runLoadTest,
+}
+
+func init() {
+	loadTestCmd.Flags().IntVar(&loadTestRate, "rps", 10, "Requests per second")
+	loadTestCmd.Flags().DurationVar(&loadTestDuration, "duration", 30*time.Second, "How long to send requests for")
+	loadTestCmd.Flags().StringVar(&loadTestURL, "url", "", "URL to send requests to (defaults to the deployed endpoint)")
+	loadTestCmd.Flags().StringVar(&loadTestMethod, "method", http.MethodPost, "HTTP method")
+	loadTestCmd.Flags().StringVar(&loadTestBody, "body", "{}", "Request body (or direct invocation payload)")
+	loadTestCmd.Flags().BoolVar(&loadTestDirect, "direct", false, "Invoke the function directly, instead of through its endpoint")
+	loadTestCmd.Flags().DurationVar(&loadTestMaxP99, "max-p99", 0, "Fail if the p99 latency is above this threshold")
+	loadTestCmd.Flags().Float64Var(&loadTestMaxErrorRate, "max-error-rate", -1, "Fail if the error rate (%) is above this threshold")
+	rootCmd.AddCommand(loadTestCmd)
+}
+
+func runLoadTest(cmd *cobra.Command, args []string) error {
+	if loadTestRate <= 0 {
+		return formatError(errors.New("--rps must be greater than zero"))
+	}
+	p, err := loadProject(args)
+	if err != nil {
+		return formatError(err)
+	}
+
+	var invoke loadtest.Invoke
+	if loadTestDirect {
+		invoker, ok := p.service.(clouds.Invoker)
+		if !ok {
+			return formatError(errors.New("direct invocation is not supported for this deployment type"))
+		}
+		fmt.Println("📈  Invoking: ", p.config.ProjectName, fmt.Sprintf("(%d rps for %s)", loadTestRate, loadTestDuration))
+		invoke = func() loadtest.Outcome {
+			if err := invoker.Invoke(p.config, p.settings, []byte(loadTestBody)); err != nil {
+				return loadtest.Failed
+			}
+			return loadtest.Succeeded
+		}
+	} else {
+		url := loadTestURL
+		if url == "" {
+			provider, ok := p.service.(clouds.EndpointProvider)
+			if !ok {
+				return formatError(errors.New("this deployment type has no endpoint (try: --url or --direct)"))
+			}
+			url, err = provider.GetEndpoint(p.config, p.settings)
+			if err != nil {
+				return formatError(err)
+			}
+		}
+		fmt.Println("📈  Requesting: ", url, fmt.Sprintf("(%d rps for %s)", loadTestRate, loadTestDuration))
+		client := &http.Client{Timeout: loadTestRequestTimeout}
+		invoke = func() loadtest.Outcome {
+			return sendRequest(client, url)
+		}
+	}
+
+	report := loadtest.Run(loadTestRate, loadTestDuration, invoke)
+	if counter, ok := p.service.(clouds.ColdStartCounter); ok {
+		report.ColdStarts, err = counter.CountColdStarts(p.config, p.settings, report.Start, time.Now())
+		if err != nil {
+			report.ColdStarts = -1
+		}
+	} else {
+		report.ColdStarts = -1
+	}
+	printLoadTestReport(report)
+
+	failures := []string{}
+	if loadTestMaxP99 > 0 && report.Percentile(99) > loadTestMaxP99 {
+		failures = append(failures, fmt.Sprintf("p99 latency %s is above %s", report.Percentile(99), loadTestMaxP99))
+	}
+	if loadTestMaxErrorRate >= 0 && report.ErrorRate() > loadTestMaxErrorRate {
+		failures = append(failures, fmt.Sprintf("error rate %.2f%% is above %.2f%%", report.ErrorRate(), loadTestMaxErrorRate))
+	}
+	if len(failures) > 0 {
+		return formatError(fmt.Errorf("the load test breached its thresholds: %s", strings.Join(failures, ", ")))
+	}
+	fmt.Println("✅  Load test complete!")
+	return nil
+}
+
+func sendRequest(client *http.Client, url string) loadtest.Outcome {
+	request, err := http.NewRequest(loadTestMethod, url, bytes.NewBufferString(loadTestBody))
+	if err != nil {
+		return loadtest.Failed
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := client.Do(request)
+	if err != nil {
+		return loadtest.Failed
+	}
+	defer response.Body.Close()
+	switch {
+	case response.StatusCode == http.StatusTooManyRequests:
+		return loadtest.Throttled
+	case response.StatusCode >= 400:
+		return loadtest.Failed
+	}
+	return loadtest.Succeeded
+}
+
+func printLoadTestReport(report *loadtest.Report) {
+	fmt.Println("📊  Requests: ", report.Requests)
+	fmt.Println("⏱   Latency: ", fmt.Sprintf("p50 %s, p90 %s, p99 %s, max %s",
+		report.Percentile(50).Round(time.Millisecond),
+		report.Percentile(90).Round(time.Millisecond),
+		report.Percentile(99).Round(time.Millisecond),
+		report.Percentile(100).Round(time.Millisecond),
+	))
+	fmt.Println("💥  Errors: ", report.Errors, fmt.Sprintf("(%.2f%%)", report.ErrorRate()))
+	fmt.Println("🚦  Throttles: ", report.Throttles)
+	if report.ColdStarts < 0 {
+		fmt.Println("🥶  Cold starts: unknown")
+	} else {
+		fmt.Println("🥶  Cold starts: ", report.ColdStarts)
+	}
+}
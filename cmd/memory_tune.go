@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/clouds"
+	"github.com/operatorai/kettle-cli/config"
+)
+
+var (
+	memoryTuneSizes       []int
+	memoryTuneInvocations int
+	memoryTunePayload     string
+	memoryTuneStrategy    string
+	memoryTuneApply       bool
+)
+
+var memoryTuneCmd = &cobra.Command{
+	Use:   "memory-tune <path>",
+	Short: "Find the memory setting with the best cost and latency for a deployed function",
+	Long: `🎛 The kettle CLI tool can invoke a deployed function with each of a range
+ of memory settings, compare their cost and latency, and recommend (and
+ apply) the one that is best.`,
+	Args: validateProjectArgs,
+	RunE: runMemoryTune,
+}
+
+func init() {
+	memoryTuneCmd.Flags().IntSliceVar(&memoryTuneSizes, "memory", []int{128, 256, 512, 1024, 1536, 2048, 3008}, "Memory settings to try (in MB)")
+	memoryTuneCmd.Flags().IntVar(&memoryTuneInvocations, "invocations", 5, "Invocations to measure with each memory setting")
+	memoryTuneCmd.Flags().StringVar(&memoryTunePayload, "payload", "{}", "The payload to invoke the function with")
+	memoryTuneCmd.Flags().StringVar(&memoryTuneStrategy, "strategy", config.TuneForBalanced, "What to optimize for: cost, speed, or balanced")
+	memoryTuneCmd.Flags().BoolVar(&memoryTuneApply, "apply", false, "Set the recommended memory in kettle.json")
+	rootCmd.AddCommand(memoryTuneCmd)
+}
+
+func runMemoryTune(cmd *cobra.Command, args []string) error {
+	if memoryTuneInvocations <= 0 {
+		return formatError(errors.New("--invocations must be greater than zero"))
+	}
+	for _, memory := range memoryTuneSizes {
+		if memory < 128 || memory > 10240 {
+			return formatError(fmt.Errorf("invalid memory setting: %d (expected 128 to 10240 MB)", memory))
+		}
+	}
+	p, err := loadProject(args)
+	if err != nil {
+		return formatError(err)
+	}
+	tuner, ok := p.service.(clouds.MemoryTuner)
+	if !ok {
+		return formatError(fmt.Errorf("memory tuning is not supported on %s %s deployments",
+			p.config.Config.CloudProvider,
+			p.config.Config.DeploymentType,
+		))
+	}
+	// The function's memory is changed while it is tuned
+	confirmed, err := p.confirm("Tune memory")
+	if err != nil {
+		return formatError(err)
+	}
+	if !confirmed {
+		return nil
+	}
+
+	results, err := tuner.TuneMemory(p.config, p.settings, memoryTuneSizes, memoryTuneInvocations, []byte(memoryTunePayload))
+	if err != nil {
+		return formatError(err)
+	}
+	recommended, err := config.RecommendMemory(results, memoryTuneStrategy)
+	if err != nil {
+		return formatError(err)
+	}
+	for _, result := range results {
+		fmt.Println(fmt.Sprintf("📊  %5d MB: ", result.Memory), fmt.Sprintf("%8.1f ms, $%.2f per million invocations",
+			result.Duration,
+			result.Cost*1000000,
+		))
+	}
+	fmt.Println(fmt.Sprintf("💡  Recommended (%s): ", memoryTuneStrategy), fmt.Sprintf("%d MB", recommended.Memory))
+	if !memoryTuneApply {
+		return nil
+	}
+
+	projectConfig, err := config.ReadConfig(p.path)
+	if err != nil {
+		return formatError(err)
+	}
+	projectConfig.Config.Memory = recommended.Memory
+	if err := config.WriteConfig(p.path, projectConfig); err != nil {
+		return formatError(err)
+	}
+	fmt.Println("✅  Memory: ", fmt.Sprintf("%d MB in kettle.json (deploy, or run kettle apply --fix-drift, to apply it)", recommended.Memory))
+	return nil
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/state"
+)
+
+var outputCmd = &cobra.Command{
+	Use:   "output",
+	Short: "Print the outputs of a deployed project (e.g. its URL)",
+	Long: `📤 The kettle CLI tool records the outputs of each deploy (e.g. the
+ endpoint's URL, the function's ARN, and the names of its add-ons), which
+ other projects can refer to in their config as ${kettle:<project>.<output>}.`,
+	Example: `  kettle output ./users
+  kettle output ./users url`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runOutput,
+}
+
+func init() {
+	rootCmd.AddCommand(outputCmd)
+}
+
+func runOutput(cmd *cobra.Command, args []string) error {
+	p, err := loadProject(args[:1])
+	if err != nil {
+		return formatError(err)
+	}
+	st, err := state.ReadState(p.path)
+	if err != nil {
+		return formatError(err)
+	}
+	if len(st.Outputs) == 0 {
+		return formatError(fmt.Errorf("%s has no outputs (has it been deployed?)", p.config.ProjectName))
+	}
+
+	// A single output is printed on its own, so that it can be used in scripts
+	if len(args) == 2 {
+		value, ok := st.Outputs[args[1]]
+		if !ok {
+			return formatError(fmt.Errorf("%s has no output %s", p.config.ProjectName, args[1]))
+		}
+		fmt.Println(value)
+		return nil
+	}
+	names := []string{}
+	for name := range st.Outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Println(fmt.Sprintf("%s: %s", name, st.Outputs[name]))
+	}
+	return nil
+}
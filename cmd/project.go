@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/clouds"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/pkg/deploy"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+	"github.com/operatorai/kettle-cli/templates"
+)
+
+// project is a kettle project that has been read from disk, alongside
+// the global settings and the cloud service that it is deployed to
+type project struct {
+	path     string
+	config   *config.Config
+	settings *settings.Settings
+	cloud    clouds.Cloud
+	service  clouds.Service
+	// Previews are isolated copies of the project, e.g. for a pull request
+	preview bool
+	// The deployment (and its stage) whose artifact is being promoted
+	promotedFrom  *state.Deployment
+	promotedStage string
+}
+
+// assumeYes is set by the --yes flag, which skips confirmation prompts
+var assumeYes bool
+
+// previewStage is set by the --preview flag of the commands
+// that can act on a preview of the project
+var previewStage bool
+
+// stageName is set by the --stage flag, which selects a named stage
+// of the project (e.g. staging); the default stage is prod
+var stageName string
+
+func validateProjectArgs(cmd *cobra.Command, args []string) error {
+	// Validate that args exist
+	if len(args) == 0 {
+		return errors.New("please specify a path or directory name")
+	}
+	return nil
+}
+
+func loadProject(args []string) (*project, error) {
+	// Construct the path we want to deploy from
+	deploymentPath, err := templates.GetProject(args)
+	if err != nil {
+		return nil, err
+	}
+	loaded, err := deploy.Load(deploymentPath, &deploy.Options{
+		Stage:   stageName,
+		Preview: previewStage,
+		Region:  settings.Region,
+		Events:  deployEvents,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &project{
+		path:     loaded.Path,
+		config:   loaded.Config,
+		settings: loaded.Settings,
+		cloud:    loaded.Cloud,
+		service:  loaded.Service,
+		preview:  loaded.Preview,
+	}, nil
+}
+
+// toDeploy returns the project for the deploy package, which
+// shares the project's config and settings
+func (p *project) toDeploy() *deploy.Project {
+	return &deploy.Project{
+		Path:          p.path,
+		Config:        p.config,
+		Settings:      p.settings,
+		Cloud:         p.cloud,
+		Service:       p.service,
+		Preview:       p.preview,
+		PromotedFrom:  p.promotedFrom,
+		PromotedStage: p.promotedStage,
+		Events:        deployEvents,
+	}
+}
+
+// printEvent prints the events of the deploy package; the steps that
+// are started are only printed in debug mode, as the spinners show them
+func printEvent(event *deploy.Event) {
+	switch event.Type {
+	case deploy.EventStep:
+		if settings.DebugMode {
+			fmt.Println("▶️   " + event.Message)
+		}
+	case deploy.EventInfo:
+		fmt.Println("✅  " + event.Message)
+	case deploy.EventWarning:
+		fmt.Println("⚠️   " + event.Message)
+	case deploy.EventViolation:
+		fmt.Println("⛔  " + event.Message)
+	case deploy.EventError:
+		fmt.Println("❌  " + event.Message)
+	default:
+		fmt.Println(event.Message)
+	}
+}
+
+// changeDirectory moves into the project directory, and returns
+// a function that returns to the original root directory
+func (p *project) changeDirectory() (func(), error) {
+	return p.toDeploy().ChangeDirectory()
+}
+
+// lockState acquires the lock on the project's remote state (if it has a state
+// backend) and downloads it, and returns a function that uploads the state and
+// releases the lock
+func (p *project) lockState() (func(), error) {
+	return p.toDeploy().LockState()
+}
+
+// confirm shows which account (or project) and region the project is about
+// to change, and asks to continue; the prompt is skipped with --yes
+func (p *project) confirm(action string) (bool, error) {
+	account, err := p.cloud.GetAccount(p.settings)
+	if err != nil {
+		return false, err
+	}
+	fmt.Println("☁️   Account: ", account)
+	fmt.Println("🌍  Region: ", p.cloud.GetRegion(p.settings))
+	if assumeYes {
+		return true, nil
+	}
+	if cli.NonInteractive {
+		return false, fmt.Errorf("%s %s needs confirmation (use --yes in non-interactive mode)", action, p.config.ProjectName)
+	}
+	return cli.PromptToConfirm(fmt.Sprintf("%s %s", action, p.config.ProjectName)), nil
+}
+
+// save writes the settings & config back (they may have been changed)
+func (p *project) save() {
+	p.toDeploy().Save()
+}
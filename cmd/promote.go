@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/clouds"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+var (
+	promoteFrom string
+	promoteTo   string
+)
+
+var promoteCmd = &cobra.Command{
+	Use:   "promote",
+	Short: "Promote the artifact that is deployed in one stage to another",
+	Long: `⏫ The kettle CLI tool can re-deploy the exact archive or image
+ that is deployed in one stage (e.g. staging) to another (e.g. prod),
+ without rebuilding it.`,
+	Args: validateProjectArgs,
+	RunE: runPromote,
+}
+
+func init() {
+	promoteCmd.Flags().StringVar(&promoteFrom, "from", "", "The stage to promote the deployed artifact from (e.g. staging)")
+	promoteCmd.Flags().StringVar(&promoteTo, "to", config.DefaultStage, "The stage to deploy the artifact to")
+	rootCmd.AddCommand(promoteCmd)
+}
+
+// runPromote pulls the artifact of the source stage's last deployment,
+// and deploys it to the target stage
+func runPromote(cmd *cobra.Command, args []string) error {
+	if promoteFrom == "" {
+		return formatError(errors.New("please specify the stage to promote from (--from)"))
+	}
+	if promoteFrom == promoteTo {
+		return formatError(errors.New("--from and --to must be different stages"))
+	}
+
+	stageName = promoteFrom
+	source, err := loadProject(args)
+	if err != nil {
+		return formatError(err)
+	}
+	promoter, ok := source.service.(clouds.Promoter)
+	if !ok {
+		return formatError(fmt.Errorf("promotion is not supported on %s %s deployments",
+			source.config.Config.CloudProvider,
+			source.config.Config.DeploymentType,
+		))
+	}
+	st, err := state.ReadState(source.path)
+	if err != nil {
+		return formatError(err)
+	}
+	deployment := st.GetLastDeployment()
+	if deployment == nil || deployment.Artifact == "" {
+		return formatError(fmt.Errorf("stage %s does not have a recorded artifact to promote", promoteFrom))
+	}
+
+	fmt.Println("⏫  Promoting: ", deployment.Artifact, fmt.Sprintf("(%s → %s)", promoteFrom, promoteTo))
+	artifact, err := promoter.PullArtifact(source.config, source.settings, deployment.Artifact)
+	if err != nil {
+		return formatError(err)
+	}
+	if _, err := os.Stat(artifact.Location); err == nil {
+		// Downloaded archives are removed once they have been deployed
+		defer os.Remove(artifact.Location)
+	}
+	source.save()
+
+	stageName = promoteTo
+	target, err := loadProject(args)
+	if err != nil {
+		return formatError(err)
+	}
+	target.config.Artifact = artifact
+	target.promotedFrom = deployment
+	target.promotedStage = promoteFrom
+	if err := deployProject(target); err != nil {
+		return formatError(err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/clouds"
+)
+
+var providersMarkdown bool
+
+var providersCmd = &cobra.Command{
+	Use:   "providers",
+	Short: "List the clouds and services that kettle deploys to, and the features that each supports",
+	Long: `☁️  The kettle CLI tool lists the deploy providers (each cloud's services),
+ and which of kettle's features (e.g. triggers, custom domains, canaries,
+ GPUs, and secrets) each of them supports.`,
+	Example: `  kettle providers
+  kettle providers --markdown > PROVIDERS.md`,
+	Args: cobra.NoArgs,
+	RunE: runProviders,
+}
+
+func init() {
+	providersCmd.Flags().BoolVar(&providersMarkdown, "markdown", false, "Print the providers' features as a markdown table (e.g. to track their parity)")
+	rootCmd.AddCommand(providersCmd)
+}
+
+func runProviders(cmd *cobra.Command, args []string) error {
+	capabilities, err := clouds.GetCapabilities()
+	if err != nil {
+		return formatError(err)
+	}
+	if providersMarkdown {
+		printProvidersTable(capabilities)
+		return nil
+	}
+	for _, provider := range capabilities {
+		fmt.Println(fmt.Sprintf("☁️   %s %s", provider.CloudProvider, provider.DeploymentType))
+		for _, feature := range clouds.Features {
+			if !provider.Features[feature.Name] {
+				fmt.Println(fmt.Sprintf("    ➖  %s", feature.Name))
+				continue
+			}
+			if feature.Name == "secrets" && provider.SecretStore != "" {
+				fmt.Println(fmt.Sprintf("    ✅  %s (%s)", feature.Name, provider.SecretStore))
+				continue
+			}
+			fmt.Println(fmt.Sprintf("    ✅  %s", feature.Name))
+		}
+	}
+	return nil
+}
+
+// printProvidersTable prints a markdown table with a row for each feature,
+// and a column for each provider
+func printProvidersTable(capabilities []*clouds.Capabilities) {
+	header := []string{"Feature"}
+	divider := []string{"---"}
+	for _, provider := range capabilities {
+		header = append(header, fmt.Sprintf("%s %s", provider.CloudProvider, provider.DeploymentType))
+		divider = append(divider, ":---:")
+	}
+	fmt.Println("| " + strings.Join(header, " | ") + " |")
+	fmt.Println("| " + strings.Join(divider, " | ") + " |")
+	for _, feature := range clouds.Features {
+		row := []string{fmt.Sprintf("%s: %s", feature.Name, feature.Description)}
+		for _, provider := range capabilities {
+			switch {
+			case !provider.Features[feature.Name]:
+				row = append(row, "")
+			case feature.Name == "secrets" && provider.SecretStore != "":
+				row = append(row, "✅ "+provider.SecretStore)
+			default:
+				row = append(row, "✅")
+			}
+		}
+		fmt.Println("| " + strings.Join(row, " | ") + " |")
+	}
+}
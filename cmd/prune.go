@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/clouds"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+var (
+	pruneExpired bool
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Find (and delete) deployments that have expired",
+	Long: `✂️  The kettle CLI tool can find the projects in a directory that were
+ deployed with a TTL (kettle deploy --ttl 72h), and delete the ones that have expired.
+
+Without --expired, the prune command only lists the deployments and when they expire.`,
+	RunE: runPrune,
+}
+
+func init() {
+	pruneCmd.Flags().BoolVar(&pruneExpired, "expired", false, "Delete the deployments that have expired")
+	rootCmd.AddCommand(pruneCmd)
+}
+
+func runPrune(cmd *cobra.Command, args []string) error {
+	directory := "."
+	if len(args) > 0 {
+		directory = args[0]
+	}
+	projects, err := findExpiringProjects(directory)
+	if err != nil {
+		return formatError(err)
+	}
+	if len(projects) == 0 {
+		fmt.Println("🤷  No deployments with a TTL were found in:", directory)
+		return nil
+	}
+
+	expired := []string{}
+	for _, projectPath := range projects {
+		st, err := state.ReadState(projectPath)
+		if err != nil {
+			return formatError(err)
+		}
+		expires, err := st.GetExpiry()
+		if err != nil {
+			return formatError(err)
+		}
+		if st.HasExpired() {
+			fmt.Println("⌛  Expired: ", projectPath, fmt.Sprintf("(%s ago)", time.Since(expires).Round(time.Minute)))
+			expired = append(expired, projectPath)
+		} else {
+			fmt.Println("⏳  Expires: ", projectPath, fmt.Sprintf("(in %s)", time.Until(expires).Round(time.Minute)))
+		}
+	}
+	if !pruneExpired || len(expired) == 0 {
+		return nil
+	}
+
+	if !assumeYes && !cli.PromptToConfirm(fmt.Sprintf("Destroy %d expired deployment(s)", len(expired))) {
+		return nil
+	}
+	for _, projectPath := range expired {
+		if err := pruneProject(projectPath); err != nil {
+			return formatError(err)
+		}
+	}
+	fmt.Println("✅  Pruned!")
+	return nil
+}
+
+// findExpiringProjects returns the project directories (below the given one)
+// whose state has an expiry
+func findExpiringProjects(directory string) ([]string, error) {
+	projects := []string{}
+	err := filepath.Walk(directory, func(projectPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		exists, err := config.HasConfigFile(projectPath)
+		if err != nil || !exists {
+			return err
+		}
+		st, err := state.ReadState(projectPath)
+		if err != nil {
+			return err
+		}
+		if st.Expires != "" {
+			projects = append(projects, projectPath)
+		}
+		return nil
+	})
+	return projects, err
+}
+
+func pruneProject(projectPath string) error {
+	p, err := loadProject([]string{projectPath})
+	if err != nil {
+		return err
+	}
+	destroyer, ok := p.service.(clouds.Destroyer)
+	if !ok {
+		return errors.New("destroy is not supported for this deployment type")
+	}
+	account, err := p.cloud.GetAccount(p.settings)
+	if err != nil {
+		return err
+	}
+	fmt.Println("🗑   Destroying: ", p.config.ProjectName, fmt.Sprintf("(%s, %s)", account, p.cloud.GetRegion(p.settings)))
+	if err := destroyer.Destroy(p.path, p.config, p.settings); err != nil {
+		return err
+	}
+
+	// The expiry no longer applies once the deployment has been deleted
+	st, err := state.ReadState(p.path)
+	if err != nil {
+		return err
+	}
+	st.Expires = ""
+	return state.WriteState(p.path, st)
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+const readOnlyEnvironmentVariable = "KETTLE_READ_ONLY"
+
+// mutation describes when a command changes a project's resources (or its
+// remote state), if it only does with some of its flags
+type mutation struct {
+	// The flag that the command changes resources with (e.g. apply --fix-drift)
+	with string
+	// The flag that the command does not change resources with (e.g. destroy --dry-run)
+	without string
+}
+
+// mutatingCommands are the commands that read-only mode refuses before they run;
+// the cloud commands that the others run are checked too, in case they change anything
+var mutatingCommands = map[string]*mutation{
+	"add trigger":   {},
+	"apply":         {with: "fix-drift"},
+	"bootstrap":     {},
+	"bootstrap-iam": {with: "create-role"},
+	"build":         {},
+	"deploy":        {},
+	"destroy":       {without: "dry-run"},
+	"env set":       {},
+	"env unset":     {},
+	"flags set":     {},
+	"flags unset":   {},
+	"force-unlock":  {},
+	"loadtest":      {},
+	"memory-tune":   {},
+	"promote":       {},
+	"prune":         {with: "expired"},
+	"refresh":       {},
+	"rename":        {},
+	"run-job":       {},
+	"upgrade-all":   {with: "pr"},
+}
+
+// setReadOnlyMode enables the read-only mode if it is set by the --read-only
+// flag, the KETTLE_READ_ONLY environment variable, or read_only: true in
+// ~/.kettle.yaml, and refuses the command if it changes resources
+func setReadOnlyMode(cmd *cobra.Command, stg *settings.Settings) error {
+	if enabled, err := strconv.ParseBool(os.Getenv(readOnlyEnvironmentVariable)); err == nil && enabled {
+		cli.ReadOnly = true
+	}
+	if stg.ReadOnly {
+		cli.ReadOnly = true
+	}
+	if !cli.ReadOnly {
+		return nil
+	}
+	name := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	m, ok := mutatingCommands[name]
+	if !ok {
+		return nil
+	}
+	if m.with != "" && !isFlagSet(cmd, m.with) {
+		return nil
+	}
+	if m.without != "" && isFlagSet(cmd, m.without) {
+		return nil
+	}
+	return fmt.Errorf("%w: refusing to run kettle %s", cli.ErrReadOnly, name)
+}
+
+func isFlagSet(cmd *cobra.Command, name string) bool {
+	flag := cmd.Flags().Lookup(name)
+	return flag != nil && flag.Changed && flag.Value.String() != "false"
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/clouds"
+	"github.com/operatorai/kettle-cli/refresh"
+)
+
+var (
+	refreshForce    bool
+	refreshWorkflow bool
+)
+
+var refreshCmd = &cobra.Command{
+	Use:   "refresh <path>",
+	Short: "Update a project's dependencies, test it, and redeploy it",
+	Long: `🔄 The kettle CLI tool can refresh a deployed project's dependencies, so
+ that it does not keep running with vulnerable ones: it updates them within the
+ versions that the project allows, runs its tests, redeploys it, and runs its
+ smoke test. With --workflow, it generates a GitHub Actions workflow that
+ refreshes the project on a schedule.`,
+	Args: validateProjectArgs,
+	RunE: runRefresh,
+}
+
+func init() {
+	refreshCmd.Flags().BoolVar(&refreshForce, "force", false, "Redeploy the project even if its dependencies did not change")
+	refreshCmd.Flags().BoolVar(&refreshWorkflow, "workflow", false, "Generate a GitHub Actions workflow that refreshes the project on its schedule")
+	rootCmd.AddCommand(refreshCmd)
+}
+
+func runRefresh(cmd *cobra.Command, args []string) error {
+	p, err := loadProject(args)
+	if err != nil {
+		return formatError(err)
+	}
+	if refreshWorkflow {
+		workflowPath, err := refresh.WriteWorkflow(args[0], p.config)
+		if err != nil {
+			return formatError(err)
+		}
+		fmt.Println("📝  Workflow: ", workflowPath, fmt.Sprintf("(%s)", p.config.RefreshSchedule()))
+		return nil
+	}
+
+	changed, err := updateAndTest(p)
+	if err != nil {
+		return formatError(err)
+	}
+	if !changed && !refreshForce {
+		fmt.Println("⏭  Dependencies are up to date")
+		return nil
+	}
+	if err := deployProject(p); err != nil {
+		return formatError(err)
+	}
+	if err := runSmokeTest(p); err != nil {
+		return formatError(err)
+	}
+	return nil
+}
+
+// updateAndTest updates the dependencies in the project directory, and runs
+// the tests if they changed; it returns true if they changed
+func updateAndTest(p *project) (bool, error) {
+	returnToRoot, err := p.changeDirectory()
+	if err != nil {
+		return false, err
+	}
+	defer returnToRoot()
+
+	changed, err := refresh.UpdateDependencies(".", p.config)
+	if err != nil {
+		return false, err
+	}
+	if !changed && !refreshForce {
+		return false, nil
+	}
+	return changed, refresh.RunTests(p.config)
+}
+
+// runSmokeTest runs the smoke test against the redeployed endpoint
+// (services without an endpoint are not smoke tested)
+func runSmokeTest(p *project) error {
+	provider, ok := p.service.(clouds.EndpointProvider)
+	if !ok {
+		fmt.Println("⏭  No smoke test (the deployment type has no endpoint)")
+		return nil
+	}
+	url, err := provider.GetEndpoint(p.config, p.settings)
+	if err != nil {
+		return err
+	}
+	return refresh.RunSmokeTest(p.path, url, p.config)
+}
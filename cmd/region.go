@@ -0,0 +1,15 @@
+package cmd
+
+import (
+	"github.com/operatorai/kettle-cli/clouds"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/pkg/deploy"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+// selectRegion sets the deployment region from the --region flag, or the region
+// that the project's stage was deployed to; otherwise, it prompts for one of the
+// regions where the project's services are available, defaulting to the last choice
+func selectRegion(cloud clouds.Cloud, stg *settings.Settings, cfg *config.Config, deployedRegion string) error {
+	return deploy.SelectRegion(cloud, stg, cfg, settings.Region, deployedRegion)
+}
@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/iancoleman/strcase"
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/clouds"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+// renameGrace is set by the --grace flag, which is how long the resources of
+// the project's previous name are kept (and its API path is served)
+var renameGrace time.Duration
+
+// renameRetire is set by the --retire flag, which deletes the resources of the
+// project's previous name; renameForce deletes them before the grace period is over
+var (
+	renameRetire bool
+	renameForce  bool
+)
+
+var renameCmd = &cobra.Command{
+	Use:   "rename <path> <new-name>",
+	Short: "Rename a project you have deployed",
+	Long: `✏️  The kettle CLI tool can rename a deployed project: it deploys a
+ function with the new name, moves the project's triggers to it, and points
+ the old API path at it; the old function and its resources keep running
+ until they are deleted, after a grace period, with --retire.`,
+	Example: `  kettle rename ./users accounts
+  kettle rename ./users accounts --stage staging --grace 24h
+  kettle rename ./accounts --retire`,
+	Args: validateRenameArgs,
+	RunE: runRename,
+}
+
+func init() {
+	renameCmd.Flags().DurationVar(&renameGrace, "grace", 72*time.Hour, "How long to keep the resources of the previous name")
+	renameCmd.Flags().BoolVar(&renameRetire, "retire", false, "Delete the resources of the previous name")
+	renameCmd.Flags().BoolVar(&renameForce, "force", false, "Delete the resources of the previous name before the grace period is over")
+	rootCmd.AddCommand(renameCmd)
+}
+
+func validateRenameArgs(cmd *cobra.Command, args []string) error {
+	if renameRetire {
+		return validateProjectArgs(cmd, args)
+	}
+	if len(args) != 2 {
+		return errors.New("please specify a path or directory name, and the project's new name")
+	}
+	return nil
+}
+
+func runRename(cmd *cobra.Command, args []string) error {
+	p, err := loadProject(args[:1])
+	if err != nil {
+		return formatError(err)
+	}
+	renamer, ok := p.service.(clouds.Renamer)
+	if !ok {
+		return formatError(errors.New("rename is not supported for this deployment type"))
+	}
+	if renameRetire {
+		return retireName(p, renamer)
+	}
+	if len(p.config.Config.AddOns) != 0 || p.config.Config.Static != nil || len(p.config.Config.ApiStages) != 0 {
+		return formatError(errors.New("projects with add-ons, static sites, or API stages cannot be renamed"))
+	}
+	if p.config.Config.StateBackend != nil {
+		// The remote state is stored under the project's name
+		return formatError(errors.New("projects with a state backend cannot be renamed"))
+	}
+	st, err := state.ReadState(p.path)
+	if err != nil {
+		return formatError(err)
+	}
+
+	// The stage's resources are named with the naming convention; kettle.json
+	// may already have the new name (e.g. if another stage was renamed first,
+	// or if the rename is being resumed)
+	newName := strcase.ToKebab(args[1])
+	renamed := *p.config
+	renamed.BaseName = newName
+	renamed.SetStage(p.config.Stage)
+
+	oldName := ""
+	functions := st.GetResources(state.AWSLambdaFunction)
+	if len(functions) != 0 {
+		oldName = functions[0].ID
+	}
+	switch {
+	case st.Retiring != nil && oldName != "" && oldName != renamed.ProjectName:
+		return formatError(fmt.Errorf("the resources of %s are still retiring (delete them first with: kettle rename %s --retire)", st.Retiring.Name, args[0]))
+	case st.Retiring != nil:
+		fmt.Println("⏭  Resuming the rename of: ", st.Retiring.Name)
+		oldName = st.Retiring.Name
+	case oldName == "":
+		return formatError(errors.New("the project has not been deployed (try: kettle deploy)"))
+	case oldName == renamed.ProjectName:
+		return formatError(fmt.Errorf("the project is already named %s", newName))
+	}
+
+	fmt.Println("✏️   Rename: ", oldName, "->", renamed.ProjectName)
+	p.config.ProjectName = oldName
+	confirmed, err := p.confirm("Rename")
+	if err != nil {
+		return formatError(err)
+	}
+	if !confirmed {
+		return nil
+	}
+
+	after := time.Now().Add(renameGrace)
+	if st.Retiring == nil {
+		if err := renamer.RetireName(p.path, p.config, p.settings, after); err != nil {
+			return formatError(err)
+		}
+	}
+	if st, err = state.ReadState(p.path); err != nil {
+		return formatError(err)
+	}
+	if after, err = st.Retiring.GetAfter(); err != nil {
+		return formatError(err)
+	}
+
+	p.config.BaseName = newName
+	p.config.SetStage(p.config.Stage)
+	projectConfig, err := config.ReadConfig(p.path)
+	if err != nil {
+		return formatError(err)
+	}
+	if projectConfig.ProjectName != newName {
+		projectConfig.ProjectName = newName
+		if err := config.WriteConfig(p.path, projectConfig); err != nil {
+			return formatError(err)
+		}
+	}
+
+	// The new function is added to the REST API if the old one was in it;
+	// the rename has already been confirmed, so the deploy is not
+	cli.Answers[cli.AnswerKey("Add Lambda function to a REST API")] = fmt.Sprintf("%t", st.Retiring.GetResource(state.AWSRestApiResource) != nil)
+	defer func(yes bool) { assumeYes = yes }(assumeYes)
+	assumeYes = true
+	if err := deployProject(p); err != nil {
+		return formatError(err)
+	}
+	if err := renamer.Cutover(p.path, p.config, p.settings); err != nil {
+		return formatError(err)
+	}
+
+	fmt.Println("✅  Renamed: ", oldName, "->", p.config.ProjectName)
+	fmt.Println("⏳  Retiring: ", oldName, "until", after.Local().Format(time.RFC1123), fmt.Sprintf("(delete it with: kettle rename %s --retire)", args[0]))
+	if len(p.config.Config.Stages) != 0 {
+		fmt.Println("💡  Rename the project's other stages with: kettle rename", args[0], newName, "--stage <stage>")
+	}
+	return nil
+}
+
+// retireName deletes the resources of the project's previous name, once
+// the grace period is over (or before it, with --force)
+func retireName(p *project, renamer clouds.Renamer) error {
+	st, err := state.ReadState(p.path)
+	if err != nil {
+		return formatError(err)
+	}
+	if st.Retiring == nil {
+		return formatError(errors.New("the project has no retiring resources"))
+	}
+	after, err := st.Retiring.GetAfter()
+	if err != nil {
+		return formatError(err)
+	}
+	if time.Now().Before(after) && !renameForce {
+		return formatError(fmt.Errorf("%s is retiring until %s (delete it now with --force)", st.Retiring.Name, after.Local().Format(time.RFC1123)))
+	}
+	confirmed, err := p.confirm(fmt.Sprintf("Delete the resources of %s, the previous name of", st.Retiring.Name))
+	if err != nil {
+		return formatError(err)
+	}
+	if !confirmed {
+		return nil
+	}
+	unlock, err := p.lockState()
+	if err != nil {
+		return formatError(err)
+	}
+	defer unlock()
+	if err := renamer.DeleteRetired(p.path, p.config, p.settings); err != nil {
+		return formatError(err)
+	}
+	fmt.Println("✅  Retired: ", st.Retiring.Name)
+	return nil
+}
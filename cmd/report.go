@@ -0,0 +1,361 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/policy"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+	"github.com/operatorai/kettle-cli/templates"
+)
+
+const (
+	// reportAuditLogEntries is how many of the last entries of the project's audit log are reported
+	reportAuditLogEntries = 200
+	reportRedactedValue   = "********"
+)
+
+// reportClis are the clis that kettle runs, whose versions are reported
+var reportClis = []string{"aws", "gcloud", "docker", "git", "gh"}
+
+// reportEnvironment are the environment variables that change how kettle
+// and the clouds' clis behave; credentials are only reported as set
+var reportEnvironment = map[string]bool{
+	"AWS_PROFILE":                    false,
+	"AWS_REGION":                     false,
+	"AWS_DEFAULT_REGION":             false,
+	"AWS_ACCESS_KEY_ID":              true,
+	"AWS_SECRET_ACCESS_KEY":          true,
+	"AWS_SESSION_TOKEN":              true,
+	"CLOUDSDK_CORE_PROJECT":          false,
+	"GOOGLE_CLOUD_PROJECT":           false,
+	"GOOGLE_APPLICATION_CREDENTIALS": true,
+	"GH_HOST":                        false,
+	"GH_TOKEN":                       true,
+	"GITHUB_TOKEN":                   true,
+	"GITLAB_API_URL":                 false,
+	"GITLAB_TOKEN":                   true,
+	"HTTPS_PROXY":                    false,
+	"HTTP_PROXY":                     false,
+	"NO_PROXY":                       false,
+}
+
+var reportOutput string
+
+var reportCmd = &cobra.Command{
+	Use:   "report [path]",
+	Short: "Bundle the last run of kettle into an archive to attach to a bug report",
+	Long: `🐞 The kettle CLI tool records each run: its arguments, the commands that
+ it ran (e.g. the aws cli's), and how they failed. kettle report bundles the
+ last run with kettle's version, the versions of the clis that it uses, the
+ environment that it ran in, and the project's config and audit log (if a
+ project is given, or is in the current directory) into a .tar.gz.
+
+Secrets (e.g. the values of environment variables whose names suggest that
+ they are secret, credentials, and private keys) are redacted, but review the
+ archive before attaching it to an issue.`,
+	Example: `  kettle report
+  kettle report my-project --output report.tar.gz`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runReport,
+}
+
+func init() {
+	reportCmd.Flags().StringVarP(&reportOutput, "output", "o", "", "The archive to write (kettle-report-<time>.tar.gz by default)")
+	rootCmd.AddCommand(reportCmd)
+}
+
+// reportFile is a file in the report's archive
+type reportFile struct {
+	name        string
+	description string
+	contents    []byte
+}
+
+func runReport(cmd *cobra.Command, args []string) error {
+	files := []*reportFile{}
+	session, err := cli.ReadLastSession()
+	if err != nil {
+		fmt.Println("⚠️   The last run is not included:", err.Error())
+	} else {
+		contents, err := json.MarshalIndent(session, "", "  ")
+		if err != nil {
+			return formatError(err)
+		}
+		files = append(files, &reportFile{
+			name:        "session.json",
+			description: fmt.Sprintf("the last run (kettle %s), and the %d commands that it ran", strings.Join(session.Args, " "), len(session.Commands)),
+			contents:    []byte(policy.RedactSecrets(string(contents))),
+		})
+	}
+
+	versions, err := json.MarshalIndent(getReportVersions(), "", "  ")
+	if err != nil {
+		return formatError(err)
+	}
+	files = append(files, &reportFile{
+		name:        "versions.json",
+		description: "the versions of kettle, and of the clis that it uses",
+		contents:    versions,
+	})
+	environment, err := json.MarshalIndent(getReportEnvironment(), "", "  ")
+	if err != nil {
+		return formatError(err)
+	}
+	files = append(files, &reportFile{
+		name:        "environment.json",
+		description: "the platform, modes, and environment variables that kettle ran with",
+		contents:    environment,
+	})
+	if settingsFile, err := getReportSettings(); err != nil {
+		fmt.Println("⚠️   The settings are not included:", err.Error())
+	} else if settingsFile != nil {
+		files = append(files, settingsFile)
+	}
+
+	projectPath, err := getReportProject(args)
+	if err != nil {
+		return formatError(err)
+	}
+	if projectPath != "" {
+		projectFiles, err := getReportProjectFiles(projectPath)
+		if err != nil {
+			return formatError(err)
+		}
+		files = append(files, projectFiles...)
+	}
+
+	archivePath := reportOutput
+	if archivePath == "" {
+		archivePath = fmt.Sprintf("kettle-report-%s.tar.gz", time.Now().Format("20060102-150405"))
+	}
+	if err := writeReport(archivePath, files); err != nil {
+		return formatError(err)
+	}
+	fmt.Println("🐞  The report has:")
+	for _, file := range files {
+		fmt.Println(fmt.Sprintf("    -  %s: %s", file.name, file.description))
+	}
+	fmt.Println("✅  Wrote the report:", archivePath)
+	fmt.Println("💡  Review it before attaching it to an issue; secrets are redacted on a best effort basis")
+	return nil
+}
+
+// getReportProject returns the project that is given, or that is in the current
+// directory, or an empty string if there is none (e.g. as kettle create failed)
+func getReportProject(args []string) (string, error) {
+	if len(args) > 0 {
+		return templates.GetProject(args)
+	}
+	directory, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	exists, err := config.HasConfigFile(directory)
+	if err != nil || !exists {
+		return "", err
+	}
+	return directory, nil
+}
+
+func getReportVersions() map[string]string {
+	versions := map[string]string{
+		"kettle": Version,
+		"go":     runtime.Version(),
+	}
+	for _, command := range reportClis {
+		if _, err := exec.LookPath(command); err != nil {
+			versions[command] = "not installed"
+			continue
+		}
+		output, err := cli.ExecuteSilently(command, []string{"--version"})
+		if err != nil {
+			versions[command] = fmt.Sprintf("unknown: %s", err)
+			continue
+		}
+		// e.g. the first line of gcloud's, which lists its components
+		versions[command] = strings.TrimSpace(strings.SplitN(strings.TrimSpace(string(output)), "\n", 2)[0])
+	}
+	return versions
+}
+
+func getReportEnvironment() map[string]interface{} {
+	variables := map[string]string{}
+	for _, variable := range os.Environ() {
+		parts := strings.SplitN(variable, "=", 2)
+		name := strings.ToUpper(parts[0])
+		credential, reported := reportEnvironment[name]
+		if !reported && !strings.HasPrefix(name, "KETTLE_") {
+			continue
+		}
+		switch {
+		case credential:
+			variables[parts[0]] = "set"
+		case strings.HasSuffix(name, "_PROXY"):
+			variables[parts[0]] = redactProxy(parts[1])
+		default:
+			variables[parts[0]] = config.MaskSecret(parts[0], parts[1])
+		}
+	}
+	return map[string]interface{}{
+		"os":   runtime.GOOS,
+		"arch": runtime.GOARCH,
+		"modes": map[string]bool{
+			"accessible":      cli.Accessible,
+			"air_gapped":      settings.AirGapped,
+			"aws_cli":         settings.AWSCli,
+			"debug":           settings.DebugMode,
+			"non_interactive": cli.NonInteractive,
+			"read_only":       cli.ReadOnly,
+		},
+		"template_bundle": settings.TemplateBundle,
+		"variables":       variables,
+	}
+}
+
+// redactProxy removes the credentials from a proxy's URL
+func redactProxy(value string) string {
+	proxyURL, err := url.Parse(value)
+	if err != nil || proxyURL.User == nil {
+		return value
+	}
+	return strings.Replace(value, proxyURL.User.String()+"@", reportRedactedValue+"@", 1)
+}
+
+// getReportSettings returns the user's settings, whose values are masked
+// line by line (as they are YAML), or nil if there are none
+func getReportSettings() (*reportFile, error) {
+	settingsPath, err := settings.GetSettingsFilePath()
+	if err != nil {
+		return nil, err
+	}
+	contents, err := ioutil.ReadFile(settingsPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(contents), "\n")
+	for i, line := range lines {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		value := strings.TrimSpace(parts[1])
+		if value != "" && config.MaskSecret(strings.TrimSpace(parts[0]), value) != value {
+			lines[i] = parts[0] + ": " + reportRedactedValue
+		}
+	}
+	return &reportFile{
+		name:        path.Base(settingsPath),
+		description: "kettle's settings",
+		contents:    []byte(policy.RedactSecrets(strings.Join(lines, "\n"))),
+	}, nil
+}
+
+// getReportProjectFiles returns the project's config, and the last entries of its audit log
+func getReportProjectFiles(projectPath string) ([]*reportFile, error) {
+	contents, err := config.ReadRedactedConfig(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	files := []*reportFile{{
+		name:        "kettle.json",
+		description: fmt.Sprintf("the config of %s", projectPath),
+		contents:    []byte(policy.RedactSecrets(string(contents))),
+	}}
+	entries, err := readReportAuditLog(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > 0 {
+		files = append(files, &reportFile{
+			name:        "audit.log",
+			description: fmt.Sprintf("the last %d commands that kettle exec ran on the project", len(entries)),
+			contents:    []byte(policy.RedactSecrets(strings.Join(entries, "\n") + "\n")),
+		})
+	}
+	return files, nil
+}
+
+// readReportAuditLog returns the last entries of the project's audit log,
+// with the values of their arguments that may be secret redacted
+func readReportAuditLog(projectPath string) ([]string, error) {
+	file, err := os.Open(state.GetAuditLogPath(projectPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	entries := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry state.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entry.Args = cli.RedactArgs(entry.Args)
+		line, err := json.Marshal(&entry)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, string(line))
+		if len(entries) > reportAuditLogEntries {
+			entries = entries[1:]
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// writeReport writes the files into a .tar.gz, in a directory that is named after it
+func writeReport(archivePath string, files []*reportFile) error {
+	directory := strings.TrimSuffix(path.Base(archivePath), ".tar.gz")
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].name < files[j].name
+	})
+	var buffer bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buffer)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for _, file := range files {
+		header := &tar.Header{
+			Name:    path.Join(directory, file.name),
+			Mode:    0644,
+			Size:    int64(len(file.contents)),
+			ModTime: time.Now(),
+		}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tarWriter.Write(file.contents); err != nil {
+			return err
+		}
+	}
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(archivePath, buffer.Bytes(), 0600)
+}
@@ -1,10 +1,15 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
 
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
 	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/templates"
 	"github.com/spf13/cobra"
 )
 
@@ -14,21 +19,114 @@ var rootCmd = &cobra.Command{
 	Short: "A CLI tool for creating http functions or services",
 	Long: "\n🎯 The kettle CLI creates machine learning pipelines" +
 		"\n or microservices from templates.",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// The command's flags and arguments have been parsed, so its errors
+		// from here on are not misuse: its usage is not printed, and its
+		// errors are printed by formatError (or by Execute)
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		// The run is recorded for kettle report, which reports on the last
+		// run rather than on itself
+		if cmd != reportCmd {
+			cli.StartSession(Version, os.Args[1:])
+		}
+		// The user's settings are read again by commands that deploy,
+		// as a stage may have its own settings file
+		userSettings, err := settings.ReadSettings()
+		if err != nil {
+			// The commands that use the settings report the error
+			userSettings = &settings.Settings{}
+		}
+		if err := setAccessibleMode(userSettings); err != nil {
+			return err
+		}
+		if err := setCABundle(userSettings); err != nil {
+			return err
+		}
+		setAirGappedMode(userSettings)
+		setAWSCliMode()
+		if err := templates.RegisterPluginFuncs(userSettings); err != nil {
+			return err
+		}
+		// Commands that read the project's config use the stage's overlay
+		config.OverlayStage = stageName
+		removeLeftovers(cmd)
+		if err := setReadOnlyMode(cmd, userSettings); err != nil {
+			return err
+		}
+		return cli.SetAnswers(answerValues, answerValuesFile)
+	},
 }
 
+// answerValues and answerValuesFile are set by the --set and --values
+// flags, which answer prompts up front
+var (
+	answerValues     []string
+	answerValuesFile string
+)
+
+// caBundle is set by the --ca-bundle flag
+var caBundle string
+
+const (
+	airGappedEnvironmentVariable      = "KETTLE_AIR_GAPPED"
+	templateBundleEnvironmentVariable = "KETTLE_TEMPLATE_BUNDLE"
+	awsCliEnvironmentVariable         = "KETTLE_AWS_CLI"
+)
+
+// flushOutput flushes the output of the accessible mode, whose
+// emoji are removed from everything that is printed
+var flushOutput = func() {}
+
 func init() {
 	rootCmd.PersistentFlags().BoolVar(&settings.DebugMode, "debug", false, "Enable debug mode")
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "Skip confirmation prompts (e.g. in CI)")
+	rootCmd.PersistentFlags().StringVar(&settings.Region, "region", "", "Deployment region (skips the region prompt)")
+	rootCmd.PersistentFlags().BoolVar(&cli.NonInteractive, "non-interactive", false, "Fail instead of prompting for values that have not been set (e.g. in CI)")
+	rootCmd.PersistentFlags().StringArrayVar(&answerValues, "set", []string{}, "Answer a prompt or template value up front (key=value, repeatable)")
+	rootCmd.PersistentFlags().StringVar(&answerValuesFile, "values", "", "A YAML file of answers to prompts and template values")
+	rootCmd.PersistentFlags().StringVar(&stageName, "stage", "", "Stage of the project to act on (e.g. staging), from its config")
+	rootCmd.PersistentFlags().BoolVar(&cli.NoCache, "no-cache", false, "Look up accounts, roles, REST APIs, and regions again, instead of using the results of recent runs")
+	rootCmd.PersistentFlags().StringVar(&caBundle, "ca-bundle", "", "A PEM file of CA certificates to trust (e.g. of a proxy that intercepts TLS)")
+	rootCmd.PersistentFlags().BoolVar(&settings.AirGapped, "air-gapped", false, "Run without internet access: only use local templates and template bundles")
+	rootCmd.PersistentFlags().BoolVar(&settings.AWSCli, "aws-cli", false, "Call the AWS APIs that deploys use with the aws cli, instead of the AWS SDK")
+	rootCmd.PersistentFlags().BoolVar(&cli.Accessible, "accessible", false, "Use screen-reader-friendly prompts (numbered lists) and output (no emoji or spinners)")
+	rootCmd.PersistentFlags().BoolVar(&cli.ReadOnly, "read-only", false, "Refuse to change any resources, e.g. so that auditors can inspect them")
+	rootCmd.PersistentFlags().BoolVar(&cli.Profiling, "profile-run", false, "Print a breakdown of where the run spent its time (e.g. rendering files, and each cloud call)")
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
+	err := rootCmd.Execute()
+	cli.EndSession(err)
+	cli.PrintProfile()
+	flushOutput()
+	if err != nil {
+		var printed *printedError
+		if !errors.As(err, &printed) {
+			fmt.Println(err)
+		}
 		os.Exit(1)
 	}
 }
 
+// setAccessibleMode enables the accessible mode if it is set by the
+// --accessible flag, the KETTLE_ACCESSIBLE environment variable, or
+// accessible: true in ~/.kettle.yaml
+func setAccessibleMode(stg *settings.Settings) error {
+	if !cli.Accessible && !cli.IsAccessibleEnvironment() && !stg.Accessible {
+		return nil
+	}
+	cli.Accessible = true
+	flush, err := cli.RemoveEmoji()
+	if err != nil {
+		return err
+	}
+	flushOutput = flush
+	return nil
+}
+
 func init() {
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
@@ -41,7 +139,75 @@ func init() {
 	// rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
 
+// setAWSCliMode calls the AWS APIs with the aws cli if it is set by the --aws-cli
+// flag or the KETTLE_AWS_CLI environment variable (aws_cli: true in ~/.kettle.yaml
+// is read when the cloud is set up)
+func setAWSCliMode() {
+	if enabled, err := strconv.ParseBool(os.Getenv(awsCliEnvironmentVariable)); err == nil && enabled {
+		settings.AWSCli = true
+	}
+}
+
+// printedError is an error that formatError has printed, so that
+// Execute exits with a non-zero status without printing it again
+type printedError struct {
+	err error
+}
+
+func (e *printedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *printedError) Unwrap() error {
+	return e.err
+}
+
+// formatError prints a command's error, with its diagnosis, and returns
+// it so that kettle exits with a non-zero status (e.g. to fail CI)
 func formatError(err error) error {
-	fmt.Println(fmt.Sprintf("\n❌ %s", err.Error()))
-	return nil
+	cli.RecordError(err)
+	fmt.Println(cli.Red(fmt.Sprintf("\n❌ %s", err.Error())))
+	if diagnosis := cli.Diagnose(err); diagnosis != nil {
+		fmt.Println(cli.Yellow(fmt.Sprintf("\n💡  %s", diagnosis.Cause)))
+		for _, fix := range diagnosis.Fixes {
+			fmt.Println("   •", fix)
+		}
+	}
+	return &printedError{err: err}
+}
+
+// setCABundle trusts the CA bundle that is set by the --ca-bundle flag,
+// the KETTLE_CA_BUNDLE environment variable, or ca_bundle in ~/.kettle.yaml
+func setCABundle(stg *settings.Settings) error {
+	bundlePath := getCABundle(stg)
+	if bundlePath == "" {
+		return nil
+	}
+	return cli.UseCABundle(bundlePath)
+}
+
+func getCABundle(stg *settings.Settings) string {
+	if caBundle != "" {
+		return caBundle
+	}
+	if bundlePath := cli.GetCABundle(); bundlePath != "" {
+		return bundlePath
+	}
+	return stg.CABundle
+}
+
+// setAirGappedMode enables the air-gapped mode if it is set by the
+// --air-gapped flag, the KETTLE_AIR_GAPPED environment variable, or
+// air_gapped: true in ~/.kettle.yaml, and sets the template bundle
+func setAirGappedMode(stg *settings.Settings) {
+	if enabled, err := strconv.ParseBool(os.Getenv(airGappedEnvironmentVariable)); err == nil && enabled {
+		settings.AirGapped = true
+	}
+	if stg.AirGapped {
+		settings.AirGapped = true
+	}
+	settings.TemplateBundle = os.Getenv(templateBundleEnvironmentVariable)
+	if settings.TemplateBundle == "" {
+		settings.TemplateBundle = stg.TemplateBundle
+	}
 }
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/clouds"
+)
+
+var (
+	jobArgs        []string
+	jobEnvironment []string
+)
+
+var runJobCmd = &cobra.Command{
+	Use:   "run-job",
+	Short: "Run a batch job that you have deployed with kettle",
+	Long: `🏃 The kettle CLI tool can submit executions of a deployed batch job,
+ and stream the job's logs until it completes.`,
+	Args: validateProjectArgs,
+	RunE: runRunJob,
+}
+
+func init() {
+	runJobCmd.Flags().StringArrayVar(&jobArgs, "arg", []string{}, "Argument to pass to the job (repeatable)")
+	runJobCmd.Flags().StringArrayVar(&jobEnvironment, "env", []string{}, "KEY=VALUE environment variable for this execution (repeatable)")
+	rootCmd.AddCommand(runJobCmd)
+}
+
+func runRunJob(cmd *cobra.Command, args []string) error {
+	environment, err := parseKeyValues(jobEnvironment)
+	if err != nil {
+		return formatError(err)
+	}
+
+	p, err := loadProject(args)
+	if err != nil {
+		return formatError(err)
+	}
+
+	runner, ok := p.service.(clouds.JobRunner)
+	if !ok {
+		return formatError(errors.New("run-job is only supported for batch job deployments"))
+	}
+	if err := runner.RunJob(p.config, p.settings, jobArgs, environment); err != nil {
+		return formatError(err)
+	}
+	fmt.Println("✅  Job completed!")
+	return nil
+}
+
+// parseKeyValues parses a list of KEY=VALUE strings
+func parseKeyValues(values []string) (map[string]string, error) {
+	result := map[string]string{}
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("expected KEY=VALUE, got: %s", value)
+		}
+		result[parts[0]] = parts[1]
+	}
+	return result, nil
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/artifacts"
+	"github.com/operatorai/kettle-cli/clouds"
+	"github.com/operatorai/kettle-cli/includes"
+)
+
+var scanArtifact string
+
+var scanCmd = &cobra.Command{
+	Use:   "scan <path>",
+	Short: "Scan a project's deployment package for known vulnerabilities",
+	Long: `🛡 The kettle CLI tool can build a project's deployment archive or image
+ (or pull a version from its artifact store), and scan it for known
+ vulnerabilities with grype, trivy, or ECR.`,
+	Args: validateProjectArgs,
+	RunE: runScan,
+}
+
+func init() {
+	scanCmd.Flags().StringVar(&scanArtifact, "artifact", "", "Scan a version of the project from its artifact store, instead of building it")
+	rootCmd.AddCommand(scanCmd)
+}
+
+func runScan(cmd *cobra.Command, args []string) error {
+	p, err := loadProject(args)
+	if err != nil {
+		return formatError(err)
+	}
+	if err := p.config.ValidateScan(); err != nil {
+		return formatError(err)
+	}
+	builder, ok := p.service.(clouds.Builder)
+	if !ok {
+		return formatError(fmt.Errorf("scanning is not supported on %s %s deployments",
+			p.config.Config.CloudProvider,
+			p.config.Config.DeploymentType,
+		))
+	}
+	if scanArtifact != "" {
+		artifact, err := artifacts.Pull(p.config, builder.GetArtifactKind(), scanArtifact)
+		if err != nil {
+			return formatError(err)
+		}
+		if builder.GetArtifactKind() == artifacts.Archive {
+			defer os.Remove(artifact.Location)
+		}
+		p.config.Artifact = artifact
+	}
+
+	returnToRoot, err := p.changeDirectory()
+	if err != nil {
+		return formatError(err)
+	}
+	defer returnToRoot()
+	if p.config.Artifact == nil {
+		removeIncludes, err := includes.Vendor(p.path, p.config)
+		if err != nil {
+			return formatError(err)
+		}
+		defer removeIncludes()
+	}
+	if err := p.toDeploy().Scan(builder); err != nil {
+		return formatError(err)
+	}
+	if builder.GetArtifactKind() == artifacts.Archive && scanArtifact == "" {
+		// The archive is only built to be scanned
+		os.Remove(p.config.Artifact.Location)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/templates"
+)
+
+var (
+	searchTemplates  string
+	searchCategory   string
+	searchTag        string
+	searchWriteIndex bool
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search [query]",
+	Short: "Search for templates by name, description, category, or tag",
+	Long: `🔎 The kettle CLI tool can search a directory of templates (e.g. an
+ internal registry), using the metadata in each template's kettle.json.`,
+	Example: `  kettle search fastapi
+  kettle search --category ml --tag gpu
+  kettle search --templates ./kettle-templates --write-index`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSearch,
+}
+
+func init() {
+	searchCmd.Flags().StringVar(&searchTemplates, "templates", "", "The templates to search: a directory, a bundle, or a git repository (the template bundle or kettle-templates by default)")
+	searchCmd.Flags().StringVar(&searchCategory, "category", "", "Only list templates in this category")
+	searchCmd.Flags().StringVar(&searchTag, "tag", "", "Only list templates with this tag")
+	searchCmd.Flags().BoolVar(&searchWriteIndex, "write-index", false, "Write the index of a directory of templates, which is searched instead of each template")
+	rootCmd.AddCommand(searchCmd)
+}
+
+func runSearch(cmd *cobra.Command, args []string) error {
+	if searchWriteIndex {
+		if searchTemplates == "" || templates.IsBundle(searchTemplates) {
+			return formatError(errors.New("--write-index needs a local directory of templates (--templates)"))
+		}
+		count, err := templates.WriteIndex(searchTemplates)
+		if err != nil {
+			return formatError(err)
+		}
+		fmt.Println("✅  Indexed: ", fmt.Sprintf("%d templates in %s", count, templates.IndexFileName))
+		return nil
+	}
+
+	directory, cleanup, err := templates.OpenCatalog(searchTemplates)
+	if err != nil {
+		return formatError(err)
+	}
+	defer cleanup()
+	entries, err := templates.ListTemplates(directory)
+	if err != nil {
+		return formatError(err)
+	}
+	query := ""
+	if len(args) == 1 {
+		query = args[0]
+	}
+	found := 0
+	for _, entry := range entries {
+		if !entry.Matches(query, searchCategory, searchTag) {
+			continue
+		}
+		found++
+		printEntry(entry)
+	}
+	if found == 0 {
+		fmt.Println("🤷  No templates found")
+	}
+	return nil
+}
+
+func printEntry(entry *templates.Entry) {
+	deployment := strings.TrimSpace(strings.Join([]string{entry.CloudProvider, entry.DeploymentType, entry.Runtime}, " "))
+	if deployment == "" {
+		fmt.Println("📦  " + entry.Name)
+	} else {
+		fmt.Println(fmt.Sprintf("📦  %s (%s)", entry.Name, deployment))
+	}
+	if entry.Metadata == nil {
+		return
+	}
+	if entry.Metadata.Description != "" {
+		fmt.Println("    " + entry.Metadata.Description)
+	}
+	if len(entry.Metadata.Categories) > 0 {
+		fmt.Println("    Categories: " + strings.Join(entry.Metadata.Categories, ", "))
+	}
+	if len(entry.Metadata.Tags) > 0 {
+		fmt.Println("    Tags: " + strings.Join(entry.Metadata.Tags, ", "))
+	}
+}
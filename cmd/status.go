@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/clouds"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the status of a deployed project",
+	Long: `🔍 The kettle CLI tool can compare a deployed project
+ against its config, and report any changes that were made outside of kettle.`,
+	Args: validateProjectArgs,
+	RunE: runStatus,
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	p, err := loadProject(args)
+	if err != nil {
+		return formatError(err)
+	}
+
+	fmt.Println("📦  Project: ", p.config.ProjectName, fmt.Sprintf("(%s %s)",
+		p.config.Config.CloudProvider,
+		p.config.Config.DeploymentType,
+	))
+	printDeployment(p)
+
+	detector, ok := p.service.(clouds.DriftDetector)
+	if !ok {
+		fmt.Println("🤷  Drift detection is not supported for this deployment type")
+		return nil
+	}
+	drift, err := detector.DetectDrift(p.config, p.settings)
+	if err != nil {
+		return formatError(err)
+	}
+	printDrift(drift)
+	return nil
+}
+
+func printDrift(drift []*config.Drift) {
+	if len(drift) == 0 {
+		fmt.Println("✅  No drift: the deployment matches the config")
+		return
+	}
+	fmt.Println("⚠️   Drift detected:")
+	for _, d := range drift {
+		fmt.Println(fmt.Sprintf("    %s: declared '%s', deployed '%s'", d.Field, d.Declared, d.Live))
+	}
+}
+
+// printDeployment prints the most recent deployment, and how much the
+// project's resources have cost this month (if the cloud can report it)
+func printDeployment(p *project) {
+	st, err := state.ReadState(p.path)
+	if err == nil && st.GetLastDeployment() != nil {
+		deployment := st.GetLastDeployment()
+		fmt.Println("🚀  Last deployed: ", deployment.Time, fmt.Sprintf("(code %s)", deployment.CodeVersion))
+		if deployment.ArtifactVersion != "" {
+			fmt.Println("📦  Artifact: ", deployment.Artifact, fmt.Sprintf("(version %s)", deployment.ArtifactVersion))
+		} else if deployment.Artifact != "" {
+			fmt.Println("📦  Artifact: ", deployment.Artifact)
+		}
+		if deployment.PromotedFrom != "" {
+			fmt.Println("⏫  Promoted from: ", deployment.PromotedFrom)
+		}
+	}
+
+	reporter, ok := p.cloud.(clouds.SpendReporter)
+	if !ok {
+		fmt.Println("💰  Month-to-date spend: unavailable")
+		return
+	}
+	spend, unit, err := reporter.GetMonthToDateSpend(p.config, p.settings)
+	if err != nil {
+		fmt.Println("💰  Month-to-date spend: unavailable")
+		return
+	}
+	spendLine := fmt.Sprintf("%.2f %s", spend, unit)
+	if p.config.Config.Budget != nil {
+		spendLine += fmt.Sprintf(" of %.2f %s", p.config.Config.Budget.Amount, p.config.BudgetCurrency())
+	}
+	fmt.Println("💰  Month-to-date spend: ", spendLine)
+}
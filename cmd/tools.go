@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/templates"
+	"github.com/operatorai/kettle-cli/tools"
+)
+
+var toolsCmd = &cobra.Command{
+	Use:   "tools",
+	Short: "List or install the tools that a project pins to a version",
+	Long: `🧰 The kettle CLI tool downloads the tools that a project pins in its
+ kettle.json (e.g. cargo-lambda) into its toolcache, after verifying their
+ checksums, so that the project is built with the same tools everywhere.`,
+}
+
+var toolsListCmd = &cobra.Command{
+	Use:   "list <path>",
+	Short: "List the project's pinned tools, and whether they are installed",
+	Args:  validateProjectArgs,
+	RunE:  runToolsList,
+}
+
+var toolsInstallCmd = &cobra.Command{
+	Use:   "install <path>",
+	Short: "Download the project's pinned tools into the toolcache (e.g. in CI)",
+	Args:  validateProjectArgs,
+	RunE:  runToolsInstall,
+}
+
+func init() {
+	toolsCmd.AddCommand(toolsListCmd)
+	toolsCmd.AddCommand(toolsInstallCmd)
+	rootCmd.AddCommand(toolsCmd)
+}
+
+func readToolsConfig(args []string) (*config.Config, error) {
+	projectPath, err := templates.GetProject(args)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := config.ReadConfig(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateTools(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func runToolsList(cmd *cobra.Command, args []string) error {
+	cfg, err := readToolsConfig(args)
+	if err != nil {
+		return formatError(err)
+	}
+	if len(cfg.Config.Tools) == 0 {
+		fmt.Println("🧰  The project does not pin any tools")
+		return nil
+	}
+	for _, name := range cfg.GetToolNames() {
+		tool := cfg.Config.Tools[name]
+		executable, err := tools.GetPath(name, tool)
+		switch {
+		case err != nil:
+			fmt.Println("⚠️  ", name, tool.Version, fmt.Sprintf("(not pinned for %s)", tools.GetPlatform()))
+		case fileExists(executable):
+			fmt.Println("✅  ", name, tool.Version, executable)
+		default:
+			fmt.Println("⬜  ", name, tool.Version, "(not installed)")
+		}
+	}
+	return nil
+}
+
+func runToolsInstall(cmd *cobra.Command, args []string) error {
+	cfg, err := readToolsConfig(args)
+	if err != nil {
+		return formatError(err)
+	}
+	installed, err := tools.Install(cfg)
+	if err != nil {
+		return formatError(err)
+	}
+	for _, tool := range installed {
+		fmt.Println("✅  ", tool.Name, tool.Version, tool.Path)
+	}
+	return nil
+}
+
+func fileExists(filePath string) bool {
+	info, err := os.Stat(filePath)
+	return err == nil && !info.IsDir()
+}
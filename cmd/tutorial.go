@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/templates"
+)
+
+// startTutorial introduces the template, for kettle create --tutorial
+func startTutorial(templateConfig *config.Config) {
+	metadata := templateConfig.Metadata
+	if metadata == nil || len(metadata.Walkthrough) == 0 {
+		fmt.Println("⚠️   This template does not have a walkthrough: its prompts are explained if they have help, and the project is created as usual")
+		return
+	}
+	fmt.Println("🎓  Tutorial: ", fmt.Sprintf("%d prompt(s), then %d component(s) of the project", len(templateConfig.Template), len(metadata.Walkthrough)))
+	if metadata.Description != "" {
+		fmt.Println(indent(metadata.Description))
+	}
+}
+
+// explainPrompt prints the context of a prompt before it is asked
+func explainPrompt(prompt *config.TemplatePrompt, step, steps int) {
+	fmt.Println(fmt.Sprintf("\n📝  Step %d of %d: %s", step, steps, prompt.Prompt))
+	if prompt.Help != "" {
+		fmt.Println(indent(prompt.Help))
+	}
+	if prompt.Default != "" {
+		fmt.Println(indent(fmt.Sprintf("(press enter for the default: %s)", prompt.Default)))
+	}
+}
+
+// walkThroughProject explains each component of the project that was created,
+// waiting for enter after each one; components that were not created are skipped
+func walkThroughProject(templateConfig *config.Config, directoryPath string, templateValues map[string]interface{}) error {
+	if templateConfig.Metadata == nil || len(templateConfig.Metadata.Walkthrough) == 0 {
+		return nil
+	}
+	steps := []*config.WalkthroughStep{}
+	paths := []string{}
+	for _, step := range templateConfig.Metadata.Walkthrough {
+		stepPath, err := templates.Render(step.Path, templateValues)
+		if err != nil {
+			return fmt.Errorf("invalid walkthrough path %s: %s", step.Path, err)
+		}
+		if _, err := os.Stat(path.Join(directoryPath, stepPath)); err != nil {
+			continue
+		}
+		steps = append(steps, step)
+		paths = append(paths, stepPath)
+	}
+
+	fmt.Println("\n🎓  What was created:")
+	for i, step := range steps {
+		fmt.Println(fmt.Sprintf("\n📄  %d of %d: %s (%s)", i+1, len(steps), step.Title, path.Join(directoryPath, paths[i])))
+		fmt.Println(indent(step.Explanation))
+		if i < len(steps)-1 {
+			cli.PromptToContinue()
+		}
+	}
+	fmt.Println("\n💡  Deploy it with: kettle deploy " + directoryPath)
+	return nil
+}
+
+func indent(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for i, line := range lines {
+		lines[i] = "    " + line
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/upgrade"
+)
+
+// updateShowDiff is set by the --diff flag, which prints the template's changes
+var updateShowDiff bool
+
+var updateCmd = &cobra.Command{
+	Use:   "update [path]",
+	Short: "Update a project to the latest version of its template",
+	Long: `⬆️  The kettle CLI tool updates a project with the changes to the template
+ that it was created from, since the version that it was created from; the
+ changes that conflict with the project's are resolved one by one.`,
+	Example: `  kettle update
+  kettle update ./users --diff`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runUpdate,
+}
+
+func init() {
+	updateCmd.Flags().BoolVar(&updateShowDiff, "diff", false, "Print the changes that updating the project makes")
+	rootCmd.AddCommand(updateCmd)
+}
+
+func runUpdate(cmd *cobra.Command, args []string) error {
+	projectPath := "."
+	if len(args) == 1 {
+		projectPath = args[0]
+	}
+	upgrader := upgrade.NewUpgrader()
+	defer upgrader.Close()
+	plan := upgrader.Plan(projectPath)
+	printUpgradePlan(plan)
+	switch plan.Status {
+	case upgrade.StatusFailed:
+		return formatError(plan.Err)
+	case upgrade.StatusUpgradable:
+	default:
+		return nil
+	}
+	if updateShowDiff {
+		os.Stdout.Write(plan.Patch)
+	}
+	if !assumeYes {
+		if cli.NonInteractive {
+			return formatError(errors.New("updating the project needs confirmation (use --yes in non-interactive mode)"))
+		}
+		if !cli.PromptToConfirm(fmt.Sprintf("Update %s", plan.Path)) {
+			return nil
+		}
+	}
+	if err := plan.Apply(); err != nil {
+		return formatError(err)
+	}
+	printUpgradeConflicts(plan)
+	return nil
+}
+
+// printUpgradePlan prints whether a project can be upgraded, and the files that it changes
+func printUpgradePlan(plan *upgrade.Plan) {
+	switch plan.Status {
+	case upgrade.StatusUpToDate:
+		fmt.Println("✅ ", plan.Path, fmt.Sprintf("is up to date (%s)", upgrade.ShortVersion(plan.Pinned)))
+	case upgrade.StatusNotPinned:
+		fmt.Println("⚪ ", plan.Path, "is not pinned to a template version (it was created before kettle recorded them, or from a template that is not in git)")
+	case upgrade.StatusFailed:
+		fmt.Println("❌ ", plan.Path, plan.Err)
+	case upgrade.StatusUpgradable:
+		fmt.Println("⬆️  ", plan.Path, fmt.Sprintf("%s → %s (%d file(s) change)", upgrade.ShortVersion(plan.Pinned), upgrade.ShortVersion(plan.Latest), len(plan.Files)))
+		for _, file := range plan.Files {
+			fmt.Println("     ", file)
+		}
+		for _, change := range plan.Changes {
+			fmt.Println("      📝", change)
+		}
+	}
+}
+
+// printUpgradeConflicts prints the files that an upgrade left conflicts in, or
+// that the project was upgraded
+func printUpgradeConflicts(plan *upgrade.Plan) {
+	if len(plan.Conflicts) == 0 {
+		fmt.Println("✅  Upgraded: ", plan.Path)
+		return
+	}
+	fmt.Println("⚠️   Upgraded, with conflicts to resolve: ", plan.Path)
+	for _, file := range plan.Conflicts {
+		fmt.Println("     ", file)
+	}
+}
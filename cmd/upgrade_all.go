@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/upgrade"
+)
+
+var upgradeAllCmd = &cobra.Command{
+	Use:   "upgrade-all [directory]",
+	Short: "Upgrade the projects in a workspace (or directory) to the latest versions of their templates",
+	Long: `⬆️  The kettle CLI tool checks each project in a workspace (or in a
+ directory) against the latest version of the template that it was created
+ from, shows the template's changes for each project, and can apply them,
+ or open a pull request for each project.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runUpgradeAll,
+}
+
+var (
+	upgradeShowDiff bool
+	upgradeApply    bool
+	upgradeOpenPRs  bool
+)
+
+func init() {
+	upgradeAllCmd.Flags().BoolVar(&upgradeShowDiff, "diff", false, "Print the changes that upgrading each project makes")
+	upgradeAllCmd.Flags().BoolVar(&upgradeApply, "apply", false, "Apply the upgrades to the projects")
+	upgradeAllCmd.Flags().BoolVar(&upgradeOpenPRs, "pr", false, "Apply each upgrade on a new branch, push it, and open a pull request for it (on GitHub, with GITHUB_TOKEN)")
+	rootCmd.AddCommand(upgradeAllCmd)
+}
+
+func runUpgradeAll(cmd *cobra.Command, args []string) error {
+	if upgradeApply && upgradeOpenPRs {
+		return formatError(errors.New("--apply and --pr cannot be used together (--pr applies the upgrades on branches)"))
+	}
+	directory := "."
+	if len(args) == 1 {
+		directory = args[0]
+	}
+	projects, err := upgrade.FindProjects(directory)
+	if err != nil {
+		return formatError(err)
+	}
+	if len(projects) == 0 {
+		return formatError(fmt.Errorf("there are no kettle projects in %s", directory))
+	}
+
+	upgrader := upgrade.NewUpgrader()
+	defer upgrader.Close()
+	upgradable := []*upgrade.Plan{}
+	for _, projectPath := range projects {
+		plan := upgrader.Plan(projectPath)
+		printUpgradePlan(plan)
+		if plan.Status == upgrade.StatusUpgradable {
+			upgradable = append(upgradable, plan)
+			if upgradeShowDiff {
+				os.Stdout.Write(plan.Patch)
+			}
+		}
+	}
+	fmt.Println(fmt.Sprintf("\n📋  %d of %d project(s) can be upgraded", len(upgradable), len(projects)))
+	if len(upgradable) == 0 || (!upgradeApply && !upgradeOpenPRs) {
+		return nil
+	}
+
+	action := "Apply the upgrades of"
+	if upgradeOpenPRs {
+		action = "Push a branch, and open a pull request, for each of"
+	}
+	if !assumeYes {
+		if cli.NonInteractive {
+			return formatError(errors.New("upgrading the projects needs confirmation (use --yes in non-interactive mode)"))
+		}
+		if !cli.PromptToConfirm(fmt.Sprintf("%s the %d project(s)", action, len(upgradable))) {
+			return nil
+		}
+	}
+	failed := 0
+	for _, plan := range upgradable {
+		if upgradeOpenPRs {
+			url, err := plan.OpenPullRequest()
+			if err != nil {
+				failed++
+				fmt.Println("❌ ", plan.Path, err)
+				continue
+			}
+			fmt.Println("🔀  Pull request: ", plan.Path, url)
+			continue
+		}
+		if err := plan.Apply(); err != nil {
+			failed++
+			fmt.Println("❌ ", plan.Path, err)
+			continue
+		}
+		printUpgradeConflicts(plan)
+	}
+	if failed > 0 {
+		return formatError(fmt.Errorf("%d of %d upgrade(s) failed", failed, len(upgradable)))
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/iancoleman/strcase"
+)
+
+// EnvironmentPrefix returns the prefix of the add-on's environment
+// variables, e.g. KETTLE_USERS for an add-on called users
+func (addOn *AddOn) EnvironmentPrefix() string {
+	return fmt.Sprintf("KETTLE_%s", strcase.ToScreamingSnake(addOn.Name))
+}
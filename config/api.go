@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+)
+
+// ApiRoute is a resource (path) of a shared REST API, and
+// the function that it is attached to (if there is one)
+
+type ApiRoute struct {
+	Path       string
+	ResourceID string
+	Function   string
+}
+
+// ApiStage is a stage of the REST API (e.g. dev) whose requests are sent to an
+// alias of the function, through a stage variable; the alias follows the latest
+// deployed version of the function, unless it is pinned to a version
+
+type ApiStage struct {
+	Alias   string `json:"alias,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// GetAlias returns the function alias of the API stage,
+// which is named after the stage by default
+func (s *ApiStage) GetAlias(stage string) string {
+	if s == nil || s.Alias == "" {
+		return stage
+	}
+	return s.Alias
+}
+
+const (
+	// PayloadFormatBody sends the request's body to the function as its event
+	PayloadFormatBody = "body"
+	// PayloadFormatProxy sends the whole request (in API Gateway's proxy event
+	// format, version 1.0), and the function returns a statusCode and body
+	PayloadFormatProxy = "1.0"
+	// PayloadFormatHTTP is the event format of HTTP APIs, which kettle does not create
+	PayloadFormatHTTP = "2.0"
+
+	maxApiTimeout             = 29
+	maxMinimumCompressionSize = 10485760
+)
+
+// Api configures the REST API that a function is added to, so that
+// the events it receives are in the format that its handler expects
+
+type Api struct {
+	PayloadFormat          string `json:"payload_format,omitempty"`
+	Timeout                int    `json:"timeout,omitempty"`
+	MinimumCompressionSize *int   `json:"minimum_compression_size,omitempty"`
+}
+
+// GetPayloadFormat returns the format of the function's events
+func (cfg *Config) GetPayloadFormat() string {
+	if cfg.Config.Api == nil || cfg.Config.Api.PayloadFormat == "" {
+		return PayloadFormatBody
+	}
+	return cfg.Config.Api.PayloadFormat
+}
+
+// ValidateApi checks the API settings, and that they are compatible
+// with the function (e.g. that it can respond before the API times out)
+func (cfg *Config) ValidateApi() error {
+	api := cfg.Config.Api
+	if api == nil {
+		return nil
+	}
+	if cfg.Config.CloudProvider != "aws" || cfg.Config.DeploymentType != "lambda" {
+		return fmt.Errorf("api settings are not supported on %s %s deployments",
+			cfg.Config.CloudProvider,
+			cfg.Config.DeploymentType,
+		)
+	}
+	switch api.PayloadFormat {
+	case "", PayloadFormatBody, PayloadFormatProxy:
+	case PayloadFormatHTTP:
+		return fmt.Errorf("payload format %s is only used by HTTP APIs, and kettle adds functions to a REST API (use %s for the proxy event, or %s)",
+			PayloadFormatHTTP,
+			PayloadFormatProxy,
+			PayloadFormatBody,
+		)
+	default:
+		return fmt.Errorf("unknown payload format: %s (expected %s or %s)", api.PayloadFormat, PayloadFormatBody, PayloadFormatProxy)
+	}
+	if api.Timeout < 0 || api.Timeout > maxApiTimeout {
+		return fmt.Errorf("the api timeout must be between 1 and %d seconds", maxApiTimeout)
+	}
+	if api.Timeout != 0 && cfg.Config.Timeout != 0 && api.Timeout > cfg.Config.Timeout {
+		return fmt.Errorf("the api timeout (%ds) is longer than the function's timeout (%ds)", api.Timeout, cfg.Config.Timeout)
+	}
+	if size := api.MinimumCompressionSize; size != nil && (*size < 0 || *size > maxMinimumCompressionSize) {
+		return fmt.Errorf("the minimum compression size must be between 0 and %d bytes", maxMinimumCompressionSize)
+	}
+	return nil
+}
+
+// GetApiTimeout returns how long (in seconds) the API waits for the function
+func (cfg *Config) GetApiTimeout() int {
+	if cfg.Config.Api == nil || cfg.Config.Api.Timeout == 0 {
+		return maxApiTimeout
+	}
+	return cfg.Config.Api.Timeout
+}
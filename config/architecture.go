@@ -0,0 +1,26 @@
+package config
+
+import "fmt"
+
+const (
+	ArchitectureX86   = "x86_64"
+	ArchitectureARM64 = "arm64"
+)
+
+// GetArchitecture returns the instruction set that the function
+// runs on, which defaults to x86_64
+func (cfg *Config) GetArchitecture() string {
+	if cfg.Config.Architecture == "" {
+		return ArchitectureX86
+	}
+	return cfg.Config.Architecture
+}
+
+// ValidateArchitecture returns an error if the config has an unknown architecture
+func (cfg *Config) ValidateArchitecture() error {
+	switch cfg.GetArchitecture() {
+	case ArchitectureX86, ArchitectureARM64:
+		return nil
+	}
+	return fmt.Errorf("unknown architecture: %s (expected %s or %s)", cfg.Config.Architecture, ArchitectureX86, ArchitectureARM64)
+}
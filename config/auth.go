@@ -0,0 +1,30 @@
+package config
+
+import "fmt"
+
+// Endpoint authentication
+const (
+	AuthNone   = "none"
+	AuthAPIKey = "api_key"
+	AuthIAM    = "iam"
+)
+
+// ValidateAuth returns an error if the project's auth
+// setting is not supported by its cloud
+func (cfg *Config) ValidateAuth() error {
+	switch cfg.Config.Auth {
+	case "", AuthNone, AuthIAM:
+		return nil
+	case AuthAPIKey:
+		if cfg.Config.CloudProvider == "aws" {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported auth: %s", cfg.Config.Auth)
+}
+
+// IsPublic returns true if anyone can call the project's endpoint; projects
+// that do not declare their auth are treated as public
+func (cfg *Config) IsPublic() bool {
+	return cfg.Config.Auth == "" || cfg.Config.Auth == AuthNone
+}
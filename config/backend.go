@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+	"os"
+)
+
+const (
+	defaultLockTable = "kettle-locks"
+)
+
+// StateKey returns where the state of the project's current stage
+// is stored in the state backend's bucket
+func (cfg *Config) StateKey() string {
+	return fmt.Sprintf("%s/%s/state.json", cfg.GetBaseProjectName(), cfg.GetStage())
+}
+
+// LockID returns the ID of the lock on the project's current stage
+func (cfg *Config) LockID() string {
+	return fmt.Sprintf("%s/%s", cfg.GetBaseProjectName(), cfg.GetStage())
+}
+
+// LockTable returns the name of the table that holds locks, which
+// defaults to kettle-locks
+func (cfg *Config) LockTable() string {
+	if cfg.Config.StateBackend.LockTable == "" {
+		return defaultLockTable
+	}
+	return cfg.Config.StateBackend.LockTable
+}
+
+// LockOwner describes who is holding a lock, e.g. so that
+// a teammate can see who is deploying
+func LockOwner() string {
+	user := os.Getenv("USER")
+	if user == "" {
+		user = "unknown"
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	if runID := os.Getenv("GITHUB_RUN_ID"); runID != "" {
+		return fmt.Sprintf("%s@%s (GitHub Actions run %s)", user, host, runID)
+	}
+	return fmt.Sprintf("%s@%s", user, host)
+}
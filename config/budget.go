@@ -0,0 +1,30 @@
+package config
+
+import "fmt"
+
+const (
+	defaultBudgetCurrency       = "USD"
+	defaultBudgetAlertThreshold = 80
+)
+
+// BudgetName is the name of the project's budget
+func (cfg *Config) BudgetName() string {
+	return fmt.Sprintf("kettle-%s", cfg.ProjectName)
+}
+
+// BudgetCurrency returns the budget's currency, which defaults to USD
+func (cfg *Config) BudgetCurrency() string {
+	if cfg.Config.Budget.Currency == "" {
+		return defaultBudgetCurrency
+	}
+	return cfg.Config.Budget.Currency
+}
+
+// BudgetAlertThreshold returns the percentage of the budget
+// at which an alert is sent, which defaults to 80%
+func (cfg *Config) BudgetAlertThreshold() int {
+	if cfg.Config.Budget.AlertThreshold == 0 {
+		return defaultBudgetAlertThreshold
+	}
+	return cfg.Config.Budget.AlertThreshold
+}
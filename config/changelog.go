@@ -0,0 +1,82 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// ChangeSecurity flags a change that fixes a vulnerability in the projects
+	// that the template creates (e.g. an outdated dependency)
+	ChangeSecurity = "security"
+	// ChangeDeploy flags a change to how the projects are deployed
+	// (e.g. a runtime that is about to be deprecated)
+	ChangeDeploy = "deploy"
+)
+
+// TemplateChange is an entry in a template's changelog; projects copy their
+// template's changelog, so the entries that a project does not have are the
+// changes that were published since it was created (or last upgraded)
+
+type TemplateChange struct {
+	// The version that the change was published in (e.g. 1.4.0), which
+	// identifies the entry
+	Version string   `json:"version"`
+	Summary string   `json:"summary"`
+	Flags   []string `json:"flags,omitempty"`
+}
+
+// IsNotable returns whether the change is flagged as security or deploy
+// related, which kettle deploy tells the projects' owners about
+func (change *TemplateChange) IsNotable() bool {
+	for _, flag := range change.Flags {
+		if flag == ChangeSecurity || flag == ChangeDeploy {
+			return true
+		}
+	}
+	return false
+}
+
+func (change *TemplateChange) String() string {
+	if len(change.Flags) == 0 {
+		return fmt.Sprintf("%s: %s", change.Version, change.Summary)
+	}
+	return fmt.Sprintf("%s (%s): %s", change.Version, strings.Join(change.Flags, ", "), change.Summary)
+}
+
+// ValidateChangelog checks that each of the changelog's entries has a unique
+// version, and a summary, and that their flags are known
+func (cfg *Config) ValidateChangelog() error {
+	versions := map[string]bool{}
+	for _, change := range cfg.Changelog {
+		if change.Version == "" || change.Summary == "" {
+			return fmt.Errorf("each entry of the changelog needs a version, and a summary")
+		}
+		if versions[change.Version] {
+			return fmt.Errorf("the changelog has more than one entry for version %s", change.Version)
+		}
+		versions[change.Version] = true
+		for _, flag := range change.Flags {
+			if flag != ChangeSecurity && flag != ChangeDeploy {
+				return fmt.Errorf("unknown flag in the changelog's entry for %s: %s (expected %s, or %s)", change.Version, flag, ChangeSecurity, ChangeDeploy)
+			}
+		}
+	}
+	return nil
+}
+
+// GetNewChanges returns the entries of a (later) changelog that are not in
+// the project's changelog, in the order of the later changelog
+func (cfg *Config) GetNewChanges(changelog []*TemplateChange) []*TemplateChange {
+	known := map[string]bool{}
+	for _, change := range cfg.Changelog {
+		known[change.Version] = true
+	}
+	changes := []*TemplateChange{}
+	for _, change := range changelog {
+		if !known[change.Version] {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	CIGitHub = "github"
+	CIGitLab = "gitlab"
+)
+
+// ciSecretNamePattern matches the names that both GitHub and GitLab
+// accept for secrets (and CI/CD variables)
+var ciSecretNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// CI is the CI system that a template's pipeline (e.g. its GitHub Actions
+// workflow) runs on, and the secrets that the pipeline needs, which
+// kettle create offers to set on the project's repository
+
+type CI struct {
+	Provider string      `json:"provider"`
+	Secrets  []*CISecret `json:"secrets,omitempty"`
+}
+
+// CISecret is a secret (e.g. the ARN of the role that the pipeline deploys
+// with) that the pipeline reads; its value is never stored in kettle.json
+
+type CISecret struct {
+	Name string `json:"name"`
+	// What the secret is, and where to find its value
+	Description string `json:"description,omitempty"`
+	// A value to suggest, for values that are not sensitive (e.g. a region)
+	Default string `json:"default,omitempty"`
+	// Whether the pipeline runs without the secret
+	Optional bool `json:"optional,omitempty"`
+}
+
+func (secret *CISecret) String() string {
+	description := secret.Name
+	if secret.Description != "" {
+		description += ": " + secret.Description
+	}
+	if secret.Optional {
+		description += " (optional)"
+	}
+	return description
+}
+
+// GetCISecrets returns the secrets that the project's pipeline needs
+func (cfg *Config) GetCISecrets() []*CISecret {
+	if cfg.CI == nil {
+		return nil
+	}
+	return cfg.CI.Secrets
+}
+
+// ValidateCI checks that the template's CI provider is known,
+// and that its secrets have unique names that can be set on it
+func (cfg *Config) ValidateCI() error {
+	if cfg.CI == nil {
+		return nil
+	}
+	if cfg.CI.Provider != CIGitHub && cfg.CI.Provider != CIGitLab {
+		return fmt.Errorf("unknown ci provider: %s (expected %s, or %s)", cfg.CI.Provider, CIGitHub, CIGitLab)
+	}
+	names := map[string]bool{}
+	for _, secret := range cfg.CI.Secrets {
+		if !ciSecretNamePattern.MatchString(secret.Name) {
+			return fmt.Errorf("invalid ci secret name: %s (use letters, digits, and underscores)", secret.Name)
+		}
+		if cfg.CI.Provider == CIGitHub && strings.HasPrefix(strings.ToUpper(secret.Name), "GITHUB_") {
+			return fmt.Errorf("invalid ci secret name: %s (GitHub reserves the GITHUB_ prefix)", secret.Name)
+		}
+		if names[secret.Name] {
+			return fmt.Errorf("the ci secret %s is listed more than once", secret.Name)
+		}
+		names[secret.Name] = true
+	}
+	return nil
+}
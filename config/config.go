@@ -7,26 +7,50 @@ import (
 	"path"
 )
 
+// ReadConfig reads the config (kettle.json) in a directory, and merges
+// its overlays (e.g. kettle.local.yaml) over it
 func ReadConfig(templatePath string) (*Config, error) {
 	configPath := path.Join(templatePath, configFileName)
 	data, err := ioutil.ReadFile(configPath)
 	if err != nil {
 		return nil, err
 	}
+	data, overlays, baseDoc, mergedDoc, err := applyOverlays(templatePath, data)
+	if err != nil {
+		return nil, err
+	}
 
 	template := &Config{}
 	err = json.Unmarshal(data, template)
 	if err != nil {
 		return nil, err
 	}
+	template.overlays = overlays
+	template.baseDoc = baseDoc
+	template.mergedDoc = mergedDoc
+	template.sourcePath = templatePath
 	return template, nil
 }
 
+// WriteConfig writes a config to kettle.json; if it was read from the same
+// directory, the values of its overlays are not written
 func WriteConfig(projectPath string, config *Config) error {
+	if config.BaseName != "" {
+		// The config stores the project's name, not the name of its resources
+		named := *config
+		named.ProjectName = config.BaseName
+		config = &named
+	}
 	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
 		return err
 	}
+	if len(config.overlays) > 0 && config.isSourcePath(projectPath) {
+		data, err = removeOverlays(config, data)
+		if err != nil {
+			return err
+		}
+	}
 
 	configPath := path.Join(projectPath, configFileName)
 	return ioutil.WriteFile(configPath, data, 0644)
@@ -0,0 +1,22 @@
+package config
+
+// Contract is the JSON Schemas of the requests and responses of the project's
+// endpoint; paths are relative to the project
+
+type Contract struct {
+	Request  string `json:"request,omitempty"`
+	Response string `json:"response,omitempty"`
+	// A directory of sample responses of the handler (e.g. recorded by
+	// its tests), which must match the response schema
+	Samples string `json:"samples,omitempty"`
+}
+
+// ValidatesRequests returns true if the cloud rejects requests that do not
+// match the contract's request schema before they reach the handler (API
+// Gateway, for AWS Lambda functions)
+func (cfg *Config) ValidatesRequests() bool {
+	return cfg.Config.Contract != nil &&
+		cfg.Config.Contract.Request != "" &&
+		cfg.Config.CloudProvider == "aws" &&
+		cfg.Config.DeploymentType == "lambda"
+}
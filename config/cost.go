@@ -0,0 +1,11 @@
+package config
+
+// Cost is the spend of one stage of a project over a period,
+// from the resources that are tagged with the project's name
+
+type Cost struct {
+	Project string
+	Stage   string
+	Amount  float64
+	Unit    string
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CustomDomain serves the REST API from a domain name (with a regional
+// ACM certificate), optionally requiring client certificates (mTLS)
+
+type CustomDomain struct {
+	Name           string     `json:"name"`
+	CertificateArn string     `json:"certificate_arn"`
+	BasePath       string     `json:"base_path,omitempty"`
+	Stage          string     `json:"stage,omitempty"`
+	MutualTLS      *MutualTLS `json:"mutual_tls,omitempty"`
+}
+
+// MutualTLS is the truststore of the certificate authorities whose client
+// certificates are accepted by a custom domain
+
+type MutualTLS struct {
+	TruststoreURI     string `json:"truststore_uri"`
+	TruststoreVersion string `json:"truststore_version,omitempty"`
+}
+
+// GetStage returns the stage of the REST API that the domain serves
+func (d *CustomDomain) GetStage() string {
+	if d.Stage == "" {
+		return DefaultStage
+	}
+	return d.Stage
+}
+
+// ValidateCustomDomain checks the project's custom domain, and its truststore
+func (cfg *Config) ValidateCustomDomain() error {
+	domain := cfg.Config.CustomDomain
+	if domain == nil {
+		return nil
+	}
+	if domain.Name == "" || domain.CertificateArn == "" {
+		return fmt.Errorf("a custom domain needs a name and a certificate_arn")
+	}
+	// e.g. arn:aws:acm:..., or arn:aws-us-gov:acm:... in AWS GovCloud (US)
+	if parts := strings.SplitN(domain.CertificateArn, ":", 4); len(parts) < 4 || parts[0] != "arn" || !strings.HasPrefix(parts[1], "aws") || parts[2] != "acm" {
+		return fmt.Errorf("the custom domain's certificate must be an ACM certificate ARN: %s", domain.CertificateArn)
+	}
+	if domain.MutualTLS != nil && !strings.HasPrefix(domain.MutualTLS.TruststoreURI, "s3://") {
+		return fmt.Errorf("the mTLS truststore must be an S3 URI (e.g. s3://my-bucket/truststore.pem): %s", domain.MutualTLS.TruststoreURI)
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package config
+
+// Drift is a difference between a value declared in the project config
+// and the value of the deployed resource
+
+type Drift struct {
+	Field    string
+	Declared string
+	Live     string
+}
+
+// IgnoresDrift returns true if the project config lists the field as being
+// intentionally managed outside of kettle
+func (cfg *Config) IgnoresDrift(field string) bool {
+	for _, ignored := range cfg.Config.IgnoreDrift {
+		if ignored == field {
+			return true
+		}
+	}
+	return false
+}
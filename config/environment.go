@@ -0,0 +1,183 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maskedValue is displayed instead of the values of secrets
+const maskedValue = "********"
+
+// environmentVariablePattern matches the names of environment variables
+var environmentVariablePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// secretReferences are the prefixes of values that refer to a secret
+// (rather than containing it), e.g. a Secrets Manager ARN
+var secretReferences = []string{
+	"arn:aws:secretsmanager:",
+	"arn:aws:ssm:",
+	"arn:aws-cn:secretsmanager:",
+	"arn:aws-cn:ssm:",
+	"arn:aws-us-gov:secretsmanager:",
+	"arn:aws-us-gov:ssm:",
+	"{{resolve:",
+	"secret:",
+}
+
+// secretNames are parts of the names of variables that hold secrets
+var secretNames = []string{
+	"SECRET",
+	"PASSWORD",
+	"PASSWD",
+	"TOKEN",
+	"API_KEY",
+	"PRIVATE_KEY",
+}
+
+// IsSecretReference returns true if the value refers to a secret (e.g. a
+// Secrets Manager ARN, or an SSM parameter), rather than containing it
+func IsSecretReference(value string) bool {
+	for _, reference := range secretReferences {
+		if strings.HasPrefix(value, reference) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSecretName returns true if the name (e.g. of an environment
+// variable) suggests that its value is a secret
+func IsSecretName(name string) bool {
+	for _, secretName := range secretNames {
+		if strings.Contains(strings.ToUpper(name), secretName) {
+			return true
+		}
+	}
+	return false
+}
+
+// DeployEnvironment returns the environment variables that are set
+// on the deployed function
+func (cfg *Config) DeployEnvironment() map[string]string {
+	environment := map[string]string{}
+	for key, value := range cfg.Config.Environment {
+		environment[key] = value
+	}
+	if stage, ok := cfg.Config.Stages[cfg.Stage]; ok && stage != nil {
+		for key, value := range stage.Environment {
+			environment[key] = value
+		}
+	}
+	for key, value := range environment {
+		environment[key] = cfg.ResolveOutputs(value)
+	}
+	for key, value := range cfg.DependencyEnvironment {
+		environment[key] = value
+	}
+	for key, value := range cfg.AddOnEnvironment {
+		environment[key] = value
+	}
+	for key, value := range cfg.SecretEnvironment {
+		environment[key] = value
+	}
+	for key, value := range cfg.TracingEnvironment() {
+		environment[key] = value
+	}
+	if cfg.HasFlags() {
+		if cfg.GetFlagStore() == FlagStoreSSM {
+			environment["KETTLE_FLAGS_PARAMETER"] = cfg.FlagParameterName()
+		} else {
+			environment["KETTLE_FLAGS"] = cfg.EncodeFlags()
+		}
+	}
+	if len(cfg.Config.LogMetrics) != 0 {
+		environment["KETTLE_METRICS_NAMESPACE"] = cfg.MetricsNamespace()
+	}
+	if cfg.Config.Model != nil {
+		environment["KETTLE_MODEL_NAME"] = cfg.Config.Model.Name
+		environment["KETTLE_MODEL_VERSION"] = cfg.Config.Model.Version
+		environment["KETTLE_MODEL_URI"] = cfg.Config.Model.URI
+		if cfg.Config.Model.Path != "" {
+			environment["KETTLE_MODEL_PATH"] = cfg.Config.Model.Path
+		}
+	}
+	return environment
+}
+
+// DeploySecrets returns the project's secrets in the deployed stage: the
+// environment variables that they are passed as, and the Vault secrets
+// (<path>#<field>) that their values are read from
+func (cfg *Config) DeploySecrets() map[string]string {
+	secrets := map[string]string{}
+	for key, reference := range cfg.Config.Secrets {
+		secrets[key] = reference
+	}
+	if stage, ok := cfg.Config.Stages[cfg.Stage]; ok && stage != nil {
+		for key, reference := range stage.Secrets {
+			secrets[key] = reference
+		}
+	}
+	return secrets
+}
+
+// ValidateSecrets checks that each of the project's secrets refers to a
+// Vault secret, and is not also one of its environment variables
+func (cfg *Config) ValidateSecrets() error {
+	secrets := cfg.DeploySecrets()
+	if len(secrets) == 0 {
+		return nil
+	}
+	environment := map[string]string{}
+	for key, value := range cfg.Config.Environment {
+		environment[key] = value
+	}
+	if stage, ok := cfg.Config.Stages[cfg.Stage]; ok && stage != nil {
+		for key, value := range stage.Environment {
+			environment[key] = value
+		}
+	}
+	for key, reference := range secrets {
+		if !environmentVariablePattern.MatchString(key) {
+			return fmt.Errorf("invalid secret name: %s (it is passed as an environment variable)", key)
+		}
+		if _, ok := environment[key]; ok {
+			return fmt.Errorf("%s is both a secret and an environment variable", key)
+		}
+		parts := strings.SplitN(reference, "#", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("invalid secret %s: %s (expected a Vault secret: <path>#<field>)", key, reference)
+		}
+	}
+	return nil
+}
+
+// GetEnvironment returns the environment variables that are declared for a
+// stage in the config (the default stage's are in the config's "environment")
+func (cfg *Config) GetEnvironment(stage string) map[string]string {
+	if stage == "" {
+		if cfg.Config.Environment == nil {
+			cfg.Config.Environment = map[string]string{}
+		}
+		return cfg.Config.Environment
+	}
+	if cfg.Config.Stages == nil {
+		cfg.Config.Stages = map[string]*Stage{}
+	}
+	if cfg.Config.Stages[stage] == nil {
+		cfg.Config.Stages[stage] = &Stage{}
+	}
+	if cfg.Config.Stages[stage].Environment == nil {
+		cfg.Config.Stages[stage].Environment = map[string]string{}
+	}
+	return cfg.Config.Stages[stage].Environment
+}
+
+// MaskSecret returns the value to display for an environment variable;
+// the values of secrets (and references to them) are masked
+func MaskSecret(key, value string) string {
+	if IsSecretReference(value) || IsSecretName(key) {
+		return maskedValue
+	}
+	return value
+}
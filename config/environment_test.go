@@ -0,0 +1,54 @@
+package config
+
+import "testing"
+
+func TestIsSecretReference(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  bool
+	}{
+		{name: "secrets manager arn", value: "arn:aws:secretsmanager:us-east-1:123456789012:secret:db-password", want: true},
+		{name: "ssm parameter arn", value: "arn:aws:ssm:eu-west-1:123456789012:parameter/api-key", want: true},
+		{name: "china partition", value: "arn:aws-cn:secretsmanager:cn-north-1:123456789012:secret:token", want: true},
+		{name: "govcloud partition", value: "arn:aws-us-gov:ssm:us-gov-west-1:123456789012:parameter/token", want: true},
+		{name: "dynamic reference", value: "{{resolve:secretsmanager:db-password}}", want: true},
+		{name: "secret provider", value: "secret:db-password", want: true},
+		{name: "plain value", value: "hunter2", want: false},
+		{name: "empty", value: "", want: false},
+		{name: "other arn", value: "arn:aws:s3:::bucket", want: false},
+		{name: "reference that is not a prefix", value: "value arn:aws:ssm:us-east-1:123456789012:parameter/token", want: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := IsSecretReference(test.value); got != test.want {
+				t.Errorf("IsSecretReference(%q) = %v, want %v", test.value, got, test.want)
+			}
+		})
+	}
+}
+
+func TestIsSecretName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "DB_PASSWORD", want: true},
+		{name: "db_password", want: true},
+		{name: "GITHUB_TOKEN", want: true},
+		{name: "STRIPE_API_KEY", want: true},
+		{name: "SSH_PRIVATE_KEY", want: true},
+		{name: "ClientSecret", want: true},
+		{name: "LDAP_PASSWD", want: true},
+		{name: "LOG_LEVEL", want: false},
+		{name: "API_URL", want: false},
+		{name: "", want: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := IsSecretName(test.name); got != test.want {
+				t.Errorf("IsSecretName(%q) = %v, want %v", test.name, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+package config
+
+// Explanation describes a resource that kettle manages for a project: what
+// it is, why kettle created it, and where to find it in the cloud's console
+
+type Explanation struct {
+	Kind       string
+	Reason     string
+	ConsoleURL string
+}
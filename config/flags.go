@@ -0,0 +1,111 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// Where the deployed function reads its feature flags from
+const (
+	// The flags are a JSON object in the KETTLE_FLAGS environment variable
+	FlagStoreEnv = "env"
+	// The flags are a JSON object in an SSM parameter, whose name is in the
+	// KETTLE_FLAGS_PARAMETER environment variable (AWS only), so that they
+	// can be changed without updating the function
+	FlagStoreSSM = "ssm"
+)
+
+// flagName is the format of feature flag names
+var flagName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]*$`)
+
+// GetFlagStore returns where the project's feature flags are stored
+func (cfg *Config) GetFlagStore() string {
+	if cfg.Config.FlagStore == "" {
+		return FlagStoreEnv
+	}
+	return cfg.Config.FlagStore
+}
+
+// HasFlags returns true if the project uses feature flags
+func (cfg *Config) HasFlags() bool {
+	return len(cfg.DeployFlags()) != 0 || cfg.Config.FlagStore != ""
+}
+
+// DeployFlags returns the feature flags of the deployed stage:
+// the config's flags, which the stage's flags override
+func (cfg *Config) DeployFlags() map[string]bool {
+	flags := map[string]bool{}
+	for name, enabled := range cfg.Config.Flags {
+		flags[name] = enabled
+	}
+	if stage, ok := cfg.Config.Stages[cfg.Stage]; ok && stage != nil {
+		for name, enabled := range stage.Flags {
+			flags[name] = enabled
+		}
+	}
+	return flags
+}
+
+// EncodeFlags returns the feature flags of the deployed stage, as the
+// JSON object that the function reads
+func (cfg *Config) EncodeFlags() string {
+	data, _ := json.Marshal(cfg.DeployFlags())
+	return string(data)
+}
+
+// GetFlags returns the feature flags that are declared for a stage in
+// the config (the default stage's are in the config's "flags")
+func (cfg *Config) GetFlags(stage string) map[string]bool {
+	if stage == "" {
+		if cfg.Config.Flags == nil {
+			cfg.Config.Flags = map[string]bool{}
+		}
+		return cfg.Config.Flags
+	}
+	if cfg.Config.Stages == nil {
+		cfg.Config.Stages = map[string]*Stage{}
+	}
+	if cfg.Config.Stages[stage] == nil {
+		cfg.Config.Stages[stage] = &Stage{}
+	}
+	if cfg.Config.Stages[stage].Flags == nil {
+		cfg.Config.Stages[stage].Flags = map[string]bool{}
+	}
+	return cfg.Config.Stages[stage].Flags
+}
+
+// FlagParameterName returns the name of the SSM parameter
+// that stores the project's feature flags
+func (cfg *Config) FlagParameterName() string {
+	return fmt.Sprintf("/kettle/%s/flags", cfg.ProjectName)
+}
+
+// ValidateFlagName returns an error if a feature flag's name
+// cannot be used by the function's code
+func ValidateFlagName(name string) error {
+	if !flagName.MatchString(name) {
+		return fmt.Errorf("invalid feature flag: %s (names start with a letter, and contain letters, numbers, _, -, and .)", name)
+	}
+	return nil
+}
+
+// ValidateFlags checks the names of the feature flags, and that
+// SSM parameters are only used to store them on AWS Lambda
+func (cfg *Config) ValidateFlags() error {
+	switch cfg.GetFlagStore() {
+	case FlagStoreEnv:
+	case FlagStoreSSM:
+		if cfg.Config.CloudProvider != "aws" || cfg.Config.DeploymentType != "lambda" {
+			return fmt.Errorf("feature flags can only be stored in SSM on aws lambda deployments (try: \"flag_store\": \"%s\")", FlagStoreEnv)
+		}
+	default:
+		return fmt.Errorf("unknown flag store: %s (expected %s or %s)", cfg.Config.FlagStore, FlagStoreEnv, FlagStoreSSM)
+	}
+	for name := range cfg.DeployFlags() {
+		if err := ValidateFlagName(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
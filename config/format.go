@@ -0,0 +1,96 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+const (
+	FormatterGofmt     = "gofmt"
+	FormatterBlack     = "black"
+	FormatterPrettier  = "prettier"
+	FormatterTerraform = "terraform"
+)
+
+// Formatter formats the files of a project that is created from a template (by
+// their names, e.g. *.go), with a command that is run with the files' paths;
+// the built-in formatters have a command and files, which can be changed
+
+type Formatter struct {
+	Formatter string   `json:"formatter,omitempty"`
+	Command   []string `json:"command,omitempty"`
+	Files     []string `json:"files,omitempty"`
+}
+
+// builtinFormatters are the commands and files of the built-in formatters
+var builtinFormatters = map[string]*Formatter{
+	FormatterGofmt: {
+		Command: []string{"gofmt", "-w"},
+		Files:   []string{"*.go"},
+	},
+	FormatterBlack: {
+		Command: []string{"black", "--quiet"},
+		Files:   []string{"*.py", "*.pyi"},
+	},
+	FormatterPrettier: {
+		// YAML is left out, as Helm charts' templates are not valid YAML
+		Command: []string{"prettier", "--write"},
+		Files:   []string{"*.js", "*.jsx", "*.mjs", "*.ts", "*.tsx", "*.json", "*.css", "*.scss", "*.html", "*.md"},
+	},
+	FormatterTerraform: {
+		Command: []string{"terraform", "fmt"},
+		Files:   []string{"*.tf", "*.tfvars"},
+	},
+}
+
+// GetFormatters returns the template's formatters, with the
+// commands and files of the built-in formatters filled in
+func (cfg *Config) GetFormatters() ([]*Formatter, error) {
+	if cfg.Render == nil {
+		return nil, nil
+	}
+	formatters := []*Formatter{}
+	for _, formatter := range cfg.Render.Format {
+		resolved := *formatter
+		if formatter.Formatter != "" {
+			builtin, ok := builtinFormatters[formatter.Formatter]
+			if !ok {
+				return nil, fmt.Errorf("unknown formatter: %s (expected %s, %s, %s, or %s, or a command)",
+					formatter.Formatter,
+					FormatterGofmt,
+					FormatterBlack,
+					FormatterPrettier,
+					FormatterTerraform,
+				)
+			}
+			if len(resolved.Command) == 0 {
+				resolved.Command = builtin.Command
+			}
+			if len(resolved.Files) == 0 {
+				resolved.Files = builtin.Files
+			}
+		}
+		if len(resolved.Command) == 0 || len(resolved.Files) == 0 {
+			return nil, fmt.Errorf("a formatter needs a command, and the files that it formats (e.g. *.rs)")
+		}
+		formatters = append(formatters, &resolved)
+	}
+	return formatters, nil
+}
+
+// Formats returns whether the formatter formats a file, by its path in the
+// project: patterns without a slash match the file's name (e.g. *.go),
+// and patterns with one match its path (e.g. src/*.ts)
+func (f *Formatter) Formats(relativePath string) bool {
+	for _, pattern := range f.Files {
+		name := path.Base(relativePath)
+		if strings.Contains(pattern, "/") {
+			name = relativePath
+		}
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
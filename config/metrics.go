@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// defaultErrorsPattern matches the errors that are logged by the
+	// structured logging helper, and the runtimes' own error logs
+	defaultErrorsPattern = "ERROR"
+	defaultMetricValue   = "1"
+	errorsMetricName     = "errors"
+)
+
+// MetricsNamespace is the namespace of the project's log metrics, and
+// of the metrics that the logging helper writes in the embedded metric format
+func (cfg *Config) MetricsNamespace() string {
+	return fmt.Sprintf("Kettle/%s", cfg.GetBaseProjectName())
+}
+
+// GetPattern returns the metric's pattern; the errors metric
+// matches logged errors, unless it has a pattern of its own
+func (m *LogMetric) GetPattern() string {
+	if m.Pattern == "" && strings.EqualFold(m.Name, errorsMetricName) {
+		return defaultErrorsPattern
+	}
+	return m.Pattern
+}
+
+// GetValue returns the value that is added to the metric for each match
+// (e.g. $.duration, for structured logs); it defaults to counting matches
+func (m *LogMetric) GetValue() string {
+	if m.Value == "" {
+		return defaultMetricValue
+	}
+	return m.Value
+}
+
+// ValidateLogMetrics checks that each log metric has a name and a pattern
+func (cfg *Config) ValidateLogMetrics() error {
+	names := map[string]bool{}
+	for _, metric := range cfg.Config.LogMetrics {
+		if metric.Name == "" {
+			return fmt.Errorf("log metrics need a name")
+		}
+		if metric.GetPattern() == "" {
+			return fmt.Errorf("log metric %s needs a pattern", metric.Name)
+		}
+		if names[metric.Name] {
+			return fmt.Errorf("log metric %s is declared more than once", metric.Name)
+		}
+		names[metric.Name] = true
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const (
+	// NamingStageSuffix names a stage's resources <project>-<stage> (the default)
+	NamingStageSuffix = "suffix"
+	// NamingStagePrefix names a stage's resources <stage>-<project>
+	NamingStagePrefix = "prefix"
+
+	// Truncated names end with a hash of the full name, so that they stay unique
+	namingHashLength    = 8
+	minNamingMaxLength  = 16
+	namingHashSeparator = "-"
+)
+
+// Naming is an organisation's convention for the names of the resources that
+// kettle creates, e.g. so that they fit its naming policies and do not collide
+// with another team's resources; it is set in the settings file
+
+type Naming struct {
+	Prefix    string `yaml:"prefix,omitempty"`
+	Suffix    string `yaml:"suffix,omitempty"`
+	Stage     string `yaml:"stage,omitempty"`
+	MaxLength int    `yaml:"max_length,omitempty"`
+}
+
+// Validate returns an error if the naming convention is not valid
+func (n *Naming) Validate() error {
+	switch n.Stage {
+	case "", NamingStageSuffix, NamingStagePrefix:
+	default:
+		return fmt.Errorf("unknown naming stage: %s (expected %s or %s)", n.Stage, NamingStageSuffix, NamingStagePrefix)
+	}
+	if n.MaxLength != 0 && n.MaxLength < minNamingMaxLength {
+		return fmt.Errorf("the naming max_length must be at least %d", minNamingMaxLength)
+	}
+	return nil
+}
+
+// SetNaming names the project's resources with the naming convention
+func (cfg *Config) SetNaming(naming *Naming) error {
+	if naming != nil {
+		if err := naming.Validate(); err != nil {
+			return err
+		}
+	}
+	if cfg.BaseName == "" {
+		cfg.BaseName = cfg.ProjectName
+	}
+	cfg.Naming = naming
+	cfg.ProjectName = cfg.getResourceName()
+	return nil
+}
+
+// getResourceName returns the name of the project's resources, in
+// the current stage, following the naming convention (if there is one)
+func (cfg *Config) getResourceName() string {
+	name := cfg.BaseName
+	if cfg.Stage != "" {
+		if cfg.Naming != nil && cfg.Naming.Stage == NamingStagePrefix {
+			name = fmt.Sprintf("%s-%s", cfg.Stage, name)
+		} else {
+			name = fmt.Sprintf("%s-%s", name, cfg.Stage)
+		}
+	}
+	if cfg.Naming == nil {
+		return name
+	}
+	if cfg.Naming.Prefix != "" {
+		name = fmt.Sprintf("%s-%s", cfg.Naming.Prefix, name)
+	}
+	if cfg.Naming.Suffix != "" {
+		name = fmt.Sprintf("%s-%s", name, cfg.Naming.Suffix)
+	}
+	if cfg.Naming.MaxLength == 0 || len(name) <= cfg.Naming.MaxLength {
+		return name
+	}
+	hash := sha1.Sum([]byte(name))
+	truncated := name[:cfg.Naming.MaxLength-namingHashLength-len(namingHashSeparator)]
+	truncated = strings.TrimRight(truncated, "-_")
+	return truncated + namingHashSeparator + hex.EncodeToString(hash[:])[:namingHashLength]
+}
+
+// GetNamePattern returns a wildcard pattern that matches the names
+// of the project's resources in all of its stages
+func (cfg *Config) GetNamePattern() string {
+	pattern := cfg.GetBaseProjectName() + "*"
+	if cfg.Naming == nil {
+		return pattern
+	}
+	if cfg.Naming.Stage == NamingStagePrefix {
+		pattern = "*" + pattern
+	}
+	if cfg.Naming.Prefix != "" {
+		pattern = fmt.Sprintf("%s-%s", cfg.Naming.Prefix, pattern)
+	}
+	if cfg.Naming.MaxLength != 0 && len(pattern) > cfg.Naming.MaxLength {
+		pattern = pattern[:cfg.Naming.MaxLength-namingHashLength-len(namingHashSeparator)]
+		pattern = strings.TrimRight(pattern, "-_*") + "*"
+	}
+	return pattern
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"regexp"
+	"sort"
+)
+
+// outputReference matches references to the outputs of other projects,
+// e.g. ${kettle:users.url}; the project is a directory next to the project
+var outputReference = regexp.MustCompile(`\$\{kettle:([^}]+)\.([A-Za-z0-9_]+)\}`)
+
+// OutputReference is a reference to an output of another project
+
+type OutputReference struct {
+	Project string
+	Output  string
+}
+
+// GetOutputReferences returns the outputs of other projects that the
+// environment variables of the stage that is deployed refer to
+func (cfg *Config) GetOutputReferences() []*OutputReference {
+	values := []string{}
+	for _, value := range cfg.Config.Environment {
+		values = append(values, value)
+	}
+	if stage, ok := cfg.Config.Stages[cfg.Stage]; ok && stage != nil {
+		for _, value := range stage.Environment {
+			values = append(values, value)
+		}
+	}
+	sort.Strings(values)
+	references := []*OutputReference{}
+	seen := map[string]bool{}
+	for _, value := range values {
+		for _, match := range outputReference.FindAllStringSubmatch(value, -1) {
+			if seen[match[0]] {
+				continue
+			}
+			seen[match[0]] = true
+			references = append(references, &OutputReference{
+				Project: match[1],
+				Output:  match[2],
+			})
+		}
+	}
+	return references
+}
+
+// ResolveOutputs replaces the references to the outputs of other projects
+// in a value; references whose outputs have not been read are kept
+func (cfg *Config) ResolveOutputs(value string) string {
+	return outputReference.ReplaceAllStringFunc(value, func(reference string) string {
+		match := outputReference.FindStringSubmatch(reference)
+		if output, ok := cfg.ReferencedOutputs[match[1]][match[2]]; ok {
+			return output
+		}
+		return reference
+	})
+}
@@ -0,0 +1,229 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// OverrideFileName is an overlay that is shared by all of the project's stages
+	OverrideFileName = "kettle.override.yaml"
+	// LocalFileName is a personal overlay, which is merged last and is not committed
+	LocalFileName = "kettle.local.yaml"
+)
+
+// OverlayStage is the stage whose overlay (kettle.<stage>.yaml) is merged
+// over the config when it is read; it is the default stage if it is not set
+var OverlayStage string
+
+// StageFileName returns the name of a stage's overlay, e.g. kettle.staging.yaml
+func StageFileName(stage string) string {
+	return fmt.Sprintf("kettle.%s.yaml", stage)
+}
+
+// OverlayFileNames returns the overlays of a stage, in the order that
+// they are merged over kettle.json: later overlays take precedence
+func OverlayFileNames(stage string) []string {
+	if stage == "" {
+		stage = DefaultStage
+	}
+	return []string{
+		OverrideFileName,
+		StageFileName(stage),
+		LocalFileName,
+	}
+}
+
+// Overlays returns the overlays that were merged over the config when it was read
+func (cfg *Config) Overlays() []string {
+	return cfg.overlays
+}
+
+// applyOverlays merges the overlays in the project's directory over its config
+// (kettle.json); each overlay is YAML, in the same shape as kettle.json, and is
+// merged like a JSON merge patch: objects are merged key by key, other values
+// (including lists) replace the config's values, and null removes a value
+func applyOverlays(projectPath string, data []byte) ([]byte, []string, map[string]interface{}, map[string]interface{}, error) {
+	base := map[string]interface{}{}
+	if err := json.Unmarshal(data, &base); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	var merged interface{} = copyDocument(base)
+	applied := []string{}
+	for _, fileName := range OverlayFileNames(OverlayStage) {
+		overlayData, err := ioutil.ReadFile(path.Join(projectPath, fileName))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		var overlay interface{}
+		if err := yaml.Unmarshal(overlayData, &overlay); err != nil {
+			return nil, nil, nil, nil, fmt.Errorf("invalid overlay %s: %s", fileName, err)
+		}
+		overlay, err = normalizeYAML(overlay)
+		if err != nil {
+			return nil, nil, nil, nil, fmt.Errorf("invalid overlay %s: %s", fileName, err)
+		}
+		if overlay == nil {
+			// The overlay is empty
+			continue
+		}
+		if _, ok := overlay.(map[string]interface{}); !ok {
+			return nil, nil, nil, nil, fmt.Errorf("invalid overlay %s: expected an object, like kettle.json", fileName)
+		}
+		merged = mergePatch(merged, overlay)
+		applied = append(applied, fileName)
+	}
+	if len(applied) == 0 {
+		return data, nil, nil, nil, nil
+	}
+
+	// The merged document is encoded and decoded again, so that its
+	// values have the same types as a config that is encoded later
+	mergedData, err := json.Marshal(merged)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	mergedDoc := map[string]interface{}{}
+	if err := json.Unmarshal(mergedData, &mergedDoc); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return mergedData, applied, base, mergedDoc, nil
+}
+
+// removeOverlays returns the config to write to kettle.json: the changes that
+// were made to the config since it was read are applied to kettle.json as it
+// was before the overlays were merged, so that their values are not written to it
+func removeOverlays(cfg *Config, data []byte) ([]byte, error) {
+	current := map[string]interface{}{}
+	if err := json.Unmarshal(data, &current); err != nil {
+		return nil, err
+	}
+	changes := diffDocuments(cfg.mergedDoc, current)
+	unmerged, err := json.Marshal(mergePatch(copyDocument(cfg.baseDoc), changes))
+	if err != nil {
+		return nil, err
+	}
+	// The config is decoded and encoded again, so that its keys are in the usual order
+	written := &Config{}
+	if err := json.Unmarshal(unmerged, written); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(written, "", "  ")
+}
+
+// isSourcePath returns whether a directory is the one that the config was read from
+func (cfg *Config) isSourcePath(projectPath string) bool {
+	source, err := filepath.Abs(cfg.sourcePath)
+	if err != nil {
+		return false
+	}
+	target, err := filepath.Abs(projectPath)
+	if err != nil {
+		return false
+	}
+	return source == target
+}
+
+// mergePatch merges a patch over a document, as in a JSON merge patch (RFC 7386)
+func mergePatch(document, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	documentObject, ok := document.(map[string]interface{})
+	if !ok {
+		documentObject = map[string]interface{}{}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(documentObject, key)
+			continue
+		}
+		documentObject[key] = mergePatch(documentObject[key], value)
+	}
+	return documentObject
+}
+
+// diffDocuments returns the merge patch that changes one document into another
+func diffDocuments(from, to map[string]interface{}) map[string]interface{} {
+	patch := map[string]interface{}{}
+	for key := range from {
+		if _, ok := to[key]; !ok {
+			patch[key] = nil
+		}
+	}
+	for key, value := range to {
+		previous, ok := from[key]
+		if !ok {
+			patch[key] = value
+			continue
+		}
+		previousObject, previousIsObject := previous.(map[string]interface{})
+		object, isObject := value.(map[string]interface{})
+		if previousIsObject && isObject {
+			if changes := diffDocuments(previousObject, object); len(changes) > 0 {
+				patch[key] = changes
+			}
+			continue
+		}
+		if !reflect.DeepEqual(previous, value) {
+			patch[key] = value
+		}
+	}
+	return patch
+}
+
+// copyDocument returns a deep copy of a document's objects, so that merging
+// into it does not change the original (lists are replaced, not merged)
+func copyDocument(document map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(document))
+	for key, value := range document {
+		if object, ok := value.(map[string]interface{}); ok {
+			value = copyDocument(object)
+		}
+		copied[key] = value
+	}
+	return copied
+}
+
+// normalizeYAML converts the objects that YAML is decoded to (whose keys
+// can be any value) to objects with string keys, like decoded JSON
+func normalizeYAML(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		object := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			name, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("the key %v is not a string", key)
+			}
+			normalized, err := normalizeYAML(item)
+			if err != nil {
+				return nil, err
+			}
+			object[name] = normalized
+		}
+		return object, nil
+	case []interface{}:
+		list := make([]interface{}, len(value))
+		for i, item := range value {
+			normalized, err := normalizeYAML(item)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = normalized
+		}
+		return list, nil
+	}
+	return value, nil
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	PackagingClean       = "clean"
+	PackagingResolveDeps = "resolve-deps"
+	PackagingBuild       = "build"
+	PackagingPrune       = "prune"
+	PackagingArchive     = "archive"
+)
+
+// PackagingStages are the stages of the pipeline that packages
+// a deployment archive, in the order that they run
+var PackagingStages = []string{
+	PackagingClean,
+	PackagingResolveDeps,
+	PackagingBuild,
+	PackagingPrune,
+	PackagingArchive,
+}
+
+// ValidatePackaging returns an error if the config customizes
+// a stage that is not in the packaging pipeline
+func (cfg *Config) ValidatePackaging() error {
+	for stage := range cfg.Config.Packaging {
+		if !isPackagingStage(stage) {
+			return fmt.Errorf("unknown packaging stage: %s (expected one of: %s)", stage, strings.Join(PackagingStages, ", "))
+		}
+	}
+	return nil
+}
+
+func isPackagingStage(stage string) bool {
+	for _, s := range PackagingStages {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// GetTarget returns where the include path is copied to in the project
+func (include *Include) GetTarget() string {
+	if include.Target == "" {
+		return filepath.Base(include.Path)
+	}
+	return include.Target
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+)
+
+// GetParity returns the project's parity checks, which are
+// empty if they are not in the config
+func (cfg *Config) GetParity() *Parity {
+	if cfg.Config.Parity == nil {
+		return &Parity{}
+	}
+	return cfg.Config.Parity
+}
+
+// ParityTests returns the commands that test the project in its
+// runtime's image; the "refresh" tests are used, unless it has its own
+func (cfg *Config) ParityTests() []string {
+	if tests := cfg.GetParity().Test; len(tests) > 0 {
+		return tests
+	}
+	return cfg.GetRefresh().Test
+}
+
+// ValidateParity checks that the project is deployed to a Lambda runtime,
+// which has a container image to test in (unless the config sets one)
+func (cfg *Config) ValidateParity() error {
+	if cfg.GetParity().Image != "" {
+		return nil
+	}
+	if cfg.Config.CloudProvider == "aws" && cfg.Config.DeploymentType == "lambda" {
+		return nil
+	}
+	return fmt.Errorf("parity checks need the image to test in on %s %s deployments (add \"parity\": {\"image\": \"...\"} to kettle.json)",
+		cfg.Config.CloudProvider,
+		cfg.Config.DeploymentType,
+	)
+}
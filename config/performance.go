@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"math"
+)
+
+// PerformanceReport describes how a deployed function performed when it
+// was invoked after a deploy, with suggestions to make it start faster
+
+type PerformanceReport struct {
+	// PackageSize is the size of the deployed package, in bytes
+	PackageSize int64
+	// MemorySize is the memory that the function is configured with, in MB
+	MemorySize  int
+	Invocations []*InvocationReport
+	Suggestions []string
+}
+
+// InvocationReport is the duration and memory usage of one invocation;
+// cold starts also spend time initializing the function
+
+type InvocationReport struct {
+	Cold           bool
+	InitDuration   float64
+	Duration       float64
+	BilledDuration float64
+	MaxMemoryUsed  int
+}
+
+// MemoryTuning is the average duration and cost of a function's
+// invocations when it is configured with an amount of memory
+
+type MemoryTuning struct {
+	// Memory is in MB
+	Memory int
+	// Duration is the average duration of the invocations, in ms
+	Duration float64
+	// Cost is the average cost of an invocation, in USD
+	Cost float64
+}
+
+// Memory tuning strategies, which choose the memory that
+// is cheapest, fastest, or the best of both
+const (
+	TuneForCost     = "cost"
+	TuneForSpeed    = "speed"
+	TuneForBalanced = "balanced"
+)
+
+// RecommendMemory returns the memory setting that is best for the strategy;
+// balanced weighs the cost and the duration (relative to the cheapest and
+// the fastest settings) equally
+func RecommendMemory(results []*MemoryTuning, strategy string) (*MemoryTuning, error) {
+	if len(results) == 0 {
+		return nil, fmt.Errorf("there are no results to recommend a memory setting from")
+	}
+	minCost, minDuration := results[0].Cost, results[0].Duration
+	for _, result := range results {
+		minCost = math.Min(minCost, result.Cost)
+		minDuration = math.Min(minDuration, result.Duration)
+	}
+
+	var score func(*MemoryTuning) float64
+	switch strategy {
+	case TuneForCost:
+		score = func(result *MemoryTuning) float64 { return result.Cost }
+	case TuneForSpeed:
+		score = func(result *MemoryTuning) float64 { return result.Duration }
+	case TuneForBalanced:
+		score = func(result *MemoryTuning) float64 {
+			return result.Cost/math.Max(minCost, math.SmallestNonzeroFloat64) + result.Duration/math.Max(minDuration, math.SmallestNonzeroFloat64)
+		}
+	default:
+		return nil, fmt.Errorf("unknown strategy: %s (expected %s, %s, or %s)", strategy, TuneForCost, TuneForSpeed, TuneForBalanced)
+	}
+
+	best := results[0]
+	for _, result := range results[1:] {
+		if score(result) < score(best) {
+			best = result
+		}
+	}
+	return best, nil
+}
@@ -0,0 +1,7 @@
+package config
+
+// IsHTTP1 returns true if the project serves plain HTTP/1 requests,
+// which is the only protocol that function (non-container) targets support
+func (cfg *Config) IsHTTP1() bool {
+	return cfg.Config.Protocol == "" || cfg.Config.Protocol == "http1"
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// maxQueueRetries is the most times SQS can deliver a message
+// (its maxReceiveCount) before it is moved to a dead-letter queue
+const maxQueueRetries = 999
+
+// ValidateQueue checks the queue's retries; they are only set up on queues
+// that kettle creates, as it does not change the redrive policy of existing queues
+func (cfg *Config) ValidateQueue() error {
+	queue := cfg.Config.Queue
+	if queue == nil || (queue.Retries == 0 && queue.AlarmEmail == "") {
+		return nil
+	}
+	if queue.Existing != "" {
+		return errors.New("retries are not supported on existing queues (set up their dead-letter queue with its redrive policy)")
+	}
+	if queue.Retries == 0 {
+		return errors.New("the queue's alarm_email needs retries, as the alarm is on its dead-letter queue")
+	}
+	if queue.Retries < 1 || queue.Retries > maxQueueRetries {
+		return fmt.Errorf("the queue's retries must be between 1 and %d", maxQueueRetries)
+	}
+	return nil
+}
+
+// DeadLetterQueueName returns the name of the queue's dead-letter queue
+func DeadLetterQueueName(queueName string) string {
+	return queueName + "-dlq"
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// QuotaWarningThreshold is the share of a quota that, once a deploy would
+// use it, is close enough to the quota's limit to warn about it
+const QuotaWarningThreshold = 0.9
+
+// Quota is a limit of the cloud account (e.g. its Lambda concurrency) that a
+// deploy uses, with how much of it is already used, and how much more of it
+// the deploy needs (e.g. a new REST API)
+
+type Quota struct {
+	// The service and code of the quota in the cloud's quota service (e.g.
+	// AWS Service Quotas); the code is empty if it could not be read
+	ServiceCode string
+	QuotaCode   string
+	Name        string
+	Limit       float64
+	Usage       float64
+	Needed      float64
+	// Whether an increase of the quota can be requested
+	Adjustable bool
+	// Why the deploy uses the quota, e.g. that the function is in a VPC
+	Reason string
+}
+
+// WouldExceed returns whether the deploy needs more of the quota than is left
+func (q *Quota) WouldExceed() bool {
+	return q.Usage+q.Needed > q.Limit
+}
+
+// IsNearLimit returns whether the deploy would use the quota
+// beyond the QuotaWarningThreshold of its limit
+func (q *Quota) IsNearLimit() bool {
+	return q.Usage+q.Needed >= q.Limit*QuotaWarningThreshold
+}
+
+// IsRequestable returns whether an increase of the quota can be
+// requested from the cloud's quota service
+func (q *Quota) IsRequestable() bool {
+	return q.Adjustable && q.ServiceCode != "" && q.QuotaCode != ""
+}
+
+// SuggestedLimit is the limit to request an increase to: double the current
+// limit, or more if the deploy (with headroom) needs more than that
+func (q *Quota) SuggestedLimit() float64 {
+	return math.Max(q.Limit*2, math.Ceil((q.Usage+q.Needed)*1.5))
+}
+
+func (q *Quota) String() string {
+	message := fmt.Sprintf("%s: %s of %s used", q.Name, formatQuotaValue(q.Usage), formatQuotaValue(q.Limit))
+	if q.Needed > 0 {
+		message += fmt.Sprintf(", and the deploy needs %s more", formatQuotaValue(q.Needed))
+	}
+	if q.Reason != "" {
+		message += fmt.Sprintf(" (%s)", q.Reason)
+	}
+	return message
+}
+
+func formatQuotaValue(value float64) string {
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", value), ".0")
+}
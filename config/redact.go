@@ -0,0 +1,40 @@
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path"
+)
+
+// ReadRedactedConfig returns the project's config (its kettle.json, without its
+// overlays), with the values that MaskSecret masks (e.g. of environment variables
+// whose names suggest that they are secret) masked, e.g. for a bug report
+func ReadRedactedConfig(projectPath string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path.Join(projectPath, configFileName))
+	if err != nil {
+		return nil, err
+	}
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(maskValues("", document), "", "  ")
+}
+
+// maskValues masks the strings in a JSON document by the keys that they
+// are the values of (the strings in a list, by the list's key)
+func maskValues(key string, value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for itemKey, item := range typed {
+			typed[itemKey] = maskValues(itemKey, item)
+		}
+	case []interface{}:
+		for i, item := range typed {
+			typed[i] = maskValues(key, item)
+		}
+	case string:
+		return MaskSecret(key, typed)
+	}
+	return value
+}
@@ -0,0 +1,20 @@
+package config
+
+// defaultRefreshSchedule refreshes the project every Monday at 06:00 UTC
+const defaultRefreshSchedule = "0 6 * * 1"
+
+// GetRefresh returns the project's refresh config, which is empty if it is not set
+func (cfg *Config) GetRefresh() *Refresh {
+	if cfg.Config.Refresh == nil {
+		return &Refresh{}
+	}
+	return cfg.Config.Refresh
+}
+
+// RefreshSchedule returns the cron schedule of the refresh workflow
+func (cfg *Config) RefreshSchedule() string {
+	if cfg.Config.Refresh == nil || cfg.Config.Refresh.Schedule == "" {
+		return defaultRefreshSchedule
+	}
+	return cfg.Config.Refresh.Schedule
+}
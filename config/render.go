@@ -0,0 +1,129 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+const (
+	// ProfileText renders files with {{ }}, and inserts values as they are
+	ProfileText = "text"
+	// ProfileTerraform escapes values for HCL's quoted strings (e.g. ${ and %{)
+	ProfileTerraform = "terraform"
+	// ProfileHelm renders files with [[ ]], so that the chart's own {{ }} are
+	// left for Helm, and escapes values for YAML's quoted strings
+	ProfileHelm = "helm"
+	// ProfileCopy copies files as they are, without rendering them
+	ProfileCopy = "copy"
+)
+
+// TemplateRender is how a template's files are rendered (by default, for
+// files without a profile of their own), the formatters that are run on them,
+// and the commands that check the project once it has been created (e.g.
+// terraform validate, or helm lint)
+
+type TemplateRender struct {
+	Profile  string            `json:"profile,omitempty"`
+	Format   []*Formatter      `json:"format,omitempty"`
+	Validate []*ValidationHook `json:"validate,omitempty"`
+}
+
+// ValidationHook is a command that is run in a directory of the
+// project (its root by default) once the project has been created
+
+type ValidationHook struct {
+	Command   []string `json:"command"`
+	Directory string   `json:"directory,omitempty"`
+}
+
+// GetRenderProfile returns the profile that a file of the template (by its
+// path in the template) is rendered with: the profile of the last of the
+// template's files that has one and contains it, or the template's profile
+func (cfg *Config) GetRenderProfile(relativePath string) string {
+	profile := ""
+	if cfg.Render != nil {
+		profile = cfg.Render.Profile
+	}
+	for _, file := range cfg.Files {
+		if file.Profile == "" {
+			continue
+		}
+		if relativePath == file.Path || strings.HasPrefix(relativePath, strings.TrimSuffix(file.Path, "/")+"/") {
+			profile = file.Profile
+		}
+	}
+	if profile == "" {
+		return ProfileText
+	}
+	return profile
+}
+
+// ValidateRender checks the template's profiles, formatters, and validation hooks
+func (cfg *Config) ValidateRender() error {
+	profiles := []string{}
+	if cfg.Render != nil && cfg.Render.Profile != "" {
+		profiles = append(profiles, cfg.Render.Profile)
+	}
+	for _, file := range cfg.Files {
+		if file.Profile != "" {
+			profiles = append(profiles, file.Profile)
+		}
+	}
+	for _, profile := range profiles {
+		switch profile {
+		case ProfileText, ProfileTerraform, ProfileHelm, ProfileCopy:
+		default:
+			return fmt.Errorf("unknown render profile: %s (expected %s, %s, %s, or %s)", profile, ProfileText, ProfileTerraform, ProfileHelm, ProfileCopy)
+		}
+	}
+	if cfg.Render == nil {
+		return nil
+	}
+	if _, err := cfg.GetFormatters(); err != nil {
+		return err
+	}
+	for _, hook := range cfg.Render.Validate {
+		if len(hook.Command) == 0 {
+			return fmt.Errorf("a validation hook has no command")
+		}
+		if directory := path.Clean(hook.Directory); path.IsAbs(directory) || directory == ".." || strings.HasPrefix(directory, "../") {
+			return fmt.Errorf("the directory of a validation hook must be in the project: %s", hook.Directory)
+		}
+	}
+	return nil
+}
+
+// GetValidationHooks returns the commands that check the project once it has
+// been created: the template's hooks, or the profiles' usual checks (terraform
+// validate in the project's root, and helm lint of its charts) if it has none
+func (cfg *Config) GetValidationHooks(charts []string, hasTerraform bool) []*ValidationHook {
+	if cfg.Render != nil && len(cfg.Render.Validate) > 0 {
+		return cfg.Render.Validate
+	}
+	hooks := []*ValidationHook{}
+	if hasTerraform && cfg.usesProfile(ProfileTerraform) {
+		hooks = append(hooks,
+			&ValidationHook{Command: []string{"terraform", "init", "-backend=false", "-input=false"}},
+			&ValidationHook{Command: []string{"terraform", "validate"}},
+		)
+	}
+	if cfg.usesProfile(ProfileHelm) {
+		for _, chart := range charts {
+			hooks = append(hooks, &ValidationHook{Command: []string{"helm", "lint", chart}})
+		}
+	}
+	return hooks
+}
+
+func (cfg *Config) usesProfile(profile string) bool {
+	if cfg.Render != nil && cfg.Render.Profile == profile {
+		return true
+	}
+	for _, file := range cfg.Files {
+		if file.Profile == profile {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	ScannerGrype = "grype"
+	ScannerTrivy = "trivy"
+	ScannerECR   = "ecr"
+
+	defaultScanSeverity = "critical"
+)
+
+// severities are the severities of vulnerabilities, from the most severe;
+// scanners that do not know a vulnerability's severity report it as unknown
+var severities = []string{"critical", "high", "medium", "low", "negligible", "unknown"}
+
+// Vulnerability is a known vulnerability in one of the packages of
+// a deployment package (or image)
+
+type Vulnerability struct {
+	ID       string
+	Package  string
+	Version  string
+	FixedIn  string
+	Severity string
+}
+
+// GetScanner returns the project's vulnerability scanner, which defaults to grype
+func (cfg *Config) GetScanner() string {
+	if cfg.Config.Scan == nil || cfg.Config.Scan.Scanner == "" {
+		return ScannerGrype
+	}
+	return cfg.Config.Scan.Scanner
+}
+
+// ScanSeverity returns the severity at which vulnerabilities
+// block a deploy, which defaults to critical
+func (cfg *Config) ScanSeverity() string {
+	if cfg.Config.Scan == nil || cfg.Config.Scan.Severity == "" {
+		return defaultScanSeverity
+	}
+	return strings.ToLower(cfg.Config.Scan.Severity)
+}
+
+// ValidateScan returns an error if the scan config has an unknown scanner or severity
+func (cfg *Config) ValidateScan() error {
+	switch cfg.GetScanner() {
+	case ScannerGrype, ScannerTrivy, ScannerECR:
+	default:
+		return fmt.Errorf("unknown scanner: %s (expected %s, %s, or %s)", cfg.GetScanner(), ScannerGrype, ScannerTrivy, ScannerECR)
+	}
+	if getSeverityRank(cfg.ScanSeverity()) < 0 {
+		return fmt.Errorf("unknown severity: %s (expected one of: %s)", cfg.ScanSeverity(), strings.Join(severities, ", "))
+	}
+	return nil
+}
+
+// BlocksDeploy returns true if the vulnerability is at or above the severity
+// threshold, and it is not ignored by the project's scan config
+func (cfg *Config) BlocksDeploy(vulnerability *Vulnerability) bool {
+	if cfg.Config.Scan != nil {
+		for _, ignored := range cfg.Config.Scan.Ignore {
+			if strings.EqualFold(ignored, vulnerability.ID) {
+				return false
+			}
+		}
+	}
+	rank := getSeverityRank(strings.ToLower(vulnerability.Severity))
+	return rank >= 0 && rank <= getSeverityRank(cfg.ScanSeverity())
+}
+
+func getSeverityRank(severity string) int {
+	for i, s := range severities {
+		if s == severity {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,34 @@
+package config
+
+import "net/http"
+
+const (
+	defaultCanaryInterval = 5
+)
+
+// GetSmokeTest returns the project's smoke test, with defaults for any
+// values that are not set; functions behind an AWS REST API only accept POST
+func (cfg *Config) GetSmokeTest() *SmokeTest {
+	smokeTest := SmokeTest{}
+	if cfg.Config.SmokeTest != nil {
+		smokeTest = *cfg.Config.SmokeTest
+	}
+	if smokeTest.Method == "" {
+		smokeTest.Method = http.MethodGet
+		if cfg.Config.DeploymentType == "lambda" {
+			smokeTest.Method = http.MethodPost
+		}
+	}
+	if smokeTest.ExpectedStatus == 0 {
+		smokeTest.ExpectedStatus = http.StatusOK
+	}
+	return &smokeTest
+}
+
+// CanaryInterval returns how often (in minutes) the canary runs
+func (cfg *Config) CanaryInterval() int {
+	if cfg.Config.Canary == nil || cfg.Config.Canary.Interval == 0 {
+		return defaultCanaryInterval
+	}
+	return cfg.Config.Canary.Interval
+}
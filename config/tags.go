@@ -0,0 +1,68 @@
+package config
+
+import (
+	"strconv"
+)
+
+const (
+	// ProjectTag is the tag (or label) that identifies a project's resources,
+	// e.g. so that their costs can be reported per project
+	ProjectTag = "kettle-project"
+	// StageTag is the tag (or label) that identifies which stage of
+	// a project a resource belongs to
+	StageTag = "kettle-stage"
+	// ExpiryTag is the tag (or label) with the Unix time after which
+	// a deployment can be pruned
+	ExpiryTag = "kettle-expires"
+	// DefaultStage is the stage of deployments that do not name one
+	DefaultStage = "prod"
+)
+
+// Tags returns the tags that kettle adds to the project's resources
+func (cfg *Config) Tags() map[string]string {
+	tags := map[string]string{
+		ProjectTag: cfg.GetBaseProjectName(),
+		StageTag:   cfg.GetStage(),
+	}
+	if !cfg.Expires.IsZero() {
+		tags[ExpiryTag] = strconv.FormatInt(cfg.Expires.Unix(), 10)
+	}
+	return tags
+}
+
+// GetStage returns the stage that is being deployed
+func (cfg *Config) GetStage() string {
+	if cfg.Stage == "" {
+		return DefaultStage
+	}
+	return cfg.Stage
+}
+
+// SetStage names the project's resources after a stage (e.g. a preview),
+// so that they are deployed as an isolated copy of the project
+func (cfg *Config) SetStage(stage string) {
+	if cfg.BaseName == "" {
+		cfg.BaseName = cfg.ProjectName
+	}
+	cfg.Stage = stage
+	cfg.ProjectName = cfg.getResourceName()
+}
+
+// GetBaseProjectName returns the project's name, without its
+// stage (or the naming convention's prefix and suffix)
+func (cfg *Config) GetBaseProjectName() string {
+	if cfg.BaseName == "" {
+		return cfg.ProjectName
+	}
+	return cfg.BaseName
+}
+
+// GetStageAccount returns the config of the stage, if it is deployed
+// to its own account (or project); otherwise, it returns nil
+func (cfg *Config) GetStageAccount(stage string) *Stage {
+	s, ok := cfg.Config.Stages[stage]
+	if !ok || (s.Profile == "" && s.RoleArn == "" && s.ProjectID == "") {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Tool is an external tool that kettle runs (e.g. cargo-lambda), which is
+// pinned to a version: it is downloaded into kettle's toolcache, and its
+// checksum is verified, so that every machine builds with the same tool
+
+type Tool struct {
+	Version string `json:"version"`
+	// The downloads of the tool, by platform (e.g. linux/amd64)
+	Platforms map[string]*ToolDownload `json:"platforms"`
+	// The executable's path in the download, if it is an archive
+	// whose executable is not named after the tool
+	Binary string `json:"binary,omitempty"`
+}
+
+// ToolDownload is where a tool is downloaded from on a platform, and the
+// SHA-256 checksum of the download (an executable, .tar.gz, or .zip)
+
+type ToolDownload struct {
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"`
+}
+
+var (
+	toolNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+	checksumPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+)
+
+// GetToolNames returns the names of the project's pinned tools, in order
+func (cfg *Config) GetToolNames() []string {
+	names := []string{}
+	for name := range cfg.Config.Tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetURL returns the download's URL, with its {version} placeholder replaced
+func (d *ToolDownload) GetURL(version string) string {
+	return strings.ReplaceAll(d.URL, "{version}", version)
+}
+
+// ValidateTools checks that each pinned tool has a version, and that
+// each of its downloads has a URL and a SHA-256 checksum
+func (cfg *Config) ValidateTools() error {
+	for _, name := range cfg.GetToolNames() {
+		tool := cfg.Config.Tools[name]
+		if !toolNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid tool name: %s", name)
+		}
+		if tool == nil || tool.Version == "" {
+			return fmt.Errorf("the tool %s must be pinned to a version", name)
+		}
+		if len(tool.Platforms) == 0 {
+			return fmt.Errorf("the tool %s has no platforms (e.g. linux/amd64) to download it for", name)
+		}
+		for platform, download := range tool.Platforms {
+			if len(strings.Split(platform, "/")) != 2 {
+				return fmt.Errorf("invalid platform for the tool %s: %s (expected <os>/<arch>, e.g. linux/amd64)", name, platform)
+			}
+			if download == nil || (!strings.HasPrefix(download.URL, "https://") && !strings.HasPrefix(download.URL, "http://")) {
+				return fmt.Errorf("the tool %s needs a url to download it from on %s", name, platform)
+			}
+			if !checksumPattern.MatchString(download.SHA256) {
+				return fmt.Errorf("the tool %s needs the sha256 checksum (in lowercase hex) of its download on %s", name, platform)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultTracingProtocol = "http/protobuf"
+)
+
+// TracingEnvironment returns the OpenTelemetry environment variables,
+// which point the service's exporter at the collector
+func (cfg *Config) TracingEnvironment() map[string]string {
+	if cfg.Config.Tracing == nil {
+		return map[string]string{}
+	}
+	tracing := cfg.Config.Tracing
+	environment := map[string]string{
+		"OTEL_SERVICE_NAME": cfg.ProjectName,
+	}
+	if tracing.Endpoint == "" {
+		return environment
+	}
+	environment["OTEL_EXPORTER_OTLP_ENDPOINT"] = tracing.Endpoint
+	environment["OTEL_EXPORTER_OTLP_PROTOCOL"] = defaultTracingProtocol
+	if tracing.Protocol != "" {
+		environment["OTEL_EXPORTER_OTLP_PROTOCOL"] = tracing.Protocol
+	}
+	if len(tracing.Headers) != 0 {
+		headers := []string{}
+		for key, value := range tracing.Headers {
+			headers = append(headers, fmt.Sprintf("%s=%s", key, value))
+		}
+		sort.Strings(headers)
+		environment["OTEL_EXPORTER_OTLP_HEADERS"] = strings.Join(headers, ",")
+	}
+	return environment
+}
+
+// ValidateTracing checks that services that are not on AWS Lambda
+// (which can export traces to X-Ray) have a collector to export to
+func (cfg *Config) ValidateTracing() error {
+	if cfg.Config.Tracing == nil || cfg.Config.Tracing.Endpoint != "" {
+		return nil
+	}
+	if cfg.Config.CloudProvider == "aws" && cfg.Config.DeploymentType == "lambda" {
+		return nil
+	}
+	return fmt.Errorf("tracing needs the endpoint of an OpenTelemetry collector on %s %s deployments",
+		cfg.Config.CloudProvider,
+		cfg.Config.DeploymentType,
+	)
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"crypto/sha1"
+	"fmt"
+)
+
+const (
+	TriggerSchedule = "schedule"
+	TriggerQueue    = "queue"
+	TriggerS3       = "s3"
+)
+
+// ValidateTrigger checks that a trigger has the values that its type needs
+func ValidateTrigger(trigger *Trigger) error {
+	switch trigger.Type {
+	case TriggerSchedule:
+		if trigger.Schedule == "" {
+			return fmt.Errorf("schedule triggers need a schedule expression, e.g. rate(1 hour)")
+		}
+	case TriggerS3:
+		if trigger.Bucket == "" {
+			return fmt.Errorf("s3 triggers need a bucket")
+		}
+	default:
+		return fmt.Errorf("unknown trigger type: %s (expected %s or %s)", trigger.Type, TriggerSchedule, TriggerS3)
+	}
+	return nil
+}
+
+// GetID returns a short ID for the trigger, which does not change
+// if other triggers are added to (or removed from) the project
+func (t *Trigger) GetID() string {
+	key := fmt.Sprintf("%s:%s:%s:%s:%s", t.Type, t.Schedule, t.Bucket, t.Prefix, t.Suffix)
+	return fmt.Sprintf("%x", sha1.Sum([]byte(key)))[:8]
+}
+
+// HasTrigger returns true if the project already has the same trigger
+func (cfg *Config) HasTrigger(trigger *Trigger) bool {
+	for _, t := range cfg.Config.Triggers {
+		if t.GetID() == trigger.GetID() {
+			return true
+		}
+	}
+	return false
+}
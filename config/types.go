@@ -1,5 +1,9 @@
 package config
 
+import (
+	"time"
+)
+
 const (
 	configFileName = "kettle.json"
 )
@@ -10,20 +14,393 @@ const (
 type Config struct {
 	ProjectName string `json:"name"`
 	Config      struct {
-		Runtime        string `json:"runtime"`
-		PythonManager  string `json:"python_manager,omitempty"`
-		CloudProvider  string `json:"cloud_provider"`
-		DeploymentType string `json:"deployment_type"`
-		EntryFunction  string `json:"entry_function"`
+		Runtime        string               `json:"runtime"`
+		PythonManager  string               `json:"python_manager,omitempty"`
+		CloudProvider  string               `json:"cloud_provider"`
+		DeploymentType string               `json:"deployment_type"`
+		EntryFunction  string               `json:"entry_function"`
+		Memory         int                  `json:"memory,omitempty"`
+		Timeout        int                  `json:"timeout,omitempty"`
+		IgnoreDrift    []string             `json:"ignore_drift,omitempty"`
+		KeepWarm       string               `json:"keep_warm,omitempty"`
+		Protocol       string               `json:"protocol,omitempty"`
+		Auth           string               `json:"auth,omitempty"`
+		GPU            bool                 `json:"gpu,omitempty"`
+		GPUType        string               `json:"gpu_type,omitempty"`
+		Architecture   string               `json:"architecture,omitempty"`
+		Environment    map[string]string    `json:"environment,omitempty"`
+		Secrets        map[string]string    `json:"secrets,omitempty"`
+		Flags          map[string]bool      `json:"flags,omitempty"`
+		FlagStore      string               `json:"flag_store,omitempty"`
+		Packaging      map[string][]string  `json:"packaging,omitempty"`
+		Model          *Model               `json:"model,omitempty"`
+		SageMaker      *SageMaker           `json:"sagemaker,omitempty"`
+		Queue          *Queue               `json:"queue,omitempty"`
+		Triggers       []*Trigger           `json:"triggers,omitempty"`
+		Static         *Static              `json:"static,omitempty"`
+		AddOns         []*AddOn             `json:"add_ons,omitempty"`
+		Include        []*Include           `json:"include,omitempty"`
+		SmokeTest      *SmokeTest           `json:"smoke_test,omitempty"`
+		Contract       *Contract            `json:"contract,omitempty"`
+		Canary         *Canary              `json:"canary,omitempty"`
+		Budget         *Budget              `json:"budget,omitempty"`
+		Refresh        *Refresh             `json:"refresh,omitempty"`
+		Parity         *Parity              `json:"parity,omitempty"`
+		Scan           *Scan                `json:"scan,omitempty"`
+		SecretScan     *SecretScan          `json:"secret_scan,omitempty"`
+		LogMetrics     []*LogMetric         `json:"log_metrics,omitempty"`
+		Tracing        *Tracing             `json:"tracing,omitempty"`
+		StateBackend   *StateBackend        `json:"state_backend,omitempty"`
+		Policy         *Policy              `json:"policy,omitempty"`
+		Protected      []string             `json:"protected,omitempty"`
+		Stages         map[string]*Stage    `json:"stages,omitempty"`
+		ApiStages      map[string]*ApiStage `json:"api_stages,omitempty"`
+		Api            *Api                 `json:"api,omitempty"`
+		CustomDomain   *CustomDomain        `json:"custom_domain,omitempty"`
+		Artifacts      *ArtifactStore       `json:"artifacts,omitempty"`
+		Tools          map[string]*Tool     `json:"tools,omitempty"`
 		AWS            struct {
-			RestApiResourceID string `json:"rest_api_resource_id,omitempty"`
+			RoleArn           string   `json:"role_arn,omitempty"`
+			RestApiID         string   `json:"rest_api_id,omitempty"`
+			RestApiName       string   `json:"rest_api_name,omitempty"`
+			RestApiResourceID string   `json:"rest_api_resource_id,omitempty"`
+			SubnetIDs         []string `json:"subnet_ids,omitempty"`
+			SecurityGroupIDs  []string `json:"security_group_ids,omitempty"`
+			WaitTimeout       int      `json:"wait_timeout,omitempty"`
 		} `json:"deploy_settings,omitempty"`
 	} `json:"config"`
-	Template []struct {
-		Prompt string `json:"prompt"`
-		Type   string `json:"type"`
-		Key    string `json:"key"`
-		Value  string `json:"value"`
-		Style  string `json:"format,omitempty"`
-	} `json:"template,omitempty"`
+	Template []*TemplatePrompt `json:"template,omitempty"`
+	Files    []*TemplateFile   `json:"files,omitempty"`
+	Metadata *TemplateMetadata `json:"metadata,omitempty"`
+	Render   *TemplateRender   `json:"render,omitempty"`
+	// The CI system that the template's pipeline runs on, and its secrets
+	CI *CI `json:"ci,omitempty"`
+	// The template that the project was created from, and its version
+	Source *TemplateSource `json:"template_source,omitempty"`
+	// The template's changelog (for projects, as of the template's version)
+	Changelog []*TemplateChange `json:"changelog,omitempty"`
+
+	// Environment variables with the connection details of provisioned
+	// add-ons; these are set during a deployment, and are not stored
+	AddOnEnvironment map[string]string `json:"-"`
+	// Environment variables with the outputs of the workspace functions
+	// that the project depends on; these are set by kettle deploy --all
+	DependencyEnvironment map[string]string `json:"-"`
+	// Environment variables that refer to the project's secrets in the cloud's
+	// secret store, once they are synced from Vault during a deployment
+	SecretEnvironment map[string]string `json:"-"`
+	// The outputs of the projects that the config refers to, by project;
+	// these are read from their state during a deployment, and are not stored
+	ReferencedOutputs map[string]map[string]string `json:"-"`
+	// The stage that is being deployed; this is set during
+	// a deployment, and is not stored
+	Stage string `json:"-"`
+	// The project's name, before the stage and naming convention
+	// are applied to it; ProjectName is the name of its resources
+	BaseName string `json:"-"`
+	// The naming convention of the project's resources, if there is one
+	Naming *Naming `json:"-"`
+	// When the deployment expires (if it was deployed with a TTL); this
+	// is recorded in the project's state, rather than its config
+	Expires time.Time `json:"-"`
+	// The artifact that is deployed; this is set when the project is
+	// built, or before a deployment to deploy a pre-built artifact
+	Artifact *Artifact `json:"-"`
+
+	// The overlays that were merged over kettle.json when the config was
+	// read, and the config (as a document) before and after they were
+	// merged, so that the overlays' values are not written to kettle.json
+	overlays   []string
+	baseDoc    map[string]interface{}
+	mergedDoc  map[string]interface{}
+	sourcePath string
+}
+
+// Model is a model artifact that is stored in S3 or GCS, and is either
+// added to the deployment package or downloaded by the function on startup
+
+type Model struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	URI     string `json:"uri"`
+	Source  string `json:"source,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Load    string `json:"load,omitempty"`
+}
+
+// SageMaker configures SageMaker endpoints, which are serverless
+// unless an instance type is set
+
+type SageMaker struct {
+	InstanceType   string `json:"instance_type,omitempty"`
+	InstanceCount  int    `json:"instance_count,omitempty"`
+	MaxConcurrency int    `json:"max_concurrency,omitempty"`
+	TrafficShift   string `json:"traffic_shift,omitempty"`
+}
+
+// Queue is an SQS queue that is created alongside (and consumed by)
+// the project's function
+
+type Queue struct {
+	Name              string `json:"name,omitempty"`
+	Existing          string `json:"existing,omitempty"`
+	BatchSize         int    `json:"batch_size,omitempty"`
+	VisibilityTimeout int    `json:"visibility_timeout,omitempty"`
+	// Messages that fail are retried this many times, and then moved to
+	// a dead-letter queue, which alarms (and emails) when it has messages
+	Retries    int    `json:"retries,omitempty"`
+	AlarmEmail string `json:"alarm_email,omitempty"`
+}
+
+// Trigger invokes a deployed function on a schedule (e.g. rate(1 hour)),
+// or when objects are created in a storage bucket
+
+type Trigger struct {
+	Type     string `json:"type"`
+	Schedule string `json:"schedule,omitempty"`
+	Bucket   string `json:"bucket,omitempty"`
+	Prefix   string `json:"prefix,omitempty"`
+	Suffix   string `json:"suffix,omitempty"`
+}
+
+// Static is a directory of static assets (e.g. a frontend) that
+// is hosted from a storage bucket, behind a CDN
+
+type Static struct {
+	Directory string `json:"directory"`
+	Bucket    string `json:"bucket,omitempty"`
+	CDN       bool   `json:"cdn,omitempty"`
+}
+
+// AddOn is a backing service (e.g. a database) that kettle provisions
+// on the first deploy, and whose connection details are passed to the
+// function as environment variables
+
+type AddOn struct {
+	Type         string `json:"type"`
+	Name         string `json:"name"`
+	PartitionKey string `json:"partition_key,omitempty"`
+	SortKey      string `json:"sort_key,omitempty"`
+	Engine       string `json:"engine,omitempty"`
+	ExpireAfter  int    `json:"expire_after_days,omitempty"`
+	Existing     string `json:"existing,omitempty"`
+}
+
+// SmokeTest is a request to the deployed service that checks
+// whether it is healthy
+
+type SmokeTest struct {
+	Path           string `json:"path,omitempty"`
+	Method         string `json:"method,omitempty"`
+	Body           string `json:"body,omitempty"`
+	ExpectedStatus int    `json:"expected_status,omitempty"`
+}
+
+// Canary runs the smoke test on a schedule, and raises
+// an alarm when it fails
+
+type Canary struct {
+	Interval   int    `json:"interval_minutes,omitempty"`
+	AlarmEmail string `json:"alarm_email,omitempty"`
+}
+
+// Budget is a monthly spending limit for the project's resources,
+// which alerts when spend passes a percentage of the limit
+
+type Budget struct {
+	Amount         float64 `json:"amount"`
+	Currency       string  `json:"currency,omitempty"`
+	AlertThreshold int     `json:"alert_threshold,omitempty"`
+	AlertEmail     string  `json:"alert_email,omitempty"`
+}
+
+// Refresh configures kettle refresh, which updates the project's dependencies,
+// tests the project, and redeploys it (e.g. on a schedule in CI)
+
+type Refresh struct {
+	// Commands that update the dependencies, instead of the built-in update for the runtime
+	Update []string `json:"update,omitempty"`
+	// Commands that test the project before it is redeployed
+	Test []string `json:"test,omitempty"`
+	// The cron schedule of the generated CI workflow
+	Schedule string `json:"schedule,omitempty"`
+}
+
+// Parity runs the project's tests in the container image of its Lambda runtime
+// before it is deployed, to catch differences from the local environment (e.g.
+// the Python version, or native dependencies that are built against another glibc)
+
+type Parity struct {
+	// Commands that test the project (its "refresh" tests, by default)
+	Test []string `json:"test,omitempty"`
+	// The image to test in, instead of the runtime's base image
+	Image string `json:"image,omitempty"`
+}
+
+// Scan checks the project's deployment package (or image) for known
+// vulnerabilities, and blocks deploys with any at or above the severity
+
+type Scan struct {
+	// The scanner: grype, trivy, or ecr (for images that are pushed to ECR)
+	Scanner  string `json:"scanner,omitempty"`
+	Severity string `json:"severity,omitempty"`
+	// Vulnerability IDs (e.g. CVE-2023-1234) that do not block deploys
+	Ignore []string `json:"ignore,omitempty"`
+}
+
+// SecretScan configures the check for secrets in the files that
+// are packaged, which blocks deploys that would include them
+
+type SecretScan struct {
+	Disabled bool `json:"disabled,omitempty"`
+	// Paths (or patterns, e.g. tests/fixtures/*) that are not checked
+	Ignore []string `json:"ignore,omitempty"`
+}
+
+// LogMetric is a metric that is counted (or measured) from the
+// function's logs, by the log events that match its pattern
+
+type LogMetric struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern,omitempty"`
+	Value   string `json:"value,omitempty"`
+	Unit    string `json:"unit,omitempty"`
+}
+
+// Tracing instruments the service with OpenTelemetry, and exports its traces
+// to a collector's OTLP endpoint (or to X-Ray, on AWS Lambda, if it is not set)
+
+type Tracing struct {
+	Endpoint string            `json:"endpoint,omitempty"`
+	Protocol string            `json:"protocol,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	// The Lambda layer with the OpenTelemetry instrumentation (AWS only),
+	// which defaults to the AWS Distro for OpenTelemetry layer for the runtime
+	Layer string `json:"layer,omitempty"`
+}
+
+// StateBackend is a bucket that the project's state is stored in, so
+// that it can be shared by a team; deploys hold a lock while they run
+
+type StateBackend struct {
+	Bucket string `json:"bucket"`
+	// The DynamoDB table that holds locks (AWS only); locks are
+	// stored alongside the state in the bucket on GCP
+	LockTable string `json:"lock_table,omitempty"`
+}
+
+// Policy lists the compliance rules that a deployment must pass: built-in
+// rules, and Rego files that are evaluated with OPA
+
+type Policy struct {
+	Rules            []string `json:"rules,omitempty"`
+	ApprovedRuntimes []string `json:"approved_runtimes,omitempty"`
+	Rego             []string `json:"rego,omitempty"`
+}
+
+// Stage is a named stage of the project (e.g. staging), which can be
+// deployed to a different account (or project) than the default stage
+
+type Stage struct {
+	// The aws cli profile, or a role that is assumed, to deploy with (AWS only)
+	Profile string `json:"aws_profile,omitempty"`
+	RoleArn string `json:"role_arn,omitempty"`
+	// The Google Cloud project to deploy to (GCP only)
+	ProjectID string `json:"project_id,omitempty"`
+	Region    string `json:"region,omitempty"`
+	// Environment variables that are only set in this stage
+	Environment map[string]string `json:"environment,omitempty"`
+	// Secrets that are only set (or are read from another path) in this stage
+	Secrets map[string]string `json:"secrets,omitempty"`
+	// Feature flags that are only set in this stage
+	Flags map[string]bool `json:"flags,omitempty"`
+}
+
+// Artifact is a deployment archive or container image, identified
+// by its digest, so that the same build can be promoted between stages
+
+type Artifact struct {
+	// A local file (for archives), or an image URI
+	Location string
+	Digest   string
+	// The version that the artifact is stored with in the artifact store
+	Version string
+}
+
+// ArtifactStore is where built artifacts are stored, with immutable versions:
+// archives are stored under a bucket path, and images in an OCI registry
+
+type ArtifactStore struct {
+	URI      string `json:"uri,omitempty"`
+	Registry string `json:"registry,omitempty"`
+}
+
+// Include is a path outside the project directory (e.g. a shared library in
+// a monorepo) that is copied into the project when it is packaged
+
+type Include struct {
+	Path string `json:"path"`
+	// Where the path is copied to in the project (its base name by default)
+	Target string `json:"target,omitempty"`
+}
+
+// TemplatePrompt is a value that is asked for when a project is created
+// from a template; its type is a string (the default), a bool, or a
+// select from a list of options
+
+type TemplatePrompt struct {
+	Prompt  string   `json:"prompt"`
+	Type    string   `json:"type"`
+	Key     string   `json:"key"`
+	Value   string   `json:"value"`
+	Style   string   `json:"format,omitempty"`
+	Default string   `json:"default,omitempty"`
+	Options []string `json:"options,omitempty"`
+	// A regular expression that string values must match
+	Validate string `json:"validate,omitempty"`
+	// Context for the prompt, which kettle create --tutorial explains
+	Help string `json:"help,omitempty"`
+}
+
+// TemplateFile is a file or directory of a template that is only created
+// when its condition (a template expression, e.g. {{.UseDocker}}) is true,
+// or that is rendered with its own profile (e.g. a Helm chart's templates)
+
+type TemplateFile struct {
+	Path    string `json:"path"`
+	When    string `json:"when,omitempty"`
+	Profile string `json:"profile,omitempty"`
+}
+
+// TemplateSource is the template that a project was created from (as it was
+// given to kettle create: a path, git repository, or name), and its version
+// (the commit of the template's repository), so that it can be upgraded
+
+type TemplateSource struct {
+	Template string `json:"template"`
+	Version  string `json:"version,omitempty"`
+}
+
+// TemplateMetadata describes a template for kettle search and kettle describe;
+// screenshots are URLs, or paths in the template, and the example output is
+// a path in the template (or text) that shows what the deployed template returns
+
+type TemplateMetadata struct {
+	Description   string   `json:"description,omitempty"`
+	Categories    []string `json:"categories,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+	Maintainer    string   `json:"maintainer,omitempty"`
+	Screenshots   []string `json:"screenshots,omitempty"`
+	ExampleOutput string   `json:"example_output,omitempty"`
+	// The components of the project that kettle create --tutorial explains, in order
+	Walkthrough []*WalkthroughStep `json:"walkthrough,omitempty"`
+}
+
+// WalkthroughStep explains a file or directory of a project that is created
+// from a template; its path can have template expressions, like the files
+
+type WalkthroughStep struct {
+	Path        string `json:"path"`
+	Title       string `json:"title"`
+	Explanation string `json:"explanation"`
 }
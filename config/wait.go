@@ -0,0 +1,18 @@
+package config
+
+import (
+	"time"
+)
+
+// DefaultWaitTimeout is how long kettle waits for a deployed function to be
+// ready (in seconds), e.g. while a new execution role propagates
+const DefaultWaitTimeout = 300
+
+// GetWaitTimeout returns how long to wait for the function
+// to be ready, before the deploy fails
+func (cfg *Config) GetWaitTimeout() time.Duration {
+	if cfg.Config.AWS.WaitTimeout > 0 {
+		return time.Duration(cfg.Config.AWS.WaitTimeout) * time.Second
+	}
+	return DefaultWaitTimeout * time.Second
+}
@@ -0,0 +1,198 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+const (
+	workspaceFileName = "kettle.workspace.json"
+)
+
+// Workspace is a directory with several projects (e.g. the functions of
+// an application), which are listed in its workspace config file
+
+type Workspace struct {
+	Functions []*WorkspaceFunction `json:"functions"`
+	// The add-ons that the functions declare, which other functions can depend on
+	AddOns []*WorkspaceAddOn `json:"-"`
+}
+
+// WorkspaceFunction is a project in a workspace, in a subdirectory
+// of the workspace that was created from a template
+
+type WorkspaceFunction struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	Template string `json:"template,omitempty"`
+	// The functions that are deployed before this one, whose outputs
+	// (e.g. URLs, and the connection details of their add-ons) it uses
+	DependsOn []string `json:"depends_on,omitempty"`
+}
+
+// WorkspaceAddOn is an add-on that a function of the workspace declares (and
+// provisions when it is deployed), which is shared with the functions that
+// depend on it
+
+type WorkspaceAddOn struct {
+	AddOn    *AddOn
+	Function string
+}
+
+// ReadWorkspace reads the workspace config in a directory; a directory
+// without one is an empty workspace
+func ReadWorkspace(directory string) (*Workspace, error) {
+	workspace := &Workspace{}
+	workspacePath := path.Join(directory, workspaceFileName)
+	exists, err := pathExists(workspacePath)
+	if err != nil || !exists {
+		return workspace, err
+	}
+
+	data, err := ioutil.ReadFile(workspacePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, workspace); err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", workspaceFileName, err)
+	}
+	return workspace, nil
+}
+
+func WriteWorkspace(directory string, workspace *Workspace) error {
+	data, err := json.MarshalIndent(workspace, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path.Join(directory, workspaceFileName), data, 0644)
+}
+
+func (w *Workspace) GetFunction(name string) *WorkspaceFunction {
+	for _, function := range w.Functions {
+		if function.Name == name {
+			return function
+		}
+	}
+	return nil
+}
+
+// GetAddOn returns the add-on with the name, that one of the functions declares
+func (w *Workspace) GetAddOn(name string) *WorkspaceAddOn {
+	for _, addOn := range w.AddOns {
+		if addOn.AddOn.Name == name {
+			return addOn
+		}
+	}
+	return nil
+}
+
+// ReadAddOns reads the add-ons that the functions declare in their configs, so
+// that functions can depend on them; an add-on is shared, so its name must
+// not be the name of a function, or of another function's add-on
+func (w *Workspace) ReadAddOns(directory string) error {
+	w.AddOns = []*WorkspaceAddOn{}
+	for _, function := range w.Functions {
+		cfg, err := ReadConfig(path.Join(directory, function.Path))
+		if err != nil {
+			return fmt.Errorf("failed to read the config of %s: %s", function.Name, err)
+		}
+		for _, addOn := range cfg.Config.AddOns {
+			if w.GetFunction(addOn.Name) != nil {
+				return fmt.Errorf("%s has an add-on with the name of a function: %s", function.Name, addOn.Name)
+			}
+			if existing := w.GetAddOn(addOn.Name); existing != nil {
+				return fmt.Errorf("%s and %s both have an add-on named: %s", existing.Function, function.Name, addOn.Name)
+			}
+			w.AddOns = append(w.AddOns, &WorkspaceAddOn{
+				AddOn:    addOn,
+				Function: function.Name,
+			})
+		}
+	}
+	return nil
+}
+
+// AddFunction registers a function in the workspace
+func (w *Workspace) AddFunction(name, functionPath, template string) error {
+	if w.GetFunction(name) != nil {
+		return fmt.Errorf("the workspace already has a function named: %s", name)
+	}
+	w.Functions = append(w.Functions, &WorkspaceFunction{
+		Name:     name,
+		Path:     functionPath,
+		Template: template,
+	})
+	return nil
+}
+
+// ValidateDependencies returns an error if a function depends on a function (or
+// an add-on) that is not in the workspace, or if the dependencies have a cycle
+func (w *Workspace) ValidateDependencies() error {
+	for _, function := range w.Functions {
+		for _, dependency := range function.DependsOn {
+			if w.GetFunction(dependency) == nil && w.GetAddOn(dependency) == nil {
+				return fmt.Errorf("%s depends on %s, which is not in the workspace", function.Name, dependency)
+			}
+			if addOn := w.GetAddOn(dependency); addOn != nil && addOn.Function == function.Name {
+				return fmt.Errorf("%s depends on its own add-on: %s", function.Name, dependency)
+			}
+		}
+	}
+	deployed := map[string]bool{}
+	for len(deployed) < len(w.Functions) {
+		ready := w.GetReadyFunctions(deployed, map[string]bool{})
+		if len(ready) == 0 {
+			waiting := []string{}
+			for _, function := range w.Functions {
+				if !deployed[function.Name] {
+					waiting = append(waiting, function.Name)
+				}
+			}
+			sort.Strings(waiting)
+			return fmt.Errorf("the dependencies of these functions have a cycle: %s", strings.Join(waiting, ", "))
+		}
+		for _, function := range ready {
+			deployed[function.Name] = true
+		}
+	}
+	return nil
+}
+
+// GetReadyFunctions returns the functions that have not been deployed (or started),
+// whose dependencies have all been deployed, in the order of the workspace; an
+// add-on has been deployed once the function that declares it has been
+func (w *Workspace) GetReadyFunctions(deployed, started map[string]bool) []*WorkspaceFunction {
+	ready := []*WorkspaceFunction{}
+	for _, function := range w.Functions {
+		if deployed[function.Name] || started[function.Name] {
+			continue
+		}
+		isReady := true
+		for _, dependency := range function.DependsOn {
+			isReady = isReady && w.isDeployed(dependency, deployed)
+		}
+		if isReady {
+			ready = append(ready, function)
+		}
+	}
+	return ready
+}
+
+func (w *Workspace) isDeployed(dependency string, deployed map[string]bool) bool {
+	if addOn := w.GetAddOn(dependency); addOn != nil {
+		return deployed[addOn.Function]
+	}
+	return deployed[dependency]
+}
+
+// OutputPrefix returns the prefix of the environment variables that pass the
+// function's outputs to the functions that depend on it, e.g. KETTLE_USERS_API
+func (function *WorkspaceFunction) OutputPrefix() string {
+	return fmt.Sprintf("KETTLE_%s", strcase.ToScreamingSnake(function.Name))
+}
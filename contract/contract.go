@@ -0,0 +1,181 @@
+// Package contract checks a project's requests and responses against the
+// JSON Schemas of its contract, before and after it is deployed
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/config"
+)
+
+// Contract is the request and response schemas of a project's endpoint
+
+type Contract struct {
+	// Either schema can be nil, if the project does not declare it
+	Request  *Schema
+	Response *Schema
+	// The paths of the handler's sample responses
+	Samples []string
+}
+
+// Test is a request to the deployed endpoint, and the response that it must return
+
+type Test struct {
+	Name           string
+	Method         string
+	Path           string
+	Body           string
+	ExpectedStatus int
+	// Checks the response's body against the response schema
+	Response *Schema
+}
+
+// Read reads the schemas of the project's contract, or returns
+// nil if the project does not have a contract
+func Read(projectPath string, cfg *config.Config) (*Contract, error) {
+	declared := cfg.Config.Contract
+	if declared == nil {
+		return nil, nil
+	}
+	c := &Contract{}
+	var err error
+	if declared.Request != "" {
+		if c.Request, err = ReadSchema(filepath.Join(projectPath, declared.Request)); err != nil {
+			return nil, err
+		}
+	}
+	if declared.Response != "" {
+		if c.Response, err = ReadSchema(filepath.Join(projectPath, declared.Response)); err != nil {
+			return nil, err
+		}
+	}
+	if declared.Samples != "" {
+		samples, err := filepath.Glob(filepath.Join(projectPath, declared.Samples, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(samples)
+		c.Samples = samples
+	}
+	return c, nil
+}
+
+// Check returns the violations of the contract in the project's smoke test
+// request, and in the handler's sample responses, which must match the schemas
+// before the project is deployed
+func (c *Contract) Check(cfg *config.Config) ([]string, error) {
+	violations := []string{}
+	if smokeTest := cfg.GetSmokeTest(); c.Request != nil && smokeTest.Body != "" {
+		found, err := c.Request.ValidateJSON([]byte(smokeTest.Body))
+		if err != nil {
+			return nil, fmt.Errorf("the smoke test body is %s", err)
+		}
+		for _, violation := range found {
+			violations = append(violations, "smoke test request "+violation)
+		}
+	}
+	if c.Response == nil {
+		return violations, nil
+	}
+	for _, sample := range c.Samples {
+		data, err := ioutil.ReadFile(sample)
+		if err != nil {
+			return nil, err
+		}
+		found, err := c.Response.ValidateJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("the sample response %s is %s", filepath.Base(sample), err)
+		}
+		for _, violation := range found {
+			violations = append(violations, fmt.Sprintf("sample response %s %s", filepath.Base(sample), violation))
+		}
+	}
+	return violations, nil
+}
+
+// Tests returns the contract tests of the project: its smoke test, whose
+// response must match the response schema, and (if the cloud validates
+// requests) a request without each of the required properties of the
+// request schema, which must be rejected
+func (c *Contract) Tests(cfg *config.Config) []*Test {
+	smokeTest := cfg.GetSmokeTest()
+	tests := []*Test{{
+		Name:           "smoke test",
+		Method:         smokeTest.Method,
+		Path:           smokeTest.Path,
+		Body:           smokeTest.Body,
+		ExpectedStatus: smokeTest.ExpectedStatus,
+		Response:       c.Response,
+	}}
+	if c.Request == nil || !cfg.ValidatesRequests() {
+		return tests
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(smokeTest.Body), &body); err != nil {
+		return tests
+	}
+	for _, name := range c.Request.Required {
+		if _, ok := body[name]; !ok {
+			continue
+		}
+		invalid := map[string]interface{}{}
+		for key, value := range body {
+			if key != name {
+				invalid[key] = value
+			}
+		}
+		data, err := json.Marshal(invalid)
+		if err != nil {
+			continue
+		}
+		tests = append(tests, &Test{
+			Name:           "request without " + name,
+			Method:         smokeTest.Method,
+			Path:           smokeTest.Path,
+			Body:           string(data),
+			ExpectedStatus: http.StatusBadRequest,
+		})
+	}
+	return tests
+}
+
+// Run sends the test's request to the endpoint, and returns an error if it
+// does not return the expected status, or its response violates the schema
+func (test *Test) Run(client *http.Client, url string) error {
+	request, err := http.NewRequest(test.Method, url+test.Path, strings.NewReader(test.Body))
+	if err != nil {
+		return err
+	}
+	if test.Body != "" {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("the %s failed: %s", test.Name, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != test.ExpectedStatus {
+		return fmt.Errorf("the %s failed: expected status %d, got %d", test.Name, test.ExpectedStatus, response.StatusCode)
+	}
+	if test.Response == nil {
+		return nil
+	}
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	violations, err := test.Response.ValidateJSON(data)
+	if err != nil {
+		return fmt.Errorf("the %s failed: the response is %s", test.Name, err)
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("the %s failed: the response does not match the contract: %s", test.Name, strings.Join(violations, "; "))
+	}
+	return nil
+}
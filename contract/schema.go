@@ -0,0 +1,195 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Schema is a JSON Schema; kettle validates the keywords that describe the
+// shape of JSON documents (types, properties, items, enums, and bounds), and
+// ignores the others (e.g. $ref and format)
+
+type Schema struct {
+	Type                 interface{}        `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+
+	// The schema's JSON, as it was read
+	raw []byte
+}
+
+// ReadSchema reads a JSON Schema from a file
+func ReadSchema(schemaPath string) (*Schema, error) {
+	data, err := ioutil.ReadFile(schemaPath)
+	if err != nil {
+		return nil, err
+	}
+	schema := &Schema{}
+	if err := json.Unmarshal(data, schema); err != nil {
+		return nil, fmt.Errorf("%s is not a JSON Schema: %s", schemaPath, err)
+	}
+	schema.raw = data
+	return schema, nil
+}
+
+// JSON returns the schema as it was read
+func (schema *Schema) JSON() []byte {
+	return schema.raw
+}
+
+// ValidateJSON returns the violations of the schema in a JSON document
+func (schema *Schema) ValidateJSON(data []byte) ([]string, error) {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("not JSON: %s", err)
+	}
+	return schema.Validate(value), nil
+}
+
+// Validate returns the violations of the schema in a value (decoded from
+// JSON), with the path of each, e.g. "$.features.rooms: expected integer"
+func (schema *Schema) Validate(value interface{}) []string {
+	violations := []string{}
+	schema.validate("$", value, &violations)
+	return violations
+}
+
+func (schema *Schema) validate(path string, value interface{}, violations *[]string) {
+	violation := func(format string, args ...interface{}) {
+		*violations = append(*violations, path+": "+fmt.Sprintf(format, args...))
+	}
+	if types := schema.types(); len(types) > 0 && !matchesType(value, types) {
+		violation("expected %s, got %s", strings.Join(types, " or "), typeOf(value))
+		return
+	}
+	if len(schema.Enum) > 0 && !isOneOf(value, schema.Enum) {
+		violation("%v is not one of the allowed values", value)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range schema.Required {
+			if _, ok := v[name]; !ok {
+				violation("%s is required", name)
+			}
+		}
+		names := []string{}
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property, ok := schema.Properties[name]
+			if !ok {
+				if schema.AdditionalProperties == false {
+					violation("%s is not allowed", name)
+				}
+				continue
+			}
+			property.validate(path+"."+name, v[name], violations)
+		}
+	case []interface{}:
+		if schema.MinItems != nil && len(v) < *schema.MinItems {
+			violation("expected at least %d items, got %d", *schema.MinItems, len(v))
+		}
+		if schema.MaxItems != nil && len(v) > *schema.MaxItems {
+			violation("expected at most %d items, got %d", *schema.MaxItems, len(v))
+		}
+		if schema.Items != nil {
+			for i, item := range v {
+				schema.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, violations)
+			}
+		}
+	case string:
+		if schema.MinLength != nil && len([]rune(v)) < *schema.MinLength {
+			violation("expected at least %d characters", *schema.MinLength)
+		}
+		if schema.MaxLength != nil && len([]rune(v)) > *schema.MaxLength {
+			violation("expected at most %d characters", *schema.MaxLength)
+		}
+		if schema.Pattern != "" {
+			if pattern, err := regexp.Compile(schema.Pattern); err == nil && !pattern.MatchString(v) {
+				violation("does not match %s", schema.Pattern)
+			}
+		}
+	case float64:
+		if schema.Minimum != nil && v < *schema.Minimum {
+			violation("expected at least %v, got %v", *schema.Minimum, v)
+		}
+		if schema.Maximum != nil && v > *schema.Maximum {
+			violation("expected at most %v, got %v", *schema.Maximum, v)
+		}
+	}
+}
+
+// types returns the schema's type, which can be a type or a list of types
+func (schema *Schema) types() []string {
+	switch t := schema.Type.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		types := []string{}
+		for _, name := range t {
+			if s, ok := name.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func matchesType(value interface{}, types []string) bool {
+	for _, t := range types {
+		if t == typeOf(value) || (t == "number" && typeOf(value) == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// typeOf returns the JSON Schema type of a value (decoded from JSON)
+func typeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func isOneOf(value interface{}, values []interface{}) bool {
+	encoded, _ := json.Marshal(value)
+	for _, v := range values {
+		if allowed, _ := json.Marshal(v); string(allowed) == string(encoded) {
+			return true
+		}
+	}
+	return false
+}
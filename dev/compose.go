@@ -0,0 +1,180 @@
+package dev
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+
+	"github.com/iancoleman/strcase"
+	"gopkg.in/yaml.v2"
+
+	"github.com/operatorai/kettle-cli/config"
+)
+
+const (
+	ComposeFileName = "docker-compose.dev.yaml"
+
+	// Credentials for the local containers, which are only reachable from localhost
+	localUsername  = "kettle"
+	localPassword  = "kettle"
+	localAccountID = "000000000000"
+	localProjectID = "kettle-dev"
+)
+
+type composeFile struct {
+	Services map[string]*composeService `yaml:"services"`
+}
+
+type composeService struct {
+	Image       string            `yaml:"image"`
+	Command     []string          `yaml:"command,omitempty"`
+	Ports       []string          `yaml:"ports,omitempty"`
+	Environment map[string]string `yaml:"environment,omitempty"`
+}
+
+// localService is a container that stands in for a cloud add-on
+// while a project is running locally
+type localService struct {
+	image       string
+	port        int
+	command     func(port int) []string
+	variables   func(addOn *config.AddOn) map[string]string
+	environment func(cfg *config.Config, addOn *config.AddOn, port int) map[string]string
+}
+
+var localServices = map[string]*localService{
+	"redis": {
+		image: "redis:7-alpine",
+		port:  6379,
+		environment: func(cfg *config.Config, addOn *config.AddOn, port int) map[string]string {
+			prefix := addOn.EnvironmentPrefix()
+			return map[string]string{
+				fmt.Sprintf("%s_HOST", prefix): "localhost",
+				fmt.Sprintf("%s_PORT", prefix): fmt.Sprintf("%d", port),
+				fmt.Sprintf("%s_URL", prefix):  fmt.Sprintf("redis://localhost:%d", port),
+			}
+		},
+	},
+	"dynamodb": {
+		image: "amazon/dynamodb-local",
+		port:  8000,
+		environment: func(cfg *config.Config, addOn *config.AddOn, port int) map[string]string {
+			return map[string]string{
+				fmt.Sprintf("%s_TABLE", addOn.EnvironmentPrefix()): resourceName(cfg, addOn),
+				"AWS_ENDPOINT_URL_DYNAMODB":                        fmt.Sprintf("http://localhost:%d", port),
+			}
+		},
+	},
+	"aurora": {
+		image: "postgres:16-alpine",
+		port:  5432,
+		variables: func(addOn *config.AddOn) map[string]string {
+			return map[string]string{
+				"POSTGRES_USER":     localUsername,
+				"POSTGRES_PASSWORD": localPassword,
+				"POSTGRES_DB":       strcase.ToSnake(addOn.Name),
+			}
+		},
+		environment: func(cfg *config.Config, addOn *config.AddOn, port int) map[string]string {
+			prefix := addOn.EnvironmentPrefix()
+			database := strcase.ToSnake(addOn.Name)
+			return map[string]string{
+				fmt.Sprintf("%s_DATABASE", prefix): database,
+				fmt.Sprintf("%s_URL", prefix): fmt.Sprintf("postgres://%s:%s@localhost:%d/%s",
+					localUsername,
+					localPassword,
+					port,
+					database,
+				),
+			}
+		},
+	},
+	"firestore": {
+		image: "gcr.io/google.com/cloudsdktool/google-cloud-cli:emulators",
+		port:  8080,
+		command: func(port int) []string {
+			return []string{"gcloud", "emulators", "firestore", "start", fmt.Sprintf("--host-port=0.0.0.0:%d", port)}
+		},
+		environment: func(cfg *config.Config, addOn *config.AddOn, port int) map[string]string {
+			return map[string]string{
+				fmt.Sprintf("%s_DATABASE", addOn.EnvironmentPrefix()): resourceName(cfg, addOn),
+				"FIRESTORE_EMULATOR_HOST":                             fmt.Sprintf("localhost:%d", port),
+				"GOOGLE_CLOUD_PROJECT":                                localProjectID,
+			}
+		},
+	},
+}
+
+// localQueue stands in for the project's SQS queue
+var localQueue = &localService{
+	image: "softwaremill/elasticmq-native",
+	port:  9324,
+}
+
+// WriteComposeFile writes a docker-compose file with a local container for each
+// of the project's add-ons (and its queue), and returns the environment variables
+// that point the project at those containers
+func WriteComposeFile(directory string, cfg *config.Config) (map[string]string, error) {
+	compose := &composeFile{
+		Services: map[string]*composeService{},
+	}
+	environment := map[string]string{}
+
+	// Add-ons of the same type are given consecutive ports
+	portOffsets := map[string]int{}
+	for _, addOn := range cfg.Config.AddOns {
+		local, ok := localServices[addOn.Type]
+		if !ok {
+			fmt.Println("⏭   No local container for add-on: ", addOn.Name, fmt.Sprintf("(%s)", addOn.Type))
+			continue
+		}
+		port := local.port + portOffsets[addOn.Type]
+		portOffsets[addOn.Type]++
+
+		compose.Services[addOn.Name] = local.service(addOn, port)
+		for key, value := range local.environment(cfg, addOn, port) {
+			environment[key] = value
+		}
+	}
+
+	if cfg.Config.Queue != nil {
+		queueName := cfg.Config.Queue.Name
+		if queueName == "" {
+			queueName = fmt.Sprintf("%s-queue", cfg.ProjectName)
+		}
+		compose.Services["queue"] = localQueue.service(nil, localQueue.port)
+		environment["AWS_ENDPOINT_URL_SQS"] = fmt.Sprintf("http://localhost:%d", localQueue.port)
+		environment["KETTLE_QUEUE_URL"] = fmt.Sprintf("http://localhost:%d/%s/%s", localQueue.port, localAccountID, queueName)
+	}
+	if len(compose.Services) == 0 {
+		return environment, nil
+	}
+
+	data, err := yaml.Marshal(compose)
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(path.Join(directory, ComposeFileName), data, 0644); err != nil {
+		return nil, err
+	}
+	return environment, nil
+}
+
+func (local *localService) service(addOn *config.AddOn, port int) *composeService {
+	service := &composeService{
+		Image: local.image,
+		Ports: []string{fmt.Sprintf("%d:%d", port, local.port)},
+	}
+	if local.variables != nil {
+		service.Environment = local.variables(addOn)
+	}
+	if local.command != nil {
+		service.Command = local.command(local.port)
+	}
+	return service
+}
+
+// resourceName matches the name of the add-on's cloud resource
+func resourceName(cfg *config.Config, addOn *config.AddOn) string {
+	return fmt.Sprintf("%s-%s", cfg.ProjectName, strcase.ToKebab(addOn.Name))
+}
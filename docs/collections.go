@@ -0,0 +1,151 @@
+package docs
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Formats of the collections of example requests
+const (
+	FormatPostman  = "postman"
+	FormatInsomnia = "insomnia"
+)
+
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+var nonIDCharacters = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// environmentVariable is a reference to an environment variable (or a command)
+// in an example request's header, which collections replace with their own variables
+var environmentVariable = regexp.MustCompile(`\$\(gcloud auth print-identity-token\)|\$([A-Z_]+)`)
+
+// Collection returns the requests as a Postman collection (v2.1) or an Insomnia
+// export (v4), to import into either app; credentials are collection variables
+func Collection(format, name string, requests []*Request) ([]byte, error) {
+	switch format {
+	case FormatPostman:
+		return json.MarshalIndent(postmanCollection(name, requests), "", "  ")
+	case FormatInsomnia:
+		return json.MarshalIndent(insomniaExport(name, requests), "", "  ")
+	}
+	return nil, fmt.Errorf("unknown collection format: %s (use %s or %s)", format, FormatPostman, FormatInsomnia)
+}
+
+func postmanCollection(name string, requests []*Request) map[string]interface{} {
+	items := []interface{}{}
+	for _, request := range requests {
+		headers := []interface{}{}
+		for _, header := range request.Headers {
+			headers = append(headers, map[string]string{
+				"key":   header.Name,
+				"value": collectionValue(header.Value, "{{%s}}"),
+			})
+		}
+		item := map[string]interface{}{
+			"method": request.Method,
+			"header": headers,
+			"url":    request.URL,
+		}
+		if request.Body != "" {
+			item["body"] = map[string]interface{}{
+				"mode": "raw",
+				"raw":  request.Body,
+				"options": map[string]interface{}{
+					"raw": map[string]string{"language": "json"},
+				},
+			}
+		}
+		if request.AWSRegion != "" {
+			item["auth"] = map[string]interface{}{
+				"type": "awsv4",
+				"awsv4": []map[string]string{
+					{"key": "accessKey", "value": "{{aws_access_key_id}}"},
+					{"key": "secretKey", "value": "{{aws_secret_access_key}}"},
+					{"key": "region", "value": request.AWSRegion},
+					{"key": "service", "value": "execute-api"},
+				},
+			}
+		}
+		items = append(items, map[string]interface{}{
+			"name":    request.Name,
+			"request": item,
+		})
+	}
+	return map[string]interface{}{
+		"info": map[string]string{
+			"name":   name,
+			"schema": postmanSchema,
+		},
+		"item": items,
+	}
+}
+
+func insomniaExport(name string, requests []*Request) map[string]interface{} {
+	workspaceID := "wrk_" + insomniaID(name)
+	resources := []interface{}{
+		map[string]string{
+			"_id":   workspaceID,
+			"_type": "workspace",
+			"name":  name,
+		},
+	}
+	for i, request := range requests {
+		headers := []interface{}{}
+		for _, header := range request.Headers {
+			headers = append(headers, map[string]string{
+				"name":  header.Name,
+				"value": collectionValue(header.Value, "{{ _.%s }}"),
+			})
+		}
+		resource := map[string]interface{}{
+			"_id":      fmt.Sprintf("req_%s_%d", insomniaID(name), i+1),
+			"_type":    "request",
+			"parentId": workspaceID,
+			"name":     request.Name,
+			"method":   request.Method,
+			"url":      request.URL,
+			"headers":  headers,
+		}
+		if request.Body != "" {
+			resource["body"] = map[string]string{
+				"mimeType": "application/json",
+				"text":     request.Body,
+			}
+		}
+		if request.AWSRegion != "" {
+			resource["authentication"] = map[string]string{
+				"type":            "iam",
+				"accessKeyId":     "{{ _.aws_access_key_id }}",
+				"secretAccessKey": "{{ _.aws_secret_access_key }}",
+				"region":          request.AWSRegion,
+				"service":         "execute-api",
+			}
+		}
+		resources = append(resources, resource)
+	}
+	return map[string]interface{}{
+		"_type":           "export",
+		"__export_format": 4,
+		"__export_source": "kettle",
+		"resources":       resources,
+	}
+}
+
+// collectionValue replaces the environment variables (and commands) in a
+// header's value with a collection variable, e.g. $API_KEY with {{api_key}}
+func collectionValue(value, variableFormat string) string {
+	return environmentVariable.ReplaceAllStringFunc(value, func(match string) string {
+		name := "identity_token"
+		if submatch := environmentVariable.FindStringSubmatch(match); submatch[1] != "" {
+			name = strings.ToLower(submatch[1])
+		}
+		return fmt.Sprintf(variableFormat, name)
+	})
+}
+
+// insomniaID replaces the characters of a name that cannot be in an Insomnia ID
+func insomniaID(name string) string {
+	return nonIDCharacters.ReplaceAllString(name, "_")
+}
@@ -0,0 +1,103 @@
+package docs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/config"
+)
+
+// Request is an example request to a deployed endpoint, from the project's
+// smoke test; its headers refer to credentials by environment variable
+
+type Request struct {
+	Name    string
+	Method  string
+	URL     string
+	Body    string
+	Headers []*Header
+	// The region of requests that are signed with AWS credentials (IAM auth)
+	AWSRegion string
+	// The status that the request returns
+	ExpectedStatus int
+}
+
+// Header is a header of an example request
+
+type Header struct {
+	Name  string
+	Value string
+}
+
+// ExampleRequest returns the example request to a project's endpoint, with
+// the credentials that its auth needs (e.g. an API key, or a Google identity token)
+func ExampleRequest(name, url, region string, cfg *config.Config) *Request {
+	smokeTest := cfg.GetSmokeTest()
+	request := &Request{
+		Name:           name,
+		Method:         smokeTest.Method,
+		URL:            url + smokeTest.Path,
+		Body:           smokeTest.Body,
+		ExpectedStatus: smokeTest.ExpectedStatus,
+	}
+	if request.Body != "" {
+		request.Headers = append(request.Headers, &Header{"Content-Type", "application/json"})
+	}
+	switch {
+	case cfg.Config.Auth == config.AuthAPIKey:
+		request.Headers = append(request.Headers, &Header{"x-api-key", "$API_KEY"})
+	case cfg.Config.Auth == config.AuthIAM && cfg.Config.CloudProvider == "aws":
+		request.AWSRegion = region
+	case cfg.Config.Auth == config.AuthIAM:
+		request.Headers = append(request.Headers, &Header{"Authorization", "Bearer $(gcloud auth print-identity-token)"})
+	}
+	return request
+}
+
+// Curl returns the request as a curl command
+func (request *Request) Curl() string {
+	lines := []string{fmt.Sprintf("curl -X %s %s", request.Method, quote(request.URL))}
+	if request.AWSRegion != "" {
+		lines = append(lines,
+			fmt.Sprintf("--aws-sigv4 'aws:amz:%s:execute-api'", request.AWSRegion),
+			`--user "$AWS_ACCESS_KEY_ID:$AWS_SECRET_ACCESS_KEY"`,
+		)
+	}
+	for _, header := range request.Headers {
+		lines = append(lines, "-H "+shellValue(header.Name+": "+header.Value))
+	}
+	if request.Body != "" {
+		lines = append(lines, "-d "+quote(request.Body))
+	}
+	return strings.Join(lines, " \\\n  ")
+}
+
+// HTTPie returns the request as an HTTPie command, or an empty
+// string if HTTPie cannot send it (e.g. it needs AWS signing)
+func (request *Request) HTTPie() string {
+	if request.AWSRegion != "" {
+		return ""
+	}
+	command := fmt.Sprintf("http %s %s", request.Method, quote(request.URL))
+	for _, header := range request.Headers {
+		command += " " + shellValue(header.Name+":"+header.Value)
+	}
+	if request.Body != "" {
+		command = fmt.Sprintf("echo %s | %s", quote(request.Body), command)
+	}
+	return command
+}
+
+// quote single-quotes a value for a shell
+func quote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// shellValue quotes a value for a shell, with double quotes if it refers to
+// environment variables (or commands), so that the shell expands them
+func shellValue(value string) string {
+	if strings.Contains(value, "$") {
+		return `"` + value + `"`
+	}
+	return quote(value)
+}
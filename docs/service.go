@@ -0,0 +1,202 @@
+// Package docs generates the documentation of deployed services from their
+// config and state, so that it stays up to date with each deploy
+package docs
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+// ServiceFileName is the file (in the project's directory) that documents the service
+const ServiceFileName = "SERVICE.md"
+
+// marker is the first line of generated docs; docs without it have
+// been written by hand, and are not replaced
+const marker = "<!-- Generated by kettle from the project's config and state: run kettle docs to update it. -->"
+
+// previousDeployments is how many of the previous deployments are listed for rollbacks
+const previousDeployments = 5
+
+// blankLines are the runs of blank lines that the template's optional sections leave
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+//go:embed service.md.tmpl
+var serviceTemplate embed.FS
+
+// serviceDocs are the values of the service's docs template
+type serviceDocs struct {
+	Marker         string
+	Name           string
+	Description    string
+	Cloud          string
+	DeploymentType string
+	Runtime        string
+	Region         string
+	Deployed       bool
+	LastDeployment *state.Deployment
+	URL            string
+	Auth           string
+	Example        *Request
+	Triggers       []string
+	Queue          string
+	Environment    []*variable
+	Outputs        []*variable
+	Previous       []*state.Deployment
+	Artifacts      bool
+}
+
+type variable struct {
+	Name   string
+	Value  string
+	Source string
+}
+
+// WriteServiceDocs writes the docs of the service in a project's directory
+// (its endpoint, an example request, its environment variables, its outputs,
+// and how to roll it back), replacing any docs that kettle generated before
+func WriteServiceDocs(projectPath string, cfg *config.Config, st *state.State) error {
+	data, err := ioutil.ReadFile(filepath.Join(projectPath, ServiceFileName))
+	if err == nil && !IsGenerated(data) {
+		return fmt.Errorf("%s was not generated by kettle, so it has not been replaced", ServiceFileName)
+	}
+	rendered, err := RenderServiceDocs(cfg, st)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(projectPath, ServiceFileName), rendered, 0644)
+}
+
+// UpdateServiceDocs rewrites the docs of the service, if kettle generated them
+func UpdateServiceDocs(projectPath string, cfg *config.Config, st *state.State) error {
+	data, err := ioutil.ReadFile(filepath.Join(projectPath, ServiceFileName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !IsGenerated(data) {
+		return nil
+	}
+	return WriteServiceDocs(projectPath, cfg, st)
+}
+
+// IsGenerated returns true if the docs were generated by kettle
+func IsGenerated(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(marker))
+}
+
+// RenderServiceDocs returns the docs of the service, as Markdown
+func RenderServiceDocs(cfg *config.Config, st *state.State) ([]byte, error) {
+	tmpl, err := template.ParseFS(serviceTemplate, "service.md.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, getServiceDocs(cfg, st)); err != nil {
+		return nil, err
+	}
+	return blankLines.ReplaceAll(b.Bytes(), []byte("\n\n")), nil
+}
+
+func getServiceDocs(cfg *config.Config, st *state.State) *serviceDocs {
+	d := &serviceDocs{
+		Marker:         marker,
+		Name:           cfg.ProjectName,
+		Cloud:          cfg.Config.CloudProvider,
+		DeploymentType: cfg.Config.DeploymentType,
+		Runtime:        cfg.Config.Runtime,
+		Region:         st.Region,
+		Deployed:       len(st.Deployments) > 0,
+		LastDeployment: st.GetLastDeployment(),
+		URL:            st.Outputs["url"],
+		Auth:           "public",
+		Artifacts:      cfg.Config.Artifacts != nil,
+	}
+	if cfg.Metadata != nil {
+		d.Description = cfg.Metadata.Description
+	}
+	if !cfg.IsPublic() {
+		d.Auth = "auth: " + cfg.Config.Auth
+	}
+	if d.URL != "" {
+		d.Example = ExampleRequest(cfg.ProjectName, d.URL, st.Region, cfg)
+	}
+
+	// Triggers
+	for _, trigger := range cfg.Config.Triggers {
+		switch {
+		case trigger.Schedule != "":
+			d.Triggers = append(d.Triggers, fmt.Sprintf("On a schedule: `%s`", trigger.Schedule))
+		case trigger.Bucket != "":
+			d.Triggers = append(d.Triggers, fmt.Sprintf("Objects in the bucket `%s%s` (%s)", trigger.Bucket, describeFilter(trigger), trigger.Type))
+		default:
+			d.Triggers = append(d.Triggers, trigger.Type)
+		}
+	}
+	if cfg.Config.Queue != nil {
+		d.Queue = st.Outputs["queue_url"]
+		if d.Queue == "" {
+			d.Queue = cfg.Config.Queue.Name
+		}
+	}
+
+	// Environment variables: the config's, its feature flags, and the connection details of add-ons
+	for name := range cfg.Config.Environment {
+		d.Environment = append(d.Environment, &variable{Name: name, Source: "kettle.json"})
+	}
+	if cfg.HasFlags() && cfg.GetFlagStore() == config.FlagStoreSSM {
+		d.Environment = append(d.Environment, &variable{Name: "KETTLE_FLAGS_PARAMETER", Source: "feature flags (`kettle flags`)"})
+	} else if cfg.HasFlags() {
+		d.Environment = append(d.Environment, &variable{Name: "KETTLE_FLAGS", Source: "feature flags (`kettle flags`)"})
+	}
+	for name := range st.Outputs {
+		if !isServiceOutput(name) {
+			d.Environment = append(d.Environment, &variable{Name: "KETTLE_" + strings.ToUpper(name), Source: "add-on"})
+		}
+	}
+	sort.Slice(d.Environment, func(i, j int) bool {
+		return d.Environment[i].Name < d.Environment[j].Name
+	})
+
+	for name, value := range st.Outputs {
+		d.Outputs = append(d.Outputs, &variable{Name: name, Value: value})
+	}
+	sort.Slice(d.Outputs, func(i, j int) bool {
+		return d.Outputs[i].Name < d.Outputs[j].Name
+	})
+
+	// The deployments before the last one, newest first
+	for i := len(st.Deployments) - 2; i >= 0 && len(d.Previous) < previousDeployments; i-- {
+		d.Previous = append(d.Previous, st.Deployments[i])
+	}
+	return d
+}
+
+// isServiceOutput returns true for the outputs of the service itself (the
+// others are the connection details of its add-ons)
+func isServiceOutput(name string) bool {
+	switch name {
+	case "name", "url", "arn", "queue_url", "queue_arn":
+		return true
+	}
+	return false
+}
+
+func describeFilter(trigger *config.Trigger) string {
+	if trigger.Prefix == "" && trigger.Suffix == "" {
+		return ""
+	}
+	return "/" + trigger.Prefix + "*" + trigger.Suffix
+}
@@ -0,0 +1,224 @@
+// Package graph describes the resources that kettle manages for a project, and
+// how they are related, so that they can be drawn (e.g. with Graphviz or Mermaid)
+package graph
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+// Node is a resource of the project
+
+type Node struct {
+	ID   string
+	Kind string
+	Name string
+	// Planned resources are in the config, but have not been deployed
+	Planned bool
+	// The resource in the project's state (nil for planned resources)
+	Resource *state.Resource
+}
+
+// Edge is a relationship between two resources, e.g. a queue that invokes a function
+
+type Edge struct {
+	From  *Node
+	To    *Node
+	Label string
+}
+
+// Graph is the resources of a project, and their relationships
+
+type Graph struct {
+	Name  string
+	Nodes []*Node
+	Edges []*Edge
+}
+
+// relation is a relationship from one type of resource to another
+type relation struct {
+	to    string
+	label string
+}
+
+// relations are the relationships between the types of resources that kettle
+// tracks; resources are only related if they are in the same group (or either
+// of them has no group)
+var relations = map[string][]relation{
+	state.AWSLambdaFunction: {
+		{state.AWSIAMRole, "runs as"},
+		{state.AWSECRRepository, "image"},
+		{state.AWSDynamoDBTable, "uses"},
+		{state.AWSAuroraCluster, "uses"},
+		{state.AWSElastiCache, "uses"},
+	},
+	state.AWSIAMRolePolicy:      {{state.AWSIAMRole, "attached to"}},
+	state.AWSRestApi:            {{state.AWSLambdaFunction, "invokes"}, {state.AWSSageMakerEndpoint, "invokes"}},
+	state.AWSRestApiResource:    {{state.AWSRestApi, "route of"}},
+	state.AWSLambdaPermission:   {{state.AWSLambdaFunction, "allows invoking"}},
+	state.AWSLambdaAlias:        {{state.AWSLambdaFunction, "points to"}},
+	state.AWSApiDomain:          {{state.AWSApiBasePathMapping, "maps"}},
+	state.AWSApiBasePathMapping: {{state.AWSRestApi, "routes to"}},
+	state.AWSEventsRule:         {{state.AWSLambdaFunction, "triggers"}},
+	state.AWSS3Notification:     {{state.AWSLambdaFunction, "triggers"}},
+	state.AWSSQSQueue:           {{state.AWSEventSourceMapping, "feeds"}},
+	state.AWSEventSourceMapping: {{state.AWSLambdaFunction, "invokes"}},
+	state.AWSSageMakerEndpoint:  {{state.AWSSageMakerConfig, "configured by"}},
+	state.AWSSageMakerConfig:    {{state.AWSSageMakerModel, "serves"}},
+	state.AWSSageMakerModel:     {{state.AWSECRRepository, "image"}, {state.AWSIAMRole, "runs as"}},
+	state.AWSCloudFront:         {{state.AWSS3Bucket, "serves"}, {state.AWSCloudFrontOAC, "uses"}},
+	state.AWSAuroraInstance:     {{state.AWSAuroraCluster, "part of"}},
+	state.AWSAuroraCluster:      {{state.AWSSecurityGroup, "secured by"}},
+	state.AWSElastiCache:        {{state.AWSSecurityGroup, "secured by"}},
+	state.AWSSyntheticsCanary:   {{state.AWSLambdaFunction, "monitors"}},
+	state.AWSCloudWatchAlarm:    {{state.AWSSyntheticsCanary, "watches"}, {state.AWSSNSTopic, "notifies"}},
+	state.AWSLogMetricFilter:    {{state.AWSLambdaFunction, "reads logs of"}},
+	state.AWSBudget:             {{state.AWSSNSTopic, "notifies"}},
+	state.AWSSSMParameter:       {{state.AWSLambdaFunction, "configures"}},
+	state.AWSSecret:             {{state.AWSLambdaFunction, "configures"}},
+	state.GoogleCloudFunction: {
+		{state.GoogleFirestore, "uses"},
+		{state.GoogleRedis, "uses"},
+	},
+	state.GoogleCloudRunService: {
+		{state.GoogleFirestore, "uses"},
+		{state.GoogleRedis, "uses"},
+	},
+	state.GoogleForwardingRule: {{state.GoogleHTTPProxy, "routes to"}},
+	state.GoogleHTTPProxy:      {{state.GoogleURLMap, "routes to"}},
+	state.GoogleURLMap:         {{state.GoogleBackendBucket, "routes to"}},
+	state.GoogleBackendBucket:  {{state.GoogleStorageBucket, "serves"}},
+	state.GoogleUptimeCheck:    {{state.GoogleCloudFunction, "monitors"}, {state.GoogleCloudRunService, "monitors"}},
+	state.GoogleAlertPolicy:    {{state.GoogleUptimeCheck, "watches"}, {state.GoogleNotification, "notifies"}},
+	state.GoogleSecret:         {{state.GoogleCloudFunction, "configures"}, {state.GoogleCloudRunService, "configures"}, {state.GoogleCloudRunJob, "configures"}},
+}
+
+// FromState returns the graph of the resources in a project's state
+func FromState(name string, st *state.State) *Graph {
+	g := &Graph{Name: name}
+	for _, resource := range st.Resources {
+		kind := resource.Type
+		if i := strings.Index(kind, ":"); i >= 0 {
+			kind = kind[i+1:]
+		}
+		node := g.addNode(kind, resource.ID, false)
+		node.Resource = resource
+	}
+	for _, from := range g.Nodes {
+		for _, rel := range relations[from.Resource.Type] {
+			for _, to := range g.Nodes {
+				if to.Resource.Type == rel.to && sameGroup(from.Resource, to.Resource) {
+					g.addEdge(from, to, rel.label)
+				}
+			}
+		}
+	}
+	return g
+}
+
+// FromConfig returns the graph of the resources that the project's config
+// declares (e.g. its triggers and add-ons), before it has been deployed
+func FromConfig(cfg *config.Config) *Graph {
+	g := &Graph{Name: cfg.ProjectName}
+	service := g.addNode(cfg.Config.DeploymentType, cfg.ProjectName, true)
+	if cfg.Config.Api != nil || cfg.Config.CustomDomain != nil {
+		api := g.addNode("api", cfg.ProjectName, true)
+		g.addEdge(api, service, "invokes")
+		if cfg.Config.CustomDomain != nil {
+			g.addEdge(g.addNode("domain", cfg.Config.CustomDomain.Name, true), api, "routes to")
+		}
+	}
+	for _, trigger := range cfg.Config.Triggers {
+		name := trigger.Schedule
+		if trigger.Bucket != "" {
+			name = trigger.Bucket
+		}
+		g.addEdge(g.addNode(trigger.Type+" trigger", name, true), service, "triggers")
+	}
+	if cfg.Config.Queue != nil {
+		name := cfg.Config.Queue.Name
+		if cfg.Config.Queue.Existing != "" {
+			name = cfg.Config.Queue.Existing
+		}
+		g.addEdge(g.addNode("queue", name, true), service, "invokes")
+	}
+	for _, addOn := range cfg.Config.AddOns {
+		g.addEdge(service, g.addNode(addOn.Type, addOn.Name, true), "uses")
+	}
+	if cfg.Config.Static != nil {
+		g.addNode("static site", cfg.Config.Static.Directory, true)
+	}
+	if cfg.Config.Canary != nil {
+		g.addEdge(g.addNode("canary", cfg.ProjectName, true), service, "monitors")
+	}
+	if cfg.Config.Budget != nil {
+		g.addNode("budget", fmt.Sprintf("%.2f %s", cfg.Config.Budget.Amount, cfg.Config.Budget.Currency), true)
+	}
+	return g
+}
+
+// DOT returns the graph in Graphviz's DOT language; planned
+// resources are drawn with dashed lines
+func (g *Graph) DOT() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %q {\n", g.Name)
+	b.WriteString("  rankdir=LR;\n  node [shape=box];\n")
+	for _, node := range g.Nodes {
+		style := ""
+		if node.Planned {
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(&b, "  %s [label=%q%s];\n", node.ID, node.Kind+"\n"+node.Name, style)
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %s -> %s [label=%q];\n", edge.From.ID, edge.To.ID, edge.Label)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid returns the graph as a Mermaid flowchart (e.g. for a README);
+// planned resources are drawn with dashed lines
+func (g *Graph) Mermaid() string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for _, node := range g.Nodes {
+		fmt.Fprintf(&b, "  %s[\"%s<br/>%s\"]\n", node.ID, mermaidText(node.Kind), mermaidText(node.Name))
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %s -->|%s| %s\n", edge.From.ID, mermaidText(edge.Label), edge.To.ID)
+	}
+	for _, node := range g.Nodes {
+		if node.Planned {
+			fmt.Fprintf(&b, "  style %s stroke-dasharray: 5 5\n", node.ID)
+		}
+	}
+	return b.String()
+}
+
+func (g *Graph) addNode(kind, name string, planned bool) *Node {
+	node := &Node{
+		ID:      fmt.Sprintf("n%d", len(g.Nodes)),
+		Kind:    kind,
+		Name:    name,
+		Planned: planned,
+	}
+	g.Nodes = append(g.Nodes, node)
+	return node
+}
+
+func (g *Graph) addEdge(from, to *Node, label string) {
+	g.Edges = append(g.Edges, &Edge{From: from, To: to, Label: label})
+}
+
+func sameGroup(from, to *state.Resource) bool {
+	return from.Group == "" || to.Group == "" || from.Group == to.Group
+}
+
+// mermaidText escapes the characters that end a Mermaid label
+func mermaidText(text string) string {
+	return strings.NewReplacer(`"`, "#quot;", "|", "#124;").Replace(text)
+}
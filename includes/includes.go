@@ -0,0 +1,135 @@
+package includes
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/config"
+)
+
+const goModFileName = "go.mod"
+
+// Vendor copies the project's include paths (e.g. a monorepo's shared
+// libraries) into the project directory, so that they are part of the
+// package, and fixes up go.mod replace directives that point at them.
+// It returns a function that removes the copies and restores go.mod
+func Vendor(directory string, cfg *config.Config) (func(), error) {
+	if len(cfg.Config.Include) == 0 {
+		return func() {}, nil
+	}
+
+	copied := []string{}
+	restore := func() {
+		for _, target := range copied {
+			os.RemoveAll(target)
+		}
+	}
+	for _, include := range cfg.Config.Include {
+		source := filepath.Join(directory, include.Path)
+		target := filepath.Join(directory, include.GetTarget())
+		if _, err := os.Stat(target); err == nil {
+			restore()
+			return nil, fmt.Errorf("cannot include %s: %s already exists in the project", include.Path, include.GetTarget())
+		}
+		fmt.Println("📎  Including: ", include.Path, fmt.Sprintf("(as %s)", include.GetTarget()))
+		if err := copyDirectory(source, target); err != nil {
+			os.RemoveAll(target)
+			restore()
+			return nil, err
+		}
+		copied = append(copied, target)
+	}
+
+	restoreGoMod, err := fixGoMod(directory, cfg.Config.Include)
+	if err != nil {
+		restore()
+		return nil, err
+	}
+	return func() {
+		restore()
+		restoreGoMod()
+	}, nil
+}
+
+// fixGoMod points go.mod replace directives at the vendored copies of the
+// include paths, since the originals are not part of the package (e.g. when
+// it is built by Cloud Build or in a container)
+func fixGoMod(directory string, includes []*config.Include) (func(), error) {
+	goMod := filepath.Join(directory, goModFileName)
+	original, err := ioutil.ReadFile(goMod)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return func() {}, nil
+		}
+		return nil, err
+	}
+
+	lines := strings.Split(string(original), "\n")
+	changed := false
+	for i, line := range lines {
+		parts := strings.SplitN(line, "=>", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		fields := strings.Fields(parts[1])
+		if len(fields) == 0 || !strings.HasPrefix(fields[0], ".") {
+			continue
+		}
+		replacement := filepath.Join(directory, fields[0])
+		for _, include := range includes {
+			rel, err := filepath.Rel(filepath.Join(directory, include.Path), replacement)
+			if err != nil || strings.HasPrefix(rel, "..") {
+				continue
+			}
+			vendored := "./" + filepath.ToSlash(filepath.Join(include.GetTarget(), rel))
+			lines[i] = parts[0] + "=> " + strings.Replace(strings.TrimSpace(parts[1]), fields[0], vendored, 1)
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return func() {}, nil
+	}
+	if err := ioutil.WriteFile(goMod, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		return nil, err
+	}
+	return func() {
+		ioutil.WriteFile(goMod, original, 0644)
+	}, nil
+}
+
+func copyDirectory(source, target string) error {
+	return filepath.Walk(source, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(source, filePath)
+		if err != nil {
+			return err
+		}
+		targetPath := filepath.Join(target, rel)
+		if info.IsDir() {
+			return os.MkdirAll(targetPath, info.Mode())
+		}
+		return copyFile(filePath, targetPath, info.Mode())
+	})
+}
+
+func copyFile(source, target string, mode os.FileMode) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, in)
+	return err
+}
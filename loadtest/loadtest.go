@@ -0,0 +1,93 @@
+package loadtest
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Outcome is the result of a single request
+type Outcome int
+
+const (
+	Succeeded Outcome = iota
+	Failed
+	Throttled
+)
+
+// Invoke sends one request to the target
+type Invoke func() Outcome
+
+// Report summarises the requests that were sent during a load test
+type Report struct {
+	Requests   int
+	Errors     int
+	Throttles  int
+	ColdStarts int
+	Start      time.Time
+	End        time.Time
+	latencies  []time.Duration
+}
+
+// Run sends requests at a constant rate for the given duration; requests are
+// sent on schedule even if earlier requests have not completed
+func Run(rate int, duration time.Duration, invoke Invoke) *Report {
+	report := &Report{
+		Start: time.Now(),
+	}
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+
+	ticker := time.NewTicker(time.Second / time.Duration(rate))
+	defer ticker.Stop()
+	deadline := time.After(duration)
+	for {
+		select {
+		case <-deadline:
+			wg.Wait()
+			report.End = time.Now()
+			sort.Slice(report.latencies, func(i, j int) bool {
+				return report.latencies[i] < report.latencies[j]
+			})
+			return report
+		case <-ticker.C:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				start := time.Now()
+				outcome := invoke()
+				latency := time.Since(start)
+
+				mutex.Lock()
+				defer mutex.Unlock()
+				report.Requests++
+				switch outcome {
+				case Failed:
+					report.Errors++
+				case Throttled:
+					report.Throttles++
+				default:
+					report.latencies = append(report.latencies, latency)
+				}
+			}()
+		}
+	}
+}
+
+// Percentile returns the latency of successful requests at
+// the given percentile (0-100)
+func (r *Report) Percentile(p float64) time.Duration {
+	if len(r.latencies) == 0 {
+		return 0
+	}
+	index := int(p / 100 * float64(len(r.latencies)-1))
+	return r.latencies[index]
+}
+
+// ErrorRate returns the percentage of requests that failed
+func (r *Report) ErrorRate() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.Errors) / float64(r.Requests) * 100
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+)
+
+// Sync uploads the project's model artifact (if it has a local source) and,
+// for models that are loaded from the deployment package, downloads it
+// into the project directory
+func Sync(directory string, cfg *config.Config) error {
+	model := cfg.Config.Model
+	if model == nil {
+		return nil
+	}
+	if model.URI == "" {
+		return errors.New("model uri not set")
+	}
+
+	fmt.Println("🧠  Model: ", model.Name, fmt.Sprintf("(%s)", model.Version))
+	if model.Source != "" {
+		source := path.Join(directory, model.Source)
+		if err := copyArtifact(source, model.URI, "Uploading model artifact"); err != nil {
+			return err
+		}
+	}
+
+	switch model.Load {
+	case "", "package":
+		if model.Path == "" {
+			return errors.New("model path not set")
+		}
+		if model.Source == model.Path {
+			// The model is already in the project directory
+			return nil
+		}
+		target := path.Join(directory, model.Path)
+		if err := os.MkdirAll(path.Dir(target), os.ModePerm); err != nil {
+			return err
+		}
+		return copyArtifact(model.URI, target, "Downloading model artifact")
+	case "startup":
+		// The function downloads the model from $KETTLE_MODEL_URI
+		return nil
+	}
+	return fmt.Errorf("unknown model load type: %s (expected package or startup)", model.Load)
+}
+
+func copyArtifact(source, destination, statusMessage string) error {
+	uri := source
+	if isRemote(destination) {
+		uri = destination
+	}
+	switch {
+	case strings.HasPrefix(uri, "s3://"):
+		return cli.Execute("aws", []string{
+			"s3",
+			"cp",
+			source,
+			destination,
+		}, statusMessage)
+	case strings.HasPrefix(uri, "gs://"):
+		return cli.Execute("gcloud", []string{
+			"storage",
+			"cp",
+			source,
+			destination,
+		}, statusMessage)
+	}
+	return fmt.Errorf("unsupported model uri: %s (expected s3:// or gs://)", uri)
+}
+
+func isRemote(uri string) bool {
+	return strings.HasPrefix(uri, "s3://") || strings.HasPrefix(uri, "gs://")
+}
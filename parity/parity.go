@@ -0,0 +1,124 @@
+// Package parity runs a project's tests in the container image of its Lambda
+// runtime (public.ecr.aws/lambda/*), for the architecture that it is deployed
+// to, so that differences from the local environment are caught before a deploy
+package parity
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+)
+
+const imageRepository = "public.ecr.aws/lambda"
+
+// The project is mounted read-only, and copied to a directory in the
+// container, so that installing its dependencies does not change it
+const (
+	mountPath = "/var/kettle/project"
+	workPath  = "/tmp/project"
+)
+
+// runtimePattern splits a Lambda runtime (e.g. python3.12, nodejs20.x,
+// or provided.al2023) into its language and version
+var runtimePattern = regexp.MustCompile(`^([a-z]+)(.*)$`)
+
+// setupCommands install the project's dependencies in the container, by
+// runtime; node_modules from the local machine may have native modules
+// that are built for it, so they are installed again
+var setupCommands = map[string]string{
+	"python": "if [ -f requirements.txt ]; then pip install --quiet -r requirements.txt; fi",
+	"nodejs": "rm -rf node_modules; if [ -f package-lock.json ]; then npm ci --silent; elif [ -f package.json ]; then npm install --silent; fi",
+}
+
+// Image returns the container image to test the project in: the base
+// image of its runtime, unless the config sets one
+func Image(cfg *config.Config) (string, error) {
+	if image := cfg.GetParity().Image; image != "" {
+		return image, nil
+	}
+	match := runtimePattern.FindStringSubmatch(cfg.Config.Runtime)
+	if match == nil {
+		return "", fmt.Errorf("there is no Lambda base image for the %s runtime (add \"parity\": {\"image\": \"...\"} to kettle.json)", cfg.Config.Runtime)
+	}
+	language := match[1]
+	version := strings.TrimSuffix(strings.TrimPrefix(match[2], "."), ".x")
+	if version == "" {
+		if language != "provided" {
+			return "", fmt.Errorf("there is no Lambda base image for the %s runtime (add \"parity\": {\"image\": \"...\"} to kettle.json)", cfg.Config.Runtime)
+		}
+		// The first custom runtime is on Amazon Linux 1
+		version = "alami"
+	}
+	return fmt.Sprintf("%s/%s:%s", imageRepository, language, version), nil
+}
+
+// Platform returns docker's platform for the project's architecture; arm64
+// images run with emulation on x86 machines (and the other way around)
+func Platform(cfg *config.Config) string {
+	if cfg.GetArchitecture() == config.ArchitectureARM64 {
+		return "linux/arm64"
+	}
+	return "linux/amd64"
+}
+
+// Run runs the project's tests (in the current directory) in the runtime's
+// image, with their output streamed, and returns an error if they fail
+func Run(cfg *config.Config) error {
+	tests := cfg.ParityTests()
+	if len(tests) == 0 {
+		return errors.New("there are no tests to run in the runtime's image (add \"parity\": {\"test\": [...]} to kettle.json)")
+	}
+	image, err := Image(cfg)
+	if err != nil {
+		return err
+	}
+	directory, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	platform := Platform(cfg)
+	err = cli.Execute("docker", []string{
+		"pull",
+		"--platform", platform,
+		image,
+	}, "Pulling "+image)
+	if err != nil {
+		return err
+	}
+
+	script := []string{
+		"set -e",
+		fmt.Sprintf("cp -r %s %s", mountPath, workPath),
+		"cd " + workPath,
+	}
+	for prefix, command := range setupCommands {
+		if strings.HasPrefix(cfg.Config.Runtime, prefix) {
+			script = append(script, command)
+		}
+	}
+	script = append(script, tests...)
+
+	fmt.Println("🧪  Testing in: ", image, fmt.Sprintf("(%s)", platform))
+	exitCode, err := cli.ExecuteInteractively("docker", []string{
+		"run",
+		"--rm",
+		"--platform", platform,
+		"--volume", fmt.Sprintf("%s:%s:ro", directory, mountPath),
+		"--entrypoint", "/bin/sh",
+		image,
+		"-c", strings.Join(script, "\n"),
+	}, nil)
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("the tests failed in %s (exit code %d), so the project was not deployed", image, exitCode)
+	}
+	fmt.Println("✅  Tests passed in the runtime's image")
+	return nil
+}
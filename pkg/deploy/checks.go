@@ -0,0 +1,221 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/clouds"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/contract"
+	"github.com/operatorai/kettle-cli/parity"
+	"github.com/operatorai/kettle-cli/policy"
+	"github.com/operatorai/kettle-cli/scan"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/upgrade"
+)
+
+// checkPolicy evaluates the project's compliance policy, and blocks
+// the deploy if any of its rules are violated
+func (p *Project) checkPolicy() error {
+	if policy.RuleCount(p.Config) == 0 {
+		return nil
+	}
+	violations, err := policy.Check(p.Path, p.Config)
+	if err != nil {
+		return err
+	}
+	if len(violations) == 0 {
+		p.emit(EventInfo, "policy", fmt.Sprintf("Policy: %d rule(s) passed", policy.RuleCount(p.Config)))
+		return nil
+	}
+	for _, violation := range violations {
+		p.emit(EventViolation, "policy", fmt.Sprintf("[%s] %s", violation.Rule, violation.Message))
+	}
+	return fmt.Errorf("the deployment violates %d policy rule(s)", len(violations))
+}
+
+// checkContract blocks the deploy if the project's smoke test request, or the
+// handler's sample responses, do not match the schemas of its contract
+func (p *Project) checkContract() error {
+	c, err := contract.Read(p.Path, p.Config)
+	if err != nil || c == nil {
+		return err
+	}
+	violations, err := c.Check(p.Config)
+	if err != nil {
+		return err
+	}
+	if len(violations) == 0 {
+		p.emit(EventInfo, "contract", fmt.Sprintf("Contract: the smoke test and %d sample response(s) match", len(c.Samples)))
+		return nil
+	}
+	for _, violation := range violations {
+		p.emit(EventViolation, "contract", violation)
+	}
+	return fmt.Errorf("the project violates its contract in %d place(s)", len(violations))
+}
+
+// startTemplateNotices looks up the changes to the project's template, since the
+// version that it was created from, that are flagged as security or deploy related;
+// they are looked up in the background, so that the deploy does not wait for the
+// template to be fetched, and not at all in air-gapped or non-interactive mode
+func (p *Project) startTemplateNotices() {
+	p.templateNotices = nil
+	if settings.AirGapped || cli.NonInteractive || p.Config.Source == nil {
+		return
+	}
+	// The lookup reads a copy of the config, which the deploy changes
+	cfg := *p.Config
+	notices := make(chan []*config.TemplateChange, 1)
+	p.templateNotices = notices
+	go func() {
+		result, err := upgrade.GetNotices(context.Background(), &cfg)
+		if err != nil && settings.DebugMode {
+			fmt.Println(err.Error())
+		}
+		notices <- result
+	}()
+}
+
+// checkTemplateNotices warns about the template's changes that were looked up
+// by startTemplateNotices, once they have been (which takes at most as long as
+// upgrade.NoticesTimeout); it never blocks the deploy, even if it cannot check
+func (p *Project) checkTemplateNotices() {
+	if p.templateNotices == nil {
+		return
+	}
+	notices := <-p.templateNotices
+	p.templateNotices = nil
+	if len(notices) == 0 {
+		return
+	}
+	for _, notice := range notices {
+		p.emit(EventWarning, "template", "Template update: "+notice.String())
+	}
+	p.emit(EventWarning, "template", fmt.Sprintf("Upgrade the project to the latest version of its template with: kettle update %s", p.Path))
+}
+
+// checkQuotas warns about the account quotas that the deploy is likely to
+// hit (e.g. its concurrency), which can be requested to be increased before
+// it is run; it never blocks the deploy, even if it cannot check
+func (p *Project) checkQuotas() {
+	p.quotas = nil
+	checker, ok := p.Service.(clouds.QuotaChecker)
+	if !ok {
+		return
+	}
+	quotas, err := checker.CheckQuotas(p.Config, p.Settings)
+	if err != nil {
+		if settings.DebugMode {
+			fmt.Println(err.Error())
+		}
+		return
+	}
+	requestable := false
+	for _, quota := range quotas {
+		if !quota.IsNearLimit() {
+			continue
+		}
+		p.quotas = append(p.quotas, quota)
+		requestable = requestable || quota.IsRequestable()
+		if quota.WouldExceed() {
+			p.emit(EventWarning, "quotas", "Quota exceeded: "+quota.String())
+			continue
+		}
+		p.emit(EventWarning, "quotas", "Quota near its limit: "+quota.String())
+	}
+	if requestable {
+		p.emit(EventWarning, "quotas", "Request increases of the quotas with: kettle deploy --request-quota-increases")
+	}
+}
+
+// checkParity blocks the deploy if the project's tests fail in the
+// container image of its Lambda runtime
+func (p *Project) checkParity() error {
+	if err := p.Config.ValidateParity(); err != nil {
+		return err
+	}
+	return parity.Run(p.Config)
+}
+
+// checkLeaks blocks the deploy if the files that are packaged (in the current
+// directory) have known credentials or random-looking secrets
+func (p *Project) checkLeaks(allowSecrets bool) error {
+	if p.Config.Config.SecretScan != nil && p.Config.Config.SecretScan.Disabled {
+		return nil
+	}
+	leaks, err := policy.FindLeaks(".", p.Config)
+	if err != nil {
+		return err
+	}
+	if len(leaks) == 0 {
+		return nil
+	}
+	for _, leak := range leaks {
+		p.emit(EventViolation, "secrets", policy.FormatLeak(leak))
+	}
+	if allowSecrets {
+		p.emit(EventWarning, "secrets", "Deploying anyway (--allow-secrets)")
+		return nil
+	}
+	return fmt.Errorf("found %d possible secret(s) in the package (move them to a secret store, add a %s comment to lines that are intentional, or deploy with --allow-secrets)", len(leaks), policy.AllowSecretComment)
+}
+
+// Scan builds the project's artifact (unless it is already set, which is
+// then deployed as it is), scans it, and returns an error if any vulnerabilities
+// block the deploy
+func (p *Project) Scan(builder clouds.Builder) error {
+	if p.Config.Artifact == nil {
+		artifact, err := builder.Build(p.Config, p.Settings)
+		if err != nil {
+			return err
+		}
+		p.Config.Artifact = artifact
+	}
+
+	var vulnerabilities []*config.Vulnerability
+	var err error
+	if p.Config.GetScanner() == config.ScannerECR {
+		scanner, ok := p.Service.(clouds.ImageScanner)
+		if !ok {
+			return fmt.Errorf("ecr scans are not supported on %s %s deployments",
+				p.Config.Config.CloudProvider,
+				p.Config.Config.DeploymentType,
+			)
+		}
+		vulnerabilities, err = scanner.ScanImage(p.Config, p.Settings, p.Config.Artifact)
+	} else {
+		vulnerabilities, err = scan.Scan(p.Config.Artifact, builder.GetArtifactKind(), p.Config.GetScanner())
+	}
+	if err != nil {
+		return err
+	}
+
+	blocking := 0
+	for _, vulnerability := range vulnerabilities {
+		if !p.Config.BlocksDeploy(vulnerability) {
+			continue
+		}
+		blocking++
+		fixedIn := ""
+		if vulnerability.FixedIn != "" {
+			fixedIn = fmt.Sprintf(" (fixed in %s)", vulnerability.FixedIn)
+		}
+		p.emit(EventViolation, "scan", fmt.Sprintf("[%s] %s: %s %s%s",
+			vulnerability.Severity,
+			vulnerability.ID,
+			vulnerability.Package,
+			vulnerability.Version,
+			fixedIn,
+		))
+	}
+	if others := len(vulnerabilities) - blocking; others > 0 {
+		p.emit(EventWarning, "scan", fmt.Sprintf("Vulnerabilities: %d below the %s threshold (or ignored)", others, p.Config.ScanSeverity()))
+	}
+	if blocking > 0 {
+		return fmt.Errorf("found %d vulnerabilities at or above %s severity (fix them, or add their IDs to scan.ignore in kettle.json)", blocking, p.Config.ScanSeverity())
+	}
+	p.emit(EventInfo, "scan", fmt.Sprintf("Scan passed: no vulnerabilities at or above %s severity", p.Config.ScanSeverity()))
+	return nil
+}
@@ -0,0 +1,139 @@
+// Package deploy deploys kettle projects, so that other Go programs (e.g.
+// platform services) can orchestrate deploys without running the kettle CLI
+package deploy
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+// deploys are run one at a time, as they change the working directory
+// and the environment (e.g. the credentials) of the process
+var deploys sync.Mutex
+
+// Options change how a project is deployed
+
+type Options struct {
+	// The stage to deploy (the default stage if it is not set)
+	Stage string
+	// Deploy an isolated copy of the project for the current pull request or git branch
+	Preview bool
+	// The region to deploy to (the region that the stage was deployed to by default)
+	Region string
+	// The credentials of the cloud's cli (its default credentials if it is not set)
+	Credentials *Credentials
+	// Expire the deployment after this long, so that it can be pruned
+	TTL time.Duration
+	// Deploy even if the files that are packaged look like they have secrets
+	AllowSecrets bool
+	// Run the project's tests in its runtime's container image before it is
+	// deployed, even if its config does not enable parity checks
+	Parity bool
+	// Request increases of the account quotas that the deploy is likely to hit
+	RequestQuotaIncreases bool
+	// Receives the events of the deploy, as they happen (log events
+	// are sent from another goroutine, as the output is read)
+	Events func(*Event)
+}
+
+// Credentials are passed to the cloud's cli (e.g. aws or gcloud) as
+// environment variables, for the duration of the deploy
+
+type Credentials struct {
+	AWSProfile         string
+	AWSAccessKeyID     string
+	AWSSecretAccessKey string
+	AWSSessionToken    string
+	// The path of a Google Cloud service account key file
+	GoogleCredentialsFile string
+}
+
+// Result is a project that has been deployed
+
+type Result struct {
+	ProjectName string
+	Stage       string
+	Region      string
+	// The deployment's outputs, which are also recorded in its state
+	Outputs *Outputs
+	// The state before the deploy, to tell which resources it created
+	Previous *state.State
+	// When the deployment can be pruned (zero if it does not expire)
+	Expires  time.Time
+	Duration time.Duration
+}
+
+// Deploy loads the project in a directory and deploys it, without prompting; the
+// output of the deploy is sent to the options' events (as log events), and the
+// deploy is stopped (between steps, and by stopping the command that is running)
+// when the context is done
+func Deploy(ctx context.Context, projectPath string, options *Options) (*Result, error) {
+	if options == nil {
+		options = &Options{}
+	}
+	deploys.Lock()
+	defer deploys.Unlock()
+
+	nonInteractive := cli.NonInteractive
+	cli.NonInteractive = true
+	defer func() { cli.NonInteractive = nonInteractive }()
+	restoreCredentials := useCredentials(options.Credentials)
+	defer restoreCredentials()
+	if options.Events != nil {
+		stopCapture, err := CaptureOutput(options.Events)
+		if err != nil {
+			return nil, err
+		}
+		defer stopCapture()
+	}
+
+	p, err := Load(projectPath, options)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Prepare(); err != nil {
+		return nil, err
+	}
+	return p.Run(ctx, options)
+}
+
+// useCredentials sets the environment variables of the credentials, and
+// returns a function that restores the variables that they replaced
+func useCredentials(credentials *Credentials) func() {
+	if credentials == nil {
+		return func() {}
+	}
+	variables := map[string]string{
+		"AWS_PROFILE":                            credentials.AWSProfile,
+		"AWS_ACCESS_KEY_ID":                      credentials.AWSAccessKeyID,
+		"AWS_SECRET_ACCESS_KEY":                  credentials.AWSSecretAccessKey,
+		"AWS_SESSION_TOKEN":                      credentials.AWSSessionToken,
+		"CLOUDSDK_AUTH_CREDENTIAL_FILE_OVERRIDE": credentials.GoogleCredentialsFile,
+	}
+	previous := map[string]*string{}
+	for variable, value := range variables {
+		if value == "" {
+			continue
+		}
+		if current, ok := os.LookupEnv(variable); ok {
+			previous[variable] = &current
+		} else {
+			previous[variable] = nil
+		}
+		os.Setenv(variable, value)
+	}
+	return func() {
+		for variable, value := range previous {
+			if value == nil {
+				os.Unsetenv(variable)
+			} else {
+				os.Setenv(variable, *value)
+			}
+		}
+	}
+}
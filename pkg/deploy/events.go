@@ -0,0 +1,82 @@
+package deploy
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/operatorai/kettle-cli/state"
+)
+
+// Types of the events of a deploy
+const (
+	// A step of the deploy has started, e.g. provisioning add-ons
+	EventStep = "step"
+	// Something has succeeded, e.g. the policy checks have passed
+	EventInfo = "info"
+	// Something that does not stop the deploy, e.g. a vulnerability below the threshold
+	EventWarning = "warning"
+	// Something that stops the deploy, e.g. a policy violation or a secret in the package
+	EventViolation = "violation"
+	// Something that failed after the deploy, e.g. releasing the lock on the state
+	EventError = "error"
+	// A line of the output of the deploy (only for Deploy, which captures it)
+	EventLog = "log"
+	// The deploy has started, once the project has been prepared
+	EventStarted = "deploy_started"
+	// The deploy created a resource (which is in the event)
+	EventResourceCreated = "resource_created"
+	// The deploy has finished (the event has its outputs)
+	EventFinished = "deploy_finished"
+)
+
+// Event is something that happened during a deploy
+
+type Event struct {
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	Step    string    `json:"step,omitempty"`
+	Message string    `json:"message"`
+	// The resource of resource_created events
+	Resource *state.Resource `json:"resource,omitempty"`
+	// The outputs of deploy_finished events
+	Outputs *Outputs `json:"outputs,omitempty"`
+}
+
+func newEvent(eventType, step, message string) *Event {
+	return &Event{
+		Time:    time.Now(),
+		Type:    eventType,
+		Step:    step,
+		Message: message,
+	}
+}
+
+// CaptureOutput sends each line that is printed to stdout to the events, as a
+// log event (blank lines are skipped); the returned function must be called
+// to restore stdout
+func CaptureOutput(events func(*Event)) (func(), error) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	done := make(chan struct{})
+	go func() {
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			if strings.TrimSpace(scanner.Text()) == "" {
+				continue
+			}
+			events(newEvent(EventLog, "", scanner.Text()))
+		}
+		close(done)
+	}()
+	return func() {
+		os.Stdout = stdout
+		writer.Close()
+		<-done
+	}, nil
+}
@@ -0,0 +1,119 @@
+package deploy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/clouds"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+)
+
+// Outputs are the outputs of a deploy, which are recorded in its state
+
+type Outputs struct {
+	Name     string            `json:"name"`
+	URL      string            `json:"url,omitempty"`
+	Arn      string            `json:"arn,omitempty"`
+	QueueURL string            `json:"queue_url,omitempty"`
+	QueueArn string            `json:"queue_arn,omitempty"`
+	AddOns   map[string]string `json:"add_ons,omitempty"`
+}
+
+// GetOutputs returns the outputs of the deployment: its name,
+// ARN, URL, queue, and the connection details of its add-ons
+func (p *Project) GetOutputs() (*Outputs, error) {
+	outputs := &Outputs{
+		Name:   p.Config.ProjectName,
+		AddOns: p.Config.AddOnEnvironment,
+	}
+	if provider, ok := p.Service.(clouds.EndpointProvider); ok {
+		url, err := provider.GetEndpoint(p.Config, p.Settings)
+		if err == nil {
+			outputs.URL = url
+		} else if settings.DebugMode {
+			fmt.Println(err.Error())
+		}
+	}
+	st, err := state.ReadState(p.Path)
+	if err != nil {
+		return nil, err
+	}
+	for _, resource := range st.Resources {
+		if resource.ID == p.Config.ProjectName && resource.Arn != "" {
+			outputs.Arn = resource.Arn
+			break
+		}
+	}
+	if queues := st.GetResources(state.AWSSQSQueue); len(queues) > 0 {
+		outputs.QueueURL = queues[0].ID
+		outputs.QueueArn = queues[0].Arn
+	}
+	return outputs, nil
+}
+
+// recordOutputs writes the outputs of the deployment to its state, where
+// add-ons are named without their prefix, e.g. users_table for KETTLE_USERS_TABLE
+func (p *Project) recordOutputs(outputs *Outputs) error {
+	st, err := state.ReadState(p.Path)
+	if err != nil {
+		return err
+	}
+	st.Outputs = map[string]string{
+		"name": outputs.Name,
+	}
+	for name, value := range map[string]string{
+		"url":       outputs.URL,
+		"arn":       outputs.Arn,
+		"queue_url": outputs.QueueURL,
+		"queue_arn": outputs.QueueArn,
+	} {
+		if value != "" {
+			st.Outputs[name] = value
+		}
+	}
+	for key, value := range outputs.AddOns {
+		st.Outputs[strings.ToLower(strings.TrimPrefix(key, "KETTLE_"))] = value
+	}
+	return state.WriteState(p.Path, st)
+}
+
+// readReferencedOutputs reads the outputs of the projects that the config refers
+// to from their state (of the same stage); each project is a directory next
+// to the project, and must have been deployed first
+func (p *Project) readReferencedOutputs() error {
+	references := p.Config.GetOutputReferences()
+	if len(references) == 0 {
+		return nil
+	}
+	projectPath, err := filepath.Abs(p.Path)
+	if err != nil {
+		return err
+	}
+	p.Config.ReferencedOutputs = map[string]map[string]string{}
+	for _, reference := range references {
+		if _, ok := p.Config.ReferencedOutputs[reference.Project]; !ok {
+			referencedPath := filepath.Join(filepath.Dir(projectPath), reference.Project)
+			if _, err := os.Stat(referencedPath); err != nil {
+				return fmt.Errorf("the project %s is not next to %s", reference.Project, p.Config.ProjectName)
+			}
+			st, err := state.ReadState(referencedPath)
+			if err != nil {
+				return err
+			}
+			// Previews refer to the default stage of projects that do not have a preview
+			if len(st.Outputs) == 0 && p.Preview {
+				if st, err = state.ReadDefaultStageState(referencedPath); err != nil {
+					return err
+				}
+			}
+			p.Config.ReferencedOutputs[reference.Project] = st.Outputs
+		}
+		if _, ok := p.Config.ReferencedOutputs[reference.Project][reference.Output]; !ok {
+			return fmt.Errorf("%s has no output %s (deploy it first, or see its outputs with kettle output)", reference.Project, reference.Output)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,339 @@
+package deploy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/clouds"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+	"github.com/operatorai/kettle-cli/templates"
+	"github.com/operatorai/kettle-cli/tools"
+)
+
+// Project is a kettle project that has been read from disk, alongside
+// the global settings and the cloud service that it is deployed to
+
+type Project struct {
+	Path     string
+	Config   *config.Config
+	Settings *settings.Settings
+	Cloud    clouds.Cloud
+	Service  clouds.Service
+	// Previews are isolated copies of the project, e.g. for a pull request
+	Preview bool
+	// The deployment (and its stage) whose artifact is being promoted
+	PromotedFrom  *state.Deployment
+	PromotedStage string
+	// Receives the steps of the project's operations, e.g. that its state is locked
+	Events func(*Event)
+
+	// The account quotas that the deploy is likely to hit, which
+	// are found when it is prepared
+	quotas []*config.Quota
+	// The changes to the project's template that are being looked up
+	// in the background, from when the deploy is prepared
+	templateNotices chan []*config.TemplateChange
+}
+
+// Load reads the project in a directory, with the stage (or preview), and
+// the region, of the options; the region is the one that the stage was
+// deployed to, unless it is set, and it is asked for if neither is set
+func Load(projectPath string, options *Options) (*Project, error) {
+	if options == nil {
+		options = &Options{}
+	}
+
+	// Read the template's config, with the stage's overlays
+	stage := options.Stage
+	if stage == "" {
+		stage = config.DefaultStage
+	}
+	config.OverlayStage = stage
+	templateConfig, err := config.ReadConfig(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	if overlays := templateConfig.Overlays(); len(overlays) > 0 {
+		fmt.Println("🧩  Overlays: ", strings.Join(overlays, ", "))
+		warnUnignoredOverlay(projectPath, overlays)
+	}
+
+	// Pinned tools (e.g. cargo-lambda) are run from the toolcache
+	if _, err := tools.Use(templateConfig); err != nil {
+		return nil, err
+	}
+
+	// Get the cloud provider & service type
+	cloudProvider, err := clouds.GetCloudProvider(templateConfig.Config.CloudProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stages that deploy to their own account (or project) use
+	// its credentials, and have their own settings
+	stageAccount := templateConfig.GetStageAccount(stage)
+	settings.Stage = ""
+	if stageAccount != nil {
+		settings.Stage = stage
+	}
+	cloudSettings, err := settings.ReadSettings()
+	if err != nil {
+		return nil, err
+	}
+	if err := useStageAccount(cloudProvider, stageAccount, cloudSettings); err != nil {
+		return nil, err
+	}
+	if err := cloudProvider.Setup(cloudSettings); err != nil {
+		return nil, err
+	}
+
+	service, err := cloudProvider.GetService(templateConfig.Config.DeploymentType)
+	if err != nil {
+		return nil, err
+	}
+	if err := templateConfig.SetNaming(cloudSettings.Naming); err != nil {
+		return nil, err
+	}
+	p := &Project{
+		Path:     projectPath,
+		Config:   templateConfig,
+		Settings: cloudSettings,
+		Cloud:    cloudProvider,
+		Service:  service,
+		Events:   options.Events,
+	}
+	state.Stage = ""
+	switch {
+	case options.Preview && options.Stage != "":
+		return nil, errors.New("--preview and --stage cannot be used together")
+	case options.Preview:
+		if err := p.usePreviewStage(); err != nil {
+			return nil, err
+		}
+	case options.Stage != "":
+		if err := p.useStage(options.Stage); err != nil {
+			return nil, err
+		}
+	}
+
+	// The region is chosen per project & stage, once the stage is known
+	st, err := state.ReadState(p.Path)
+	if err != nil {
+		return nil, err
+	}
+	region := st.Region
+	if region == "" && state.Stage != "" {
+		// Previews are deployed to the same region as the project
+		defaultState, err := state.ReadDefaultStageState(p.Path)
+		if err != nil {
+			return nil, err
+		}
+		region = defaultState.Region
+	}
+	if stageConfig, ok := p.Config.Config.Stages[stage]; region == "" && ok {
+		region = stageConfig.Region
+	}
+	if err := SelectRegion(p.Cloud, p.Settings, p.Config, options.Region, region); err != nil {
+		return nil, err
+	}
+	// The project's own resources are used instead of the shared defaults,
+	// e.g. so that its endpoint is on its team's REST API
+	if user, ok := p.Service.(clouds.ProjectResourceUser); ok {
+		if err := user.UseProjectResources(p.Path, p.Config, p.Settings); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// SelectRegion sets the deployment region to the region that is set (e.g. with
+// --region), or the region that the project's stage was deployed to; otherwise,
+// it prompts for one of the regions where the project's services are available,
+// defaulting to the last choice
+func SelectRegion(cloud clouds.Cloud, stg *settings.Settings, cfg *config.Config, region, deployedRegion string) error {
+	if region != "" {
+		cloud.SetRegion(stg, region)
+		return nil
+	}
+	if deployedRegion != "" {
+		cloud.SetRegion(stg, deployedRegion)
+		return nil
+	}
+
+	regions, err := cloud.GetRegions(cfg)
+	if err != nil {
+		return err
+	}
+	region, err = cli.PromptForValueWithDefault("Deployment region", regions, cloud.GetRegion(stg))
+	if err != nil {
+		return err
+	}
+	if region == "" {
+		return fmt.Errorf("please choose a deployment region (or use: --region)")
+	}
+	cloud.SetRegion(stg, region)
+	return nil
+}
+
+// ChangeDirectory moves into the project directory, and returns
+// a function that returns to the original root directory
+func (p *Project) ChangeDirectory() (func(), error) {
+	rootDir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chdir(p.Path); err != nil {
+		return nil, err
+	}
+	return func() {
+		os.Chdir(rootDir)
+	}, nil
+}
+
+// warnUnignoredOverlay warns if the personal overlay (kettle.local.yaml)
+// is not ignored by git, so that it is not committed by mistake
+func warnUnignoredOverlay(projectPath string, overlays []string) {
+	for _, overlay := range overlays {
+		if overlay != config.LocalFileName {
+			continue
+		}
+		// check-ignore exits with 1 if the file is not ignored (and 128 outside of a repository)
+		_, err := cli.ExecuteSilently("git", []string{"-C", projectPath, "check-ignore", "-q", overlay})
+		var commandError *cli.CommandError
+		if errors.As(err, &commandError) && commandError.ExitCode == 1 {
+			fmt.Println(fmt.Sprintf("⚠️   %s is not ignored by git: add it to .gitignore, so that it is not committed", overlay))
+		}
+	}
+}
+
+// usePreviewStage switches the project to the preview stage of the
+// current pull request or git branch
+func (p *Project) usePreviewStage() error {
+	stage, err := templates.GetPreviewStage()
+	if err != nil {
+		return err
+	}
+	p.Config.SetStage(stage)
+	state.Stage = stage
+	p.Preview = true
+	fmt.Println("🔀  Preview: ", p.Config.ProjectName)
+	return nil
+}
+
+// useStage switches the project to a named stage from its config; the
+// resources of stages (other than the default stage) have their own names
+func (p *Project) useStage(stage string) error {
+	if stage == config.DefaultStage {
+		return nil
+	}
+	if _, ok := p.Config.Config.Stages[stage]; !ok {
+		return fmt.Errorf("stage %s is not in the project's config", stage)
+	}
+	p.Config.SetStage(stage)
+	state.Stage = stage
+	fmt.Println("🎬  Stage: ", p.Config.ProjectName)
+	return nil
+}
+
+// useStageAccount points the cloud's cli at the stage's account (or
+// project); a nil stage uses the cli's default account
+func useStageAccount(cloud clouds.Cloud, stage *config.Stage, stg *settings.Settings) error {
+	switcher, ok := cloud.(clouds.StageAccountSwitcher)
+	if !ok {
+		if stage == nil {
+			return nil
+		}
+		return errors.New("stages with their own account are not supported on this cloud")
+	}
+	return switcher.UseStageAccount(stage, stg)
+}
+
+// LockState acquires the lock on the project's remote state (if it has a state
+// backend) and downloads it, and returns a function that uploads the state and
+// releases the lock
+func (p *Project) LockState() (func(), error) {
+	if p.Config.Config.StateBackend == nil {
+		return func() {}, nil
+	}
+	backend, ok := p.Cloud.(clouds.StateBackend)
+	if !ok {
+		return nil, fmt.Errorf("state backends are not supported on: %s", p.Config.Config.CloudProvider)
+	}
+	if err := backend.Lock(p.Config, p.Settings); err != nil {
+		return nil, err
+	}
+	p.emit(EventInfo, "lock", "Locked: "+p.Config.LockID())
+	unlock := func() {
+		if err := backend.PushState(p.Path, p.Config, p.Settings); err != nil {
+			p.emit(EventError, "lock", "Failed to upload state: "+err.Error())
+		}
+		if err := backend.Unlock(p.Config, p.Settings); err != nil {
+			p.emit(EventError, "lock", "Failed to release lock (run: kettle force-unlock): "+err.Error())
+		}
+	}
+	if err := backend.PullState(p.Path, p.Config, p.Settings); err != nil {
+		// Nothing has changed, so the lock is released without uploading the state
+		backend.Unlock(p.Config, p.Settings)
+		return nil, err
+	}
+	return unlock, nil
+}
+
+// Save writes the settings & config back (they may have been changed); previews
+// and other stages do not write the config, which describes the default stage
+func (p *Project) Save() {
+	if err := settings.WriteSettings(p.Settings); err != nil {
+		if settings.DebugMode {
+			fmt.Println(err.Error())
+		}
+	}
+	if p.Config.Stage != "" {
+		return
+	}
+	if err := config.WriteConfig(p.Path, p.Config); err != nil {
+		if settings.DebugMode {
+			fmt.Println(err.Error())
+		}
+	}
+}
+
+// RecordDeployment adds the code (and model) version, and the digest of
+// the deployed artifact, to the deploy history
+func (p *Project) RecordDeployment() error {
+	st, err := state.ReadState(p.Path)
+	if err != nil {
+		return err
+	}
+	modelVersion := ""
+	if p.Config.Config.Model != nil {
+		modelVersion = p.Config.Config.Model.Version
+	}
+	deployment := st.AddDeployment(templates.GetCodeVersion(), modelVersion)
+	if p.Config.Artifact != nil {
+		deployment.Artifact = p.Config.Artifact.Digest
+		deployment.ArtifactVersion = p.Config.Artifact.Version
+	}
+	if p.PromotedFrom != nil {
+		// Promoted artifacts were built from the source stage's code
+		deployment.CodeVersion = p.PromotedFrom.CodeVersion
+		deployment.ModelVersion = p.PromotedFrom.ModelVersion
+		deployment.PromotedFrom = p.PromotedStage
+	}
+	st.Region = p.Cloud.GetRegion(p.Settings)
+	return state.WriteState(p.Path, st)
+}
+
+func (p *Project) emit(eventType, step, message string) {
+	p.send(newEvent(eventType, step, message))
+}
+
+func (p *Project) send(event *Event) {
+	if p.Events != nil {
+		p.Events(event)
+	}
+}
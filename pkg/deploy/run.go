@@ -0,0 +1,381 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/clouds"
+	"github.com/operatorai/kettle-cli/docs"
+	"github.com/operatorai/kettle-cli/includes"
+	"github.com/operatorai/kettle-cli/models"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/state"
+	"github.com/operatorai/kettle-cli/vault"
+)
+
+// Prepare checks that the project can be deployed (that the cloud supports
+// its features, and that it passes its policy and contract), and reads the
+// outputs of the other projects that its config refers to; nothing has been
+// changed yet
+func (p *Project) Prepare() error {
+	p.emit(EventStep, "prepare", "Checking the project")
+	if err := clouds.ValidateFeatures(p.Service, p.Config); err != nil {
+		return err
+	}
+	if err := p.Config.ValidateAuth(); err != nil {
+		return err
+	}
+	if err := p.checkPolicy(); err != nil {
+		return err
+	}
+	if err := p.checkContract(); err != nil {
+		return err
+	}
+	p.startTemplateNotices()
+	p.checkQuotas()
+	return p.readReferencedOutputs()
+}
+
+// Run deploys the project, which has been prepared; it stops between
+// steps, and stops the command that is running, when the context is done
+func (p *Project) Run(ctx context.Context, options *Options) (*Result, error) {
+	if options == nil {
+		options = &Options{}
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	started := time.Now()
+	restoreContext := cli.UseContext(ctx)
+	defer restoreContext()
+
+	unlock, err := p.LockState()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if err := p.setExpiry(options.TTL); err != nil {
+		return nil, err
+	}
+	p.emit(EventStarted, "", fmt.Sprintf("Deploying %s to %s", p.Config.ProjectName, p.Cloud.GetRegion(p.Settings)))
+
+	// Change to the directory where the function to deploy is implemented
+	// and run the deployment command
+	returnToRoot, err := p.ChangeDirectory()
+	if err != nil {
+		return nil, err
+	}
+	defer returnToRoot()
+
+	// Copy any shared code (e.g. from a monorepo) into the package,
+	// unless a pre-built artifact is being deployed
+	if p.Config.Artifact == nil {
+		p.emit(EventStep, "package", "Copying shared code")
+		removeIncludes, err := includes.Vendor(p.Path, p.Config)
+		if err != nil {
+			return nil, err
+		}
+		defer removeIncludes()
+
+		if err := p.checkLeaks(options.AllowSecrets); err != nil {
+			return nil, err
+		}
+
+		// Test the package (with its shared code) in the runtime's image
+		if p.Config.Config.Parity != nil || options.Parity {
+			if err := step(ctx, p, "parity", "Testing in the runtime's image"); err != nil {
+				return nil, err
+			}
+			if err := p.checkParity(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Upload or download the model artifact
+	if err := step(ctx, p, "model", "Syncing the model artifact"); err != nil {
+		return nil, err
+	}
+	if err := models.Sync(p.Path, p.Config); err != nil {
+		return nil, err
+	}
+
+	// Scan the package (which is then deployed) before anything is changed
+	if p.Config.Config.Scan != nil {
+		if err := step(ctx, p, "scan", "Scanning the package"); err != nil {
+			return nil, err
+		}
+		builder, ok := p.Service.(clouds.Builder)
+		if !ok {
+			return nil, fmt.Errorf("scanning is not supported on %s %s deployments",
+				p.Config.Config.CloudProvider,
+				p.Config.Config.DeploymentType,
+			)
+		}
+		if err := p.Scan(builder); err != nil {
+			return nil, err
+		}
+	}
+
+	// Request increases of the quotas that the deploy is likely to hit, before
+	// anything is created
+	if options.RequestQuotaIncreases {
+		if err := p.requestQuotaIncreases(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	// Create any add-ons, and pass their connection details to the service
+	if len(p.Config.Config.AddOns) > 0 {
+		if err := step(ctx, p, "add-ons", "Provisioning add-ons"); err != nil {
+			return nil, err
+		}
+		provisioner, ok := p.Cloud.(clouds.AddOnProvisioner)
+		if !ok {
+			return nil, fmt.Errorf("add-ons are not supported on: %s", p.Config.Config.CloudProvider)
+		}
+		environment, err := provisioner.ProvisionAddOns(p.Path, p.Config, p.Settings)
+		if err != nil {
+			return nil, err
+		}
+		p.Config.AddOnEnvironment = environment
+	}
+
+	// Sync the project's secrets from Vault to the cloud's secret store, and pass
+	// references to them to the service (secrets that were removed are deleted)
+	if err := p.syncSecrets(ctx); err != nil {
+		return nil, err
+	}
+
+	// The resources before the deploy, to tell which ones it created
+	existing, err := state.ReadState(p.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Deploy
+	if err := step(ctx, p, "deploy", "Deploying "+p.Config.ProjectName); err != nil {
+		return nil, err
+	}
+	if err := p.Service.Deploy(p.Path, p.Config, p.Settings); err != nil {
+		return nil, err
+	}
+
+	// Deploy the static assets alongside the service
+	if p.Config.Config.Static != nil {
+		if err := step(ctx, p, "static", "Deploying the static site"); err != nil {
+			return nil, err
+		}
+		host, ok := p.Cloud.(clouds.StaticSiteHost)
+		if !ok {
+			return nil, fmt.Errorf("static sites are not supported on: %s", p.Config.Config.CloudProvider)
+		}
+		if err := host.DeployStaticSite(p.Path, p.Config, p.Settings); err != nil {
+			return nil, err
+		}
+	}
+
+	// Monitor the deployed endpoint with the smoke test (previews
+	// are short-lived, so they are not monitored)
+	if p.Config.Config.Canary != nil && !p.Preview {
+		if err := step(ctx, p, "canary", "Deploying the canary"); err != nil {
+			return nil, err
+		}
+		if err := p.deployCanary(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Previews are tagged with the project's name, so they are part of its budget
+	if p.Config.Config.Budget != nil && !p.Preview {
+		if err := step(ctx, p, "budget", "Setting the budget"); err != nil {
+			return nil, err
+		}
+		manager, ok := p.Cloud.(clouds.BudgetManager)
+		if !ok {
+			return nil, fmt.Errorf("budgets are not supported on: %s", p.Config.Config.CloudProvider)
+		}
+		if err := manager.SetBudget(p.Path, p.Config, p.Settings); err != nil {
+			return nil, err
+		}
+	}
+
+	p.Save()
+	if err := p.RecordDeployment(); err != nil {
+		if settings.DebugMode {
+			fmt.Println(err.Error())
+		}
+	}
+	outputs, err := p.GetOutputs()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.recordOutputs(outputs); err != nil {
+		return nil, err
+	}
+	if err := p.emitCreatedResources(existing); err != nil {
+		return nil, err
+	}
+
+	// Keep the service's docs up to date, if they have been generated (they
+	// describe the default stage, like the config)
+	if p.Config.Stage == "" {
+		st, err := state.ReadState(p.Path)
+		if err != nil {
+			return nil, err
+		}
+		if err := docs.UpdateServiceDocs(p.Path, p.Config, st); err != nil {
+			p.emit(EventWarning, "docs", "Failed to update "+docs.ServiceFileName+": "+err.Error())
+		}
+	}
+	result := &Result{
+		ProjectName: p.Config.ProjectName,
+		Stage:       p.Config.Stage,
+		Region:      p.Cloud.GetRegion(p.Settings),
+		Outputs:     outputs,
+		Previous:    existing,
+		Expires:     p.Config.Expires,
+		Duration:    time.Since(started),
+	}
+	p.checkTemplateNotices()
+	finished := newEvent(EventFinished, "", fmt.Sprintf("Deployed %s in %s", p.Config.ProjectName, result.Duration.Round(time.Second)))
+	finished.Outputs = outputs
+	p.send(finished)
+	return result, nil
+}
+
+// emitCreatedResources sends a resource_created event for each resource
+// in the project's state that was not in its state before the deploy
+func (p *Project) emitCreatedResources(existing *state.State) error {
+	if p.Events == nil {
+		return nil
+	}
+	st, err := state.ReadState(p.Path)
+	if err != nil {
+		return err
+	}
+	previous := map[string]bool{}
+	for _, resource := range existing.Resources {
+		previous[resource.Type+"/"+resource.ID] = true
+	}
+	for _, resource := range st.Resources {
+		if previous[resource.Type+"/"+resource.ID] {
+			continue
+		}
+		event := newEvent(EventResourceCreated, "deploy", fmt.Sprintf("Created %s: %s", resource.Type, resource.ID))
+		event.Resource = resource
+		p.send(event)
+	}
+	return nil
+}
+
+// requestQuotaIncreases files requests to increase the quotas that the deploy is
+// likely to hit; the deploy continues if they cannot be filed, as it may still fit
+func (p *Project) requestQuotaIncreases(ctx context.Context) error {
+	if len(p.quotas) == 0 {
+		return nil
+	}
+	checker, ok := p.Service.(clouds.QuotaChecker)
+	if !ok {
+		return nil
+	}
+	if err := step(ctx, p, "quotas", "Requesting quota increases"); err != nil {
+		return err
+	}
+	for _, quota := range p.quotas {
+		if !quota.IsRequestable() {
+			p.emit(EventWarning, "quotas", fmt.Sprintf("An increase of the %s quota cannot be requested", quota.Name))
+			continue
+		}
+		requestID, err := checker.RequestQuotaIncrease(quota, p.Settings)
+		if err != nil {
+			p.emit(EventWarning, "quotas", fmt.Sprintf("Cannot request an increase of the %s quota: %s", quota.Name, err))
+			continue
+		}
+		p.emit(EventInfo, "quotas", fmt.Sprintf("Requested an increase of the %s quota to %.0f (request: %s)", quota.Name, quota.SuggestedLimit(), requestID))
+	}
+	return nil
+}
+
+// step starts a step of the deploy, unless the context is done
+// syncSecrets reads the project's secrets from Vault, and stores them in the
+// cloud's secret store; it is skipped if the project has never had secrets
+func (p *Project) syncSecrets(ctx context.Context) error {
+	secrets := p.Config.DeploySecrets()
+	if len(secrets) == 0 {
+		st, err := state.ReadState(p.Path)
+		if err != nil {
+			return err
+		}
+		if len(st.GetResources(state.AWSSecret)) == 0 && len(st.GetResources(state.GoogleSecret)) == 0 {
+			return nil
+		}
+	}
+	if err := step(ctx, p, "secrets", "Syncing secrets from Vault"); err != nil {
+		return err
+	}
+	syncer, ok := p.Cloud.(clouds.SecretSyncer)
+	if !ok {
+		return fmt.Errorf("secrets are not supported on: %s", p.Config.Config.CloudProvider)
+	}
+	values := map[string]string{}
+	for key, reference := range secrets {
+		value, err := vault.Read(p.Settings.Vault, reference)
+		if err != nil {
+			return fmt.Errorf("cannot read the secret %s: %w", key, err)
+		}
+		values[key] = value
+	}
+	environment, err := syncer.SyncSecrets(p.Path, p.Config, p.Settings, values)
+	if err != nil {
+		return err
+	}
+	p.Config.SecretEnvironment = environment
+	return nil
+}
+
+func step(ctx context.Context, p *Project, name, message string) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("the deploy was stopped before %s: %s", name, err)
+	}
+	p.emit(EventStep, name, message)
+	return nil
+}
+
+// setExpiry tags the deployment with an expiry when it is deployed with a TTL,
+// or keeps the expiry of a previous deployment that had one
+func (p *Project) setExpiry(ttl time.Duration) error {
+	st, err := state.ReadState(p.Path)
+	if err != nil {
+		return err
+	}
+	if ttl < 0 {
+		return errors.New("--ttl must be greater than zero")
+	}
+	if ttl == 0 {
+		p.Config.Expires, err = st.GetExpiry()
+		return err
+	}
+	p.Config.Expires = time.Now().Add(ttl)
+	st.SetExpiry(p.Config.Expires)
+	return state.WriteState(p.Path, st)
+}
+
+func (p *Project) deployCanary() error {
+	host, ok := p.Cloud.(clouds.CanaryHost)
+	if !ok {
+		return fmt.Errorf("canaries are not supported on: %s", p.Config.Config.CloudProvider)
+	}
+	provider, ok := p.Service.(clouds.EndpointProvider)
+	if !ok {
+		return errors.New("canaries need a deployment type with an http endpoint")
+	}
+	url, err := provider.GetEndpoint(p.Config, p.Settings)
+	if err != nil {
+		return err
+	}
+	return host.DeployCanary(p.Path, p.Config, p.Settings, url)
+}
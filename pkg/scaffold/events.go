@@ -0,0 +1,45 @@
+package scaffold
+
+import "time"
+
+// Types of the events of rendering a template
+const (
+	// Rendering has started, once the values of the template's prompts are known
+	EventStarted = "render_started"
+	// A file of the project has been written (its path is in the event)
+	EventFileWritten = "file_written"
+	// A formatter (e.g. gofmt) has formatted the project's files, or has
+	// been skipped because its command is not installed
+	EventFormatted         = "formatted"
+	EventFormattingSkipped = "formatting_skipped"
+	// A validation hook (e.g. terraform validate) has passed, or has been
+	// skipped because its command is not installed
+	EventValidated         = "validated"
+	EventValidationSkipped = "validation_skipped"
+	// Rendering has finished, and the project's config has been written
+	EventFinished = "render_finished"
+)
+
+// Event is something that happened while rendering a template
+
+type Event struct {
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	Message string    `json:"message"`
+	// The path of the file (relative to the project) of file_written events
+	Path string `json:"path,omitempty"`
+}
+
+func newEvent(eventType, message string) *Event {
+	return &Event{
+		Time:    time.Now(),
+		Type:    eventType,
+		Message: message,
+	}
+}
+
+func (options *Options) emit(event *Event) {
+	if options.Events != nil {
+		options.Events(event)
+	}
+}
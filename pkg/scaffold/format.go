@@ -0,0 +1,43 @@
+package scaffold
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/config"
+)
+
+// format runs the template's formatters on the files that were rendered (files
+// that were copied as they are are left alone), so that the project's code is
+// formatted whatever the values were; formatters whose command is not installed
+// are skipped
+func format(dest string, rendered []string, templateConfig *config.Config, result *Result, options *Options) error {
+	formatters, err := templateConfig.GetFormatters()
+	if err != nil {
+		return err
+	}
+	for _, formatter := range formatters {
+		files := []string{}
+		for _, file := range rendered {
+			if formatter.Formats(file) {
+				files = append(files, file)
+			}
+		}
+		if len(files) == 0 {
+			continue
+		}
+		command := strings.Join(formatter.Command, " ")
+		if !isInstalled(formatter.Command[0]) {
+			result.SkippedFormatting = append(result.SkippedFormatting, command)
+			options.emit(newEvent(EventFormattingSkipped, fmt.Sprintf("Skipped %s (%s is not installed)", command, formatter.Command[0])))
+			continue
+		}
+		err := runInProject(dest, append(append([]string{}, formatter.Command...), files...), fmt.Sprintf("Formatting %d file(s): %s", len(files), command))
+		if err != nil {
+			return fmt.Errorf("formatting the project failed (%s): %w", command, err)
+		}
+		result.Formatted = append(result.Formatted, command)
+		options.emit(newEvent(EventFormatted, fmt.Sprintf("Formatted %d file(s) with %s", len(files), command)))
+	}
+	return nil
+}
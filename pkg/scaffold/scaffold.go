@@ -0,0 +1,317 @@
+// Package scaffold creates projects from kettle templates, so that other Go
+// programs (e.g. internal platforms) can use kettle's templating without
+// running the kettle CLI
+package scaffold
+
+import (
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/policy"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/tempdir"
+	"github.com/operatorai/kettle-cli/templates"
+)
+
+// Options change how a project is created from a template
+
+type Options struct {
+	// The name of the project (the base name of the destination by default)
+	ProjectName string
+	// Asks for the values of the template's prompts that are not in the values;
+	// without it, the prompt's default is used (or it is an error if it has none)
+	Prompt func(prompt *config.TemplatePrompt) (string, error)
+	// Look up the values of the template's prompts that are not in the values,
+	// before they are asked for (e.g. values that are standard in an organisation)
+	Providers []templates.Provider
+	// Receives the events of rendering the template, as they happen
+	Events func(*Event)
+	// Skips the template's formatters (e.g. gofmt), and its
+	// validation hooks (e.g. terraform validate)
+	SkipFormatting bool
+	SkipValidation bool
+}
+
+// Result is the project that was created from a template
+
+type Result struct {
+	// The project's config, which has been written to its kettle.json
+	Config *config.Config
+	// The template's values (e.g. the answers to its prompts), by key
+	Values map[string]interface{}
+	// Possible secrets in the project's files (e.g. from the values)
+	Leaks []*policy.Leak
+	// The keys of the values that were looked up in the providers
+	Provided []string
+	// The formatters and validation hooks that were run, and those
+	// that were skipped because their command is not installed
+	Formatted         []string
+	SkippedFormatting []string
+	Validated         []string
+	SkippedValidation []string
+}
+
+// RenderTemplate creates a project in dest from a template (a local path, a
+// bundle, a git repository, or the name of a template in kettle-templates);
+// values are the answers to the template's prompts, by key. The destination
+// is created if it does not exist, and is not removed if rendering fails
+func RenderTemplate(src string, values map[string]string, dest string, options *Options) (*Result, error) {
+	templatePath, isTempDir, err := templates.GetTemplate(src)
+	if err != nil {
+		return nil, err
+	}
+	if isTempDir {
+		defer tempdir.Remove(templatePath)
+	}
+	templateConfig, err := config.ReadConfig(templatePath)
+	if err != nil {
+		return nil, err
+	}
+	templateConfig.Source = templates.GetSource(src, templatePath)
+	return Render(templatePath, templateConfig, values, dest, options)
+}
+
+// Render creates a project in dest from a template that has already been fetched
+// (to templatePath), with its config; this is RenderTemplate's second step
+func Render(templatePath string, templateConfig *config.Config, values map[string]string, dest string, options *Options) (*Result, error) {
+	if options == nil {
+		options = &Options{}
+	}
+	if err := templateConfig.ValidateRender(); err != nil {
+		return nil, err
+	}
+	if err := templateConfig.ValidateChangelog(); err != nil {
+		return nil, err
+	}
+	if err := templateConfig.ValidateCI(); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dest, os.ModePerm); err != nil {
+		return nil, err
+	}
+	projectName := options.ProjectName
+	if projectName == "" {
+		projectName = filepath.Base(dest)
+	}
+
+	// Get the values of the template's prompts: from the given values (by the
+	// template's key), by asking for them, or from their defaults
+	templateConfig.ProjectName = projectName
+	templateValues := map[string]interface{}{
+		"ProjectName": projectName,
+	}
+	provided := []string{}
+	for _, templateEntry := range templateConfig.Template {
+		userInput, isProvided, err := getValue(templateEntry, values, options)
+		if err != nil {
+			return nil, err
+		}
+		if isProvided {
+			provided = append(provided, templateEntry.Key)
+		}
+		if templateEntry.Style == "camel" {
+			userInput = strcase.ToCamel(userInput)
+		}
+		value, err := templates.GetValue(templateEntry, userInput)
+		if err != nil {
+			return nil, err
+		}
+		templateEntry.Value = userInput
+		templateValues[templateEntry.Key] = value
+	}
+
+	options.emit(newEvent(EventStarted, fmt.Sprintf("Creating %s in %s", projectName, dest)))
+	rendered, err := renderFiles(templatePath, templateConfig, templateValues, dest, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// Seed the project's config with the template's deploy defaults
+	if err := templates.ApplyDeployDefaults(templatePath, templateConfig, templateValues); err != nil {
+		return nil, err
+	}
+
+	// Add a structured logging helper (and tracing and batch snippets) for the project's runtime
+	if err := templates.AddLoggingHelper(dest, templateConfig.Config.Runtime); err != nil {
+		return nil, err
+	}
+	if templateConfig.Config.Tracing != nil {
+		if err := templates.AddTracingHelper(dest, templateConfig.Config.Runtime); err != nil {
+			return nil, err
+		}
+	}
+	if templateConfig.Config.Queue != nil && templateConfig.Config.Queue.Retries > 0 {
+		if err := templates.AddBatchHelper(dest, templateConfig.Config.Runtime); err != nil {
+			return nil, err
+		}
+	}
+
+	// Templates can render values (e.g. answers to their prompts) into the
+	// project's files; deploys are blocked if they have secrets
+	leaks, err := policy.FindLeaks(dest, templateConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.WriteConfig(dest, templateConfig); err != nil {
+		return nil, err
+	}
+	result := &Result{
+		Config:   templateConfig,
+		Values:   templateValues,
+		Leaks:    leaks,
+		Provided: provided,
+	}
+
+	// Format the rendered files, and check the project (e.g. the
+	// Terraform or Helm chart that it generated)
+	if !options.SkipFormatting {
+		if err := format(dest, rendered, templateConfig, result, options); err != nil {
+			return nil, err
+		}
+	}
+	if !options.SkipValidation {
+		if err := validate(dest, templateConfig, result, options); err != nil {
+			return nil, err
+		}
+	}
+	options.emit(newEvent(EventFinished, "Created "+dest))
+	return result, nil
+}
+
+// getValue returns the value of a prompt, and whether it was looked up in a provider
+func getValue(templateEntry *config.TemplatePrompt, values map[string]string, options *Options) (string, bool, error) {
+	if value, ok := values[templateEntry.Key]; ok {
+		return value, false, nil
+	}
+	value, ok, err := templates.GetProvidedValue(options.Providers, templateEntry.Key)
+	if err != nil || ok {
+		return value, ok, err
+	}
+	if options.Prompt != nil {
+		value, err := options.Prompt(templateEntry)
+		return value, false, err
+	}
+	if templateEntry.Default != "" {
+		return templateEntry.Default, false, nil
+	}
+	return "", false, fmt.Errorf("%s has no value (and no default)", templateEntry.Key)
+}
+
+// renderFiles creates the project's files from the files in the template's
+// template directory, skipping any whose condition is false, and returns the
+// paths (in the project) of the files that were rendered, rather than copied
+func renderFiles(templatePath string, templateConfig *config.Config, templateValues map[string]interface{}, dest string, options *Options) ([]string, error) {
+	templateDirectory := path.Join(templatePath, "template")
+	rendered := []string{}
+	err := filepath.Walk(templateDirectory, func(filePath string, info fs.FileInfo, err error) error {
+		if err != nil {
+			if settings.DebugMode {
+				fmt.Printf("error accessing a path %q: %v\n", filePath, err)
+				return err
+			}
+			return nil
+		}
+
+		// Skip files (and directories) whose condition is false
+		relativePath := strings.TrimPrefix(strings.Replace(filePath, templateDirectory, "", 1), "/")
+		included, err := templates.IsIncluded(relativePath, templateConfig.Files, templateValues)
+		if err != nil {
+			return err
+		}
+		if !included {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Skip directories
+		if info.IsDir() {
+			return nil
+		}
+
+		// Create the target path, which can have template expressions
+		// (e.g. {{.ProjectName}}/main.py)
+		targetPath, err := templates.Render(relativePath, templateValues)
+		if err != nil {
+			return err
+		}
+		projectPath := targetPath
+		targetPath = path.Join(dest, targetPath)
+
+		// Create the target file
+		profile := templateConfig.GetRenderProfile(relativePath)
+		stopTracking := cli.Track("render", relativePath)
+		err = createFile(targetPath, filePath, profile, templateValues)
+		stopTracking()
+		if err != nil {
+			return err
+		}
+		if profile != config.ProfileCopy {
+			rendered = append(rendered, projectPath)
+		}
+		written := newEvent(EventFileWritten, "Created "+projectPath)
+		written.Path = projectPath
+		options.emit(written)
+		if strings.HasSuffix(targetPath, ".sh") {
+			if err := os.Chmod(targetPath, 0775); err != nil {
+				if settings.DebugMode {
+					fmt.Println(err.Error())
+				}
+			}
+		}
+		return nil
+	})
+	return rendered, err
+}
+
+func createFile(targetPath, filePath, profile string, templateValues map[string]interface{}) error {
+	// Read the source file
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	// Create the parent directory
+	parentDir, _ := path.Split(targetPath)
+	err = os.MkdirAll(parentDir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	// Create the target file
+	f, err := os.Create(targetPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Binary files (e.g. images), and files with the copy profile, are copied as they are
+	if templates.IsBinary(data) || profile == config.ProfileCopy {
+		_, err = f.Write(data)
+		return err
+	}
+
+	// Populate the target file by executing the template, with
+	// the profile's delimiters and its escaped values
+	_, fileName := path.Split(filePath)
+	tmpl, err := templates.NewTemplate(fileName).Delims(templates.GetDelimiters(profile)).Parse(string(data))
+	if err != nil {
+		return err
+	}
+
+	err = tmpl.Execute(f, templates.EscapeValues(profile, templateValues))
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package scaffold
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+)
+
+// validate runs the template's validation hooks in the project, e.g. so that a
+// template that generates Terraform or a Helm chart is known to generate valid
+// ones; hooks whose command is not installed are skipped
+func validate(dest string, templateConfig *config.Config, result *Result, options *Options) error {
+	charts, hasTerraform, err := findInfrastructure(dest)
+	if err != nil {
+		return err
+	}
+	hooks := templateConfig.GetValidationHooks(charts, hasTerraform)
+	if len(hooks) == 0 {
+		return nil
+	}
+	for _, hook := range hooks {
+		command := strings.Join(hook.Command, " ")
+		if !isInstalled(hook.Command[0]) {
+			result.SkippedValidation = append(result.SkippedValidation, command)
+			options.emit(newEvent(EventValidationSkipped, fmt.Sprintf("Skipped %s (%s is not installed)", command, hook.Command[0])))
+			continue
+		}
+		err := runInProject(path.Join(dest, hook.Directory), hook.Command, fmt.Sprintf("Validating the project: %s", command))
+		if err != nil {
+			return fmt.Errorf("the project failed validation (%s): %w", command, err)
+		}
+		result.Validated = append(result.Validated, command)
+		options.emit(newEvent(EventValidated, "Validated with "+command))
+	}
+	return nil
+}
+
+// runInProject runs a command in a directory of the project
+func runInProject(directory string, command []string, statusMessage string) error {
+	rootDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if err := os.Chdir(directory); err != nil {
+		return err
+	}
+	defer os.Chdir(rootDir)
+	_, err = cli.ExecuteWithResult(command[0], command[1:], statusMessage)
+	return err
+}
+
+func isInstalled(command string) bool {
+	_, err := exec.LookPath(command)
+	return err == nil
+}
+
+// findInfrastructure returns the project's Helm charts (the directories with a
+// Chart.yaml, relative to the project), and whether its root has Terraform files
+func findInfrastructure(dest string) ([]string, bool, error) {
+	charts := []string{}
+	hasTerraform := false
+	err := filepath.Walk(dest, func(filePath string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && (info.Name() == ".terraform" || info.Name() == ".git") {
+			return filepath.SkipDir
+		}
+		directory, err := filepath.Rel(dest, filepath.Dir(filePath))
+		if err != nil {
+			return err
+		}
+		switch {
+		case info.Name() == "Chart.yaml":
+			charts = append(charts, filepath.ToSlash(directory))
+		case strings.HasSuffix(info.Name(), ".tf") && directory == ".":
+			hasTerraform = true
+		}
+		return nil
+	})
+	return charts, hasTerraform, err
+}
@@ -0,0 +1,233 @@
+package policy
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/config"
+)
+
+const (
+	// AllowSecretComment marks a line whose secret is intentional (e.g. a test fixture)
+	AllowSecretComment = "kettle:allow-secret"
+	// maxLeakFileSize is the size above which files (e.g. models) are not checked
+	maxLeakFileSize = 1024 * 1024
+	// minSecretLength is the length of the shortest string that is checked for entropy
+	minSecretLength = 20
+	// Strings with more entropy (bits per character) than these look random, like
+	// keys; the thresholds are lower for hex, which has fewer characters
+	base64EntropyThreshold = 4.5
+	hexEntropyThreshold    = 3.0
+)
+
+// leakSkippedDirectories are not the project's own code (e.g. dependencies),
+// or are not packaged
+var leakSkippedDirectories = map[string]bool{
+	".git":          true,
+	".kettle":       true,
+	"node_modules":  true,
+	"__pycache__":   true,
+	".venv":         true,
+	"venv":          true,
+	"site-packages": true,
+	"target":        true,
+}
+
+// leakSkippedFiles have high-entropy checksums, rather than secrets
+var leakSkippedFiles = map[string]bool{
+	"package-lock.json": true,
+	"yarn.lock":         true,
+	"pnpm-lock.yaml":    true,
+	"poetry.lock":       true,
+	"Pipfile.lock":      true,
+	"go.sum":            true,
+	"Cargo.lock":        true,
+}
+
+// redactedSecret replaces the secrets that are redacted
+const redactedSecret = "********"
+
+var (
+	quotedString = regexp.MustCompile(`["']([^"'\s]+)["']`)
+	hexString    = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+	base64String = regexp.MustCompile(`^[A-Za-z0-9+/=_-]+$`)
+	// privateKeyBlock is a whole private key, which is redacted
+	// rather than only its first line
+	privateKeyBlock = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)
+)
+
+// Leak is a line in one of the project's files that looks like it has a secret
+type Leak struct {
+	Path        string
+	Line        int
+	Description string
+}
+
+// FindLeaks checks the files that are packaged from the directory for known
+// credentials, and for random-looking strings that are assigned to names like
+// password or token; lines with a kettle:allow-secret comment are skipped
+func FindLeaks(directory string, cfg *config.Config) ([]*Leak, error) {
+	leaks := []*Leak{}
+	err := filepath.Walk(directory, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(directory, filePath)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if relativePath != "." && (leakSkippedDirectories[info.Name()] || isIgnoredPath(relativePath, cfg)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() || info.Size() > maxLeakFileSize || leakSkippedFiles[info.Name()] || isIgnoredPath(relativePath, cfg) {
+			return nil
+		}
+		fileLeaks, err := findFileLeaks(filePath, relativePath)
+		if err != nil {
+			return err
+		}
+		leaks = append(leaks, fileLeaks...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return leaks, nil
+}
+
+func findFileLeaks(filePath, relativePath string) ([]*Leak, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	// Binary files (with a NUL byte near their start) are not checked
+	head := data
+	if len(head) > 8000 {
+		head = head[:8000]
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return nil, nil
+	}
+
+	leaks := []*Leak{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLeakFileSize)
+	for number := 1; scanner.Scan(); number++ {
+		line := scanner.Text()
+		if strings.Contains(line, AllowSecretComment) {
+			continue
+		}
+		for _, description := range findLineSecrets(line) {
+			leaks = append(leaks, &Leak{
+				Path:        relativePath,
+				Line:        number,
+				Description: description,
+			})
+		}
+	}
+	return leaks, scanner.Err()
+}
+
+// findLineSecrets returns a description of each kind of secret on the line
+func findLineSecrets(line string) []string {
+	descriptions := []string{}
+	for description, pattern := range secretPatterns {
+		if pattern.MatchString(line) {
+			descriptions = append(descriptions, description)
+		}
+	}
+	if len(descriptions) > 0 {
+		sort.Strings(descriptions)
+		return descriptions
+	}
+	if !config.IsSecretName(line) {
+		return nil
+	}
+	for _, match := range quotedString.FindAllStringSubmatch(line, -1) {
+		if isRandomLooking(match[1]) {
+			return []string{"a random-looking string that is assigned to a secret"}
+		}
+	}
+	return nil
+}
+
+// RedactSecrets returns the text (e.g. a log, or a config file) with the
+// credentials that it has, and the random-looking strings that are
+// assigned to secrets, replaced
+func RedactSecrets(text string) string {
+	text = privateKeyBlock.ReplaceAllString(text, redactedSecret)
+	for _, pattern := range secretPatterns {
+		text = pattern.ReplaceAllString(text, redactedSecret)
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if !config.IsSecretName(line) {
+			continue
+		}
+		lines[i] = quotedString.ReplaceAllStringFunc(line, func(match string) string {
+			if !isRandomLooking(match[1 : len(match)-1]) {
+				return match
+			}
+			return match[:1] + redactedSecret + match[len(match)-1:]
+		})
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isRandomLooking returns true if the string is long, and its characters
+// are as varied as those of a random key
+func isRandomLooking(value string) bool {
+	if len(value) < minSecretLength {
+		return false
+	}
+	switch {
+	case hexString.MatchString(value):
+		return getEntropy(value) > hexEntropyThreshold
+	case base64String.MatchString(value):
+		return getEntropy(value) > base64EntropyThreshold
+	}
+	return false
+}
+
+// getEntropy is the Shannon entropy of the string, in bits per character
+func getEntropy(value string) float64 {
+	counts := map[rune]int{}
+	for _, r := range value {
+		counts[r]++
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(len(value))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+func isIgnoredPath(relativePath string, cfg *config.Config) bool {
+	if cfg.Config.SecretScan == nil {
+		return false
+	}
+	for _, pattern := range cfg.Config.SecretScan.Ignore {
+		pattern = strings.TrimSuffix(pattern, "/")
+		if matched, _ := filepath.Match(pattern, relativePath); matched || pattern == relativePath {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatLeak describes where a leak is, e.g. app/settings.py:12 contains an AWS access key
+func FormatLeak(leak *Leak) string {
+	return fmt.Sprintf("%s:%d contains %s", leak.Path, leak.Line, leak.Description)
+}
@@ -0,0 +1,82 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/operatorai/kettle-cli/config"
+)
+
+// Violation is a policy rule that a deployment does not pass
+type Violation struct {
+	Rule    string
+	Message string
+}
+
+// rule is a built-in policy rule, which returns a message
+// for each way that the deployment violates it
+type rule struct {
+	description string
+	check       func(cfg *config.Config) []string
+}
+
+var builtInRules = map[string]*rule{
+	"no-public-endpoints-in-prod": {
+		description: "endpoints in the prod stage must require auth",
+		check:       checkPublicEndpoints,
+	},
+	"no-inline-secrets": {
+		description: "environment variables must not contain inline secrets",
+		check:       checkInlineSecrets,
+	},
+	"approved-runtimes": {
+		description: "the runtime must be one of the policy's approved runtimes",
+		check:       checkApprovedRuntimes,
+	},
+}
+
+// Check evaluates the project's policy (its built-in rules, and any Rego
+// files) against the planned deployment, and returns the violations
+func Check(directory string, cfg *config.Config) ([]*Violation, error) {
+	if cfg.Config.Policy == nil {
+		return nil, nil
+	}
+	violations := []*Violation{}
+	for _, name := range cfg.Config.Policy.Rules {
+		rule, ok := builtInRules[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown policy rule: %s (available: %s)", name, ruleNames())
+		}
+		for _, message := range rule.check(cfg) {
+			violations = append(violations, &Violation{
+				Rule:    name,
+				Message: message,
+			})
+		}
+	}
+	for _, regoFile := range cfg.Config.Policy.Rego {
+		regoViolations, err := evaluateRego(directory, regoFile, cfg)
+		if err != nil {
+			return nil, err
+		}
+		violations = append(violations, regoViolations...)
+	}
+	return violations, nil
+}
+
+// RuleCount returns how many rules (and Rego files) the project's policy has
+func RuleCount(cfg *config.Config) int {
+	if cfg.Config.Policy == nil {
+		return 0
+	}
+	return len(cfg.Config.Policy.Rules) + len(cfg.Config.Policy.Rego)
+}
+
+func ruleNames() []string {
+	names := []string{}
+	for name := range builtInRules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,83 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+)
+
+// regoQuery is the rule that Rego policies define, as a set of messages
+const regoQuery = "data.kettle.deny"
+
+// regoInput is the planned deployment, as it is passed to Rego policies
+type regoInput struct {
+	Project string         `json:"project"`
+	Stage   string         `json:"stage"`
+	Config  *config.Config `json:"config"`
+}
+
+// evaluateRego evaluates a Rego policy file with OPA, and returns a violation
+// for each message in its deny set
+// https://www.openpolicyagent.org/docs/latest/cli/#opa-eval
+func evaluateRego(directory, regoFile string, cfg *config.Config) ([]*Violation, error) {
+	if !path.IsAbs(regoFile) {
+		regoFile = path.Join(directory, regoFile)
+	}
+	input, err := json.Marshal(&regoInput{
+		Project: cfg.GetBaseProjectName(),
+		Stage:   cfg.GetStage(),
+		Config:  cfg,
+	})
+	if err != nil {
+		return nil, err
+	}
+	inputFile, err := os.CreateTemp("", "kettle-policy-input-*.json")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(inputFile.Name())
+	if _, err := inputFile.Write(input); err != nil {
+		return nil, err
+	}
+	if err := inputFile.Close(); err != nil {
+		return nil, err
+	}
+
+	output, err := cli.ExecuteWithResult("opa", []string{
+		"eval",
+		"--format", "json",
+		"--data", regoFile,
+		"--input", inputFile.Name(),
+		regoQuery,
+	}, fmt.Sprintf("Evaluating policy: %s", path.Base(regoFile)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate %s with opa: %w", regoFile, err)
+	}
+
+	var result struct {
+		Result []struct {
+			Expressions []struct {
+				Value []string `json:"value"`
+			} `json:"expressions"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, err
+	}
+	violations := []*Violation{}
+	for _, r := range result.Result {
+		for _, expression := range r.Expressions {
+			for _, message := range expression.Value {
+				violations = append(violations, &Violation{
+					Rule:    path.Base(regoFile),
+					Message: message,
+				})
+			}
+		}
+	}
+	return violations, nil
+}
@@ -0,0 +1,76 @@
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/config"
+)
+
+// Patterns of well-known credentials
+var secretPatterns = map[string]*regexp.Regexp{
+	"an AWS access key":   regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`),
+	"a private key":       regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`),
+	"a GitHub token":      regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),
+	"a Slack token":       regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),
+	"a Stripe secret key": regexp.MustCompile(`\b[sr]k_live_[A-Za-z0-9]{16,}`),
+	"a Google API key":    regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`),
+}
+
+func checkPublicEndpoints(cfg *config.Config) []string {
+	if cfg.GetStage() != config.DefaultStage || !cfg.IsPublic() {
+		return nil
+	}
+	if cfg.Config.DeploymentType == "job" {
+		// Jobs do not have an endpoint
+		return nil
+	}
+	return []string{fmt.Sprintf(
+		`the endpoint does not require auth (set "auth" to "%s" or "%s")`,
+		config.AuthIAM, config.AuthAPIKey,
+	)}
+}
+
+func checkInlineSecrets(cfg *config.Config) []string {
+	messages := []string{}
+	for _, key := range sortedKeys(cfg.Config.Environment) {
+		value := cfg.Config.Environment[key]
+		if value == "" || config.IsSecretReference(value) {
+			// References to secrets (e.g. SSM parameters) are resolved when the function is deployed
+			continue
+		}
+		for description, pattern := range secretPatterns {
+			if pattern.MatchString(value) {
+				messages = append(messages, fmt.Sprintf("%s contains %s", key, description))
+			}
+		}
+		if config.IsSecretName(key) {
+			messages = append(messages, fmt.Sprintf("%s looks like a secret, but its value is set in kettle.json", key))
+		}
+	}
+	return messages
+}
+
+func checkApprovedRuntimes(cfg *config.Config) []string {
+	approved := cfg.Config.Policy.ApprovedRuntimes
+	if len(approved) == 0 {
+		return []string{`the policy does not list any "approved_runtimes"`}
+	}
+	for _, runtime := range approved {
+		if runtime == cfg.Config.Runtime {
+			return nil
+		}
+	}
+	return []string{fmt.Sprintf("%s is not an approved runtime (approved: %s)", cfg.Config.Runtime, strings.Join(approved, ", "))}
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
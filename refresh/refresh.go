@@ -0,0 +1,172 @@
+package refresh
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/contract"
+)
+
+// smokeTestTimeout is how long the smoke test request has to respond
+const smokeTestTimeout = 30 * time.Second
+
+// dependencyUpdate updates a package manager's dependencies, within the version
+// ranges of its manifest, and rewrites its lockfile; the manifest is not changed
+type dependencyUpdate struct {
+	lockfile string
+	commands [][]string
+}
+
+// getDependencyUpdates returns the updates for the runtime, in order
+// of preference; the first one whose lockfile exists is used
+func getDependencyUpdates(runtime string) []*dependencyUpdate {
+	switch {
+	case strings.HasPrefix(runtime, "python"):
+		return []*dependencyUpdate{
+			{"poetry.lock", [][]string{{"poetry", "update"}}},
+			{"Pipfile.lock", [][]string{{"pipenv", "update"}}},
+			{"requirements.in", [][]string{
+				{"pip-compile", "--upgrade", "--output-file", "requirements.txt", "requirements.in"},
+				{"pip", "install", "-r", "requirements.txt"},
+			}},
+		}
+	case strings.HasPrefix(runtime, "nodejs"):
+		return []*dependencyUpdate{
+			{"package-lock.json", [][]string{{"npm", "update"}}},
+			{"yarn.lock", [][]string{{"yarn", "upgrade"}}},
+			{"pnpm-lock.yaml", [][]string{{"pnpm", "update"}}},
+		}
+	case strings.HasPrefix(runtime, "go"):
+		return []*dependencyUpdate{
+			{"go.sum", [][]string{{"go", "get", "-u", "./..."}, {"go", "mod", "tidy"}}},
+		}
+	case runtime == "rust":
+		return []*dependencyUpdate{
+			{"Cargo.lock", [][]string{{"cargo", "update"}}},
+		}
+	}
+	return nil
+}
+
+// UpdateDependencies updates the project's dependencies with its "refresh"
+// update commands, or the package manager of its runtime, and returns
+// true if its lockfile changed
+func UpdateDependencies(directory string, cfg *config.Config) (bool, error) {
+	if commands := cfg.GetRefresh().Update; len(commands) > 0 {
+		for _, command := range commands {
+			if err := runCommand(command, "Updating dependencies"); err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+
+	for _, update := range getDependencyUpdates(cfg.Config.Runtime) {
+		lockfile := filepath.Join(directory, update.lockfile)
+		if update.lockfile == "requirements.in" {
+			// pip-compile writes the lockfile from requirements.in
+			lockfile = filepath.Join(directory, "requirements.txt")
+		}
+		before, err := ioutil.ReadFile(lockfile)
+		if err != nil {
+			continue
+		}
+		for _, command := range update.commands {
+			if err := cli.Execute(command[0], command[1:], fmt.Sprintf("Running %s", strings.Join(command, " "))); err != nil {
+				return false, err
+			}
+		}
+		after, err := ioutil.ReadFile(lockfile)
+		if err != nil {
+			return false, err
+		}
+		changed := !bytes.Equal(before, after)
+		if changed {
+			fmt.Println("🔄  Updated: ", filepath.Base(lockfile))
+		}
+		return changed, nil
+	}
+	return false, fmt.Errorf("the project has no lockfile that kettle can update for %s (add \"refresh\": {\"update\": [...]} to kettle.json)", cfg.Config.Runtime)
+}
+
+// RunTests runs the project's "refresh" test commands
+func RunTests(cfg *config.Config) error {
+	commands := cfg.GetRefresh().Test
+	if len(commands) == 0 {
+		fmt.Println("⏭  No tests (add \"refresh\": {\"test\": [...]} to kettle.json)")
+		return nil
+	}
+	for _, command := range commands {
+		if err := runCommand(command, "Running tests"); err != nil {
+			return fmt.Errorf("the tests failed: %s", err)
+		}
+	}
+	fmt.Println("✅  Tests passed")
+	return nil
+}
+
+func runCommand(command string, statusMessage string) error {
+	return cli.Execute("sh", []string{
+		"-c",
+		command,
+	}, fmt.Sprintf("%s: %s", statusMessage, command))
+}
+
+// RunSmokeTest sends the project's smoke test request to the deployed endpoint,
+// and returns an error if it does not respond with the expected status; projects
+// with a contract also run its contract tests
+func RunSmokeTest(projectPath, url string, cfg *config.Config) error {
+	c, err := contract.Read(projectPath, cfg)
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		c = &contract.Contract{}
+	}
+	client := &http.Client{Timeout: smokeTestTimeout}
+	for i, test := range c.Tests(cfg) {
+		if err := test.Run(client, url); err != nil {
+			return err
+		}
+		if i == 0 {
+			fmt.Println("✅  Smoke test passed: ", fmt.Sprintf("%s %s", test.Method, url+test.Path))
+			continue
+		}
+		fmt.Println("✅  Contract test passed: ", test.Name)
+	}
+	return nil
+}
+
+// WriteWorkflow writes a GitHub Actions workflow that refreshes the
+// project on its schedule, and returns the workflow's path
+func WriteWorkflow(projectPath string, cfg *config.Config) (string, error) {
+	name := cfg.BaseName
+	if name == "" {
+		name = cfg.ProjectName
+	}
+	var credentials string
+	switch cfg.Config.CloudProvider {
+	case "aws":
+		credentials = awsCredentialsStep
+	case "gcloud":
+		credentials = gcloudCredentialsStep
+	default:
+		return "", errors.New("refresh workflows are only supported on aws and gcloud")
+	}
+
+	workflowPath := filepath.Join(".github", "workflows", fmt.Sprintf("kettle-refresh-%s.yml", name))
+	if err := os.MkdirAll(filepath.Dir(workflowPath), 0755); err != nil {
+		return "", err
+	}
+	workflow := fmt.Sprintf(workflowTemplate, name, cfg.RefreshSchedule(), credentials, projectPath, name, name)
+	return workflowPath, ioutil.WriteFile(workflowPath, []byte(workflow), 0644)
+}
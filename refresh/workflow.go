@@ -0,0 +1,45 @@
+package refresh
+
+// workflowTemplate refreshes the project on a schedule (or on demand), and
+// opens a pull request with the updated lockfile once it is redeployed; it
+// authenticates with the deploy identity that kettle bootstrap-iam creates
+const workflowTemplate = `name: kettle refresh %s
+
+on:
+  schedule:
+    - cron: "%s"
+  workflow_dispatch:
+
+permissions:
+  id-token: write
+  contents: write
+  pull-requests: write
+
+jobs:
+  refresh:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/setup-go@v5
+        with:
+          go-version: stable
+      - run: go install github.com/operatorai/kettle-cli@latest
+%s
+      - run: kettle-cli refresh %s --yes
+      - uses: peter-evans/create-pull-request@v6
+        with:
+          branch: kettle-refresh/%s
+          title: "Refresh the dependencies of %s"
+          commit-message: Refresh dependencies
+`
+
+const awsCredentialsStep = `      - uses: aws-actions/configure-aws-credentials@v4
+        with:
+          role-to-assume: ${{ secrets.KETTLE_DEPLOY_ROLE_ARN }}
+          aws-region: ${{ vars.AWS_REGION }}`
+
+const gcloudCredentialsStep = `      - uses: google-github-actions/auth@v2
+        with:
+          workload_identity_provider: ${{ secrets.KETTLE_WORKLOAD_IDENTITY_PROVIDER }}
+          service_account: ${{ secrets.KETTLE_DEPLOY_SERVICE_ACCOUNT }}
+      - uses: google-github-actions/setup-gcloud@v2`
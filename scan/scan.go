@@ -0,0 +1,174 @@
+package scan
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/artifacts"
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/tempdir"
+)
+
+// Scan checks a built artifact for known vulnerabilities with grype or trivy;
+// archives are extracted first, so that the scanners see their packages
+func Scan(artifact *config.Artifact, kind string, scanner string) ([]*config.Vulnerability, error) {
+	target := artifact.Location
+	if kind == artifacts.Archive {
+		directory, err := extractArchive(artifact.Location)
+		if err != nil {
+			return nil, err
+		}
+		defer tempdir.Remove(directory)
+		target = directory
+	}
+
+	switch scanner {
+	case config.ScannerGrype:
+		return scanWithGrype(target, kind)
+	case config.ScannerTrivy:
+		return scanWithTrivy(target, kind)
+	}
+	return nil, fmt.Errorf("%s cannot scan this artifact", scanner)
+}
+
+// grype dir:<directory> -o json, or grype docker:<image> -o json
+func scanWithGrype(target string, kind string) ([]*config.Vulnerability, error) {
+	source := "dir:" + target
+	if kind == artifacts.Image {
+		source = "docker:" + target
+	}
+	output, err := cli.ExecuteWithResult("grype", []string{
+		source,
+		"-o", "json",
+	}, "Scanning for vulnerabilities with grype")
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Matches []struct {
+			Vulnerability struct {
+				ID       string `json:"id"`
+				Severity string `json:"severity"`
+				Fix      struct {
+					Versions []string `json:"versions"`
+				} `json:"fix"`
+			} `json:"vulnerability"`
+			Artifact struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"artifact"`
+		} `json:"matches"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, err
+	}
+	vulnerabilities := []*config.Vulnerability{}
+	for _, match := range result.Matches {
+		vulnerabilities = append(vulnerabilities, &config.Vulnerability{
+			ID:       match.Vulnerability.ID,
+			Package:  match.Artifact.Name,
+			Version:  match.Artifact.Version,
+			FixedIn:  strings.Join(match.Vulnerability.Fix.Versions, ", "),
+			Severity: strings.ToLower(match.Vulnerability.Severity),
+		})
+	}
+	return vulnerabilities, nil
+}
+
+// trivy fs --format json <directory>, or trivy image --format json <image>
+func scanWithTrivy(target string, kind string) ([]*config.Vulnerability, error) {
+	command := "fs"
+	if kind == artifacts.Image {
+		command = "image"
+	}
+	output, err := cli.ExecuteWithResult("trivy", []string{
+		command,
+		"--format", "json",
+		"--quiet",
+		target,
+	}, "Scanning for vulnerabilities with trivy")
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Results []struct {
+			Vulnerabilities []struct {
+				VulnerabilityID  string `json:"VulnerabilityID"`
+				PkgName          string `json:"PkgName"`
+				InstalledVersion string `json:"InstalledVersion"`
+				FixedVersion     string `json:"FixedVersion"`
+				Severity         string `json:"Severity"`
+			} `json:"Vulnerabilities"`
+		} `json:"Results"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, err
+	}
+	vulnerabilities := []*config.Vulnerability{}
+	for _, target := range result.Results {
+		for _, vulnerability := range target.Vulnerabilities {
+			vulnerabilities = append(vulnerabilities, &config.Vulnerability{
+				ID:       vulnerability.VulnerabilityID,
+				Package:  vulnerability.PkgName,
+				Version:  vulnerability.InstalledVersion,
+				FixedIn:  vulnerability.FixedVersion,
+				Severity: strings.ToLower(vulnerability.Severity),
+			})
+		}
+	}
+	return vulnerabilities, nil
+}
+
+// extractArchive extracts a deployment archive (a zip) to a temporary directory
+func extractArchive(archivePath string) (string, error) {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	directory, err := tempdir.Create("-scan")
+	if err != nil {
+		return "", err
+	}
+	for _, f := range reader.File {
+		if err := extractFile(f, directory); err != nil {
+			tempdir.Remove(directory)
+			return "", err
+		}
+	}
+	return directory, nil
+}
+
+func extractFile(f *zip.File, directory string) error {
+	// Entries must not be written outside of the directory
+	target := filepath.Join(directory, f.Name)
+	if !strings.HasPrefix(target, filepath.Clean(directory)+string(os.PathSeparator)) {
+		return fmt.Errorf("invalid path in archive: %s", f.Name)
+	}
+	if f.FileInfo().IsDir() {
+		return os.MkdirAll(target, 0755)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	source, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	destination, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer destination.Close()
+	_, err = io.Copy(destination, source)
+	return err
+}
@@ -0,0 +1,88 @@
+package settings
+
+// The REST API and execution role in the settings are the defaults that projects
+// share; a project that has its own (e.g. a team's shared API, by its rest_api_name,
+// or a role that it adopts) uses it instead while it is deployed (or destroyed),
+// but it is never written to the settings file, so that other projects keep
+// using the default
+
+// UseProjectRestApi uses the project's own REST API instead of the default
+func (aws *AWSSettings) UseProjectRestApi(restApiID string) {
+	if aws.RestApiID == restApiID {
+		return
+	}
+	if aws.sharedRestApi == nil {
+		aws.sharedRestApi = &sharedRestApi{
+			id:     aws.RestApiID,
+			rootID: aws.RestApiRootID,
+		}
+	}
+	aws.RestApiID = restApiID
+	aws.RestApiRootID = ""
+}
+
+// ForgetRestApi stops using a REST API that has been deleted: a project's
+// own API is replaced by the default, and the default is unset if it was deleted
+func (aws *AWSSettings) ForgetRestApi(restApiID string) {
+	if aws.sharedRestApi != nil && aws.sharedRestApi.id == restApiID {
+		aws.sharedRestApi = &sharedRestApi{}
+	}
+	if aws.RestApiID != restApiID {
+		return
+	}
+	if aws.sharedRestApi != nil {
+		aws.RestApiID = aws.sharedRestApi.id
+		aws.RestApiRootID = aws.sharedRestApi.rootID
+		aws.sharedRestApi = nil
+		return
+	}
+	aws.RestApiID = ""
+	aws.RestApiRootID = ""
+}
+
+// UseProjectRole uses the project's own execution role instead of the default
+func (aws *AWSSettings) UseProjectRole(roleArn string) {
+	if aws.RoleArn == roleArn {
+		return
+	}
+	if aws.sharedRoleArn == nil {
+		sharedRoleArn := aws.RoleArn
+		aws.sharedRoleArn = &sharedRoleArn
+	}
+	aws.RoleArn = roleArn
+}
+
+// ForgetRole stops using an execution role that has been deleted, like ForgetRestApi
+func (aws *AWSSettings) ForgetRole(roleArn string) {
+	if aws.sharedRoleArn != nil && *aws.sharedRoleArn == roleArn {
+		*aws.sharedRoleArn = ""
+	}
+	if aws.RoleArn != roleArn {
+		return
+	}
+	if aws.sharedRoleArn != nil {
+		aws.RoleArn = *aws.sharedRoleArn
+		aws.sharedRoleArn = nil
+		return
+	}
+	aws.RoleArn = ""
+}
+
+// shared returns the settings with the defaults that
+// the project's own resources replaced, to be written
+func (aws *AWSSettings) shared() *AWSSettings {
+	if aws.sharedRestApi == nil && aws.sharedRoleArn == nil {
+		return aws
+	}
+	written := *aws
+	if aws.sharedRestApi != nil {
+		written.RestApiID = aws.sharedRestApi.id
+		written.RestApiRootID = aws.sharedRestApi.rootID
+		written.sharedRestApi = nil
+	}
+	if aws.sharedRoleArn != nil {
+		written.RoleArn = *aws.sharedRoleArn
+		written.sharedRoleArn = nil
+	}
+	return &written
+}
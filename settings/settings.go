@@ -1,6 +1,7 @@
 package settings
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
@@ -9,16 +10,21 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
-func getSettingsFilePath() (string, error) {
+// GetSettingsFilePath returns the path of the user's settings
+// (~/.kettle.yaml), or of the stage's settings if a stage is set
+func GetSettingsFilePath() (string, error) {
 	home, err := homedir.Dir()
 	if err != nil {
 		return "", err
 	}
+	if Stage != "" {
+		return path.Join(home, fmt.Sprintf(".kettle-%s.yaml", Stage)), nil
+	}
 	return path.Join(home, ".kettle.yaml"), nil
 }
 
 func ReadSettings() (*Settings, error) {
-	settingsFile, err := getSettingsFilePath()
+	settingsFile, err := GetSettingsFilePath()
 	if err != nil {
 		return nil, err
 	}
@@ -39,11 +45,21 @@ func ReadSettings() (*Settings, error) {
 	return stg, nil
 }
 
+// WriteSettings writes the settings to the settings file; the resources that
+// the project that is deployed uses instead of the defaults are not written
 func WriteSettings(stg *Settings) error {
-	settingsFile, err := getSettingsFilePath()
+	if SharedFile {
+		return nil
+	}
+	settingsFile, err := GetSettingsFilePath()
 	if err != nil {
 		return err
 	}
+	if stg.AWS != nil {
+		written := *stg
+		written.AWS = stg.AWS.shared()
+		stg = &written
+	}
 
 	data, err := yaml.Marshal(stg)
 	if err != nil {
@@ -1,8 +1,35 @@
 package settings
 
+import (
+	"github.com/operatorai/kettle-cli/config"
+)
+
 // Debug mode (kettle <command> --debug)
 var DebugMode bool
 
+// Region overrides the deployment region (kettle <command> --region <region>)
+var Region string
+
+// Stage is set when a stage deploys to its own account (or project),
+// so that its settings are stored separately (kettle <command> --stage <stage>)
+var Stage string
+
+// AirGapped is set when kettle runs without internet access: templates are
+// only read from local paths and bundles (kettle <command> --air-gapped)
+var AirGapped bool
+
+// SharedFile is set when the settings file is shared with deploys that run at
+// the same time (e.g. by kettle deploy --all), so it is read but not written
+var SharedFile bool
+
+// AWSCli is set when kettle calls the AWS APIs that deploys use (Lambda, API
+// Gateway, IAM, and STS) with the aws cli, instead of the AWS SDK (kettle <command> --aws-cli)
+var AWSCli bool
+
+// TemplateBundle is a template bundle (.tar.gz) that templates are looked
+// up in by name, instead of in the kettle-templates repository
+var TemplateBundle string
+
 // Settings are values that do not change across multiple deployments
 // and are therefore stored in a settings file
 
@@ -10,17 +37,102 @@ type GoogleCloudSettings struct {
 	ProjectName      string `yaml:"project_name,omitempty"`
 	ProjectID        string `yaml:"project_id,omitempty"`
 	DeploymentRegion string `yaml:"region,omitempty"`
+	// Endpoints override the API endpoints of services, by service (e.g. run)
+	Endpoints map[string]string `yaml:"endpoints,omitempty"`
 }
 
 type AWSSettings struct {
 	AccountID        string `yaml:"account_id,omitempty"`
 	RoleArn          string `yaml:"role_arn,omitempty"`
+	SageMakerRoleArn string `yaml:"sagemaker_role_arn,omitempty"`
+	CanaryRoleArn    string `yaml:"canary_role_arn,omitempty"`
 	RestApiID        string `yaml:"rest_api_id,omitempty"`
 	RestApiRootID    string `yaml:"rest_api_root_id,omitempty"`
 	DeploymentRegion string `yaml:"region,omitempty"`
+	// Endpoints override the endpoints of services, by service (e.g. lambda),
+	// or of all services (default), e.g. for private endpoints or GovCloud
+	Endpoints map[string]string `yaml:"endpoints,omitempty"`
+	// sharedRestApi and sharedRoleArn are the default REST API and execution
+	// role, while a project uses its own instead (they are written to the
+	// settings file in their place)
+	sharedRestApi *sharedRestApi
+	sharedRoleArn *string
+}
+
+type sharedRestApi struct {
+	id     string
+	rootID string
 }
 
 type Settings struct {
 	GoogleCloud *GoogleCloudSettings `yaml:"gcloud,omitempty"`
 	AWS         *AWSSettings         `yaml:"aws,omitempty"`
+	Naming      *config.Naming       `yaml:"naming,omitempty"`
+	// Accessible enables the screen-reader-friendly prompts and output
+	Accessible bool `yaml:"accessible,omitempty"`
+	// CABundle is a PEM file of CA certificates to trust, e.g. in a
+	// corporate network whose proxy intercepts TLS
+	CABundle string `yaml:"ca_bundle,omitempty"`
+	// AirGapped and TemplateBundle set the air-gapped mode, and
+	// the bundle that templates are looked up in
+	AirGapped      bool   `yaml:"air_gapped,omitempty"`
+	TemplateBundle string `yaml:"template_bundle,omitempty"`
+	// AWSCli calls the AWS APIs that deploys use with the aws cli, instead of the AWS SDK
+	AWSCli bool `yaml:"aws_cli,omitempty"`
+	// ReadOnly refuses all of the commands that change resources (e.g. for
+	// auditors); it cannot be turned off with the --read-only flag
+	ReadOnly bool `yaml:"read_only,omitempty"`
+	// ValueProviders look up the values of templates' prompts (e.g. a team
+	// name, or a cost center) before they are asked for, in order
+	ValueProviders []*ValueProvider `yaml:"value_providers,omitempty"`
+	// Vault is the HashiCorp Vault server that secrets are read from
+	Vault *VaultSettings `yaml:"vault,omitempty"`
+	// Funcs are custom functions that templates can call (e.g. costcenter),
+	// by name, which are implemented by commands
+	Funcs map[string]*FuncPlugin `yaml:"funcs,omitempty"`
+}
+
+// FuncPlugin is a template function that runs a command (e.g. a script that
+// an organisation shares) with the function's arguments, and returns its output
+
+type FuncPlugin struct {
+	Command []string `yaml:"command"`
+}
+
+// VaultSettings are how kettle logs in to Vault; the vault cli's own
+// settings (e.g. VAULT_ADDR and VAULT_TOKEN) are used if they are not set
+
+type VaultSettings struct {
+	Address   string `yaml:"address,omitempty"`
+	Namespace string `yaml:"namespace,omitempty"`
+	// token (the default), approle, or oidc
+	Auth string `yaml:"auth,omitempty"`
+	// The path that the auth method is mounted at (its name by default)
+	Mount string `yaml:"mount,omitempty"`
+	// The role to log in with (oidc), or the role ID (approle)
+	Role string `yaml:"role,omitempty"`
+	// The environment variable with the AppRole's secret ID (VAULT_SECRET_ID by default)
+	SecretIDEnv string `yaml:"secret_id_env,omitempty"`
+}
+
+// ValueProvider is a source of the values of templates' prompts, by their key
+
+type ValueProvider struct {
+	// env, file, ssm, secretsmanager, vault, or http
+	Type string `yaml:"type"`
+	// The keys that the provider has (all keys by default)
+	Keys []string `yaml:"keys,omitempty"`
+	// The prefix of environment variables (env, KETTLE_VALUE_ by default),
+	// or of parameter names (ssm, e.g. /platform/kettle/)
+	Prefix string `yaml:"prefix,omitempty"`
+	// A YAML file of values (file), or the path of a secret (vault)
+	Path string `yaml:"path,omitempty"`
+	// A secret whose value is a JSON object of values (secretsmanager)
+	SecretID string `yaml:"secret_id,omitempty"`
+	// The region of the parameters or secret (ssm and secretsmanager)
+	Region string `yaml:"region,omitempty"`
+	// An endpoint that returns a JSON object of values (http), and an
+	// environment variable with a bearer token to send to it
+	URL      string `yaml:"url,omitempty"`
+	TokenEnv string `yaml:"token_env,omitempty"`
 }
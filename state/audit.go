@@ -0,0 +1,46 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"time"
+)
+
+const auditLogFileName = "audit.log"
+
+// AuditEntry records a command that kettle ran on behalf of
+// someone, outside of the operations that it models
+type AuditEntry struct {
+	Time     time.Time `json:"time"`
+	User     string    `json:"user"`
+	Stage    string    `json:"stage,omitempty"`
+	Region   string    `json:"region,omitempty"`
+	Command  string    `json:"command"`
+	Args     []string  `json:"args"`
+	ExitCode int       `json:"exit_code"`
+}
+
+// GetAuditLogPath returns the path of the project's audit log,
+// which is shared by all of its stages
+func GetAuditLogPath(projectPath string) string {
+	return path.Join(projectPath, stateDirectory, auditLogFileName)
+}
+
+// AppendAuditLog adds an entry (as a line of JSON) to the project's audit log
+func AppendAuditLog(projectPath string, entry *AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(path.Join(projectPath, stateDirectory), os.ModePerm); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(GetAuditLogPath(projectPath), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,257 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+)
+
+// getStateDirectory returns the directory of the current stage's state
+func getStateDirectory(projectPath string) string {
+	if Stage == "" {
+		return path.Join(projectPath, stateDirectory)
+	}
+	return path.Join(projectPath, stateDirectory, stagesDirectory, Stage)
+}
+
+// GetStatePath returns the path of the current stage's state file
+func GetStatePath(projectPath string) string {
+	return path.Join(getStateDirectory(projectPath), stateFileName)
+}
+
+func ReadState(projectPath string) (*State, error) {
+	statePath := GetStatePath(projectPath)
+	if _, err := os.Stat(statePath); os.IsNotExist(err) {
+		// Return empty state
+		return &State{}, nil
+	}
+
+	data, err := ioutil.ReadFile(statePath)
+	if err != nil {
+		return nil, err
+	}
+
+	st := &State{}
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+func WriteState(projectPath string, st *State) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	stateDirectoryPath := getStateDirectory(projectPath)
+	if err := os.MkdirAll(stateDirectoryPath, os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path.Join(stateDirectoryPath, stateFileName), data, 0644)
+}
+
+// ReadDefaultStageState reads the state of the project's default stage,
+// e.g. so that a preview can be deployed alongside it
+func ReadDefaultStageState(projectPath string) (*State, error) {
+	stage := Stage
+	Stage = ""
+	defer func() {
+		Stage = stage
+	}()
+	return ReadState(projectPath)
+}
+
+// DeleteStageState removes the state of the current preview stage,
+// once its resources have been destroyed
+func DeleteStageState(projectPath string) error {
+	if Stage == "" {
+		return nil
+	}
+	return os.RemoveAll(getStateDirectory(projectPath))
+}
+
+// AddResource records a resource, replacing any existing
+// resource with the same type and ID
+func (st *State) AddResource(resourceType, id, arn string) *Resource {
+	if resource := st.GetResource(resourceType, id); resource != nil {
+		resource.Arn = arn
+		return resource
+	}
+	resource := &Resource{
+		Type: resourceType,
+		ID:   id,
+		Arn:  arn,
+	}
+	st.Resources = append(st.Resources, resource)
+	return resource
+}
+
+func (st *State) GetResource(resourceType, id string) *Resource {
+	for _, resource := range st.Resources {
+		if resource.Type == resourceType && resource.ID == id {
+			return resource
+		}
+	}
+	return nil
+}
+
+func (st *State) GetResources(resourceType string) []*Resource {
+	resources := []*Resource{}
+	for _, resource := range st.Resources {
+		if resource.Type == resourceType {
+			resources = append(resources, resource)
+		}
+	}
+	return resources
+}
+
+func (st *State) RemoveResource(resourceType, id string) {
+	resources := []*Resource{}
+	for _, resource := range st.Resources {
+		if resource.Type != resourceType || resource.ID != id {
+			resources = append(resources, resource)
+		}
+	}
+	st.Resources = resources
+}
+
+// PlanDestroy splits the resources into the ones that are deleted, in the given
+// order of resource types, and the ones that are not (e.g. adopted ones, and the
+// ones that are protected by the state, or by the types or IDs in protected)
+func (st *State) PlanDestroy(order []string, protected []string) ([]*Resource, []*Resource) {
+	deleted := []*Resource{}
+	for _, resourceType := range order {
+		for _, resource := range st.GetResources(resourceType) {
+			if !resource.Adopted && !resource.IsProtected(protected) {
+				deleted = append(deleted, resource)
+			}
+		}
+	}
+	kept := []*Resource{}
+	for _, resource := range st.Resources {
+		if !containsResource(deleted, resource) {
+			kept = append(kept, resource)
+		}
+	}
+	return deleted, kept
+}
+
+// IsProtected returns true if the resource is protected in the state,
+// or if its type or ID is one of the protected types or IDs
+func (r *Resource) IsProtected(protected []string) bool {
+	if r.Protected {
+		return true
+	}
+	for _, value := range protected {
+		if value == r.Type || value == r.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// Describe returns the resource's type and ID, and why it is kept
+// when the project is destroyed (if it is protected or adopted)
+func (r *Resource) Describe(protected []string) string {
+	description := fmt.Sprintf("%s %s", r.Type, r.ID)
+	switch {
+	case r.IsProtected(protected):
+		description += " (protected)"
+	case r.Adopted:
+		description += " (adopted)"
+	}
+	return description
+}
+
+// HasProtectedResources returns true if any of the resources are protected
+func (st *State) HasProtectedResources(protected []string) bool {
+	for _, resource := range st.Resources {
+		if resource.IsProtected(protected) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsResource(resources []*Resource, resource *Resource) bool {
+	for _, r := range resources {
+		if r == resource {
+			return true
+		}
+	}
+	return false
+}
+
+func (st *State) AddDeployment(codeVersion, modelVersion string) *Deployment {
+	deployment := &Deployment{
+		Time:         time.Now().UTC().Format(time.RFC3339),
+		CodeVersion:  codeVersion,
+		ModelVersion: modelVersion,
+	}
+	st.Deployments = append(st.Deployments, deployment)
+	return deployment
+}
+
+// GetLastDeployment returns the most recent deployment, or nil
+// if the project has not been deployed
+func (st *State) GetLastDeployment() *Deployment {
+	if len(st.Deployments) == 0 {
+		return nil
+	}
+	return st.Deployments[len(st.Deployments)-1]
+}
+
+// GetExpiry returns when the deployment expires, or a zero
+// time if it was not deployed with a TTL
+func (st *State) GetExpiry() (time.Time, error) {
+	if st.Expires == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, st.Expires)
+}
+
+// SetExpiry records when the deployment expires
+func (st *State) SetExpiry(expires time.Time) {
+	st.Expires = expires.UTC().Format(time.RFC3339)
+}
+
+// HasExpired returns true if the deployment has a TTL which has passed
+func (st *State) HasExpired() bool {
+	expires, err := st.GetExpiry()
+	if err != nil || expires.IsZero() {
+		return false
+	}
+	return time.Now().After(expires)
+}
+
+// Retire moves resources to the state's retiring resources, which are
+// kept under the project's previous name until they can be deleted
+func (st *State) Retire(name string, after time.Time, resources []*Resource) {
+	st.Retiring = &Retiring{
+		Name:      name,
+		After:     after.UTC().Format(time.RFC3339),
+		Resources: resources,
+	}
+	for _, resource := range resources {
+		st.RemoveResource(resource.Type, resource.ID)
+	}
+}
+
+// GetAfter returns when the retiring resources can be deleted
+func (r *Retiring) GetAfter() (time.Time, error) {
+	return time.Parse(time.RFC3339, r.After)
+}
+
+// GetResource returns the retiring resource with a type, or nil if there is none
+func (r *Retiring) GetResource(resourceType string) *Resource {
+	for _, resource := range r.Resources {
+		if resource.Type == resourceType {
+			return resource
+		}
+	}
+	return nil
+}
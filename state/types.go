@@ -0,0 +1,114 @@
+package state
+
+const (
+	stateDirectory  = ".kettle"
+	stateFileName   = "state.json"
+	stagesDirectory = "stages"
+)
+
+// Stage is the preview stage whose state is read and written; the state
+// of each preview is kept separately from the project's default stage
+var Stage string
+
+// Resource types that kettle tracks
+const (
+	AWSLambdaFunction     = "aws:lambda-function"
+	AWSIAMRole            = "aws:iam-role"
+	AWSRestApi            = "aws:rest-api"
+	AWSRestApiResource    = "aws:rest-api-resource"
+	AWSLambdaPermission   = "aws:lambda-permission"
+	AWSLambdaAlias        = "aws:lambda-alias"
+	AWSApiDomain          = "aws:api-domain"
+	AWSApiBasePathMapping = "aws:api-base-path-mapping"
+	AWSEventsRule         = "aws:events-rule"
+	AWSS3Notification     = "aws:s3-notification"
+	AWSECRRepository      = "aws:ecr-repository"
+	AWSSageMakerModel     = "aws:sagemaker-model"
+	AWSSageMakerConfig    = "aws:sagemaker-endpoint-config"
+	AWSSageMakerEndpoint  = "aws:sagemaker-endpoint"
+	AWSSQSQueue           = "aws:sqs-queue"
+	AWSEventSourceMapping = "aws:event-source-mapping"
+	AWSS3Bucket           = "aws:s3-bucket"
+	AWSCloudFrontOAC      = "aws:cloudfront-origin-access-control"
+	AWSCloudFront         = "aws:cloudfront-distribution"
+	AWSIAMRolePolicy      = "aws:iam-role-policy"
+	AWSDynamoDBTable      = "aws:dynamodb-table"
+	AWSAuroraCluster      = "aws:aurora-cluster"
+	AWSAuroraInstance     = "aws:aurora-instance"
+	AWSElastiCache        = "aws:elasticache-serverless-cache"
+	AWSSecurityGroup      = "aws:security-group"
+	AWSSyntheticsCanary   = "aws:synthetics-canary"
+	AWSCloudWatchAlarm    = "aws:cloudwatch-alarm"
+	AWSLogMetricFilter    = "aws:log-metric-filter"
+	AWSSNSTopic           = "aws:sns-topic"
+	AWSBudget             = "aws:budget"
+	AWSSSMParameter       = "aws:ssm-parameter"
+	AWSSecret             = "aws:secretsmanager-secret"
+	GoogleCloudFunction   = "gcloud:function"
+	GoogleCloudRunService = "gcloud:run-service"
+	GoogleCloudRunJob     = "gcloud:run-job"
+	GoogleFirestore       = "gcloud:firestore-database"
+	GoogleStorageBucket   = "gcloud:storage-bucket"
+	GoogleBackendBucket   = "gcloud:backend-bucket"
+	GoogleURLMap          = "gcloud:url-map"
+	GoogleHTTPProxy       = "gcloud:target-http-proxy"
+	GoogleForwardingRule  = "gcloud:forwarding-rule"
+	GoogleRedis           = "gcloud:redis-instance"
+	GoogleUptimeCheck     = "gcloud:uptime-check"
+	GoogleAlertPolicy     = "gcloud:alert-policy"
+	GoogleNotification    = "gcloud:notification-channel"
+	GoogleBudget          = "gcloud:billing-budget"
+	GoogleSecret          = "gcloud:secret"
+)
+
+// State records the cloud resources that kettle manages for a project,
+// and is therefore stored alongside the project's config file
+
+type State struct {
+	Resources   []*Resource   `json:"resources"`
+	Deployments []*Deployment `json:"deployments,omitempty"`
+	// When the deployment can be pruned, in RFC3339 format
+	Expires string `json:"expires,omitempty"`
+	// The region that the stage is deployed to
+	Region string `json:"region,omitempty"`
+	// The outputs of the last deploy (e.g. url, arn), which other
+	// projects refer to as ${kettle:<project>.<output>}
+	Outputs map[string]string `json:"outputs,omitempty"`
+	// The resources of the project's previous name, after it is renamed
+	Retiring *Retiring `json:"retiring,omitempty"`
+}
+
+// Resources that are created together (e.g. a queue and its consumer)
+// share a group, and are destroyed as one unit
+type Resource struct {
+	Type  string `json:"type"`
+	ID    string `json:"id"`
+	Arn   string `json:"arn,omitempty"`
+	Group string `json:"group,omitempty"`
+	// Adopted resources existed before the project, and are never deleted
+	Adopted bool `json:"adopted,omitempty"`
+	// Protected resources are not deleted when the project is destroyed
+	Protected bool `json:"protected,omitempty"`
+}
+
+// Retiring records the resources of a project's previous name, which still
+// serve requests (e.g. its API path) until they are deleted
+
+type Retiring struct {
+	Name string `json:"name"`
+	// When the resources can be deleted, in RFC3339 format
+	After     string      `json:"after"`
+	Resources []*Resource `json:"resources"`
+}
+
+// Deployment is an entry in the project's deploy history
+type Deployment struct {
+	Time         string `json:"time"`
+	CodeVersion  string `json:"code_version,omitempty"`
+	ModelVersion string `json:"model_version,omitempty"`
+	// The digest (and artifact store version) of the deployed archive or image,
+	// and the stage that it was promoted from (if it was not built for this stage)
+	Artifact        string `json:"artifact,omitempty"`
+	ArtifactVersion string `json:"artifact_version,omitempty"`
+	PromotedFrom    string `json:"promoted_from,omitempty"`
+}
@@ -0,0 +1,212 @@
+// Package tempdir creates the temporary directories that kettle works in (e.g.
+// template clones, extracted bundles, and scanned archives), and records each
+// of them in a manifest, so that the ones that a run leaves behind (e.g. as it
+// was interrupted while cloning a template) are removed by a later run
+package tempdir
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+const (
+	// Prefix is the prefix of the names of kettle's temporary directories
+	Prefix = "kettle"
+	// UntrackedAge is how old a temporary directory that is not in the manifest
+	// (e.g. from a version of kettle that did not track them) must be to be removed
+	UntrackedAge = 24 * time.Hour
+	// staleAge is how old a directory in the manifest must be to be removed, even if
+	// the process that created it seems to be running (as process IDs are reused)
+	staleAge = 72 * time.Hour
+)
+
+// entry is a directory in the manifest, which has a file for each directory
+// (so that concurrent runs do not change the same file), named by its hash
+type entry struct {
+	Path    string    `json:"path"`
+	PID     int       `json:"pid"`
+	Created time.Time `json:"created"`
+}
+
+// Create creates a temporary directory, whose name starts with kettle and the
+// suffix (e.g. -templates), and records it in the manifest
+func Create(suffix string) (string, error) {
+	directory, err := ioutil.TempDir("", Prefix+suffix)
+	if err != nil {
+		return "", err
+	}
+	manifestPath, err := getEntryPath(directory)
+	if err != nil {
+		// The manifest is best effort: untracked directories are removed by kettle clean
+		return directory, nil
+	}
+	data, err := json.Marshal(&entry{
+		Path:    directory,
+		PID:     os.Getpid(),
+		Created: time.Now(),
+	})
+	if err == nil && os.MkdirAll(path.Dir(manifestPath), 0700) == nil {
+		ioutil.WriteFile(manifestPath, data, 0600)
+	}
+	return directory, nil
+}
+
+// Remove removes the temporary directory that has the path (its root, if the
+// path is in a directory, e.g. a template in a clone), and its manifest entry;
+// paths that are not in a temporary directory are removed as they are
+func Remove(directory string) error {
+	entries, err := readEntries()
+	if err != nil {
+		return os.RemoveAll(directory)
+	}
+	for entryPath, e := range entries {
+		if isInDirectory(directory, e.Path) {
+			err := os.RemoveAll(e.Path)
+			os.Remove(entryPath)
+			return err
+		}
+	}
+	return os.RemoveAll(directory)
+}
+
+// RemoveLeftovers removes the temporary directories in the manifest whose run
+// has ended (e.g. as it crashed, or was interrupted), and returns their paths
+func RemoveLeftovers(dryRun bool) ([]string, error) {
+	entries, err := readEntries()
+	if err != nil {
+		return nil, err
+	}
+	removed := []string{}
+	for entryPath, e := range entries {
+		if isRunning(e.PID) && time.Since(e.Created) < staleAge {
+			continue
+		}
+		if _, err := os.Stat(e.Path); err == nil {
+			removed = append(removed, e.Path)
+		}
+		if dryRun {
+			continue
+		}
+		if err := os.RemoveAll(e.Path); err != nil {
+			return removed, err
+		}
+		os.Remove(entryPath)
+	}
+	return removed, nil
+}
+
+// RemoveUntracked removes kettle's temporary directories that are not in
+// the manifest (and are older than UntrackedAge), and returns their paths
+func RemoveUntracked(dryRun bool) ([]string, error) {
+	entries, err := readEntries()
+	if err != nil {
+		return nil, err
+	}
+	tracked := map[string]bool{}
+	for _, e := range entries {
+		tracked[e.Path] = true
+	}
+	infos, err := ioutil.ReadDir(os.TempDir())
+	if err != nil {
+		return nil, err
+	}
+	removed := []string{}
+	for _, info := range infos {
+		directory := filepath.Join(os.TempDir(), info.Name())
+		if !info.IsDir() || !strings.HasPrefix(info.Name(), Prefix) || tracked[directory] {
+			continue
+		}
+		if time.Since(info.ModTime()) < UntrackedAge {
+			continue
+		}
+		removed = append(removed, directory)
+		if dryRun {
+			continue
+		}
+		if err := os.RemoveAll(directory); err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+func getManifestDirectory() (string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(home, ".kettle", "temp"), nil
+}
+
+func getEntryPath(directory string) (string, error) {
+	manifestDirectory, err := getManifestDirectory()
+	if err != nil {
+		return "", err
+	}
+	hash := sha1.Sum([]byte(directory))
+	return path.Join(manifestDirectory, hex.EncodeToString(hash[:])+".json"), nil
+}
+
+// readEntries returns the manifest's entries, by the path of their file;
+// entries that cannot be read are skipped
+func readEntries() (map[string]*entry, error) {
+	manifestDirectory, err := getManifestDirectory()
+	if err != nil {
+		return nil, err
+	}
+	infos, err := ioutil.ReadDir(manifestDirectory)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]*entry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	entries := map[string]*entry{}
+	for _, info := range infos {
+		entryPath := path.Join(manifestDirectory, info.Name())
+		data, err := ioutil.ReadFile(entryPath)
+		if err != nil {
+			continue
+		}
+		e := &entry{}
+		if err := json.Unmarshal(data, e); err != nil || e.Path == "" {
+			continue
+		}
+		entries[entryPath] = e
+	}
+	return entries, nil
+}
+
+func isInDirectory(filePath, directory string) bool {
+	relative, err := filepath.Rel(directory, filePath)
+	return err == nil && relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator))
+}
+
+// isRunning returns whether a process is running
+func isRunning(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	defer process.Release()
+	if runtime.GOOS == "windows" {
+		// On Windows, processes are only found if they are running
+		return true
+	}
+	err = process.Signal(syscall.Signal(0))
+	// A process of another user cannot be signalled, but is running
+	return err == nil || errors.Is(err, os.ErrPermission)
+}
@@ -0,0 +1,149 @@
+package templates
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/tempdir"
+)
+
+// IsBundle returns true if the template is a bundle: a .tar.gz of a
+// template, or of a directory of templates (e.g. kettle-templates)
+func IsBundle(templatePath string) bool {
+	return strings.HasSuffix(templatePath, ".tar.gz") || strings.HasSuffix(templatePath, ".tgz")
+}
+
+// extractBundle extracts the bundle to a temporary directory, and returns
+// the template at its root (or in its only top-level directory)
+func extractBundle(bundlePath string) (string, error) {
+	tempDirectory, err := unpackBundle(bundlePath)
+	if err != nil {
+		return "", err
+	}
+	for _, candidate := range []string{tempDirectory, getOnlyDirectory(tempDirectory)} {
+		if exists, _ := config.HasConfigFile(candidate); exists {
+			return candidate, nil
+		}
+	}
+	tempdir.Remove(tempDirectory)
+	return "", fmt.Errorf("the bundle does not have a template (a kettle.json) at its root: %s", bundlePath)
+}
+
+// searchBundle looks for a template by name in a bundle of templates
+func searchBundle(bundlePath, templateName string) (string, error) {
+	tempDirectory, err := unpackBundle(bundlePath)
+	if err != nil {
+		return "", err
+	}
+	for _, root := range []string{tempDirectory, getOnlyDirectory(tempDirectory)} {
+		candidate := path.Join(root, templateName)
+		if exists, _ := config.HasConfigFile(candidate); exists {
+			return candidate, nil
+		}
+	}
+	tempdir.Remove(tempDirectory)
+	return "", fmt.Errorf("template %s is not in the bundle: %s", templateName, bundlePath)
+}
+
+// ValidateBundle checks that a bundle can be read, and
+// returns how many templates (kettle.json files) it has
+func ValidateBundle(bundlePath string) (int, error) {
+	f, err := os.Open(bundlePath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return 0, fmt.Errorf("the bundle is not a .tar.gz: %s", err)
+	}
+	archive := tar.NewReader(gz)
+	count := 0
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		if path.Base(header.Name) == "kettle.json" {
+			count++
+		}
+	}
+}
+
+func unpackBundle(bundlePath string) (string, error) {
+	f, err := os.Open(bundlePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return "", fmt.Errorf("the bundle is not a .tar.gz: %s", err)
+	}
+	tempDirectory, err := tempdir.Create("-bundle")
+	if err != nil {
+		return "", err
+	}
+	if err := unpackArchive(tar.NewReader(gz), tempDirectory); err != nil {
+		tempdir.Remove(tempDirectory)
+		return "", err
+	}
+	return tempDirectory, nil
+}
+
+func unpackArchive(archive *tar.Reader, directory string) error {
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// Entries must not be written outside of the directory
+		target := filepath.Join(directory, header.Name)
+		if !strings.HasPrefix(target, filepath.Clean(directory)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path in bundle: %s", header.Name)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode)&0755|0600)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, archive)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// getOnlyDirectory returns the directory's only subdirectory,
+// e.g. the kettle-templates directory of a bundle
+func getOnlyDirectory(directory string) string {
+	entries, err := ioutil.ReadDir(directory)
+	if err != nil || len(entries) != 1 || !entries[0].IsDir() {
+		return directory
+	}
+	return path.Join(directory, entries[0].Name())
+}
@@ -0,0 +1,187 @@
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/tempdir"
+)
+
+// IndexFileName is the index of a directory (or bundle) of templates, which
+// kettle search reads instead of every template's kettle.json
+const IndexFileName = "kettle-index.json"
+
+// Entry is a template in a directory of templates
+
+type Entry struct {
+	Name           string                   `json:"name"`
+	Runtime        string                   `json:"runtime,omitempty"`
+	CloudProvider  string                   `json:"cloud_provider,omitempty"`
+	DeploymentType string                   `json:"deployment_type,omitempty"`
+	Metadata       *config.TemplateMetadata `json:"metadata,omitempty"`
+}
+
+// Index lists the templates of a registry (e.g. an internal kettle-templates)
+
+type Index struct {
+	Templates []*Entry `json:"templates"`
+}
+
+// OpenCatalog returns a directory of templates: a local directory, a bundle, a git
+// repository, or (by default) the template bundle or the kettle-templates repository;
+// the returned function removes any directory that was created
+func OpenCatalog(source string) (string, func(), error) {
+	keep := func() {}
+	if source == "" {
+		source = settings.TemplateBundle
+	}
+	switch {
+	case source != "" && IsBundle(source):
+		directory, err := unpackBundle(source)
+		if err != nil {
+			return "", keep, err
+		}
+		return getOnlyDirectory(directory), func() { tempdir.Remove(directory) }, nil
+	case source != "" && !isGitRepository(source):
+		return source, keep, nil
+	case settings.AirGapped:
+		return "", keep, fmt.Errorf("cannot clone the templates in air-gapped mode (use a local path or a template bundle)")
+	}
+	if source == "" {
+		source = "https://github.com/operatorai/kettle-templates"
+	}
+	directory, err := tempdir.Create("-templates")
+	if err != nil {
+		return "", keep, err
+	}
+	remove := func() { tempdir.Remove(directory) }
+	if err := cli.Execute("git", []string{
+		"clone",
+		"--depth", "1",
+		source,
+		directory,
+	}, "Cloning templates"); err != nil {
+		remove()
+		return "", keep, err
+	}
+	return directory, remove, nil
+}
+
+// ListTemplates returns the templates in a directory, from its index
+// if it has one, or else from the kettle.json of each template
+func ListTemplates(directory string) ([]*Entry, error) {
+	data, err := ioutil.ReadFile(path.Join(directory, IndexFileName))
+	if err == nil {
+		index := &Index{}
+		if err := json.Unmarshal(data, index); err != nil {
+			return nil, fmt.Errorf("cannot read %s: %s", IndexFileName, err)
+		}
+		return index.Templates, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	entries := []*Entry{}
+	err = filepath.Walk(directory, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" || info.Name() == "node_modules" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Name() != "kettle.json" {
+			return nil
+		}
+		templatePath := filepath.Dir(filePath)
+		cfg, err := config.ReadConfig(templatePath)
+		if err != nil {
+			// Templates with placeholders in their kettle.json may not parse
+			if settings.DebugMode {
+				fmt.Println(fmt.Sprintf("cannot read %s: %s", filePath, err))
+			}
+			return nil
+		}
+		name, err := filepath.Rel(directory, templatePath)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, &Entry{
+			Name:           filepath.ToSlash(name),
+			Runtime:        cfg.Config.Runtime,
+			CloudProvider:  cfg.Config.CloudProvider,
+			DeploymentType: cfg.Config.DeploymentType,
+			Metadata:       cfg.Metadata,
+		})
+		// The templates of a template are not listed
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+	return entries, nil
+}
+
+// WriteIndex writes the index of the templates in a directory, and
+// returns how many templates it has; an existing index is rebuilt
+func WriteIndex(directory string) (int, error) {
+	indexPath := path.Join(directory, IndexFileName)
+	if err := os.Remove(indexPath); err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+	entries, err := ListTemplates(directory)
+	if err != nil {
+		return 0, err
+	}
+	data, err := json.MarshalIndent(&Index{Templates: entries}, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	return len(entries), ioutil.WriteFile(indexPath, data, 0644)
+}
+
+// Matches returns true if the template matches the query (in its name,
+// description, categories, or tags), and has the category and tag (if set)
+func (entry *Entry) Matches(query, category, tag string) bool {
+	metadata := entry.Metadata
+	if metadata == nil {
+		metadata = &config.TemplateMetadata{}
+	}
+	if category != "" && !containsFold(metadata.Categories, category) {
+		return false
+	}
+	if tag != "" && !containsFold(metadata.Tags, tag) {
+		return false
+	}
+	if query == "" {
+		return true
+	}
+	text := []string{entry.Name, metadata.Description}
+	text = append(text, metadata.Categories...)
+	text = append(text, metadata.Tags...)
+	return strings.Contains(strings.ToLower(strings.Join(text, " ")), strings.ToLower(query))
+}
+
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/operatorai/kettle-cli/config"
+)
+
+// DeployDefaultsFileName is the (optional) file in a template with its
+// sensible deploy settings, which seed the config of the projects it creates
+const DeployDefaultsFileName = "deploy.defaults.yaml"
+
+// ApplyDeployDefaults sets the values in the template's deploy defaults (e.g. the
+// runtime, memory, or queue) in the project's config, unless the template's
+// kettle.json already sets them. The defaults are executed as a Go template with
+// the template's values first, so that they can depend on them
+func ApplyDeployDefaults(templatePath string, cfg *config.Config, values map[string]interface{}) error {
+	defaultsPath := path.Join(templatePath, DeployDefaultsFileName)
+	data, err := ioutil.ReadFile(defaultsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	rendered, err := Render(string(data), values)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %s", DeployDefaultsFileName, err)
+	}
+
+	var parsed interface{}
+	if err := yaml.Unmarshal([]byte(rendered), &parsed); err != nil {
+		return fmt.Errorf("invalid %s: %s", DeployDefaultsFileName, err)
+	}
+	defaults, ok := toJSONValue(parsed).(map[string]interface{})
+	if !ok {
+		if parsed == nil {
+			return nil
+		}
+		return fmt.Errorf("invalid %s: expected a map of config values", DeployDefaultsFileName)
+	}
+
+	// The values that the template's kettle.json sets take precedence
+	data, err = json.Marshal(cfg.Config)
+	if err != nil {
+		return err
+	}
+	current := map[string]interface{}{}
+	if err := json.Unmarshal(data, &current); err != nil {
+		return err
+	}
+	for key, value := range current {
+		if !isEmptyValue(value) {
+			defaults[key] = value
+		}
+	}
+
+	data, err = json.Marshal(defaults)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &cfg.Config); err != nil {
+		return fmt.Errorf("invalid %s: %s", DeployDefaultsFileName, err)
+	}
+	return nil
+}
+
+// toJSONValue converts the maps that are parsed from YAML, whose
+// keys can be of any type, to maps that can be encoded as JSON
+func toJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		converted := map[string]interface{}{}
+		for key, item := range v {
+			converted[fmt.Sprintf("%v", key)] = toJSONValue(item)
+		}
+		return converted
+	case []interface{}:
+		for i, item := range v {
+			v[i] = toJSONValue(item)
+		}
+		return v
+	}
+	return value
+}
+
+func isEmptyValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case float64:
+		return v == 0
+	case bool:
+		return !v
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+var (
+	// funcs are the custom functions that templates can call, by name
+	funcs      = template.FuncMap{}
+	funcsMutex sync.RWMutex
+
+	funcNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	errorType       = reflect.TypeOf((*error)(nil)).Elem()
+
+	// The functions that text/template defines, which cannot be replaced
+	builtinFuncs = map[string]bool{
+		"and": true, "call": true, "html": true, "index": true, "slice": true,
+		"js": true, "len": true, "not": true, "or": true, "print": true,
+		"printf": true, "println": true, "urlquery": true, "eq": true,
+		"ge": true, "gt": true, "le": true, "lt": true, "ne": true,
+	}
+)
+
+// RegisterFunc adds a function that templates can call in their files, paths,
+// and conditions, e.g. {{costcenter .TeamName}}; like the functions of
+// text/template, it returns one value, or a value and an error. Programs that
+// embed kettle register their organisation's helpers before they render templates
+func RegisterFunc(name string, fn interface{}) error {
+	if !funcNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid template function name: %s", name)
+	}
+	if builtinFuncs[name] {
+		return fmt.Errorf("%s is a built-in template function, which cannot be replaced", name)
+	}
+	fnType := reflect.TypeOf(fn)
+	if fnType == nil || fnType.Kind() != reflect.Func {
+		return fmt.Errorf("the template function %s is not a function", name)
+	}
+	switch {
+	case fnType.NumOut() == 1:
+	case fnType.NumOut() == 2 && fnType.Out(1) == errorType:
+	default:
+		return fmt.Errorf("the template function %s must return one value, or a value and an error", name)
+	}
+	funcsMutex.Lock()
+	defer funcsMutex.Unlock()
+	funcs[name] = fn
+	return nil
+}
+
+// RegisterPluginFuncs registers the template functions that are set in the user's
+// settings (funcs:), which run a command with the function's arguments, and
+// return what it prints, e.g. so that an organisation can share its helpers
+// as a script, without building its own kettle
+func RegisterPluginFuncs(stg *settings.Settings) error {
+	for name, plugin := range stg.Funcs {
+		if plugin == nil || len(plugin.Command) == 0 {
+			return fmt.Errorf("the template function %s needs a command (in ~/.kettle.yaml)", name)
+		}
+		if err := RegisterFunc(name, newPluginFunc(name, plugin.Command)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newPluginFunc returns a template function that runs the command; its results
+// are reused, as a template can call a function with the same arguments many times
+func newPluginFunc(name string, command []string) func(...interface{}) (string, error) {
+	results := map[string]string{}
+	var mutex sync.Mutex
+	return func(args ...interface{}) (string, error) {
+		commandArgs := append([]string{}, command[1:]...)
+		for _, arg := range args {
+			commandArgs = append(commandArgs, fmt.Sprint(arg))
+		}
+		key := strings.Join(commandArgs, "\x00")
+		mutex.Lock()
+		defer mutex.Unlock()
+		if result, ok := results[key]; ok {
+			return result, nil
+		}
+		// Templates can be rendered concurrently (e.g. by kettle bench-template),
+		// so the command runs without a spinner
+		output, err := cli.ExecuteSilently(command[0], commandArgs)
+		if err != nil {
+			var commandError *cli.CommandError
+			if errors.As(err, &commandError) && commandError.Stderr != "" {
+				return "", fmt.Errorf("the template function %s failed: %s", name, strings.TrimSpace(commandError.Stderr))
+			}
+			return "", fmt.Errorf("the template function %s failed: %w", name, err)
+		}
+		result := strings.TrimRight(string(output), "\r\n")
+		results[key] = result
+		return result, nil
+	}
+}
+
+// NewTemplate returns a template that can call the registered functions
+func NewTemplate(name string) *template.Template {
+	funcsMutex.RLock()
+	defer funcsMutex.RUnlock()
+	return template.New(name).Funcs(funcs)
+}
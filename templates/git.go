@@ -1,14 +1,26 @@
 package templates
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"os"
+	"os/exec"
 	"path"
+	"path/filepath"
 	"strings"
 
 	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/tempdir"
+)
+
+const (
+	// Preview stages are appended to resource names, which are often limited
+	maxPreviewStageLength = 20
+	// templatesRepository is the repository that templates are looked up in by name
+	templatesRepository = "https://github.com/operatorai/kettle-templates"
 )
 
 func isGitRepository(templatePath string) bool {
@@ -21,7 +33,7 @@ func isGitRepository(templatePath string) bool {
 }
 
 func cloneRepository(url string) (string, error) {
-	tempDirectory, err := ioutil.TempDir("", "kettle")
+	tempDirectory, err := tempdir.Create("")
 	if err != nil {
 		return "", err
 	}
@@ -34,7 +46,7 @@ func cloneRepository(url string) (string, error) {
 }
 
 func searchTemplates(templateName string) (string, error) {
-	tempDirectory, err := ioutil.TempDir("", "kettle-templates")
+	tempDirectory, err := tempdir.Create("-templates")
 	if err != nil {
 		return "", err
 	}
@@ -46,7 +58,7 @@ func searchTemplates(templateName string) (string, error) {
 		"--depth", "1",
 		"--filter=blob:none",
 		"--sparse",
-		"https://github.com/operatorai/kettle-templates",
+		templatesRepository,
 		tempDirectory,
 	}, "Searching for template..."); err != nil {
 		return "", err
@@ -88,3 +100,132 @@ func searchTemplates(templateName string) (string, error) {
 	}
 	return tempDirectory, nil
 }
+
+// ReadLatestConfig reads the config of the latest version of a template without
+// showing its progress, and stops when the context is done (e.g. for lookups that
+// run in the background); a template in a git repository (or in kettle-templates)
+// is read from a clone that only checks out its kettle.json
+func ReadLatestConfig(ctx context.Context, template string) (*config.Config, error) {
+	exists, err := pathExists(template)
+	if err != nil {
+		return nil, err
+	}
+	if exists || (!isGitRepository(template) && settings.TemplateBundle != "") {
+		// Local templates, and bundles, are read without running any commands
+		templatePath, isTempDir, err := GetTemplate(template)
+		if err != nil {
+			return nil, err
+		}
+		if isTempDir {
+			defer tempdir.Remove(templatePath)
+		}
+		return config.ReadConfig(templatePath)
+	}
+	if settings.AirGapped {
+		return nil, fmt.Errorf("cannot fetch %s in air-gapped mode", template)
+	}
+
+	url, subdirectory := template, "."
+	if !isGitRepository(template) {
+		url, subdirectory = templatesRepository, template
+	}
+	directory, err := tempdir.Create("-changelog")
+	if err != nil {
+		return nil, err
+	}
+	defer tempdir.Remove(directory)
+	for _, args := range [][]string{
+		{"clone", "--quiet", "--depth", "1", "--filter=blob:none", "--no-checkout", url, directory},
+		{"-C", directory, "checkout", "HEAD", "--", path.Join(subdirectory, "kettle.json")},
+	} {
+		osCmd := exec.CommandContext(ctx, "git", args...)
+		// git must not ask for credentials, as nothing would answer it
+		osCmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+		if output, err := osCmd.CombinedOutput(); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("cannot read the latest version of %s: %s", template, strings.TrimSpace(string(output)))
+		}
+	}
+	return config.ReadConfig(path.Join(directory, subdirectory))
+}
+
+// GetCodeVersion returns the git commit of the current directory, or
+// an empty string if it is not in a git repository
+func GetCodeVersion() string {
+	output, err := cli.ExecuteWithResult("git", []string{
+		"rev-parse",
+		"--short",
+		"HEAD",
+	}, "Reading code version")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// GetSource returns the source of a template (as it was given to kettle create),
+// and its version: the commit of its git repository, if it is in one; local
+// paths are made absolute, so that the template can be found from elsewhere
+func GetSource(template, templatePath string) *config.TemplateSource {
+	source := &config.TemplateSource{Template: template}
+	if exists, err := pathExists(template); err == nil && exists {
+		if absolutePath, err := filepath.Abs(template); err == nil {
+			source.Template = absolutePath
+		}
+	}
+	output, err := cli.ExecuteSilently("git", []string{"-C", templatePath, "rev-parse", "HEAD"})
+	if err == nil {
+		source.Version = strings.TrimSpace(string(output))
+	}
+	return source
+}
+
+// GetPreviewStage returns the name of a preview stage for the current pull
+// request (when running in CI) or git branch, e.g. pr-12 or add-login-page
+func GetPreviewStage() (string, error) {
+	// GitHub Actions sets the ref of pull requests as: refs/pull/<number>/merge
+	ref := strings.Split(os.Getenv("GITHUB_REF"), "/")
+	if len(ref) == 4 && ref[1] == "pull" {
+		return fmt.Sprintf("pr-%s", ref[2]), nil
+	}
+	if mergeRequest := os.Getenv("CI_MERGE_REQUEST_IID"); mergeRequest != "" {
+		return fmt.Sprintf("pr-%s", mergeRequest), nil
+	}
+
+	output, err := cli.ExecuteWithResult("git", []string{
+		"rev-parse",
+		"--abbrev-ref",
+		"HEAD",
+	}, "Reading git branch")
+	if err != nil {
+		return "", err
+	}
+	branch := strings.TrimSpace(string(output))
+	switch branch {
+	case "HEAD":
+		return "", errors.New("previews need a git branch (HEAD is detached)")
+	case "main", "master":
+		return "", fmt.Errorf("previews are for feature branches, not: %s", branch)
+	}
+	return previewStageName(branch), nil
+}
+
+// previewStageName converts a branch name into a stage name, which
+// only contains lowercase letters, numbers, and dashes
+func previewStageName(branch string) string {
+	stage := []rune{}
+	for _, r := range strings.ToLower(branch) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			stage = append(stage, r)
+		} else if len(stage) > 0 && stage[len(stage)-1] != '-' {
+			stage = append(stage, '-')
+		}
+	}
+	name := strings.Trim(string(stage), "-")
+	if len(name) > maxPreviewStageLength {
+		name = strings.Trim(name[:maxPreviewStageLength], "-")
+	}
+	return name
+}
@@ -0,0 +1,71 @@
+package templates
+
+import (
+	"embed"
+	"io/ioutil"
+	"path"
+	"strings"
+)
+
+// helpers are files that kettle adds to the projects that it creates
+//
+//go:embed helpers
+var helpers embed.FS
+
+// loggingHelpers are the structured logging helpers, by runtime; their logs
+// are understood by the log metrics (and embedded metrics) that kettle sets up
+var loggingHelpers = map[string]string{
+	"python": "kettle_logging.py",
+	"nodejs": "kettle_logging.js",
+}
+
+// tracingHelpers are starter OpenTelemetry snippets, by runtime,
+// for projects that are deployed with tracing
+var tracingHelpers = map[string]string{
+	"python": "kettle_tracing.py",
+	"nodejs": "kettle_tracing.js",
+}
+
+// batchHelpers report the messages of a queue's batch that failed, by
+// runtime, for projects with a retry queue
+var batchHelpers = map[string]string{
+	"python": "kettle_batch.py",
+	"nodejs": "kettle_batch.js",
+}
+
+// AddLoggingHelper adds the structured logging helper for the project's
+// runtime (if there is one), unless the template already has one
+func AddLoggingHelper(directoryPath, runtime string) error {
+	return addHelper(directoryPath, runtime, loggingHelpers)
+}
+
+// AddTracingHelper adds the tracing snippet for the project's runtime
+// (if there is one), unless the template already has one
+func AddTracingHelper(directoryPath, runtime string) error {
+	return addHelper(directoryPath, runtime, tracingHelpers)
+}
+
+// AddBatchHelper adds the partial batch failures helper for the project's
+// runtime (if there is one), unless the template already has one
+func AddBatchHelper(directoryPath, runtime string) error {
+	return addHelper(directoryPath, runtime, batchHelpers)
+}
+
+func addHelper(directoryPath, runtime string, runtimeHelpers map[string]string) error {
+	for prefix, fileName := range runtimeHelpers {
+		if !strings.HasPrefix(runtime, prefix) {
+			continue
+		}
+		targetPath := path.Join(directoryPath, fileName)
+		exists, err := pathExists(targetPath)
+		if err != nil || exists {
+			return err
+		}
+		data, err := helpers.ReadFile(path.Join("helpers", fileName))
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(targetPath, data, 0644)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+// Partial batch failures for kettle projects with a retry queue.
+//
+// Lambda receives SQS messages in batches; processBatch() calls process with
+// the body of each message, and reports the ones that threw (or rejected), so
+// that only they are retried (and moved to the dead-letter queue once they
+// have failed the queue's retries), e.g.
+//
+//   const { processBatch } = require("./kettle_batch");
+//   exports.handler = (event) => processBatch(event, handleOrder);
+
+function parse(body) {
+  try {
+    return JSON.parse(body);
+  } catch (err) {
+    return body;
+  }
+}
+
+async function processBatch(event, process) {
+  const batchItemFailures = [];
+  for (const record of event.Records || []) {
+    try {
+      await process(parse(record.body));
+    } catch (err) {
+      console.error(err);
+      batchItemFailures.push({ itemIdentifier: record.messageId });
+    }
+  }
+  return { batchItemFailures };
+}
+
+module.exports = { processBatch };
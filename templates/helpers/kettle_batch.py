@@ -0,0 +1,30 @@
+"""Partial batch failures for kettle projects with a retry queue.
+
+Lambda receives SQS messages in batches; process_batch() calls process() with
+the body of each message, and reports the ones that raised an exception, so
+that only they are retried (and moved to the dead-letter queue once they have
+failed the queue's retries), e.g.
+
+    from kettle_batch import process_batch
+
+    def handler(event, context):
+        return process_batch(event, handle_order)
+"""
+import json
+import traceback
+
+
+def process_batch(event, process):
+    failures = []
+    for record in event.get("Records", []):
+        try:
+            body = record["body"]
+            try:
+                body = json.loads(body)
+            except ValueError:
+                pass
+            process(body)
+        except Exception:
+            traceback.print_exc()
+            failures.append({"itemIdentifier": record["messageId"]})
+    return {"batchItemFailures": failures}
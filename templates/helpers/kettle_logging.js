@@ -0,0 +1,37 @@
+// Structured logging for kettle projects.
+//
+// Each log line is a JSON object with a "level" and a "message", so that the
+// log_metrics in kettle.json can match them (e.g. the errors metric, or a
+// pattern like { $.level = "WARNING" }). metric() writes a record in
+// CloudWatch's embedded metric format, which is turned into a custom metric.
+const NAMESPACE = process.env.KETTLE_METRICS_NAMESPACE || "Kettle";
+
+function log(level, message, fields = {}) {
+  const record = { level, message, timestamp: Date.now(), ...fields };
+  process.stdout.write(JSON.stringify(record) + "\n");
+}
+
+const info = (message, fields) => log("INFO", message, fields);
+const warning = (message, fields) => log("WARNING", message, fields);
+const error = (message, fields) => log("ERROR", message, fields);
+
+// Records a custom metric, e.g. metric("OrdersPlaced", 1, "Count", { country: "GB" })
+function metric(name, value, unit = "Count", dimensions = {}) {
+  const record = {
+    _aws: {
+      Timestamp: Date.now(),
+      CloudWatchMetrics: [
+        {
+          Namespace: NAMESPACE,
+          Dimensions: [Object.keys(dimensions)],
+          Metrics: [{ Name: name, Unit: unit }],
+        },
+      ],
+    },
+    [name]: value,
+    ...dimensions,
+  };
+  process.stdout.write(JSON.stringify(record) + "\n");
+}
+
+module.exports = { log, info, warning, error, metric };
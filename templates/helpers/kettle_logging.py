@@ -0,0 +1,54 @@
+"""Structured logging for kettle projects.
+
+Each log line is a JSON object with a "level" and a "message", so that the
+log_metrics in kettle.json can match them (e.g. the errors metric, or a
+pattern like { $.level = "WARNING" }). metric() writes a record in
+CloudWatch's embedded metric format, which is turned into a custom metric.
+"""
+import json
+import os
+import sys
+import time
+
+NAMESPACE = os.environ.get("KETTLE_METRICS_NAMESPACE", "Kettle")
+
+
+def log(level, message, **fields):
+    record = {
+        "level": level,
+        "message": message,
+        "timestamp": int(time.time() * 1000),
+    }
+    record.update(fields)
+    print(json.dumps(record, default=str), file=sys.stdout, flush=True)
+
+
+def info(message, **fields):
+    log("INFO", message, **fields)
+
+
+def warning(message, **fields):
+    log("WARNING", message, **fields)
+
+
+def error(message, **fields):
+    log("ERROR", message, **fields)
+
+
+def metric(name, value, unit="Count", **dimensions):
+    """Records a custom metric, e.g. metric("OrdersPlaced", 1, country="GB")"""
+    record = {
+        "_aws": {
+            "Timestamp": int(time.time() * 1000),
+            "CloudWatchMetrics": [
+                {
+                    "Namespace": NAMESPACE,
+                    "Dimensions": [list(dimensions.keys())],
+                    "Metrics": [{"Name": name, "Unit": unit}],
+                }
+            ],
+        },
+        name: value,
+    }
+    record.update(dimensions)
+    print(json.dumps(record, default=str), file=sys.stdout, flush=True)
@@ -0,0 +1,28 @@
+// OpenTelemetry tracing for kettle projects.
+//
+// The tracing layer (or the exporter settings that kettle deploys with)
+// instruments the function and exports its spans; use traced() to add
+// spans of your own, e.g.
+//
+//   const { traced } = require("./kettle_tracing");
+//   const model = await traced("load-model", () => loadModel());
+const { trace, SpanStatusCode } = require("@opentelemetry/api");
+
+const tracer = trace.getTracer("kettle");
+
+// Records a span around fn, which can return a promise
+function traced(name, fn, attributes = {}) {
+  return tracer.startActiveSpan(name, { attributes }, async (span) => {
+    try {
+      return await fn();
+    } catch (err) {
+      span.recordException(err);
+      span.setStatus({ code: SpanStatusCode.ERROR });
+      throw err;
+    } finally {
+      span.end();
+    }
+  });
+}
+
+module.exports = { traced };
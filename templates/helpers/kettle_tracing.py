@@ -0,0 +1,31 @@
+"""OpenTelemetry tracing for kettle projects.
+
+The tracing layer (or the exporter settings that kettle deploys with)
+instruments the function and exports its spans; use traced() to add
+spans of your own, e.g.
+
+    from kettle_tracing import traced
+
+    @traced("load-model")
+    def load_model():
+        ...
+"""
+import functools
+
+from opentelemetry import trace
+
+tracer = trace.get_tracer("kettle")
+
+
+def traced(name, **attributes):
+    """Records a span for each call of the decorated function"""
+
+    def decorator(function):
+        @functools.wraps(function)
+        def wrapper(*args, **kwargs):
+            with tracer.start_as_current_span(name, attributes=attributes):
+                return function(*args, **kwargs)
+
+        return wrapper
+
+    return decorator
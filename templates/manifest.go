@@ -0,0 +1,97 @@
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/config"
+)
+
+const (
+	PromptString = "string"
+	PromptBool   = "bool"
+	PromptSelect = "select"
+	// Files with a NUL byte in their first 8000 bytes are binary (like git)
+	binaryCheckLength = 8000
+)
+
+// GetValue converts the answer to a prompt to the prompt's type,
+// and returns an error if it is not valid
+func GetValue(prompt *config.TemplatePrompt, answer string) (interface{}, error) {
+	switch prompt.Type {
+	case "", PromptString:
+		if prompt.Validate == "" {
+			return answer, nil
+		}
+		matched, err := regexp.MatchString(prompt.Validate, answer)
+		if err != nil {
+			return nil, fmt.Errorf("invalid validation for %s: %s", prompt.Key, err)
+		}
+		if !matched {
+			return nil, fmt.Errorf("%s must match: %s", prompt.Key, prompt.Validate)
+		}
+		return answer, nil
+	case PromptBool:
+		value, err := strconv.ParseBool(answer)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false", prompt.Key)
+		}
+		return value, nil
+	case PromptSelect:
+		for _, option := range prompt.Options {
+			if option == answer {
+				return answer, nil
+			}
+		}
+		return nil, fmt.Errorf("%s must be one of: %s", prompt.Key, strings.Join(prompt.Options, ", "))
+	}
+	return nil, fmt.Errorf("unknown type for %s: %s (expected string, bool, or select)", prompt.Key, prompt.Type)
+}
+
+// Render executes a template expression (e.g. in a file path,
+// or a condition) with the template's values
+func Render(text string, values map[string]interface{}) (string, error) {
+	tmpl, err := NewTemplate("").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, values); err != nil {
+		return "", err
+	}
+	return rendered.String(), nil
+}
+
+// IsIncluded returns whether a file (or directory) in the template is created,
+// given the conditions of the template's files and the template's values
+func IsIncluded(relativePath string, files []*config.TemplateFile, values map[string]interface{}) (bool, error) {
+	for _, file := range files {
+		if file.When == "" {
+			// The file only has a profile
+			continue
+		}
+		if relativePath != file.Path && !strings.HasPrefix(relativePath, strings.TrimSuffix(file.Path, "/")+"/") {
+			continue
+		}
+		condition, err := Render(file.When, values)
+		if err != nil {
+			return false, fmt.Errorf("invalid condition for %s: %s", file.Path, err)
+		}
+		if strings.TrimSpace(condition) != "true" {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// IsBinary returns whether a file's contents are binary, so that
+// it is copied as it is, rather than executed as a template
+func IsBinary(data []byte) bool {
+	if len(data) > binaryCheckLength {
+		data = data[:binaryCheckLength]
+	}
+	return bytes.IndexByte(data, 0) != -1
+}
@@ -0,0 +1,61 @@
+package templates
+
+import (
+	"strings"
+
+	"github.com/operatorai/kettle-cli/config"
+)
+
+var (
+	// Values in HCL's quoted strings, where ${ and %{ start template sequences
+	hclEscaper = strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"\n", `\n`,
+		"\r", `\r`,
+		"\t", `\t`,
+		"${", "$${",
+		"%{", "%%{",
+	)
+	// Values in YAML's double-quoted strings
+	yamlEscaper = strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"\n", `\n`,
+		"\r", `\r`,
+		"\t", `\t`,
+	)
+)
+
+// GetDelimiters returns the delimiters of a profile's template expressions
+func GetDelimiters(profile string) (string, string) {
+	if profile == config.ProfileHelm {
+		return "[[", "]]"
+	}
+	return "{{", "}}"
+}
+
+// EscapeValues returns the template's values, escaped for the profile: the
+// string values of the terraform and helm profiles can be put in the quoted
+// strings of HCL and YAML, e.g. name = "{{.ProjectName}}"; the values are
+// also available as they are, as .Raw (e.g. {{.Raw.Description}})
+func EscapeValues(profile string, values map[string]interface{}) map[string]interface{} {
+	var escaper *strings.Replacer
+	switch profile {
+	case config.ProfileTerraform:
+		escaper = hclEscaper
+	case config.ProfileHelm:
+		escaper = yamlEscaper
+	default:
+		return values
+	}
+	escaped := make(map[string]interface{}, len(values)+1)
+	for key, value := range values {
+		if text, ok := value.(string); ok {
+			value = escaper.Replace(text)
+		}
+		escaped[key] = value
+	}
+	escaped["Raw"] = values
+	return escaped
+}
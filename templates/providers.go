@@ -0,0 +1,253 @@
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+	"gopkg.in/yaml.v2"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/settings"
+	"github.com/operatorai/kettle-cli/vault"
+)
+
+const (
+	ProviderEnv            = "env"
+	ProviderFile           = "file"
+	ProviderSSM            = "ssm"
+	ProviderSecretsManager = "secretsmanager"
+	ProviderVault          = "vault"
+	ProviderHTTP           = "http"
+
+	defaultProviderEnvPrefix = "KETTLE_VALUE_"
+	providerRequestTimeout   = 10 * time.Second
+)
+
+// Provider looks up the values of a template's prompts before they are
+// asked for, e.g. values that are standard in an organisation
+type Provider interface {
+	// Name describes the provider, e.g. in errors
+	Name() string
+	// GetValue returns the value of a prompt by its key, and
+	// false if the provider does not have it
+	GetValue(key string) (string, bool, error)
+}
+
+// NewProviders returns the providers that are set in the user's settings
+func NewProviders(stg *settings.Settings) ([]Provider, error) {
+	created := []Provider{}
+	for _, provider := range stg.ValueProviders {
+		provider := provider
+		var p Provider
+		switch provider.Type {
+		case ProviderEnv:
+			p = &envProvider{prefix: provider.Prefix}
+		case ProviderFile:
+			if provider.Path == "" {
+				return nil, fmt.Errorf("the %s value provider needs a path", provider.Type)
+			}
+			p = &objectProvider{name: "file " + provider.Path, load: func() ([]byte, error) {
+				filePath, err := homedir.Expand(provider.Path)
+				if err != nil {
+					return nil, err
+				}
+				return ioutil.ReadFile(filePath)
+			}}
+		case ProviderSSM:
+			p = &ssmProvider{prefix: provider.Prefix, region: provider.Region}
+		case ProviderSecretsManager:
+			if provider.SecretID == "" {
+				return nil, fmt.Errorf("the %s value provider needs a secret_id", provider.Type)
+			}
+			p = &objectProvider{name: "secret " + provider.SecretID, load: func() ([]byte, error) {
+				return getSecret(provider)
+			}}
+		case ProviderVault:
+			if provider.Path == "" {
+				return nil, fmt.Errorf("the %s value provider needs a path", provider.Type)
+			}
+			p = &objectProvider{name: "vault " + provider.Path, load: func() ([]byte, error) {
+				return getVaultSecret(provider, stg.Vault)
+			}}
+		case ProviderHTTP:
+			if !strings.HasPrefix(provider.URL, "https://") && !strings.HasPrefix(provider.URL, "http://") {
+				return nil, fmt.Errorf("the %s value provider needs a url", provider.Type)
+			}
+			p = &objectProvider{name: provider.URL, load: func() ([]byte, error) {
+				return getValues(provider)
+			}}
+		default:
+			return nil, fmt.Errorf("unknown value provider: %s (expected env, file, ssm, secretsmanager, vault, or http)", provider.Type)
+		}
+		if len(provider.Keys) > 0 {
+			p = &keysProvider{Provider: p, keys: provider.Keys}
+		}
+		created = append(created, p)
+	}
+	return created, nil
+}
+
+// GetProvidedValue returns the value of a prompt from the first
+// provider that has it, and false if none of them do
+func GetProvidedValue(providers []Provider, key string) (string, bool, error) {
+	for _, provider := range providers {
+		value, ok, err := provider.GetValue(key)
+		if err != nil {
+			return "", false, fmt.Errorf("cannot get %s from %s: %w", key, provider.Name(), err)
+		}
+		if ok {
+			return value, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// keysProvider only looks up its keys, so that e.g. a
+// parameter store is not searched for every prompt
+type keysProvider struct {
+	Provider
+	keys []string
+}
+
+func (p *keysProvider) GetValue(key string) (string, bool, error) {
+	for _, k := range p.keys {
+		if k == key {
+			return p.Provider.GetValue(key)
+		}
+	}
+	return "", false, nil
+}
+
+// envProvider looks up values in environment variables, e.g. KETTLE_VALUE_TeamName
+type envProvider struct {
+	prefix string
+}
+
+func (p *envProvider) Name() string {
+	return "the environment"
+}
+
+func (p *envProvider) GetValue(key string) (string, bool, error) {
+	prefix := p.prefix
+	if prefix == "" {
+		prefix = defaultProviderEnvPrefix
+	}
+	value, ok := os.LookupEnv(prefix + key)
+	return value, ok, nil
+}
+
+// ssmProvider looks up values in SSM parameters, by the prefix and the key
+type ssmProvider struct {
+	prefix string
+	region string
+}
+
+func (p *ssmProvider) Name() string {
+	return "SSM"
+}
+
+func (p *ssmProvider) GetValue(key string) (string, bool, error) {
+	args := []string{
+		"ssm",
+		"get-parameter",
+		"--name", p.prefix + key,
+		"--with-decryption",
+		"--query", "Parameter.Value",
+		"--output", "text",
+	}
+	if p.region != "" {
+		args = append(args, "--region", p.region)
+	}
+	output, err := cli.ExecuteWithResult("aws", args, fmt.Sprintf("Looking up %s in SSM", key))
+	if err != nil {
+		if cli.HasErrorCode(err, "ParameterNotFound") {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return strings.TrimSpace(string(output)), true, nil
+}
+
+// objectProvider looks up values in an object of values (in YAML or JSON), e.g.
+// a file or a secret, which is only loaded when the first value is looked up
+type objectProvider struct {
+	name   string
+	load   func() ([]byte, error)
+	values map[string]interface{}
+}
+
+func (p *objectProvider) Name() string {
+	return p.name
+}
+
+func (p *objectProvider) GetValue(key string) (string, bool, error) {
+	if p.values == nil {
+		data, err := p.load()
+		if err != nil {
+			return "", false, err
+		}
+		// JSON objects are YAML too
+		values := map[string]interface{}{}
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return "", false, fmt.Errorf("invalid values: %s", err)
+		}
+		p.values = values
+	}
+	value, ok := p.values[key]
+	if !ok || value == nil {
+		return "", false, nil
+	}
+	return fmt.Sprintf("%v", value), true, nil
+}
+
+// getSecret returns the value of a Secrets Manager secret
+func getSecret(provider *settings.ValueProvider) ([]byte, error) {
+	args := []string{
+		"secretsmanager",
+		"get-secret-value",
+		"--secret-id", provider.SecretID,
+		"--query", "SecretString",
+		"--output", "text",
+	}
+	if provider.Region != "" {
+		args = append(args, "--region", provider.Region)
+	}
+	return cli.ExecuteWithResult("aws", args, "Looking up values in Secrets Manager")
+}
+
+// getVaultSecret returns the data of a Vault secret, as JSON
+func getVaultSecret(provider *settings.ValueProvider, stg *settings.VaultSettings) ([]byte, error) {
+	data, err := vault.ReadData(stg, provider.Path)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(data)
+}
+
+// getValues returns the values from an HTTP endpoint (e.g. an internal platform)
+func getValues(provider *settings.ValueProvider) ([]byte, error) {
+	request, err := http.NewRequest(http.MethodGet, provider.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", "application/json")
+	if provider.TokenEnv != "" {
+		request.Header.Set("Authorization", "Bearer "+os.Getenv(provider.TokenEnv))
+	}
+	client := &http.Client{Timeout: providerRequestTimeout}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned: %s", provider.URL, response.Status)
+	}
+	return ioutil.ReadAll(response.Body)
+}
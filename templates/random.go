@@ -0,0 +1,128 @@
+package templates
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp/syntax"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+
+	"github.com/operatorai/kettle-cli/config"
+)
+
+const (
+	// Random values are generated until one is valid, up to this many times
+	randomValueAttempts = 20
+	// Repeats without a maximum (e.g. a* or a+) have up to this many more than their minimum
+	randomRepeatLimit = 8
+	randomAlphabet    = "abcdefghijklmnopqrstuvwxyz0123456789"
+)
+
+// RandomValue returns a random answer to a prompt that is valid for its type
+// (and its validation, if it has one), e.g. to test a template with many values
+func RandomValue(prompt *config.TemplatePrompt, random *rand.Rand) (string, error) {
+	switch prompt.Type {
+	case PromptBool:
+		if random.Intn(2) == 0 {
+			return "false", nil
+		}
+		return "true", nil
+	case PromptSelect:
+		if len(prompt.Options) == 0 {
+			return "", fmt.Errorf("%s has no options", prompt.Key)
+		}
+		return prompt.Options[random.Intn(len(prompt.Options))], nil
+	}
+
+	var pattern *syntax.Regexp
+	if prompt.Validate != "" {
+		parsed, err := syntax.Parse(prompt.Validate, syntax.Perl)
+		if err != nil {
+			return "", fmt.Errorf("invalid validation for %s: %s", prompt.Key, err)
+		}
+		pattern = parsed.Simplify()
+	}
+	for i := 0; i < randomValueAttempts; i++ {
+		answer := randomWord(random, 3+random.Intn(10))
+		if pattern != nil {
+			var value strings.Builder
+			generateMatch(&value, pattern, random)
+			answer = value.String()
+		}
+		if prompt.Style == "camel" {
+			answer = strcase.ToCamel(answer)
+		}
+		if _, err := GetValue(prompt, answer); err == nil {
+			return answer, nil
+		}
+	}
+	if prompt.Default != "" {
+		return prompt.Default, nil
+	}
+	return "", fmt.Errorf("cannot generate a valid value for %s (which must match: %s)", prompt.Key, prompt.Validate)
+}
+
+// generateMatch writes a random string that matches a (simplified) regular
+// expression; flags such as case insensitivity are not taken into account,
+// so the string is checked against the expression afterwards
+func generateMatch(value *strings.Builder, pattern *syntax.Regexp, random *rand.Rand) {
+	switch pattern.Op {
+	case syntax.OpLiteral:
+		value.WriteString(string(pattern.Rune))
+	case syntax.OpCharClass:
+		// Rune is a list of ranges (pairs of their first and last runes)
+		if len(pattern.Rune) == 0 {
+			return
+		}
+		i := random.Intn(len(pattern.Rune)/2) * 2
+		low, high := pattern.Rune[i], pattern.Rune[i+1]
+		if low < ' ' && high >= ' ' {
+			// Skip control characters (e.g. the start of [^,])
+			low = ' '
+		}
+		if high-low > 0xff {
+			// Keep to the start of wide ranges (e.g. [^,]), which are mostly not printable
+			high = low + 0xff
+		}
+		value.WriteRune(low + rune(random.Intn(int(high-low)+1)))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		value.WriteByte(randomAlphabet[random.Intn(len(randomAlphabet))])
+	case syntax.OpCapture:
+		generateMatch(value, pattern.Sub[0], random)
+	case syntax.OpConcat:
+		for _, sub := range pattern.Sub {
+			generateMatch(value, sub, random)
+		}
+	case syntax.OpAlternate:
+		generateMatch(value, pattern.Sub[random.Intn(len(pattern.Sub))], random)
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		min, max := 0, -1
+		switch pattern.Op {
+		case syntax.OpPlus:
+			min = 1
+		case syntax.OpQuest:
+			max = 1
+		case syntax.OpRepeat:
+			min, max = pattern.Min, pattern.Max
+		}
+		if max < 0 {
+			max = min + randomRepeatLimit
+		}
+		count := min + random.Intn(max-min+1)
+		for i := 0; i < count; i++ {
+			generateMatch(value, pattern.Sub[0], random)
+		}
+	}
+	// Anchors (e.g. ^ and $) and empty matches do not add anything
+}
+
+func randomWord(random *rand.Rand, length int) string {
+	word := make([]byte, length)
+	// Words start with a letter, like most names
+	word[0] = randomAlphabet[random.Intn(26)]
+	for i := 1; i < length; i++ {
+		word[i] = randomAlphabet[random.Intn(len(randomAlphabet))]
+	}
+	return string(word)
+}
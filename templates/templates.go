@@ -6,6 +6,7 @@ import (
 	"path"
 
 	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
 )
 
 func GetTemplate(templatePath string) (string, bool, error) {
@@ -15,16 +16,30 @@ func GetTemplate(templatePath string) (string, bool, error) {
 		return "", false, err
 	}
 	if exists {
+		if IsBundle(templatePath) {
+			tempDirectory, err := extractBundle(templatePath)
+			return tempDirectory, true, err
+		}
 		return templatePath, false, nil
 	}
 
 	// Match against a github repo & clone the repo to a tmp directory
 	if isGitRepository(templatePath) {
+		if settings.AirGapped {
+			return "", false, fmt.Errorf("cannot clone %s in air-gapped mode (use a local path or a template bundle)", templatePath)
+		}
 		tempDirectory, err := cloneRepository(templatePath)
 		return tempDirectory, true, err
 	}
 
-	// Look for the template in the kettle-templates monorepo
+	// Look for the template in the template bundle, or in the kettle-templates monorepo
+	if settings.TemplateBundle != "" {
+		tempDirectory, err := searchBundle(settings.TemplateBundle, templatePath)
+		return tempDirectory, true, err
+	}
+	if settings.AirGapped {
+		return "", false, fmt.Errorf("template %s was not found (in air-gapped mode, set a template_bundle to look templates up by name)", templatePath)
+	}
 	tempDirectory, err := searchTemplates(templatePath)
 	if err != nil {
 		return "", false, err
@@ -0,0 +1,314 @@
+// Package tools installs the external tools that a project pins to a version
+// (e.g. cargo-lambda) into kettle's toolcache, and puts them on the PATH, so
+// that builds use the same tools on every machine
+package tools
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+// ToolcacheEnvironmentVariable overrides the toolcache's directory (~/.kettle/tools),
+// e.g. so that CI can cache it between runs
+const ToolcacheEnvironmentVariable = "KETTLE_TOOLCACHE"
+
+// Installed is a pinned tool in the toolcache
+type Installed struct {
+	Name    string
+	Version string
+	Path    string
+	// Whether it was downloaded by this run (rather than being in the toolcache already)
+	Downloaded bool
+}
+
+// Use installs the project's pinned tools (unless they are in the toolcache
+// already), and puts them first on the PATH, so that the pinned versions are
+// run by kettle, and by the tools that it runs (e.g. cargo's subcommands)
+func Use(cfg *config.Config) ([]*Installed, error) {
+	installed, err := Install(cfg)
+	if err != nil || len(installed) == 0 {
+		return installed, err
+	}
+	directories := []string{}
+	for _, tool := range installed {
+		directories = append(directories, filepath.Dir(tool.Path))
+	}
+	// The directories are moved to the front if they are on the PATH already
+	// (e.g. when each project of a workspace is loaded)
+	for _, directory := range filepath.SplitList(os.Getenv("PATH")) {
+		if !contains(directories[:len(installed)], directory) {
+			directories = append(directories, directory)
+		}
+	}
+	os.Setenv("PATH", strings.Join(directories, string(os.PathListSeparator)))
+	return installed, nil
+}
+
+// Install downloads the project's pinned tools into the toolcache, unless they
+// are there already, and verifies their checksums before they are installed
+func Install(cfg *config.Config) ([]*Installed, error) {
+	if err := cfg.ValidateTools(); err != nil {
+		return nil, err
+	}
+	installed := []*Installed{}
+	for _, name := range cfg.GetToolNames() {
+		tool, err := install(name, cfg.Config.Tools[name])
+		if err != nil {
+			return nil, err
+		}
+		installed = append(installed, tool)
+	}
+	return installed, nil
+}
+
+// GetPlatform returns the platform that kettle is running on, e.g. linux/amd64
+func GetPlatform() string {
+	return fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH)
+}
+
+// GetToolcacheDirectory returns the directory that tools are installed in
+func GetToolcacheDirectory() (string, error) {
+	if directory := os.Getenv(ToolcacheEnvironmentVariable); directory != "" {
+		return directory, nil
+	}
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(home, ".kettle", "tools"), nil
+}
+
+// GetPath returns where a tool's executable is (or would be) installed: tools
+// are installed by version and checksum, so that a changed pin is downloaded again
+func GetPath(name string, tool *config.Tool) (string, error) {
+	download, ok := tool.Platforms[GetPlatform()]
+	if !ok {
+		return "", fmt.Errorf("the tool %s is not pinned for this platform (%s): add it to the tool's platforms", name, GetPlatform())
+	}
+	directory, err := GetToolcacheDirectory()
+	if err != nil {
+		return "", err
+	}
+	executable := name
+	if runtime.GOOS == "windows" {
+		executable += ".exe"
+	}
+	return filepath.Join(directory, name, tool.Version, download.SHA256[:12], executable), nil
+}
+
+func install(name string, tool *config.Tool) (*Installed, error) {
+	executable, err := GetPath(name, tool)
+	if err != nil {
+		return nil, err
+	}
+	installed := &Installed{
+		Name:    name,
+		Version: tool.Version,
+		Path:    executable,
+	}
+	// The executable is only moved into place once its download has been verified
+	if _, err := os.Stat(executable); err == nil {
+		return installed, nil
+	}
+	download := tool.Platforms[GetPlatform()]
+	if settings.AirGapped {
+		return nil, fmt.Errorf("the tool %s (%s) is not in the toolcache, and cannot be downloaded in air-gapped mode: copy the toolcache from a machine that has it (%s)", name, tool.Version, filepath.Dir(executable))
+	}
+
+	fmt.Println("🧰  Tool: ", name, fmt.Sprintf("(%s)", tool.Version))
+	if err := os.MkdirAll(filepath.Dir(executable), 0755); err != nil {
+		return nil, err
+	}
+	archive, err := downloadFile(download.GetURL(tool.Version), filepath.Dir(executable))
+	if err != nil {
+		return nil, fmt.Errorf("cannot download the tool %s: %w", name, err)
+	}
+	defer os.Remove(archive)
+	if err := verifyChecksum(archive, download.SHA256); err != nil {
+		return nil, fmt.Errorf("cannot install the tool %s: %w", name, err)
+	}
+
+	extracted, err := ioutil.TempFile(filepath.Dir(executable), ".extract-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(extracted.Name())
+	err = extract(archive, download.GetURL(tool.Version), getBinaryNames(name, tool), extracted)
+	if closeErr := extracted.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot install the tool %s: %w", name, err)
+	}
+	if err := os.Chmod(extracted.Name(), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(extracted.Name(), executable); err != nil {
+		return nil, err
+	}
+	installed.Downloaded = true
+	return installed, nil
+}
+
+// downloadFile downloads a URL into a temporary file in the directory
+func downloadFile(url, directory string) (string, error) {
+	response, err := http.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned: %s", url, response.Status)
+	}
+	f, err := ioutil.TempFile(directory, ".download-")
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(f, response.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+func verifyChecksum(filePath, expected string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return err
+	}
+	if actual := hex.EncodeToString(hash.Sum(nil)); actual != expected {
+		return fmt.Errorf("the download's sha256 checksum is %s, not the pinned %s", actual, expected)
+	}
+	return nil
+}
+
+// getBinaryNames returns the paths (or names) that the executable may have in an archive
+func getBinaryNames(name string, tool *config.Tool) []string {
+	if tool.Binary != "" {
+		return []string{path.Clean(tool.Binary)}
+	}
+	return []string{name, name + ".exe"}
+}
+
+// extract writes the executable from a download to a file: the download is
+// the executable itself, unless it is a .tar.gz (or .tgz) or .zip archive
+func extract(archive, url string, binaryNames []string, target io.Writer) error {
+	url = strings.ToLower(strings.SplitN(url, "?", 2)[0])
+	switch {
+	case strings.HasSuffix(url, ".tar.gz"), strings.HasSuffix(url, ".tgz"):
+		return extractTar(archive, binaryNames, target)
+	case strings.HasSuffix(url, ".zip"):
+		return extractZip(archive, binaryNames, target)
+	}
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(target, f)
+	return err
+}
+
+func extractTar(archive string, binaryNames []string, target io.Writer) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return notInArchive(binaryNames)
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag == tar.TypeReg && isBinary(header.Name, binaryNames) {
+			_, err := io.Copy(target, reader)
+			return err
+		}
+	}
+}
+
+func extractZip(archive string, binaryNames []string, target io.Writer) error {
+	reader, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() || !isBinary(file.Name, binaryNames) {
+			continue
+		}
+		contents, err := file.Open()
+		if err != nil {
+			return err
+		}
+		defer contents.Close()
+		_, err = io.Copy(target, contents)
+		return err
+	}
+	return notInArchive(binaryNames)
+}
+
+// isBinary returns whether a file in an archive is the executable: the binary's
+// path (relative to the archive's root), or a file named after the tool
+func isBinary(name string, binaryNames []string) bool {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	for _, binaryName := range binaryNames {
+		if strings.Contains(binaryName, "/") {
+			if name == binaryName {
+				return true
+			}
+		} else if path.Base(name) == binaryName {
+			return true
+		}
+	}
+	return false
+}
+
+func notInArchive(binaryNames []string) error {
+	return errors.New("the archive does not have the executable (" + strings.Join(binaryNames, " or ") + "): set the tool's binary to its path in the archive")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,260 @@
+package upgrade
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+)
+
+// An upgrade that does not apply cleanly is merged (with git apply --3way)
+// into the project, and each conflict (a hunk that both the project and
+// the template changed) is resolved in turn; conflicts that are deferred
+// are left in the project's files, between standard conflict markers
+
+const (
+	resolveMine     = "mine"
+	resolveTemplate = "template"
+	resolveEdit     = "edit"
+	resolveDiff     = "diff"
+	resolveDefer    = "defer"
+
+	resolveLabel = "Resolve the conflict"
+	// sideBySideWidth is the width of each side of a conflict that is shown side by side
+	sideBySideWidth = 38
+	conflictStart   = "<<<<<<<"
+	conflictBase    = "|||||||"
+	conflictMiddle  = "======="
+	conflictEnd     = ">>>>>>>"
+)
+
+// resolutions are the ways to resolve a conflict (the answers of its prompt)
+var resolutions = map[string]string{
+	"Keep mine":                             resolveMine,
+	"Take the template's":                   resolveTemplate,
+	"Edit it (in $EDITOR)":                  resolveEdit,
+	"Show both, side by side":               resolveDiff,
+	"Defer it (leave the conflict markers)": resolveDefer,
+}
+
+// conflict is a hunk of a file, between conflict markers
+type conflict struct {
+	// The lines of the hunk (with its markers) in the file
+	start    int
+	end      int
+	mine     []string
+	template []string
+}
+
+// mergePatch merges the upgrade's changes into a project in a git repository,
+// and resolves their conflicts with the project's changes; the files that
+// conflicts were deferred in are recorded in the plan's Conflicts
+func (plan *Plan) mergePatch(args []string, patchPath string) error {
+	status, err := cli.ExecuteSilently("git", append([]string{"-C", plan.Path, "status", "--porcelain", "--"}, plan.Files...))
+	if err != nil {
+		return err
+	}
+	if len(strings.TrimSpace(string(status))) > 0 {
+		return fmt.Errorf("the template's changes conflict with uncommitted changes to %s (commit or stash them first)", plan.Path)
+	}
+	// The merge needs the files that the changes were made to (the pinned version's)
+	base := []string{"-C", plan.Path, "hash-object", "-w", "--"}
+	for _, file := range plan.Files {
+		if _, err := os.Stat(filepath.Join(plan.base, file)); err == nil {
+			base = append(base, filepath.Join(plan.base, file))
+		}
+	}
+	if _, err := cli.ExecuteSilently("git", base); err != nil {
+		return err
+	}
+
+	mergeErr := cli.Execute("git", append(append([]string{}, args...), "--3way", patchPath), fmt.Sprintf("Merging the upgrade of %s", plan.Path))
+	output, err := cli.ExecuteSilently("git", []string{"-C", plan.Path, "diff", "--name-only", "--relative", "--diff-filter=U"})
+	if err != nil {
+		return err
+	}
+	unmerged := strings.Fields(string(output))
+	if mergeErr != nil && len(unmerged) == 0 {
+		return fmt.Errorf("the template's changes cannot be merged into %s: %w", plan.Path, mergeErr)
+	}
+	for _, file := range unmerged {
+		deferred, err := resolveConflicts(path.Join(plan.Path, file))
+		if err != nil {
+			return err
+		}
+		if deferred > 0 {
+			plan.Conflicts = append(plan.Conflicts, file)
+			continue
+		}
+		if err := cli.Execute("git", []string{"-C", plan.Path, "add", "--", file}, "Marking "+file+" as resolved"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveConflicts prompts for how to resolve each conflict in a file, and
+// returns how many of them were deferred
+func resolveConflicts(filePath string) (int, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return 0, err
+	}
+	contents, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return 0, err
+	}
+	lines := strings.Split(string(contents), "\n")
+	conflicts := parseConflicts(lines)
+	resolved := []string{}
+	deferred := 0
+	next := 0
+	for i, c := range conflicts {
+		resolved = append(resolved, lines[next:c.start]...)
+		next = c.end
+		fmt.Println(fmt.Sprintf("⚔️   Conflict %d of %d in %s (line %d)", i+1, len(conflicts), filePath, len(resolved)+1))
+		hunk, err := resolveConflict(filePath, lines[c.start:c.end], c)
+		if err != nil {
+			return 0, err
+		}
+		deferred += len(parseConflicts(hunk))
+		resolved = append(resolved, hunk...)
+	}
+	resolved = append(resolved, lines[next:]...)
+	return deferred, ioutil.WriteFile(filePath, []byte(strings.Join(resolved, "\n")), info.Mode())
+}
+
+// resolveConflict prompts for how to resolve a conflict, and returns its lines once
+// it is resolved (or with its markers, if it is deferred); it is shown side by side
+// at most once, so that an answer (with --set) cannot show it over and over
+func resolveConflict(filePath string, hunk []string, c *conflict) ([]string, error) {
+	values := map[string]string{}
+	for label, value := range resolutions {
+		values[label] = value
+	}
+	for {
+		resolution, err := cli.PromptForValueWithDefault(resolveLabel, values, resolveDefer)
+		if err != nil {
+			return nil, err
+		}
+		switch resolution {
+		case resolveMine:
+			return c.mine, nil
+		case resolveTemplate:
+			return c.template, nil
+		case resolveEdit:
+			return editConflict(filePath, hunk)
+		case resolveDiff:
+			printSideBySide(c)
+			delete(values, "Show both, side by side")
+		default:
+			return hunk, nil
+		}
+	}
+}
+
+// editConflict opens the conflict (with its markers) in the user's editor
+// ($VISUAL, or $EDITOR), and returns the lines that it is saved with
+func editConflict(filePath string, hunk []string) ([]string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	// The conflict is edited in a file with the same extension, for the editor's highlighting
+	editFile, err := ioutil.TempFile("", "kettle-conflict-*"+filepath.Ext(filePath))
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(editFile.Name())
+	_, err = editFile.WriteString(strings.Join(hunk, "\n") + "\n")
+	if closeErr := editFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	command := strings.Fields(editor)
+	exitCode, err := cli.ExecuteInteractively(command[0], append(command[1:], editFile.Name()), nil)
+	if err != nil {
+		return nil, err
+	}
+	if exitCode != 0 {
+		return nil, fmt.Errorf("the editor exited with %d (%s)", exitCode, editor)
+	}
+	edited, err := ioutil.ReadFile(editFile.Name())
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimSuffix(string(edited), "\n"), "\n"), nil
+}
+
+// printSideBySide prints the project's side of a conflict next to the template's
+func printSideBySide(c *conflict) {
+	fmt.Println(fmt.Sprintf("%-*s │ %s", sideBySideWidth, "Mine", "The template's"))
+	fmt.Println(strings.Repeat("─", sideBySideWidth) + "─┼─" + strings.Repeat("─", sideBySideWidth))
+	rows := len(c.mine)
+	if len(c.template) > rows {
+		rows = len(c.template)
+	}
+	for i := 0; i < rows; i++ {
+		mine, template := "", ""
+		if i < len(c.mine) {
+			mine = c.mine[i]
+		}
+		if i < len(c.template) {
+			template = c.template[i]
+		}
+		fmt.Println(fmt.Sprintf("%-*s │ %s", sideBySideWidth, fitColumn(mine), fitColumn(template)))
+	}
+}
+
+// fitColumn expands the tabs of a line, and truncates it to the width of a column
+func fitColumn(line string) string {
+	runes := []rune(strings.ReplaceAll(line, "\t", "    "))
+	if len(runes) > sideBySideWidth {
+		return string(runes[:sideBySideWidth-1]) + "…"
+	}
+	return string(runes)
+}
+
+// parseConflicts returns the conflicts between the markers in a file's lines
+// (with, or without, the base's lines of the diff3 style)
+func parseConflicts(lines []string) []*conflict {
+	conflicts := []*conflict{}
+	var current *conflict
+	side := ""
+	for i, line := range lines {
+		switch {
+		case isConflictMarker(line, conflictStart):
+			current = &conflict{start: i}
+			side = resolveMine
+		case current == nil:
+			continue
+		case isConflictMarker(line, conflictBase):
+			side = ""
+		case line == conflictMiddle:
+			side = resolveTemplate
+		case isConflictMarker(line, conflictEnd):
+			current.end = i + 1
+			conflicts = append(conflicts, current)
+			current = nil
+		case side == resolveMine:
+			current.mine = append(current.mine, line)
+		case side == resolveTemplate:
+			current.template = append(current.template, line)
+		}
+	}
+	return conflicts
+}
+
+func isConflictMarker(line, marker string) bool {
+	return line == marker || strings.HasPrefix(line, marker+" ")
+}
@@ -0,0 +1,61 @@
+package upgrade
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseConflicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     []*conflict
+	}{
+		{
+			name:     "no conflicts",
+			contents: "a\nb\nc",
+			want:     []*conflict{},
+		},
+		{
+			name:     "one conflict",
+			contents: "a\n<<<<<<< ours\nmine\n=======\ntemplate\n>>>>>>> theirs\nb",
+			want: []*conflict{
+				{start: 1, end: 6, mine: []string{"mine"}, template: []string{"template"}},
+			},
+		},
+		{
+			name:     "diff3 style, without the base's lines",
+			contents: "<<<<<<< ours\nmine\n||||||| base\nbase\n=======\ntemplate\nmore\n>>>>>>> theirs",
+			want: []*conflict{
+				{start: 0, end: 8, mine: []string{"mine"}, template: []string{"template", "more"}},
+			},
+		},
+		{
+			name:     "several conflicts, and an empty side",
+			contents: "<<<<<<<\n=======\nadded\n>>>>>>>\nkept\n<<<<<<< ours\nremoved\n=======\n>>>>>>> theirs",
+			want: []*conflict{
+				{start: 0, end: 4, template: []string{"added"}},
+				{start: 5, end: 9, mine: []string{"removed"}},
+			},
+		},
+		{
+			name:     "lines that only start like markers",
+			contents: "<<<<<<<<\n=======x\n>>>>>>>>",
+			want:     []*conflict{},
+		},
+		{
+			name:     "a conflict that does not end",
+			contents: "<<<<<<< ours\nmine\n=======\ntemplate",
+			want:     []*conflict{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := parseConflicts(strings.Split(test.contents, "\n"))
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("parseConflicts() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
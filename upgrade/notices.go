@@ -0,0 +1,52 @@
+package upgrade
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/templates"
+)
+
+const (
+	// NoticesTTL is how long the latest changelog of a template is kept, so
+	// that a project's deploys do not fetch its template every time
+	NoticesTTL = 24 * time.Hour
+	// NoticesTimeout is how long fetching the latest changelog of a template
+	// can take, as it is fetched while a project is deployed
+	NoticesTimeout = 20 * time.Second
+)
+
+// GetNotices returns the changes to the project's template, since the version
+// that the project is pinned to, that are flagged as security or deploy related;
+// it stops fetching the template when the context is done, or after NoticesTimeout
+func GetNotices(ctx context.Context, cfg *config.Config) ([]*config.TemplateChange, error) {
+	if cfg.Source == nil || cfg.Source.Version == "" {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, NoticesTimeout)
+	defer cancel()
+	output, err := cli.CacheLookup("template-changelog\x00"+cfg.Source.Template, NoticesTTL, func() ([]byte, error) {
+		templateConfig, err := templates.ReadLatestConfig(ctx, cfg.Source.Template)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(templateConfig.Changelog)
+	})
+	if err != nil {
+		return nil, err
+	}
+	changelog := []*config.TemplateChange{}
+	if err := json.Unmarshal(output, &changelog); err != nil {
+		return nil, err
+	}
+	notices := []*config.TemplateChange{}
+	for _, change := range cfg.GetNewChanges(changelog) {
+		if change.IsNotable() {
+			notices = append(notices, change)
+		}
+	}
+	return notices, nil
+}
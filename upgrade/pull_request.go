@@ -0,0 +1,191 @@
+package upgrade
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/operatorai/kettle-cli/cli"
+)
+
+const (
+	gitHubTokenVariable  = "GITHUB_TOKEN"
+	gitHubAPIVariable    = "GITHUB_API_URL"
+	defaultGitHubAPI     = "https://api.github.com"
+	gitHubRequestTimeout = 30 * time.Second
+)
+
+// gitHubRemotePattern matches the owner and repository of a GitHub remote,
+// e.g. git@github.com:owner/repo.git or https://github.com/owner/repo
+var gitHubRemotePattern = regexp.MustCompile(`github\.com[:/]([^/]+)/([^/]+?)(\.git)?/?$`)
+
+// OpenPullRequest applies the upgrade on a new branch of the project's
+// repository, pushes it, and opens a pull request for it on GitHub (with the
+// token in GITHUB_TOKEN); the repository is returned to its branch afterwards
+func (plan *Plan) OpenPullRequest() (string, error) {
+	token := os.Getenv(gitHubTokenVariable)
+	if token == "" {
+		return "", fmt.Errorf("opening pull requests needs a GitHub token in %s", gitHubTokenVariable)
+	}
+	owner, repository, err := getGitHubRepository(plan.Path)
+	if err != nil {
+		return "", err
+	}
+	status, err := cli.ExecuteSilently("git", []string{"-C", plan.Path, "status", "--porcelain", "--", "."})
+	if err != nil {
+		return "", err
+	}
+	if len(bytes.TrimSpace(status)) > 0 {
+		return "", fmt.Errorf("%s has uncommitted changes (commit or stash them first)", plan.Path)
+	}
+	output, err := cli.ExecuteSilently("git", []string{"-C", plan.Path, "rev-parse", "--abbrev-ref", "HEAD"})
+	if err != nil {
+		return "", err
+	}
+	base := strings.TrimSpace(string(output))
+	branch := fmt.Sprintf("kettle/upgrade-%s-%s", plan.Config.ProjectName, ShortVersion(plan.Latest))
+	title := fmt.Sprintf("Upgrade %s to the latest version of its template (%s)", plan.Config.ProjectName, ShortVersion(plan.Latest))
+
+	if err := cli.Execute("git", []string{"-C", plan.Path, "checkout", "-b", branch}, "Creating the upgrade's branch"); err != nil {
+		return "", err
+	}
+	err = plan.commitUpgrade(branch, title)
+	if err != nil {
+		// The project had no uncommitted changes, so any that are left are the upgrade's
+		cli.ExecuteSilently("git", []string{"-C", plan.Path, "reset", "--quiet", "--", "."})
+		cli.ExecuteSilently("git", []string{"-C", plan.Path, "checkout", "--", "."})
+		cli.ExecuteSilently("git", []string{"-C", plan.Path, "clean", "--force", "-d", "--quiet", "--", "."})
+	}
+	// The repository is returned to its branch, whether or not the upgrade was pushed
+	if checkoutErr := cli.Execute("git", []string{"-C", plan.Path, "checkout", base}, "Returning to "+base); err == nil {
+		err = checkoutErr
+	} else if checkoutErr == nil {
+		cli.ExecuteSilently("git", []string{"-C", plan.Path, "branch", "--delete", "--force", branch})
+	}
+	if err != nil {
+		return "", err
+	}
+	return createPullRequest(token, owner, repository, &pullRequest{
+		Title: title,
+		Head:  branch,
+		Base:  base,
+		Body: fmt.Sprintf("Upgrades `%s` from version `%s` to `%s` of its template (%s), which changes:\n\n- %s\n\nCreated by `kettle upgrade-all`.",
+			plan.Path,
+			ShortVersion(plan.Pinned),
+			ShortVersion(plan.Latest),
+			plan.Template,
+			strings.Join(plan.Files, "\n- "),
+		),
+	})
+}
+
+// commitUpgrade applies the upgrade, commits the project, and pushes the branch
+func (plan *Plan) commitUpgrade(branch, title string) error {
+	if cli.ReadOnly {
+		return fmt.Errorf("%w: refusing to push the upgrade of %s", cli.ErrReadOnly, plan.Path)
+	}
+	if err := plan.Apply(); err != nil {
+		return err
+	}
+	if len(plan.Conflicts) > 0 {
+		// Conflict markers are not pushed for review
+		return fmt.Errorf("the upgrade has conflicts that were not resolved (upgrade it with --apply instead): %s", strings.Join(plan.Conflicts, ", "))
+	}
+	if err := cli.Execute("git", []string{"-C", plan.Path, "add", "--all", "--", "."}, "Adding the upgrade"); err != nil {
+		return err
+	}
+	if err := cli.Execute("git", []string{"-C", plan.Path, "commit", "--message", title}, "Committing the upgrade"); err != nil {
+		return err
+	}
+	return cli.Execute("git", []string{"-C", plan.Path, "push", "--set-upstream", "origin", branch}, "Pushing the upgrade's branch")
+}
+
+// getGitHubRepository returns the owner and name of the GitHub repository
+// that the project's repository (its origin) is a clone of
+func getGitHubRepository(projectPath string) (string, string, error) {
+	output, err := cli.ExecuteSilently("git", []string{"-C", projectPath, "remote", "get-url", "origin"})
+	if err != nil {
+		return "", "", fmt.Errorf("%s is not in a git repository with an origin", projectPath)
+	}
+	remote := strings.TrimSpace(string(output))
+	match := gitHubRemotePattern.FindStringSubmatch(remote)
+	if match == nil && os.Getenv(gitHubAPIVariable) == "" {
+		return "", "", fmt.Errorf("opening pull requests is only supported on GitHub (the origin is %s)", remote)
+	}
+	if match == nil {
+		// GitHub Enterprise servers have their own hostname
+		parts := strings.Split(strings.TrimSuffix(strings.TrimSuffix(remote, "/"), ".git"), "/")
+		if len(parts) < 2 {
+			return "", "", fmt.Errorf("cannot read the repository of the origin: %s", remote)
+		}
+		owner := parts[len(parts)-2]
+		if i := strings.LastIndex(owner, ":"); i != -1 {
+			owner = owner[i+1:]
+		}
+		return owner, parts[len(parts)-1], nil
+	}
+	return match[1], match[2], nil
+}
+
+type pullRequest struct {
+	Title string `json:"title"`
+	Head  string `json:"head"`
+	Base  string `json:"base"`
+	Body  string `json:"body"`
+}
+
+// createPullRequest opens a pull request with GitHub's API (or the API of
+// a GitHub Enterprise server, in GITHUB_API_URL), and returns its URL
+func createPullRequest(token, owner, repository string, request *pullRequest) (string, error) {
+	if cli.ReadOnly {
+		return "", fmt.Errorf("%w: refusing to open a pull request on %s/%s", cli.ErrReadOnly, owner, repository)
+	}
+	api := os.Getenv(gitHubAPIVariable)
+	if api == "" {
+		api = defaultGitHubAPI
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls", strings.TrimSuffix(api, "/"), owner, repository)
+	httpRequest, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	httpRequest.Header.Set("Accept", "application/vnd.github+json")
+	httpRequest.Header.Set("Authorization", "Bearer "+token)
+	httpRequest.Header.Set("Content-Type", "application/json")
+	client := &http.Client{Timeout: gitHubRequestTimeout}
+	response, err := client.Do(httpRequest)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", err
+	}
+	created := struct {
+		URL     string `json:"html_url"`
+		Message string `json:"message"`
+	}{}
+	json.Unmarshal(data, &created)
+	if response.StatusCode != http.StatusCreated {
+		if created.Message != "" {
+			return "", fmt.Errorf("GitHub did not open the pull request: %s (%s)", created.Message, response.Status)
+		}
+		return "", fmt.Errorf("GitHub did not open the pull request: %s", response.Status)
+	}
+	if created.URL == "" {
+		return "", errors.New("GitHub did not return the pull request's URL")
+	}
+	return created.URL, nil
+}
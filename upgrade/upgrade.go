@@ -0,0 +1,422 @@
+// Package upgrade upgrades projects to the latest version of the template that
+// they were created from: the changes between the template's version that a
+// project was created from and its latest version are rendered with the
+// project's values, and applied to the project as a patch
+package upgrade
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/config"
+	"github.com/operatorai/kettle-cli/pkg/scaffold"
+	"github.com/operatorai/kettle-cli/tempdir"
+	"github.com/operatorai/kettle-cli/templates"
+)
+
+const (
+	StatusUpToDate   = "up to date"
+	StatusUpgradable = "upgradable"
+	StatusNotPinned  = "not pinned"
+	StatusFailed     = "failed"
+
+	configFileName = "kettle.json"
+)
+
+// Plan is the upgrade of a project to the latest version of its template
+type Plan struct {
+	Path     string
+	Config   *config.Config
+	Status   string
+	Template string
+	// The version that the project was created from, and the latest version
+	Pinned string
+	Latest string
+	// The changes to the project (a patch, for git apply), and the files they change
+	Patch []byte
+	Files []string
+	// The latest version's changelog, and its entries that the project does not have
+	Changelog []*config.TemplateChange
+	Changes   []*config.TemplateChange
+	// The files that were left with conflicts (between conflict markers) by Apply
+	Conflicts []string
+	Err       error
+	// The pinned version, rendered with the project's values (for merging the changes)
+	base string
+}
+
+// Upgrader plans the upgrades of projects; each template (and each of its
+// versions) is only fetched once, as many projects can share a template
+type Upgrader struct {
+	latest    map[string]*fetchedTemplate
+	versions  map[string]string
+	temporary []string
+}
+
+type fetchedTemplate struct {
+	path      string
+	root      string
+	version   string
+	changelog []*config.TemplateChange
+}
+
+func NewUpgrader() *Upgrader {
+	return &Upgrader{
+		latest:   map[string]*fetchedTemplate{},
+		versions: map[string]string{},
+	}
+}
+
+// Close removes the templates that were fetched
+func (u *Upgrader) Close() {
+	for _, directory := range u.temporary {
+		tempdir.Remove(directory)
+	}
+}
+
+// FindProjects returns the projects in a directory: the functions of its
+// workspace, if it has one, or else the directories (in it, and below it)
+// that have a kettle.json
+func FindProjects(directory string) ([]string, error) {
+	workspace, err := config.ReadWorkspace(directory)
+	if err != nil {
+		return nil, err
+	}
+	projects := []string{}
+	if len(workspace.Functions) > 0 {
+		for _, function := range workspace.Functions {
+			projects = append(projects, path.Join(directory, function.Path))
+		}
+		return projects, nil
+	}
+	err = filepath.Walk(directory, func(filePath string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		name := info.Name()
+		if filePath != directory && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor") {
+			return filepath.SkipDir
+		}
+		if exists, err := config.HasConfigFile(filePath); err != nil || !exists {
+			return err
+		}
+		projects = append(projects, filePath)
+		// Projects are not searched for other projects (e.g. in their templates)
+		return filepath.SkipDir
+	})
+	return projects, err
+}
+
+// Plan renders the versions of the project's template that it was created from,
+// and the latest version, with the project's values, and returns the changes
+// between them; the project's kettle.json is not changed by upgrades
+func (u *Upgrader) Plan(projectPath string) *Plan {
+	plan := &Plan{Path: projectPath}
+	cfg, err := config.ReadConfig(projectPath)
+	if err != nil {
+		return plan.fail(err)
+	}
+	plan.Config = cfg
+	if cfg.Source == nil || cfg.Source.Version == "" {
+		plan.Status = StatusNotPinned
+		return plan
+	}
+	plan.Template = cfg.Source.Template
+	plan.Pinned = cfg.Source.Version
+
+	latest, err := u.fetchLatest(cfg.Source.Template)
+	if err != nil {
+		return plan.fail(err)
+	}
+	plan.Latest = latest.version
+	if latest.version == "" {
+		return plan.fail(fmt.Errorf("the template is no longer in a git repository: %s", cfg.Source.Template))
+	}
+	plan.Changelog = latest.changelog
+	plan.Changes = cfg.GetNewChanges(latest.changelog)
+	if latest.version == plan.Pinned {
+		plan.Status = StatusUpToDate
+		return plan
+	}
+	pinnedPath, err := u.fetchVersion(latest, plan.Pinned)
+	if err != nil {
+		return plan.fail(err)
+	}
+
+	directory, err := tempdir.Create("-upgrade-")
+	if err != nil {
+		return plan.fail(err)
+	}
+	// The rendered versions are kept until the upgrader is closed, as
+	// applying the upgrade merges the changes into the pinned version
+	u.temporary = append(u.temporary, directory)
+	for name, templatePath := range map[string]string{"pinned": pinnedPath, "latest": latest.path} {
+		if err := renderVersion(templatePath, cfg, path.Join(directory, name)); err != nil {
+			return plan.fail(fmt.Errorf("cannot render the %s version of the template: %w", name, err))
+		}
+	}
+	plan.Patch, err = diff(directory, "pinned", "latest")
+	if err != nil {
+		return plan.fail(err)
+	}
+	plan.base = path.Join(directory, "pinned")
+	// If the template changed, but not the files that it creates for the project,
+	// the upgrade only pins the project to the latest version
+	plan.Files = getPatchedFiles(plan.Patch)
+	plan.Status = StatusUpgradable
+	return plan
+}
+
+func (plan *Plan) fail(err error) *Plan {
+	plan.Status = StatusFailed
+	plan.Err = err
+	return plan
+}
+
+// Apply applies the upgrade's changes to the project, and pins the project to
+// the latest version of its template (and its changelog); changes that conflict
+// with the project's (e.g. because it changed the same lines) are resolved one
+// by one, if the project is in a git repository, or else nothing is changed
+func (plan *Plan) Apply() error {
+	if plan.Status != StatusUpgradable {
+		return fmt.Errorf("%s is %s", plan.Path, plan.Status)
+	}
+	if len(plan.Patch) > 0 {
+		if err := plan.applyPatch(); err != nil {
+			return err
+		}
+	}
+
+	// The project's config is read again, so that its overlays are not written to it
+	cfg, err := config.ReadConfig(plan.Path)
+	if err != nil {
+		return err
+	}
+	cfg.Source.Version = plan.Latest
+	cfg.Changelog = plan.Changelog
+	return config.WriteConfig(plan.Path, cfg)
+}
+
+// applyPatch applies the upgrade's changes to the project's files
+func (plan *Plan) applyPatch() error {
+	patchFile, err := ioutil.TempFile("", "kettle-upgrade-*.patch")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(patchFile.Name())
+	_, err = patchFile.Write(plan.Patch)
+	if closeErr := patchFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	// Inside a repository, git apply's paths are relative to the repository's root
+	args := []string{"-C", plan.Path, "apply", "-p2"}
+	prefix, repositoryErr := cli.ExecuteSilently("git", []string{"-C", plan.Path, "rev-parse", "--show-prefix"})
+	if repositoryErr == nil {
+		if directory := strings.TrimSpace(string(prefix)); directory != "" {
+			args = append(args, "--directory="+directory)
+		}
+	}
+	if _, err := cli.ExecuteSilently("git", append(append([]string{}, args...), "--check", patchFile.Name())); err != nil {
+		if repositoryErr != nil {
+			return fmt.Errorf("the template's changes do not apply cleanly to %s, which is not in a git repository to merge them in (see them with --diff, and upgrade it by hand): %w", plan.Path, err)
+		}
+		return plan.mergePatch(args, patchFile.Name())
+	}
+	return cli.Execute("git", append(args, patchFile.Name()), fmt.Sprintf("Upgrading %s", plan.Path))
+}
+
+// fetchLatest fetches the latest version of a template
+func (u *Upgrader) fetchLatest(template string) (*fetchedTemplate, error) {
+	if fetched, ok := u.latest[template]; ok {
+		return fetched, nil
+	}
+	templatePath, isTempDir, err := templates.GetTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+	fetched := &fetchedTemplate{path: templatePath}
+	if output, err := cli.ExecuteSilently("git", []string{"-C", templatePath, "rev-parse", "--show-toplevel"}); err == nil {
+		fetched.root = strings.TrimSpace(string(output))
+	}
+	if isTempDir {
+		// Removing the template removes the temporary directory that it is in
+		// (e.g. the clone of kettle-templates)
+		u.temporary = append(u.temporary, templatePath)
+	}
+	fetched.version = templates.GetSource(template, templatePath).Version
+	templateConfig, err := config.ReadConfig(templatePath)
+	if err != nil {
+		return nil, err
+	}
+	fetched.changelog = templateConfig.Changelog
+	u.latest[template] = fetched
+	return fetched, nil
+}
+
+// fetchVersion exports a version of a template from its repository (fetching
+// the version if it is not in a shallow clone), and returns its path
+func (u *Upgrader) fetchVersion(latest *fetchedTemplate, version string) (string, error) {
+	key := latest.path + "@" + version
+	if versionPath, ok := u.versions[key]; ok {
+		return versionPath, nil
+	}
+	absoluteRoot, err := filepath.EvalSymlinks(latest.root)
+	if err != nil {
+		return "", err
+	}
+	absolutePath, err := filepath.Abs(latest.path)
+	if err != nil {
+		return "", err
+	}
+	absolutePath, err = filepath.EvalSymlinks(absolutePath)
+	if err != nil {
+		return "", err
+	}
+	subdirectory, err := filepath.Rel(absoluteRoot, absolutePath)
+	if err != nil {
+		return "", err
+	}
+	archiveArgs := []string{"-C", latest.root, "archive", "--format=tar", version, "--", filepath.ToSlash(subdirectory)}
+	archive, err := cli.ExecuteSilently("git", archiveArgs)
+	if err != nil {
+		err := cli.Execute("git", []string{"-C", latest.root, "fetch", "--depth", "1", "origin", version},
+			fmt.Sprintf("Fetching version %s of the template", ShortVersion(version)))
+		if err != nil {
+			return "", fmt.Errorf("cannot fetch version %s of the template: %w", version, err)
+		}
+		if archive, err = cli.ExecuteSilently("git", archiveArgs); err != nil {
+			return "", err
+		}
+	}
+
+	directory, err := tempdir.Create("-template-")
+	if err != nil {
+		return "", err
+	}
+	u.temporary = append(u.temporary, directory)
+	if err := extractArchive(archive, directory); err != nil {
+		return "", err
+	}
+	versionPath := filepath.Join(directory, subdirectory)
+	u.versions[key] = versionPath
+	return versionPath, nil
+}
+
+// renderVersion renders a version of the template with the project's
+// values, without its config (which upgrades do not change)
+func renderVersion(templatePath string, cfg *config.Config, dest string) error {
+	templateConfig, err := config.ReadConfig(templatePath)
+	if err != nil {
+		return err
+	}
+	values := map[string]string{}
+	for _, prompt := range cfg.Template {
+		values[prompt.Key] = prompt.Value
+	}
+	_, err = scaffold.Render(templatePath, templateConfig, values, dest, &scaffold.Options{
+		ProjectName:    cfg.ProjectName,
+		SkipValidation: true,
+	})
+	if err != nil {
+		return err
+	}
+	return os.Remove(path.Join(dest, configFileName))
+}
+
+// diff returns the changes between two directories, as a patch whose
+// paths start with a/<from>/ and b/<to>/ (so it is applied with -p2), and
+// which records the full IDs of the files that it changes (for git apply --3way)
+func diff(directory, from, to string) ([]byte, error) {
+	osCmd := exec.Command("git", "-C", directory, "diff", "--no-index", "--binary", "--full-index", "--src-prefix=a/", "--dst-prefix=b/", from, to)
+	var stderr bytes.Buffer
+	osCmd.Stderr = &stderr
+	output, err := osCmd.Output()
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) && exitError.ExitCode() == 1 {
+		// git diff exits with 1 if there are differences
+		return output, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot compare the template's versions: %s", strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
+
+// getPatchedFiles returns the files (in the project) that a patch changes
+func getPatchedFiles(patch []byte) []string {
+	files := []string{}
+	for _, line := range strings.Split(string(patch), "\n") {
+		if !strings.HasPrefix(line, "diff --git ") {
+			continue
+		}
+		// diff --git a/pinned/<file> b/latest/<file> (files that were added or
+		// removed have the same directory on both sides)
+		fields := strings.Fields(line)
+		parts := strings.SplitN(fields[len(fields)-1], "/", 3)
+		if len(parts) == 3 {
+			files = append(files, parts[2])
+		}
+	}
+	return files
+}
+
+// extractArchive extracts the files of a tar archive (from git archive)
+func extractArchive(archive []byte, directory string) error {
+	reader := tar.NewReader(bytes.NewReader(archive))
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(directory, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(target, filepath.Clean(directory)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path in the template's archive: %s", header.Name)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			data, err := ioutil.ReadAll(reader)
+			if err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(target, data, os.FileMode(header.Mode)&0777); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ShortVersion abbreviates a version of a template (a commit)
+func ShortVersion(version string) string {
+	if len(version) > 7 {
+		return version[:7]
+	}
+	return version
+}
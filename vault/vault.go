@@ -0,0 +1,168 @@
+// Package vault reads secrets from HashiCorp Vault with the vault cli, e.g. the
+// values of templates' prompts, and the secrets that are synced to a
+// cloud's secret store when a project is deployed
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/operatorai/kettle-cli/cli"
+	"github.com/operatorai/kettle-cli/settings"
+)
+
+const (
+	AuthToken   = "token"
+	AuthAppRole = "approle"
+	AuthOIDC    = "oidc"
+
+	defaultSecretIDEnv = "VAULT_SECRET_ID"
+)
+
+// loggedIn is set once kettle has a valid token, so that it only logs in once
+var loggedIn bool
+
+// Login sets the Vault server (and namespace) of the vault cli, and logs in
+// with the auth method in the settings, unless the cli already has a valid token
+func Login(stg *settings.VaultSettings) error {
+	if loggedIn {
+		return nil
+	}
+	if stg == nil {
+		stg = &settings.VaultSettings{}
+	}
+	// The vault cli reads its server from the environment, which commands inherit
+	if stg.Address != "" {
+		os.Setenv("VAULT_ADDR", stg.Address)
+	}
+	if stg.Namespace != "" {
+		os.Setenv("VAULT_NAMESPACE", stg.Namespace)
+	}
+	if _, err := cli.ExecuteSilently("vault", []string{"token", "lookup", "-format=json"}); err == nil {
+		loggedIn = true
+		return nil
+	}
+
+	mount := stg.Mount
+	switch stg.Auth {
+	case "", AuthToken:
+		return errors.New("no valid Vault token: set VAULT_TOKEN, or log in with: vault login")
+	case AuthAppRole:
+		if mount == "" {
+			mount = AuthAppRole
+		}
+		secretIDEnv := stg.SecretIDEnv
+		if secretIDEnv == "" {
+			secretIDEnv = defaultSecretIDEnv
+		}
+		secretID := os.Getenv(secretIDEnv)
+		if stg.Role == "" || secretID == "" {
+			return fmt.Errorf("logging in to Vault with an AppRole needs a role (its role ID) in ~/.kettle.yaml, and its secret ID in %s", secretIDEnv)
+		}
+		// The secret ID is read from stdin, so that it is not a command line argument
+		output, err := cli.ExecuteWithInput("vault", []string{
+			"write",
+			"-field=token",
+			fmt.Sprintf("auth/%s/login", mount),
+			fmt.Sprintf("role_id=%s", stg.Role),
+			"secret_id=-",
+		}, []byte(secretID), "Logging in to Vault")
+		if err != nil {
+			return err
+		}
+		os.Setenv("VAULT_TOKEN", strings.TrimSpace(string(output)))
+	case AuthOIDC:
+		if cli.NonInteractive {
+			return errors.New("logging in to Vault with OIDC opens a browser, so it cannot be used in non-interactive mode (use approle, or set VAULT_TOKEN)")
+		}
+		if mount == "" {
+			mount = AuthOIDC
+		}
+		args := []string{
+			"login",
+			"-method=oidc",
+			fmt.Sprintf("-path=%s", mount),
+		}
+		if stg.Role != "" {
+			args = append(args, fmt.Sprintf("role=%s", stg.Role))
+		}
+		// The token is stored by the cli's token helper (e.g. in ~/.vault-token)
+		exitCode, err := cli.ExecuteInteractively("vault", args, nil)
+		if err != nil {
+			return err
+		}
+		if exitCode != 0 {
+			return fmt.Errorf("logging in to Vault failed (exit code %d)", exitCode)
+		}
+	default:
+		return fmt.Errorf("unknown Vault auth method: %s (expected token, approle, or oidc)", stg.Auth)
+	}
+	loggedIn = true
+	return nil
+}
+
+// ReadData returns the data of a secret in a KV secrets engine (version 1 or 2)
+func ReadData(stg *settings.VaultSettings, path string) (map[string]interface{}, error) {
+	if err := Login(stg); err != nil {
+		return nil, err
+	}
+	output, err := cli.ExecuteWithResult("vault", []string{
+		"kv",
+		"get",
+		"-format=json",
+		path,
+	}, fmt.Sprintf("Reading the Vault secret: %s", path))
+	if err != nil {
+		return nil, err
+	}
+	secret := struct {
+		Data map[string]interface{} `json:"data"`
+	}{}
+	if err := json.Unmarshal(output, &secret); err != nil {
+		return nil, err
+	}
+	// Version 2 engines have the secret's data (and its metadata) in data
+	if data, ok := secret.Data["data"].(map[string]interface{}); ok {
+		if _, ok := secret.Data["metadata"]; ok {
+			return data, nil
+		}
+	}
+	return secret.Data, nil
+}
+
+// Read returns the value of a field of a secret, by a reference to
+// it: the secret's path and the field, e.g. secret/users#db_password
+func Read(stg *settings.VaultSettings, reference string) (string, error) {
+	path, field, err := ParseReference(reference)
+	if err != nil {
+		return "", err
+	}
+	data, err := ReadData(stg, path)
+	if err != nil {
+		return "", err
+	}
+	value, ok := data[field]
+	if !ok || value == nil {
+		return "", fmt.Errorf("the Vault secret %s has no field: %s", path, field)
+	}
+	if text, ok := value.(string); ok {
+		return text, nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// ParseReference returns the path and field of a reference to a secret's field
+func ParseReference(reference string) (string, string, error) {
+	parts := strings.SplitN(reference, "#", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid Vault secret: %s (expected <path>#<field>)", reference)
+	}
+	return parts[0], parts[1], nil
+}